@@ -5,4 +5,8 @@ type Config struct {
 	ProfileFilePath string `mapstructure:"PROFILE_FILE_PATH"`
 	ResyncFilePath  string `mapstructure:"RESYNC_FILE_PATH"`
 	RcloneFilePath  string `mapstructure:"RCLONE_FILE_PATH"`
+
+	// Log size caps, mostly raised for debugging sessions (0 = default)
+	MaxLogMessagesPerStatus int `mapstructure:"MAX_LOG_MESSAGES_PER_STATUS"`
+	LogStreamBufferSize     int `mapstructure:"LOG_STREAM_BUFFER_SIZE"`
 }
@@ -0,0 +1,146 @@
+package delta
+
+import (
+	"strings"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// coalesceChange merges a new change into the buffer instead of appending it
+// verbatim, keeping changesets small:
+//   - repeated events for the same path collapse into one entry
+//   - a delete of an object created within the buffer window drops both
+//   - a delete and an appearance with the same object ID become a rename
+//   - changes inside a changed directory are covered by the directory's
+//     scope rule and are dropped
+func coalesceChange(changes []FileChange, c FileChange) []FileChange {
+	// Already covered by a changed ancestor directory
+	if coveredByDirectory(changes, c) {
+		return changes
+	}
+
+	idx := indexOfPath(changes, c.Path)
+	if idx < 0 {
+		if c.EntryType == fs.EntryDirectory && c.Type != ChangeDeleted {
+			changes = dropDescendants(changes, c.Path)
+		}
+		changes = append(changes, c)
+		return matchRename(changes, len(changes)-1)
+	}
+
+	e := changes[idx]
+	if c.Type == ChangeDeleted {
+		switch {
+		case e.Created:
+			// Created and deleted before the next sync: nothing to do
+			return removeAt(changes, idx)
+		case e.Type == ChangeRenamed:
+			// Renamed then deleted: only the original path needs deleting
+			e.Path = e.OldPath
+			e.OldPath = ""
+		}
+		e.Type = ChangeDeleted
+	} else if e.Type != ChangeRenamed {
+		// Renamed entries keep their type; a later edit is covered by the new path.
+		// A path that was deleted existed before the window, so a re-create
+		// must not be collapsed away by a later delete.
+		e.Created = (e.Created || c.Created) && e.Type != ChangeDeleted
+		e.Type = ChangeModified
+	}
+
+	e.EntryType = c.EntryType
+	e.DetectedAt = c.DetectedAt
+	if c.ObjectID != "" {
+		e.ObjectID = c.ObjectID
+	}
+	changes[idx] = e
+
+	if e.EntryType == fs.EntryDirectory && e.Type != ChangeDeleted {
+		changes = dropDescendants(changes, e.Path)
+		idx = indexOfPath(changes, e.Path)
+	}
+	return matchRename(changes, idx)
+}
+
+// matchRename pairs the entry at idx with a counterpart carrying the same
+// object ID: a deleted entry and a live entry at another path become a single
+// ChangeRenamed at the live path.
+func matchRename(changes []FileChange, idx int) []FileChange {
+	e := changes[idx]
+	if e.ObjectID == "" {
+		return changes
+	}
+
+	for j, other := range changes {
+		if j == idx || other.ObjectID != e.ObjectID || other.Path == e.Path {
+			continue
+		}
+
+		deleted, live := j, idx
+		if e.Type == ChangeDeleted {
+			deleted, live = idx, j
+		}
+		if changes[deleted].Type != ChangeDeleted || changes[live].Type == ChangeDeleted {
+			continue
+		}
+
+		renamed := changes[live]
+		if renamed.Type != ChangeRenamed {
+			renamed.OldPath = changes[deleted].Path
+		}
+		renamed.Type = ChangeRenamed
+		renamed.Created = false
+		changes[live] = renamed
+		return removeAt(changes, deleted)
+	}
+	return changes
+}
+
+// coveredByDirectory reports whether a live changed directory in the buffer
+// already scopes the change (and its old path, for renames).
+func coveredByDirectory(changes []FileChange, c FileChange) bool {
+	for _, e := range changes {
+		if e.EntryType != fs.EntryDirectory || e.Type == ChangeDeleted {
+			continue
+		}
+		if isDescendant(c.Path, e.Path) && (c.OldPath == "" || isDescendant(c.OldPath, e.Path)) {
+			return true
+		}
+	}
+	return false
+}
+
+// dropDescendants removes entries inside dir, which the directory's scope rule covers.
+func dropDescendants(changes []FileChange, dir string) []FileChange {
+	kept := changes[:0]
+	for _, e := range changes {
+		if isDescendant(e.Path, dir) && (e.OldPath == "" || isDescendant(e.OldPath, dir)) {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	return kept
+}
+
+// isDescendant reports whether p is strictly inside dir.
+func isDescendant(p, dir string) bool {
+	dir = strings.Trim(dir, "/")
+	p = strings.Trim(p, "/")
+	if dir == "" {
+		return p != ""
+	}
+	return strings.HasPrefix(p, dir+"/")
+}
+
+func indexOfPath(changes []FileChange, p string) int {
+	for i, e := range changes {
+		if e.Path == p {
+			return i
+		}
+	}
+	return -1
+}
+
+func removeAt(changes []FileChange, i int) []FileChange {
+	return append(changes[:i], changes[i+1:]...)
+}
@@ -0,0 +1,192 @@
+package delta
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+)
+
+func file(p string, t ChangeType, id string) FileChange {
+	return FileChange{Path: p, EntryType: fs.EntryObject, Type: t, ObjectID: id, DetectedAt: time.Now()}
+}
+
+func coalesceAll(changes ...FileChange) []FileChange {
+	var buf []FileChange
+	for _, c := range changes {
+		buf = coalesceChange(buf, c)
+	}
+	return buf
+}
+
+func TestCoalesceDedupesSamePath(t *testing.T) {
+	buf := coalesceAll(
+		file("a.txt", ChangeModified, ""),
+		file("a.txt", ChangeModified, ""),
+		file("b.txt", ChangeModified, ""),
+		file("a.txt", ChangeModified, ""),
+	)
+	if len(buf) != 2 || buf[0].Path != "a.txt" || buf[1].Path != "b.txt" {
+		t.Errorf("expected 2 deduplicated changes, got %+v", buf)
+	}
+}
+
+func TestCoalesceCreateDeletePair(t *testing.T) {
+	created := file("tmp.txt", ChangeModified, "id1")
+	created.Created = true
+	buf := coalesceAll(created, file("tmp.txt", ChangeDeleted, ""))
+	if len(buf) != 0 {
+		t.Errorf("expected create+delete to collapse, got %+v", buf)
+	}
+
+	// A pre-existing file that is modified then deleted must keep its delete
+	buf = coalesceAll(file("old.txt", ChangeModified, "id2"), file("old.txt", ChangeDeleted, ""))
+	if len(buf) != 1 || buf[0].Type != ChangeDeleted {
+		t.Errorf("expected a single delete, got %+v", buf)
+	}
+
+	// Deleted then re-created: the original existed, so a later delete stays
+	recreated := file("old.txt", ChangeModified, "id3")
+	recreated.Created = true
+	buf = coalesceAll(file("old.txt", ChangeDeleted, ""), recreated, file("old.txt", ChangeDeleted, ""))
+	if len(buf) != 1 || buf[0].Type != ChangeDeleted {
+		t.Errorf("expected the delete of a pre-existing path to be kept, got %+v", buf)
+	}
+}
+
+func TestCoalesceRename(t *testing.T) {
+	// Old path seen first, then deleted, then the object shows up elsewhere
+	buf := coalesceAll(
+		file("docs/a.txt", ChangeModified, "id1"),
+		file("docs/a.txt", ChangeDeleted, ""),
+		file("docs/b.txt", ChangeModified, "id1"),
+	)
+	if len(buf) != 1 || buf[0].Type != ChangeRenamed || buf[0].Path != "docs/b.txt" || buf[0].OldPath != "docs/a.txt" {
+		t.Fatalf("expected a single rename, got %+v", buf)
+	}
+
+	// Reverse order: the new path is reported before the old one is deleted
+	buf = coalesceAll(
+		file("docs/a.txt", ChangeModified, "id1"),
+		file("docs/b.txt", ChangeModified, "id1"),
+		file("docs/a.txt", ChangeDeleted, ""),
+	)
+	if len(buf) != 1 || buf[0].Type != ChangeRenamed || buf[0].OldPath != "docs/a.txt" {
+		t.Fatalf("expected a single rename, got %+v", buf)
+	}
+
+	// Renamed then deleted: only the original path needs deleting
+	buf = coalesceChange(buf, file("docs/b.txt", ChangeDeleted, ""))
+	if len(buf) != 1 || buf[0].Type != ChangeDeleted || buf[0].Path != "docs/a.txt" {
+		t.Errorf("expected a delete of the original path, got %+v", buf)
+	}
+
+	// Without IDs nothing is paired
+	buf = coalesceAll(file("x.txt", ChangeDeleted, ""), file("y.txt", ChangeModified, ""))
+	if len(buf) != 2 {
+		t.Errorf("expected changes without IDs to stay separate, got %+v", buf)
+	}
+}
+
+func TestCoalesceDirectoryCoversDescendants(t *testing.T) {
+	dir := FileChange{Path: "photos", EntryType: fs.EntryDirectory, Type: ChangeModified}
+	buf := coalesceAll(
+		file("photos/1.jpg", ChangeModified, ""),
+		file("notes.txt", ChangeModified, ""),
+		dir,
+		file("photos/2024/2.jpg", ChangeModified, ""),
+	)
+	if len(buf) != 2 || buf[0].Path != "notes.txt" || buf[1].Path != "photos" {
+		t.Errorf("expected descendants to be folded into the directory, got %+v", buf)
+	}
+
+	// A rename out of the directory still needs its own entry
+	moved := FileChange{Path: "photos/3.jpg", OldPath: "inbox/3.jpg", EntryType: fs.EntryObject, Type: ChangeRenamed}
+	buf = coalesceChange(buf, moved)
+	if len(buf) != 3 {
+		t.Errorf("expected a rename from outside the directory to be kept, got %+v", buf)
+	}
+}
+
+func TestNotifyCallbackCoalesces(t *testing.T) {
+	w := NewWatcher("gdrive:", nil)
+	w.ctx = context.Background()
+	w.since = time.Now()
+
+	existing := map[string]entryInfo{
+		"a.txt":   {known: true, exists: true, id: "id1"},
+		"new.txt": {known: true, exists: true, id: "id2", created: time.Now().Add(time.Second)},
+	}
+	w.resolve = func(ctx context.Context, p string, entryType fs.EntryType) entryInfo {
+		if info, ok := existing[p]; ok {
+			return info
+		}
+		return entryInfo{known: true}
+	}
+
+	w.notifyCallback("a.txt", fs.EntryObject)
+	w.notifyCallback("new.txt", fs.EntryObject)
+	// a.txt renamed to b.txt; new.txt created and removed
+	existing["b.txt"] = existing["a.txt"]
+	delete(existing, "a.txt")
+	delete(existing, "new.txt")
+	w.notifyCallback("a.txt", fs.EntryObject)
+	w.notifyCallback("b.txt", fs.EntryObject)
+	w.notifyCallback("new.txt", fs.EntryObject)
+
+	changes := w.DrainChanges()
+	if len(changes) != 1 || changes[0].Type != ChangeRenamed || changes[0].Path != "b.txt" || changes[0].OldPath != "a.txt" {
+		t.Errorf("expected a single rename after coalescing, got %+v", changes)
+	}
+}
+
+func TestNotifyCallbackReportsChanges(t *testing.T) {
+	w := NewWatcher("gdrive:/data", nil)
+	w.ctx = context.Background()
+	w.since = time.Now()
+	w.resolve = func(ctx context.Context, p string, entryType fs.EntryType) entryInfo {
+		return entryInfo{known: true}
+	}
+
+	var keys []string
+	var seen []FileChange
+	w.onChange = func(remoteKey string, change FileChange) {
+		keys = append(keys, remoteKey)
+		seen = append(seen, change)
+	}
+
+	w.notifyCallback("gone.txt", fs.EntryObject)
+
+	if len(seen) != 1 || keys[0] != "gdrive:/data" || seen[0].Path != "gone.txt" || seen[0].Type != ChangeDeleted {
+		t.Errorf("expected the deletion to be reported, got %v %+v", keys, seen)
+	}
+}
+
+func TestNotifyCallbackFiltersDocuments(t *testing.T) {
+	w := NewWatcher("gdrive:", nil)
+	w.ctx = context.Background()
+	w.since = time.Now()
+
+	docs := map[string]entryInfo{
+		// Opened or shared since the last sync
+		"Budget": {known: true, exists: true, document: true, path: "Budget.xlsx", modTime: w.since.Add(-time.Hour)},
+		// Edited since the last sync
+		"notes/Report": {known: true, exists: true, document: true, path: "notes/Report.docx", modTime: w.since.Add(time.Second)},
+	}
+	w.resolve = func(ctx context.Context, p string, entryType fs.EntryType) entryInfo {
+		return docs[p]
+	}
+
+	w.notifyCallback("Budget", fs.EntryObject)
+	w.notifyCallback("notes/Report", fs.EntryObject)
+	w.notifyCallback("notes/Report", fs.EntryObject)
+
+	changes := w.DrainChanges()
+	if len(changes) != 1 || changes[0].Path != "notes/Report.docx" || changes[0].Type != ChangeModified {
+		t.Errorf("expected only the edited document, at its export path, got %+v", changes)
+	}
+	if status := w.Status(); status.Ignored != 1 {
+		t.Errorf("expected 1 ignored change, got %d", status.Ignored)
+	}
+}
@@ -0,0 +1,43 @@
+package delta
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// driveDocument looks up a change Google Drive reported for a path that
+// isn't listed. Drive reports Google-native documents (Docs, Sheets,
+// Slides...) under their name, e.g. "Report", while rclone lists them under
+// the name of the format they're exported in, e.g. "Report.docx", with an
+// unknown size. Returns false if the remote isn't a Google Drive or the
+// path isn't a document.
+func driveDocument(ctx context.Context, f fs.Fs, p string) (entryInfo, bool) {
+	if info := fs.FindFromFs(f); info == nil || info.Name != "drive" {
+		return entryInfo{}, false
+	}
+
+	dir, leaf := path.Split(p)
+	entries, err := f.List(ctx, strings.TrimSuffix(dir, "/"))
+	if err != nil {
+		return entryInfo{}, false
+	}
+	for _, entry := range entries {
+		obj, ok := entry.(fs.Object)
+		if !ok || obj.Size() >= 0 {
+			continue
+		}
+		name := path.Base(obj.Remote())
+		if ext := path.Ext(name); ext == "" || strings.TrimSuffix(name, ext) != leaf {
+			continue
+		}
+		info := entryInfo{known: true, exists: true, document: true, path: obj.Remote(), modTime: obj.ModTime(ctx)}
+		if idr, ok := obj.(fs.IDer); ok {
+			info.id = idr.ID()
+		}
+		return info, true
+	}
+	return entryInfo{}, false
+}
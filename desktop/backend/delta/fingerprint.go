@@ -0,0 +1,131 @@
+package delta
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+
+	"github.com/rclone/rclone/fs"
+)
+
+const (
+	// bloomBits is the size of each directory's entry digest.
+	bloomBits = 256
+	// bloomHashes is the number of bits set per entry.
+	bloomHashes = 3
+)
+
+// DirFingerprint summarises one directory listing: entry count, latest
+// modification time, and a Bloom filter over (name, size, modtime) of the
+// entries, which catches replaced or renamed entries that keep the count
+// and latest modtime unchanged.
+type DirFingerprint struct {
+	Count  int    `json:"n"`
+	Latest int64  `json:"t"` // unix nanoseconds
+	Bloom  string `json:"b"` // hex-encoded bloomBits-bit filter
+}
+
+// Fingerprint is a compact summary of a remote's root and top-level
+// directory listings, keyed by directory ("" is the root). It is used as a
+// quick change check for backends without ChangeNotify: changes deeper than
+// the top-level directories only show up through directory modtimes, so the
+// periodic full sync remains the safety net.
+type Fingerprint map[string]DirFingerprint
+
+// ComputeFingerprint lists the remote's root and each top-level directory.
+func ComputeFingerprint(ctx context.Context, f fs.Fs) (Fingerprint, error) {
+	root, err := f.List(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list root: %w", err)
+	}
+
+	fp := Fingerprint{"": digestEntries(ctx, root)}
+	for _, entry := range root {
+		dir, ok := entry.(fs.Directory)
+		if !ok {
+			continue
+		}
+		entries, err := f.List(ctx, dir.Remote())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", dir.Remote(), err)
+		}
+		fp[dir.Remote()] = digestEntries(ctx, entries)
+	}
+	return fp, nil
+}
+
+// digestEntries builds the fingerprint of a single listing.
+func digestEntries(ctx context.Context, entries fs.DirEntries) DirFingerprint {
+	var bloom [bloomBits / 64]uint64
+	var latest int64
+
+	for _, entry := range entries {
+		modTime := entry.ModTime(ctx).UnixNano()
+		if modTime > latest {
+			latest = modTime
+		}
+
+		h := fnv.New64a()
+		h.Write([]byte(entry.Remote()))
+		h.Write([]byte{0})
+		h.Write([]byte(strconv.FormatInt(entry.Size(), 10)))
+		h.Write([]byte{0})
+		h.Write([]byte(strconv.FormatInt(modTime, 10)))
+		sum := h.Sum64()
+
+		// Double hashing: derive bloomHashes positions from two halves of one hash
+		h1, h2 := sum&0xffffffff, sum>>32
+		for i := uint64(0); i < bloomHashes; i++ {
+			bit := (h1 + i*h2) % bloomBits
+			bloom[bit/64] |= 1 << (bit % 64)
+		}
+	}
+
+	buf := make([]byte, 0, bloomBits/8)
+	for _, word := range bloom {
+		buf = binary.BigEndian.AppendUint64(buf, word)
+	}
+	return DirFingerprint{
+		Count:  len(entries),
+		Latest: latest,
+		Bloom:  hex.EncodeToString(buf),
+	}
+}
+
+// Equal reports whether two fingerprints describe the same listings.
+func (fp Fingerprint) Equal(other Fingerprint) bool {
+	if len(fp) != len(other) {
+		return false
+	}
+	for dir, digest := range fp {
+		if o, ok := other[dir]; !ok || o != digest {
+			return false
+		}
+	}
+	return true
+}
+
+// Encode serialises the fingerprint for storage.
+func (fp Fingerprint) Encode() (string, error) {
+	data, err := json.Marshal(fp)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// DecodeFingerprint parses a stored fingerprint. An empty string yields nil.
+func DecodeFingerprint(data string) (Fingerprint, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var fp Fingerprint
+	if err := json.Unmarshal([]byte(data), &fp); err != nil {
+		return nil, err
+	}
+	return fp, nil
+}
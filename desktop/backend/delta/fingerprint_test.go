@@ -0,0 +1,112 @@
+package delta
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/rclone/rclone/backend/local"
+	"github.com/rclone/rclone/fs"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestComputeFingerprint(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "root.txt"), "root")
+	writeFile(t, filepath.Join(dir, "docs", "a.txt"), "a")
+	writeFile(t, filepath.Join(dir, "photos", "img.jpg"), "img")
+
+	f, err := fs.NewFs(ctx, dir)
+	if err != nil {
+		t.Fatalf("failed to open local fs: %v", err)
+	}
+
+	fp, err := ComputeFingerprint(ctx, f)
+	if err != nil {
+		t.Fatalf("ComputeFingerprint failed: %v", err)
+	}
+	if len(fp) != 3 || fp[""].Count != 3 || fp["docs"].Count != 1 {
+		t.Errorf("unexpected fingerprint: %+v", fp)
+	}
+
+	again, _ := ComputeFingerprint(ctx, f)
+	if !fp.Equal(again) {
+		t.Error("expected identical listings to give equal fingerprints")
+	}
+
+	// Round-trips through storage encoding
+	data, err := fp.Encode()
+	if err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	decoded, err := DecodeFingerprint(data)
+	if err != nil || !fp.Equal(decoded) {
+		t.Errorf("expected decoded fingerprint to match, err=%v", err)
+	}
+
+	// A changed size in a top-level directory changes the fingerprint
+	writeFile(t, filepath.Join(dir, "docs", "a.txt"), "changed")
+	changed, _ := ComputeFingerprint(ctx, f)
+	if fp.Equal(changed) {
+		t.Error("expected modified file to change the fingerprint")
+	}
+
+	// So does a new top-level directory
+	writeFile(t, filepath.Join(dir, "music", "song.mp3"), "la")
+	added, _ := ComputeFingerprint(ctx, f)
+	if changed.Equal(added) {
+		t.Error("expected new directory to change the fingerprint")
+	}
+}
+
+func TestQuickCheck(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "docs", "a.txt"), "a")
+
+	f, err := fs.NewFs(ctx, dir)
+	if err != nil {
+		t.Fatalf("failed to open local fs: %v", err)
+	}
+	if SupportsWatching(f) {
+		t.Skip("local backend unexpectedly supports ChangeNotify")
+	}
+
+	store := newTestStore(t)
+	d := NewDeltaService(store)
+	defer d.StopAll()
+	key := "local:" + dir
+
+	if _, reason := d.QuickCheck(ctx, f, key); reason != ReasonNoBaseline {
+		t.Errorf("expected %q before any full sync, got %q", ReasonNoBaseline, reason)
+	}
+
+	if err := d.CommitFullSync(f, key); err != nil {
+		t.Fatalf("CommitFullSync failed: %v", err)
+	}
+	fp, reason := d.QuickCheck(ctx, f, key)
+	if reason != ReasonNoFingerprint {
+		t.Errorf("expected %q, got %q", ReasonNoFingerprint, reason)
+	}
+
+	d.CommitFingerprint(key, fp)
+	if _, reason := d.QuickCheck(ctx, f, key); reason != "" {
+		t.Errorf("expected unchanged remote, got %q", reason)
+	}
+
+	writeFile(t, filepath.Join(dir, "docs", "b.txt"), "b")
+	if _, reason := d.QuickCheck(ctx, f, key); reason != ReasonFingerprintChanged {
+		t.Errorf("expected %q, got %q", ReasonFingerprintChanged, reason)
+	}
+}
@@ -0,0 +1,65 @@
+package delta
+
+import (
+	"sort"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// MaxRecentChanges caps how many recently changed files are remembered per
+// remote; the oldest are forgotten first
+const MaxRecentChanges = 10000
+
+// recordRecent remembers a changed file so RecentChanges can report it after
+// the change was drained by a sync. Deletions and directories are skipped.
+func (d *DeltaService) recordRecent(remoteKey string, change FileChange) {
+	if change.EntryType == fs.EntryDirectory || change.Type == ChangeDeleted || change.Path == "" {
+		return
+	}
+	when := change.DetectedAt
+	if when.IsZero() {
+		when = time.Now()
+	}
+
+	d.recentMu.Lock()
+	defer d.recentMu.Unlock()
+	if d.recent == nil {
+		d.recent = make(map[string]map[string]time.Time)
+	}
+	files := d.recent[remoteKey]
+	if files == nil {
+		files = make(map[string]time.Time)
+		d.recent[remoteKey] = files
+	}
+	if change.OldPath != "" {
+		delete(files, change.OldPath)
+	}
+	files[change.Path] = when
+
+	for len(files) > MaxRecentChanges {
+		oldest, oldestAt := "", time.Time{}
+		for p, at := range files {
+			if oldest == "" || at.Before(oldestAt) {
+				oldest, oldestAt = p, at
+			}
+		}
+		delete(files, oldest)
+	}
+}
+
+// RecentChanges returns the files of a remote that its watcher saw change
+// since the given time, sorted. Only changes seen since the app started are
+// known, up to MaxRecentChanges per remote.
+func (d *DeltaService) RecentChanges(remoteKey string, since time.Time) []string {
+	d.recentMu.Lock()
+	defer d.recentMu.Unlock()
+	var paths []string
+	for p, at := range d.recent[remoteKey] {
+		if !at.Before(since) {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,29 @@
+package delta
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+)
+
+func TestRecentChanges(t *testing.T) {
+	d := &DeltaService{}
+	start := time.Now()
+	key := "gdrive:/data"
+
+	d.recordRecent(key, FileChange{Path: "old.txt", EntryType: fs.EntryObject, DetectedAt: start.Add(-time.Hour)})
+	d.recordRecent(key, FileChange{Path: "a.txt", EntryType: fs.EntryObject, DetectedAt: start})
+	d.recordRecent(key, FileChange{Path: "b.txt", OldPath: "a.txt", Type: ChangeRenamed, EntryType: fs.EntryObject, DetectedAt: start})
+	d.recordRecent(key, FileChange{Path: "gone.txt", Type: ChangeDeleted, EntryType: fs.EntryObject, DetectedAt: start})
+	d.recordRecent(key, FileChange{Path: "dir", EntryType: fs.EntryDirectory, DetectedAt: start})
+	d.recordRecent("other:", FileChange{Path: "c.txt", EntryType: fs.EntryObject, DetectedAt: start})
+
+	if got := d.RecentChanges(key, start); !slices.Equal(got, []string{"b.txt"}) {
+		t.Errorf("RecentChanges since start = %v, want [b.txt]", got)
+	}
+	if got := d.RecentChanges(key, time.Time{}); !slices.Equal(got, []string{"b.txt", "old.txt"}) {
+		t.Errorf("RecentChanges = %v, want [b.txt old.txt]", got)
+	}
+}
@@ -21,6 +21,13 @@ const (
 
 	// MaxChangesBeforeFallback triggers a full sync instead of filter-scoped delta.
 	MaxChangesBeforeFallback = 5000
+
+	// WatcherRestartBaseDelay is the first restart delay for a watcher that went down.
+	// Each consecutive restart without a healthy probe doubles it.
+	WatcherRestartBaseDelay = 1 * time.Minute
+
+	// WatcherRestartMaxDelay caps the restart backoff.
+	WatcherRestartMaxDelay = 30 * time.Minute
 )
 
 // DeltaService manages delta watchers for all configured remotes.
@@ -30,19 +37,48 @@ type DeltaService struct {
 	mu       sync.RWMutex
 	ctx      context.Context
 	cancel   context.CancelFunc
+
+	restartTimers   map[string]*time.Timer
+	restartAttempts map[string]int
+	onWatcherDown   func(status WatcherStatus)
+	onChange        func(remoteKey string, change FileChange)
+
+	lastRuns map[string]RunInfo
+
+	recent   map[string]map[string]time.Time // remoteKey -> changed file -> when
+	recentMu sync.Mutex
 }
 
 // NewDeltaService creates a new DeltaService.
 func NewDeltaService(store *DeltaStore) *DeltaService {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &DeltaService{
-		store:    store,
-		watchers: make(map[string]*Watcher),
-		ctx:      ctx,
-		cancel:   cancel,
+		store:           store,
+		watchers:        make(map[string]*Watcher),
+		ctx:             ctx,
+		cancel:          cancel,
+		restartTimers:   make(map[string]*time.Timer),
+		restartAttempts: make(map[string]int),
+		lastRuns:        make(map[string]RunInfo),
 	}
 }
 
+// SetWatcherDownHandler sets a callback invoked when a watcher goes down.
+// The status includes the delay until the automatic restart.
+func (d *DeltaService) SetWatcherDownHandler(handler func(status WatcherStatus)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onWatcherDown = handler
+}
+
+// SetChangeHandler sets a callback invoked for every change a watcher detects,
+// as it arrives rather than when the changes are drained by a sync.
+func (d *DeltaService) SetChangeHandler(handler func(remoteKey string, change FileChange)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onChange = handler
+}
+
 // getProviderType returns the provider type string for a filesystem,
 // or "none" if it doesn't support ChangeNotify.
 func getProviderType(remoteFs fs.Fs) string {
@@ -68,8 +104,28 @@ func (d *DeltaService) EnsureWatcher(remoteFs fs.Fs, remoteKey string) error {
 		return nil
 	}
 
-	// Create and start watcher
+	// A full sync supersedes any pending restart
+	d.cancelRestartLocked(remoteKey)
+	d.startWatcherLocked(remoteKey, remoteFs, false)
+
+	return nil
+}
+
+// startWatcherLocked creates and starts a watcher. needsFullSync marks a
+// restarted watcher that may have missed changes while it was down.
+// Caller must hold d.mu.
+func (d *DeltaService) startWatcherLocked(remoteKey string, remoteFs fs.Fs, needsFullSync bool) {
 	w := NewWatcher(remoteKey, remoteFs)
+	w.needsFullSync = needsFullSync
+	w.store = d.store
+	w.onDown = d.handleWatcherDown
+	onChange := d.onChange
+	w.onChange = func(remoteKey string, change FileChange) {
+		d.recordRecent(remoteKey, change)
+		if onChange != nil {
+			onChange(remoteKey, change)
+		}
+	}
 	w.Start(d.ctx, DefaultPollInterval)
 	d.watchers[remoteKey] = w
 
@@ -77,58 +133,344 @@ func (d *DeltaService) EnsureWatcher(remoteFs fs.Fs, remoteKey string) error {
 	if err := d.store.SetWatching(remoteKey, true); err != nil {
 		log.Printf("[delta] Failed to update watching state for %s: %v", remoteKey, err)
 	}
+}
 
-	return nil
+// handleWatcherDown stops a watcher whose health probes keep failing
+// (e.g. expired token) and schedules a restart with exponential backoff.
+func (d *DeltaService) handleWatcherDown(w *Watcher, err error) {
+	d.mu.Lock()
+	if d.ctx.Err() != nil || d.watchers[w.remoteKey] != w {
+		// Shutting down, or the watcher was already replaced
+		d.mu.Unlock()
+		return
+	}
+
+	key := w.remoteKey
+	if w.hasProbed() {
+		// It was healthy for a while; start the backoff over
+		d.restartAttempts[key] = 0
+	}
+	attempt := d.restartAttempts[key]
+	d.restartAttempts[key] = attempt + 1
+	delay := watcherRestartDelay(attempt)
+
+	w.Stop()
+	if err := d.store.SetWatching(key, false); err != nil {
+		log.Printf("[delta] Failed to update watching state for %s: %v", key, err)
+	}
+
+	d.cancelRestartLocked(key)
+	d.restartTimers[key] = time.AfterFunc(delay, func() {
+		d.restartWatcher(key, w)
+	})
+	log.Printf("[delta] %s: watcher down (%v), restarting in %v", key, err, delay)
+
+	status := w.Status()
+	status.Restarts = attempt + 1
+	status.RetryIn = delay.String()
+	handler := d.onWatcherDown
+	d.mu.Unlock()
+
+	if handler != nil {
+		handler(status)
+	}
+}
+
+// restartWatcher replaces a downed watcher with a new one, unless a full
+// sync already started a fresh watcher in the meantime.
+func (d *DeltaService) restartWatcher(remoteKey string, old *Watcher) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.restartTimers, remoteKey)
+	if d.ctx.Err() != nil || d.watchers[remoteKey] != old {
+		return
+	}
+
+	log.Printf("[delta] %s: restarting watcher (attempt %d)", remoteKey, d.restartAttempts[remoteKey])
+	d.startWatcherLocked(remoteKey, old.remoteFs, true)
+	// Keep what the old watcher collected; its flushes are replaced by the new one's
+	d.watchers[remoteKey].RestoreChanges(old.PendingChanges())
+}
+
+// cancelRestartLocked stops a pending watcher restart. Caller must hold d.mu.
+func (d *DeltaService) cancelRestartLocked(remoteKey string) {
+	if t, ok := d.restartTimers[remoteKey]; ok {
+		t.Stop()
+		delete(d.restartTimers, remoteKey)
+	}
+}
+
+// watcherRestartDelay returns the backoff delay for the given restart attempt (0-based).
+func watcherRestartDelay(attempt int) time.Duration {
+	delay := WatcherRestartBaseDelay
+	for i := 0; i < attempt; i++ {
+		delay *= 2
+		if delay >= WatcherRestartMaxDelay {
+			return WatcherRestartMaxDelay
+		}
+	}
+	return delay
+}
+
+// WatcherStatuses returns the health of every known watcher, including
+// watchers that are down and waiting to restart.
+func (d *DeltaService) WatcherStatuses() []WatcherStatus {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	statuses := make([]WatcherStatus, 0, len(d.watchers))
+	for key, w := range d.watchers {
+		status := w.Status()
+		status.Restarts = d.restartAttempts[key]
+		statuses = append(statuses, status)
+	}
+	return statuses
 }
 
 // ShouldSkipSync returns true if the watcher for this remote reports 0 changes
-// and conditions are met for a delta sync (watcher running, not too many
+// and conditions are met for a delta sync (watcher healthy, not too many
 // consecutive deltas, not too long since last full sync).
 // Returns false (meaning do full sync) if any condition is not met.
 func (d *DeltaService) ShouldSkipSync(remoteKey string) bool {
+	if reason := d.FullSyncReason(remoteKey); reason != "" {
+		if reason != ReasonNoWatcher {
+			log.Printf("[delta] %s: full sync required: %s", remoteKey, reason)
+		}
+		return false
+	}
+
+	d.mu.RLock()
+	w, exists := d.watchers[remoteKey]
+	d.mu.RUnlock()
+	if !exists {
+		return false
+	}
+
+	// Watcher reports no changes → safe to skip
+	return !w.HasChanges()
+}
+
+// Reasons a remote can't use delta state, reported by FullSyncReason.
+const (
+	ReasonNoWatcher        = "remote has no change watcher"
+	ReasonWatcherUnhealthy = "change watcher is down or may have missed changes"
+	ReasonNoBaseline       = "no full sync recorded yet"
+	ReasonMaxDeltas        = "too many consecutive delta syncs"
+	ReasonFullSyncDue      = "periodic full sync is due"
+)
+
+// FullSyncReason returns why the remote's delta state can't be trusted for
+// this sync, or "" if a delta sync is possible.
+func (d *DeltaService) FullSyncReason(remoteKey string) string {
 	d.mu.RLock()
 	w, exists := d.watchers[remoteKey]
 	d.mu.RUnlock()
 
 	// No watcher → can't determine, do full sync
-	if !exists || !w.IsRunning() {
-		return false
+	if !exists {
+		return ReasonNoWatcher
+	}
+
+	// Down, stale or restarted watcher → changes may have been missed
+	if !w.IsHealthy() {
+		return ReasonWatcherUnhealthy
 	}
 
+	return d.periodicReason(remoteKey)
+}
+
+// periodicReason returns why the remote is due a full sync regardless of
+// detected changes, or "" if it isn't.
+func (d *DeltaService) periodicReason(remoteKey string) string {
 	// Check state for periodic full sync requirements
 	state, err := d.store.GetState(remoteKey)
-	if err != nil || state == nil {
-		return false
+	if err != nil || state == nil || state.LastFullSync == nil {
+		return ReasonNoBaseline
 	}
 
 	// Force full sync after too many consecutive delta syncs
 	if state.DeltaCount >= MaxDeltaSyncsBeforeFullSync {
-		log.Printf("[delta] %s: forcing full sync after %d consecutive delta syncs", remoteKey, state.DeltaCount)
-		return false
+		return ReasonMaxDeltas
 	}
 
 	// Force full sync after too long since last full sync
-	if state.LastFullSync != nil && time.Since(*state.LastFullSync) > MaxTimeBetweenFullSyncs {
-		log.Printf("[delta] %s: forcing full sync, last full sync was %v ago", remoteKey, time.Since(*state.LastFullSync))
-		return false
+	if time.Since(*state.LastFullSync) > MaxTimeBetweenFullSyncs {
+		return ReasonFullSyncDue
 	}
 
-	// Watcher reports no changes → safe to skip
-	return !w.HasChanges()
+	return ""
+}
+
+// SupportsWatching reports whether the remote's backend supports ChangeNotify.
+// Remotes that don't can use QuickCheck instead.
+func SupportsWatching(remoteFs fs.Fs) bool {
+	return getProviderType(remoteFs) != "none"
+}
+
+// Reasons a quick check can't rule out changes, reported by QuickCheck.
+const (
+	ReasonQuickCheckFailed   = "quick check failed"
+	ReasonNoFingerprint      = "no listing fingerprint recorded yet"
+	ReasonFingerprintChanged = "listing fingerprint changed"
+)
+
+// QuickCheck computes the remote's listing fingerprint and compares it with
+// the one stored after the last sync. Returns the fresh fingerprint (nil if it
+// couldn't be computed) and why the remote needs syncing, or "" if it looks
+// unchanged and isn't due a periodic full sync.
+func (d *DeltaService) QuickCheck(ctx context.Context, remoteFs fs.Fs, remoteKey string) (Fingerprint, string) {
+	fp, err := ComputeFingerprint(ctx, remoteFs)
+	if err != nil {
+		log.Printf("[delta] %s: quick check failed: %v", remoteKey, err)
+		return nil, ReasonQuickCheckFailed
+	}
+
+	if reason := d.periodicReason(remoteKey); reason != "" {
+		return fp, reason
+	}
+
+	stored, err := d.store.GetFingerprint(remoteKey)
+	if err != nil || stored == nil {
+		return fp, ReasonNoFingerprint
+	}
+	if !stored.Equal(fp) {
+		return fp, ReasonFingerprintChanged
+	}
+	return fp, ""
+}
+
+// CommitFingerprint stores a fingerprint taken when the remote was in sync.
+func (d *DeltaService) CommitFingerprint(remoteKey string, fp Fingerprint) {
+	if fp == nil {
+		return
+	}
+	if err := d.store.SetFingerprint(remoteKey, fp); err != nil {
+		log.Printf("[delta] Failed to store fingerprint for %s: %v", remoteKey, err)
+	}
+}
+
+// CheckSubtrees compares the remote's directory tokens with those committed
+// after its last sync, probing the first time whether its directory metadata
+// reflects changes below each directory. Returns nil when it doesn't, when
+// the probe or listing failed, or when the remote is due a periodic full
+// sync; otherwise the check's Unchanged subtrees can be left out of a full
+// sync, and its Tokens committed with CommitSubtrees once it succeeded.
+func (d *DeltaService) CheckSubtrees(ctx context.Context, remoteFs fs.Fs, remoteKey string) *SubtreeCheck {
+	probe, previous, err := d.store.GetSubtrees(remoteKey)
+	if err != nil {
+		log.Printf("[delta] %s: failed to read directory tokens: %v", remoteKey, err)
+		return nil
+	}
+	if probe == "" {
+		probe = SubtreesUnreliable
+		reliable, err := ProbeSubtreeTokens(ctx, remoteFs)
+		if err != nil {
+			log.Printf("[delta] %s: directory metadata probe failed: %v", remoteKey, err)
+		} else if reliable {
+			probe = SubtreesReliable
+		}
+		log.Printf("[delta] %s: directory metadata is %s for skipping unchanged subtrees", remoteKey, probe)
+		if err := d.store.SetSubtreeProbe(remoteKey, probe); err != nil {
+			log.Printf("[delta] Failed to store directory metadata probe for %s: %v", remoteKey, err)
+		}
+	}
+	if probe != SubtreesReliable {
+		return nil
+	}
+
+	if d.periodicReason(remoteKey) != "" {
+		// Check everything, but still record the tokens for next time
+		previous = nil
+	}
+	check, err := ComputeSubtreeTokens(ctx, remoteFs, previous, fs.GetConfig(ctx).Checkers)
+	if err != nil {
+		log.Printf("[delta] %s: failed to compute directory tokens: %v", remoteKey, err)
+		return nil
+	}
+	return check
+}
+
+// CommitSubtrees stores directory tokens taken when the remote was in sync.
+func (d *DeltaService) CommitSubtrees(remoteKey string, check *SubtreeCheck) {
+	if check == nil {
+		return
+	}
+	if err := d.store.SetSubtreeTokens(remoteKey, check.Tokens); err != nil {
+		log.Printf("[delta] Failed to store directory tokens for %s: %v", remoteKey, err)
+	}
 }
 
-// GetChanges drains changes from the watcher for filter scoping.
-// Returns nil if no watcher, no changes, or too many changes.
+// RecordRun records how a sync used delta state. Delta and skipped runs get
+// a time-saved estimate against the last full sync of the same remote.
+// Returns the recorded info.
+func (d *DeltaService) RecordRun(remoteKey string, info RunInfo) RunInfo {
+	info.RemoteKey = remoteKey
+	if info.FinishedAt.IsZero() {
+		info.FinishedAt = time.Now()
+	}
+
+	if info.Mode != RunModeFull {
+		if stats, err := d.store.GetStats(remoteKey); err == nil && stats != nil && stats.LastFullDuration > info.Duration {
+			info.TimeSaved = stats.LastFullDuration - info.Duration
+		}
+	}
+
+	if err := d.store.RecordRun(remoteKey, info); err != nil {
+		log.Printf("[delta] Failed to record run for %s: %v", remoteKey, err)
+	}
+
+	d.mu.Lock()
+	d.lastRuns[remoteKey] = info
+	d.mu.Unlock()
+	return info
+}
+
+// LastRun returns the most recent run recorded for a remote since app start, or nil.
+func (d *DeltaService) LastRun(remoteKey string) *RunInfo {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if info, ok := d.lastRuns[remoteKey]; ok {
+		return &info
+	}
+	return nil
+}
+
+// GetStats returns delta usage statistics for a remote, including the
+// watcher's health. Returns nil if nothing has been recorded for the remote.
+func (d *DeltaService) GetStats(remoteKey string) (*DeltaStats, error) {
+	stats, err := d.store.GetStats(remoteKey)
+	if err != nil || stats == nil {
+		return stats, err
+	}
+
+	stats.LastRun = d.LastRun(remoteKey)
+
+	d.mu.RLock()
+	w, exists := d.watchers[remoteKey]
+	d.mu.RUnlock()
+	if exists {
+		status := w.Status()
+		stats.Watcher = &status
+		stats.PendingChanges = len(w.PendingChanges())
+	}
+	return stats, nil
+}
+
+// GetChanges drains changes from the watcher for filter scoping, and from
+// the store. Returns nil if no watcher, the watcher is unhealthy, no
+// changes, or too many changes.
 func (d *DeltaService) GetChanges(remoteKey string) *ChangeSet {
 	d.mu.RLock()
 	w, exists := d.watchers[remoteKey]
 	d.mu.RUnlock()
 
-	if !exists || !w.IsRunning() {
+	if !exists || !w.IsHealthy() {
 		return nil
 	}
 
 	changes := w.DrainChanges()
+	w.flush()
 	if len(changes) == 0 {
 		return &ChangeSet{
 			RemoteKey:  remoteKey,
@@ -154,6 +496,25 @@ func (d *DeltaService) RestoreChanges(remoteKey string, changes []FileChange) {
 		return
 	}
 	w.RestoreChanges(changes)
+	w.flush()
+}
+
+// PendingChanges returns the changes of a remote that no sync picked up
+// yet: the ones its watcher collected, or without a watcher the ones stored
+// before the app last exited. Unlike GetChanges it leaves them in place.
+func (d *DeltaService) PendingChanges(remoteKey string) []FileChange {
+	d.mu.RLock()
+	w, exists := d.watchers[remoteKey]
+	d.mu.RUnlock()
+	if exists {
+		return w.PendingChanges()
+	}
+
+	changes, err := d.store.GetPendingChanges(remoteKey)
+	if err != nil {
+		log.Printf("[delta] Failed to load pending changes for %s: %v", remoteKey, err)
+	}
+	return changes
 }
 
 // CommitDelta records a successful delta sync (increments counter).
@@ -175,16 +536,34 @@ func (d *DeltaService) CommitFullSync(remoteFs fs.Fs, remoteKey string) error {
 		}
 	}
 
-	return d.store.RecordFullSync(remoteKey, provider, isWatching)
+	err := d.store.RecordFullSync(remoteKey, provider, isWatching)
+
+	d.mu.RLock()
+	if w, ok := d.watchers[remoteKey]; ok {
+		// The full sync covers anything a restarted watcher missed
+		w.markFullSynced()
+		// Recording it dropped the stored changes; the watcher's are still pending
+		w.markDirty()
+	}
+	d.mu.RUnlock()
+	return err
 }
 
-// StopAll stops all watchers. Called on app shutdown.
+// StopAll stops all watchers and flushes the changes they collected that no
+// sync picked up. Called on app shutdown.
 func (d *DeltaService) StopAll() {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	for key, t := range d.restartTimers {
+		t.Stop()
+		delete(d.restartTimers, key)
+	}
+
 	for key, w := range d.watchers {
 		w.Stop()
+		// Keep changes no sync picked up yet, so they aren't lost with the watcher
+		w.flush()
 		if err := d.store.SetWatching(key, false); err != nil {
 			log.Printf("[delta] Failed to update watching state on stop for %s: %v", key, err)
 		}
@@ -3,6 +3,8 @@ package delta
 import (
 	"database/sql"
 	"time"
+
+	"github.com/rclone/rclone/fs"
 )
 
 // DeltaStore provides CRUD operations for delta state in SQLite.
@@ -50,7 +52,7 @@ func (s *DeltaStore) GetState(remoteKey string) (*DeltaState, error) {
 }
 
 // RecordFullSync records a full sync completion: resets delta_count, sets last_full_sync,
-// and updates provider and watching state.
+// updates provider and watching state, and drops pending changes the full sync covered.
 func (s *DeltaStore) RecordFullSync(remoteKey, provider string, isWatching bool) error {
 	db, err := s.getDB()
 	if err != nil {
@@ -73,6 +75,10 @@ func (s *DeltaStore) RecordFullSync(remoteKey, provider string, isWatching bool)
 			delta_count = 0,
 			updated_at = excluded.updated_at`,
 		remoteKey, provider, watchInt, now, now)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`DELETE FROM delta_changes WHERE remote_key = ?`, remoteKey)
 	return err
 }
 
@@ -107,3 +113,245 @@ func (s *DeltaStore) SetWatching(remoteKey string, watching bool) error {
 		WHERE remote_key = ?`, watchInt, now, remoteKey)
 	return err
 }
+
+// RecordRun adds a sync run to the per-remote counters. Full runs also
+// update the duration used to estimate the time delta runs save.
+func (s *DeltaStore) RecordRun(remoteKey string, info RunInfo) error {
+	db, err := s.getDB()
+	if err != nil {
+		return err
+	}
+
+	var full, deltaRun, skipped int
+	switch info.Mode {
+	case RunModeFull:
+		full = 1
+	case RunModeDelta:
+		deltaRun = 1
+	case RunModeSkipped:
+		skipped = 1
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err = db.Exec(`
+		INSERT INTO delta_state (remote_key, full_runs, delta_runs, skipped_runs, last_full_duration_ms,
+			time_saved_ms, last_mode, last_reason, updated_at)
+		VALUES (?, ?, ?, ?, CASE WHEN ? = 1 THEN ? ELSE 0 END, ?, ?, ?, ?)
+		ON CONFLICT(remote_key) DO UPDATE SET
+			full_runs = full_runs + excluded.full_runs,
+			delta_runs = delta_runs + excluded.delta_runs,
+			skipped_runs = skipped_runs + excluded.skipped_runs,
+			last_full_duration_ms = CASE WHEN excluded.full_runs = 1 THEN excluded.last_full_duration_ms ELSE last_full_duration_ms END,
+			time_saved_ms = time_saved_ms + excluded.time_saved_ms,
+			last_mode = excluded.last_mode,
+			last_reason = excluded.last_reason,
+			updated_at = excluded.updated_at`,
+		remoteKey, full, deltaRun, skipped, full, info.Duration.Milliseconds(),
+		info.TimeSaved.Milliseconds(), info.Mode, info.FallbackReason, now)
+	return err
+}
+
+// GetStats returns the delta counters for a remote endpoint, or nil if not found.
+func (s *DeltaStore) GetStats(remoteKey string) (*DeltaStats, error) {
+	state, err := s.GetState(remoteKey)
+	if err != nil || state == nil {
+		return nil, err
+	}
+
+	db, err := s.getDB()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &DeltaStats{
+		RemoteKey:    state.RemoteKey,
+		Provider:     state.Provider,
+		IsWatching:   state.IsWatching,
+		LastFullSync: state.LastFullSync,
+		DeltaCount:   state.DeltaCount,
+	}
+	var lastFullMs, savedMs int64
+	err = db.QueryRow(`
+		SELECT full_runs, delta_runs, skipped_runs, last_full_duration_ms, time_saved_ms, last_mode, last_reason
+		FROM delta_state WHERE remote_key = ?`, remoteKey).Scan(
+		&stats.FullRuns, &stats.DeltaRuns, &stats.SkippedRuns, &lastFullMs, &savedMs, &stats.LastMode, &stats.LastReason)
+	if err != nil {
+		return nil, err
+	}
+	stats.LastFullDuration = time.Duration(lastFullMs) * time.Millisecond
+	stats.TotalTimeSaved = time.Duration(savedMs) * time.Millisecond
+
+	pending, err := s.GetPendingChanges(remoteKey)
+	if err != nil {
+		return nil, err
+	}
+	stats.PendingChanges = len(pending)
+	return stats, nil
+}
+
+// SaveChanges replaces the stored changes of a remote endpoint with the
+// ones its watcher has collected but no sync picked up, so they outlive the
+// app. They are kept until the next full sync.
+func (s *DeltaStore) SaveChanges(remoteKey string, changes []FileChange) error {
+	db, err := s.getDB()
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM delta_changes WHERE remote_key = ?`, remoteKey); err != nil {
+		return err
+	}
+	for _, c := range changes {
+		created := 0
+		if c.Created {
+			created = 1
+		}
+		_, err := tx.Exec(`
+			INSERT INTO delta_changes (remote_key, path, old_path, entry_type, change_type, object_id, created, detected_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			remoteKey, c.Path, c.OldPath, int(c.EntryType), int(c.Type), c.ObjectID, created,
+			c.DetectedAt.UTC().Format(time.RFC3339Nano))
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetPendingChanges returns the changes stored by SaveChanges in the order
+// they were collected, or nil if there are none.
+func (s *DeltaStore) GetPendingChanges(remoteKey string) ([]FileChange, error) {
+	db, err := s.getDB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT path, old_path, entry_type, change_type, object_id, created, detected_at
+		FROM delta_changes WHERE remote_key = ? ORDER BY id`, remoteKey)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []FileChange
+	for rows.Next() {
+		var c FileChange
+		var detectedAt string
+		var entryType, changeType, created int
+		if err := rows.Scan(&c.Path, &c.OldPath, &entryType, &changeType, &c.ObjectID, &created, &detectedAt); err != nil {
+			return nil, err
+		}
+		c.EntryType = fs.EntryType(entryType)
+		c.Type = ChangeType(changeType)
+		c.Created = created != 0
+		c.DetectedAt, _ = time.Parse(time.RFC3339Nano, detectedAt)
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// GetFingerprint returns the stored quick-check fingerprint for a remote endpoint,
+// or nil if none was recorded.
+func (s *DeltaStore) GetFingerprint(remoteKey string) (Fingerprint, error) {
+	db, err := s.getDB()
+	if err != nil {
+		return nil, err
+	}
+
+	var data string
+	err = db.QueryRow(`SELECT fingerprint FROM delta_state WHERE remote_key = ?`, remoteKey).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return DecodeFingerprint(data)
+}
+
+// SetFingerprint stores the quick-check fingerprint for a remote endpoint.
+func (s *DeltaStore) SetFingerprint(remoteKey string, fp Fingerprint) error {
+	db, err := s.getDB()
+	if err != nil {
+		return err
+	}
+
+	data, err := fp.Encode()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err = db.Exec(`
+		INSERT INTO delta_state (remote_key, fingerprint, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(remote_key) DO UPDATE SET
+			fingerprint = excluded.fingerprint,
+			updated_at = excluded.updated_at`,
+		remoteKey, data, now)
+	return err
+}
+
+// GetSubtrees returns whether the remote's directory metadata was found to
+// reflect changes below each directory ("" if it wasn't probed yet), and the
+// directory tokens stored after its last sync, or nil.
+func (s *DeltaStore) GetSubtrees(remoteKey string) (string, SubtreeTokens, error) {
+	db, err := s.getDB()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var probe, data string
+	err = db.QueryRow(`SELECT subtree_probe, subtree_tokens FROM delta_state WHERE remote_key = ?`, remoteKey).Scan(&probe, &data)
+	if err == sql.ErrNoRows {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	tokens, err := DecodeSubtreeTokens(data)
+	return probe, tokens, err
+}
+
+// SetSubtreeProbe stores the result of probing a remote's directory metadata.
+func (s *DeltaStore) SetSubtreeProbe(remoteKey, probe string) error {
+	db, err := s.getDB()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err = db.Exec(`
+		INSERT INTO delta_state (remote_key, subtree_probe, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(remote_key) DO UPDATE SET
+			subtree_probe = excluded.subtree_probe,
+			updated_at = excluded.updated_at`,
+		remoteKey, probe, now)
+	return err
+}
+
+// SetSubtreeTokens stores the directory tokens of a remote in sync.
+func (s *DeltaStore) SetSubtreeTokens(remoteKey string, tokens SubtreeTokens) error {
+	db, err := s.getDB()
+	if err != nil {
+		return err
+	}
+
+	data, err := tokens.Encode()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	_, err = db.Exec(`
+		INSERT INTO delta_state (remote_key, subtree_tokens, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(remote_key) DO UPDATE SET
+			subtree_tokens = excluded.subtree_tokens,
+			updated_at = excluded.updated_at`,
+		remoteKey, data, now)
+	return err
+}
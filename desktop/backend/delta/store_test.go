@@ -0,0 +1,188 @@
+package delta
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	_ "modernc.org/sqlite"
+)
+
+func newTestStore(t *testing.T) *DeltaStore {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "delta.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`CREATE TABLE delta_state (
+		remote_key            TEXT PRIMARY KEY,
+		provider              TEXT NOT NULL DEFAULT '',
+		is_watching           INTEGER NOT NULL DEFAULT 0,
+		last_full_sync        TEXT,
+		delta_count           INTEGER NOT NULL DEFAULT 0,
+		created_at            TEXT NOT NULL DEFAULT (datetime('now')),
+		updated_at            TEXT NOT NULL DEFAULT (datetime('now')),
+		full_runs             INTEGER NOT NULL DEFAULT 0,
+		delta_runs            INTEGER NOT NULL DEFAULT 0,
+		skipped_runs          INTEGER NOT NULL DEFAULT 0,
+		last_full_duration_ms INTEGER NOT NULL DEFAULT 0,
+		time_saved_ms         INTEGER NOT NULL DEFAULT 0,
+		last_mode             TEXT NOT NULL DEFAULT '',
+		last_reason           TEXT NOT NULL DEFAULT '',
+		fingerprint           TEXT NOT NULL DEFAULT '',
+		pending_changes       TEXT NOT NULL DEFAULT '',
+		subtree_probe         TEXT NOT NULL DEFAULT '',
+		subtree_tokens        TEXT NOT NULL DEFAULT ''
+	);
+	CREATE TABLE delta_changes (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		remote_key  TEXT NOT NULL,
+		path        TEXT NOT NULL,
+		old_path    TEXT NOT NULL DEFAULT '',
+		entry_type  INTEGER NOT NULL DEFAULT 0,
+		change_type INTEGER NOT NULL DEFAULT 0,
+		object_id   TEXT NOT NULL DEFAULT '',
+		created     INTEGER NOT NULL DEFAULT 0,
+		detected_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		t.Fatalf("failed to create tables: %v", err)
+	}
+	return NewDeltaStore(func() (*sql.DB, error) { return db, nil })
+}
+
+func TestRecordRunStats(t *testing.T) {
+	store := newTestStore(t)
+	d := NewDeltaService(store)
+	defer d.StopAll()
+
+	const key = "gdrive:/data"
+	if err := store.RecordFullSync(key, "drive", true); err != nil {
+		t.Fatalf("RecordFullSync failed: %v", err)
+	}
+
+	d.RecordRun(key, RunInfo{Mode: RunModeFull, FallbackReason: ReasonNoBaseline, Duration: 10 * time.Minute})
+	run := d.RecordRun(key, RunInfo{Mode: RunModeDelta, ChangesScoped: 42, Duration: 1 * time.Minute})
+	if run.TimeSaved != 9*time.Minute {
+		t.Errorf("expected 9m saved, got %v", run.TimeSaved)
+	}
+	d.RecordRun(key, RunInfo{Mode: RunModeSkipped, Duration: time.Second})
+
+	stats, err := d.GetStats(key)
+	if err != nil || stats == nil {
+		t.Fatalf("GetStats failed: %v", err)
+	}
+	if stats.FullRuns != 1 || stats.DeltaRuns != 1 || stats.SkippedRuns != 1 {
+		t.Errorf("unexpected run counts: %+v", stats)
+	}
+	if stats.LastFullDuration != 10*time.Minute {
+		t.Errorf("expected last full duration 10m, got %v", stats.LastFullDuration)
+	}
+	if want := 9*time.Minute + 10*time.Minute - time.Second; stats.TotalTimeSaved != want {
+		t.Errorf("expected %v saved in total, got %v", want, stats.TotalTimeSaved)
+	}
+	if stats.LastMode != RunModeSkipped || stats.LastRun == nil || stats.LastRun.Mode != RunModeSkipped {
+		t.Errorf("expected last run to be the skipped one, got %+v", stats)
+	}
+	if stats.Provider != "drive" || !stats.IsWatching {
+		t.Errorf("expected watcher state from delta_state, got %+v", stats)
+	}
+
+	if stats, err := d.GetStats("unknown:"); err != nil || stats != nil {
+		t.Errorf("expected nil stats for unknown remote, got %+v, %v", stats, err)
+	}
+}
+
+func TestFullSyncReason(t *testing.T) {
+	store := newTestStore(t)
+	d := NewDeltaService(store)
+	defer d.StopAll()
+
+	if reason := d.FullSyncReason("gdrive:"); reason != ReasonNoWatcher {
+		t.Errorf("expected %q, got %q", ReasonNoWatcher, reason)
+	}
+
+	w := NewWatcher("gdrive:", nil)
+	w.running = true
+	w.pollInterval = time.Minute
+	w.lastHeartbeat = time.Now()
+	d.watchers["gdrive:"] = w
+	if reason := d.FullSyncReason("gdrive:"); reason != ReasonNoBaseline {
+		t.Errorf("expected %q, got %q", ReasonNoBaseline, reason)
+	}
+
+	store.RecordFullSync("gdrive:", "drive", true)
+	if reason := d.FullSyncReason("gdrive:"); reason != "" {
+		t.Errorf("expected delta to be possible, got %q", reason)
+	}
+
+	w.needsFullSync = true
+	if reason := d.FullSyncReason("gdrive:"); reason != ReasonWatcherUnhealthy {
+		t.Errorf("expected %q, got %q", ReasonWatcherUnhealthy, reason)
+	}
+}
+
+func TestStopAllSavesPendingChanges(t *testing.T) {
+	store := newTestStore(t)
+	d := NewDeltaService(store)
+
+	const key = "gdrive:"
+	store.RecordFullSync(key, "drive", true)
+	w := NewWatcher(key, nil)
+	w.store = store
+	w.running = true
+	w.changes = []FileChange{{Path: "a.txt"}, {Path: "old", Type: ChangeDeleted}}
+	w.dirty = true
+	d.watchers[key] = w
+
+	d.StopAll()
+	changes, err := store.GetPendingChanges(key)
+	if err != nil || len(changes) != 2 || changes[1].Path != "old" || changes[1].Type != ChangeDeleted {
+		t.Fatalf("expected the watcher's changes to be saved, got %+v, %v", changes, err)
+	}
+	if stats, _ := store.GetStats(key); stats == nil || stats.PendingChanges != 2 || stats.IsWatching {
+		t.Errorf("unexpected stats after stop: %+v", stats)
+	}
+	if pending := d.PendingChanges(key); len(pending) != 2 {
+		t.Errorf("expected the stored changes without a watcher, got %+v", pending)
+	}
+
+	// The next full sync covers them
+	store.RecordFullSync(key, "drive", true)
+	if changes, _ := store.GetPendingChanges(key); changes != nil {
+		t.Errorf("expected a full sync to drop pending changes, got %+v", changes)
+	}
+}
+
+func TestSaveChanges(t *testing.T) {
+	store := newTestStore(t)
+	detected := time.Date(2024, 5, 1, 12, 0, 0, 500, time.UTC)
+	changes := []FileChange{
+		{Path: "docs/new.txt", EntryType: fs.EntryObject, ObjectID: "id1", Created: true, DetectedAt: detected},
+		{Path: "docs/b.txt", OldPath: "docs/a.txt", EntryType: fs.EntryObject, Type: ChangeRenamed, DetectedAt: detected},
+		{Path: "old", EntryType: fs.EntryDirectory, Type: ChangeDeleted, DetectedAt: detected},
+	}
+	if err := store.SaveChanges("gdrive:", changes); err != nil {
+		t.Fatalf("SaveChanges failed: %v", err)
+	}
+	store.SaveChanges("onedrive:", []FileChange{{Path: "other.txt"}})
+
+	got, err := store.GetPendingChanges("gdrive:")
+	if err != nil || !reflect.DeepEqual(got, changes) {
+		t.Fatalf("GetPendingChanges() = %+v, %v, want %+v", got, err, changes)
+	}
+
+	// Saving again replaces them
+	store.SaveChanges("gdrive:", changes[2:])
+	if got, _ := store.GetPendingChanges("gdrive:"); len(got) != 1 || got[0].Path != "old" {
+		t.Errorf("expected the changes to be replaced, got %+v", got)
+	}
+	if got, _ := store.GetPendingChanges("onedrive:"); len(got) != 1 {
+		t.Errorf("expected other remotes' changes to be kept, got %+v", got)
+	}
+}
@@ -0,0 +1,211 @@
+package delta
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/operations"
+)
+
+// Results of probing whether a remote's directory metadata reflects changes
+// anywhere below each directory, stored per remote.
+const (
+	SubtreesReliable   = "reliable"
+	SubtreesUnreliable = "unreliable"
+)
+
+// subtreeProbeWait is how long the probe waits between changes, so they
+// get distinct modification times on backends with second precision.
+var subtreeProbeWait = 1100 * time.Millisecond
+
+// SubtreeTokens maps each directory of a remote to a token built from what
+// its parent's listing says about it: ID, modification time, size and item
+// count. On backends that update these when anything below the directory
+// changes, an unchanged token means an unchanged subtree.
+type SubtreeTokens map[string]string
+
+// SubtreeCheck is the outcome of comparing a remote's directory tokens with
+// the ones committed after its last sync.
+type SubtreeCheck struct {
+	Tokens    SubtreeTokens // fresh tokens, to commit once the sync succeeded
+	Unchanged []string      // topmost directories whose subtree is unchanged
+}
+
+// dirToken builds the token of a directory entry.
+func dirToken(ctx context.Context, d fs.Directory) string {
+	return strings.Join([]string{
+		d.ID(),
+		strconv.FormatInt(d.ModTime(ctx).UnixNano(), 10),
+		strconv.FormatInt(d.Size(), 10),
+		strconv.FormatInt(d.Items(), 10),
+	}, "|")
+}
+
+// ComputeSubtreeTokens lists f from the root down, a level at a time with up
+// to checkers listings at once, but doesn't descend into directories whose
+// token equals the one in previous: those are returned as unchanged, and the
+// tokens of the directories below them are carried over from previous.
+func ComputeSubtreeTokens(ctx context.Context, f fs.Fs, previous SubtreeTokens, checkers int) (*SubtreeCheck, error) {
+	check := &SubtreeCheck{Tokens: SubtreeTokens{}}
+	level := []string{""}
+	for len(level) > 0 {
+		listings := make([]fs.DirEntries, len(level))
+		errs := make([]error, len(level))
+		sem := make(chan struct{}, max(checkers, 1))
+		var wg sync.WaitGroup
+		for i, dir := range level {
+			wg.Add(1)
+			go func(i int, dir string) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				listings[i], errs[i] = f.List(ctx, dir)
+			}(i, dir)
+		}
+		wg.Wait()
+
+		var next []string
+		for i, dir := range level {
+			if errs[i] != nil {
+				return nil, fmt.Errorf("failed to list %q: %w", dir, errs[i])
+			}
+			for _, entry := range listings[i] {
+				d, ok := entry.(fs.Directory)
+				if !ok {
+					continue
+				}
+				token := dirToken(ctx, d)
+				check.Tokens[d.Remote()] = token
+				if prev, ok := previous[d.Remote()]; ok && prev == token {
+					check.Unchanged = append(check.Unchanged, d.Remote())
+					continue
+				}
+				next = append(next, d.Remote())
+			}
+		}
+		level = next
+	}
+
+	unchanged := make(map[string]bool, len(check.Unchanged))
+	for _, dir := range check.Unchanged {
+		unchanged[dir] = true
+	}
+	for dir, token := range previous {
+		if HasAncestor(unchanged, dir) {
+			check.Tokens[dir] = token
+		}
+	}
+	return check, nil
+}
+
+// HasAncestor reports whether any parent directory of p is in dirs.
+func HasAncestor(dirs map[string]bool, p string) bool {
+	for parent := path.Dir(p); parent != "." && parent != "/"; parent = path.Dir(parent) {
+		if dirs[parent] {
+			return true
+		}
+	}
+	return false
+}
+
+// ProbeSubtreeTokens reports whether changing a file two levels down changes
+// the token of the directory above in the root's listing, and nothing else
+// does. It writes a probe directory at the remote's root and purges it
+// again. Local disks only update a directory's modification time for its
+// own entries, so they are never reliable and aren't probed.
+func ProbeSubtreeTokens(ctx context.Context, f fs.Fs) (bool, error) {
+	if f.Features().IsLocal {
+		return false, nil
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return false, err
+	}
+	probe := ".ngdrive-subtree-probe-" + hex.EncodeToString(suffix)
+	defer func() {
+		if err := operations.Purge(ctx, f, probe); err != nil {
+			fs.Errorf(f, "failed to remove %s: %v", probe, err)
+		}
+	}()
+
+	write := func(name, content string) error {
+		_, err := operations.Rcat(ctx, f, path.Join(probe, "sub", name), io.NopCloser(bytes.NewReader([]byte(content))), time.Now(), nil)
+		return err
+	}
+	token := func() (string, error) {
+		entries, err := f.List(ctx, "")
+		if err != nil {
+			return "", err
+		}
+		for _, entry := range entries {
+			if d, ok := entry.(fs.Directory); ok && d.Remote() == probe {
+				return dirToken(ctx, d), nil
+			}
+		}
+		return "", fmt.Errorf("probe directory %s not listed", probe)
+	}
+
+	if err := write("a", "a"); err != nil {
+		return false, err
+	}
+	first, err := token()
+	if err != nil {
+		return false, err
+	}
+	again, err := token()
+	if err != nil || again != first {
+		// A token that changes by itself would never skip anything
+		return false, err
+	}
+
+	// Both a file changed in place and a file added must show
+	prev := first
+	for _, change := range []struct{ name, content string }{{"a", "changed"}, {"b", "b"}} {
+		time.Sleep(subtreeProbeWait)
+		if err := write(change.name, change.content); err != nil {
+			return false, err
+		}
+		current, err := token()
+		if err != nil {
+			return false, err
+		}
+		if current == prev {
+			return false, nil
+		}
+		prev = current
+	}
+	return true, nil
+}
+
+// Encode serialises the tokens for storage.
+func (t SubtreeTokens) Encode() (string, error) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// DecodeSubtreeTokens parses stored tokens. An empty string yields nil.
+func DecodeSubtreeTokens(data string) (SubtreeTokens, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var t SubtreeTokens
+	if err := json.Unmarshal([]byte(data), &t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
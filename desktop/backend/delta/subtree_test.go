@@ -0,0 +1,94 @@
+package delta
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+)
+
+func TestComputeSubtreeTokens(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "docs", "a.txt"), "a")
+	writeFile(t, filepath.Join(dir, "docs", "sub", "b.txt"), "b")
+	writeFile(t, filepath.Join(dir, "music", "c.mp3"), "c")
+
+	f, err := fs.NewFs(ctx, dir)
+	if err != nil {
+		t.Fatalf("failed to open local fs: %v", err)
+	}
+
+	first, err := ComputeSubtreeTokens(ctx, f, nil, 4)
+	if err != nil {
+		t.Fatalf("ComputeSubtreeTokens failed: %v", err)
+	}
+	if len(first.Tokens) != 3 || len(first.Unchanged) != 0 {
+		t.Fatalf("expected 3 tokens and nothing unchanged, got %+v", first)
+	}
+
+	// Unchanged directories aren't descended into, their tokens carried over
+	again, err := ComputeSubtreeTokens(ctx, f, first.Tokens, 4)
+	if err != nil {
+		t.Fatalf("ComputeSubtreeTokens failed: %v", err)
+	}
+	slices.Sort(again.Unchanged)
+	if !slices.Equal(again.Unchanged, []string{"docs", "music"}) || again.Tokens["docs/sub"] != first.Tokens["docs/sub"] {
+		t.Fatalf("expected docs and music unchanged, got %+v", again)
+	}
+
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "music"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := ComputeSubtreeTokens(ctx, f, first.Tokens, 4)
+	if err != nil {
+		t.Fatalf("ComputeSubtreeTokens failed: %v", err)
+	}
+	if !slices.Equal(changed.Unchanged, []string{"docs"}) || changed.Tokens["music"] == first.Tokens["music"] {
+		t.Errorf("expected music changed, got %+v", changed)
+	}
+}
+
+func TestCheckSubtrees(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "docs", "a.txt"), "a")
+
+	f, err := fs.NewFs(ctx, dir)
+	if err != nil {
+		t.Fatalf("failed to open local fs: %v", err)
+	}
+	store := newTestStore(t)
+	d := NewDeltaService(store)
+	defer d.StopAll()
+	key := "local:" + dir
+
+	// Local directories only change with their own entries
+	if check := d.CheckSubtrees(ctx, f, key); check != nil {
+		t.Fatalf("expected no subtree check on a local disk, got %+v", check)
+	}
+	if probe, _, _ := store.GetSubtrees(key); probe != SubtreesUnreliable {
+		t.Fatalf("expected the probe result stored, got %q", probe)
+	}
+
+	// With reliable metadata, committed tokens let unchanged subtrees be skipped
+	if err := store.SetSubtreeProbe(key, SubtreesReliable); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.CommitFullSync(f, key); err != nil {
+		t.Fatalf("CommitFullSync failed: %v", err)
+	}
+	check := d.CheckSubtrees(ctx, f, key)
+	if check == nil || len(check.Unchanged) != 0 {
+		t.Fatalf("expected nothing unchanged before tokens were committed, got %+v", check)
+	}
+	d.CommitSubtrees(key, check)
+	if check := d.CheckSubtrees(ctx, f, key); check == nil || !slices.Equal(check.Unchanged, []string{"docs"}) {
+		t.Errorf("expected docs unchanged, got %+v", check)
+	}
+}
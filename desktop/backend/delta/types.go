@@ -15,13 +15,19 @@ const (
 	ChangeModified ChangeType = iota
 	// ChangeDeleted indicates a file/dir was removed.
 	ChangeDeleted
+	// ChangeRenamed indicates a file moved from OldPath to Path.
+	// Only detected when the provider exposes stable object IDs.
+	ChangeRenamed
 )
 
 // FileChange represents a single detected change on a remote.
 type FileChange struct {
 	Path       string       // Relative path from the remote root
+	OldPath    string       // Previous path, set for ChangeRenamed
 	EntryType  fs.EntryType // fs.EntryDirectory or fs.EntryObject
 	Type       ChangeType
+	ObjectID   string // Provider object ID, if the provider exposes one
+	Created    bool   // Object was created after the buffer window started
 	DetectedAt time.Time
 }
 
@@ -40,3 +46,55 @@ type DeltaState struct {
 	LastFullSync *time.Time
 	DeltaCount   int
 }
+
+// WatcherStatus reports the health of a remote's watcher.
+type WatcherStatus struct {
+	RemoteKey     string    `json:"remote_key"`
+	Running       bool      `json:"running"`
+	Healthy       bool      `json:"healthy"`
+	NeedsFullSync bool      `json:"needs_full_sync"` // changes may have been missed while the watcher was down
+	LastHeartbeat time.Time `json:"last_heartbeat"`
+	Failures      int       `json:"failures"` // consecutive failed health probes
+	LastError     string    `json:"last_error,omitempty"`
+	Restarts      int       `json:"restarts"`           // consecutive restarts without a healthy probe
+	RetryIn       string    `json:"retry_in,omitempty"` // delay until the next restart, set when down
+	Ignored       int       `json:"ignored,omitempty"`  // Google document changes that left their export unchanged
+}
+
+// Run modes recorded for each sync.
+const (
+	RunModeFull    = "full"    // the whole tree was compared
+	RunModeDelta   = "delta"   // the sync was scoped to watcher changes
+	RunModeSkipped = "skipped" // no changes on either side, nothing ran
+)
+
+// RunInfo describes how a single sync used delta state.
+type RunInfo struct {
+	RemoteKey       string        `json:"remote_key"`
+	Mode            string        `json:"mode"` // RunModeFull, RunModeDelta or RunModeSkipped
+	ChangesScoped   int           `json:"changes_scoped"`
+	SubtreesSkipped int           `json:"subtrees_skipped,omitempty"` // unchanged directories a full sync didn't check
+	FallbackReason  string        `json:"fallback_reason,omitempty"`  // why a full sync ran instead of a delta
+	Duration        time.Duration `json:"duration"`
+	TimeSaved       time.Duration `json:"time_saved"` // estimate against the last full sync
+	FinishedAt      time.Time     `json:"finished_at"`
+}
+
+// DeltaStats summarises delta usage for a remote endpoint.
+type DeltaStats struct {
+	RemoteKey        string         `json:"remote_key"`
+	Provider         string         `json:"provider"`
+	IsWatching       bool           `json:"is_watching"`
+	LastFullSync     *time.Time     `json:"last_full_sync,omitempty"`
+	DeltaCount       int            `json:"delta_count"` // consecutive delta syncs since the last full sync
+	FullRuns         int            `json:"full_runs"`
+	DeltaRuns        int            `json:"delta_runs"`
+	SkippedRuns      int            `json:"skipped_runs"`
+	LastFullDuration time.Duration  `json:"last_full_duration"`
+	TotalTimeSaved   time.Duration  `json:"total_time_saved"`
+	LastMode         string         `json:"last_mode,omitempty"`
+	LastReason       string         `json:"last_reason,omitempty"` // fallback reason of the last full sync
+	LastRun          *RunInfo       `json:"last_run,omitempty"`    // only known for runs since app start
+	PendingChanges   int            `json:"pending_changes"`       // changes collected but not synced yet, including ones kept from before the app last exited
+	Watcher          *WatcherStatus `json:"watcher,omitempty"`
+}
@@ -2,6 +2,7 @@ package delta
 
 import (
 	"context"
+	"errors"
 	"log"
 	"sync"
 	"time"
@@ -9,16 +10,76 @@ import (
 	"github.com/rclone/rclone/fs"
 )
 
+const (
+	// MaxProbeFailures marks a watcher down after this many consecutive failed health probes.
+	MaxProbeFailures = 3
+
+	// StaleHeartbeatPolls marks a watcher unhealthy when no heartbeat arrived
+	// for this many poll intervals (e.g. the health probe is stuck).
+	StaleHeartbeatPolls = 3
+
+	// probeTimeout bounds a single health probe.
+	probeTimeout = 30 * time.Second
+
+	// heartbeatProbeName is looked up on the remote to check it is still reachable.
+	// It normally doesn't exist; "not found" is a successful answer.
+	heartbeatProbeName = ".gn-drive-heartbeat"
+
+	// resolveTimeout bounds the lookup of a changed path used for coalescing.
+	resolveTimeout = 10 * time.Second
+
+	// flushInterval is how often a watcher writes its collected changes to
+	// the store, bounding what a crash loses.
+	flushInterval = 10 * time.Second
+)
+
+// entryInfo is what a lookup of a changed path found on the remote.
+type entryInfo struct {
+	known   bool      // lookup succeeded; false leaves the change as a plain modification
+	exists  bool      // false means the path was deleted
+	id      string    // provider object ID, if exposed
+	created time.Time // creation (birth) time, if exposed
+
+	// Google-native documents are listed under another path than the one
+	// reported (see driveDocument)
+	document bool
+	path     string    // listed path of the document
+	modTime  time.Time // last edit of the document
+}
+
 // Watcher wraps a single remote's ChangeNotify to collect changes in the background.
 type Watcher struct {
 	remoteKey string
 	remoteFs  fs.Fs
 	pollCh    chan time.Duration
 	changes   []FileChange
+	since     time.Time // start of the buffer window (last drain)
 	mu        sync.Mutex
 	running   bool
 	ctx       context.Context
 	cancel    context.CancelFunc
+
+	// Health tracking. ChangeNotify only logs its errors, so a periodic probe
+	// checks that the remote (and its token) still answers.
+	pollInterval  time.Duration
+	lastHeartbeat time.Time
+	failures      int
+	lastErr       error
+	probed        bool // at least one probe succeeded since start
+	needsFullSync bool // changes may have been missed before this watcher started
+	probe         func(ctx context.Context) error
+	onDown        func(w *Watcher, err error)
+	onChange      func(remoteKey string, change FileChange) // called for each detected change
+	ignored       int                                       // document changes that left their export unchanged
+
+	// resolve looks up a changed path for coalescing; nil uses the remote
+	resolve func(ctx context.Context, path string, entryType fs.EntryType) entryInfo
+
+	// The collected changes are flushed to store when dirty; nil keeps
+	// them in memory only
+	store   *DeltaStore
+	dirty   bool
+	flushMu sync.Mutex // orders flushes so an older buffer never overwrites a newer one
 }
 
 // NewWatcher creates a watcher for a remote filesystem.
@@ -48,7 +109,14 @@ func (w *Watcher) Start(parentCtx context.Context, pollInterval time.Duration) {
 	w.ctx, w.cancel = context.WithCancel(parentCtx)
 	w.pollCh = make(chan time.Duration, 1)
 	w.changes = nil
+	w.since = time.Now()
 	w.running = true
+	w.pollInterval = pollInterval
+	w.lastHeartbeat = time.Now()
+	w.failures = 0
+	w.lastErr = nil
+	w.probed = false
+	w.ignored = 0
 
 	// Start ChangeNotify — it spawns its own goroutine internally
 	features.ChangeNotify(w.ctx, w.notifyCallback, w.pollCh)
@@ -60,20 +128,216 @@ func (w *Watcher) Start(parentCtx context.Context, pollInterval time.Duration) {
 	// Send the initial poll interval (outside mutex)
 	pollCh <- pollInterval
 
+	go w.monitor(w.ctx, pollInterval)
+	if w.store != nil {
+		go w.flushLoop(w.ctx)
+	}
+
 	log.Printf("[delta-watcher] %s: started with poll interval %v", w.remoteKey, pollInterval)
 }
 
 // notifyCallback is called by ChangeNotify for each detected change.
+// The path is looked up so the change can be coalesced with earlier ones
+// (deletes, create+delete pairs, renames).
 func (w *Watcher) notifyCallback(path string, entryType fs.EntryType) {
-	w.mu.Lock()
-	defer w.mu.Unlock()
-
-	w.changes = append(w.changes, FileChange{
+	now := time.Now()
+	change := FileChange{
 		Path:       path,
 		EntryType:  entryType,
 		Type:       ChangeModified,
-		DetectedAt: time.Now(),
-	})
+		DetectedAt: now,
+	}
+
+	w.mu.Lock()
+	ctx, since, buffered := w.ctx, w.since, len(w.changes)
+	w.mu.Unlock()
+
+	// Past the fallback threshold a full sync runs anyway; skip the lookups
+	if ctx != nil && buffered < MaxChangesBeforeFallback {
+		info := w.resolveChange(ctx, path, entryType)
+		if info.document {
+			// Opening, sharing or commenting on a document is reported too,
+			// but only an edit since the last sync changes its export
+			if !info.modTime.After(since) {
+				w.mu.Lock()
+				w.ignored++
+				w.lastHeartbeat = now
+				w.mu.Unlock()
+				return
+			}
+			change.Path = info.path
+		}
+		if info.known {
+			if !info.exists {
+				change.Type = ChangeDeleted
+			}
+			change.ObjectID = info.id
+			change.Created = !info.created.IsZero() && info.created.After(since)
+		}
+	}
+
+	w.mu.Lock()
+	w.changes = coalesceChange(w.changes, change)
+	w.dirty = true
+	w.lastHeartbeat = now
+	onChange := w.onChange
+	w.mu.Unlock()
+
+	if onChange != nil {
+		onChange(w.remoteKey, change)
+	}
+}
+
+// resolveChange looks up a changed path on the remote.
+func (w *Watcher) resolveChange(ctx context.Context, path string, entryType fs.EntryType) entryInfo {
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+
+	if w.resolve != nil {
+		return w.resolve(ctx, path, entryType)
+	}
+
+	// Directories can't be looked up cheaply; their scope rule covers the contents
+	if entryType != fs.EntryObject {
+		return entryInfo{}
+	}
+
+	obj, err := w.remoteFs.NewObject(ctx, path)
+	if errors.Is(err, fs.ErrorObjectNotFound) {
+		if info, ok := driveDocument(ctx, w.remoteFs, path); ok {
+			return info
+		}
+		return entryInfo{known: true}
+	}
+	if err != nil {
+		return entryInfo{}
+	}
+
+	info := entryInfo{known: true, exists: true}
+	if idr, ok := obj.(fs.IDer); ok {
+		info.id = idr.ID()
+	}
+	if metadata, err := fs.GetMetadata(ctx, obj); err == nil {
+		if btime, ok := metadata["btime"]; ok {
+			if t, err := time.Parse(time.RFC3339Nano, btime); err == nil {
+				info.created = t
+			}
+		}
+	}
+	return info
+}
+
+// monitor probes the remote once per poll interval until the watcher stops.
+func (w *Watcher) monitor(ctx context.Context, pollInterval time.Duration) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+			err := w.runProbe(probeCtx)
+			cancel()
+			if ctx.Err() != nil {
+				return
+			}
+			if w.recordProbe(err, time.Now()) {
+				log.Printf("[delta-watcher] %s: down after %d failed probes: %v", w.remoteKey, MaxProbeFailures, err)
+				w.mu.Lock()
+				onDown := w.onDown
+				w.mu.Unlock()
+				if onDown != nil {
+					onDown(w, err)
+				}
+				return
+			}
+		}
+	}
+}
+
+// runProbe checks the remote is reachable with a single metadata lookup.
+func (w *Watcher) runProbe(ctx context.Context) error {
+	if w.probe != nil {
+		return w.probe(ctx)
+	}
+	_, err := w.remoteFs.NewObject(ctx, heartbeatProbeName)
+	if err == nil || errors.Is(err, fs.ErrorObjectNotFound) || errors.Is(err, fs.ErrorIsDir) || errors.Is(err, fs.ErrorNotAFile) {
+		return nil
+	}
+	return err
+}
+
+// recordProbe records a probe result. Returns true when the watcher has
+// just crossed MaxProbeFailures and should be treated as down.
+func (w *Watcher) recordProbe(err error, at time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err == nil {
+		w.lastHeartbeat = at
+		w.failures = 0
+		w.lastErr = nil
+		w.probed = true
+		return false
+	}
+
+	w.failures++
+	w.lastErr = err
+	return w.failures == MaxProbeFailures
+}
+
+// IsHealthy returns whether the watcher is running, has heartbeated recently
+// and hasn't missed changes. Only a healthy watcher can justify skipping a sync.
+func (w *Watcher) IsHealthy() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.isHealthyLocked(time.Now())
+}
+
+func (w *Watcher) isHealthyLocked(now time.Time) bool {
+	if !w.running || w.needsFullSync || w.failures >= MaxProbeFailures {
+		return false
+	}
+	if w.pollInterval > 0 && now.Sub(w.lastHeartbeat) > StaleHeartbeatPolls*w.pollInterval {
+		return false
+	}
+	return true
+}
+
+// Status returns a snapshot of the watcher's health.
+func (w *Watcher) Status() WatcherStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	status := WatcherStatus{
+		RemoteKey:     w.remoteKey,
+		Running:       w.running,
+		Healthy:       w.isHealthyLocked(time.Now()),
+		NeedsFullSync: w.needsFullSync,
+		LastHeartbeat: w.lastHeartbeat,
+		Failures:      w.failures,
+		Ignored:       w.ignored,
+	}
+	if w.lastErr != nil {
+		status.LastError = w.lastErr.Error()
+	}
+	return status
+}
+
+// hasProbed returns whether a health probe succeeded since the watcher started.
+func (w *Watcher) hasProbed() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.probed
+}
+
+// markFullSynced clears the missed-changes flag after a full sync.
+func (w *Watcher) markFullSynced() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.needsFullSync = false
 }
 
 // HasChanges returns true if any changes have been collected since the last drain.
@@ -94,9 +358,19 @@ func (w *Watcher) DrainChanges() []FileChange {
 
 	changes := w.changes
 	w.changes = nil
+	w.dirty = true
+	w.since = time.Now()
 	return changes
 }
 
+// PendingChanges returns a copy of the changes collected since the last
+// drain, leaving them in the buffer.
+func (w *Watcher) PendingChanges() []FileChange {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]FileChange(nil), w.changes...)
+}
+
 // RestoreChanges prepends previously drained changes back into the buffer.
 // Used when a scoped delta sync fails, so changes are not lost.
 func (w *Watcher) RestoreChanges(changes []FileChange) {
@@ -107,8 +381,73 @@ func (w *Watcher) RestoreChanges(changes []FileChange) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	// Prepend restored changes before any new ones that arrived since the drain
-	w.changes = append(changes, w.changes...)
+	// Put restored changes before any new ones that arrived since the drain,
+	// coalescing the new ones into them
+	merged := make([]FileChange, len(changes), len(changes)+len(w.changes))
+	copy(merged, changes)
+	for _, c := range w.changes {
+		merged = coalesceChange(merged, c)
+	}
+	w.changes = merged
+	w.dirty = true
+
+	// The failed sync didn't cover the restored changes; reopen the window over them
+	for _, c := range changes {
+		if c.DetectedAt.Before(w.since) {
+			w.since = c.DetectedAt
+		}
+	}
+}
+
+// flushLoop flushes the collected changes every flushInterval until the
+// watcher stops.
+func (w *Watcher) flushLoop(ctx context.Context) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.flush()
+		}
+	}
+}
+
+// flush writes the collected changes to the store if they changed since
+// the last flush, replacing the ones written before. A failed write is
+// retried on the next flush.
+func (w *Watcher) flush() {
+	if w.store == nil {
+		return
+	}
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	if !w.dirty {
+		w.mu.Unlock()
+		return
+	}
+	changes := append([]FileChange(nil), w.changes...)
+	w.dirty = false
+	w.mu.Unlock()
+
+	if err := w.store.SaveChanges(w.remoteKey, changes); err != nil {
+		log.Printf("[delta-watcher] %s: failed to save %d changes: %v", w.remoteKey, len(changes), err)
+		w.mu.Lock()
+		w.dirty = true
+		w.mu.Unlock()
+	}
+}
+
+// markDirty makes the next flush write the collected changes even if they
+// didn't change, e.g. after the store dropped them.
+func (w *Watcher) markDirty() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.dirty = true
 }
 
 // IsRunning returns whether the watcher is currently active.
@@ -0,0 +1,176 @@
+package delta
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+)
+
+func TestRecordProbe(t *testing.T) {
+	w := NewWatcher("gdrive:", nil)
+	w.running = true
+	w.pollInterval = time.Minute
+	now := time.Now()
+	w.lastHeartbeat = now
+
+	probeErr := errors.New("oauth2: cannot fetch token")
+	for i := 1; i < MaxProbeFailures; i++ {
+		if w.recordProbe(probeErr, now) {
+			t.Fatalf("watcher reported down after %d failures", i)
+		}
+	}
+	if !w.recordProbe(probeErr, now) {
+		t.Fatalf("expected watcher down after %d failures", MaxProbeFailures)
+	}
+	if w.IsHealthy() {
+		t.Error("expected watcher to be unhealthy after failed probes")
+	}
+	if status := w.Status(); status.LastError == "" || status.Failures != MaxProbeFailures {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	// A successful probe resets the failure count
+	w.recordProbe(nil, time.Now())
+	if !w.IsHealthy() || !w.hasProbed() {
+		t.Error("expected watcher to be healthy after a successful probe")
+	}
+}
+
+func TestIsHealthy(t *testing.T) {
+	w := NewWatcher("gdrive:", nil)
+	w.pollInterval = time.Minute
+	w.lastHeartbeat = time.Now()
+
+	if w.IsHealthy() {
+		t.Error("stopped watcher should not be healthy")
+	}
+
+	w.running = true
+	if !w.IsHealthy() {
+		t.Error("running watcher with a fresh heartbeat should be healthy")
+	}
+
+	w.lastHeartbeat = time.Now().Add(-(StaleHeartbeatPolls + 1) * time.Minute)
+	if w.IsHealthy() {
+		t.Error("watcher with a stale heartbeat should not be healthy")
+	}
+
+	w.lastHeartbeat = time.Now()
+	w.needsFullSync = true
+	if w.IsHealthy() {
+		t.Error("restarted watcher should not be healthy until a full sync")
+	}
+	w.markFullSynced()
+	if !w.IsHealthy() {
+		t.Error("expected watcher to be healthy after a full sync")
+	}
+}
+
+func TestWatcherRestartDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{0, 1 * time.Minute},
+		{1, 2 * time.Minute},
+		{2, 4 * time.Minute},
+		{4, 16 * time.Minute},
+		{5, WatcherRestartMaxDelay},
+		{50, WatcherRestartMaxDelay},
+	}
+	for _, tt := range tests {
+		if got := watcherRestartDelay(tt.attempt); got != tt.want {
+			t.Errorf("watcherRestartDelay(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestHandleWatcherDown(t *testing.T) {
+	store := NewDeltaStore(func() (*sql.DB, error) {
+		return nil, errors.New("no database")
+	})
+	d := NewDeltaService(store)
+	defer d.StopAll()
+
+	var downs []WatcherStatus
+	d.SetWatcherDownHandler(func(status WatcherStatus) {
+		downs = append(downs, status)
+	})
+
+	w := NewWatcher("gdrive:", nil)
+	w.running = true
+	w.pollInterval = time.Minute
+	w.lastHeartbeat = time.Now()
+	d.watchers["gdrive:"] = w
+
+	d.handleWatcherDown(w, errors.New("token expired"))
+
+	if len(downs) != 1 {
+		t.Fatalf("expected 1 watcher_down callback, got %d", len(downs))
+	}
+	if downs[0].RemoteKey != "gdrive:" || downs[0].RetryIn != "1m0s" || downs[0].Restarts != 1 {
+		t.Errorf("unexpected down status: %+v", downs[0])
+	}
+	if w.IsRunning() {
+		t.Error("expected downed watcher to be stopped")
+	}
+	if d.ShouldSkipSync("gdrive:") {
+		t.Error("ShouldSkipSync must not skip while the watcher is down")
+	}
+	if _, ok := d.restartTimers["gdrive:"]; !ok {
+		t.Error("expected a pending restart")
+	}
+
+	// Second failure without a healthy probe in between backs off further
+	d.watchers["gdrive:"] = w
+	w.running = true
+	d.handleWatcherDown(w, errors.New("token expired"))
+	if len(downs) != 2 || downs[1].RetryIn != "2m0s" {
+		t.Errorf("expected backoff to double, got %+v", downs)
+	}
+
+	// A replaced watcher's late failure is ignored
+	d.watchers["gdrive:"] = NewWatcher("gdrive:", nil)
+	d.handleWatcherDown(w, errors.New("token expired"))
+	if len(downs) != 2 {
+		t.Error("expected failure of a replaced watcher to be ignored")
+	}
+}
+
+func TestWatcherFlush(t *testing.T) {
+	store := newTestStore(t)
+	d := NewDeltaService(store)
+	defer d.StopAll()
+
+	const key = "gdrive:"
+	w := NewWatcher(key, nil)
+	w.store = store
+	w.running = true
+	w.pollInterval = time.Minute
+	w.lastHeartbeat = time.Now()
+	d.watchers[key] = w
+	store.RecordFullSync(key, "drive", true)
+
+	w.notifyCallback("a.txt", fs.EntryObject)
+	w.notifyCallback("b.txt", fs.EntryObject)
+	w.flush()
+	if changes, _ := store.GetPendingChanges(key); len(changes) != 2 {
+		t.Fatalf("expected 2 flushed changes, got %+v", changes)
+	}
+
+	// Draining for a sync drains the store too, and a failed sync puts them back
+	set := d.GetChanges(key)
+	if set == nil || len(set.Changes) != 2 {
+		t.Fatalf("expected 2 drained changes, got %+v", set)
+	}
+	if changes, _ := store.GetPendingChanges(key); changes != nil {
+		t.Errorf("expected drained changes to leave the store, got %+v", changes)
+	}
+	d.RestoreChanges(key, set.Changes)
+	if changes, _ := store.GetPendingChanges(key); len(changes) != 2 {
+		t.Errorf("expected restored changes to be stored again, got %+v", changes)
+	}
+}
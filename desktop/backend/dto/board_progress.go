@@ -0,0 +1,19 @@
+package dto
+
+// BoardProgressDTO combines the progress of all cards in a board run
+type BoardProgressDTO struct {
+	BoardId          string  `json:"board_id"`
+	Status           string  `json:"status"`   // "running", "completed", "failed", "cancelled"
+	Progress         float64 `json:"progress"` // 0-100; finished and skipped cards count as done
+	TotalCards       int     `json:"total_cards"`
+	PendingCards     int     `json:"pending_cards"`
+	RunningCards     int     `json:"running_cards"`
+	CompletedCards   int     `json:"completed_cards"`
+	FailedCards      int     `json:"failed_cards"`
+	SkippedCards     int     `json:"skipped_cards"`
+	FilesTransferred int64   `json:"files_transferred"`
+	TotalFiles       int64   `json:"total_files"`
+	BytesTransferred int64   `json:"bytes_transferred"`
+	TotalBytes       int64   `json:"total_bytes"`
+	Errors           int     `json:"errors"`
+}
@@ -28,9 +28,10 @@ type SyncStatusDTO struct {
 	ElapsedTime     string    `json:"elapsed_time"`
 	Action          string             `json:"action"`                      // "pull", "push", "bi", "bi-resync"
 	LogMessages     []string           `json:"log_messages,omitempty"`      // Captured rclone log messages since last emission
-	Transfers       []FileTransferInfo `json:"transfers,omitempty"`         // Per-file transfer info
+	Transfers       []FileTransferInfo `json:"transfers,omitempty"`         // Active transfers and checks (finished files are sent as transfer events)
 	DeltaMode       bool               `json:"delta_mode,omitempty"`        // true if using delta sync optimization
 	DeltaSkipped    bool               `json:"delta_skipped,omitempty"`     // true if sync was skipped (no changes detected)
+	Report          *TransferReport    `json:"report,omitempty"`            // top-N file report, only on the final status of a run
 }
 
 // FileTransferInfo represents a single file's transfer status
@@ -40,8 +41,31 @@ type FileTransferInfo struct {
 	Bytes    int64   `json:"bytes"`
 	Progress float64 `json:"progress"`            // 0-100
 	Status   string  `json:"status"`              // "transferring", "completed", "failed", "checking", "checked"
-	Speed    float64 `json:"speed,omitempty"`     // bytes per second
+	Speed    float64 `json:"speed,omitempty"`     // average bytes per second since the file started
 	Error    string  `json:"error,omitempty"`
+
+	CurrentSpeed float64 `json:"current_speed,omitempty"` // bytes per second over the last few seconds
+	ETA          int64   `json:"eta,omitempty"`           // seconds left at the current speed
+	Stalled      bool    `json:"stalled,omitempty"`       // no bytes moved for utils.FileStallTimeout; see SyncService.RestartFileTransfer
+	StalledMs    int64   `json:"stalled_ms,omitempty"`    // how long no bytes have moved
+	Locked       bool    `json:"locked,omitempty"`        // failed because another application holds the source file open
+}
+
+// TransferReport lists the files worth a look when troubleshooting a slow run
+type TransferReport struct {
+	Slowest     []FileReportEntry `json:"slowest,omitempty"`      // longest transfer time
+	Largest     []FileReportEntry `json:"largest,omitempty"`      // largest size
+	MostRetried []FileReportEntry `json:"most_retried,omitempty"` // most transfer attempts
+}
+
+// FileReportEntry is one file in a TransferReport
+type FileReportEntry struct {
+	Name       string  `json:"name"`
+	Size       int64   `json:"size"`
+	DurationMs int64   `json:"duration_ms"`     // of the last attempt
+	Speed      float64 `json:"speed,omitempty"` // bytes per second of the last attempt
+	Attempts   int     `json:"attempts"`
+	Failed     bool    `json:"failed,omitempty"` // last attempt failed
 }
 
 // ToJSON converts SyncStatusDTO to JSON bytes
@@ -0,0 +1,200 @@
+package errors
+
+import (
+	"desktop/backend/models"
+	"strings"
+)
+
+// Error codes for well-known rclone failures, produced by ClassifyRcloneError
+const (
+	TokenExpired           ErrorCode = "TOKEN_EXPIRED"
+	QuotaExceeded          ErrorCode = "QUOTA_EXCEEDED"
+	LowDiskSpace           ErrorCode = "LOW_DISK_SPACE"
+	RateLimited            ErrorCode = "RATE_LIMITED"
+	PathTooLong            ErrorCode = "PATH_TOO_LONG"
+	ChecksumMismatch       ErrorCode = "CHECKSUM_MISMATCH"
+	DestinationMissing     ErrorCode = "DESTINATION_MISSING"
+	DestinationNotWritable ErrorCode = "DESTINATION_NOT_WRITABLE"
+	FileLocked             ErrorCode = "FILE_LOCKED"
+	RemotePinChanged       ErrorCode = "REMOTE_PIN_CHANGED"
+)
+
+// Remediation action IDs suggested alongside a classified error.
+// The frontend maps these to buttons (e.g. reconnect opens the remote's OAuth flow).
+const (
+	RemedyReconnectRemote   = "reconnect_remote"
+	RemedyFreeSpace         = "free_space"
+	RemedyReduceTransfers   = "reduce_transfers"
+	RemedyRetryLater        = "retry_later"
+	RemedyShortenPaths      = "shorten_paths"
+	RemedyRetry             = "retry"
+	RemedyDiskSettings      = "disk_settings"
+	RemedyCreateDestination = "create_destination"
+	RemedyCheckPermissions  = "check_permissions"
+	RemedyCloseApplications = "close_applications"
+	RemedyLockedFiles       = "locked_files_settings"
+	RemedyReviewPin         = "review_pin"
+)
+
+// knownError is a knowledge base entry: the messages that identify a failure
+// and what to tell the user about it
+type knownError struct {
+	code        ErrorCode
+	title       string
+	remediation string
+	actions     []string
+	patterns    []string // lowercase substrings of rclone error messages
+}
+
+// knowledgeBase lists known failures in match order. Rate limiting comes
+// before quota because providers report both as "...LimitExceeded".
+var knowledgeBase = []knownError{
+	{
+		code:        TokenExpired,
+		title:       "Authorization expired",
+		remediation: "The remote's access token has expired or was revoked. Reconnect the remote to sign in again.",
+		actions:     []string{RemedyReconnectRemote},
+		patterns: []string{
+			"token expired", "token has been expired or revoked", "invalid_grant",
+			"invalid_token", "couldn't fetch token", "oauth2: cannot fetch token",
+			"401 unauthorized", "error 401",
+		},
+	},
+	{
+		code:        RateLimited,
+		title:       "Rate limited by provider",
+		remediation: "The provider is throttling requests. Lower transfers/checkers or set a TPS limit, then retry later.",
+		actions:     []string{RemedyReduceTransfers, RemedyRetryLater},
+		patterns: []string{
+			"ratelimitexceeded", "userratelimitexceeded", "rate limit", "too many requests",
+			"error 429", "429 too many", "slowdown", "throttl", "dailylimitexceeded",
+		},
+	},
+	{
+		code:        LowDiskSpace,
+		title:       "Local disk almost full",
+		remediation: "The sync was stopped before this computer's disk filled up. Free up local disk space or lower the free space threshold in settings, then retry.",
+		actions:     []string{RemedyFreeSpace, RemedyDiskSettings, RemedyRetry},
+		patterns:    []string{"not enough free disk space"},
+	},
+	{
+		code:        QuotaExceeded,
+		title:       "Storage quota exceeded",
+		remediation: "The destination is out of space. Free up space or upgrade the storage plan, then retry.",
+		actions:     []string{RemedyFreeSpace, RemedyRetry},
+		patterns: []string{
+			"storagequotaexceeded", "quota exceeded", "quotaexceeded", "insufficient storage",
+			"insufficient_storage", "no space left on device", "not enough space", "disk full",
+		},
+	},
+	{
+		code:        PathTooLong,
+		title:       "Path too long",
+		remediation: "A file path exceeds the destination's length limit. Shorten folder or file names, or exclude the affected paths.",
+		actions:     []string{RemedyShortenPaths},
+		patterns: []string{
+			"file name too long", "filename too long", "path too long", "name too long",
+			"the filename or extension is too long", "enametoolong",
+		},
+	},
+	// Before checksums: a file written to during its transfer changes under it
+	{
+		code:        FileLocked,
+		title:       "Files in use",
+		remediation: "Some source files are open or locked by another application, such as mail data files or running virtual machines. Close the application and retry, or set the profile to retry locked files at the end of the run or to copy them from a snapshot.",
+		actions:     []string{RemedyCloseApplications, RemedyLockedFiles, RemedyRetry},
+		patterns:    fileLockedPatterns,
+	},
+	{
+		code:        ChecksumMismatch,
+		title:       "Checksum mismatch",
+		remediation: "A file was corrupted in transit or changed during the transfer. Retry; if it persists, run a check on the profile.",
+		actions:     []string{RemedyRetry},
+		patterns: []string{
+			"corrupted on transfer", "hashes differ", "hash differ", "md5 differ",
+			"checksum mismatch", "checksums differ",
+		},
+	},
+	// After quota: a full destination isn't writable either
+	{
+		code:        DestinationMissing,
+		title:       "Destination missing",
+		remediation: "The folder the sync writes to doesn't exist, e.g. a drive that isn't mounted or a folder that was renamed. Create it, or fix the profile's path, then retry.",
+		actions:     []string{RemedyCreateDestination, RemedyRetry},
+		patterns:    []string{"destination does not exist"},
+	},
+	{
+		code:        DestinationNotWritable,
+		title:       "Destination not writable",
+		remediation: "A test file couldn't be written to the folder the sync writes to, e.g. a read-only share. Check the permissions of the folder or the remote's account, then retry.",
+		actions:     []string{RemedyCheckPermissions, RemedyRetry},
+		patterns:    []string{"destination is not writable"},
+	},
+	{
+		code:        RemotePinChanged,
+		title:       "Server identity changed",
+		remediation: "The server presents a different host key or certificate than the one pinned for the remote. If the server was rebuilt or its certificate renewed, check the new fingerprint with its administrator and accept it; otherwise the connection may be intercepted.",
+		actions:     []string{RemedyReviewPin},
+		patterns:    []string{"than the one pinned", "knownhosts: key mismatch"},
+	},
+}
+
+// fileLockedPatterns identify a source file another application holds open
+// or locked, or is writing to during its transfer
+var fileLockedPatterns = []string{
+	"being used by another process",                    // Windows ERROR_SHARING_VIOLATION
+	"another process has locked a portion of the file", // Windows ERROR_LOCK_VIOLATION
+	"source file is being updated",                     // rclone: size or modification time changed while copying
+	"text file busy", "device or resource busy", "resource busy",
+}
+
+// IsFileLocked reports whether an rclone error message is about a source
+// file that is open or locked by another application
+func IsFileLocked(message string) bool {
+	lower := strings.ToLower(message)
+	for _, pattern := range fileLockedPatterns {
+		if strings.Contains(lower, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClassifyRcloneError matches an rclone error message against the knowledge
+// base. Returns nil if the message isn't recognised.
+func ClassifyRcloneError(message string) *models.ErrorInfo {
+	if message == "" {
+		return nil
+	}
+	lower := strings.ToLower(message)
+	for _, known := range knowledgeBase {
+		for _, pattern := range known.patterns {
+			if strings.Contains(lower, pattern) {
+				return known.info()
+			}
+		}
+	}
+	return nil
+}
+
+// LookupErrorInfo returns the knowledge base entry for an error code,
+// or nil if the code is unknown
+func LookupErrorInfo(code string) *models.ErrorInfo {
+	for _, known := range knowledgeBase {
+		if string(known.code) == code {
+			return known.info()
+		}
+	}
+	return nil
+}
+
+func (k knownError) info() *models.ErrorInfo {
+	actions := make([]string, len(k.actions))
+	copy(actions, k.actions)
+	return &models.ErrorInfo{
+		Code:        string(k.code),
+		Title:       k.title,
+		Remediation: k.remediation,
+		Actions:     actions,
+	}
+}
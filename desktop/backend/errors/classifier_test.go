@@ -0,0 +1,68 @@
+package errors
+
+import "testing"
+
+func TestClassifyRcloneError(t *testing.T) {
+	tests := []struct {
+		message string
+		want    ErrorCode // empty = unrecognised
+	}{
+		{`couldn't list directory: oauth2: cannot fetch token: 400 Bad Request Response: {"error": "invalid_grant"}`, TokenExpired},
+		{"Token has been expired or revoked.", TokenExpired},
+		{"googleapi: Error 403: User Rate Limit Exceeded. Rate of requests for user exceed configured project quota., userRateLimitExceeded", RateLimited},
+		{"HTTP error 429 (429 Too Many Requests)", RateLimited},
+		{"SlowDown: Please reduce your request rate", RateLimited},
+		{"googleapi: Error 403: The user's Drive storage quota has been exceeded., storageQuotaExceeded", QuotaExceeded},
+		{"write /mnt/backup/file.bin: no space left on device", QuotaExceeded},
+		{"sync failed: not enough free disk space on /mnt/backup: 512 MiB free, 1 GiB required", LowDiskSpace},
+		{"open /very/long/path: file name too long", PathTooLong},
+		{"The filename or extension is too long.", PathTooLong},
+		{"corrupted on transfer: md5 hashes differ src(s3) \"a\" vs dst(local) \"b\"", ChecksumMismatch},
+		{"sync failed: destination does not exist: /mnt/nas/backup; create it, then run again", DestinationMissing},
+		{"sync failed: destination is not writable: smb:share; check its permissions, then run again: permission denied", DestinationNotWritable},
+		{"sync failed: destination is not writable: /mnt/usb; check its permissions, then run again: no space left on device", QuotaExceeded},
+		{"sync failed: remote nas presents a different SSH host key than the one pinned (pinned SHA256:a, now SHA256:b)", RemotePinChanged},
+		{"couldn't connect SSH: ssh: handshake failed: knownhosts: key mismatch", RemotePinChanged},
+		{"Failed to copy: failed to open source object: The process cannot access the file because it is being used by another process.", FileLocked},
+		{"Failed to copy: can't copy - source file is being updated (size changed from 1048576 to 2097152)", FileLocked},
+		{"directory not found", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		got := ClassifyRcloneError(tt.message)
+		if tt.want == "" {
+			if got != nil {
+				t.Errorf("ClassifyRcloneError(%q) = %s, want nil", tt.message, got.Code)
+			}
+			continue
+		}
+		if got == nil {
+			t.Errorf("ClassifyRcloneError(%q) = nil, want %s", tt.message, tt.want)
+			continue
+		}
+		if got.Code != string(tt.want) {
+			t.Errorf("ClassifyRcloneError(%q) = %s, want %s", tt.message, got.Code, tt.want)
+		}
+		if got.Remediation == "" || len(got.Actions) == 0 {
+			t.Errorf("ClassifyRcloneError(%q) returned no remediation", tt.message)
+		}
+	}
+}
+
+func TestLookupErrorInfo(t *testing.T) {
+	info := LookupErrorInfo(string(QuotaExceeded))
+	if info == nil || info.Title == "" {
+		t.Fatalf("LookupErrorInfo(%s) = %v, want entry", QuotaExceeded, info)
+	}
+
+	// Returned actions must not alias the knowledge base
+	info.Actions[0] = "changed"
+	if again := LookupErrorInfo(string(QuotaExceeded)); again.Actions[0] == "changed" {
+		t.Error("LookupErrorInfo returned a shared Actions slice")
+	}
+
+	if LookupErrorInfo("UNKNOWN") != nil {
+		t.Error("Expected nil for unknown code")
+	}
+}
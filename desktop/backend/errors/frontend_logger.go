@@ -2,10 +2,10 @@ package errors
 
 import (
 	"desktop/backend/models"
+	"desktop/backend/utils"
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
 	"sync"
 	"time"
 )
@@ -42,12 +42,7 @@ func NewFrontendLogger(debug bool) *FrontendLogger {
 
 // getFrontendLogFile returns the path for frontend log file
 func getFrontendLogFile() string {
-	wd, err := os.Getwd()
-	if err != nil {
-		log.Printf("Failed to get working directory: %v", err)
-		return "gn-drive-frontend.log"
-	}
-	return filepath.Join(wd, "gn-drive-frontend.log")
+	return utils.LogFilePath("gn-drive-frontend.log")
 }
 
 // LogEntry logs a frontend log entry
@@ -2,11 +2,11 @@ package errors
 
 import (
 	"context"
+	"desktop/backend/utils"
 	"encoding/json"
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
 	"runtime/debug"
 	"time"
 )
@@ -31,13 +31,7 @@ func NewErrorHandler(debug bool) *ErrorHandler {
 
 // getLogFile returns a file handle for logging errors
 func getLogFile() *os.File {
-	wd, err := os.Getwd()
-	if err != nil {
-		log.Printf("Failed to get working directory: %v", err)
-		return os.Stderr
-	}
-
-	logPath := filepath.Join(wd, "gn-drive-errors.log")
+	logPath := utils.LogFilePath("gn-drive-errors.log")
 	file, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		log.Printf("Failed to open log file: %v", err)
@@ -126,12 +126,42 @@ func (b *WailsEventBus) EmitHistoryEvent(event *HistoryEvent) error {
 	return b.Emit(event)
 }
 
+// EmitSettingsEvent is a convenience method for settings events
+func (b *WailsEventBus) EmitSettingsEvent(event *SettingsEvent) error {
+	return b.Emit(event)
+}
+
 // EmitCryptEvent is a convenience method for crypt events
 func (b *WailsEventBus) EmitCryptEvent(event *CryptEvent) error {
 	return b.Emit(event)
 }
 
+// EmitMountEvent is a convenience method for mount events
+func (b *WailsEventBus) EmitMountEvent(event *MountEvent) error {
+	return b.Emit(event)
+}
+
 // EmitBoardEvent is a convenience method for board events
 func (b *WailsEventBus) EmitBoardEvent(event *BoardEvent) error {
 	return b.Emit(event)
 }
+
+// EmitNotificationEvent is a convenience method for notification events
+func (b *WailsEventBus) EmitNotificationEvent(event *NotificationEvent) error {
+	return b.Emit(event)
+}
+
+// EmitIntegrationEvent is a convenience method for integration events
+func (b *WailsEventBus) EmitIntegrationEvent(event *IntegrationEvent) error {
+	return b.Emit(event)
+}
+
+// EmitDeltaEvent is a convenience method for delta watcher events
+func (b *WailsEventBus) EmitDeltaEvent(event *DeltaEvent) error {
+	return b.Emit(event)
+}
+
+// EmitTransferEvent is a convenience method for per-file transfer events
+func (b *WailsEventBus) EmitTransferEvent(event *TransferEvent) error {
+	return b.Emit(event)
+}
@@ -7,11 +7,19 @@ type EventType string
 
 const (
 	// Sync Events
-	SyncStarted   EventType = "sync:started"
-	SyncProgress  EventType = "sync:progress"
-	SyncCompleted EventType = "sync:completed"
-	SyncFailed    EventType = "sync:failed"
-	SyncCancelled EventType = "sync:cancelled"
+	SyncStarted     EventType = "sync:started"
+	SyncProgress    EventType = "sync:progress"
+	SyncCompleted   EventType = "sync:completed"
+	SyncFailed      EventType = "sync:failed"
+	SyncCancelled   EventType = "sync:cancelled"
+	SyncQueued      EventType = "sync:queued"
+	SyncPaused      EventType = "sync:paused"
+	SyncResumed     EventType = "sync:resumed"
+	SyncInterrupted EventType = "sync:interrupted" // a run the app last exited during was found at startup
+	SyncFailover    EventType = "sync:failover"    // a run went to the profile's failover destination
+	SyncReconciled  EventType = "sync:reconciled"  // a profile's primary destination is up to date again after a failover
+	SyncRestored    EventType = "sync:restored"    // a restore ended; its report is in GetRestoreReports
+	SyncConflict    EventType = "sync:conflict"    // a push or pull left out files changed on both sides; see GetSyncConflicts
 
 	// Config Events
 	ConfigUpdated  EventType = "config:updated"
@@ -25,6 +33,9 @@ const (
 	RemoteDeleted EventType = "remote:deleted"
 	RemotesList   EventType = "remotes:list"
 
+	// RemotePinChanged: a pinned remote's server presents a different host key or certificate
+	RemotePinChanged EventType = "remote:pin_changed"
+
 	// Tab Events
 	TabCreated EventType = "tab:created"
 	TabUpdated EventType = "tab:updated"
@@ -48,15 +59,47 @@ const (
 	ScheduleUpdated   EventType = "schedule:updated"
 	ScheduleDeleted   EventType = "schedule:deleted"
 	ScheduleTriggered EventType = "schedule:triggered"
+	ScheduleSkipped   EventType = "schedule:skipped"
+	ScheduleQueued    EventType = "schedule:queued"
+	ScheduleCompleted EventType = "schedule:completed"
+	ScheduleVerified  EventType = "schedule:verified"
+	ScheduleForecast  EventType = "schedule:forecast"
+	ScheduleDrift     EventType = "schedule:drift"
+
+	// Notification Events
+	NotificationSent   EventType = "notification:sent"
+	NotificationAction EventType = "notification:action"
+
+	// Settings Events
+	SettingsChanged EventType = "settings:changed"
+
+	// Integration Events (OS shell / URL scheme)
+	IntegrationFolderRequested EventType = "integration:folder_requested"
+
+	// Transfer Events (per-file lifecycle, emitted once per transition)
+	TransferStarted    EventType = "transfer:started"
+	TransferProgressed EventType = "transfer:progressed"
+	TransferStalled    EventType = "transfer:stalled"
+	TransferCompleted  EventType = "transfer:completed"
+	TransferFailed     EventType = "transfer:failed"
+
+	// Delta Events (change-notification watchers)
+	DeltaWatcherDown EventType = "delta:watcher_down"
+	DeltaChange      EventType = "delta:change"
 
 	// History Events
 	HistoryAdded   EventType = "history:added"
 	HistoryCleared EventType = "history:cleared"
+	HistoryUpdated EventType = "history:updated"
 
 	// Crypt Events
 	CryptRemoteCreated EventType = "crypt:created"
 	CryptRemoteDeleted EventType = "crypt:deleted"
 
+	// Mount Events
+	MountMounted   EventType = "mount:mounted"
+	MountUnmounted EventType = "mount:unmounted"
+
 	// Board Events
 	BoardUpdated            EventType = "board:updated"
 	BoardExecutionStarted   EventType = "board:execution:started"
@@ -301,6 +344,26 @@ func NewHistoryEvent(eventType EventType, data interface{}) *HistoryEvent {
 	}
 }
 
+// SettingsEvent represents app settings events
+type SettingsEvent struct {
+	BaseEvent
+	Keys     []string `json:"keys"`
+	Revision int64    `json:"revision"`
+}
+
+// NewSettingsEvent creates a new settings event
+func NewSettingsEvent(eventType EventType, keys []string, revision int64, data interface{}) *SettingsEvent {
+	return &SettingsEvent{
+		BaseEvent: BaseEvent{
+			Type:      eventType,
+			Timestamp: time.Now(),
+			Data:      data,
+		},
+		Keys:     keys,
+		Revision: revision,
+	}
+}
+
 // BoardEvent represents board flow events
 type BoardEvent struct {
 	BaseEvent
@@ -341,3 +404,99 @@ func NewCryptEvent(eventType EventType, remoteName string, data interface{}) *Cr
 		RemoteName: remoteName,
 	}
 }
+
+// MountEvent represents remote mount events
+type MountEvent struct {
+	BaseEvent
+	MountPoint string `json:"mountPoint"`
+}
+
+// NewMountEvent creates a new mount event
+func NewMountEvent(eventType EventType, mountPoint string, data interface{}) *MountEvent {
+	return &MountEvent{
+		BaseEvent: BaseEvent{
+			Type:      eventType,
+			Timestamp: time.Now(),
+			Data:      data,
+		},
+		MountPoint: mountPoint,
+	}
+}
+
+// NotificationEvent represents desktop notification events
+type NotificationEvent struct {
+	BaseEvent
+	NotificationId string `json:"notificationId"`
+}
+
+// NewNotificationEvent creates a new notification event
+func NewNotificationEvent(eventType EventType, notificationId string, data interface{}) *NotificationEvent {
+	return &NotificationEvent{
+		BaseEvent: BaseEvent{
+			Type:      eventType,
+			Timestamp: time.Now(),
+			Data:      data,
+		},
+		NotificationId: notificationId,
+	}
+}
+
+// IntegrationEvent represents OS integration events (context menu, URL scheme)
+type IntegrationEvent struct {
+	BaseEvent
+	RequestId string `json:"requestId"`
+}
+
+// NewIntegrationEvent creates a new integration event
+func NewIntegrationEvent(eventType EventType, requestId string, data interface{}) *IntegrationEvent {
+	return &IntegrationEvent{
+		BaseEvent: BaseEvent{
+			Type:      eventType,
+			Timestamp: time.Now(),
+			Data:      data,
+		},
+		RequestId: requestId,
+	}
+}
+
+// DeltaEvent represents delta watcher events
+type DeltaEvent struct {
+	BaseEvent
+	RemoteKey string `json:"remoteKey"`
+}
+
+// NewDeltaEvent creates a new delta watcher event
+func NewDeltaEvent(eventType EventType, remoteKey string, data interface{}) *DeltaEvent {
+	return &DeltaEvent{
+		BaseEvent: BaseEvent{
+			Type:      eventType,
+			Timestamp: time.Now(),
+			Data:      data,
+		},
+		RemoteKey: remoteKey,
+	}
+}
+
+// TransferEvent represents a per-file transfer lifecycle event
+type TransferEvent struct {
+	BaseEvent
+	TaskId int    `json:"taskId"`
+	TabId  string `json:"tabId,omitempty"`
+	Action string `json:"action"`
+	Name   string `json:"name"`
+}
+
+// NewTransferEvent creates a new transfer lifecycle event
+func NewTransferEvent(eventType EventType, taskId int, tabId, action, name string, data interface{}) *TransferEvent {
+	return &TransferEvent{
+		BaseEvent: BaseEvent{
+			Type:      eventType,
+			Timestamp: time.Now(),
+			Data:      data,
+		},
+		TaskId: taskId,
+		TabId:  tabId,
+		Action: action,
+		Name:   name,
+	}
+}
@@ -1,6 +1,15 @@
 package models
 
-import "time"
+import (
+	"desktop/backend/dto"
+	"time"
+)
+
+// Board execution modes: how the cards of a column run
+const (
+	BoardModeParallel   = "parallel"   // cards of a column run at the same time (default)
+	BoardModeSequential = "sequential" // cards of a column run one after another
+)
 
 // BoardNode represents a remote storage endpoint on the board canvas
 type BoardNode struct {
@@ -19,6 +28,12 @@ type BoardEdge struct {
 	TargetId   string  `json:"target_id"`
 	Action     string  `json:"action"` // "pull","push","bi","bi-resync"
 	SyncConfig Profile `json:"sync_config"`
+
+	// Minutes after a scheduled run of the board starts that the card may
+	// start, e.g. 60 for a card that runs at 03:00 on a board scheduled at
+	// 02:00. The card still waits for the cards before it; manual runs
+	// start it right away.
+	StartOffsetMinutes int `json:"start_offset_minutes,omitempty"`
 }
 
 // Board represents a complete flow definition
@@ -37,6 +52,24 @@ type Board struct {
 	LastRun         *time.Time `json:"last_run,omitempty"`
 	NextRun         *time.Time `json:"next_run,omitempty"`
 	LastResult      string     `json:"last_result,omitempty"` // "success", "failed", "cancelled"
+
+	// Execution settings
+	ExecutionMode string `json:"execution_mode,omitempty"` // BoardModeParallel (default) or BoardModeSequential
+	MaxParallel   int    `json:"max_parallel,omitempty"`   // cards running at once per column in parallel mode; 0 = unlimited
+
+	// Minutes a scheduled run may start cards in: cards that haven't started
+	// by then are skipped and the run fails. Cards already running finish.
+	// 0 = no limit.
+	WindowMinutes int `json:"window_minutes,omitempty"`
+
+	// Retry budget shared by all cards of a run: once a card is refused a
+	// retry, the cards that haven't started are skipped and the run fails.
+	// 0 = no limit; with both unset each card retries on its own.
+	MaxRetries      int `json:"max_retries,omitempty"`       // retries of all cards together
+	MaxRetrySeconds int `json:"max_retry_seconds,omitempty"` // time spent retrying by all cards together
+
+	// Webhooks are told when a run of the board starts and finishes
+	Webhooks []RunWebhook `json:"webhooks,omitempty"`
 }
 
 // BoardExecutionStatus represents the status of a running board flow
@@ -46,6 +79,13 @@ type BoardExecutionStatus struct {
 	EdgeStatuses []EdgeExecutionStatus `json:"edge_statuses"`
 	StartTime    time.Time             `json:"start_time"`
 	EndTime      *time.Time            `json:"end_time,omitempty"`
+	Progress     *dto.BoardProgressDTO `json:"progress,omitempty"` // combined progress of all cards
+
+	// Retry budget use, when the board has one; set when the run ends
+	Retries         int    `json:"retries,omitempty"`
+	RetrySeconds    int    `json:"retry_seconds,omitempty"`
+	BudgetExhausted bool   `json:"budget_exhausted,omitempty"`
+	Summary         string `json:"summary,omitempty"` // why the run failed, when it didn't just have failed cards
 }
 
 // EdgeExecutionStatus represents the status of a single edge execution
@@ -56,4 +96,5 @@ type EdgeExecutionStatus struct {
 	Message   string     `json:"message,omitempty"`
 	StartTime *time.Time `json:"start_time,omitempty"`
 	EndTime   *time.Time `json:"end_time,omitempty"`
+	NotBefore *time.Time `json:"not_before,omitempty"` // when a pending card's start offset lets it start
 }
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// BoardTemplate is a reusable board structure. Any text in the board may
+// contain ${VARIABLE} placeholders that are filled in when a board is
+// created from the template.
+type BoardTemplate struct {
+	Id          string                  `json:"id"`
+	Name        string                  `json:"name"`
+	Description string                  `json:"description,omitempty"`
+	Variables   []BoardTemplateVariable `json:"variables"`
+	Board       Board                   `json:"board"` // node and edge IDs are replaced on instantiation
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+}
+
+// BoardTemplateVariable describes a placeholder used in a board template
+type BoardTemplateVariable struct {
+	Name        string `json:"name"` // e.g. "CLIENT_NAME" for ${CLIENT_NAME}
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"` // used when no value is given
+}
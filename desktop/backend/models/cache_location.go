@@ -0,0 +1,34 @@
+package models
+
+// Cache location IDs
+const (
+	CacheLocationTemp    = "temp"    // rclone temp files, e.g. uploads buffered to disk
+	CacheLocationRclone  = "rclone"  // rclone cache dir: VFS cache, bisync state and backend caches
+	CacheLocationStaging = "staging" // archives built by flow archive steps before upload
+	CacheLocationListing = "listing" // cached remote directory listings for offline browsing (in the database)
+)
+
+// CacheLocation is a directory the app writes temporary or cached data to
+type CacheLocation struct {
+	Id          string `json:"id"`
+	Path        string `json:"path"`                   // directory in use
+	DefaultPath string `json:"default_path,omitempty"` // used when Path is not configured
+	MaxSize     string `json:"max_size,omitempty"`     // oldest files are removed above this size, e.g. "10G"; empty = no cap
+	UsedBytes   int64  `json:"used_bytes"`
+	Files       int    `json:"files"`
+}
+
+// CacheClearResult reports the space a cache clear or size cap reclaimed in one location
+type CacheClearResult struct {
+	Id             string `json:"id"`
+	Path           string `json:"path,omitempty"`
+	ReclaimedBytes int64  `json:"reclaimed_bytes"`
+	FilesRemoved   int    `json:"files_removed"`
+	Error          string `json:"error,omitempty"`
+}
+
+// CacheLocationSetting is the configured directory and size cap of a cache location
+type CacheLocationSetting struct {
+	Path    string `json:"path,omitempty"`     // empty uses the default directory
+	MaxSize string `json:"max_size,omitempty"` // e.g. "10G"; empty = no cap
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Change feed event types
+const (
+	ChangeFeedCreated  = "created"
+	ChangeFeedModified = "modified"
+	ChangeFeedDeleted  = "deleted"
+	ChangeFeedRenamed  = "renamed"
+)
+
+// ChangeFeedEvent is a change a delta watcher detected on a remote, in the
+// form the change feed hands to external tools
+type ChangeFeedEvent struct {
+	Seq        int64     `json:"seq"`                // increases by one per event since the app started
+	Remote     string    `json:"remote"`             // the watched remote and root, e.g. "gdrive:Photos"
+	Path       string    `json:"path"`               // relative to Remote
+	OldPath    string    `json:"old_path,omitempty"` // previous path of a rename
+	FullPath   string    `json:"full_path"`          // Remote and Path joined, e.g. "gdrive:Photos/2024/a.jpg"
+	Type       string    `json:"type"`               // ChangeFeed*
+	IsDir      bool      `json:"is_dir,omitempty"`
+	DetectedAt time.Time `json:"detected_at"`
+}
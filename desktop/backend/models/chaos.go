@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// ChaosConfig sets which faults chaos mode injects into managed runs.
+// Rates are fractions of operations, from 0 to 1.
+type ChaosConfig struct {
+	NetworkErrorRate     float64 `json:"network_error_rate"`     // operations failing with a dropped connection
+	RateLimitRate        float64 `json:"rate_limit_rate"`        // operations answered with a 429 rate limit
+	TransferCutRate      float64 `json:"transfer_cut_rate"`      // uploads cut off halfway through
+	CancelAfterTransfers int     `json:"cancel_after_transfers"` // cancel the run once this many uploads started; 0 = never
+	Seed                 int64   `json:"seed,omitempty"`         // repeats the same faults for the same run; 0 = random
+}
+
+// ChaosCounts counts the operations chaos mode saw and the faults it injected
+type ChaosCounts struct {
+	Operations    int  `json:"operations"`
+	Transfers     int  `json:"transfers"`
+	NetworkErrors int  `json:"network_errors"`
+	RateLimits    int  `json:"rate_limits"`
+	TransferCuts  int  `json:"transfer_cuts"`
+	Cancelled     bool `json:"cancelled"`
+}
+
+// ResilienceReport is how a managed run coped with the faults chaos mode injected
+type ResilienceReport struct {
+	TaskId           int         `json:"task_id"`
+	ProfileName      string      `json:"profile_name"`
+	Action           string      `json:"action"`
+	Config           ChaosConfig `json:"config"`
+	Injected         ChaosCounts `json:"injected"`
+	Status           string      `json:"status"`    // final task status: "completed", "failed", "cancelled", "paused"
+	Recovered        bool        `json:"recovered"` // completed despite injected faults
+	Error            string      `json:"error,omitempty"`
+	FilesTransferred int64       `json:"files_transferred"`
+	Errors           int         `json:"errors"`
+	FailedFiles      int         `json:"failed_files"`  // files still failed at the end, retryable via RetryFailedFiles
+	RetriedFiles     int         `json:"retried_files"` // files among the transfer report's most retried that needed more than one attempt
+	StartTime        time.Time   `json:"start_time"`
+	EndTime          time.Time   `json:"end_time"`
+}
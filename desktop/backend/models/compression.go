@@ -0,0 +1,34 @@
+package models
+
+// CompressionEstimate samples how well a profile's source files would
+// compress on its destination with compression wrapping (Profile.CompressDest)
+type CompressionEstimate struct {
+	ProfileName         string                 `json:"profile_name"`
+	Files               int64                  `json:"files"`
+	Bytes               int64                  `json:"bytes"`
+	EstimatedBytes      int64                  `json:"estimated_bytes"`      // estimated size on the destination
+	Ratio               float64                `json:"ratio"`                // Bytes / EstimatedBytes
+	IncompressibleBytes int64                  `json:"incompressible_bytes"` // in types that would be stored uncompressed
+	Types               []CompressionTypeStats `json:"types"`                // by file extension, most bytes first
+}
+
+// CompressionTypeStats is the sample of one file type in a CompressionEstimate
+type CompressionTypeStats struct {
+	Extension    string  `json:"extension"` // lower case with the dot, e.g. ".jpg"; "" for files without one
+	Files        int64   `json:"files"`
+	Bytes        int64   `json:"bytes"`
+	Sampled      int     `json:"sampled"`           // files whose start was read; 0 for known compressed formats
+	Entropy      float64 `json:"entropy,omitempty"` // mean bits per byte of the samples, 0 to 8
+	Ratio        float64 `json:"ratio,omitempty"`   // mean compression ratio of the samples
+	Compressible bool    `json:"compressible"`
+}
+
+// CompressionRun is the compression a push achieved on the files it wrote
+// to a destination with compression wrapping
+type CompressionRun struct {
+	Files         int64   `json:"files"`
+	OriginalBytes int64   `json:"original_bytes"`
+	StoredBytes   int64   `json:"stored_bytes"`
+	Ratio         float64 `json:"ratio"`        // OriginalBytes / StoredBytes
+	Uncompressed  int64   `json:"uncompressed"` // files stored as they are because they didn't compress
+}
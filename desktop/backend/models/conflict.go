@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// ConflictNaming is how a bisync names the copies it keeps of files changed
+// on both sides. Without hostname or timestamp the copies are named like
+// rclone does, e.g. "report.docx.conflict1".
+type ConflictNaming struct {
+	Hostname  bool   `json:"hostname,omitempty"`  // add this computer's name, e.g. "report.docx.laptop-conflict1"
+	Timestamp bool   `json:"timestamp,omitempty"` // add the date and time of the run, e.g. "report.docx.20240501-120000-conflict1"
+	Folder    string `json:"folder,omitempty"`    // move the copies into this folder at the root of each side, keeping their directories, e.g. "_conflicts"
+}
+
+// ConflictCopy is a copy of a conflicting file a bisync kept
+type ConflictCopy struct {
+	Side     string    `json:"side"`     // "from" or "to": the profile path it is on
+	Path     string    `json:"path"`     // relative to the side's root
+	Original string    `json:"original"` // the file it is a copy of, relative to the side's root
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"mod_time"`
+}
+
+// SyncConflict is a file a push or pull of a profile with DetectConflicts
+// left out because it changed on both sides since the profile's last run.
+// Later runs leave it out too until it is resolved.
+type SyncConflict struct {
+	Id            int64     `json:"id"`
+	ProfileName   string    `json:"profile_name"`
+	Action        string    `json:"action"` // "push" or "pull"
+	Source        string    `json:"source"` // root the run reads from
+	Dest          string    `json:"dest"`   // root the run writes to
+	Path          string    `json:"path"`   // relative to both roots
+	SourceSize    int64     `json:"source_size"`
+	SourceModTime time.Time `json:"source_mod_time"`
+	DestSize      int64     `json:"dest_size"`
+	DestModTime   time.Time `json:"dest_mod_time"`
+	DetectedAt    time.Time `json:"detected_at"`
+}
+
+// Ways to resolve a SyncConflict
+const (
+	ConflictKeepSource = "keep_source" // the source's version overwrites the destination's
+	ConflictKeepDest   = "keep_dest"   // the destination's version overwrites the source's
+	ConflictKeepBoth   = "keep_both"   // the destination's version is kept as a conflict copy on both sides
+)
@@ -0,0 +1,18 @@
+package models
+
+// Directory sync states, in increasing precedence
+const (
+	DirInSync   = "in_sync"  // nothing below it changed since the last run, which completed
+	DirPending  = "pending"  // changes below it wait for the next run, or the profile never completed one
+	DirError    = "error"    // files below it failed in the last run
+	DirExcluded = "excluded" // the profile's include/exclude rules leave it out
+)
+
+// DirectoryStatus is the sync state of a directory of a profile's source,
+// for badging folders in the file browser
+type DirectoryStatus struct {
+	Path    string `json:"path"`              // relative to the profile's source; "" is the source itself
+	State   string `json:"state"`             // DirInSync, DirPending, DirError or DirExcluded
+	Pending int    `json:"pending,omitempty"` // changes below it no run picked up yet
+	Errors  int    `json:"errors,omitempty"`  // files below it that failed in the last run
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Kinds of differences a drift check finds between a source and its mirror
+const (
+	DriftNew     = "new"     // in the source, not yet on the destination
+	DriftChanged = "changed" // on both sides, with different contents
+	DriftExtra   = "extra"   // on the destination, no longer in the source
+)
+
+// DriftReport is the result of checking whether a profile's destination
+// still mirrors its source, without transferring or fixing anything. Drift
+// checks are recorded so a mirror's divergence can be followed over time.
+type DriftReport struct {
+	Id            int64       `json:"id"`
+	ScheduleId    string      `json:"schedule_id,omitempty"`
+	ProfileName   string      `json:"profile_name"`
+	Source        string      `json:"source"`
+	Destination   string      `json:"destination"`
+	Status        string      `json:"status"` // "in_sync", "drifted", "failed"
+	Matched       int         `json:"matched"`
+	NewCount      int         `json:"new_count"`
+	ChangedCount  int         `json:"changed_count"`
+	ExtraCount    int         `json:"extra_count"`
+	ErrorCount    int         `json:"error_count"`              // files that could not be read or compared
+	DriftingSince *time.Time  `json:"drifting_since,omitempty"` // when the oldest difference still there was first found
+	Files         []DriftFile `json:"files,omitempty"`          // the differences, oldest first; only returned by the check itself
+	ErrorMessage  string      `json:"error_message,omitempty"`
+	StartTime     time.Time   `json:"start_time"`
+	EndTime       time.Time   `json:"end_time"`
+}
+
+// Differences returns how many files differ between the source and the
+// destination
+func (r *DriftReport) Differences() int {
+	return r.NewCount + r.ChangedCount + r.ExtraCount
+}
+
+// DriftFile is a difference between a profile's source and destination,
+// with when drift checks first and last found it
+type DriftFile struct {
+	Path      string    `json:"path"`
+	Kind      string    `json:"kind"` // DriftNew, DriftChanged or DriftExtra
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
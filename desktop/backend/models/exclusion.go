@@ -0,0 +1,18 @@
+package models
+
+// Reasons an exclusion is suggested
+const (
+	ExclusionFailing = "failing" // the file failed in run after run
+	ExclusionChurn   = "churn"   // the file, or temp/lock files like it, is transferred again run after run
+	ExclusionVolume  = "volume"  // the file makes up most of the data the profile transfers
+)
+
+// ExclusionSuggestion is an exclude rule proposed from a profile's run history
+type ExclusionSuggestion struct {
+	Rule   string   `json:"rule"`            // filter glob to add to the profile's excluded_paths
+	Reason string   `json:"reason"`          // ExclusionFailing, ExclusionChurn or ExclusionVolume
+	Detail string   `json:"detail"`          // why, for showing to the user
+	Paths  []string `json:"paths"`           // files in the history the rule leaves out, at most 10
+	Runs   int      `json:"runs"`            // runs they failed in or were transferred by
+	Bytes  int64    `json:"bytes,omitempty"` // transferred by those runs
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // FileEntry represents a file or directory in a remote listing
 type FileEntry struct {
 	Path     string `json:"path"`
@@ -10,6 +12,17 @@ type FileEntry struct {
 	MimeType string `json:"mime_type,omitempty"`
 }
 
+// DirListing is a directory listing served to the file browser, either live
+// from the remote or from the listing cache
+type DirListing struct {
+	Path      string      `json:"path"`
+	Entries   []FileEntry `json:"entries"`
+	FetchedAt time.Time   `json:"fetched_at"`      // when the entries were listed from the remote
+	Cached    bool        `json:"cached"`          // served from the listing cache
+	Stale     bool        `json:"stale"`           // may no longer match the remote (expired, changed or remote unavailable)
+	Error     string      `json:"error,omitempty"` // why the remote couldn't be listed, when falling back to the cache
+}
+
 // QuotaInfo contains storage quota information for a remote
 type QuotaInfo struct {
 	Total   int64 `json:"total"`
@@ -24,3 +37,34 @@ type ListOptions struct {
 	MaxDepth  int    `json:"max_depth"`
 	SortBy    string `json:"sort_by"` // "name", "size", "mod_time"
 }
+
+// Orders of a DirPageQuery. Directories always come first, and entries with
+// the same size or time are ordered by name.
+const (
+	ListOrderName    = "name"
+	ListOrderSize    = "size"
+	ListOrderModTime = "mod_time"
+)
+
+// DirPageQuery selects a page of a directory listing
+type DirPageQuery struct {
+	Cursor     string `json:"cursor,omitempty"`     // NextCursor of the previous page; empty for the first page
+	Limit      int    `json:"limit,omitempty"`      // entries per page; 0 = 500
+	Filter     string `json:"filter,omitempty"`     // only names containing this, ignoring case
+	OrderBy    string `json:"order_by,omitempty"`   // ListOrderName (default), ListOrderSize or ListOrderModTime
+	Descending bool   `json:"descending,omitempty"` // reverse the order, directories still first
+	Refresh    bool   `json:"refresh,omitempty"`    // list the remote again for the first page, like BrowseFiles
+}
+
+// DirPage is a page of a directory listing served to the file browser, for
+// directories too large to send whole
+type DirPage struct {
+	Path       string      `json:"path"`
+	Entries    []FileEntry `json:"entries"`
+	Total      int         `json:"total"`                 // entries matching the filter, on all pages
+	NextCursor string      `json:"next_cursor,omitempty"` // empty on the last page
+	FetchedAt  time.Time   `json:"fetched_at"`            // when the entries were listed from the remote
+	Cached     bool        `json:"cached"`                // served from the listing cache
+	Stale      bool        `json:"stale"`                 // may no longer match the remote
+	Error      string      `json:"error,omitempty"`       // why the remote couldn't be listed, when falling back to the cache
+}
@@ -2,27 +2,98 @@ package models
 
 // Flow represents a sync workflow containing sequential operations
 type Flow struct {
-	Id              string      `json:"id"`
-	Name            string      `json:"name"`
-	IsCollapsed     bool        `json:"is_collapsed"`
-	ScheduleEnabled bool        `json:"schedule_enabled"`
-	CronExpr        string      `json:"cron_expr,omitempty"`
-	SortOrder       int         `json:"sort_order"`
-	Operations      []Operation `json:"operations"`
-	CreatedAt       string      `json:"created_at,omitempty"`
-	UpdatedAt       string      `json:"updated_at,omitempty"`
+	Id              string       `json:"id"`
+	Name            string       `json:"name"`
+	IsCollapsed     bool         `json:"is_collapsed"`
+	ScheduleEnabled bool         `json:"schedule_enabled"`
+	CronExpr        string       `json:"cron_expr,omitempty"`
+	SortOrder       int          `json:"sort_order"`
+	Operations      []Operation  `json:"operations"`
+	Webhooks        []RunWebhook `json:"webhooks,omitempty"` // told when a backend run of the flow starts and finishes
+	CreatedAt       string       `json:"created_at,omitempty"`
+	UpdatedAt       string       `json:"updated_at,omitempty"`
 }
 
-// Operation represents a single sync operation between two remotes
+// Operation step types
+const (
+	OperationTypeSync    = "sync"    // sync between two remotes (default)
+	OperationTypeHTTP    = "http"    // HTTP request, e.g. a webhook call
+	OperationTypeWait    = "wait"    // fixed delay or wait for a condition
+	OperationTypeArchive = "archive" // archive a local directory for the next step
+)
+
+// Operation represents a single step of a flow: by default a sync operation
+// between two remotes
 type Operation struct {
-	Id           string  `json:"id"`
-	FlowId       string  `json:"flow_id"`
-	SourceRemote string  `json:"source_remote"`
-	SourcePath   string  `json:"source_path"`
-	TargetRemote string  `json:"target_remote"`
-	TargetPath   string  `json:"target_path"`
-	Action       string  `json:"action"`
-	SyncConfig   Profile `json:"sync_config"`  // JSON-serialized Profile with all rclone options
-	IsExpanded   bool    `json:"is_expanded"`
-	SortOrder    int     `json:"sort_order"`
+	Id           string       `json:"id"`
+	FlowId       string       `json:"flow_id"`
+	Type         string       `json:"type,omitempty"` // one of the OperationType constants; default OperationTypeSync
+	SourceRemote string       `json:"source_remote"`
+	SourcePath   string       `json:"source_path"`
+	TargetRemote string       `json:"target_remote"`
+	TargetPath   string       `json:"target_path"`
+	Action       string       `json:"action"`
+	SyncConfig   Profile      `json:"sync_config"`       // JSON-serialized Profile with all rclone options
+	HTTP         *HTTPStep    `json:"http,omitempty"`    // request of an HTTP step
+	Wait         *WaitStep    `json:"wait,omitempty"`    // settings of a wait step
+	Archive      *ArchiveStep `json:"archive,omitempty"` // settings of an archive step
+	IsExpanded   bool         `json:"is_expanded"`
+	SortOrder    int          `json:"sort_order"`
+}
+
+// HTTPStep is an HTTP request made as a flow step. URL, header values and
+// body may contain ${VARIABLE} placeholders for run variables.
+type HTTPStep struct {
+	URL            string       `json:"url"`
+	Method         string       `json:"method,omitempty"` // default POST
+	Headers        []HTTPHeader `json:"headers,omitempty"`
+	Body           string       `json:"body,omitempty"`
+	TimeoutSeconds int          `json:"timeout_seconds,omitempty"` // default 30
+}
+
+// HTTPHeader is a request header whose value is given inline or read from the
+// secrets vault, so tokens are not stored in the flow
+type HTTPHeader struct {
+	Name   string `json:"name"`
+	Value  string `json:"value,omitempty"`
+	Secret string `json:"secret,omitempty"` // name of a vault secret; takes precedence over Value
+}
+
+// Wait step conditions
+const (
+	WaitConditionDelay           = ""                 // wait a fixed number of seconds
+	WaitConditionRemoteReachable = "remote_reachable" // Remote answers a listing
+	WaitConditionFileExists      = "file_exists"      // Path exists on Remote
+	WaitConditionFreeSpace       = "free_space"       // Remote has at least MinFreeBytes free
+)
+
+// WaitStep pauses a flow for a fixed delay or until a condition holds. A
+// condition is checked every PollSeconds and fails the step after
+// TimeoutSeconds, e.g. "wait until the NAS is online, then sync".
+type WaitStep struct {
+	Condition      string `json:"condition,omitempty"`
+	DelaySeconds   int    `json:"delay_seconds,omitempty"` // for WaitConditionDelay
+	Remote         string `json:"remote,omitempty"`
+	Path           string `json:"path,omitempty"`            // for WaitConditionFileExists
+	MinFreeBytes   int64  `json:"min_free_bytes,omitempty"`  // for WaitConditionFreeSpace
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"` // default 3600
+	PollSeconds    int    `json:"poll_seconds,omitempty"`    // default 30
+}
+
+// Archive formats
+const (
+	ArchiveFormatZip    = "zip"
+	ArchiveFormatTarZst = "tar.zst"
+)
+
+// ArchiveStep packs a local directory into a single archive in a temp
+// directory. The next sync step uploads the archive instead of its own
+// source; the archive is deleted when the flow run ends.
+type ArchiveStep struct {
+	SourcePath     string   `json:"source_path"`
+	Format         string   `json:"format,omitempty"` // ArchiveFormatZip (default) or ArchiveFormatTarZst
+	Name           string   `json:"name,omitempty"`   // file name without extension; may use run variables
+	IncludedPaths  []string `json:"included_paths,omitempty"`
+	ExcludedPaths  []string `json:"excluded_paths,omitempty"`
+	PasswordSecret string   `json:"password_secret,omitempty"` // vault secret; encrypts the archive in rclone crypt format
 }
@@ -0,0 +1,18 @@
+package models
+
+// WindowForecast estimates whether a scheduled run fits its sync window,
+// from the size of a dry-run plan and the upload throughput measured on
+// past runs of the profile
+type WindowForecast struct {
+	ScheduleId       string  `json:"schedule_id"`
+	ProfileName      string  `json:"profile_name"`
+	WindowMinutes    int     `json:"window_minutes"`
+	PlannedFiles     int64   `json:"planned_files"`
+	PlannedBytes     int64   `json:"planned_bytes"`
+	BytesPerSecond   float64 `json:"bytes_per_second"`
+	ThroughputSource string  `json:"throughput_source,omitempty"` // "history", "bandwidth_limit"; empty when unknown
+	EstimatedSeconds int64   `json:"estimated_seconds"`
+	WindowsNeeded    int     `json:"windows_needed"`
+	Verdict          string  `json:"verdict"` // "fits", "exceeds", "unknown"
+	Message          string  `json:"message"`
+}
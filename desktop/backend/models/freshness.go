@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Freshness alert levels, in the order an overdue profile escalates through them
+const (
+	FreshnessLevelInApp   = "in_app"  // shown in the app
+	FreshnessLevelOS      = "os"      // desktop notification
+	FreshnessLevelWebhook = "webhook" // the profile's freshness webhooks are POSTed
+)
+
+// FreshnessStatus is how recently a profile with a freshness target last
+// completed a run, and how far its alert escalated while it is overdue
+type FreshnessStatus struct {
+	ProfileName  string     `json:"profile_name"`
+	Target       string     `json:"target"`                  // the profile's freshness_target
+	LastSuccess  *time.Time `json:"last_success,omitempty"`  // end of the last completed pull, push or bisync; nil if none
+	AgeSeconds   int64      `json:"age_seconds"`             // since LastSuccess, or since monitoring started when there is none
+	Breached     bool       `json:"breached"`                // older than the target
+	BreachedAt   *time.Time `json:"breached_at,omitempty"`   // when the target was first missed
+	Level        string     `json:"level,omitempty"`         // highest FreshnessLevel* reached; empty when fresh
+	WatchedSince time.Time  `json:"watched_since"`           // when monitoring of the profile started
+	NextLevelAt  *time.Time `json:"next_level_at,omitempty"` // when the alert escalates next, while breached
+}
@@ -1,29 +1,120 @@
 package models
 
-import "time"
+import (
+	"desktop/backend/dto"
+	"time"
+)
 
 // HistoryEntry represents a record of a completed sync/operation
 type HistoryEntry struct {
-	Id               string    `json:"id"`
-	ProfileName      string    `json:"profile_name"`
-	Action           string    `json:"action"`           // "pull", "push", "bi", "bi-resync", "copy", "move", etc.
-	Status           string    `json:"status"`           // "completed", "failed", "cancelled"
-	StartTime        time.Time `json:"start_time"`
-	EndTime          time.Time `json:"end_time"`
-	Duration         string    `json:"duration"`
-	FilesTransferred int64     `json:"files_transferred"`
-	BytesTransferred int64     `json:"bytes_transferred"`
-	Errors           int       `json:"errors"`
-	ErrorMessage     string    `json:"error_message,omitempty"`
+	Id               string     `json:"id"`
+	ProfileName      string     `json:"profile_name"`
+	Action           string     `json:"action"` // "pull", "push", "bi", "bi-resync", "copy", "move", "verify", etc.
+	Status           string     `json:"status"` // "completed", "failed", "cancelled", "interrupted" (the app exited during the run)
+	StartTime        time.Time  `json:"start_time"`
+	EndTime          time.Time  `json:"end_time"`
+	Duration         string     `json:"duration"`
+	FilesTransferred int64      `json:"files_transferred"`
+	BytesTransferred int64      `json:"bytes_transferred"`
+	Errors           int        `json:"errors"`
+	ErrorMessage     string     `json:"error_message,omitempty"`
+	ErrorInfo        *ErrorInfo `json:"error_info,omitempty"` // classified from ErrorMessage when recognised
+	Delta            *DeltaRun  `json:"delta,omitempty"`      // how the run used delta (change-notification) state
+
+	Report *dto.TransferReport `json:"report,omitempty"` // slowest, largest and most-retried files of the run
+
+	Destinations []DestinationResult `json:"destinations,omitempty"` // per-destination outcome of a fan-out run
+
+	Failover *FailoverRun `json:"failover,omitempty"` // the run went to the profile's failover destination, or brought its primary up to date again
+
+	RemoteHooks []RemoteHookResult `json:"remote_hooks,omitempty"` // outcomes of the profile's remote hooks after the run
+
+	Compression *CompressionRun `json:"compression,omitempty"` // achieved on a destination with compression wrapping
+
+	LockedFiles *LockedFilesRun `json:"locked_files,omitempty"` // source files that were open in another application during the run
+
+	RawFlags []RawFlag `json:"raw_flags,omitempty"` // raw rclone flags the run used, with their risk
+
+	APICalls map[string]int64 `json:"api_calls,omitempty"` // estimated API calls per provider; runs at the same time share theirs
+
+	Source string `json:"source,omitempty"` // tool whose logs an imported run came from, e.g. "rclone", "rsync"; empty for runs made here
+
+	TaskId int `json:"task_id,omitempty"` // sync task of a run made here, so the run's own raw flags and remote hooks are attached; not stored
+
+	Labels []string `json:"labels,omitempty"` // why and how the run happened, e.g. "schedule:<id>", "delta-scoped"; the user can add their own
+	Note   string   `json:"note,omitempty"`   // written by the user
+}
+
+// Labels attached to history entries automatically
+const (
+	LabelSchedulePrefix = "schedule:"     // + schedule ID: a schedule started the run
+	LabelRetryOfPrefix  = "retry-of:"     // + history entry ID: the run retried the files that failed in that run
+	LabelResumeOfPrefix = "resume-of:"    // + history entry ID: the run started an interrupted run again
+	LabelDeltaScoped    = "delta-scoped"  // the sync only looked at the changes a watcher reported
+	LabelDeltaSkipped   = "delta-skipped" // the sync was skipped: nothing had changed
+	LabelVerifyPrefix   = "verify:"       // + verify report ID: the run verified the profile; see SyncService.Verify
+)
+
+// HistoryLabelCount is a label and how many history entries have it
+type HistoryLabelCount struct {
+	Label   string `json:"label"`
+	Entries int    `json:"entries"`
+}
+
+// DestinationResult is the outcome of one destination of a fan-out sync
+type DestinationResult struct {
+	Destination string `json:"destination"`
+	Status      string `json:"status"` // "completed", "failed", "cancelled"
+	Error       string `json:"error,omitempty"`
+	DurationMs  int64  `json:"duration_ms"`
+}
+
+// FailoverRun marks a run that synced to a profile's failover destination
+// because its primary destination was unreachable, or a run that
+// reconciled the primary once it was back
+type FailoverRun struct {
+	Primary        string `json:"primary"`
+	Fallback       string `json:"fallback"`
+	Reason         string `json:"reason,omitempty"` // why the primary was taken for unreachable
+	Reconciliation bool   `json:"reconciliation,omitempty"`
+}
+
+// LockedFilesRun lists the source files of a run that failed because
+// another application had them open or locked, and whether the profile's
+// end-of-run retry copied them
+type LockedFilesRun struct {
+	Files         []string `json:"files"`                    // still not copied when the run ended
+	Recovered     []string `json:"recovered,omitempty"`      // copied by the retry
+	Handling      string   `json:"handling,omitempty"`       // the profile's LockedFiles: "", "retry" or "snapshot"
+	Snapshot      bool     `json:"snapshot,omitempty"`       // the retry read from a VSS snapshot
+	SnapshotError string   `json:"snapshot_error,omitempty"` // why no snapshot was taken; the retry read the live files
+}
+
+// DeltaRun records whether a sync ran as a delta, a skip or a full sync
+type DeltaRun struct {
+	Mode           string `json:"mode"` // "full", "delta", "skipped"
+	ChangesScoped  int    `json:"changes_scoped"`
+	FallbackReason string `json:"fallback_reason,omitempty"` // why a full sync ran instead of a delta
+	TimeSavedMs    int64  `json:"time_saved_ms"`             // estimate against the last full sync
 }
 
 // AggregateStats contains summary statistics across all history entries
 type AggregateStats struct {
-	TotalOperations int    `json:"total_operations"`
-	SuccessCount    int    `json:"success_count"`
-	FailureCount    int    `json:"failure_count"`
-	CancelledCount  int    `json:"cancelled_count"`
-	TotalBytes      int64  `json:"total_bytes"`
-	TotalFiles      int64  `json:"total_files"`
-	AverageDuration string `json:"average_duration"`
+	TotalOperations  int    `json:"total_operations"`
+	SuccessCount     int    `json:"success_count"`
+	FailureCount     int    `json:"failure_count"`
+	CancelledCount   int    `json:"cancelled_count"`
+	InterruptedCount int    `json:"interrupted_count"`
+	TotalBytes       int64  `json:"total_bytes"`
+	TotalFiles       int64  `json:"total_files"`
+	AverageDuration  string `json:"average_duration"`
+}
+
+// ErrorInfo is a classified failure with suggested remediation, produced by
+// the error knowledge base in the errors package
+type ErrorInfo struct {
+	Code        string   `json:"code"`        // e.g. "TOKEN_EXPIRED", "QUOTA_EXCEEDED"
+	Title       string   `json:"title"`       // short human-readable summary
+	Remediation string   `json:"remediation"` // what the user can do about it
+	Actions     []string `json:"actions"`     // suggested action IDs: "reconnect_remote", "free_space", "reduce_transfers", "retry_later", "shorten_paths", "retry"
 }
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// LifecycleRule moves files that haven't changed for a while from one remote
+// path to another, typically cheaper, one. Rules run from schedules with
+// target type "lifecycle".
+type LifecycleRule struct {
+	Id            string     `json:"id"`
+	Name          string     `json:"name"`
+	SourcePath    string     `json:"source_path"`              // e.g. "remoteA:/projects"
+	TargetPath    string     `json:"target_path"`              // e.g. "remoteB:/archive"
+	MinAge        string     `json:"min_age"`                  // files older than this move, e.g. "1y", "180d"
+	IncludedPaths []string   `json:"included_paths,omitempty"` // rclone filter globs
+	ExcludedPaths []string   `json:"excluded_paths,omitempty"` // rclone filter globs
+	MaxFiles      int        `json:"max_files,omitempty"`      // safety cap on files moved per run; 0 uses the default
+	MaxBytes      string     `json:"max_bytes,omitempty"`      // safety cap on data moved per run, e.g. "50G"; empty = no cap
+	LastRun       *time.Time `json:"last_run,omitempty"`
+	LastResult    string     `json:"last_result,omitempty"` // "success", "failed", "nothing to move"
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// LifecycleCandidate is a file a lifecycle rule would move
+type LifecycleCandidate struct {
+	Path    string `json:"path"` // relative to the rule's source path
+	Size    int64  `json:"size"`
+	ModTime string `json:"mod_time"`
+}
+
+// LifecyclePreview lists what a lifecycle rule would move on its next run.
+// Files beyond the rule's safety caps are counted but left for later runs.
+type LifecyclePreview struct {
+	RuleId       string               `json:"rule_id"`
+	Files        []LifecycleCandidate `json:"files"` // oldest first, within the caps
+	TotalFiles   int                  `json:"total_files"`
+	TotalBytes   int64                `json:"total_bytes"`
+	MatchedFiles int                  `json:"matched_files"` // all files old enough, including those over the caps
+	MatchedBytes int64                `json:"matched_bytes"`
+	Capped       bool                 `json:"capped"` // some matched files were left for later runs
+}
+
+// LifecycleMove records a file moved by a lifecycle rule, so it can be found
+// and brought back later
+type LifecycleMove struct {
+	Id         int64     `json:"id"`
+	RuleId     string    `json:"rule_id"`
+	RuleName   string    `json:"rule_name"`
+	Path       string    `json:"path"`        // relative to both paths below
+	SourcePath string    `json:"source_path"` // where the file was moved from
+	TargetPath string    `json:"target_path"` // where the file is now
+	Size       int64     `json:"size"`
+	ModTime    string    `json:"mod_time"`
+	MovedAt    time.Time `json:"moved_at"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// HistoryDay sums a profile's runs of one action on one day (UTC). History
+// entries are rolled up into days when they age out or the history cap
+// removes them.
+type HistoryDay struct {
+	Day              string `json:"day"` // YYYY-MM-DD
+	ProfileName      string `json:"profile_name"`
+	Action           string `json:"action"`
+	Runs             int    `json:"runs"`
+	Completed        int    `json:"completed"`
+	Failed           int    `json:"failed"`
+	Cancelled        int    `json:"cancelled"`
+	FilesTransferred int64  `json:"files_transferred"`
+	BytesTransferred int64  `json:"bytes_transferred"`
+	Errors           int    `json:"errors"`
+	DurationMs       int64  `json:"duration_ms"` // of all its runs
+}
+
+// MaintenanceReport is what a maintenance run of the database and log files did
+type MaintenanceReport struct {
+	RanAt           time.Time `json:"ran_at"`
+	DetailsStripped int64     `json:"details_stripped"` // entries whose per-file transfer report was dropped
+	EntriesRolledUp int64     `json:"entries_rolled_up"`
+	LogsCompressed  int       `json:"logs_compressed"`
+	LogsDeleted     int       `json:"logs_deleted"`
+	Vacuumed        bool      `json:"vacuumed"`
+	SizeBefore      int64     `json:"size_before"`
+	SizeAfter       int64     `json:"size_after"`
+	Errors          []string  `json:"errors,omitempty"`
+}
+
+// DatabaseSizeSample is the database size measured at one maintenance run
+type DatabaseSizeSample struct {
+	MeasuredAt time.Time `json:"measured_at"`
+	SizeBytes  int64     `json:"size_bytes"`
+	FreeBytes  int64     `json:"free_bytes"` // unused pages a vacuum would release
+}
+
+// TableRows is the row count of one database table
+type TableRows struct {
+	Table string `json:"table"`
+	Rows  int64  `json:"rows"`
+}
+
+// DatabaseStats describes the size of the database and how it grows
+type DatabaseStats struct {
+	SizeBytes       int64                `json:"size_bytes"`
+	FreeBytes       int64                `json:"free_bytes"`
+	Tables          []TableRows          `json:"tables"`         // largest first
+	Samples         []DatabaseSizeSample `json:"samples"`        // oldest first
+	GrowthPerDay    int64                `json:"growth_per_day"` // bytes, over the samples of the last 30 days
+	LastMaintenance *MaintenanceReport   `json:"last_maintenance,omitempty"`
+}
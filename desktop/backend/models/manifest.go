@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// ManifestVersion is the format version of the manifests written here
+const ManifestVersion = 1
+
+// Manifest lists the files of a backup with their sizes and hashes, as
+// written to the destination's root after a run, so the backup can be
+// checked or restored by any tool without this app
+type Manifest struct {
+	Version     int            `json:"version"`
+	RunId       string         `json:"run_id"`
+	ProfileName string         `json:"profile_name"`
+	Source      string         `json:"source"`
+	Destination string         `json:"destination"`
+	HashType    string         `json:"hash_type,omitempty"` // e.g. "md5", "sha1"; empty when the destination has no hashes
+	CreatedAt   time.Time      `json:"created_at"`
+	Files       []ManifestFile `json:"files"`
+}
+
+// ManifestFile is a file of a Manifest
+type ManifestFile struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Hash    string    `json:"hash,omitempty"`
+}
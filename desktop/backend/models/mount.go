@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// VFS cache modes of a mount, as rclone's --vfs-cache-mode
+const (
+	MountCacheOff     = "off"     // read and write the remote directly; files can only be written start to end
+	MountCacheMinimal = "minimal" // cache files opened for both reading and writing
+	MountCacheWrites  = "writes"  // cache files opened for writing, so any app can save to the mount
+	MountCacheFull    = "full"    // cache what is read too
+)
+
+// Statuses of a mount
+const (
+	MountStatusMounting   = "mounting"
+	MountStatusMounted    = "mounted"
+	MountStatusUnmounting = "unmounting" // waiting for files open for writing to be closed
+)
+
+// MountOptions configures a mount
+type MountOptions struct {
+	CacheMode      string `json:"cache_mode,omitempty"`        // one of MountCache*; default "writes"
+	CacheMaxSizeMB int    `json:"cache_max_size_mb,omitempty"` // disk space the cache may use; 0 = no limit
+	CacheMaxAgeMin int    `json:"cache_max_age_min,omitempty"` // minutes unused files stay cached; 0 = 60
+	ReadOnly       bool   `json:"read_only,omitempty"`
+	VolumeName     string `json:"volume_name,omitempty"` // name the drive is shown with; defaults to the remote
+}
+
+// MountInfo is a remote mounted as a local drive by MountService
+type MountInfo struct {
+	RemotePath        string       `json:"remote_path"`
+	MountPoint        string       `json:"mount_point"`
+	Options           MountOptions `json:"options"`
+	Status            string       `json:"status"` // one of MountStatus*
+	MountedAt         time.Time    `json:"mounted_at"`
+	UploadsInProgress int          `json:"uploads_in_progress"` // files written to the cache being uploaded
+	UploadsQueued     int          `json:"uploads_queued"`      // files written to the cache waiting to be uploaded
+	CachedFiles       int          `json:"cached_files"`
+	CacheBytes        int64        `json:"cache_bytes"`
+	Error             string       `json:"error,omitempty"` // why the mount ended, in mount:unmounted events
+}
@@ -0,0 +1,21 @@
+package models
+
+// IP families for NetworkSettings.IPFamily and Profile.IPFamily
+const (
+	IPFamilyAny  = ""
+	IPFamilyIPv4 = "ipv4"
+	IPFamilyIPv6 = "ipv6"
+)
+
+// NetworkSettings configure rclone's HTTP client for proxied or
+// TLS-intercepting (corporate) networks. They apply app-wide, or to a
+// single remote as rclone config overrides.
+type NetworkSettings struct {
+	Proxy              string `json:"proxy,omitempty"`                // http(s):// or socks5(h):// URL; empty uses the system proxy environment
+	CACertFile         string `json:"ca_cert_file,omitempty"`         // PEM bundle trusted instead of the system roots
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"` // skip TLS certificate verification (insecure)
+
+	BindAddress string `json:"bind_address,omitempty"` // local IP address or interface name for outgoing connections
+	IPFamily    string `json:"ip_family,omitempty"`    // "" (any), "ipv4" or "ipv6"
+	DNSServer   string `json:"dns_server,omitempty"`   // host[:port] of the DNS server HTTP remotes resolve through; app-wide only
+}
@@ -0,0 +1,11 @@
+package models
+
+// PathVariable maps a variable used in profile paths, e.g. "${PHOTOS_DIR}/2024",
+// to a local path on this machine. The mapping is kept per machine and isn't
+// exported or synced, so profiles shared between machines resolve to each
+// machine's own folders.
+type PathVariable struct {
+	Name     string   `json:"name"`     // e.g. "PHOTOS_DIR"
+	Path     string   `json:"path"`     // absolute local path; empty when not defined on this machine
+	Profiles []string `json:"profiles"` // profiles whose paths use the variable
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// PendingDeletion is a file deleted from a profile's source that a run with
+// DeferDeletes moved to the pending-delete folder of the destination
+// instead of deleting it
+type PendingDeletion struct {
+	Side      string    `json:"side,omitempty"` // "from" or "to": the profile path the folder is on, "from" after pulls
+	Path      string    `json:"path"`           // relative to the destination's root, where it was before
+	DeletedAt time.Time `json:"deleted_at"`     // when a run moved it there
+	Runs      int       `json:"runs"`           // successful runs since, the file still deleted; purged at DeferDeletes
+	Size      int64     `json:"size"`
+}
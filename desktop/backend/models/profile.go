@@ -1,5 +1,7 @@
 package models
 
+import "encoding/json"
+
 type Profile struct {
 	Name          string   `json:"name"`
 	From          string   `json:"from"`
@@ -53,12 +55,79 @@ type Profile struct {
 	// Sync-specific
 	DeleteTiming string `json:"delete_timing,omitempty"` // "before","during","after" (--delete-before/during/after)
 
+	// Deferred deletion (push and pull): files deleted from the source are moved to a pending-delete folder
+	// on the destination rather than deleted, and purged once they stayed deleted for DeferDeletes more
+	// successful runs (see models.PendingDeletion)
+	DeferDeletes int `json:"defer_deletes,omitempty"` // runs to keep a deleted file; 0 = delete at once
+
+	// Conflicts (push and pull): files changed on both sides since the last run are left out instead of
+	// overwritten, and recorded until resolved (see models.SyncConflict)
+	DetectConflicts bool `json:"detect_conflicts,omitempty"`
+
 	// Bisync-specific
-	Resilient      bool   `json:"resilient,omitempty"`       // --resilient
-	MaxLock        string `json:"max_lock,omitempty"`        // --max-lock e.g. "15m"
-	CheckAccess    bool   `json:"check_access,omitempty"`    // --check-access
-	ConflictLoser  string `json:"conflict_loser,omitempty"`  // --conflict-loser: "num","pathname","delete"
-	ConflictSuffix string `json:"conflict_suffix,omitempty"` // --conflict-suffix
+	Resilient      bool            `json:"resilient,omitempty"`       // --resilient
+	MaxLock        string          `json:"max_lock,omitempty"`        // --max-lock e.g. "15m"
+	CheckAccess    bool            `json:"check_access,omitempty"`    // --check-access
+	ConflictLoser  string          `json:"conflict_loser,omitempty"`  // --conflict-loser: "num","pathname","delete"
+	ConflictSuffix string          `json:"conflict_suffix,omitempty"` // --conflict-suffix
+	ConflictNaming *ConflictNaming `json:"conflict_naming,omitempty"` // host/time in conflict copy names and a folder to gather them in
+
+	// Delta
+	QuickCheck bool `json:"quick_check,omitempty"` // skip syncs when listing fingerprints are unchanged (remotes without change notifications)
+
+	// Network
+	BindAddress string `json:"bind_address,omitempty"` // --bind: local IP address or interface name for outgoing connections
+	IPFamily    string `json:"ip_family,omitempty"`    // "" (any), "ipv4" or "ipv6"
+
+	// Fan-out (push only): the source is listed once and synced to To and to each of FanOutTo
+	FanOutTo   []string `json:"fan_out_to,omitempty"`   // additional destinations
+	FanOutMode string   `json:"fan_out_mode,omitempty"` // "" (sequential) or "parallel"
+
+	// Failover (push only): when To is unreachable at run time the sync goes to FailoverTo instead,
+	// and the profile is synced to To again once it is back
+	FailoverTo string `json:"failover_to,omitempty"`
+
+	// Manifest (push only): after each run, write the destination's files with their sizes and hashes
+	// to a manifest at its root (see models.Manifest)
+	WriteManifest bool `json:"write_manifest,omitempty"`
+
+	// Sessions (push and pull): for slow links, each run transfers at most SessionTransfer, in SessionOrder,
+	// and the files left are queued for the next run (see models.TransferSession)
+	SessionTransfer string `json:"session_transfer,omitempty"` // rclone size suffix e.g. "2G"; empty = no sessions
+	SessionOrder    string `json:"session_order,omitempty"`    // "smallest" (default) or "newest" first
+
+	// Transfer order: which files a run starts first, the smallest so many files finish quickly or the
+	// largest to saturate bandwidth early (see models.TransferPlan). Runs in sessions use SessionOrder.
+	TransferOrder string `json:"transfer_order,omitempty"` // "smallest" or "largest"; empty = rclone's listing order
+
+	// Locked files (push and pull): source files open in another application, like mail data files or
+	// running virtual machines, fail to copy. They are always reported apart (see models.LockedFilesRun);
+	// LockedFiles can also copy them again once the rest of the run is done.
+	LockedFiles string `json:"locked_files,omitempty"` // "retry", or "snapshot" to copy them from a VSS snapshot on Windows; empty = report only
+
+	// Freshness: a run of the profile must complete at least every FreshnessTarget. A monitor
+	// escalates alerts while it is overdue, whether or not anything tried to run it (see models.FreshnessStatus)
+	FreshnessTarget   string       `json:"freshness_target,omitempty"`   // Go duration e.g. "24h"; empty = not monitored
+	FreshnessWebhooks []RunWebhook `json:"freshness_webhooks,omitempty"` // POSTed a FreshnessStatus at the last escalation and on recovery; events don't apply
+
+	// Remote hooks: tasks triggered on the server after a push or bisync uploads to it, in order (see models.RemoteHook)
+	RemoteHooks []RemoteHook `json:"remote_hooks,omitempty"`
+
+	// Objects written to the destination
+	StorageClass         string   `json:"storage_class,omitempty"`          // e.g. "STANDARD_IA", "GLACIER_IR" (backends with a storage_class option, like s3 and gcs)
+	UploadHeaders        []string `json:"upload_headers,omitempty"`         // "Name: value" headers set on uploaded files e.g. "Cache-Control: max-age=86400"
+	ServerSideEncryption string   `json:"server_side_encryption,omitempty"` // s3: "AES256" or "aws:kms"
+	SSEKMSKeyId          string   `json:"sse_kms_key_id,omitempty"`         // s3: KMS key ARN when ServerSideEncryption is "aws:kms"
+
+	// Raw rclone flags for options the profile doesn't model, e.g. "--no-update-modtime" or
+	// "--multi-thread-cutoff=64M"; checked against rclone's flag registry (see models.RawFlag)
+	RawFlags []string `json:"raw_flags,omitempty"`
+
+	// Notifications
+	NotifyMode string `json:"notify_mode,omitempty"` // per-profile override: "" (use global setting), "off", "failures", "all"
+
+	// Crash recovery
+	ResumeInterrupted string `json:"resume_interrupted,omitempty"` // what to do at startup with a run the app exited during: "" (offer it), "rerun" or "retry_failed"
 
 	// Encryption (on-the-fly crypt wrapping, runtime only - not persisted to DB)
 	EncryptSource    bool   `json:"encrypt_source,omitempty"`    // Wrap source with crypt remote
@@ -67,6 +136,60 @@ type Profile struct {
 	EncryptPassword2 string `json:"encrypt_password2,omitempty"` // Salt password (optional)
 	EncryptFilename  string `json:"encrypt_filename,omitempty"`  // "standard", "obfuscate", "off"
 	EncryptDirectory bool   `json:"encrypt_directory,omitempty"` // Encrypt directory names
+
+	// Files a run leaves out, relative to both roots (runtime only - not persisted), e.g. unresolved conflicts
+	SkipFiles []string `json:"-"`
+
+	// Compression (on-the-fly compress wrapping of the destination, before encryption): files are stored
+	// compressed, except those whose start doesn't compress, which are stored as they are (see models.CompressionEstimate)
+	CompressDest bool   `json:"compress_dest,omitempty"`
+	CompressMode string `json:"compress_mode,omitempty"` // "gzip" (default) or "zstd"
+
+	// Fields of the profile written by a newer version, kept so they survive an import and a
+	// later export or settings sync (see ProfileSchemaVersion)
+	UnknownFields map[string]json.RawMessage `json:"unknown_fields,omitempty"`
+}
+
+// Fan-out modes
+const (
+	FanOutSequential = ""
+	FanOutParallel   = "parallel"
+)
+
+// What happens at startup to a run that was interrupted by the app exiting
+const (
+	ResumeInterruptedOffer       = ""             // record it and offer to resume it
+	ResumeInterruptedRerun       = "rerun"        // run it again
+	ResumeInterruptedRetryFailed = "retry_failed" // retry only the files that had failed before it was interrupted
+)
+
+// Session orders: which files a session transfers first
+const (
+	SessionOrderSmallest = "smallest"
+	SessionOrderNewest   = "newest"
+)
+
+// Transfer orders: which files a run starts first
+const (
+	TransferOrderSmallest = "smallest"
+	TransferOrderLargest  = "largest"
+)
+
+// Compression modes of a destination with compression wrapping
+const (
+	CompressGzip = "gzip"
+	CompressZstd = "zstd"
+)
+
+// Locked file handling: what a run does with source files another application holds open
+const (
+	LockedFilesRetry    = "retry"
+	LockedFilesSnapshot = "snapshot"
+)
+
+// Destinations returns the profile's destinations: To, then FanOutTo
+func (p Profile) Destinations() []string {
+	return append([]string{p.To}, p.FanOutTo...)
 }
 
 // StripEncryptPasswords clears encryption passwords so they are not persisted to DB.
@@ -0,0 +1,27 @@
+package models
+
+// Profile overlap kinds
+const (
+	OverlapDuplicate         = "duplicate"          // same source and destination
+	OverlapNested            = "nested"             // one profile's source and destination lie within the other's
+	OverlapSharedDestination = "shared_destination" // different sources are synced into the same destination tree
+	OverlapReversed          = "reversed"           // one profile syncs the other's destination back to its source
+)
+
+// ProfileOverlap describes two profiles that do the same or conflicting work
+type ProfileOverlap struct {
+	Profiles         []string `json:"profiles"` // the two profile names
+	Kind             string   `json:"kind"`
+	FilterSimilarity float64  `json:"filter_similarity"` // 0..1, how alike the include/exclude rules are
+	Warnings         []string `json:"warnings"`
+	Mergeable        bool     `json:"mergeable"` // same endpoints, so the merge assistant can combine them
+}
+
+// ProfileMergePreview shows the profile a merge would produce before it is applied
+type ProfileMergePreview struct {
+	Profile        Profile  `json:"profile"` // the merged profile, keeping the first profile's name
+	Removed        []string `json:"removed"` // profiles folded into it and deleted
+	Notes          []string `json:"notes"`   // how differing settings were combined
+	HistoryEntries int      `json:"history_entries"`
+	Schedules      int      `json:"schedules"`
+}
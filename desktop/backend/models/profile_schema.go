@@ -0,0 +1,18 @@
+package models
+
+// ProfileSchemaVersion is the version of the profile JSON this version
+// writes. Profile JSON without a schema_version, such as exports and
+// profiles.json files from before it was recorded, is version 1.
+//
+// Version 2 records the version and keeps the fields of newer versions.
+const ProfileSchemaVersion = 2
+
+// ProfileSchemaEntry is the outcome of reading one profile of profile JSON
+type ProfileSchemaEntry struct {
+	Index         int      `json:"index"` // 1-based position in the document
+	Name          string   `json:"name"`
+	Profile       *Profile `json:"profile,omitempty"` // nil if the profile couldn't be read
+	Warnings      []string `json:"warnings"`          // deprecated fields converted or dropped
+	UnknownFields []string `json:"unknown_fields"`    // fields kept in Profile.UnknownFields
+	Errors        []string `json:"errors"`            // why the profile is skipped
+}
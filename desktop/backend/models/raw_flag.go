@@ -0,0 +1,18 @@
+package models
+
+// Risk labels of raw rclone flags
+const (
+	RawFlagSafe        = "safe"        // changes how files are compared, listed or reported
+	RawFlagPerformance = "performance" // changes speed, concurrency or resource use
+	RawFlagDestructive = "destructive" // can lose, overwrite or skip data that would otherwise be kept or checked
+)
+
+// RawFlag is a raw rclone flag of a profile, checked against rclone's flag
+// registry and labelled with its risk
+type RawFlag struct {
+	Flag  string `json:"flag"`            // as given, e.g. "--no-update-modtime"
+	Name  string `json:"name"`            // rclone option name, e.g. "no_update_modtime"
+	Value string `json:"value,omitempty"` // empty for a boolean flag given without one
+	Risk  string `json:"risk"`            // RawFlagSafe, RawFlagPerformance or RawFlagDestructive
+	Help  string `json:"help,omitempty"`  // first line of rclone's help for the flag
+}
@@ -7,3 +7,20 @@ type Remote struct {
 }
 
 type Remotes []Remote
+
+// DriveScope is an OAuth scope a Google Drive remote can be authorized with
+type DriveScope struct {
+	Value       string `json:"value"` // rclone "scope" option, e.g. "drive.file"
+	Label       string `json:"label"`
+	Description string `json:"description"`
+}
+
+// DriveComputer is a computer backed up to Google Drive by Google's Backup &
+// Sync / Drive for desktop. Its files live outside My Drive, under the
+// "Computers" root.
+type DriveComputer struct {
+	Id           string `json:"id"`
+	Name         string `json:"name"`
+	ModifiedTime string `json:"modified_time"`
+	Path         string `json:"path"` // rclone path rooted at the computer's folder, usable for browsing and pulls
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// RemoteHook triggers a task on the server a profile syncs to once a run
+// has uploaded to it, like re-indexing a NAS media library or taking a
+// snapshot. It is an HTTP request, such as a call to an rclone rc server, or
+// a command run over SSH with the credentials of an SFTP remote.
+type RemoteHook struct {
+	Name string    `json:"name,omitempty"`
+	On   string    `json:"on,omitempty"`   // RemoteHookOnSuccess (default) or RemoteHookAlways
+	HTTP *HTTPStep `json:"http,omitempty"` // request, like a flow HTTP step
+	SSH  *SSHHook  `json:"ssh,omitempty"`  // command run over SSH; exactly one of HTTP and SSH is set
+}
+
+// SSHHook is a command run on a server over SSH. The host, port, user and
+// key or password come from an rclone SFTP remote, so no credential is
+// stored in the profile.
+type SSHHook struct {
+	Remote         string `json:"remote"`  // name of an sftp remote
+	Command        string `json:"command"` // run variables are expanded shell-quoted
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
+}
+
+// When remote hooks run
+const (
+	RemoteHookOnSuccess = ""       // after runs that complete
+	RemoteHookAlways    = "always" // after runs that complete or fail
+)
+
+// RemoteHookResult is the outcome of a remote hook after a run
+type RemoteHookResult struct {
+	Name       string    `json:"name"`
+	Kind       string    `json:"kind"`   // "http" or "ssh"
+	Status     string    `json:"status"` // "completed" or "failed"
+	Error      string    `json:"error,omitempty"`
+	Output     string    `json:"output,omitempty"` // start of the SSH command's output
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int64     `json:"duration_ms"`
+}
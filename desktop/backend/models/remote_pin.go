@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Kinds of remote pins
+const (
+	PinSSHHostKey     = "ssh_host_key"    // the host key of an sftp remote's server
+	PinTLSCertificate = "tls_certificate" // the certificate of an https webdav remote's server
+)
+
+// Statuses of a remote pin check
+const (
+	PinStatusUnpinned    = "unpinned"
+	PinStatusMatch       = "match"
+	PinStatusChanged     = "changed"
+	PinStatusUnreachable = "unreachable"
+)
+
+// RemotePin is the identity of a self-hosted remote's server that was
+// trusted, so a different one is noticed: a server rebuild or a MITM
+type RemotePin struct {
+	Remote      string    `json:"remote"`
+	Kind        string    `json:"kind"`        // Pin*
+	Address     string    `json:"address"`     // host:port of the server
+	Fingerprint string    `json:"fingerprint"` // "SHA256:..." of the host key, or the certificate's SHA-256 in hex
+	PinnedAt    time.Time `json:"pinned_at"`
+}
+
+// RemotePinStatus compares what a remote's server presents now with its pin
+type RemotePinStatus struct {
+	Remote    string     `json:"remote"`
+	Kind      string     `json:"kind"`
+	Address   string     `json:"address"`
+	Status    string     `json:"status"`             // PinStatus*
+	Pinned    string     `json:"pinned,omitempty"`   // the pinned fingerprint
+	Current   string     `json:"current,omitempty"`  // the fingerprint the server presents now
+	KeyType   string     `json:"key_type,omitempty"` // e.g. "ssh-ed25519"
+	Subject   string     `json:"subject,omitempty"`  // of the certificate
+	Issuer    string     `json:"issuer,omitempty"`   // of the certificate
+	NotAfter  *time.Time `json:"not_after,omitempty"`
+	Error     string     `json:"error,omitempty"` // why the server couldn't be reached
+	CheckedAt time.Time  `json:"checked_at"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// RestoreOptions chooses what a restore copies, and where to
+type RestoreOptions struct {
+	ProfileName string `json:"profile_name,omitempty"` // profile the backup belongs to, for history; "restore" if empty
+	Source      string `json:"source"`                 // a profile's destination, or a snapshot dir of it
+	Target      string `json:"target"`                 // local folder to rebuild; existing files in it are never overwritten or deleted
+	Parallel    int    `json:"parallel,omitempty"`     // concurrent transfers; 0 = default
+
+	// Files restores only these files, given relative to Source, instead of
+	// the whole backup. Flatten puts them at the root of Target instead of
+	// in their original folders.
+	Files   []string `json:"files,omitempty"`
+	Flatten bool     `json:"flatten,omitempty"`
+}
+
+// RestoreReport is the result of a restore. When the source has a
+// manifest, the restored files are checked against it. Path lists are
+// capped; the counts are not.
+type RestoreReport struct {
+	Id            int64     `json:"id"`
+	ProfileName   string    `json:"profile_name"`
+	Source        string    `json:"source"`
+	Target        string    `json:"target"`
+	Status        string    `json:"status"` // "ok", "problems", "failed"
+	FilesRestored int64     `json:"files_restored"`
+	BytesRestored int64     `json:"bytes_restored"`
+	ManifestRunId string    `json:"manifest_run_id,omitempty"` // run that wrote the manifest; empty when the source has none
+	HashType      string    `json:"hash_type,omitempty"`       // hash the files were checked with; empty when only sizes were
+	Verified      int       `json:"verified"`                  // files matching the manifest
+	Mismatched    []string  `json:"mismatched,omitempty"`      // files whose size or hash differs from the manifest
+	MismatchCount int       `json:"mismatch_count"`
+	Missing       []string  `json:"missing,omitempty"` // manifest files not in the target
+	MissingCount  int       `json:"missing_count"`
+	ErrorMessage  string    `json:"error_message,omitempty"`
+	StartTime     time.Time `json:"start_time"`
+	EndTime       time.Time `json:"end_time"`
+}
+
+// Problems returns how many files didn't match the manifest
+func (r *RestoreReport) Problems() int {
+	return r.MismatchCount + r.MissingCount
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Run webhook events
+const (
+	RunWebhookStarted  = "started"
+	RunWebhookFinished = "finished"
+)
+
+// RunWebhook is an endpoint that a board or flow run's context is POSTed to
+// when the run starts and finishes, so dashboards can follow whole
+// workflows rather than single syncs
+type RunWebhook struct {
+	URL     string       `json:"url"`
+	Headers []HTTPHeader `json:"headers,omitempty"`
+	Events  []string     `json:"events,omitempty"` // RunWebhookStarted and/or RunWebhookFinished; empty = both
+}
+
+// Wants reports whether the webhook is sent for event
+func (w RunWebhook) Wants(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// RunWebhookPayload is the JSON body POSTed to run webhooks
+type RunWebhookPayload struct {
+	Event            string           `json:"event"`  // RunWebhookStarted or RunWebhookFinished
+	Kind             string           `json:"kind"`   // "board" or "flow"
+	Id               string           `json:"id"`     // board or flow ID
+	Name             string           `json:"name"`   // board or flow name
+	Status           string           `json:"status"` // "running", "completed", "failed", "cancelled"
+	StartTime        time.Time        `json:"start_time"`
+	EndTime          *time.Time       `json:"end_time,omitempty"`
+	Cards            []RunWebhookCard `json:"cards"` // board cards, or flow steps in order
+	FilesTransferred int64            `json:"files_transferred"`
+	BytesTransferred int64            `json:"bytes_transferred"`
+	Errors           int              `json:"errors"`             // transfer errors of all cards
+	Failures         []string         `json:"failures,omitempty"` // messages of the failed cards
+}
+
+// RunWebhookCard is the status of a board card or flow step in a run webhook
+type RunWebhookCard struct {
+	Id               string `json:"id"`
+	Name             string `json:"name,omitempty"` // "source -> target" for board cards, the step type for flow steps
+	Status           string `json:"status"`         // "pending", "running", "completed", "failed", "skipped"
+	Message          string `json:"message,omitempty"`
+	TaskId           int    `json:"task_id,omitempty"`
+	FilesTransferred int64  `json:"files_transferred"`
+	BytesTransferred int64  `json:"bytes_transferred"`
+}
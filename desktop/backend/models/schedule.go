@@ -4,13 +4,27 @@ import "time"
 
 // ScheduleEntry represents a scheduled sync operation
 type ScheduleEntry struct {
-	Id          string     `json:"id"`
-	ProfileName string     `json:"profile_name"`
-	Action      string     `json:"action"`      // "pull", "push", "bi", "bi-resync", "copy", "move"
-	CronExpr    string     `json:"cron_expr"`   // cron expression e.g. "0 */6 * * *"
-	Enabled     bool       `json:"enabled"`
-	LastRun     *time.Time `json:"last_run,omitempty"`
-	NextRun     *time.Time `json:"next_run,omitempty"`
-	LastResult  string     `json:"last_result,omitempty"` // "success", "failed", "cancelled"
-	CreatedAt   time.Time  `json:"created_at"`
+	Id                string     `json:"id"`
+	ProfileName       string     `json:"profile_name"`
+	Action            string     `json:"action"`                   // "pull", "push", "bi", "bi-resync", "copy", "move"; "check" or "download" for verify
+	CronExpr          string     `json:"cron_expr"`                // cron expression e.g. "0 */6 * * *", with an optional leading seconds field
+	Timezone          string     `json:"timezone,omitempty"`       // IANA time zone the expression is in, e.g. "Europe/Paris"; local time when empty
+	TargetType        string     `json:"target_type,omitempty"`    // "profile" (default), "board", "flow", "lifecycle", "verify", "drift"
+	TargetId          string     `json:"target_id,omitempty"`      // board, flow or lifecycle rule ID for those target types
+	OverlapPolicy     string     `json:"overlap_policy,omitempty"` // "skip" (default), "queue", "cancel" — applied when the previous run is still active
+	CatchUp           string     `json:"catch_up,omitempty"`       // "skip" (default), "once", "all" — for fire times missed while the machine slept or the app was closed
+	Tags              []string   `json:"tags,omitempty"`
+	Enabled           bool       `json:"enabled"`
+	SuspendedRemote   string     `json:"suspended_remote,omitempty"`     // remote whose suspension paused this schedule; empty when not suspended
+	BlockingProcesses []string   `json:"blocking_processes,omitempty"`   // applications (process names, case-insensitive) that hold off runs while running
+	PauseForProcesses bool       `json:"pause_for_processes,omitempty"`  // also pause a run in progress when a blocking application starts
+	Urgent            bool       `json:"urgent,omitempty"`               // runs even when a provider's daily API budget is nearly used up
+	SamplePercent     float64    `json:"sample_percent,omitempty"`       // verify schedules: check only this percent of the files, plus recently changed ones
+	WindowMinutes     int        `json:"window_minutes,omitempty"`       // how long a run may take, e.g. overnight; runs are forecast against it
+	SplitAcrossWindow bool       `json:"split_across_windows,omitempty"` // stop a run at the end of its window and carry on at the next trigger
+	LastRun           *time.Time `json:"last_run,omitempty"`
+	NextRun           *time.Time `json:"next_run,omitempty"`
+	LastResult        string     `json:"last_result,omitempty"`  // "success", "failed", "cancelled", "skipped", "partial" (stopped at the end of its window)
+	PausedUntil       *time.Time `json:"paused_until,omitempty"` // set by PauseSchedules; triggers before it are skipped
+	CreatedAt         time.Time  `json:"created_at"`
 }
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// SecretInfo describes a secret in the vault. The value is never returned to
+// the frontend; flow steps reference secrets by name.
+type SecretInfo struct {
+	Name      string    `json:"name"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
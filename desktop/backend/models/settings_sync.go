@@ -0,0 +1,74 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Settings sync results
+const (
+	SettingsSyncUpToDate = "up_to_date"
+	SettingsSyncPushed   = "pushed"   // the local configuration was written to the remote path
+	SettingsSyncPulled   = "pulled"   // the remote configuration was applied here
+	SettingsSyncMerged   = "merged"   // first sync of this installation: both sides were combined
+	SettingsSyncConflict = "conflict" // both sides changed; the last writer won (see SettingsSyncConflictInfo)
+	SettingsSyncFailed   = "failed"
+)
+
+// Sides of a settings sync conflict
+const (
+	SettingsSyncLocal  = "local"
+	SettingsSyncRemote = "remote"
+)
+
+// Kinds of synced items
+const (
+	SettingsSyncProfile  = "profile"
+	SettingsSyncBoard    = "board"
+	SettingsSyncSchedule = "schedule"
+)
+
+// SettingsSync is how profiles, boards and schedules are kept in sync with
+// other installations through an encrypted export archive at a remote path
+type SettingsSync struct {
+	RemotePath      string     `json:"remote_path"`                // e.g. "gdrive:ng-drive/settings.nsd"
+	Password        string     `json:"password,omitempty"`         // encrypts the archive; only set, never returned
+	HasPassword     bool       `json:"has_password"`               // read only
+	IntervalMinutes int        `json:"interval_minutes,omitempty"` // sync this often; 0 = only with SyncSettingsNow
+	MachineId       string     `json:"machine_id,omitempty"`       // read only: this installation
+	LastSyncAt      *time.Time `json:"last_sync_at,omitempty"`     // read only
+	LastResult      string     `json:"last_result,omitempty"`      // read only: one of the SettingsSync* results
+	LastError       string     `json:"last_error,omitempty"`       // read only
+	Warnings        []string   `json:"warnings,omitempty"`         // read only: items the last sync couldn't apply here
+	Conflict        bool       `json:"conflict,omitempty"`         // read only: a conflict waits for a manual merge
+}
+
+// SettingsSyncConflictInfo is what both sides had when they both changed
+// since the last sync. The winner's version is in effect on both; the
+// items that differ can be taken from either side with
+// ResolveSettingsSyncConflict.
+type SettingsSyncConflictInfo struct {
+	DetectedAt      time.Time          `json:"detected_at"`
+	Winner          string             `json:"winner"` // SettingsSyncLocal or SettingsSyncRemote
+	LocalChangedAt  *time.Time         `json:"local_changed_at,omitempty"`
+	RemoteChangedAt *time.Time         `json:"remote_changed_at,omitempty"`
+	RemoteMachine   string             `json:"remote_machine,omitempty"`
+	Items           []SettingsSyncItem `json:"items"`
+}
+
+// SettingsSyncItem is a profile, board or schedule that differs between
+// the two sides of a conflict
+type SettingsSyncItem struct {
+	Kind   string          `json:"kind"` // SettingsSyncProfile, SettingsSyncBoard or SettingsSyncSchedule
+	Id     string          `json:"id"`   // profile name, board or schedule ID
+	Name   string          `json:"name"`
+	Local  json.RawMessage `json:"local,omitempty"`  // nil when only the remote side has it
+	Remote json.RawMessage `json:"remote,omitempty"` // nil when only the local side has it
+}
+
+// SettingsSyncChoice picks the side of a conflict item to keep
+type SettingsSyncChoice struct {
+	Kind string `json:"kind"`
+	Id   string `json:"id"`
+	Side string `json:"side"` // SettingsSyncLocal or SettingsSyncRemote
+}
@@ -0,0 +1,29 @@
+package models
+
+// Planned change types of a sync preview
+const (
+	PlannedCreate = "create"
+	PlannedUpdate = "update"
+	PlannedDelete = "delete"
+)
+
+// SyncPreview lists what a push or pull of a profile would change on its
+// destination, worked out with a dry run
+type SyncPreview struct {
+	ProfileName string          `json:"profile_name"`
+	Action      string          `json:"action"` // "push" or "pull"
+	Creates     int64           `json:"creates"`
+	Updates     int64           `json:"updates"`
+	Deletes     int64           `json:"deletes"`
+	Bytes       int64           `json:"bytes"`   // to transfer for the creates and updates
+	Changes     []PlannedChange `json:"changes"` // the first changes, by path
+	Truncated   bool            `json:"truncated,omitempty"`
+}
+
+// PlannedChange is a file a sync would create, update or delete
+type PlannedChange struct {
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`      // of the file copied, or of the file deleted
+	Type      string `json:"type"`      // Planned*
+	Direction string `json:"direction"` // "from->to" for a push, "to->from" for a pull
+}
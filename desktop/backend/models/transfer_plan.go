@@ -0,0 +1,12 @@
+package models
+
+// TransferPlan previews the files a one-way run of a profile would
+// transfer, in the order it starts them (see Profile.TransferOrder)
+type TransferPlan struct {
+	ProfileName string       `json:"profile_name"`
+	Action      string       `json:"action"` // "push" or "pull"
+	Order       string       `json:"order"`  // "smallest", "largest", "newest" (sessions) or "" for rclone's listing order
+	Files       int64        `json:"files"`
+	Bytes       int64        `json:"bytes"`
+	Queue       []QueuedFile `json:"queue"` // the first files, in transfer order
+}
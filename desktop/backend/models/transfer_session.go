@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// TransferSession tracks a profile synced in capped sessions over a slow
+// link (see Profile.SessionTransfer): what is left to transfer, in the
+// order the next sessions transfer it
+type TransferSession struct {
+	ProfileName      string       `json:"profile_name"`
+	Action           string       `json:"action"` // "push" or "pull"
+	Order            string       `json:"order"`  // "smallest" or "newest"
+	Status           string       `json:"status"` // "in_progress" while files are left, "complete"
+	Sessions         int          `json:"sessions"`
+	LastSessionFiles int64        `json:"last_session_files"`
+	LastSessionBytes int64        `json:"last_session_bytes"`
+	RemainingFiles   int64        `json:"remaining_files"`
+	RemainingBytes   int64        `json:"remaining_bytes"`
+	Queue            []QueuedFile `json:"queue"` // the first files left, in transfer order
+	StartedAt        time.Time    `json:"started_at"`
+	UpdatedAt        time.Time    `json:"updated_at"`
+}
+
+// QueuedFile is a file waiting for a later session
+type QueuedFile struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
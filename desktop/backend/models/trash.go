@@ -0,0 +1,27 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Kinds of trashed items
+const (
+	TrashProfile  = "profile"
+	TrashBoard    = "board"
+	TrashFlow     = "flow"
+	TrashSchedule = "schedule"
+)
+
+// TrashItem is a deleted profile, board, flow or schedule. It can be
+// restored until it expires; history stays linked to it by name or ID, so
+// a restored item has its history back.
+type TrashItem struct {
+	Kind         string          `json:"kind"` // TrashProfile, TrashBoard, TrashFlow or TrashSchedule
+	Id           string          `json:"id"`   // profile name, or board, flow or schedule ID
+	Name         string          `json:"name"`
+	DeletedAt    time.Time       `json:"deleted_at"`
+	ExpiresAt    time.Time       `json:"expires_at"`              // purged after this
+	HistoryCount int             `json:"history_count,omitempty"` // profiles: runs in history
+	Data         json.RawMessage `json:"data"`                    // the item as it was deleted
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// UsageDrift compares how much a remote's provider-reported usage changed
+// between two checks with the bytes the app wrote to it in between
+type UsageDrift struct {
+	Remote       string     `json:"remote"`
+	Used         int64      `json:"used"`                   // provider-reported usage at the last check
+	UsageDelta   int64      `json:"usage_delta"`            // change of Used over the last compared period
+	WrittenBytes int64      `json:"written_bytes"`          // bytes the app wrote over that period
+	DriftBytes   int64      `json:"drift_bytes"`            // UsageDelta - WrittenBytes; negative is expected from deletions and overwrites
+	Flagged      bool       `json:"flagged"`                // usage grew by significantly more than the app wrote
+	PeriodStart  *time.Time `json:"period_start,omitempty"` // start of the compared period; nil until a second check
+	CheckedAt    time.Time  `json:"checked_at"`
+}
@@ -0,0 +1,85 @@
+package models
+
+import "time"
+
+// VerifyReport is the result of verifying a profile's destination against
+// its source without transferring anything, e.g. from a schedule with
+// target type "verify". Path lists are capped; the counts are not.
+type VerifyReport struct {
+	Id           int64         `json:"id"`
+	ScheduleId   string        `json:"schedule_id,omitempty"`
+	ProfileName  string        `json:"profile_name"`
+	Source       string        `json:"source"`
+	Destination  string        `json:"destination"`
+	Download     bool          `json:"download"`              // contents were compared, not hashes or sizes
+	CryptCheck   bool          `json:"crypt_check,omitempty"` // the encrypted destination's hashes were compared, like rclone cryptcheck
+	Sample       *VerifySample `json:"sample,omitempty"`      // set when only a sample of the files was checked
+	Status       string        `json:"status"`                // "ok", "problems", "failed"
+	Matched      int           `json:"matched"`               // files identical on both sides
+	Differ       []string      `json:"differ,omitempty"`      // files whose destination copy differs, e.g. corrupted
+	DifferCount  int           `json:"differ_count"`
+	MissingOnDst []string      `json:"missing_on_dst,omitempty"` // source files missing from the destination
+	MissingCount int           `json:"missing_count"`
+	MissingOnSrc []string      `json:"missing_on_src,omitempty"` // destination files no longer in the source
+	ExtraCount   int           `json:"extra_count"`
+	Errors       []string      `json:"errors,omitempty"` // files that could not be read or compared
+	ErrorCount   int           `json:"error_count"`
+	ErrorMessage string        `json:"error_message,omitempty"`
+	StartTime    time.Time     `json:"start_time"`
+	EndTime      time.Time     `json:"end_time"`
+}
+
+// Problems returns how many files are corrupted, missing from the
+// destination or unreadable. Files only in the destination aren't problems.
+func (r *VerifyReport) Problems() int {
+	return r.DifferCount + r.MissingCount + r.ErrorCount
+}
+
+// MultiVerifyReport is the result of verifying all of a profile's
+// destinations against its source in one pass, e.g. the copies of a 3-2-1
+// backup. Reports has one report per destination, in the profile's order.
+type MultiVerifyReport struct {
+	ProfileName string           `json:"profile_name"`
+	Source      string           `json:"source"`
+	Download    bool             `json:"download"`
+	Status      string           `json:"status"`       // "ok" when every copy is up to date, "problems", "failed"
+	SourceFiles int              `json:"source_files"` // files in the source
+	Reports     []VerifyReport   `json:"reports"`
+	OutOfDate   []string         `json:"out_of_date,omitempty"` // destinations with problems or that couldn't be verified
+	Files       []VerifyFileCopy `json:"files,omitempty"`       // source files out of date in some copy, capped
+	FileCount   int              `json:"file_count"`
+	StartTime   time.Time        `json:"start_time"`
+	EndTime     time.Time        `json:"end_time"`
+}
+
+// VerifyFileCopy lists the destinations whose copy of a source file is out
+// of date
+type VerifyFileCopy struct {
+	Path    string   `json:"path"`
+	Differ  []string `json:"differ,omitempty"`  // destinations whose copy differs
+	Missing []string `json:"missing,omitempty"` // destinations without a copy
+	Errors  []string `json:"errors,omitempty"`  // destinations whose copy couldn't be read or compared
+}
+
+// VerifyOptions chooses how a verification compares files, and whether it
+// checks all of them or a sample
+type VerifyOptions struct {
+	Download      bool    `json:"download"`                 // compare contents instead of hashes or sizes
+	SamplePercent float64 `json:"sample_percent,omitempty"` // check only this percent of the files; 0 or 100 checks all
+	Seed          int64   `json:"seed,omitempty"`           // selects the sample; 0 picks a new one
+}
+
+// VerifySample describes the files a sampled verification checked: a
+// random selection that the seed reproduces, plus the files changed since
+// the profile was last verified. The problem bound is estimated from the
+// random selection only.
+type VerifySample struct {
+	Seed              int64   `json:"seed"` // verify with it again to check the same files
+	Percent           float64 `json:"percent"`
+	TotalFiles        int     `json:"total_files"`         // files in the source
+	SampledFiles      int     `json:"sampled_files"`       // randomly selected files
+	RecentFiles       int     `json:"recent_files"`        // recently changed files checked besides them
+	SampleProblems    int     `json:"sample_problems"`     // problems among the randomly selected files
+	Confidence        float64 `json:"confidence"`          // e.g. 0.95
+	MaxProblemPercent float64 `json:"max_problem_percent"` // with that confidence, at most this percent of all files have problems
+}
@@ -0,0 +1,80 @@
+package rclone
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/rclone/rclone/fs/fshttp"
+)
+
+// apiMetrics counts every HTTP request rclone's transports make, by host.
+// Transports pick up fshttp.DefaultMetrics when they are created, so it is
+// set before any remote is used.
+var apiMetrics = fshttp.NewMetrics("gn_drive")
+
+func init() {
+	fshttp.DefaultMetrics = apiMetrics
+}
+
+// providerHosts maps API host suffixes to the rclone backend type whose
+// quota their calls count against. Hosts that only serve file contents
+// (e.g. OneDrive download links) aren't listed.
+var providerHosts = []struct{ suffix, provider string }{
+	{"photoslibrary.googleapis.com", "googlephotos"},
+	{"googleapis.com", "drive"},
+	{"dropboxapi.com", "dropbox"},
+	{"graph.microsoft.com", "onedrive"},
+	{"cloud-api.yandex.net", "yandex"},
+	{"icloud.com", "iclouddrive"},
+}
+
+// KnownDailyQuotas are the documented per-day API call quotas of providers
+// that have one, keyed by rclone backend type
+var KnownDailyQuotas = map[string]int64{
+	"drive":        1_000_000_000, // queries per day per project
+	"googlephotos": 10_000,        // Library API requests per day per project
+}
+
+// providerForHost returns the provider whose API a host serves, or "" for
+// hosts that aren't a known provider's API
+func providerForHost(host string) string {
+	host = strings.ToLower(host)
+	if i := strings.LastIndex(host, ":"); i >= 0 && !strings.HasSuffix(host, "]") {
+		host = host[:i]
+	}
+	for _, p := range providerHosts {
+		if host == p.suffix || strings.HasSuffix(host, "."+p.suffix) {
+			return p.provider
+		}
+	}
+	return ""
+}
+
+// APICallCounts returns how many API calls each provider has received since
+// the app started, failed and rate-limited ones included. The counts are
+// estimates of quota use: providers may count some calls differently.
+func APICallCounts() map[string]int64 {
+	ch := make(chan prometheus.Metric, 64)
+	go func() {
+		apiMetrics.StatusCode.Collect(ch)
+		close(ch)
+	}()
+
+	counts := make(map[string]int64)
+	for metric := range ch {
+		var m dto.Metric
+		if err := metric.Write(&m); err != nil || m.Counter == nil {
+			continue
+		}
+		for _, label := range m.Label {
+			if label.GetName() != "host" {
+				continue
+			}
+			if provider := providerForHost(label.GetValue()); provider != "" {
+				counts[provider] += int64(m.Counter.GetValue())
+			}
+		}
+	}
+	return counts
+}
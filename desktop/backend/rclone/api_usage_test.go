@@ -0,0 +1,39 @@
+package rclone
+
+import "testing"
+
+func TestProviderForHost(t *testing.T) {
+	tests := map[string]string{
+		"www.googleapis.com":               "drive",
+		"photoslibrary.googleapis.com:443": "googlephotos",
+		"content.dropboxapi.com":           "dropbox",
+		"Graph.Microsoft.com":              "onedrive",
+		"public.sn.files.1drv.com":         "",
+		"example.com":                      "",
+		"notgoogleapis.com":                "",
+	}
+	for host, want := range tests {
+		if got := providerForHost(host); got != want {
+			t.Errorf("providerForHost(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
+
+func TestAPICallCounts(t *testing.T) {
+	before := APICallCounts()
+	apiMetrics.StatusCode.WithLabelValues("www.googleapis.com", "GET", "200").Inc()
+	apiMetrics.StatusCode.WithLabelValues("www.googleapis.com", "POST", "403").Inc()
+	apiMetrics.StatusCode.WithLabelValues("api.dropboxapi.com", "POST", "200").Inc()
+	apiMetrics.StatusCode.WithLabelValues("127.0.0.1:8080", "GET", "200").Inc()
+
+	after := APICallCounts()
+	if got := after["drive"] - before["drive"]; got != 2 {
+		t.Errorf("drive calls = %d, want 2", got)
+	}
+	if got := after["dropbox"] - before["dropbox"]; got != 1 {
+		t.Errorf("dropbox calls = %d, want 1", got)
+	}
+	if len(after) != 2 {
+		t.Errorf("unexpected providers: %v", after)
+	}
+}
@@ -0,0 +1,246 @@
+package rclone
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"desktop/backend/models"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/rclone/rclone/backend/crypt"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/rclone/rclone/fs/filter"
+)
+
+// EncryptedArchiveSuffix is appended to encrypted archives, matching what an
+// rclone crypt remote with filename encryption off expects
+const EncryptedArchiveSuffix = ".bin"
+
+// ArchiveOptions configures CreateArchive
+type ArchiveOptions struct {
+	Format        string   // models.ArchiveFormatZip (default) or models.ArchiveFormatTarZst
+	IncludedPaths []string // rclone filter rules, relative to the source directory
+	ExcludedPaths []string
+	Password      string // if set, the archive is encrypted in rclone crypt format
+}
+
+// ArchiveExtension returns the file extension for an archive format
+func ArchiveExtension(format string) (string, error) {
+	switch format {
+	case "", models.ArchiveFormatZip:
+		return ".zip", nil
+	case models.ArchiveFormatTarZst:
+		return ".tar.zst", nil
+	}
+	return "", fmt.Errorf("unknown archive format '%s'", format)
+}
+
+// CreateArchive writes the files of the local directory srcDir that pass the
+// filter rules to an archive at dstPath. With a password the archive can be
+// read back through an rclone crypt remote (filename encryption off) or
+// DecryptArchive. Returns the number of files archived.
+func CreateArchive(ctx context.Context, srcDir, dstPath string, opt ArchiveOptions) (int, error) {
+	if _, err := ArchiveExtension(opt.Format); err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(srcDir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to access %s: %w", srcDir, err)
+	}
+	if !info.IsDir() {
+		return 0, fmt.Errorf("%s is not a directory", srcDir)
+	}
+
+	filterOpt := newDefaultFilterOpts()
+	filterOpt.IncludeRule = append([]string(nil), opt.IncludedPaths...)
+	filterOpt.ExcludeRule = append([]string(nil), opt.ExcludedPaths...)
+	fi, err := filter.NewFilter(&filterOpt)
+	if err != nil {
+		return 0, fmt.Errorf("invalid filter rules: %w", err)
+	}
+
+	out, err := os.OpenFile(dstPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer out.Close()
+
+	var count int
+	if opt.Password == "" {
+		count, err = writeArchive(ctx, out, srcDir, opt.Format, fi)
+	} else {
+		count, err = writeEncryptedArchive(ctx, out, srcDir, opt, fi)
+	}
+	if err == nil {
+		err = out.Close()
+	}
+	if err != nil {
+		os.Remove(dstPath)
+		return 0, err
+	}
+	return count, nil
+}
+
+// DecryptArchive returns a reader for the plain archive of an encrypted one
+func DecryptArchive(in io.ReadCloser, password string) (io.ReadCloser, error) {
+	cipher, err := newArchiveCipher(password)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.DecryptData(in)
+}
+
+// newArchiveCipher returns the rclone crypt cipher for an archive password
+func newArchiveCipher(password string) (*crypt.Cipher, error) {
+	obscured, err := obscure.Obscure(password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obscure password: %w", err)
+	}
+	cipher, err := crypt.NewCipher(configmap.Simple{
+		"password":            obscured,
+		"filename_encryption": "off",
+		"filename_encoding":   "base32",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher, nil
+}
+
+// writeEncryptedArchive streams the archive through the crypt cipher into out
+func writeEncryptedArchive(ctx context.Context, out io.Writer, srcDir string, opt ArchiveOptions, fi *filter.Filter) (int, error) {
+	cipher, err := newArchiveCipher(opt.Password)
+	if err != nil {
+		return 0, err
+	}
+
+	pr, pw := io.Pipe()
+	var count int
+	go func() {
+		var err error
+		count, err = writeArchive(ctx, pw, srcDir, opt.Format, fi)
+		pw.CloseWithError(err)
+	}()
+
+	encrypted, err := cipher.EncryptData(pr)
+	if err != nil {
+		pr.CloseWithError(err)
+		return 0, fmt.Errorf("failed to encrypt archive: %w", err)
+	}
+	if _, err := io.Copy(out, encrypted); err != nil {
+		pr.CloseWithError(err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// writeArchive writes the filtered files of srcDir to w in the given format
+func writeArchive(ctx context.Context, w io.Writer, srcDir, format string, fi *filter.Filter) (int, error) {
+	var add func(rel string, info fs.FileInfo, path string) error
+	var finish func() error
+
+	switch format {
+	case models.ArchiveFormatTarZst:
+		zw, err := zstd.NewWriter(w)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create zstd writer: %w", err)
+		}
+		defer zw.Close()
+		tw := tar.NewWriter(zw)
+		add = func(rel string, info fs.FileInfo, path string) error {
+			hdr, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return err
+			}
+			hdr.Name = rel
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			return copyFileTo(tw, path)
+		}
+		finish = func() error {
+			if err := tw.Close(); err != nil {
+				return err
+			}
+			return zw.Close()
+		}
+	default:
+		zw := zip.NewWriter(w)
+		add = func(rel string, info fs.FileInfo, path string) error {
+			hdr, err := zip.FileInfoHeader(info)
+			if err != nil {
+				return err
+			}
+			hdr.Name = rel
+			hdr.Method = zip.Deflate
+			fw, err := zw.CreateHeader(hdr)
+			if err != nil {
+				return err
+			}
+			return copyFileTo(fw, path)
+		}
+		finish = zw.Close
+	}
+
+	count := 0
+	err := filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !fi.Include(rel, info.Size(), info.ModTime(), nil) {
+			return nil
+		}
+		if err := add(rel, info, path); err != nil {
+			return fmt.Errorf("failed to archive %s: %w", rel, err)
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := finish(); err != nil {
+		return 0, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return count, nil
+}
+
+// copyFileTo copies the file at path to w
+func copyFileTo(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// ArchiveName returns a default archive file name (without extension) for a
+// source directory, e.g. "documents-20240102-150405"
+func ArchiveName(srcDir string, now time.Time) string {
+	return filepath.Base(filepath.Clean(srcDir)) + "-" + now.Format("20060102-150405")
+}
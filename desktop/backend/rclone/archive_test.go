@@ -0,0 +1,170 @@
+package rclone
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"desktop/backend/models"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func makeArchiveSource(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"report.txt":      "quarterly numbers",
+		"photos/a.jpg":    "jpeg bytes",
+		"cache/tmp.bin":   "scratch",
+		"photos/b.jpg":    "more jpeg bytes",
+		"notes/todo.md":   "- back up",
+		"notes/.DS_Store": "junk",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func zipEntries(t *testing.T, path string) map[string]string {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	entries := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		entries[f.Name] = string(data)
+	}
+	return entries
+}
+
+func tarEntries(t *testing.T, r io.Reader) map[string]string {
+	t.Helper()
+	zr, err := zstd.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+	entries := map[string]string{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(tr)
+		entries[hdr.Name] = string(data)
+	}
+	return entries
+}
+
+func entryNames(entries map[string]string) string {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func TestCreateArchive_ZipWithFilters(t *testing.T) {
+	src := makeArchiveSource(t)
+	dst := filepath.Join(t.TempDir(), "out.zip")
+
+	count, err := CreateArchive(context.Background(), src, dst, ArchiveOptions{
+		ExcludedPaths: []string{"/cache/**", ".DS_Store"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 4 {
+		t.Errorf("count = %d, want 4", count)
+	}
+	entries := zipEntries(t, dst)
+	if got := entryNames(entries); got != "notes/todo.md,photos/a.jpg,photos/b.jpg,report.txt" {
+		t.Errorf("entries = %s", got)
+	}
+	if entries["report.txt"] != "quarterly numbers" {
+		t.Errorf("report.txt = %q", entries["report.txt"])
+	}
+}
+
+func TestCreateArchive_TarZst(t *testing.T) {
+	src := makeArchiveSource(t)
+	dst := filepath.Join(t.TempDir(), "out.tar.zst")
+
+	if _, err := CreateArchive(context.Background(), src, dst, ArchiveOptions{
+		Format:        models.ArchiveFormatTarZst,
+		IncludedPaths: []string{"/photos/**"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	entries := tarEntries(t, f)
+	if got := entryNames(entries); got != "photos/a.jpg,photos/b.jpg" {
+		t.Errorf("entries = %s", got)
+	}
+}
+
+func TestCreateArchive_Encrypted(t *testing.T) {
+	src := makeArchiveSource(t)
+	dst := filepath.Join(t.TempDir(), "out.tar.zst.bin")
+
+	if _, err := CreateArchive(context.Background(), src, dst, ArchiveOptions{
+		Format:   models.ArchiveFormatTarZst,
+		Password: "correct horse",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := DecryptArchive(f, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plain.Close()
+	entries := tarEntries(t, plain)
+	if entries["notes/todo.md"] != "- back up" || len(entries) != 6 {
+		t.Errorf("entries = %s", entryNames(entries))
+	}
+}
+
+func TestCreateArchive_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := CreateArchive(context.Background(), filepath.Join(dir, "missing"), filepath.Join(dir, "a.zip"), ArchiveOptions{}); err == nil {
+		t.Error("expected error for missing source")
+	}
+	if _, err := CreateArchive(context.Background(), dir, filepath.Join(dir, "a.rar"), ArchiveOptions{Format: "rar"}); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
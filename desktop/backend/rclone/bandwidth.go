@@ -0,0 +1,41 @@
+package rclone
+
+import (
+	"fmt"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+)
+
+// SetGlobalBandwidthLimit caps the bandwidth of all transfers, in progress
+// and to come, e.g. "512k" or "1M:256k" (upload:download). With several
+// limits the lowest in each direction applies; empty limits are ignored,
+// and without any the cap is removed.
+func SetGlobalBandwidthLimit(limits ...string) error {
+	var bw fs.BwPair
+	for _, limit := range limits {
+		if limit == "" {
+			continue
+		}
+		var pair fs.BwPair
+		if err := pair.Set(limit); err != nil {
+			return fmt.Errorf("invalid bandwidth limit %q: %w", limit, err)
+		}
+		bw.Tx = lowerBandwidth(bw.Tx, pair.Tx)
+		bw.Rx = lowerBandwidth(bw.Rx, pair.Rx)
+	}
+	accounting.TokenBucket.SetBwLimit(bw)
+	return nil
+}
+
+// lowerBandwidth returns the lower of two bandwidth limits, where 0 or less
+// means unlimited
+func lowerBandwidth(a, b fs.SizeSuffix) fs.SizeSuffix {
+	switch {
+	case a <= 0:
+		return b
+	case b <= 0:
+		return a
+	}
+	return min(a, b)
+}
@@ -0,0 +1,42 @@
+package rclone
+
+import (
+	"testing"
+
+	"github.com/rclone/rclone/fs"
+)
+
+func TestSetGlobalBandwidthLimit(t *testing.T) {
+	defer SetGlobalBandwidthLimit("")
+
+	if err := SetGlobalBandwidthLimit("512k"); err != nil {
+		t.Errorf("SetGlobalBandwidthLimit(512k): %v", err)
+	}
+	if err := SetGlobalBandwidthLimit("1M:256k"); err != nil {
+		t.Errorf("SetGlobalBandwidthLimit(1M:256k): %v", err)
+	}
+	if err := SetGlobalBandwidthLimit("512k", "", "1M:256k"); err != nil {
+		t.Errorf("SetGlobalBandwidthLimit(512k, 1M:256k): %v", err)
+	}
+	if err := SetGlobalBandwidthLimit("fast"); err == nil {
+		t.Error("expected an error for an invalid limit")
+	}
+	if err := SetGlobalBandwidthLimit(""); err != nil {
+		t.Errorf("removing the limit: %v", err)
+	}
+}
+
+func TestLowerBandwidth(t *testing.T) {
+	tests := []struct{ a, b, want fs.SizeSuffix }{
+		{0, 512, 512},
+		{512, 0, 512},
+		{-1, 512, 512},
+		{1024, 512, 512},
+		{0, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := lowerBandwidth(tt.a, tt.b); got != tt.want {
+			t.Errorf("lowerBandwidth(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
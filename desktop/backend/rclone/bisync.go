@@ -13,8 +13,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/rclone/rclone/cmd/bisync"
+	"github.com/rclone/rclone/cmd/bisync/bilib"
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/filter"
 )
@@ -48,10 +50,9 @@ func BiSync(ctx context.Context, config config.Config, profile models.Profile, r
 		return fmt.Errorf("invalid conflict_loser %q: %w", conflictLoser, err)
 	}
 
-	// Conflict suffix
-	if profile.ConflictSuffix != "" {
-		opt.ConflictSuffixFlag = profile.ConflictSuffix
-	}
+	// Conflict suffix, with the host and run time if the profile names
+	// conflict copies by them
+	opt.ConflictSuffixFlag = conflictSuffixFlag(profile, conflictHostname(), time.Now())
 
 	if err = opt.CheckSync.Set(bisync.CheckSyncFalse.String()); err != nil {
 		return err
@@ -142,16 +143,23 @@ func BiSync(ctx context.Context, config config.Config, profile models.Profile, r
 		}
 	}
 
-	srcFs, err := fs.NewFs(ctx, profile.From)
+	srcFs, err := newFs(ctx, objectOptionsRemote(profile.From, profile))
 	if utils.HandleError(err, "Failed to initialize source filesystem", nil, nil) != nil {
 		return err
 	}
 
-	dstFs, err := fs.NewFs(ctx, profile.To)
+	dstFs, err := newFs(ctx, objectOptionsRemote(profile.To, profile))
 	if utils.HandleError(err, "Failed to initialize destination filesystem", nil, nil) != nil {
 		return err
 	}
 
+	// Bisync aborts without the listings of a previous run, e.g. when the
+	// first resync failed or the cache was cleared: bootstrap with a resync
+	if !opt.Resync && !hasBisyncListings(ctx, dstFs, srcFs) {
+		log.Printf("[bisync] No prior listings for %s and %s, running a resync", profile.To, profile.From)
+		opt.Resync = true
+	}
+
 	// Set up filter rules (prefix with {{regexp:}} if UseRegex is enabled)
 	filterOpt := CopyFilterOpt(ctx)
 	for _, p := range profile.IncludedPaths {
@@ -168,6 +176,11 @@ func BiSync(ctx context.Context, config config.Config, profile models.Profile, r
 			filterOpt.ExcludeRule = append(filterOpt.ExcludeRule, p)
 		}
 	}
+	// Conflict copies gathered in the conflict folder stay on their side
+	conflictDir := conflictFolder(profile)
+	if conflictDir != "" {
+		filterOpt.ExcludeRule = append(filterOpt.ExcludeRule, "/"+conflictDir+"/**")
+	}
 	newFilter, err := filter.NewFilter(&filterOpt)
 	if err := utils.HandleError(err, "Invalid filters file", nil, func() {
 		ctx = filter.ReplaceConfig(ctx, newFilter)
@@ -201,6 +214,8 @@ func BiSync(ctx context.Context, config config.Config, profile models.Profile, r
 	// Delta sync: check if we can skip bisync entirely (not for resync)
 	srcKey := remoteKey(profile.From)
 	dstKey := remoteKey(profile.To)
+	startedAt := time.Now()
+	run := delta.RunInfo{Mode: delta.RunModeFull, FallbackReason: "resync requested"}
 
 	if deltaSvc != nil && !resync && !opt.Resync {
 		if deltaSvc.ShouldSkipSync(srcKey) && deltaSvc.ShouldSkipSync(dstKey) {
@@ -208,20 +223,48 @@ func BiSync(ctx context.Context, config config.Config, profile models.Profile, r
 			sendSkippedStatus(outStatus)
 			_ = deltaSvc.CommitDelta(srcKey)
 			_ = deltaSvc.CommitDelta(dstKey)
+			deltaSvc.RecordRun(srcKey, delta.RunInfo{Mode: delta.RunModeSkipped, Duration: time.Since(startedAt)})
 			return nil
 		}
+		// Bisync can't be scoped; any change means a full run
+		run.FallbackReason = untrustedReason(deltaSvc, srcKey, dstKey)
+		if run.FallbackReason == "" {
+			run.FallbackReason = "changes detected (bisync can't be scoped)"
+		}
 	}
 
 	syncErr := utils.RunRcloneWithRetryAndStats(ctx, true, false, outStatus, func() error {
 		return utils.HandleError(bisync.Bisync(ctx, dstFs, srcFs, opt), "Sync failed", nil, nil)
 	})
 
+	// Gather the conflict copies the run kept into the conflict folder
+	if syncErr == nil && conflictDir != "" && !opt.DryRun {
+		for _, f := range []fs.Fs{srcFs, dstFs} {
+			moved, err := moveConflictCopies(ctx, f, profile)
+			if err != nil {
+				log.Printf("[bisync] warning: %v", err)
+			}
+			if moved > 0 {
+				log.Printf("[bisync] Moved %d conflict copies into %s on %s", moved, conflictDir, fs.ConfigString(f))
+			}
+		}
+	}
+
 	// Commit delta state after bisync
 	if deltaSvc != nil && syncErr == nil {
 		// After bisync (or resync), establish baseline and start watchers
 		_ = deltaSvc.CommitFullSync(srcFs, srcKey)
 		_ = deltaSvc.CommitFullSync(dstFs, dstKey)
+		run.Duration = time.Since(startedAt)
+		deltaSvc.RecordRun(srcKey, run)
 	}
 
 	return syncErr
 }
+
+// hasBisyncListings reports whether bisync has the listings of a previous
+// run between path1 and path2 in its working directory
+func hasBisyncListings(ctx context.Context, path1, path2 fs.Fs) bool {
+	base := bilib.BasePath(ctx, bisync.DefaultWorkdir, path1, path2)
+	return bilib.FileExists(base+".path1.lst") && bilib.FileExists(base+".path2.lst")
+}
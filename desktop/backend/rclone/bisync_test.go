@@ -0,0 +1,49 @@
+package rclone
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/rclone/rclone/cmd/bisync"
+	"github.com/rclone/rclone/cmd/bisync/bilib"
+)
+
+func TestHasBisyncListings(t *testing.T) {
+	ctx := context.Background()
+	workdir := bisync.DefaultWorkdir
+	bisync.DefaultWorkdir = t.TempDir()
+	defer func() { bisync.DefaultWorkdir = workdir }()
+
+	path1, err := newFs(ctx, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path2, err := newFs(ctx, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasBisyncListings(ctx, path1, path2) {
+		t.Fatal("expected no listings before the first run")
+	}
+
+	// A critical error renames the listings, which locks out later runs
+	base := bilib.BasePath(ctx, bisync.DefaultWorkdir, path1, path2)
+	for _, name := range []string{base + ".path1.lst", base + ".path2.lst-err"} {
+		if err := os.WriteFile(name, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if hasBisyncListings(ctx, path1, path2) {
+		t.Error("expected a listing renamed after an error not to count")
+	}
+	if err := os.WriteFile(base+".path2.lst", nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !hasBisyncListings(ctx, path1, path2) {
+		t.Error("expected the listings to be found")
+	}
+	if hasBisyncListings(ctx, path2, path1) {
+		t.Error("expected the listings of the paths the other way round not to count")
+	}
+}
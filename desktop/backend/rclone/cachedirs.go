@@ -0,0 +1,197 @@
+package rclone
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"desktop/backend/models"
+
+	fsConfig "github.com/rclone/rclone/fs/config"
+)
+
+// Default cache locations, captured before ApplyCacheLocations moves the temp dir
+var (
+	systemTempDir   = os.TempDir()
+	rcloneCacheDir  = fsConfig.GetCacheDir()
+	cacheLocationMu sync.RWMutex
+	stagingDir      = DefaultCacheLocationPath(models.CacheLocationStaging)
+)
+
+// cacheLocationKeep lists entries directly under a location that clearing and
+// size caps leave alone: bisync's listings would otherwise force a resync
+var cacheLocationKeep = map[string][]string{
+	models.CacheLocationRclone: {"bisync"},
+}
+
+// DefaultCacheLocationPath returns the directory a cache location uses when
+// none is configured. The temp and staging locations get their own
+// directories so clearing them never touches other programs' files.
+func DefaultCacheLocationPath(id string) string {
+	switch id {
+	case models.CacheLocationTemp:
+		return filepath.Join(systemTempDir, "gn-drive")
+	case models.CacheLocationRclone:
+		return rcloneCacheDir
+	case models.CacheLocationStaging:
+		return filepath.Join(systemTempDir, "gn-drive-staging")
+	}
+	return ""
+}
+
+// ApplyCacheLocations points rclone's temp and cache directories and the
+// archive staging directory at the given paths (id -> path; empty uses the
+// default) and creates them
+func ApplyCacheLocations(paths map[string]string) error {
+	resolved := make(map[string]string)
+	for _, id := range []string{models.CacheLocationTemp, models.CacheLocationRclone, models.CacheLocationStaging} {
+		dir := paths[id]
+		if dir == "" {
+			dir = DefaultCacheLocationPath(id)
+		}
+		if !filepath.IsAbs(dir) {
+			return fmt.Errorf("%s directory must be an absolute path: %s", id, dir)
+		}
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return fmt.Errorf("failed to create %s directory: %w", id, err)
+		}
+		resolved[id] = dir
+	}
+
+	if err := fsConfig.SetTempDir(resolved[models.CacheLocationTemp]); err != nil {
+		return fmt.Errorf("failed to set temp directory: %w", err)
+	}
+	if err := fsConfig.SetCacheDir(resolved[models.CacheLocationRclone]); err != nil {
+		return fmt.Errorf("failed to set cache directory: %w", err)
+	}
+	cacheLocationMu.Lock()
+	stagingDir = resolved[models.CacheLocationStaging]
+	cacheLocationMu.Unlock()
+	return nil
+}
+
+// StagingDir returns the directory flow archive steps build archives in
+func StagingDir() string {
+	cacheLocationMu.RLock()
+	defer cacheLocationMu.RUnlock()
+	return stagingDir
+}
+
+// cacheFile is a file found while measuring or trimming a cache location
+type cacheFile struct {
+	path    string
+	size    int64
+	modTime int64
+}
+
+// listCacheFiles returns the files under dir, skipping the top-level entries in keep
+func listCacheFiles(dir string, keep []string) ([]cacheFile, error) {
+	var files []cacheFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if path == dir {
+			return nil
+		}
+		if filepath.Dir(path) == dir {
+			for _, k := range keep {
+				if d.Name() == k {
+					if d.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+			}
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil // removed while walking
+		}
+		files = append(files, cacheFile{path: path, size: info.Size(), modTime: info.ModTime().UnixNano()})
+		return nil
+	})
+	return files, err
+}
+
+// CacheLocationUsage returns the bytes and number of files stored in a cache location
+func CacheLocationUsage(id, dir string) (int64, int, error) {
+	files, err := listCacheFiles(dir, cacheLocationKeep[id])
+	if err != nil {
+		return 0, 0, err
+	}
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	return total, len(files), nil
+}
+
+// TrimCacheLocation removes the oldest files of a cache location until it
+// holds at most maxBytes; 0 clears it. Emptied directories are removed too.
+// Returns the space reclaimed and the number of files removed.
+func TrimCacheLocation(id, dir string, maxBytes int64) (int64, int, error) {
+	files, err := listCacheFiles(dir, cacheLocationKeep[id])
+	if err != nil {
+		return 0, 0, err
+	}
+	var total int64
+	for _, f := range files {
+		total += f.size
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime < files[j].modTime })
+
+	var reclaimed int64
+	removed := 0
+	var firstErr error
+	for _, f := range files {
+		if total-reclaimed <= maxBytes {
+			break
+		}
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		reclaimed += f.size
+		removed++
+	}
+	removeEmptyDirs(dir, cacheLocationKeep[id])
+	return reclaimed, removed, firstErr
+}
+
+// removeEmptyDirs removes empty directories below dir, deepest first
+func removeEmptyDirs(dir string, keep []string) {
+	var dirs []string
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || path == dir {
+			return nil
+		}
+		if filepath.Dir(path) == dir {
+			for _, k := range keep {
+				if d.Name() == k {
+					return filepath.SkipDir
+				}
+			}
+		}
+		dirs = append(dirs, path)
+		return nil
+	})
+	sort.Slice(dirs, func(i, j int) bool {
+		return strings.Count(dirs[i], string(filepath.Separator)) > strings.Count(dirs[j], string(filepath.Separator))
+	})
+	for _, d := range dirs {
+		_ = os.Remove(d) // fails if not empty
+	}
+}
@@ -0,0 +1,66 @@
+package rclone
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"desktop/backend/models"
+)
+
+func TestTrimCacheLocation(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	write := func(name string, size int, age time.Duration) {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, make([]byte, size), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("vfs/remote/old.bin", 100, 3*time.Hour)
+	write("vfs/remote/mid.bin", 100, 2*time.Hour)
+	write("new.bin", 100, time.Hour)
+	write("bisync/state.lst", 100, 5*time.Hour)
+
+	used, files, err := CacheLocationUsage(models.CacheLocationRclone, dir)
+	if err != nil || used != 300 || files != 3 {
+		t.Fatalf("expected 300 bytes in 3 files outside bisync, got %d in %d (%v)", used, files, err)
+	}
+
+	reclaimed, removed, err := TrimCacheLocation(models.CacheLocationRclone, dir, 150)
+	if err != nil || reclaimed != 200 || removed != 2 {
+		t.Fatalf("expected the two oldest files to be trimmed, got %d bytes in %d files (%v)", reclaimed, removed, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new.bin")); err != nil {
+		t.Error("expected the newest file to be kept")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "vfs")); !os.IsNotExist(err) {
+		t.Error("expected the emptied directories to be removed")
+	}
+
+	reclaimed, removed, err = TrimCacheLocation(models.CacheLocationRclone, dir, 0)
+	if err != nil || reclaimed != 100 || removed != 1 {
+		t.Fatalf("expected clearing to remove the last file, got %d bytes in %d files (%v)", reclaimed, removed, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bisync", "state.lst")); err != nil {
+		t.Error("expected bisync state to survive clearing")
+	}
+}
+
+func TestDefaultCacheLocationPath(t *testing.T) {
+	for _, id := range []string{models.CacheLocationTemp, models.CacheLocationRclone, models.CacheLocationStaging} {
+		if p := DefaultCacheLocationPath(id); !filepath.IsAbs(p) {
+			t.Errorf("default %s location should be an absolute path, got %q", id, p)
+		}
+	}
+	if p := DefaultCacheLocationPath(models.CacheLocationListing); p != "" {
+		t.Errorf("the listing cache has no directory, got %q", p)
+	}
+}
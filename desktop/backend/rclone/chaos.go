@@ -0,0 +1,280 @@
+package rclone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fserrors"
+)
+
+// ErrChaosCancelled is the cause chaos mode cancels a run with
+var ErrChaosCancelled = errors.New("run cancelled by chaos mode")
+
+// chaosRetryAfter is the back-off injected rate limit responses ask for
+const chaosRetryAfter = time.Second
+
+// chaosContextKey carries a run's ChaosInjector
+type chaosContextKey struct{}
+
+// ChaosInjector injects faults into the remotes of one run and counts them
+type ChaosInjector struct {
+	cfg    models.ChaosConfig
+	cancel func(error) // cancels the run
+
+	mu     sync.Mutex
+	rnd    *rand.Rand
+	counts models.ChaosCounts
+}
+
+// ValidateChaosConfig checks the fault rates are fractions
+func ValidateChaosConfig(cfg models.ChaosConfig) error {
+	for name, rate := range map[string]float64{
+		"network error rate": cfg.NetworkErrorRate,
+		"rate limit rate":    cfg.RateLimitRate,
+		"transfer cut rate":  cfg.TransferCutRate,
+	} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s must be between 0 and 1, got %v", name, rate)
+		}
+	}
+	if cfg.NetworkErrorRate+cfg.RateLimitRate > 1 {
+		return fmt.Errorf("network error and rate limit rates add up to more than 1")
+	}
+	if cfg.CancelAfterTransfers < 0 {
+		return fmt.Errorf("cancel after transfers must not be negative")
+	}
+	return nil
+}
+
+// NewChaosInjector creates an injector for one run; cancel stops the run
+func NewChaosInjector(cfg models.ChaosConfig, cancel func(error)) *ChaosInjector {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &ChaosInjector{cfg: cfg, cancel: cancel, rnd: rand.New(rand.NewSource(seed))}
+}
+
+// WithChaos makes the remotes a run opens inject faults from c
+func WithChaos(ctx context.Context, c *ChaosInjector) context.Context {
+	return context.WithValue(ctx, chaosContextKey{}, c)
+}
+
+// Config returns the faults the injector was set up with
+func (c *ChaosInjector) Config() models.ChaosConfig {
+	return c.cfg
+}
+
+// Counts returns what the injector has seen and injected so far
+func (c *ChaosInjector) Counts() models.ChaosCounts {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts
+}
+
+// fault returns an injected network or rate limit error for an operation, or nil
+func (c *ChaosInjector) fault(op string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts.Operations++
+	roll := c.rnd.Float64()
+	switch {
+	case roll < c.cfg.NetworkErrorRate:
+		c.counts.NetworkErrors++
+		return fserrors.RetryError(fmt.Errorf("chaos: %s: connection reset by peer", op))
+	case roll < c.cfg.NetworkErrorRate+c.cfg.RateLimitRate:
+		c.counts.RateLimits++
+		return fmt.Errorf("chaos: %s: 429 Too Many Requests: %w", op, fserrors.NewErrorRetryAfter(chaosRetryAfter))
+	}
+	return nil
+}
+
+// startTransfer counts an upload, cancels the run once CancelAfterTransfers
+// uploads have started, and reports whether to cut this upload off halfway
+func (c *ChaosInjector) startTransfer() bool {
+	c.mu.Lock()
+	c.counts.Transfers++
+	cancel := c.cfg.CancelAfterTransfers > 0 && c.counts.Transfers == c.cfg.CancelAfterTransfers
+	if cancel {
+		c.counts.Cancelled = true
+	}
+	cut := c.rnd.Float64() < c.cfg.TransferCutRate
+	if cut {
+		c.counts.TransferCuts++
+	}
+	c.mu.Unlock()
+
+	if cancel && c.cancel != nil {
+		fs.Logf(nil, "chaos: cancelling the run after %d transfers", c.cfg.CancelAfterTransfers)
+		c.cancel(ErrChaosCancelled)
+	}
+	return cut
+}
+
+// newFs creates the Fs for a remote path, injecting faults into it when the
+// run has chaos mode on
+func newFs(ctx context.Context, path string) (fs.Fs, error) {
+	f, err := fs.NewFs(ctx, path)
+	if f == nil {
+		return nil, err
+	}
+	if c, ok := ctx.Value(chaosContextKey{}).(*ChaosInjector); ok && c != nil {
+		f = newChaosFs(ctx, f, c)
+	}
+	return f, err
+}
+
+// chaosFs wraps a remote so its operations fail as chaos mode dictates.
+// Optional features such as server-side copy are not passed through.
+type chaosFs struct {
+	fs.Fs
+	c        *ChaosInjector
+	features *fs.Features
+}
+
+// chaosObject is a file on a chaosFs
+type chaosObject struct {
+	fs.Object
+	f *chaosFs
+}
+
+// chaosReader fails with a dropped connection once limit bytes were read
+type chaosReader struct {
+	in     io.Reader
+	limit  int64
+	remote string
+}
+
+func newChaosFs(ctx context.Context, f fs.Fs, c *ChaosInjector) *chaosFs {
+	inner := f.Features()
+	w := &chaosFs{Fs: f, c: c}
+	w.features = (&fs.Features{
+		CaseInsensitive:         inner.CaseInsensitive,
+		DuplicateFiles:          inner.DuplicateFiles,
+		CanHaveEmptyDirectories: inner.CanHaveEmptyDirectories,
+		BucketBased:             inner.BucketBased,
+		BucketBasedRootOK:       inner.BucketBasedRootOK,
+		IsLocal:                 inner.IsLocal,
+		SlowModTime:             inner.SlowModTime,
+		SlowHash:                inner.SlowHash,
+	}).Fill(ctx, w)
+	return w
+}
+
+// Features returns the optional features of the wrapped remote that chaos mode supports
+func (f *chaosFs) Features() *fs.Features {
+	return f.features
+}
+
+func (f *chaosFs) wrap(o fs.Object) fs.Object {
+	if o == nil {
+		return nil
+	}
+	return &chaosObject{Object: o, f: f}
+}
+
+// List lists a directory, possibly failing
+func (f *chaosFs) List(ctx context.Context, dir string) (fs.DirEntries, error) {
+	if err := f.c.fault("list " + dir); err != nil {
+		return nil, err
+	}
+	entries, err := f.Fs.List(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	for i, entry := range entries {
+		if o, ok := entry.(fs.Object); ok {
+			entries[i] = f.wrap(o)
+		}
+	}
+	return entries, nil
+}
+
+// NewObject finds an object, possibly failing
+func (f *chaosFs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	if err := f.c.fault("stat " + remote); err != nil {
+		return nil, err
+	}
+	o, err := f.Fs.NewObject(ctx, remote)
+	return f.wrap(o), err
+}
+
+// Put uploads an object, possibly failing up front or halfway through
+func (f *chaosFs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	if err := f.c.fault("upload " + src.Remote()); err != nil {
+		return nil, err
+	}
+	if f.c.startTransfer() {
+		in = &chaosReader{in: in, limit: src.Size() / 2, remote: src.Remote()}
+	}
+	o, err := f.Fs.Put(ctx, in, src, options...)
+	return f.wrap(o), err
+}
+
+// Mkdir creates a directory, possibly failing
+func (f *chaosFs) Mkdir(ctx context.Context, dir string) error {
+	if err := f.c.fault("mkdir " + dir); err != nil {
+		return err
+	}
+	return f.Fs.Mkdir(ctx, dir)
+}
+
+// Rmdir removes a directory, possibly failing
+func (f *chaosFs) Rmdir(ctx context.Context, dir string) error {
+	if err := f.c.fault("rmdir " + dir); err != nil {
+		return err
+	}
+	return f.Fs.Rmdir(ctx, dir)
+}
+
+// Fs returns the chaos remote the object is on
+func (o *chaosObject) Fs() fs.Info {
+	return o.f
+}
+
+// Open opens the object for download, possibly failing
+func (o *chaosObject) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	if err := o.f.c.fault("download " + o.Remote()); err != nil {
+		return nil, err
+	}
+	return o.Object.Open(ctx, options...)
+}
+
+// Update uploads new contents, possibly failing up front or halfway through
+func (o *chaosObject) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	if err := o.f.c.fault("upload " + o.Remote()); err != nil {
+		return err
+	}
+	if o.f.c.startTransfer() {
+		in = &chaosReader{in: in, limit: src.Size() / 2, remote: o.Remote()}
+	}
+	return o.Object.Update(ctx, in, src, options...)
+}
+
+// Remove deletes the object, possibly failing
+func (o *chaosObject) Remove(ctx context.Context) error {
+	if err := o.f.c.fault("delete " + o.Remote()); err != nil {
+		return err
+	}
+	return o.Object.Remove(ctx)
+}
+
+func (r *chaosReader) Read(p []byte) (int, error) {
+	if r.limit <= 0 {
+		return 0, fserrors.RetryError(fmt.Errorf("chaos: upload %s: connection lost mid-transfer: %w", r.remote, io.ErrUnexpectedEOF))
+	}
+	if int64(len(p)) > r.limit {
+		p = p[:r.limit]
+	}
+	n, err := r.in.Read(p)
+	r.limit -= int64(n)
+	return n, err
+}
@@ -0,0 +1,111 @@
+package rclone
+
+import (
+	"context"
+	"desktop/backend/dto"
+	"desktop/backend/models"
+	"errors"
+	"testing"
+
+	beConfig "desktop/backend/config"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/operations"
+)
+
+// runChaosSync syncs profile with chaos mode on and returns the run's error and cause
+func runChaosSync(t *testing.T, taskId int, profile models.Profile, cfg models.ChaosConfig) (*ChaosInjector, error, error) {
+	t.Helper()
+	ctx, err := NewTaskContext(context.Background(), taskId)
+	if err != nil {
+		t.Fatalf("NewTaskContext: %v", err)
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	c := NewChaosInjector(cfg, cancel)
+	ctx = WithChaos(ctx, c)
+
+	outStatus := make(chan *dto.SyncStatusDTO)
+	go func() {
+		for range outStatus {
+		}
+	}()
+	err = Sync(ctx, beConfig.Config{}, "push", profile, outStatus, nil)
+	close(outStatus)
+	return c, err, context.Cause(ctx)
+}
+
+func TestValidateChaosConfig(t *testing.T) {
+	valid := []models.ChaosConfig{
+		{},
+		{NetworkErrorRate: 0.2, RateLimitRate: 0.1, TransferCutRate: 1, CancelAfterTransfers: 5},
+	}
+	for _, cfg := range valid {
+		if err := ValidateChaosConfig(cfg); err != nil {
+			t.Errorf("ValidateChaosConfig(%+v) = %v, want nil", cfg, err)
+		}
+	}
+	invalid := []models.ChaosConfig{
+		{NetworkErrorRate: -0.1},
+		{TransferCutRate: 1.5},
+		{NetworkErrorRate: 0.6, RateLimitRate: 0.6},
+		{CancelAfterTransfers: -1},
+	}
+	for _, cfg := range invalid {
+		if err := ValidateChaosConfig(cfg); err == nil {
+			t.Errorf("ValidateChaosConfig(%+v) should fail", cfg)
+		}
+	}
+}
+
+func TestChaosFaultsAreRetryable(t *testing.T) {
+	c := NewChaosInjector(models.ChaosConfig{NetworkErrorRate: 1}, nil)
+	if err := c.fault("list"); !fserrors.IsRetryError(err) {
+		t.Errorf("network fault = %v, want a retryable error", err)
+	}
+	c = NewChaosInjector(models.ChaosConfig{RateLimitRate: 1}, nil)
+	if err := c.fault("list"); !fserrors.IsRetryAfterError(err) {
+		t.Errorf("rate limit fault = %v, want a retry-after error", err)
+	}
+	if counts := c.Counts(); counts.Operations != 1 || counts.RateLimits != 1 {
+		t.Errorf("Counts() = %+v, want 1 operation and 1 rate limit", counts)
+	}
+}
+
+func TestChaosSyncRecoversFromTransferCuts(t *testing.T) {
+	profile := models.Profile{
+		From: ":simulated,files=20,seed=21:",
+		To:   ":simulated,files=0:chaos-cuts",
+	}
+	c, err, _ := runChaosSync(t, 9001, profile, models.ChaosConfig{TransferCutRate: 0.2, Seed: 4})
+	if err != nil {
+		t.Fatalf("Sync should recover from injected faults, got %v", err)
+	}
+	counts := c.Counts()
+	if counts.TransferCuts == 0 {
+		t.Fatalf("no faults injected: %+v", counts)
+	}
+
+	ctx := context.Background()
+	src, _ := fs.NewFs(ctx, profile.From)
+	dst, _ := fs.NewFs(ctx, profile.To)
+	if err := operations.Check(ctx, &operations.CheckOpt{Fdst: dst, Fsrc: src, OneWay: true}); err != nil {
+		t.Errorf("destination differs from source after recovery: %v", err)
+	}
+}
+
+func TestChaosCancelsRun(t *testing.T) {
+	profile := models.Profile{
+		From: ":simulated,files=20,seed=22:",
+		To:   ":simulated,files=0:chaos-cancel",
+	}
+	// The run's error depends on where the cancel lands; the service goes by the cancel cause
+	c, _, cause := runChaosSync(t, 9002, profile, models.ChaosConfig{CancelAfterTransfers: 3})
+	if !errors.Is(cause, ErrChaosCancelled) {
+		t.Errorf("cancel cause = %v, want ErrChaosCancelled", cause)
+	}
+	if counts := c.Counts(); !counts.Cancelled {
+		t.Errorf("Counts() = %+v, want Cancelled", counts)
+	}
+}
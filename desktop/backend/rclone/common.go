@@ -181,7 +181,7 @@ func NewTaskContext(parentCtx context.Context, taskId int) (context.Context, err
 	ctx, _ := fs.AddConfig(parentCtx)
 
 	// 2. Isolated stats group
-	ctx = accounting.WithStatsGroup(ctx, fmt.Sprintf("task-%d", taskId))
+	ctx = accounting.WithStatsGroup(ctx, utils.TaskStatsGroup(taskId))
 	stats := accounting.Stats(ctx)
 	stats.ResetCounters()
 	stats.ResetErrors()
@@ -228,6 +228,7 @@ func CopyFilterOpt(ctx context.Context) filter.Options {
 	opt.ExcludeRule = append([]string(nil), opt.ExcludeRule...)
 	opt.FilterFrom = append([]string(nil), opt.FilterFrom...)
 	opt.ExcludeFile = append([]string(nil), opt.ExcludeFile...)
+	opt.FilterRule = append([]string(nil), opt.FilterRule...)
 	return opt
 }
 
@@ -272,11 +273,26 @@ func ApplyProfileOptions(ctx context.Context, profile models.Profile) (context.C
 		}
 	}
 
+	// Manifest: the destination's manifest isn't a backed up file
+	if profile.WriteManifest {
+		filterOpt.FilterRule = append(filterOpt.FilterRule, "- /"+ManifestName)
+	}
+
+	// Deferred deletion: the pending-delete folder and its ledger aren't synced
+	if profile.DeferDeletes > 0 {
+		filterOpt.FilterRule = append(filterOpt.FilterRule, "- /"+PendingDeleteFolder+"/**", "- /"+PendingDeleteLedger)
+	}
+
 	// Filtering: delete excluded files on destination
 	if profile.DeleteExcluded {
 		filterOpt.DeleteExcluded = true
 	}
 
+	// Raw rclone flags for options the profile doesn't model
+	if err := applyRawFlags(fsConfig, &filterOpt, profile.RawFlags); err != nil {
+		return ctx, err
+	}
+
 	// Rebuild filter with updated options
 	newFilter, err := filter.NewFilter(&filterOpt)
 	if err != nil {
@@ -289,6 +305,15 @@ func ApplyProfileOptions(ctx context.Context, profile models.Profile) (context.C
 		fsConfig.MaxDepth = *profile.MaxDepth
 	}
 
+	// Network: bind address / IP family
+	if profile.BindAddress != "" || profile.IPFamily != "" {
+		bindAddr, err := ResolveBindAddress(profile.BindAddress, profile.IPFamily)
+		if err != nil {
+			return ctx, err
+		}
+		fsConfig.BindAddr = bindAddr
+	}
+
 	// Safety: backup directory
 	if profile.BackupPath != "" {
 		fsConfig.BackupDir = profile.BackupPath
@@ -331,6 +356,15 @@ func ApplyProfileOptions(ctx context.Context, profile models.Profile) (context.C
 		fsConfig.SuffixKeepExtension = true
 	}
 
+	// Objects: headers set on uploaded files
+	if len(profile.UploadHeaders) > 0 {
+		headers, err := parseUploadHeaders(profile.UploadHeaders)
+		if err != nil {
+			return ctx, err
+		}
+		fsConfig.UploadHeaders = append(fsConfig.UploadHeaders, headers...)
+	}
+
 	// Performance: multi-thread streams
 	if profile.MultiThreadStreams != nil {
 		fsConfig.MultiThreadStreams = *profile.MultiThreadStreams
@@ -370,6 +404,20 @@ func ApplyProfileOptions(ctx context.Context, profile models.Profile) (context.C
 		fsConfig.OrderBy = profile.OrderBy
 	}
 
+	// Transfer order: smallest or largest files first
+	if profile.TransferOrder != "" {
+		fsConfig.OrderBy = transferOrderBy(profile.TransferOrder)
+	}
+
+	// Sessions: cap the run, without starting files that won't fit, in priority order
+	if profile.SessionTransfer != "" {
+		if err := fsConfig.MaxTransfer.Set(profile.SessionTransfer); err != nil {
+			return ctx, fmt.Errorf("invalid session_transfer %q: %w", profile.SessionTransfer, err)
+		}
+		fsConfig.CutoffMode = fs.CutoffModeCautious
+		fsConfig.OrderBy = sessionOrderBy(profile.SessionOrder)
+	}
+
 	// Performance: retries sleep
 	if profile.RetriesSleep != "" {
 		var d fs.Duration
@@ -0,0 +1,227 @@
+package rclone
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"desktop/backend/models"
+
+	_ "github.com/rclone/rclone/backend/compress"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/operations"
+)
+
+const (
+	compressionSampleBytes    = 64 * 1024 // read from the start of each sampled file
+	compressionSamplesPerType = 3
+	compressionSampledTypes   = 50 // types with the most bytes that are sampled; the others are estimated as incompressible
+
+	// minCompressionRatio is the ratio below which the compress backend
+	// stores a file as it is
+	minCompressionRatio = 1.1
+)
+
+// compressedExtensions are formats that are compressed already; files of
+// these types aren't sampled
+var compressedExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true, ".heic": true, ".avif": true,
+	".mp3": true, ".m4a": true, ".aac": true, ".ogg": true, ".opus": true, ".flac": true,
+	".mp4": true, ".m4v": true, ".mov": true, ".mkv": true, ".avi": true, ".webm": true,
+	".zip": true, ".gz": true, ".tgz": true, ".bz2": true, ".xz": true, ".zst": true, ".7z": true, ".rar": true,
+	".docx": true, ".xlsx": true, ".pptx": true, ".odt": true, ".ods": true, ".epub": true, ".jar": true, ".apk": true,
+}
+
+// ApplyCompressWrapping points the profile's To at an on-the-fly compress
+// remote wrapping it, so a run stores files compressed. Call it after
+// ApplyCryptWrapping: files are compressed, then encrypted.
+func ApplyCompressWrapping(profile *models.Profile) {
+	if !profile.CompressDest {
+		return
+	}
+	profile.To = compressRemote(objectOptionsRemote(profile.To, *profile), profile.CompressMode)
+}
+
+// compressRemote returns the connection string of a compress remote
+// wrapping remote, at the backend's recommended level for the mode: its
+// level option has no default
+func compressRemote(remote, mode string) string {
+	level := "-1"
+	if mode == models.CompressZstd {
+		level = "2"
+	} else {
+		mode = models.CompressGzip
+	}
+	return `:compress,mode=` + mode + `,level=` + level + `,remote="` + strings.ReplaceAll(remote, `"`, `""`) + `":`
+}
+
+// EstimateCompression samples how well the profile's source files would
+// compress with compression wrapping. Files are grouped by extension, and
+// the start of a few files of each type is compressed the way the compress
+// backend decides whether to store a file compressed.
+func EstimateCompression(ctx context.Context, profile models.Profile) (*models.CompressionEstimate, error) {
+	ctx, err := SimpleContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	srcFs, err := fs.NewFs(ctx, profile.From)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize source filesystem: %w", err)
+	}
+	ctx = applyFiltersAndBandwidth(ctx, fs.GetConfig(ctx), profile)
+	if ctx, err = ApplyProfileOptions(ctx, profile); err != nil {
+		return nil, fmt.Errorf("failed to apply profile options: %w", err)
+	}
+
+	var mu sync.Mutex
+	types := make(map[string]*models.CompressionTypeStats)
+	samples := make(map[string][]fs.Object)
+	err = operations.ListFn(ctx, srcFs, func(o fs.Object) {
+		ext := strings.ToLower(path.Ext(o.Remote()))
+		mu.Lock()
+		defer mu.Unlock()
+		t := types[ext]
+		if t == nil {
+			t = &models.CompressionTypeStats{Extension: ext}
+			types[ext] = t
+		}
+		t.Files++
+		t.Bytes += o.Size()
+		if !compressedExtensions[ext] && o.Size() > 0 && len(samples[ext]) < compressionSamplesPerType {
+			samples[ext] = append(samples[ext], o)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the source: %w", err)
+	}
+
+	estimate := &models.CompressionEstimate{ProfileName: profile.Name, Types: []models.CompressionTypeStats{}}
+	for _, t := range types {
+		estimate.Types = append(estimate.Types, *t)
+	}
+	sort.Slice(estimate.Types, func(i, j int) bool { return estimate.Types[i].Bytes > estimate.Types[j].Bytes })
+
+	for i := range estimate.Types {
+		t := &estimate.Types[i]
+		if i < compressionSampledTypes {
+			for _, o := range samples[t.Extension] {
+				entropy, ratio, err := sampleCompression(ctx, o)
+				if err != nil {
+					fs.Debugf(o, "Not sampled for compression: %v", err)
+					continue
+				}
+				t.Entropy += entropy
+				t.Ratio += ratio
+				t.Sampled++
+			}
+		}
+		stored := t.Bytes
+		if t.Sampled > 0 {
+			t.Entropy /= float64(t.Sampled)
+			t.Ratio /= float64(t.Sampled)
+			t.Compressible = t.Ratio >= minCompressionRatio
+		}
+		if t.Compressible {
+			stored = int64(float64(t.Bytes) / t.Ratio)
+		} else {
+			estimate.IncompressibleBytes += t.Bytes
+		}
+		estimate.Files += t.Files
+		estimate.Bytes += t.Bytes
+		estimate.EstimatedBytes += stored
+	}
+	if estimate.EstimatedBytes > 0 {
+		estimate.Ratio = float64(estimate.Bytes) / float64(estimate.EstimatedBytes)
+	}
+	return estimate, nil
+}
+
+// sampleCompression reads the start of a file and returns its entropy in
+// bits per byte and the ratio it compresses by
+func sampleCompression(ctx context.Context, o fs.Object) (entropy, ratio float64, err error) {
+	in, err := operations.Open(ctx, o, &fs.RangeOption{Start: 0, End: compressionSampleBytes - 1})
+	if err != nil {
+		return 0, 0, err
+	}
+	defer in.Close()
+	data, err := io.ReadAll(io.LimitReader(in, compressionSampleBytes))
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(data) == 0 {
+		return 0, 0, fmt.Errorf("empty sample")
+	}
+
+	var compressed bytes.Buffer
+	w, _ := flate.NewWriter(&compressed, flate.DefaultCompression)
+	w.Write(data)
+	w.Close()
+	return shannonEntropy(data), float64(len(data)) / float64(compressed.Len()), nil
+}
+
+// shannonEntropy returns the entropy of data in bits per byte, from 0 for a
+// single repeated byte to 8 for random data
+func shannonEntropy(data []byte) float64 {
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+	entropy := 0.0
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / float64(len(data))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// MeasureCompression compares the size of files on a destination with
+// compression wrapping with the size they are stored at. Only the files in
+// names are counted.
+func MeasureCompression(ctx context.Context, dest string, names map[string]struct{}) (*models.CompressionRun, error) {
+	ctx, err := SimpleContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dstFs, err := fs.NewFs(ctx, dest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize destination filesystem: %w", err)
+	}
+
+	var mu sync.Mutex
+	run := &models.CompressionRun{}
+	err = operations.ListFn(ctx, dstFs, func(o fs.Object) {
+		if _, ok := names[o.Remote()]; !ok {
+			return
+		}
+		stored := o
+		if u, ok := o.(fs.ObjectUnWrapper); ok {
+			stored = u.UnWrap()
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		run.Files++
+		run.OriginalBytes += o.Size()
+		run.StoredBytes += stored.Size()
+		// The compress backend names files it stores as they are *.bin
+		if strings.HasSuffix(stored.Remote(), ".bin") {
+			run.Uncompressed++
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the destination: %w", err)
+	}
+	if run.StoredBytes > 0 {
+		run.Ratio = float64(run.OriginalBytes) / float64(run.StoredBytes)
+	}
+	return run, nil
+}
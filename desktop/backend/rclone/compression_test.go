@@ -0,0 +1,75 @@
+package rclone
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/fs"
+	fssync "github.com/rclone/rclone/fs/sync"
+)
+
+func TestCompression(t *testing.T) {
+	ctx := context.Background()
+	random := make([]byte, 32*1024)
+	rand.New(rand.NewSource(1)).Read(random)
+	src, dst := t.TempDir(), t.TempDir()
+	writeTestFiles(t, src, map[string]string{
+		"notes.txt":   strings.Repeat("the same line of text\n", 2000),
+		"archive.dat": string(random),
+		"photo.jpg":   "not really a photo",
+	})
+
+	estimate, err := EstimateCompression(ctx, models.Profile{Name: "docs", From: src})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.Files != 3 || len(estimate.Types) != 3 || estimate.Ratio <= 1 {
+		t.Fatalf("estimate = %+v", estimate)
+	}
+	for _, ty := range estimate.Types {
+		switch ty.Extension {
+		case ".txt":
+			if !ty.Compressible || ty.Sampled != 1 {
+				t.Errorf("text = %+v, want sampled and compressible", ty)
+			}
+		case ".dat":
+			if ty.Compressible || ty.Entropy < 7.5 {
+				t.Errorf("random data = %+v, want high entropy and incompressible", ty)
+			}
+		case ".jpg":
+			if ty.Compressible || ty.Sampled != 0 {
+				t.Errorf("photo = %+v, want an incompressible known format", ty)
+			}
+		}
+	}
+
+	profile := models.Profile{To: dst, CompressDest: true, CompressMode: models.CompressZstd}
+	ApplyCompressWrapping(&profile)
+	ctx, err = SimpleContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcFs, err := fs.NewFs(ctx, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dstFs, err := fs.NewFs(ctx, profile.To)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fssync.CopyDir(ctx, dstFs, srcFs, false); err != nil {
+		t.Fatal(err)
+	}
+
+	run, err := MeasureCompression(ctx, profile.To, map[string]struct{}{"notes.txt": {}, "archive.dat": {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Files != 2 || run.Uncompressed != 1 || run.OriginalBytes != 44000+32*1024 || run.Ratio <= 1 {
+		t.Errorf("run = %+v", run)
+	}
+}
@@ -0,0 +1,190 @@
+package rclone
+
+import (
+	"context"
+	"desktop/backend/models"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/operations"
+)
+
+// conflictTimeFormat is how the run time is written in conflict copy names
+const conflictTimeFormat = "20060102-150405"
+
+// conflictTimeGlob matches the {...} time globs rclone expands in a conflict suffix
+var conflictTimeGlob = regexp.MustCompile(`\{[^}]*\}`)
+
+// conflictSuffixBases returns the conflict suffixes of a profile as bisync
+// reads them: one for both sides, or one per side separated by a comma
+func conflictSuffixBases(profile models.Profile) []string {
+	if profile.ConflictSuffix == "" {
+		return []string{"conflict"}
+	}
+	return strings.Split(profile.ConflictSuffix, ",")
+}
+
+// conflictSuffixFlag returns the --conflict-suffix a bisync of profile runs
+// with, adding host and the run time in front of each suffix as the
+// profile's conflict naming asks
+func conflictSuffixFlag(profile models.Profile, host string, now time.Time) string {
+	naming := profile.ConflictNaming
+	if naming == nil || (!naming.Hostname && !naming.Timestamp) {
+		return profile.ConflictSuffix
+	}
+	prefix := ""
+	if naming.Hostname && host != "" {
+		prefix += host + "-"
+	}
+	if naming.Timestamp {
+		prefix += now.Format(conflictTimeFormat) + "-"
+	}
+	bases := conflictSuffixBases(profile)
+	for i, base := range bases {
+		bases[i] = prefix + base
+	}
+	return strings.Join(bases, ",")
+}
+
+// conflictHostname returns this computer's name in a form that can be put
+// in a file name, or "" if it isn't known
+func conflictHostname() string {
+	host, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	host, _, _ = strings.Cut(host, ".")
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '-'
+	}, host)
+}
+
+// conflictCopyPattern returns a pattern matching the names of the conflict
+// copies a bisync of profile keeps, whatever host and time they were named
+// with. The first group is the original name without its extension when
+// the extension is kept, the second the extension.
+func conflictCopyPattern(profile models.Profile) *regexp.Regexp {
+	bases := conflictSuffixBases(profile)
+	for i, base := range bases {
+		parts := conflictTimeGlob.Split(base, -1)
+		for j, part := range parts {
+			parts[j] = regexp.QuoteMeta(part)
+		}
+		bases[i] = strings.Join(parts, ".+?")
+	}
+	suffix := `\.(?:[A-Za-z0-9_-]+-)?(?:` + strings.Join(bases, "|") + `)\d*`
+	if profile.SuffixKeepExtension {
+		return regexp.MustCompile(`^(.+?)` + suffix + `(\.[^/]*)?$`)
+	}
+	return regexp.MustCompile(`^(.+)` + suffix + `()$`)
+}
+
+// conflictFolder returns the folder conflict copies of profile are gathered
+// in, or "" if they stay next to their originals
+func conflictFolder(profile models.Profile) string {
+	if profile.ConflictNaming == nil {
+		return ""
+	}
+	return strings.Trim(profile.ConflictNaming.Folder, "/")
+}
+
+// conflictOriginal returns the file the conflict copy at remote is a copy
+// of, and whether remote is a conflict copy at all
+func conflictOriginal(pattern *regexp.Regexp, folder, remote string) (string, bool) {
+	inFolder := false
+	if folder != "" && strings.HasPrefix(remote, folder+"/") {
+		remote = strings.TrimPrefix(remote, folder+"/")
+		inFolder = true
+	}
+	dir, name := path.Split(remote)
+	m := pattern.FindStringSubmatch(name)
+	if m == nil {
+		// Everything in the folder was put there as a conflict copy
+		return remote, inFolder
+	}
+	return dir + m[1] + m[2], true
+}
+
+// ListConflictCopies lists the conflict copies a bisync of profile kept on
+// either side, newest first
+func ListConflictCopies(ctx context.Context, profile models.Profile) ([]models.ConflictCopy, error) {
+	ctx, err := SimpleContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pattern := conflictCopyPattern(profile)
+	folder := conflictFolder(profile)
+
+	copies := []models.ConflictCopy{}
+	for _, side := range []struct{ name, remote string }{{"from", profile.From}, {"to", profile.To}} {
+		f, err := fs.NewFs(ctx, side.remote)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize %s: %w", side.remote, err)
+		}
+		var mu sync.Mutex
+		err = operations.ListFn(ctx, f, func(o fs.Object) {
+			original, ok := conflictOriginal(pattern, folder, o.Remote())
+			if !ok {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			copies = append(copies, models.ConflictCopy{
+				Side:     side.name,
+				Path:     o.Remote(),
+				Original: original,
+				Size:     o.Size(),
+				ModTime:  o.ModTime(ctx),
+			})
+		})
+		if err != nil && !errors.Is(err, fs.ErrorDirNotFound) {
+			return nil, fmt.Errorf("failed to list %s: %w", side.remote, err)
+		}
+	}
+	sort.SliceStable(copies, func(i, j int) bool {
+		return copies[i].ModTime.After(copies[j].ModTime)
+	})
+	return copies, nil
+}
+
+// moveConflictCopies moves the conflict copies on f that aren't in the
+// conflict folder yet into it, keeping their directories. It returns how
+// many were moved.
+func moveConflictCopies(ctx context.Context, f fs.Fs, profile models.Profile) (int, error) {
+	pattern := conflictCopyPattern(profile)
+	folder := conflictFolder(profile)
+
+	var mu sync.Mutex
+	var found []fs.Object
+	err := operations.ListFn(ctx, f, func(o fs.Object) {
+		if strings.HasPrefix(o.Remote(), folder+"/") || !pattern.MatchString(path.Base(o.Remote())) {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		found = append(found, o)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list %s: %w", fs.ConfigString(f), err)
+	}
+
+	moved := 0
+	for _, o := range found {
+		if _, err := operations.Move(ctx, f, nil, path.Join(folder, o.Remote()), o); err != nil {
+			return moved, fmt.Errorf("failed to move %s into %s: %w", o.Remote(), folder, err)
+		}
+		moved++
+	}
+	return moved, nil
+}
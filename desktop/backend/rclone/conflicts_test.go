@@ -0,0 +1,97 @@
+package rclone
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/fs"
+)
+
+func TestConflictSuffixFlag(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		profile models.Profile
+		want    string
+	}{
+		{"no naming", models.Profile{ConflictSuffix: "mine,theirs"}, "mine,theirs"},
+		{"folder only", models.Profile{ConflictNaming: &models.ConflictNaming{Folder: "_conflicts"}}, ""},
+		{"host", models.Profile{ConflictNaming: &models.ConflictNaming{Hostname: true}}, "laptop-conflict"},
+		{"host and time", models.Profile{ConflictNaming: &models.ConflictNaming{Hostname: true, Timestamp: true}}, "laptop-20240501-120000-conflict"},
+		{"per side", models.Profile{ConflictSuffix: "mine,theirs", ConflictNaming: &models.ConflictNaming{Timestamp: true}}, "20240501-120000-mine,20240501-120000-theirs"},
+	}
+	for _, tt := range tests {
+		if got := conflictSuffixFlag(tt.profile, "laptop", now); got != tt.want {
+			t.Errorf("%s: conflictSuffixFlag() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestConflictOriginal(t *testing.T) {
+	tests := []struct {
+		name     string
+		profile  models.Profile
+		remote   string
+		want     string
+		wantCopy bool
+	}{
+		{"rclone default", models.Profile{}, "docs/report.docx.conflict1", "docs/report.docx", true},
+		{"host and time", models.Profile{}, "report.docx.laptop-20240501-120000-conflict2", "report.docx", true},
+		{"extension kept", models.Profile{SuffixKeepExtension: true}, "docs/report.laptop-conflict1.docx", "docs/report.docx", true},
+		{"per side", models.Profile{ConflictSuffix: "mine,theirs"}, "a.txt.theirs", "a.txt", true},
+		{"time glob", models.Profile{ConflictSuffix: "conflict-{DateOnly}"}, "a.txt.conflict-2024-05-01", "a.txt", true},
+		{"in the folder", models.Profile{ConflictNaming: &models.ConflictNaming{Folder: "_conflicts"}}, "_conflicts/docs/a.txt.conflict1", "docs/a.txt", true},
+		{"anything in the folder", models.Profile{ConflictNaming: &models.ConflictNaming{Folder: "_conflicts"}}, "_conflicts/notes.txt", "notes.txt", true},
+		{"not a copy", models.Profile{}, "docs/conflict.txt", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := conflictOriginal(conflictCopyPattern(tt.profile), conflictFolder(tt.profile), tt.remote)
+		if ok != tt.wantCopy || (ok && got != tt.want) {
+			t.Errorf("%s: conflictOriginal(%q) = %q, %v, want %q, %v", tt.name, tt.remote, got, ok, tt.want, tt.wantCopy)
+		}
+	}
+}
+
+func TestConflictCopies(t *testing.T) {
+	ctx := context.Background()
+	src, dst := t.TempDir(), t.TempDir()
+	writeTestFiles(t, src, map[string]string{
+		"docs/report.docx":                     "mine",
+		"docs/report.docx.laptop-conflict1":    "theirs",
+		"_conflicts/old.txt.desktop-conflict2": "older",
+		"notes.txt":                            "notes",
+	})
+	writeTestFiles(t, dst, map[string]string{"docs/report.docx": "mine"})
+	profile := models.Profile{From: src, To: dst, ConflictLoser: "num", ConflictNaming: &models.ConflictNaming{Hostname: true, Folder: "_conflicts"}}
+
+	ctx, err := SimpleContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcFs, err := fs.NewFs(ctx, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved, err := moveConflictCopies(ctx, srcFs, profile)
+	if err != nil || moved != 1 {
+		t.Fatalf("moveConflictCopies() = %d, %v, want 1 moved", moved, err)
+	}
+
+	copies, err := ListConflictCopies(ctx, profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]string{}
+	for _, c := range copies {
+		if c.Side != "from" {
+			t.Errorf("copy on the wrong side: %+v", c)
+		}
+		found[c.Path] = c.Original
+	}
+	if len(found) != 2 || found["_conflicts/docs/report.docx.laptop-conflict1"] != "docs/report.docx" || found["_conflicts/old.txt.desktop-conflict2"] != "old.txt" {
+		t.Errorf("conflict copies = %v", found)
+	}
+}
@@ -60,7 +60,7 @@ func ApplyCryptWrapping(ctx context.Context, profile *models.Profile) (cleanup f
 
 	if profile.EncryptDest {
 		remoteName := tempCryptPrefix + uuid.New().String()[:8]
-		if err := createTempCryptRemote(ctx, remoteName, profile.To, profile.EncryptPassword, profile.EncryptPassword2, filenameEncrypt, profile.EncryptDirectory); err != nil {
+		if err := createTempCryptRemote(ctx, remoteName, objectOptionsRemote(profile.To, *profile), profile.EncryptPassword, profile.EncryptPassword2, filenameEncrypt, profile.EncryptDirectory); err != nil {
 			cleanup()
 			return nil, fmt.Errorf("failed to create dest crypt remote: %w", err)
 		}
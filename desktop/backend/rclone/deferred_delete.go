@@ -0,0 +1,178 @@
+package rclone
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"time"
+
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/operations"
+	fssync "github.com/rclone/rclone/fs/sync"
+)
+
+// PendingDeleteFolder is the folder at a destination's root files deleted
+// from the source wait in when the profile defers deletions
+const PendingDeleteFolder = ".ngdrive-deleted"
+
+// PendingDeleteLedger is the file at a destination's root that records
+// the files in PendingDeleteFolder and how many runs they waited
+const PendingDeleteLedger = ".ngdrive-deleted.json"
+
+// syncDeferringDeletes makes dstFs match srcFs like a sync, except that
+// files missing from the source are moved to PendingDeleteFolder and only
+// purged once they stayed deleted for runs more successful runs
+func syncDeferringDeletes(ctx context.Context, dstFs, srcFs fs.Fs, runs int) error {
+	if err := fssync.CopyDir(ctx, dstFs, srcFs, false); err != nil {
+		return err
+	}
+	return deferDeletions(ctx, dstFs, srcFs, runs, time.Now().UTC())
+}
+
+// deferDeletions counts this run for the files already pending, purging
+// those that waited runs runs or are back in the source, then moves the
+// destination's files that aren't in the source into PendingDeleteFolder
+func deferDeletions(ctx context.Context, dstFs, srcFs fs.Fs, runs int, now time.Time) error {
+	pending, err := readPendingDeletions(ctx, dstFs)
+	if err != nil {
+		return err
+	}
+
+	kept := make(map[string]models.PendingDeletion, len(pending))
+	for _, p := range pending {
+		// The ledger isn't filtered like the listings below, so a run
+		// scoped to changed files still counts for every pending file
+		_, err := srcFs.NewObject(ctx, p.Path)
+		back := err == nil
+		if !back {
+			p.Runs++
+		}
+		if back || p.Runs >= runs {
+			if err := purgePendingDeletion(ctx, dstFs, p.Path); err != nil {
+				return err
+			}
+			continue
+		}
+		kept[p.Path] = p
+	}
+
+	source, err := listVerifyObjects(ctx, srcFs)
+	if err != nil {
+		return fmt.Errorf("failed to list source: %w", err)
+	}
+	dest, err := listVerifyObjects(ctx, dstFs)
+	if err != nil {
+		return fmt.Errorf("failed to list destination: %w", err)
+	}
+	for p, o := range dest {
+		if _, ok := source[p]; ok {
+			continue
+		}
+		size := o.Size()
+		if _, err := operations.Move(ctx, dstFs, nil, path.Join(PendingDeleteFolder, p), o); err != nil {
+			return fmt.Errorf("failed to move %s into %s: %w", p, PendingDeleteFolder, err)
+		}
+		kept[p] = models.PendingDeletion{Path: p, DeletedAt: now, Size: size}
+	}
+
+	if fs.GetConfig(ctx).DryRun {
+		return nil
+	}
+	return writePendingDeletions(ctx, dstFs, kept)
+}
+
+// purgePendingDeletion deletes the copy of a file in PendingDeleteFolder
+func purgePendingDeletion(ctx context.Context, dstFs fs.Fs, remote string) error {
+	o, err := dstFs.NewObject(ctx, path.Join(PendingDeleteFolder, remote))
+	if errors.Is(err, fs.ErrorObjectNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find pending deletion %s: %w", remote, err)
+	}
+	if err := operations.DeleteFile(ctx, o); err != nil {
+		return fmt.Errorf("failed to purge pending deletion %s: %w", remote, err)
+	}
+	return nil
+}
+
+// readPendingDeletions reads the ledger of f, empty when there is none
+func readPendingDeletions(ctx context.Context, f fs.Fs) ([]models.PendingDeletion, error) {
+	o, err := f.NewObject(ctx, PendingDeleteLedger)
+	if errors.Is(err, fs.ErrorObjectNotFound) || errors.Is(err, fs.ErrorDirNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s: %w", PendingDeleteLedger, err)
+	}
+	in, err := o.Open(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", PendingDeleteLedger, err)
+	}
+	defer in.Close()
+	var pending []models.PendingDeletion
+	if err := json.NewDecoder(in).Decode(&pending); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", PendingDeleteLedger, err)
+	}
+	return pending, nil
+}
+
+// writePendingDeletions replaces the ledger of f, removing it when nothing
+// is pending
+func writePendingDeletions(ctx context.Context, f fs.Fs, pending map[string]models.PendingDeletion) error {
+	if len(pending) == 0 {
+		o, err := f.NewObject(ctx, PendingDeleteLedger)
+		if err != nil {
+			return nil
+		}
+		return operations.DeleteFile(ctx, o)
+	}
+	list := make([]models.PendingDeletion, 0, len(pending))
+	for _, p := range pending {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	if _, err := operations.Rcat(ctx, f, PendingDeleteLedger, io.NopCloser(bytes.NewReader(data)), time.Now(), nil); err != nil {
+		return fmt.Errorf("failed to write %s: %w", PendingDeleteLedger, err)
+	}
+	return nil
+}
+
+// ListPendingDeletions lists the files runs of profile moved to the
+// pending-delete folder on either side, oldest deletion first
+func ListPendingDeletions(ctx context.Context, profile models.Profile) ([]models.PendingDeletion, error) {
+	ctx, err := SimpleContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	all := []models.PendingDeletion{}
+	for _, side := range []struct{ name, remote string }{{"from", profile.From}, {"to", profile.To}} {
+		f, err := fs.NewFs(ctx, side.remote)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize %s: %w", side.remote, err)
+		}
+		pending, err := readPendingDeletions(ctx, f)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range pending {
+			p.Side = side.name
+			all = append(all, p)
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].DeletedAt.Before(all[j].DeletedAt)
+	})
+	return all, nil
+}
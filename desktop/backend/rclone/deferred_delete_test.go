@@ -0,0 +1,73 @@
+package rclone
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	beConfig "desktop/backend/config"
+	"desktop/backend/dto"
+	"desktop/backend/models"
+)
+
+func TestSyncDeferringDeletes(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeTestFiles(t, src, map[string]string{"a.txt": "a", "dir/b.txt": "b", "c.txt": "c"})
+	profile := models.Profile{Name: "deferred", From: src, To: dst, DeferDeletes: 2}
+
+	run := func() {
+		t.Helper()
+		outStatus := make(chan *dto.SyncStatusDTO)
+		go func() {
+			for range outStatus {
+			}
+		}()
+		err := Sync(context.Background(), beConfig.Config{}, "push", profile, outStatus, nil)
+		close(outStatus)
+		if err != nil {
+			t.Fatalf("Sync: %v", err)
+		}
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dst, filepath.FromSlash(name)))
+		return err == nil
+	}
+
+	run()
+	if err := os.Remove(filepath.Join(src, "dir", "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(src, "c.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deleted files are moved aside rather than deleted
+	run()
+	if exists("dir/b.txt") || !exists(PendingDeleteFolder+"/dir/b.txt") || !exists(PendingDeleteFolder+"/c.txt") {
+		t.Fatal("expected the deleted files in the pending-delete folder")
+	}
+	pending, err := ListPendingDeletions(context.Background(), profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 2 || pending[0].Side != "to" || pending[0].Runs != 0 {
+		t.Fatalf("unexpected pending deletions: %+v", pending)
+	}
+
+	// A file back in the source isn't kept twice
+	writeTestFiles(t, src, map[string]string{"c.txt": "c again"})
+	run()
+	if !exists("c.txt") || exists(PendingDeleteFolder+"/c.txt") || !exists(PendingDeleteFolder+"/dir/b.txt") {
+		t.Fatal("expected c.txt restored from the source and dir/b.txt still pending")
+	}
+
+	// Purged after two runs it stayed deleted
+	run()
+	if exists(PendingDeleteFolder+"/dir/b.txt") || exists(PendingDeleteLedger) {
+		t.Fatal("expected dir/b.txt purged with the ledger")
+	}
+	if !exists("a.txt") {
+		t.Error("expected a.txt to be kept")
+	}
+}
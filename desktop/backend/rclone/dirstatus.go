@@ -0,0 +1,69 @@
+package rclone
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/filter"
+)
+
+// ListSubdirs returns the directories directly inside dir, given relative
+// to root, as paths relative to root, sorted.
+func ListSubdirs(ctx context.Context, root, dir string) ([]string, error) {
+	ctx, err := SimpleContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rootFs, err := fs.NewFs(ctx, root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access %s: %w", root, err)
+	}
+	entries, err := rootFs.List(ctx, strings.Trim(dir, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s in %s: %w", dir, root, err)
+	}
+	var dirs []string
+	for _, entry := range entries {
+		if d, ok := entry.(fs.Directory); ok {
+			dirs = append(dirs, d.Remote())
+		}
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// ExcludedDirFunc returns a function reporting whether the profile's include
+// and exclude rules leave a directory, given relative to the source, out of
+// its syncs.
+func ExcludedDirFunc(profile models.Profile) (func(dir string) bool, error) {
+	filterOpt := newDefaultFilterOpts()
+	for _, p := range profile.IncludedPaths {
+		if profile.UseRegex {
+			p = "{{regexp:}}" + p
+		}
+		filterOpt.IncludeRule = append(filterOpt.IncludeRule, p)
+	}
+	for _, p := range profile.ExcludedPaths {
+		if profile.UseRegex {
+			p = "{{regexp:}}" + p
+		}
+		filterOpt.ExcludeRule = append(filterOpt.ExcludeRule, p)
+	}
+	fi, err := filter.NewFilter(&filterOpt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter rules: %w", err)
+	}
+	include := fi.IncludeDirectory(context.Background(), nil)
+	return func(dir string) bool {
+		if strings.Trim(dir, "/") == "" {
+			return false
+		}
+		ok, err := include(dir)
+		return err == nil && !ok
+	}, nil
+}
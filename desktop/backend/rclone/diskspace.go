@@ -0,0 +1,147 @@
+package rclone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/fs"
+	fsConfig "github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/fspath"
+	"github.com/rclone/rclone/lib/diskusage"
+)
+
+// DiskSpaceCheckInterval is how often free space is re-checked while a sync runs
+const DiskSpaceCheckInterval = 10 * time.Second
+
+// LowDiskSpaceError reports that a local directory a sync writes to has less
+// free space than the configured threshold
+type LowDiskSpaceError struct {
+	Path     string
+	Free     int64
+	Required int64
+}
+
+func (e *LowDiskSpaceError) Error() string {
+	return fmt.Sprintf("not enough free disk space on %s: %s free, %s required",
+		e.Path, fs.SizeSuffix(e.Free).ByteUnit(), fs.SizeSuffix(e.Required).ByteUnit())
+}
+
+// LocalWriteDirs returns the local directories a sync action writes to: its
+// local destinations and backup dir, plus rclone's cache and temp directories
+func LocalWriteDirs(action string, profile models.Profile) []string {
+	var targets []string
+	switch action {
+	case "pull":
+		targets = append(targets, profile.From)
+	case "push":
+		targets = append(targets, profile.To)
+		targets = append(targets, profile.FanOutTo...)
+	default: // bisync writes to both sides
+		targets = append(targets, profile.From, profile.To)
+	}
+	if profile.BackupPath != "" {
+		targets = append(targets, profile.BackupPath)
+	}
+
+	dirs := []string{}
+	seen := make(map[string]bool)
+	add := func(dir string) {
+		if dir != "" && !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, t := range targets {
+		if dir, ok := localPath(t); ok {
+			add(dir)
+		}
+	}
+	add(fsConfig.GetCacheDir())
+	add(os.TempDir())
+	return dirs
+}
+
+// localPath returns the local directory of an rclone path, if it is local:
+// a plain path, an on-the-fly ":local:" path or a remote of type local
+func localPath(remotePath string) (string, bool) {
+	parsed, err := fspath.Parse(remotePath)
+	if err != nil {
+		return "", false
+	}
+	switch parsed.Name {
+	case "":
+	case ":local":
+	default:
+		if t, _ := fsConfig.FileGetValue(parsed.Name, "type"); t != "local" {
+			return "", false
+		}
+	}
+	if parsed.Path == "" {
+		return "", false
+	}
+	return filepath.Clean(parsed.Path), true
+}
+
+// CheckFreeSpace returns a *LowDiskSpaceError for the first directory with less
+// than minFree bytes available. Directories that don't exist yet are checked
+// through their nearest existing parent; platforms without disk usage support
+// are skipped.
+func CheckFreeSpace(dirs []string, minFree int64) error {
+	if minFree <= 0 {
+		return nil
+	}
+	for _, dir := range dirs {
+		info, err := diskusage.New(existingAncestor(dir))
+		if err != nil {
+			if !errors.Is(err, diskusage.ErrUnsupported) {
+				fs.Debugf(nil, "Could not read free space of %s: %v", dir, err)
+			}
+			continue
+		}
+		if int64(info.Available) < minFree {
+			return &LowDiskSpaceError{Path: dir, Free: int64(info.Available), Required: minFree}
+		}
+	}
+	return nil
+}
+
+// WatchFreeSpace re-checks free space every interval until ctx is done and
+// calls onLow once with the first *LowDiskSpaceError
+func WatchFreeSpace(ctx context.Context, dirs []string, minFree int64, interval time.Duration, onLow func(error)) {
+	if minFree <= 0 || len(dirs) == 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := CheckFreeSpace(dirs, minFree); err != nil {
+				onLow(err)
+				return
+			}
+		}
+	}
+}
+
+// existingAncestor returns dir or its nearest parent that exists
+func existingAncestor(dir string) string {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
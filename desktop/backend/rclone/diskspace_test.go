@@ -0,0 +1,51 @@
+package rclone
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"desktop/backend/models"
+)
+
+func TestLocalWriteDirs(t *testing.T) {
+	profile := models.Profile{From: "/home/user/docs", To: "gdrive:backup", BackupPath: "/mnt/backup/docs"}
+
+	pull := LocalWriteDirs("pull", profile)
+	if pull[0] != filepath.Clean("/home/user/docs") || pull[1] != filepath.Clean("/mnt/backup/docs") {
+		t.Errorf("expected the local source and backup dir for a pull, got %v", pull)
+	}
+
+	push := LocalWriteDirs("push", profile)
+	for _, dir := range push {
+		if dir == filepath.Clean("/home/user/docs") {
+			t.Errorf("a push to a remote should not check the local source, got %v", push)
+		}
+	}
+
+	profile.FanOutTo = []string{"/media/usb/docs", "s3:docs"}
+	push = LocalWriteDirs("push", profile)
+	if push[0] != filepath.Clean("/media/usb/docs") {
+		t.Errorf("expected the local fan-out destination to be checked, got %v", push)
+	}
+}
+
+func TestCheckFreeSpace(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := CheckFreeSpace([]string{dir}, 1); err != nil {
+		t.Errorf("expected enough space for one byte, got %v", err)
+	}
+	if err := CheckFreeSpace([]string{filepath.Join(dir, "not", "created")}, 1); err != nil {
+		t.Errorf("expected a missing directory to be checked through its parent, got %v", err)
+	}
+
+	err := CheckFreeSpace([]string{dir}, 1<<62)
+	var lowSpace *LowDiskSpaceError
+	if !errors.As(err, &lowSpace) || lowSpace.Path != dir {
+		t.Fatalf("expected a LowDiskSpaceError for %s, got %v", dir, err)
+	}
+	if err := CheckFreeSpace([]string{dir}, 0); err != nil {
+		t.Errorf("expected a zero threshold to disable the check, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package rclone
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// Google Drive scopes, as accepted by the drive backend's "scope" option
+const (
+	DriveScopeFull      = "drive"
+	DriveScopeReadOnly  = "drive.readonly"
+	DriveScopeFile      = "drive.file"
+	DriveScopeAppFolder = "drive.appfolder"
+)
+
+// DriveScopes lists the scopes offered when creating a Google Drive remote
+var DriveScopes = []models.DriveScope{
+	{Value: DriveScopeFull, Label: "Full access", Description: "All files in My Drive, shared drives and Computers."},
+	{Value: DriveScopeReadOnly, Label: "Read-only", Description: "Read all files, including Computers backups; nothing can be changed."},
+	{Value: DriveScopeFile, Label: "App files only", Description: "Only files created by this app; other files stay invisible."},
+	{Value: DriveScopeAppFolder, Label: "App data folder", Description: "A hidden folder private to this app, not visible in Drive."},
+}
+
+// driveComputersQuery finds folders owned by the user. Computer roots are the
+// ones without a parent: they sit next to My Drive, not inside it.
+const driveComputersQuery = "'me' in owners and mimeType = 'application/vnd.google-apps.folder' and trashed = false"
+
+// ValidateDriveScope checks a Drive "scope" option, which may list several
+// comma-separated scopes. Empty uses rclone's default (full access).
+func ValidateDriveScope(scope string) error {
+	if scope == "" {
+		return nil
+	}
+	for _, s := range strings.Split(scope, ",") {
+		s = strings.TrimSpace(s)
+		known := false
+		for _, d := range DriveScopes {
+			if s == d.Value {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown Google Drive scope '%s'", s)
+		}
+	}
+	return nil
+}
+
+// DriveScopeSeesComputers reports whether a Drive scope can read the
+// Computers root; the app-only scopes can't
+func DriveScopeSeesComputers(scope string) bool {
+	if scope == "" {
+		return true
+	}
+	for _, s := range strings.Split(scope, ",") {
+		switch strings.TrimSpace(s) {
+		case DriveScopeFull, DriveScopeReadOnly:
+			return true
+		}
+	}
+	return false
+}
+
+// DriveFolderPath returns an rclone path rooted at a Drive folder ID, such as
+// a computer from the Computers root
+func DriveFolderPath(remote, folderId string) string {
+	return fmt.Sprintf("%s,root_folder_id=%s:", strings.TrimSuffix(remote, ":"), folderId)
+}
+
+// ListDriveComputers returns the computers backed up to a Google Drive remote
+// by Google's Backup & Sync / Drive for desktop
+func ListDriveComputers(ctx context.Context, remote string) ([]models.DriveComputer, error) {
+	f, err := fs.NewFs(ctx, strings.TrimSuffix(remote, ":")+":")
+	if err != nil {
+		return nil, fmt.Errorf("failed to access %s: %w", remote, err)
+	}
+	commander := f.Features().Command
+	if commander == nil {
+		return nil, fmt.Errorf("%s is not a Google Drive remote", remote)
+	}
+	out, err := commander(ctx, "query", []string{driveComputersQuery}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list computers: %w", err)
+	}
+	return parseDriveComputers(remote, out)
+}
+
+// parseDriveComputers picks the parentless folders out of a Drive query result
+func parseDriveComputers(remote string, out interface{}) ([]models.DriveComputer, error) {
+	data, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	var folders []struct {
+		Id           string   `json:"id"`
+		Name         string   `json:"name"`
+		ModifiedTime string   `json:"modifiedTime"`
+		Parents      []string `json:"parents"`
+	}
+	if err := json.Unmarshal(data, &folders); err != nil {
+		return nil, fmt.Errorf("unexpected query result: %w", err)
+	}
+
+	computers := []models.DriveComputer{}
+	for _, folder := range folders {
+		if len(folder.Parents) > 0 {
+			continue
+		}
+		computers = append(computers, models.DriveComputer{
+			Id:           folder.Id,
+			Name:         folder.Name,
+			ModifiedTime: folder.ModifiedTime,
+			Path:         DriveFolderPath(remote, folder.Id),
+		})
+	}
+	sort.Slice(computers, func(i, j int) bool { return computers[i].Name < computers[j].Name })
+	return computers, nil
+}
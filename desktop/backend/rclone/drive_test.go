@@ -0,0 +1,38 @@
+package rclone
+
+import "testing"
+
+func TestValidateDriveScope(t *testing.T) {
+	for _, scope := range []string{"", "drive", "drive.file", "drive.appfolder", "drive.readonly,drive.appfolder"} {
+		if err := ValidateDriveScope(scope); err != nil {
+			t.Errorf("ValidateDriveScope(%q) = %v, want nil", scope, err)
+		}
+	}
+	if err := ValidateDriveScope("drive.photos"); err == nil {
+		t.Error("expected an unknown scope to be rejected")
+	}
+
+	if DriveScopeSeesComputers("drive.file") || !DriveScopeSeesComputers("") || !DriveScopeSeesComputers("drive.readonly") {
+		t.Error("only the full and read-only scopes can see the Computers root")
+	}
+}
+
+func TestParseDriveComputers(t *testing.T) {
+	// Shape of the drive backend's "query" command result
+	out := []map[string]interface{}{
+		{"id": "2", "name": "Work laptop", "modifiedTime": "2024-01-02T00:00:00Z"},
+		{"id": "3", "name": "Photos", "parents": []string{"root"}},
+		{"id": "1", "name": "Home PC", "modifiedTime": "2023-05-06T00:00:00Z"},
+	}
+
+	computers, err := parseDriveComputers("gdrive:", out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(computers) != 2 || computers[0].Name != "Home PC" || computers[1].Name != "Work laptop" {
+		t.Fatalf("expected the two parentless folders sorted by name, got %+v", computers)
+	}
+	if computers[0].Path != "gdrive,root_folder_id=1:" {
+		t.Errorf("unexpected computer path %q", computers[0].Path)
+	}
+}
@@ -0,0 +1,32 @@
+package rclone
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// probeTimeout bounds how long ProbeRemote waits for a remote to answer
+const probeTimeout = 30 * time.Second
+
+// ProbeRemote reports whether a remote path can be reached by opening it
+// and listing its top directory. A directory that doesn't exist yet counts
+// as reachable, since the sync creates it.
+func ProbeRemote(ctx context.Context, path string) error {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	f, err := fs.NewFs(ctx, path)
+	if errors.Is(err, fs.ErrorIsFile) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := f.List(ctx, ""); err != nil && !errors.Is(err, fs.ErrorDirNotFound) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package rclone
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestProbeRemote(t *testing.T) {
+	dir := t.TempDir()
+	for _, path := range []string{dir, filepath.Join(dir, "not-created-yet")} {
+		if err := ProbeRemote(context.Background(), path); err != nil {
+			t.Errorf("ProbeRemote(%s) = %v, want reachable", path, err)
+		}
+	}
+	if err := ProbeRemote(context.Background(), "nosuchremote:backup"); err == nil {
+		t.Error("expected an unknown remote to be unreachable")
+	}
+}
@@ -0,0 +1,157 @@
+package rclone
+
+import (
+	"context"
+	"desktop/backend/dto"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	beConfig "desktop/backend/config"
+	"desktop/backend/models"
+	"desktop/backend/utils"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/dirtree"
+	fssync "github.com/rclone/rclone/fs/sync"
+	"github.com/rclone/rclone/fs/walk"
+)
+
+// cachedListFs serves directory listings of a source from a tree listed once,
+// so syncing it to several destinations doesn't list it again for each
+type cachedListFs struct {
+	fs.Fs
+	tree     dirtree.DirTree
+	features *fs.Features
+}
+
+// newCachedListFs lists f recursively (honouring the context's filters and
+// max depth) and returns it wrapped to answer listings from memory
+func newCachedListFs(ctx context.Context, f fs.Fs) (*cachedListFs, error) {
+	tree, err := walk.NewDirTree(ctx, f, "", false, fs.GetConfig(ctx).MaxDepth)
+	if err != nil {
+		return nil, err
+	}
+	// Hide recursive and paged listing so callers go through List
+	features := *f.Features()
+	features.ListR = nil
+	features.ListP = nil
+	return &cachedListFs{Fs: f, tree: tree, features: &features}, nil
+}
+
+// List returns the cached entries of dir
+func (f *cachedListFs) List(ctx context.Context, dir string) (fs.DirEntries, error) {
+	entries, ok := f.tree[dir]
+	if !ok {
+		if dir == "" {
+			return fs.DirEntries{}, nil
+		}
+		return nil, fs.ErrorDirNotFound
+	}
+	// Callers sort and filter the slice in place
+	return append(fs.DirEntries(nil), entries...), nil
+}
+
+// Features returns the source's features without ListR and ListP
+func (f *cachedListFs) Features() *fs.Features {
+	return f.features
+}
+
+// FanOutSync syncs profile.From to each of profile.Destinations(), listing
+// the source once. Destinations run one after another, or all at once in
+// parallel mode. Retries only repeat the destinations that failed. onResult
+// is called with each destination's final outcome. Delta state isn't used:
+// every destination gets a full sync.
+func FanOutSync(ctx context.Context, config beConfig.Config, profile models.Profile, outStatus chan *dto.SyncStatusDTO, onResult func(models.DestinationResult)) error {
+	srcFs, err := newFs(ctx, profile.From)
+	if utils.HandleError(err, "Failed to initialize source filesystem", nil, nil) != nil {
+		return err
+	}
+	destinations := profile.Destinations()
+	dstFss := make([]fs.Fs, len(destinations))
+	for i, dest := range destinations {
+		dstFss[i], err = newFs(ctx, objectOptionsRemote(dest, profile))
+		if utils.HandleError(err, "Failed to initialize destination filesystem", nil, nil) != nil {
+			return fmt.Errorf("destination %s: %w", dest, err)
+		}
+	}
+
+	ctx, err = applySyncOptions(ctx, profile)
+	if err != nil {
+		return err
+	}
+
+	results := make([]models.DestinationResult, len(destinations))
+	pending := make(map[int]bool, len(destinations))
+	for i, dest := range destinations {
+		results[i] = models.DestinationResult{Destination: dest, Status: "failed"}
+		pending[i] = true
+	}
+
+	var cached *cachedListFs
+	syncErr := utils.RunRcloneWithRetryAndStats(ctx, true, false, outStatus, func() error {
+		if cached == nil {
+			started := time.Now()
+			if cached, err = newCachedListFs(ctx, srcFs); err != nil {
+				return utils.HandleError(err, "Failed to list source", nil, nil)
+			}
+			log.Printf("[fan-out] Listed source %s once in %s for %d destinations", profile.From, time.Since(started).Round(time.Millisecond), len(destinations))
+		}
+
+		var mu sync.Mutex
+		var errs []error
+		runOne := func(i int) {
+			started := time.Now()
+			err := fssync.Sync(ctx, dstFss[i], cached, false)
+			mu.Lock()
+			defer mu.Unlock()
+			results[i].DurationMs = time.Since(started).Milliseconds()
+			if err != nil {
+				results[i].Error = err.Error()
+				errs = append(errs, fmt.Errorf("%s: %w", destinations[i], err))
+				return
+			}
+			results[i].Status = "completed"
+			results[i].Error = ""
+			delete(pending, i)
+		}
+
+		indexes := make([]int, 0, len(pending))
+		for i := range destinations {
+			if pending[i] {
+				indexes = append(indexes, i)
+			}
+		}
+		if profile.FanOutMode == models.FanOutParallel {
+			var wg sync.WaitGroup
+			for _, i := range indexes {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					runOne(i)
+				}(i)
+			}
+			wg.Wait()
+		} else {
+			for _, i := range indexes {
+				if ctx.Err() != nil {
+					break
+				}
+				runOne(i)
+			}
+		}
+		return utils.HandleError(errors.Join(errs...), "Sync failed", nil, nil)
+	})
+
+	for i := range results {
+		if pending[i] && ctx.Err() != nil {
+			results[i].Status = "cancelled"
+		}
+		if onResult != nil {
+			onResult(results[i])
+		}
+	}
+	return syncErr
+}
@@ -0,0 +1,120 @@
+package rclone
+
+import (
+	"context"
+	"desktop/backend/dto"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	beConfig "desktop/backend/config"
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/fs"
+)
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCachedListFs(t *testing.T) {
+	src := t.TempDir()
+	writeTestFiles(t, src, map[string]string{"a.txt": "a", "sub/b.txt": "b"})
+
+	ctx := context.Background()
+	f, err := fs.NewFs(ctx, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached, err := newCachedListFs(ctx, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// New files don't show up: the listing was taken once
+	writeTestFiles(t, src, map[string]string{"late.txt": "late"})
+	entries, err := cached.List(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("root entries = %v, want a.txt and sub", entries)
+	}
+	if _, err := cached.List(ctx, "missing"); err != fs.ErrorDirNotFound {
+		t.Errorf("List(missing) err = %v, want ErrorDirNotFound", err)
+	}
+	if cached.Features().ListR != nil || cached.Features().ListP != nil {
+		t.Error("recursive and paged listing should be hidden")
+	}
+}
+
+func TestFanOutSync(t *testing.T) {
+	for _, mode := range []string{models.FanOutSequential, models.FanOutParallel} {
+		t.Run("mode="+mode, func(t *testing.T) {
+			src, dst1, dst2 := t.TempDir(), t.TempDir(), t.TempDir()
+			writeTestFiles(t, src, map[string]string{"a.txt": "a", "sub/b.txt": "b", "skip.tmp": "x"})
+			writeTestFiles(t, dst2, map[string]string{"stale.txt": "old"})
+
+			outStatus := make(chan *dto.SyncStatusDTO)
+			done := make(chan struct{})
+			go func() {
+				for range outStatus {
+				}
+				close(done)
+			}()
+
+			var mu sync.Mutex
+			var results []models.DestinationResult
+			profile := models.Profile{
+				From: src, To: dst1, FanOutTo: []string{dst2}, FanOutMode: mode,
+				ExcludedPaths: []string{"*.tmp"},
+			}
+			ctx, _ := fs.AddConfig(context.Background())
+			err := FanOutSync(ctx, beConfig.Config{}, profile, outStatus, func(r models.DestinationResult) {
+				mu.Lock()
+				results = append(results, r)
+				mu.Unlock()
+			})
+			close(outStatus)
+			<-done
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, dst := range []string{dst1, dst2} {
+				for _, name := range []string{"a.txt", "sub/b.txt"} {
+					if _, err := os.Stat(filepath.Join(dst, name)); err != nil {
+						t.Errorf("%s missing in %s", name, dst)
+					}
+				}
+				if _, err := os.Stat(filepath.Join(dst, "skip.tmp")); err == nil {
+					t.Errorf("excluded file copied to %s", dst)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(dst2, "stale.txt")); err == nil {
+				t.Error("stale.txt should be deleted from the second destination")
+			}
+
+			sort.Slice(results, func(i, j int) bool { return results[i].Destination < results[j].Destination })
+			if len(results) != 2 {
+				t.Fatalf("results = %+v", results)
+			}
+			for _, r := range results {
+				if r.Status != "completed" || r.Error != "" {
+					t.Errorf("result = %+v", r)
+				}
+			}
+		})
+	}
+}
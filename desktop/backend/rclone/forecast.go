@@ -0,0 +1,62 @@
+package rclone
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	fssync "github.com/rclone/rclone/fs/sync"
+)
+
+// planCounter numbers the stats groups of transfer plans
+var planCounter atomic.Int64
+
+// PlanTransfer works out, with a dry run, how many files and bytes a one-way
+// sync of the profile would transfer: those missing or changed at the
+// destination, with the profile's filters applied. A pull is planned from
+// profile.To to profile.From.
+func PlanTransfer(ctx context.Context, profile models.Profile, pull bool) (files, bytes int64, err error) {
+	ctx, err = SimpleContext(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	ctx = accounting.WithStatsGroup(ctx, fmt.Sprintf("plan-%d", planCounter.Add(1)))
+	stats := accounting.Stats(ctx)
+	stats.ResetCounters()
+
+	fsConfig := fs.GetConfig(ctx)
+	if profile.Parallel > 0 {
+		fsConfig.Checkers = profile.Parallel * 2
+	}
+	from, to := profile.From, profile.To
+	if pull {
+		from, to = to, from
+	}
+	srcFs, err := fs.NewFs(ctx, from)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to initialize source filesystem: %w", err)
+	}
+	dstFs, err := fs.NewFs(ctx, to)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to initialize destination filesystem: %w", err)
+	}
+
+	ctx = applyFiltersAndBandwidth(ctx, fsConfig, profile)
+	ctx, err = ApplyProfileOptions(ctx, profile)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to apply profile options: %w", err)
+	}
+	fsConfig.DryRun = true
+	if err := fsConfig.Reload(ctx); err != nil {
+		return 0, 0, err
+	}
+
+	if err := fssync.CopyDir(ctx, dstFs, srcFs, false); err != nil {
+		return 0, 0, fmt.Errorf("failed to plan transfer: %w", err)
+	}
+	return stats.GetTransfers(), stats.GetBytes(), nil
+}
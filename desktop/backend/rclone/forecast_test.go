@@ -0,0 +1,30 @@
+package rclone
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"desktop/backend/models"
+)
+
+func TestPlanTransfer(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeTestFiles(t, src, map[string]string{"a.txt": "hello", "dir/b.txt": "world!", "same.txt": "same"})
+	writeTestFiles(t, dst, map[string]string{"same.txt": "same"})
+
+	files, bytes, err := PlanTransfer(context.Background(), models.Profile{From: src, To: dst}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files != 2 || bytes != 11 {
+		t.Errorf("PlanTransfer() = %d files, %d bytes, want 2 files, 11 bytes", files, bytes)
+	}
+	if _, err := os.Stat(dst + "/a.txt"); !os.IsNotExist(err) {
+		t.Error("planning should not transfer anything")
+	}
+
+	if files, _, err := PlanTransfer(context.Background(), models.Profile{From: src, To: dst}, true); err != nil || files != 0 {
+		t.Errorf("pull plan = %d files, %v; want nothing to pull", files, err)
+	}
+}
@@ -0,0 +1,114 @@
+package rclone
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/filter"
+	"github.com/rclone/rclone/fs/operations"
+)
+
+// LifecycleCandidates lists the files under the rule's source path that are
+// older than its minimum age and pass its include/exclude filters. Files are
+// ordered oldest first and cut off at maxFiles and maxBytes (0 = no cap), so
+// a run never moves more than the rule's safety caps allow.
+func LifecycleCandidates(ctx context.Context, rule models.LifecycleRule, maxFiles int, maxBytes int64) (*models.LifecyclePreview, error) {
+	srcFs, err := fs.NewFs(ctx, rule.SourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access %s: %w", rule.SourcePath, err)
+	}
+
+	filterOpt := CopyFilterOpt(ctx)
+	if err := filterOpt.MinAge.Set(rule.MinAge); err != nil {
+		return nil, fmt.Errorf("invalid min_age %q: %w", rule.MinAge, err)
+	}
+	filterOpt.IncludeRule = append(filterOpt.IncludeRule, rule.IncludedPaths...)
+	filterOpt.ExcludeRule = append(filterOpt.ExcludeRule, rule.ExcludedPaths...)
+	newFilter, err := filter.NewFilter(&filterOpt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create filter: %w", err)
+	}
+	ctx = filter.ReplaceConfig(ctx, newFilter)
+
+	var files []models.LifecycleCandidate
+	opt := operations.ListJSONOpt{Recurse: true, FilesOnly: true, NoMimeType: true}
+	err = operations.ListJSON(ctx, srcFs, "", &opt, func(item *operations.ListJSONItem) error {
+		files = append(files, models.LifecycleCandidate{
+			Path:    item.Path,
+			Size:    item.Size,
+			ModTime: item.ModTime.When.UTC().Format("2006-01-02T15:04:05Z"),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files in %s: %w", rule.SourcePath, err)
+	}
+
+	return capLifecycleCandidates(rule.Id, files, maxFiles, maxBytes), nil
+}
+
+// capLifecycleCandidates orders files oldest first and keeps as many as fit
+// within maxFiles and maxBytes (0 = no cap)
+func capLifecycleCandidates(ruleId string, files []models.LifecycleCandidate, maxFiles int, maxBytes int64) *models.LifecyclePreview {
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].ModTime != files[j].ModTime {
+			return files[i].ModTime < files[j].ModTime
+		}
+		return files[i].Path < files[j].Path
+	})
+
+	preview := &models.LifecyclePreview{RuleId: ruleId, Files: []models.LifecycleCandidate{}}
+	for _, f := range files {
+		preview.MatchedFiles++
+		preview.MatchedBytes += f.Size
+		if preview.Capped {
+			continue
+		}
+		if (maxFiles > 0 && len(preview.Files) >= maxFiles) || (maxBytes > 0 && preview.TotalBytes+f.Size > maxBytes) {
+			preview.Capped = true
+			continue
+		}
+		preview.Files = append(preview.Files, f)
+		preview.TotalBytes += f.Size
+	}
+	preview.TotalFiles = len(preview.Files)
+	return preview
+}
+
+// ExistingFiles reports which of the given paths (relative to remotePath)
+// exist as files on the remote. Only the directories leading to them are listed.
+func ExistingFiles(ctx context.Context, remotePath string, paths []string) (map[string]bool, error) {
+	found := make(map[string]bool, len(paths))
+	if len(paths) == 0 {
+		return found, nil
+	}
+
+	remoteFs, err := fs.NewFs(ctx, remotePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access %s: %w", remotePath, err)
+	}
+
+	filterOpt := newDefaultFilterOpts()
+	for _, p := range paths {
+		filterOpt.IncludeRule = append(filterOpt.IncludeRule, "/"+escapeGlob(p))
+	}
+	newFilter, err := filter.NewFilter(&filterOpt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create filter: %w", err)
+	}
+	ctx = filter.ReplaceConfig(ctx, newFilter)
+
+	opt := operations.ListJSONOpt{Recurse: true, FilesOnly: true, NoModTime: true, NoMimeType: true}
+	err = operations.ListJSON(ctx, remoteFs, "", &opt, func(item *operations.ListJSONItem) error {
+		found[item.Path] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files in %s: %w", remotePath, err)
+	}
+	return found, nil
+}
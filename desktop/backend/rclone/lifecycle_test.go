@@ -0,0 +1,77 @@
+package rclone
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"desktop/backend/models"
+)
+
+func TestCapLifecycleCandidates(t *testing.T) {
+	files := []models.LifecycleCandidate{
+		{Path: "c.txt", Size: 30, ModTime: "2023-03-01T00:00:00Z"},
+		{Path: "a.txt", Size: 10, ModTime: "2021-01-01T00:00:00Z"},
+		{Path: "b.txt", Size: 20, ModTime: "2022-02-01T00:00:00Z"},
+	}
+
+	preview := capLifecycleCandidates("r1", append([]models.LifecycleCandidate(nil), files...), 0, 0)
+	if preview.Capped || preview.TotalFiles != 3 || preview.TotalBytes != 60 || preview.Files[0].Path != "a.txt" {
+		t.Errorf("expected all files oldest first, got %+v", preview)
+	}
+
+	preview = capLifecycleCandidates("r1", append([]models.LifecycleCandidate(nil), files...), 2, 0)
+	if !preview.Capped || preview.TotalFiles != 2 || preview.MatchedFiles != 3 || preview.Files[1].Path != "b.txt" {
+		t.Errorf("expected the file cap to keep the two oldest, got %+v", preview)
+	}
+
+	preview = capLifecycleCandidates("r1", append([]models.LifecycleCandidate(nil), files...), 0, 35)
+	if !preview.Capped || preview.TotalFiles != 2 || preview.TotalBytes != 30 || preview.MatchedBytes != 60 {
+		t.Errorf("expected the size cap to stop before c.txt, got %+v", preview)
+	}
+}
+
+func TestLifecycleCandidatesAndExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-400 * 24 * time.Hour)
+	write := func(name string, modTime time.Time) {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("projects/old.txt", old)
+	write("projects/[draft].txt", old)
+	write("projects/skip.log", old)
+	write("projects/new.txt", time.Now())
+
+	ctx, err := SimpleContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rule := models.LifecycleRule{Id: "r1", SourcePath: dir, MinAge: "1y", ExcludedPaths: []string{"*.log"}}
+	preview, err := LifecycleCandidates(ctx, rule, 0, 0)
+	if err != nil {
+		t.Fatalf("LifecycleCandidates: %v", err)
+	}
+	if preview.TotalFiles != 2 {
+		t.Fatalf("expected the two old non-log files, got %+v", preview.Files)
+	}
+
+	found, err := ExistingFiles(ctx, dir, []string{"projects/[draft].txt", "projects/gone.txt"})
+	if err != nil {
+		t.Fatalf("ExistingFiles: %v", err)
+	}
+	if !found["projects/[draft].txt"] || found["projects/gone.txt"] || len(found) != 1 {
+		t.Errorf("unexpected existing files: %v", found)
+	}
+}
@@ -0,0 +1,109 @@
+package rclone
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/walk"
+)
+
+// ManifestName is the name of the manifest written to a destination's root
+const ManifestName = ".ngdrive-manifest.json"
+
+// ChecksumCache remembers file hashes that are slow to compute, keyed by
+// the file's full path, size and modification time, so unchanged files
+// aren't read again
+type ChecksumCache interface {
+	GetChecksum(path string, size int64, modTime time.Time, hashType string) (string, bool)
+	PutChecksum(path string, size int64, modTime time.Time, hashType, sum string)
+}
+
+// WriteManifest lists the profile's destination and writes its files, with
+// their sizes and hashes, to ManifestName at its root. The destination's
+// first supported hash is used; on remotes where hashes are slow, such as
+// local disks, they are looked up in the cache first. cache may be nil.
+func WriteManifest(ctx context.Context, profile models.Profile, runId string, cache ChecksumCache) (*models.Manifest, error) {
+	dstFs, err := fs.NewFs(ctx, profile.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize destination filesystem: %w", err)
+	}
+
+	ht := dstFs.Hashes().GetOne()
+	if !dstFs.Features().SlowHash {
+		cache = nil
+	}
+	manifest := &models.Manifest{
+		Version:     models.ManifestVersion,
+		RunId:       runId,
+		ProfileName: profile.Name,
+		Source:      profile.From,
+		Destination: profile.To,
+		CreatedAt:   time.Now().UTC(),
+		Files:       []models.ManifestFile{},
+	}
+	if ht != hash.None {
+		manifest.HashType = ht.String()
+	}
+
+	root := fs.ConfigString(dstFs)
+	err = walk.ListR(ctx, dstFs, "", false, -1, walk.ListObjects, func(entries fs.DirEntries) error {
+		for _, entry := range entries {
+			o, ok := entry.(fs.Object)
+			if !ok || o.Remote() == ManifestName || o.Remote() == PendingDeleteLedger || strings.HasPrefix(o.Remote(), PendingDeleteFolder+"/") {
+				continue
+			}
+			file := models.ManifestFile{Path: o.Remote(), Size: o.Size(), ModTime: o.ModTime(ctx).UTC()}
+			if ht != hash.None {
+				sum, err := manifestHash(ctx, o, ht, root, cache)
+				if err != nil {
+					return fmt.Errorf("failed to hash %s: %w", o.Remote(), err)
+				}
+				file.Hash = sum
+			}
+			manifest.Files = append(manifest.Files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list destination: %w", err)
+	}
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Path < manifest.Files[j].Path })
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := operations.Rcat(ctx, dstFs, ManifestName, io.NopCloser(bytes.NewReader(data)), time.Now(), nil); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// manifestHash returns an object's hash, from the cache when it has it
+func manifestHash(ctx context.Context, o fs.Object, ht hash.Type, root string, cache ChecksumCache) (string, error) {
+	path := root + "/" + o.Remote()
+	if cache != nil {
+		if sum, ok := cache.GetChecksum(path, o.Size(), o.ModTime(ctx), ht.String()); ok {
+			return sum, nil
+		}
+	}
+	sum, err := o.Hash(ctx, ht)
+	if err != nil {
+		return "", err
+	}
+	if cache != nil && sum != "" {
+		cache.PutChecksum(path, o.Size(), o.ModTime(ctx), ht.String(), sum)
+	}
+	return sum, nil
+}
@@ -0,0 +1,90 @@
+package rclone
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	beConfig "desktop/backend/config"
+	"desktop/backend/dto"
+	"desktop/backend/models"
+)
+
+// mapChecksumCache is a ChecksumCache in memory
+type mapChecksumCache map[string]string
+
+func (c mapChecksumCache) GetChecksum(path string, size int64, modTime time.Time, hashType string) (string, bool) {
+	sum, ok := c[path]
+	return sum, ok
+}
+
+func (c mapChecksumCache) PutChecksum(path string, size int64, modTime time.Time, hashType, sum string) {
+	c[path] = sum
+}
+
+func TestWriteManifest(t *testing.T) {
+	dst := t.TempDir()
+	writeTestFiles(t, dst, map[string]string{"a.txt": "hello", "dir/b.txt": "world!"})
+
+	cache := mapChecksumCache{}
+	profile := models.Profile{Name: "backup", From: "/data", To: dst}
+	manifest, err := WriteManifest(context.Background(), profile, "run-1", cache)
+	if err != nil {
+		t.Fatalf("WriteManifest failed: %v", err)
+	}
+	if manifest.HashType != "md5" || len(manifest.Files) != 2 || manifest.Files[0].Path != "a.txt" ||
+		manifest.Files[0].Hash != "5d41402abc4b2a76b9719d911017c592" || manifest.Files[1].Size != 6 || len(cache) != 2 {
+		t.Fatalf("unexpected manifest: %+v (cache %v)", manifest, cache)
+	}
+
+	// The manifest is at the destination's root and isn't listed in the next one
+	data, err := os.ReadFile(filepath.Join(dst, ManifestName))
+	if err != nil {
+		t.Fatalf("manifest not written: %v", err)
+	}
+	var written models.Manifest
+	if err := json.Unmarshal(data, &written); err != nil || written.RunId != "run-1" || written.Version != models.ManifestVersion {
+		t.Fatalf("unexpected written manifest: %+v, %v", written, err)
+	}
+
+	// Cached hashes are reused
+	for path := range cache {
+		cache[path] = "cached"
+	}
+	again, err := WriteManifest(context.Background(), profile, "run-2", cache)
+	if err != nil {
+		t.Fatalf("WriteManifest failed: %v", err)
+	}
+	var paths []string
+	for _, f := range again.Files {
+		paths = append(paths, f.Path)
+	}
+	if !slices.Equal(paths, []string{"a.txt", "dir/b.txt"}) || again.Files[0].Hash != "cached" {
+		t.Errorf("expected the cached hashes and no manifest entry, got %+v", again.Files)
+	}
+
+	// Syncing doesn't delete the manifest as a file missing from the source
+	src := t.TempDir()
+	writeTestFiles(t, src, map[string]string{"a.txt": "hello"})
+	profile.From, profile.WriteManifest = src, true
+	outStatus := make(chan *dto.SyncStatusDTO)
+	go func() {
+		for range outStatus {
+		}
+	}()
+	err = Sync(context.Background(), beConfig.Config{}, "push", profile, outStatus, nil)
+	close(outStatus)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, ManifestName)); err != nil {
+		t.Errorf("expected the manifest to survive the sync: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "dir", "b.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected the sync to delete dir/b.txt, got %v", err)
+	}
+}
@@ -0,0 +1,149 @@
+package rclone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rclone/rclone/cmd/mountlib"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/rc"
+	"github.com/rclone/rclone/vfs/vfscommon"
+
+	// Mount implementations, each registering itself where it is supported:
+	// FUSE on Linux; cmount (WinFsp, macFUSE or FUSE-T) in builds with the
+	// cmount tag; NFS on macOS and the BSDs
+	_ "github.com/rclone/rclone/cmd/cmount"
+	_ "github.com/rclone/rclone/cmd/mount"
+	_ "github.com/rclone/rclone/cmd/nfsmount"
+)
+
+// ErrMountUnsupported is returned by MountRemote when this build has no way to
+// mount on this system
+var ErrMountUnsupported = errors.New("mounting isn't supported on this system")
+
+// mountTypes are the mount implementations tried, in order
+var mountTypes = []string{"mount", "cmount", "nfsmount"}
+
+// MountConfig configures a mount
+type MountConfig struct {
+	CacheMode    string        // VFS cache mode: "off", "minimal", "writes" or "full"
+	CacheMaxSize int64         // bytes the VFS cache may use; 0 = rclone's default
+	CacheMaxAge  time.Duration // how long unused files stay in the VFS cache; 0 = rclone's default
+	ReadOnly     bool
+	VolumeName   string // name shown for the drive; defaults to the remote
+}
+
+// Mount is a remote mounted at a local mountpoint
+type Mount struct {
+	point *mountlib.MountPoint
+}
+
+// MountStats is the state of a mount's VFS cache
+type MountStats struct {
+	UploadsInProgress int
+	UploadsQueued     int
+	CachedFiles       int
+	CacheBytes        int64
+}
+
+// MountSupported reports whether this build can mount on this system
+func MountSupported() bool {
+	_, mountFn := resolveMount()
+	return mountFn != nil
+}
+
+// resolveMount returns the first mount implementation registered
+func resolveMount() (string, mountlib.MountFn) {
+	for _, mountType := range mountTypes {
+		if name, mountFn := mountlib.ResolveMountMethod(mountType); mountFn != nil {
+			return name, mountFn
+		}
+	}
+	return "", nil
+}
+
+// MountRemote mounts remotePath at mountpoint through rclone's VFS. Any
+// remote rclone can open can be mounted, crypt remotes included. The
+// returned Mount's Done channel reports when it ends, also when it was
+// unmounted from outside the app.
+func MountRemote(ctx context.Context, remotePath, mountpoint string, cfg MountConfig) (*Mount, error) {
+	mountType, mountFn := resolveMount()
+	if mountFn == nil {
+		return nil, ErrMountUnsupported
+	}
+	vfsOpt, err := mountVFSOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	mountOpt := mountlib.Opt
+	mountOpt.VolumeName = cfg.VolumeName
+
+	f, err := fs.NewFs(ctx, remotePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", remotePath, err)
+	}
+	point := mountlib.NewMountPoint(mountFn, mountpoint, f, &mountOpt, vfsOpt)
+	if _, err := point.Mount(); err != nil {
+		return nil, err
+	}
+	fs.Debugf(nil, "Mounted %s at %s using %s", remotePath, mountpoint, mountType)
+	return &Mount{point: point}, nil
+}
+
+// mountVFSOptions returns rclone's VFS options for cfg
+func mountVFSOptions(cfg MountConfig) (*vfscommon.Options, error) {
+	opt := vfscommon.Opt
+	if cfg.CacheMode != "" {
+		if err := opt.CacheMode.Set(cfg.CacheMode); err != nil {
+			return nil, fmt.Errorf("invalid cache mode %q: %w", cfg.CacheMode, err)
+		}
+	}
+	if cfg.CacheMaxSize > 0 {
+		opt.CacheMaxSize = fs.SizeSuffix(cfg.CacheMaxSize)
+	}
+	if cfg.CacheMaxAge > 0 {
+		opt.CacheMaxAge = fs.Duration(cfg.CacheMaxAge)
+	}
+	opt.ReadOnly = cfg.ReadOnly
+	return &opt, nil
+}
+
+// Done returns a channel that reports when the mount ends, with the error
+// that ended it if any
+func (m *Mount) Done() <-chan error {
+	return m.point.ErrChan
+}
+
+// Unmount waits up to timeout for files open for writing to be closed,
+// then unmounts. Uploads still queued in the VFS cache resume the next time
+// the remote is mounted with the cache on.
+func (m *Mount) Unmount(timeout time.Duration) error {
+	m.point.VFS.WaitForWriters(timeout)
+	err := m.point.Unmount()
+	m.point.VFS.Shutdown()
+	if err != nil {
+		return fmt.Errorf("failed to unmount %s: %w", m.point.MountPoint, err)
+	}
+	return nil
+}
+
+// Release frees what is left of a mount that ended without Unmount, e.g.
+// when it was unmounted outside the app
+func (m *Mount) Release() {
+	m.point.VFS.Shutdown()
+}
+
+// Stats returns the state of the mount's VFS cache
+func (m *Mount) Stats() MountStats {
+	stats := m.point.VFS.Stats()
+	out := MountStats{}
+	if disk, ok := stats["diskCache"].(rc.Params); ok {
+		out.UploadsInProgress, _ = disk["uploadsInProgress"].(int)
+		out.UploadsQueued, _ = disk["uploadsQueued"].(int)
+		out.CachedFiles, _ = disk["files"].(int)
+		out.CacheBytes, _ = disk["bytesUsed"].(int64)
+	}
+	return out
+}
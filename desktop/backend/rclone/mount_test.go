@@ -0,0 +1,32 @@
+package rclone
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/vfs/vfscommon"
+)
+
+func TestMountVFSOptions(t *testing.T) {
+	opt, err := mountVFSOptions(MountConfig{CacheMode: "writes", CacheMaxSize: 1 << 30, CacheMaxAge: time.Hour, ReadOnly: true})
+	if err != nil {
+		t.Fatalf("mountVFSOptions: %v", err)
+	}
+	if opt.CacheMode != vfscommon.CacheModeWrites || opt.CacheMaxSize != fs.SizeSuffix(1<<30) ||
+		opt.CacheMaxAge != fs.Duration(time.Hour) || !opt.ReadOnly {
+		t.Errorf("unexpected options: %+v", opt)
+	}
+
+	opt, err = mountVFSOptions(MountConfig{})
+	if err != nil {
+		t.Fatalf("mountVFSOptions: %v", err)
+	}
+	if opt.CacheMode != vfscommon.Opt.CacheMode || opt.CacheMaxSize != vfscommon.Opt.CacheMaxSize {
+		t.Errorf("expected rclone's defaults, got %+v", opt)
+	}
+
+	if _, err := mountVFSOptions(MountConfig{CacheMode: "everything"}); err == nil {
+		t.Error("expected an unknown cache mode to be rejected")
+	}
+}
@@ -0,0 +1,252 @@
+package rclone
+
+import (
+	"context"
+	"crypto/x509"
+	"desktop/backend/models"
+	"desktop/backend/validation"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fshttp"
+)
+
+// rclone config keys that apply network settings to a single remote
+const (
+	OverrideProxyKey        = "override.http_proxy"
+	OverrideCACertKey       = "override.ca_cert"
+	OverrideSkipVerifyKey   = "override.no_check_certificate"
+	OverrideBindKey         = "override.bind_addr"
+	insecureSkipVerifyAlarm = "TLS certificate verification is disabled: connections can be intercepted without notice"
+)
+
+// proxySchemes are the proxy URL schemes Go's HTTP transport supports
+var proxySchemes = map[string]bool{"http": true, "https": true, "socks5": true, "socks5h": true}
+
+// ValidateNetworkSettings checks the proxy URL and CA bundle up front.
+// rclone exits the process on an unreadable CA file, so it must never see one.
+func ValidateNetworkSettings(settings models.NetworkSettings) error {
+	if settings.Proxy != "" {
+		u, err := url.Parse(settings.Proxy)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		if !proxySchemes[u.Scheme] || u.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q: expected http://, https://, socks5:// or socks5h:// with a host", settings.Proxy)
+		}
+	}
+	if err := validation.ValidateBindAddress(settings.BindAddress, settings.IPFamily); err != nil {
+		return err
+	}
+	if settings.DNSServer != "" {
+		if _, err := dnsServerAddress(settings.DNSServer); err != nil {
+			return err
+		}
+	}
+	if settings.CACertFile != "" {
+		data, err := os.ReadFile(settings.CACertFile)
+		if err != nil {
+			return fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(data) {
+			return fmt.Errorf("no PEM certificates found in CA bundle %q", settings.CACertFile)
+		}
+	}
+	return nil
+}
+
+// NetworkWarnings returns the warnings to show for risky settings
+func NetworkWarnings(settings models.NetworkSettings) []string {
+	var warnings []string
+	if settings.InsecureSkipVerify {
+		warnings = append(warnings, insecureSkipVerifyAlarm)
+	}
+	if settings.CACertFile != "" {
+		warnings = append(warnings, "The CA bundle replaces the system certificate roots; it must include every CA the remotes need")
+	}
+	return warnings
+}
+
+// ApplyNetworkSettings applies app-wide network settings to rclone's global
+// config. An interface name is resolved to its address now. Later HTTP
+// clients use the settings; cached filesystems are dropped so their clients
+// are rebuilt. Nothing is changed if the settings are invalid.
+func ApplyNetworkSettings(settings models.NetworkSettings) error {
+	if err := ValidateNetworkSettings(settings); err != nil {
+		return err
+	}
+	bindAddr, err := ResolveBindAddress(settings.BindAddress, settings.IPFamily)
+	if err != nil {
+		return err
+	}
+	resolver, err := dnsResolver(settings.DNSServer)
+	if err != nil {
+		return err
+	}
+
+	ci := fs.GetConfig(context.Background())
+	ci.HTTPProxy = settings.Proxy
+	ci.CaCert = nil
+	if settings.CACertFile != "" {
+		ci.CaCert = []string{settings.CACertFile}
+	}
+	ci.InsecureSkipVerify = settings.InsecureSkipVerify
+	if settings.InsecureSkipVerify {
+		log.Printf("WARNING: %s (all remotes)", insecureSkipVerifyAlarm)
+	}
+	ci.BindAddr = bindAddr
+
+	resetTransport(context.Background(), resolver)
+	ClearFsCache()
+	return nil
+}
+
+// NetworkOverrides returns the rclone config overrides that apply network
+// settings to a single remote. Empty settings map to "" (key removed). An
+// interface name is resolved to its address now, since rclone only takes IPs;
+// the DNS server can't be set per remote.
+func NetworkOverrides(settings models.NetworkSettings) (map[string]string, error) {
+	if settings.DNSServer != "" {
+		return nil, fmt.Errorf("the DNS server can only be set app-wide")
+	}
+	overrides := map[string]string{
+		OverrideProxyKey:      settings.Proxy,
+		OverrideCACertKey:     settings.CACertFile,
+		OverrideSkipVerifyKey: "",
+		OverrideBindKey:       "",
+	}
+	if settings.InsecureSkipVerify {
+		overrides[OverrideSkipVerifyKey] = strconv.FormatBool(true)
+	}
+	bindAddr, err := ResolveBindAddress(settings.BindAddress, settings.IPFamily)
+	if err != nil {
+		return nil, err
+	}
+	if bindAddr != nil {
+		overrides[OverrideBindKey] = bindAddr.String()
+	}
+	return overrides, nil
+}
+
+// NetworkSettingsFromOverrides reads a remote's network settings back from its
+// rclone config overrides
+func NetworkSettingsFromOverrides(get func(key string) (string, bool)) models.NetworkSettings {
+	var settings models.NetworkSettings
+	settings.Proxy, _ = get(OverrideProxyKey)
+	settings.CACertFile, _ = get(OverrideCACertKey)
+	if v, ok := get(OverrideSkipVerifyKey); ok {
+		settings.InsecureSkipVerify, _ = strconv.ParseBool(v)
+	}
+	if v, ok := get(OverrideBindKey); ok {
+		// An unspecified address only selects the IP family
+		if ip := net.ParseIP(v); ip != nil && ip.IsUnspecified() {
+			settings.IPFamily = models.IPFamilyIPv6
+			if ip.To4() != nil {
+				settings.IPFamily = models.IPFamilyIPv4
+			}
+		} else {
+			settings.BindAddress = v
+		}
+	}
+	return settings
+}
+
+// ResolveBindAddress returns the local IP for rclone's --bind. The address
+// may be an IP or an interface name, which resolves to the interface's first
+// address of the requested family. A family with no address binds to that
+// family's unspecified address, which makes rclone dial only that family.
+// Returns nil when neither is set.
+func ResolveBindAddress(address, family string) (net.IP, error) {
+	if err := validation.ValidateBindAddress(address, family); err != nil {
+		return nil, err
+	}
+	if address == "" {
+		switch family {
+		case models.IPFamilyIPv4:
+			return net.IPv4zero, nil
+		case models.IPFamilyIPv6:
+			return net.IPv6unspecified, nil
+		}
+		return nil, nil
+	}
+	if ip := net.ParseIP(address); ip != nil {
+		return ip, nil
+	}
+
+	iface, err := net.InterfaceByName(address)
+	if err != nil {
+		return nil, fmt.Errorf("network interface %q not found: %w", address, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read addresses of interface %q: %w", address, err)
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		isV4 := ipNet.IP.To4() != nil
+		if (family == models.IPFamilyIPv4 && !isV4) || (family == models.IPFamilyIPv6 && isV4) {
+			continue
+		}
+		return ipNet.IP, nil
+	}
+	return nil, fmt.Errorf("network interface %q has no usable address", address)
+}
+
+// dnsServerAddress normalizes a DNS server to host:port, defaulting to port 53
+func dnsServerAddress(server string) (string, error) {
+	if host, port, err := net.SplitHostPort(server); err == nil {
+		if net.ParseIP(host) == nil || port == "" {
+			return "", fmt.Errorf("invalid DNS server %q: expected an IP address with an optional port", server)
+		}
+		return server, nil
+	}
+	if net.ParseIP(strings.Trim(server, "[]")) == nil {
+		return "", fmt.Errorf("invalid DNS server %q: expected an IP address with an optional port", server)
+	}
+	return net.JoinHostPort(strings.Trim(server, "[]"), "53"), nil
+}
+
+// dnsResolver returns a resolver that asks a DNS server, or nil for the
+// system resolver when server is empty
+func dnsResolver(server string) (*net.Resolver, error) {
+	if server == "" {
+		return nil, nil
+	}
+	address, err := dnsServerAddress(server)
+	if err != nil {
+		return nil, err
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, address)
+		},
+	}, nil
+}
+
+// resetTransport makes rclone build its shared HTTP transport again from the
+// global config. With a resolver, the transport dials through a dialer of
+// its own that uses it: the process-wide resolver stays as it is, since
+// lookups in flight read it.
+func resetTransport(ctx context.Context, resolver *net.Resolver) *fshttp.Transport {
+	fshttp.ResetTransport()
+	t := fshttp.NewTransport(ctx)
+	if resolver != nil {
+		t.DialContext = func(reqCtx context.Context, network, addr string) (net.Conn, error) {
+			dialer := fshttp.NewDialer(ctx)
+			dialer.Resolver = resolver
+			return dialer.DialContext(reqCtx, network, addr)
+		}
+	}
+	return t
+}
@@ -0,0 +1,71 @@
+package rclone
+
+import (
+	"fmt"
+	"strings"
+
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fspath"
+)
+
+// objectOptionsRemote returns path with the profile's storage class and
+// server-side encryption set as connection string options, so the objects
+// written there are created with them. Only options the remote's backend
+// has are set: an s3 remote gets them all, a gcs remote only its storage
+// class, and local paths none. The path is returned unchanged when the
+// profile sets none of them or its remote can't be looked up.
+func objectOptionsRemote(path string, profile models.Profile) string {
+	options := [][2]string{
+		{"storage_class", profile.StorageClass},
+		{"server_side_encryption", profile.ServerSideEncryption},
+		{"sse_kms_key_id", profile.SSEKMSKeyId},
+	}
+	fsInfo, _, _, _, err := fs.ParseRemote(path)
+	if err != nil {
+		return path
+	}
+	parsed, err := fspath.Parse(path)
+	if err != nil || parsed.Name == "" {
+		return path
+	}
+
+	config := strings.TrimSuffix(parsed.ConfigString, ":")
+	changed := false
+	for _, opt := range options {
+		if opt[1] == "" || !hasBackendOption(fsInfo, opt[0]) {
+			continue
+		}
+		config += "," + opt[0] + `="` + strings.ReplaceAll(opt[1], `"`, `""`) + `"`
+		changed = true
+	}
+	if !changed {
+		return path
+	}
+	return config + ":" + parsed.Path
+}
+
+// hasBackendOption reports whether a backend has an option of that name
+func hasBackendOption(fsInfo *fs.RegInfo, name string) bool {
+	for _, o := range fsInfo.Options {
+		if o.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseUploadHeaders parses "Name: value" headers to set on uploaded files
+func parseUploadHeaders(headers []string) ([]*fs.HTTPOption, error) {
+	var opts []*fs.HTTPOption
+	for _, h := range headers {
+		key, value, ok := strings.Cut(h, ":")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid upload header %q, expected \"Name: value\"", h)
+		}
+		opts = append(opts, &fs.HTTPOption{Key: key, Value: value})
+	}
+	return opts, nil
+}
@@ -0,0 +1,56 @@
+package rclone
+
+import (
+	"context"
+	"testing"
+
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/fspath"
+)
+
+func init() {
+	fs.Register(&fs.RegInfo{
+		Name: "objopts",
+		NewFs: func(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
+			return nil, fs.ErrorNotImplemented
+		},
+		Options: []fs.Option{{Name: "storage_class"}, {Name: "server_side_encryption"}, {Name: "sse_kms_key_id"}},
+	})
+}
+
+func TestObjectOptionsRemote(t *testing.T) {
+	profile := models.Profile{StorageClass: "GLACIER_IR", ServerSideEncryption: "aws:kms", SSEKMSKeyId: `key"1`}
+
+	got := objectOptionsRemote(":objopts:bucket/archive", profile)
+	want := `:objopts,storage_class="GLACIER_IR",server_side_encryption="aws:kms",sse_kms_key_id="key""1":bucket/archive`
+	if got != want {
+		t.Fatalf("objectOptionsRemote = %s, want %s", got, want)
+	}
+	parsed, err := fspath.Parse(got)
+	if err != nil || parsed.Path != "bucket/archive" || parsed.Config["server_side_encryption"] != "aws:kms" || parsed.Config["sse_kms_key_id"] != `key"1` {
+		t.Errorf("unexpected parse of %s: %+v, %v", got, parsed, err)
+	}
+
+	// Backends without the options, local paths and profiles without options are left alone
+	for _, path := range []string{":local:/mnt/backup", "/mnt/backup"} {
+		if got := objectOptionsRemote(path, profile); got != path {
+			t.Errorf("objectOptionsRemote(%s) = %s, want it unchanged", path, got)
+		}
+	}
+	if got := objectOptionsRemote(":objopts:bucket", models.Profile{}); got != ":objopts:bucket" {
+		t.Errorf("objectOptionsRemote without options = %s", got)
+	}
+}
+
+func TestParseUploadHeaders(t *testing.T) {
+	headers, err := parseUploadHeaders([]string{"Cache-Control: max-age=86400", "X-Empty:"})
+	if err != nil || len(headers) != 2 || headers[0].Key != "Cache-Control" || headers[0].Value != "max-age=86400" || headers[1].Value != "" {
+		t.Fatalf("unexpected headers %v, %v", headers, err)
+	}
+	if _, err := parseUploadHeaders([]string{"no separator"}); err == nil {
+		t.Error("expected an error for a header without a value")
+	}
+}
@@ -1,9 +1,13 @@
 package rclone
 
 import (
+	"bytes"
 	"context"
 	"desktop/backend/dto"
+	"errors"
 	"fmt"
+	"io"
+	"time"
 
 	beConfig "desktop/backend/config"
 	"desktop/backend/models"
@@ -11,6 +15,7 @@ import (
 
 	"github.com/rclone/rclone/fs"
 	"github.com/rclone/rclone/fs/filter"
+	"github.com/rclone/rclone/fs/fspath"
 	"github.com/rclone/rclone/fs/operations"
 	fssync "github.com/rclone/rclone/fs/sync"
 )
@@ -26,7 +31,7 @@ func Copy(ctx context.Context, config beConfig.Config, profile models.Profile, o
 		return err
 	}
 
-	dstFs, err := fs.NewFs(ctx, profile.To)
+	dstFs, err := fs.NewFs(ctx, objectOptionsRemote(profile.To, profile))
 	if utils.HandleError(err, "Failed to initialize destination filesystem", nil, nil) != nil {
 		return err
 	}
@@ -58,7 +63,7 @@ func Move(ctx context.Context, config beConfig.Config, profile models.Profile, o
 		return err
 	}
 
-	dstFs, err := fs.NewFs(ctx, profile.To)
+	dstFs, err := fs.NewFs(ctx, objectOptionsRemote(profile.To, profile))
 	if utils.HandleError(err, "Failed to initialize destination filesystem", nil, nil) != nil {
 		return err
 	}
@@ -206,6 +211,36 @@ func About(ctx context.Context, remoteName string) (*models.QuotaInfo, error) {
 	return qi, nil
 }
 
+// Reachable checks that a remote can be reached by listing its root.
+func Reachable(ctx context.Context, remoteName string) error {
+	remoteFs, err := fs.NewFs(ctx, remoteName+":")
+	if err != nil {
+		return fmt.Errorf("failed to initialize filesystem %q: %w", remoteName, err)
+	}
+	if _, err := remoteFs.List(ctx, ""); err != nil && !errors.Is(err, fs.ErrorDirNotFound) {
+		return fmt.Errorf("failed to list %q: %w", remoteName, err)
+	}
+	return nil
+}
+
+// PathExists reports whether a file or directory exists at the given remote path.
+func PathExists(ctx context.Context, remotePath string) (bool, error) {
+	remoteFs, err := fs.NewFs(ctx, remotePath)
+	if errors.Is(err, fs.ErrorIsFile) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to initialize filesystem %q: %w", remotePath, err)
+	}
+	if _, err := remoteFs.List(ctx, ""); err != nil {
+		if errors.Is(err, fs.ErrorDirNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to list %q: %w", remotePath, err)
+	}
+	return true, nil
+}
+
 // GetSize returns the total number of objects and their size at the given remote path.
 func GetSize(ctx context.Context, remotePath string) (int64, int64, error) {
 	remoteFs, err := fs.NewFs(ctx, remotePath)
@@ -221,6 +256,70 @@ func GetSize(ctx context.Context, remotePath string) (int64, int64, error) {
 	return objects, size, nil
 }
 
+// UploadBytes writes data as a single object at the given remote file path
+// (e.g. "gdrive:reports/status.html"), creating parent directories as needed.
+func UploadBytes(ctx context.Context, remotePath string, data []byte) error {
+	dir, name, err := fspath.Split(remotePath)
+	if err != nil {
+		return fmt.Errorf("invalid remote path %q: %w", remotePath, err)
+	}
+	if name == "" {
+		return fmt.Errorf("remote path %q has no file name", remotePath)
+	}
+	if dir == "" {
+		// Bare file name: relative to the local working directory
+		dir = "."
+	}
+
+	remoteFs, err := fs.NewFs(ctx, dir)
+	if err != nil {
+		return fmt.Errorf("failed to initialize filesystem %q: %w", dir, err)
+	}
+
+	if _, err := operations.Rcat(ctx, remoteFs, name, io.NopCloser(bytes.NewReader(data)), time.Now(), nil); err != nil {
+		return fmt.Errorf("failed to upload %s: %w", remotePath, err)
+	}
+	return nil
+}
+
+// DownloadBytes reads the object at the given remote file path (e.g.
+// "gdrive:ng-drive/settings.nsd"), or returns nil if there is none.
+func DownloadBytes(ctx context.Context, remotePath string) ([]byte, error) {
+	dir, name, err := fspath.Split(remotePath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote path %q: %w", remotePath, err)
+	}
+	if name == "" {
+		return nil, fmt.Errorf("remote path %q has no file name", remotePath)
+	}
+	if dir == "" {
+		dir = "."
+	}
+
+	remoteFs, err := fs.NewFs(ctx, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize filesystem %q: %w", dir, err)
+	}
+
+	o, err := remoteFs.NewObject(ctx, name)
+	if errors.Is(err, fs.ErrorObjectNotFound) || errors.Is(err, fs.ErrorDirNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s: %w", remotePath, err)
+	}
+	in, err := o.Open(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", remotePath, err)
+	}
+	defer in.Close()
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", remotePath, err)
+	}
+	return data, nil
+}
+
 // applyFiltersAndBandwidth sets up filter rules and bandwidth from profile.
 // Returns the updated context.
 func applyFiltersAndBandwidth(ctx context.Context, fsConfig *fs.ConfigInfo, profile models.Profile) context.Context {
@@ -0,0 +1,135 @@
+package rclone
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"desktop/backend/models"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// PinProbe is what a remote's server presented when it was probed
+type PinProbe struct {
+	Fingerprint string
+	KeyType     string
+	HostKey     string // the host key in authorized_keys form, for known_hosts
+	Certificate *x509.Certificate
+}
+
+// PinChangedError reports that a remote's server presented a different
+// host key or certificate than the one pinned
+type PinChangedError struct {
+	Remote  string
+	Kind    string
+	Pinned  string
+	Current string
+}
+
+func (e *PinChangedError) Error() string {
+	what := "SSH host key"
+	if e.Kind == models.PinTLSCertificate {
+		what = "TLS certificate"
+	}
+	return fmt.Sprintf("remote %s presents a different %s than the one pinned (pinned %s, now %s): the server was rebuilt or the connection is intercepted; review the change before trusting it",
+		e.Remote, what, e.Pinned, e.Current)
+}
+
+// errHostKeyCaptured stops an SSH handshake once the host key is known
+var errHostKeyCaptured = errors.New("host key captured")
+
+// PinTarget returns what can be pinned of a remote from its config: the
+// host key of an sftp remote's server or the certificate of an https
+// webdav remote's server, and the server's host:port
+func PinTarget(get func(key string) string) (kind, address string, err error) {
+	switch get("type") {
+	case "sftp":
+		host := get("host")
+		if host == "" {
+			return "", "", fmt.Errorf("the remote has no host")
+		}
+		port := get("port")
+		if port == "" {
+			port = "22"
+		}
+		return models.PinSSHHostKey, net.JoinHostPort(host, port), nil
+	case "webdav":
+		u, err := url.Parse(get("url"))
+		if err != nil || u.Host == "" {
+			return "", "", fmt.Errorf("the remote has no valid url")
+		}
+		if u.Scheme != "https" {
+			return "", "", fmt.Errorf("only https servers have a certificate to pin")
+		}
+		port := u.Port()
+		if port == "" {
+			port = "443"
+		}
+		return models.PinTLSCertificate, net.JoinHostPort(u.Hostname(), port), nil
+	}
+	return "", "", fmt.Errorf("only sftp and webdav remotes can be pinned")
+}
+
+// ProbePin connects to a server just long enough to read its host key or
+// certificate. Nothing is verified: the result is compared with a pin.
+func ProbePin(ctx context.Context, kind, address string) (*PinProbe, error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	switch kind {
+	case models.PinSSHHostKey:
+		var key ssh.PublicKey
+		_, _, _, err := ssh.NewClientConn(conn, address, &ssh.ClientConfig{
+			HostKeyCallback: func(hostname string, remote net.Addr, k ssh.PublicKey) error {
+				key = k
+				return errHostKeyCaptured
+			},
+		})
+		if key == nil {
+			return nil, fmt.Errorf("ssh handshake with %s failed: %w", address, err)
+		}
+		return &PinProbe{
+			Fingerprint: ssh.FingerprintSHA256(key),
+			KeyType:     key.Type(),
+			HostKey:     strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))),
+		}, nil
+	case models.PinTLSCertificate:
+		host, _, _ := net.SplitHostPort(address)
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host, InsecureSkipVerify: true})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return nil, fmt.Errorf("tls handshake with %s failed: %w", address, err)
+		}
+		certs := tlsConn.ConnectionState().PeerCertificates
+		if len(certs) == 0 {
+			return nil, fmt.Errorf("%s presented no certificate", address)
+		}
+		return &PinProbe{Fingerprint: CertificateFingerprint(certs[0]), KeyType: certs[0].PublicKeyAlgorithm.String(), Certificate: certs[0]}, nil
+	}
+	return nil, fmt.Errorf("unknown pin kind %q", kind)
+}
+
+// CertificateFingerprint returns the SHA-256 of a certificate in the hex
+// form browsers show, e.g. "AB:CD:..."
+func CertificateFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
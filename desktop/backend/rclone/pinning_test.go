@@ -0,0 +1,88 @@
+package rclone
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"desktop/backend/models"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestPinTarget(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      map[string]string
+		wantKind    string
+		wantAddress string
+		wantErr     bool
+	}{
+		{"sftp", map[string]string{"type": "sftp", "host": "nas.local"}, models.PinSSHHostKey, "nas.local:22", false},
+		{"sftp port", map[string]string{"type": "sftp", "host": "10.0.0.2", "port": "2222"}, models.PinSSHHostKey, "10.0.0.2:2222", false},
+		{"webdav", map[string]string{"type": "webdav", "url": "https://cloud.example.com/remote.php/dav"}, models.PinTLSCertificate, "cloud.example.com:443", false},
+		{"webdav port", map[string]string{"type": "webdav", "url": "https://nas.local:5006"}, models.PinTLSCertificate, "nas.local:5006", false},
+		{"plain http", map[string]string{"type": "webdav", "url": "http://nas.local"}, "", "", true},
+		{"sftp without host", map[string]string{"type": "sftp"}, "", "", true},
+		{"cloud remote", map[string]string{"type": "drive"}, "", "", true},
+	}
+	for _, tt := range tests {
+		kind, address, err := PinTarget(func(key string) string { return tt.config[key] })
+		if (err != nil) != tt.wantErr || kind != tt.wantKind || address != tt.wantAddress {
+			t.Errorf("%s: PinTarget() = %q, %q, %v, want %q, %q", tt.name, kind, address, err, tt.wantKind, tt.wantAddress)
+		}
+	}
+}
+
+func TestProbePin_Certificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	probe, err := ProbePin(context.Background(), models.PinTLSCertificate, strings.TrimPrefix(server.URL, "https://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := CertificateFingerprint(server.Certificate()); probe.Fingerprint != want {
+		t.Errorf("fingerprint = %s, want %s", probe.Fingerprint, want)
+	}
+}
+
+func TestProbePin_HostKey(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{NoClientAuth: true}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		ssh.NewServerConn(conn, config)
+	}()
+
+	probe, err := ProbePin(context.Background(), models.PinSSHHostKey, listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ssh.FingerprintSHA256(signer.PublicKey()); probe.Fingerprint != want || probe.KeyType != ssh.KeyAlgoED25519 {
+		t.Errorf("probe = %+v, want fingerprint %s", probe, want)
+	}
+}
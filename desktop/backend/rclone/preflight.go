@@ -0,0 +1,108 @@
+package rclone
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/operations"
+)
+
+// preflightMarkerPrefix names the marker object written to test that a
+// destination is writable; it is removed right away
+const preflightMarkerPrefix = ".ng-drive-preflight-"
+
+// DestinationMissingError reports that a destination directory doesn't exist
+type DestinationMissingError struct {
+	Path string
+}
+
+func (e *DestinationMissingError) Error() string {
+	return fmt.Sprintf("destination does not exist: %s; create it, then run again", e.Path)
+}
+
+// DestinationNotWritableError reports that a test file couldn't be written
+// to a destination, e.g. a read-only share
+type DestinationNotWritableError struct {
+	Path string
+	Err  error
+}
+
+func (e *DestinationNotWritableError) Error() string {
+	return fmt.Sprintf("destination is not writable: %s; check its permissions, then run again: %v", e.Path, e.Err)
+}
+
+func (e *DestinationNotWritableError) Unwrap() error {
+	return e.Err
+}
+
+// WriteDestinations returns the paths a sync action writes files into: the
+// destinations of a push, the source of a pull, or both sides of a bisync
+func WriteDestinations(action string, profile models.Profile) []string {
+	switch action {
+	case "pull":
+		return []string{profile.From}
+	case "push":
+		return profile.Destinations()
+	case "bi", "bi-resync":
+		return []string{profile.From, profile.To}
+	}
+	return nil
+}
+
+// CheckDestination checks that a destination directory exists and, with
+// write, that a marker object can be written there. Returns a
+// *DestinationMissingError or *DestinationNotWritableError. Destinations
+// that can't be opened or listed aren't reported, the run reports them;
+// directories aren't required on remotes where they only exist through the
+// files in them, like buckets; and backends that don't support uploads
+// aren't write tested.
+func CheckDestination(ctx context.Context, path string, write bool) error {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	f, err := fs.NewFs(ctx, path)
+	if err != nil {
+		// fs.ErrorIsFile: the destination is a single file
+		return nil
+	}
+	features := f.Features()
+	if _, err := f.List(ctx, ""); errors.Is(err, fs.ErrorDirNotFound) {
+		if features.BucketBased || !features.CanHaveEmptyDirectories {
+			return nil
+		}
+		return &DestinationMissingError{Path: path}
+	} else if err != nil {
+		return nil
+	}
+	if !write {
+		return nil
+	}
+
+	suffix := make([]byte, 8)
+	rand.Read(suffix)
+	name := preflightMarkerPrefix + hex.EncodeToString(suffix)
+	data := []byte("ng-drive write test\n")
+	obj, err := operations.Rcat(ctx, f, name, io.NopCloser(bytes.NewReader(data)), time.Now(), nil)
+	if errors.Is(err, fs.ErrorNotImplemented) || errors.Is(err, fs.ErrorCantUploadEmptyFiles) {
+		return nil
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return &DestinationNotWritableError{Path: path, Err: err}
+	}
+	if err := obj.Remove(ctx); err != nil {
+		fs.Logf(f, "Could not remove the write test file %s: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package rclone
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"desktop/backend/models"
+)
+
+func TestCheckDestination(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	if err := CheckDestination(ctx, dir, true); err != nil {
+		t.Errorf("CheckDestination(existing) = %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), preflightMarkerPrefix) {
+			t.Errorf("write test file %s was left behind", e.Name())
+		}
+	}
+
+	var missing *DestinationMissingError
+	if err := CheckDestination(ctx, filepath.Join(dir, "missing"), true); !errors.As(err, &missing) {
+		t.Errorf("CheckDestination(missing) = %v, want *DestinationMissingError", err)
+	}
+
+	if os.Geteuid() == 0 {
+		t.Skip("permissions don't apply to root")
+	}
+	readOnly := filepath.Join(dir, "read-only")
+	if err := os.Mkdir(readOnly, 0o555); err != nil {
+		t.Fatal(err)
+	}
+	var notWritable *DestinationNotWritableError
+	if err := CheckDestination(ctx, readOnly, true); !errors.As(err, &notWritable) {
+		t.Errorf("CheckDestination(read-only) = %v, want *DestinationNotWritableError", err)
+	}
+	if err := CheckDestination(ctx, readOnly, false); err != nil {
+		t.Errorf("CheckDestination(read-only, no write test) = %v", err)
+	}
+}
+
+func TestWriteDestinations(t *testing.T) {
+	profile := models.Profile{From: "/src", To: "gdrive:dst", FanOutTo: []string{"s3:dst"}}
+	if got := WriteDestinations("push", profile); len(got) != 2 || got[0] != "gdrive:dst" || got[1] != "s3:dst" {
+		t.Errorf("WriteDestinations(push) = %v", got)
+	}
+	if got := WriteDestinations("pull", profile); len(got) != 1 || got[0] != "/src" {
+		t.Errorf("WriteDestinations(pull) = %v", got)
+	}
+	if got := WriteDestinations("bi", profile); len(got) != 2 {
+		t.Errorf("WriteDestinations(bi) = %v", got)
+	}
+}
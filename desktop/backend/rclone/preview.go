@@ -0,0 +1,114 @@
+package rclone
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/operations"
+	fssync "github.com/rclone/rclone/fs/sync"
+)
+
+// PreviewSync runs a one-way sync of the profile as a dry run and collects
+// the files it would create, update and delete on the destination from
+// rclone's sync logger. The result counts all of them, but lists only the
+// first limit by path. A pull is previewed from profile.To to profile.From.
+func PreviewSync(ctx context.Context, profile models.Profile, pull bool, limit int) (*models.SyncPreview, error) {
+	ctx, err := SimpleContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = accounting.WithStatsGroup(ctx, fmt.Sprintf("plan-%d", planCounter.Add(1)))
+
+	from, to := profile.From, profile.To
+	direction := "from->to"
+	if pull {
+		from, to = to, from
+		direction = "to->from"
+	}
+	srcFs, err := fs.NewFs(ctx, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize source filesystem: %w", err)
+	}
+	dstFs, err := fs.NewFs(ctx, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize destination filesystem: %w", err)
+	}
+	ctx, err = applySyncOptions(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+	fsConfig := fs.GetConfig(ctx)
+	fsConfig.DryRun = true
+	if err := fsConfig.Reload(ctx); err != nil {
+		return nil, err
+	}
+
+	preview := &models.SyncPreview{}
+	var mu sync.Mutex
+	ctx = operations.WithSyncLogger(ctx, operations.LoggerOpt{
+		LoggerFn: func(ctx context.Context, sigil operations.Sigil, src, dst fs.DirEntry, err error) {
+			change, ok := plannedChange(sigil, src, dst, err)
+			if !ok {
+				return
+			}
+			change.Direction = direction
+			mu.Lock()
+			defer mu.Unlock()
+			switch change.Type {
+			case models.PlannedCreate:
+				preview.Creates++
+				preview.Bytes += change.Size
+			case models.PlannedUpdate:
+				preview.Updates++
+				preview.Bytes += change.Size
+			case models.PlannedDelete:
+				preview.Deletes++
+			}
+			preview.Changes = append(preview.Changes, change)
+		},
+	})
+
+	if err := fssync.Sync(ctx, dstFs, srcFs, false); err != nil {
+		return nil, fmt.Errorf("failed to preview the sync: %w", err)
+	}
+
+	sort.Slice(preview.Changes, func(i, j int) bool { return preview.Changes[i].Path < preview.Changes[j].Path })
+	if preview.Changes == nil {
+		preview.Changes = []models.PlannedChange{}
+	}
+	if len(preview.Changes) > limit {
+		preview.Changes = preview.Changes[:limit]
+		preview.Truncated = true
+	}
+	return preview, nil
+}
+
+// plannedChange turns what rclone's sync logger reports about a file into
+// the change the sync would make. Directories, matching files and errors
+// aren't changes.
+func plannedChange(sigil operations.Sigil, src, dst fs.DirEntry, err error) (models.PlannedChange, bool) {
+	if err != nil {
+		return models.PlannedChange{}, false
+	}
+	switch sigil {
+	case operations.MissingOnDst:
+		if o, ok := src.(fs.ObjectInfo); ok {
+			return models.PlannedChange{Path: o.Remote(), Size: o.Size(), Type: models.PlannedCreate}, true
+		}
+	case operations.Differ:
+		if o, ok := src.(fs.ObjectInfo); ok {
+			return models.PlannedChange{Path: o.Remote(), Size: o.Size(), Type: models.PlannedUpdate}, true
+		}
+	case operations.MissingOnSrc:
+		if o, ok := dst.(fs.ObjectInfo); ok {
+			return models.PlannedChange{Path: o.Remote(), Size: o.Size(), Type: models.PlannedDelete}, true
+		}
+	}
+	return models.PlannedChange{}, false
+}
@@ -0,0 +1,69 @@
+package rclone
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"desktop/backend/models"
+)
+
+func TestPreviewSync(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeTestFiles(t, src, map[string]string{
+		"new.txt":      "new file",
+		"same.txt":     "same",
+		"sub/edit.txt": "edited contents",
+		"skip.log":     "excluded",
+	})
+	writeTestFiles(t, dst, map[string]string{
+		"same.txt":     "same",
+		"sub/edit.txt": "old",
+		"gone.txt":     "deleted at the source",
+	})
+	info, err := os.Stat(filepath.Join(src, "same.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Join(dst, "same.txt"), info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	profile := models.Profile{From: src, To: dst, ExcludedPaths: []string{"*.log"}}
+
+	preview, err := PreviewSync(context.Background(), profile, false, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Creates != 1 || preview.Updates != 1 || preview.Deletes != 1 || preview.Bytes != int64(len("new file")+len("edited contents")) {
+		t.Errorf("preview = %+v", preview)
+	}
+	want := []models.PlannedChange{
+		{Path: "gone.txt", Size: int64(len("deleted at the source")), Type: models.PlannedDelete, Direction: "from->to"},
+		{Path: "new.txt", Size: int64(len("new file")), Type: models.PlannedCreate, Direction: "from->to"},
+		{Path: "sub/edit.txt", Size: int64(len("edited contents")), Type: models.PlannedUpdate, Direction: "from->to"},
+	}
+	if len(preview.Changes) != len(want) {
+		t.Fatalf("changes = %+v", preview.Changes)
+	}
+	for i := range want {
+		if preview.Changes[i] != want[i] {
+			t.Errorf("change %d = %+v, want %+v", i, preview.Changes[i], want[i])
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dst, "new.txt")); !os.IsNotExist(err) {
+		t.Error("expected the preview not to copy anything")
+	}
+	if _, err := os.Stat(filepath.Join(dst, "gone.txt")); err != nil {
+		t.Error("expected the preview not to delete anything")
+	}
+
+	// A pull would bring gone.txt back and delete the others
+	preview, err = PreviewSync(context.Background(), profile, true, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.Creates != 1 || preview.Deletes != 1 || !preview.Truncated || len(preview.Changes) != 1 || preview.Changes[0].Direction != "to->from" {
+		t.Errorf("pull preview = %+v", preview)
+	}
+}
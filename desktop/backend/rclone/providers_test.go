@@ -23,6 +23,7 @@ func TestProviderRegistration(t *testing.T) {
 		{"iCloud Drive", "iclouddrive"},
 		{"Local", "local"},
 		{"Cache", "cache"},
+		{"Simulated", "simulated"},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,256 @@
+package rclone
+
+import (
+	"desktop/backend/models"
+	"fmt"
+	"strings"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/config/configstruct"
+	"github.com/rclone/rclone/fs/filter"
+)
+
+// rawFlagBlocks are the rclone option blocks raw flags are looked up in:
+// the global options and the filter options of a run
+var rawFlagBlocks = []string{"main", "filter"}
+
+// rawFlagModeled maps the rclone options a profile models to the profile
+// field to use instead, so a raw flag can't fight with it
+var rawFlagModeled = map[string]string{
+	"transfers":             "parallel",
+	"checkers":              "parallel",
+	"bwlimit":               "bandwidth",
+	"include":               "included_paths",
+	"exclude":               "excluded_paths",
+	"filter":                "included_paths and excluded_paths",
+	"filter_from":           "filter_from_file",
+	"min_size":              "min_size",
+	"max_size":              "max_size",
+	"min_age":               "min_age",
+	"max_age":               "max_age",
+	"max_depth":             "max_depth",
+	"exclude_if_present":    "exclude_if_present",
+	"delete_excluded":       "delete_excluded",
+	"backup_dir":            "backup_path",
+	"max_delete":            "max_delete",
+	"max_delete_size":       "max_delete_size",
+	"immutable":             "immutable",
+	"dry_run":               "dry_run",
+	"max_transfer":          "max_transfer",
+	"cutoff_mode":           "session_transfer",
+	"suffix":                "suffix",
+	"suffix_keep_extension": "suffix_keep_extension",
+	"multi_thread_streams":  "multi_thread_streams",
+	"buffer_size":           "buffer_size",
+	"retries":               "retries",
+	"retries_sleep":         "retries_sleep",
+	"low_level_retries":     "low_level_retries",
+	"max_duration":          "max_duration",
+	"check_first":           "check_first",
+	"order_by":              "order_by or transfer_order",
+	"tpslimit":              "tps_limit",
+	"contimeout":            "conn_timeout",
+	"timeout":               "io_timeout",
+	"size_only":             "size_only",
+	"update":                "update_mode",
+	"ignore_existing":       "ignore_existing",
+	"bind":                  "bind_address",
+}
+
+// rawFlagManaged are rclone options the app sets itself or that don't
+// belong in a profile, with why they can't be raw flags
+var rawFlagManaged = map[string]string{
+	"interactive":              "the app runs without a terminal",
+	"auto_confirm":             "the app runs without a terminal",
+	"ask_password":             "the app runs without a terminal",
+	"no_console":               "the app runs without a terminal",
+	"color":                    "the app runs without a terminal",
+	"human_readable":           "the app formats sizes itself",
+	"password_command":         "it runs a program",
+	"metadata_mapper":          "it runs a program",
+	"client_pass":              "it holds a secret",
+	"client_cert":              "rclone exits on a bad certificate or key",
+	"client_key":               "rclone exits on a bad certificate or key",
+	"ca_cert":                  "set the CA bundle in the network settings",
+	"no_check_certificate":     "it turns off TLS verification: use the network settings, which warn about it",
+	"use_server_modtime":       "the app sets it",
+	"no_unicode_normalization": "the app sets it",
+	"fs_cache_expire_duration": "the remote cache is shared by all runs",
+	"fs_cache_expire_interval": "the remote cache is shared by all runs",
+	"kv_lock_time":             "it is shared by all runs",
+	"max_buffer_memory":        "it is shared by all runs",
+	"max_connections":          "it is shared by all runs",
+	"error_on_no_transfer":     "the app decides how a run ends",
+}
+
+// rawFlagManagedGroups are the rclone option groups whose options the app
+// manages itself: logging and debugging go to the app's log
+var rawFlagManagedGroups = []string{"Logging", "Debugging"}
+
+// rawFlagDestructive are the rclone options that can lose, overwrite or
+// skip data a run would otherwise keep or check
+var rawFlagDestructive = map[string]bool{
+	"ignore_errors":   true, // deletes on the destination even after errors
+	"no_check_dest":   true, // overwrites without looking at the destination
+	"inplace":         true, // a failed transfer leaves a partial file
+	"ignore_checksum": true,
+	"ignore_size":     true,
+	"fix_case":        true, // renames files on the destination
+	"track_renames":   true, // moves files on the destination
+}
+
+// rawFlagPerformance are the rclone options outside the Performance and
+// Networking groups that change speed or resource use
+var rawFlagPerformance = map[string]bool{
+	"fast_list":                      true,
+	"list_cutoff":                    true,
+	"max_backlog":                    true,
+	"multi_thread_cutoff":            true,
+	"multi_thread_chunk_size":        true,
+	"multi_thread_write_buffer_size": true,
+	"streaming_upload_cutoff":        true,
+	"use_mmap":                       true,
+	"no_traverse":                    true,
+}
+
+// CheckRawFlags checks a profile's raw rclone flags against rclone's flag
+// registry, each "--name" or "--name=value", and labels them with their
+// risk. Unknown and repeated flags, flags the profile models or the app
+// manages, and values rclone can't parse are rejected. A flag without a
+// value must be a boolean one.
+func CheckRawFlags(flags []string) ([]models.RawFlag, error) {
+	checked := make([]models.RawFlag, 0, len(flags))
+	seen := make(map[string]bool, len(flags))
+	for _, flag := range flags {
+		rawFlag, _, err := checkRawFlag(flag)
+		if err != nil {
+			return nil, err
+		}
+		if seen[rawFlag.Name] {
+			return nil, fmt.Errorf("%s is given more than once", flag)
+		}
+		seen[rawFlag.Name] = true
+		checked = append(checked, rawFlag)
+	}
+	return checked, nil
+}
+
+// checkRawFlag checks a raw rclone flag, returning it with the option
+// block it belongs to
+func checkRawFlag(flag string) (models.RawFlag, string, error) {
+	flag = strings.TrimSpace(flag)
+	if !strings.HasPrefix(flag, "--") || len(flag) == 2 {
+		return models.RawFlag{}, "", fmt.Errorf("%q is not a flag: expected --name or --name=value", flag)
+	}
+	name, value, hasValue := strings.Cut(flag[2:], "=")
+	name = strings.ReplaceAll(name, "-", "_")
+
+	block, opt := lookupRawFlag(name)
+	if opt == nil {
+		return models.RawFlag{}, "", fmt.Errorf("unknown rclone flag %s", flag)
+	}
+	if field, ok := rawFlagModeled[name]; ok {
+		return models.RawFlag{}, "", fmt.Errorf("%s is set by the profile: use %s instead", flag, field)
+	}
+	if reason, ok := rawFlagManaged[name]; ok {
+		return models.RawFlag{}, "", fmt.Errorf("%s can't be a raw flag: %s", flag, reason)
+	}
+	for _, group := range rawFlagManagedGroups {
+		if rawFlagInGroup(opt, group) {
+			return models.RawFlag{}, "", fmt.Errorf("%s can't be a raw flag: the app manages %s options", flag, strings.ToLower(group))
+		}
+	}
+	if opt.IsPassword || opt.Sensitive {
+		return models.RawFlag{}, "", fmt.Errorf("%s can't be a raw flag: it holds a secret", flag)
+	}
+
+	if !hasValue {
+		if _, ok := opt.Default.(bool); !ok {
+			return models.RawFlag{}, "", fmt.Errorf("%s needs a value, e.g. --%s=VALUE", flag, strings.ReplaceAll(name, "_", "-"))
+		}
+	} else if _, err := configstruct.StringToInterface(opt.Default, value); err != nil {
+		return models.RawFlag{}, "", fmt.Errorf("invalid value for %s: %w", flag, err)
+	}
+
+	help, _, _ := strings.Cut(opt.Help, "\n")
+	return models.RawFlag{
+		Flag:  flag,
+		Name:  name,
+		Value: value,
+		Risk:  rawFlagRisk(opt),
+		Help:  help,
+	}, block, nil
+}
+
+// lookupRawFlag returns the option block and registry entry of an rclone
+// option, or nil if rclone doesn't have it
+func lookupRawFlag(name string) (string, *fs.Option) {
+	for _, block := range rawFlagBlocks {
+		info, ok := fs.OptionsRegistry[block]
+		if !ok {
+			continue
+		}
+		for i := range info.Options {
+			if info.Options[i].Name == name {
+				return block, &info.Options[i]
+			}
+		}
+	}
+	return "", nil
+}
+
+// rawFlagInGroup reports whether an rclone option belongs to a group
+func rawFlagInGroup(opt *fs.Option, group string) bool {
+	for _, g := range strings.Split(opt.Groups, ",") {
+		if strings.TrimSpace(g) == group {
+			return true
+		}
+	}
+	return false
+}
+
+// rawFlagRisk labels an rclone option with its risk
+func rawFlagRisk(opt *fs.Option) string {
+	switch {
+	case rawFlagDestructive[opt.Name]:
+		return models.RawFlagDestructive
+	case rawFlagPerformance[opt.Name], rawFlagInGroup(opt, "Performance"), rawFlagInGroup(opt, "Networking"):
+		return models.RawFlagPerformance
+	}
+	return models.RawFlagSafe
+}
+
+// applyRawFlags sets a profile's raw rclone flags on the run's config and
+// filter options. They are applied after the profile's own options, which
+// they can't overlap.
+func applyRawFlags(fsConfig *fs.ConfigInfo, filterOpt *filter.Options, flags []string) error {
+	if len(flags) == 0 {
+		return nil
+	}
+	if _, err := CheckRawFlags(flags); err != nil {
+		return err
+	}
+	mainValues := configmap.Simple{}
+	filterValues := configmap.Simple{}
+	for _, flag := range flags {
+		rawFlag, block, _ := checkRawFlag(flag)
+		value := rawFlag.Value
+		if !strings.Contains(rawFlag.Flag, "=") {
+			value = "true"
+		}
+		if block == "filter" {
+			filterValues[rawFlag.Name] = value
+		} else {
+			mainValues[rawFlag.Name] = value
+		}
+		fs.Debugf(nil, "Raw flag %s (%s)", rawFlag.Flag, rawFlag.Risk)
+	}
+	if err := configstruct.Set(filterValues, filterOpt); err != nil {
+		return fmt.Errorf("failed to apply raw flags: %w", err)
+	}
+	if err := configstruct.Set(mainValues, fsConfig); err != nil {
+		return fmt.Errorf("failed to apply raw flags: %w", err)
+	}
+	return nil
+}
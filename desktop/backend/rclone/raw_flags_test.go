@@ -0,0 +1,101 @@
+package rclone
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/filter"
+)
+
+func TestCheckRawFlags(t *testing.T) {
+	checked, err := CheckRawFlags([]string{
+		"--no-update-modtime",
+		"--multi-thread-cutoff=64M",
+		"--ignore-errors",
+		"--ignore-case=true",
+		"--user-agent=backup",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.RawFlag{
+		{Flag: "--no-update-modtime", Name: "no_update_modtime", Risk: models.RawFlagSafe},
+		{Flag: "--multi-thread-cutoff=64M", Name: "multi_thread_cutoff", Value: "64M", Risk: models.RawFlagPerformance},
+		{Flag: "--ignore-errors", Name: "ignore_errors", Risk: models.RawFlagDestructive},
+		{Flag: "--ignore-case=true", Name: "ignore_case", Value: "true", Risk: models.RawFlagSafe},
+		{Flag: "--user-agent=backup", Name: "user_agent", Value: "backup", Risk: models.RawFlagPerformance},
+	}
+	if len(checked) != len(want) {
+		t.Fatalf("checked %d flags, want %d", len(checked), len(want))
+	}
+	for i, f := range checked {
+		if f.Help == "" {
+			t.Errorf("%s has no help", f.Flag)
+		}
+		f.Help = ""
+		if f != want[i] {
+			t.Errorf("flag %d = %+v, want %+v", i, f, want[i])
+		}
+	}
+
+	for _, tc := range []struct {
+		flags []string
+		want  string
+	}{
+		{[]string{"no-update-modtime"}, "is not a flag"},
+		{[]string{"--"}, "is not a flag"},
+		{[]string{"--no-such-flag"}, "unknown rclone flag"},
+		{[]string{"--transfers=8"}, "use parallel instead"},
+		{[]string{"--exclude=*.tmp"}, "use excluded_paths instead"},
+		{[]string{"--password-command=cat"}, "it runs a program"},
+		{[]string{"--ca-cert=/missing.pem"}, "network settings"},
+		{[]string{"--no-check-certificate"}, "network settings"},
+		{[]string{"--client-cert=/tmp/cert.pem"}, "rclone exits"},
+		{[]string{"--client-key=/tmp/key.pem"}, "rclone exits"},
+		{[]string{"--log-level=DEBUG"}, "the app manages logging options"},
+		{[]string{"--dump=headers"}, "the app manages debugging options"},
+		{[]string{"--multi-thread-cutoff"}, "needs a value"},
+		{[]string{"--multi-thread-cutoff=lots"}, "invalid value"},
+		{[]string{"--fast-list=maybe"}, "invalid value"},
+		{[]string{"--fast-list", "--fast-list=false"}, "more than once"},
+	} {
+		if _, err := CheckRawFlags(tc.flags); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("CheckRawFlags(%q) = %v, want %q", tc.flags, err, tc.want)
+		}
+	}
+}
+
+func TestApplyRawFlags(t *testing.T) {
+	ctx, err := SimpleContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, err = ApplyProfileOptions(ctx, models.Profile{
+		Name:     "raw",
+		MaxSize:  "1G",
+		RawFlags: []string{"--no-update-modtime", "--multi-thread-cutoff=64M", "--ignore-case"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fsConfig := fs.GetConfig(ctx)
+	if !fsConfig.NoUpdateModTime || fsConfig.MultiThreadCutoff != 64*fs.Mebi {
+		t.Errorf("config = no_update_modtime %v, multi_thread_cutoff %v", fsConfig.NoUpdateModTime, fsConfig.MultiThreadCutoff)
+	}
+	filterOpt := filter.GetConfig(ctx).Opt
+	if !filterOpt.IgnoreCase || filterOpt.MaxSize != fs.Gibi {
+		t.Errorf("filter = ignore_case %v, max_size %v", filterOpt.IgnoreCase, filterOpt.MaxSize)
+	}
+	if fs.GetConfig(context.Background()).NoUpdateModTime {
+		t.Error("raw flags leaked into the global config")
+	}
+
+	if _, err := ApplyProfileOptions(ctx, models.Profile{Name: "raw", RawFlags: []string{"--transfers=8"}}); err == nil {
+		t.Error("a flag the profile models was applied")
+	}
+}
@@ -0,0 +1,243 @@
+package rclone
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	beConfig "desktop/backend/config"
+	"desktop/backend/dto"
+	"desktop/backend/models"
+	"desktop/backend/utils"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/filter"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/operations"
+	fssync "github.com/rclone/rclone/fs/sync"
+)
+
+// Restore copies profile.From, a backup or a snapshot dir of one, into the
+// local folder profile.To. Nothing in the target is deleted, and files
+// already there are left as they are. When the source has a manifest, the
+// target is then checked against it, by hash when the manifest has them.
+// Files that don't match are returned in the report, not as an error.
+//
+// When files is set, only those files, relative to profile.From, are
+// restored; flatten puts them all at the root of the target instead of
+// their original folders. Selected files the manifest doesn't list are
+// checked against the source instead.
+func Restore(ctx context.Context, config beConfig.Config, profile models.Profile, files []string, flatten bool, outStatus chan *dto.SyncStatusDTO) (*models.RestoreReport, error) {
+	// A copy of the config, so the restore's settings don't leak into the
+	// context it was given
+	ctx, fsConfig := fs.AddConfig(ctx)
+
+	srcFs, err := fs.NewFs(ctx, profile.From)
+	if utils.HandleError(err, "Failed to initialize source filesystem", nil, nil) != nil {
+		return nil, err
+	}
+	dstFs, err := fs.NewFs(ctx, profile.To)
+	if utils.HandleError(err, "Failed to initialize restore target", nil, nil) != nil {
+		return nil, err
+	}
+	if !dstFs.Features().IsLocal {
+		return nil, fmt.Errorf("restore target %s is not a local folder", profile.To)
+	}
+
+	if profile.Parallel > 0 {
+		fsConfig.Transfers = profile.Parallel
+		fsConfig.Checkers = profile.Parallel * 2
+	}
+	fsConfig.IgnoreExisting = true
+
+	manifest, err := readManifest(ctx, srcFs)
+	if err != nil {
+		return nil, err
+	}
+
+	// The manifest describes the backup; it isn't a restored file
+	ctx = applyFiltersAndBandwidth(ctx, fsConfig, profile)
+	filterOpt := CopyFilterOpt(ctx)
+	filterOpt.FilterRule = append(filterOpt.FilterRule, "- /"+ManifestName)
+	restoreFilter, err := filter.NewFilter(&filterOpt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create filter: %w", err)
+	}
+	ctx = filter.ReplaceConfig(ctx, restoreFilter)
+	if err := fsConfig.Reload(ctx); err != nil {
+		return nil, err
+	}
+
+	entries := restoreEntries(files, flatten)
+	err = utils.RunRcloneWithRetryAndStats(ctx, true, false, outStatus, func() error {
+		if entries == nil {
+			return utils.HandleError(fssync.CopyDir(ctx, dstFs, srcFs, false), "Restore failed", nil, nil)
+		}
+		return utils.HandleError(restoreFiles(ctx, dstFs, srcFs, entries), "Restore failed", nil, nil)
+	})
+	report := &models.RestoreReport{
+		ProfileName:   profile.Name,
+		Source:        profile.From,
+		Target:        profile.To,
+		FilesRestored: accounting.Stats(ctx).GetTransfers(),
+		BytesRestored: accounting.Stats(ctx).GetBytes(),
+	}
+	if err != nil {
+		return report, err
+	}
+	if entries == nil && manifest != nil {
+		for _, file := range manifest.Files {
+			if filter.GetConfig(ctx).IncludeRemote(file.Path) {
+				entries = append(entries, restoreEntry{src: file.Path, dst: file.Path})
+			}
+		}
+	}
+	if entries != nil {
+		if err := verifyRestore(ctx, srcFs, dstFs, manifest, entries, report); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// restoreEntry is a file to restore: its path in the source and in the target
+type restoreEntry struct {
+	src, dst string
+}
+
+// restoreEntries returns where each selected file is restored to, or nil
+// when all files are restored
+func restoreEntries(files []string, flatten bool) []restoreEntry {
+	if len(files) == 0 {
+		return nil
+	}
+	entries := make([]restoreEntry, 0, len(files))
+	for _, file := range files {
+		src := path.Clean(strings.TrimPrefix(file, "/"))
+		dst := src
+		if flatten {
+			dst = path.Base(src)
+		}
+		entries = append(entries, restoreEntry{src: src, dst: dst})
+	}
+	return entries
+}
+
+// restoreFiles copies the selected files. Files that aren't in the source
+// are skipped; verification reports them as missing.
+func restoreFiles(ctx context.Context, dstFs, srcFs fs.Fs, entries []restoreEntry) error {
+	for _, e := range entries {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		err := operations.CopyFile(ctx, dstFs, srcFs, e.dst, e.src)
+		if errors.Is(err, fs.ErrorObjectNotFound) {
+			fs.Logf(srcFs, "%s is not in the backup", e.src)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readManifest reads the manifest at the root of a backup, or returns nil
+// if it has none
+func readManifest(ctx context.Context, f fs.Fs) (*models.Manifest, error) {
+	o, err := f.NewObject(ctx, ManifestName)
+	if errors.Is(err, fs.ErrorObjectNotFound) || errors.Is(err, fs.ErrorIsDir) || errors.Is(err, fs.ErrorDirNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find manifest: %w", err)
+	}
+	in, err := o.Open(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	defer in.Close()
+
+	var manifest models.Manifest
+	if err := json.NewDecoder(in).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// verifyRestore checks the restored files, by size and, when there is one
+// both sides have, by hash. Files are checked against the manifest when it
+// lists them, and against the source otherwise.
+func verifyRestore(ctx context.Context, srcFs, dstFs fs.Fs, manifest *models.Manifest, entries []restoreEntry, report *models.RestoreReport) error {
+	listed := map[string]models.ManifestFile{}
+	manifestHt := hash.None
+	if manifest != nil {
+		report.ManifestRunId = manifest.RunId
+		for _, file := range manifest.Files {
+			listed[file.Path] = file
+		}
+		if manifest.HashType != "" {
+			if err := manifestHt.Set(manifest.HashType); err != nil || !dstFs.Hashes().Contains(manifestHt) {
+				manifestHt = hash.None
+			}
+		}
+	}
+	sourceHt := srcFs.Hashes().Overlap(dstFs.Hashes()).GetOne()
+
+	mismatched := pathCollector{}
+	missing := pathCollector{}
+	for _, e := range entries {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		o, err := dstFs.NewObject(ctx, e.dst)
+		if err != nil {
+			missing.add(e.dst)
+			continue
+		}
+		var ok bool
+		if file, inManifest := listed[e.src]; inManifest {
+			ok = o.Size() == file.Size
+			if ok && manifestHt != hash.None && file.Hash != "" {
+				report.HashType = manifestHt.String()
+				sum, err := o.Hash(ctx, manifestHt)
+				ok = err == nil && sum == file.Hash
+			}
+		} else {
+			ok = matchesSource(ctx, srcFs, o, e.src, sourceHt)
+			if sourceHt != hash.None && report.HashType == "" {
+				report.HashType = sourceHt.String()
+			}
+		}
+		if !ok {
+			mismatched.add(e.dst)
+			continue
+		}
+		report.Verified++
+	}
+	report.Mismatched, report.MismatchCount = mismatched.paths, mismatched.count
+	report.Missing, report.MissingCount = missing.paths, missing.count
+	return nil
+}
+
+// matchesSource reports whether a restored file has the size and, if ht is
+// set, the hash of the source file it was restored from
+func matchesSource(ctx context.Context, srcFs fs.Fs, o fs.Object, src string, ht hash.Type) bool {
+	srcObj, err := srcFs.NewObject(ctx, src)
+	if err != nil || srcObj.Size() != o.Size() {
+		return false
+	}
+	if ht == hash.None {
+		return true
+	}
+	srcSum, err := srcObj.Hash(ctx, ht)
+	if err != nil {
+		return false
+	}
+	sum, err := o.Hash(ctx, ht)
+	return err == nil && sum == srcSum
+}
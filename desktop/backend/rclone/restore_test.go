@@ -0,0 +1,97 @@
+package rclone
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	beConfig "desktop/backend/config"
+	"desktop/backend/dto"
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/fs"
+)
+
+func TestRestore(t *testing.T) {
+	backup, target := t.TempDir(), t.TempDir()
+	writeTestFiles(t, backup, map[string]string{"a.txt": "hello", "dir/b.txt": "world!", "dir/c.txt": "intact"})
+	if _, err := WriteManifest(context.Background(), models.Profile{To: backup}, "run-1", nil); err != nil {
+		t.Fatal(err)
+	}
+	// The backup copy of b.txt rots after the manifest was written, and
+	// the target already has its own a.txt
+	writeTestFiles(t, backup, map[string]string{"dir/b.txt": "w0rld!"})
+	writeTestFiles(t, target, map[string]string{"a.txt": "local"})
+
+	outStatus := make(chan *dto.SyncStatusDTO)
+	go func() {
+		for range outStatus {
+		}
+	}()
+	ctx, err := NewTaskContext(context.Background(), 9101)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := Restore(ctx, beConfig.Config{}, models.Profile{Name: "docs", From: backup, To: target}, nil, false, outStatus)
+	close(outStatus)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(target, "a.txt")); string(data) != "local" {
+		t.Errorf("existing a.txt was overwritten with %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(target, ManifestName)); !os.IsNotExist(err) {
+		t.Error("the manifest should not be restored")
+	}
+	if report.ManifestRunId != "run-1" || report.HashType != "md5" || report.FilesRestored != 2 || report.Verified != 1 ||
+		!slices.Equal(report.Mismatched, []string{"a.txt", "dir/b.txt"}) || report.MissingCount != 0 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	remoteCtx, err := NewTaskContext(context.Background(), 9103)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Restore(remoteCtx, beConfig.Config{}, models.Profile{From: backup, To: ":memory:restore"}, nil, false, nil); err == nil {
+		t.Error("expected a remote target to be refused")
+	}
+	if fs.GetConfig(context.Background()).IgnoreExisting {
+		t.Error("the restore's settings leaked into the global config")
+	}
+}
+
+func TestRestoreSelectedFiles(t *testing.T) {
+	backup, target := t.TempDir(), t.TempDir()
+	writeTestFiles(t, backup, map[string]string{"a.txt": "hello", "dir/b.txt": "world!", "dir/sub/c.txt": "intact"})
+	if _, err := WriteManifest(context.Background(), models.Profile{To: backup}, "run-2", nil); err != nil {
+		t.Fatal(err)
+	}
+	// d.txt is newer than the manifest, so it is checked against the backup
+	writeTestFiles(t, backup, map[string]string{"dir/d.txt": "later"})
+
+	ctx, err := NewTaskContext(context.Background(), 9102)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := []string{"/dir/b.txt", "dir/sub/c.txt", "dir/d.txt", "gone.txt"}
+	report, err := Restore(ctx, beConfig.Config{}, models.Profile{From: backup, To: target}, files, true, nil)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+
+	entries, _ := os.ReadDir(target)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if !slices.Equal(names, []string{"b.txt", "c.txt", "d.txt"}) {
+		t.Errorf("expected the selected files at the target's root, got %v", names)
+	}
+	if report.FilesRestored != 3 || report.Verified != 3 || report.MismatchCount != 0 ||
+		!slices.Equal(report.Missing, []string{"gone.txt"}) || report.ManifestRunId != "run-2" {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
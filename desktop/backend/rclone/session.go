@@ -0,0 +1,150 @@
+package rclone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/march"
+	"github.com/rclone/rclone/fs/operations"
+)
+
+// sessionOrderBy returns the --order-by of a session order
+func sessionOrderBy(order string) string {
+	if order == models.SessionOrderNewest {
+		return "modtime,descending"
+	}
+	return "size,ascending"
+}
+
+// SessionLimitReached reports whether a run stopped because it transferred
+// its session's share (see Profile.SessionTransfer)
+func SessionLimitReached(err error) bool {
+	return errors.Is(err, accounting.ErrorMaxTransferLimitReached)
+}
+
+// PlanSessionQueue lists the files a one-way sync of the profile still has
+// to transfer, in the order sessions transfer them. The result counts all
+// of them, but its queue holds only the first limit files. A pull is
+// planned from profile.To to profile.From.
+func PlanSessionQueue(ctx context.Context, profile models.Profile, pull bool, limit int) (*models.TransferSession, error) {
+	queue, err := planTransferQueue(ctx, profile, pull)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan the session queue: %w", err)
+	}
+	order := models.SessionOrderSmallest
+	if profile.SessionOrder == models.SessionOrderNewest {
+		order = models.SessionOrderNewest
+	}
+	sortTransferQueue(queue, order)
+
+	session := &models.TransferSession{Order: profile.SessionOrder, RemainingFiles: int64(len(queue))}
+	for _, file := range queue {
+		session.RemainingBytes += file.Size
+	}
+	if len(queue) > limit {
+		queue = queue[:limit]
+	}
+	session.Queue = queue
+	return session, nil
+}
+
+// planTransferQueue lists the source files a one-way sync of the profile
+// would transfer, in listing order
+func planTransferQueue(ctx context.Context, profile models.Profile, pull bool) ([]models.QueuedFile, error) {
+	ctx, srcFs, dstFs, err := oneWayFs(ctx, profile, pull)
+	if err != nil {
+		return nil, err
+	}
+	planner := &queuePlanner{ctx: ctx}
+	m := &march.March{Ctx: ctx, Fdst: dstFs, Fsrc: srcFs, Callback: planner}
+	if err := m.Run(ctx); err != nil {
+		return nil, err
+	}
+	return planner.files, nil
+}
+
+// oneWayFs opens the source and destination of a one-way sync of the
+// profile, and returns a context with its filters for listing them. A pull
+// reads from profile.To and writes to profile.From.
+func oneWayFs(ctx context.Context, profile models.Profile, pull bool) (context.Context, fs.Fs, fs.Fs, error) {
+	ctx, err := SimpleContext(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	from, to := profile.From, profile.To
+	if pull {
+		from, to = to, from
+	}
+	srcFs, err := fs.NewFs(ctx, from)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to initialize source filesystem: %w", err)
+	}
+	dstFs, err := fs.NewFs(ctx, to)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to initialize destination filesystem: %w", err)
+	}
+	ctx = applyFiltersAndBandwidth(ctx, fs.GetConfig(ctx), profile)
+	if ctx, err = ApplyProfileOptions(ctx, profile); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to apply profile options: %w", err)
+	}
+	return ctx, srcFs, dstFs, nil
+}
+
+// sortTransferQueue sorts files in a session or transfer order: "smallest",
+// "largest" or "newest" first. Any other order keeps the listing order.
+func sortTransferQueue(queue []models.QueuedFile, order string) {
+	switch order {
+	case models.SessionOrderSmallest:
+		sort.SliceStable(queue, func(i, j int) bool { return queue[i].Size < queue[j].Size })
+	case models.TransferOrderLargest:
+		sort.SliceStable(queue, func(i, j int) bool { return queue[i].Size > queue[j].Size })
+	case models.SessionOrderNewest:
+		sort.SliceStable(queue, func(i, j int) bool { return queue[i].ModTime.After(queue[j].ModTime) })
+	}
+}
+
+// queuePlanner collects the source files a sync would transfer
+type queuePlanner struct {
+	ctx   context.Context
+	mu    sync.Mutex
+	files []models.QueuedFile
+}
+
+func (p *queuePlanner) add(o fs.Object) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.files = append(p.files, models.QueuedFile{Path: o.Remote(), Size: o.Size(), ModTime: o.ModTime(p.ctx).UTC()})
+}
+
+// SrcOnly queues files missing from the destination
+func (p *queuePlanner) SrcOnly(src fs.DirEntry) bool {
+	if o, ok := src.(fs.Object); ok {
+		p.add(o)
+		return false
+	}
+	return true
+}
+
+// DstOnly ignores files only in the destination
+func (p *queuePlanner) DstOnly(dst fs.DirEntry) bool {
+	return false
+}
+
+// Match queues files that differ from the destination's
+func (p *queuePlanner) Match(ctx context.Context, dst, src fs.DirEntry) bool {
+	srcObj, ok := src.(fs.Object)
+	if !ok {
+		return true
+	}
+	if dstObj, ok := dst.(fs.Object); !ok || operations.NeedTransfer(ctx, dstObj, srcObj) {
+		p.add(srcObj)
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package rclone
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	beConfig "desktop/backend/config"
+	"desktop/backend/dto"
+	"desktop/backend/models"
+)
+
+func TestSyncSessions(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeTestFiles(t, src, map[string]string{
+		"small.txt":  "12345",
+		"medium.txt": "1234567890",
+		"large.txt":  "12345678901234567890",
+	})
+	profile := models.Profile{Name: "slow", From: src, To: dst, Parallel: 1, SessionTransfer: "16B"}
+
+	ctx, err := NewTaskContext(context.Background(), 9201)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outStatus := make(chan *dto.SyncStatusDTO)
+	go func() {
+		for range outStatus {
+		}
+	}()
+	err = Sync(ctx, beConfig.Config{}, "push", profile, outStatus, nil)
+	close(outStatus)
+	if !SessionLimitReached(err) {
+		t.Fatalf("Sync() = %v, want the session limit to be reached", err)
+	}
+	for name, want := range map[string]bool{"small.txt": true, "medium.txt": true, "large.txt": false} {
+		if _, err := os.Stat(filepath.Join(dst, name)); (err == nil) != want {
+			t.Errorf("%s transferred = %v, want %v", name, err == nil, want)
+		}
+	}
+
+	session, err := PlanSessionQueue(context.Background(), profile, false, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.RemainingFiles != 1 || session.RemainingBytes != 20 || len(session.Queue) != 1 || session.Queue[0].Path != "large.txt" {
+		t.Errorf("PlanSessionQueue() = %+v, want large.txt left", session)
+	}
+}
+
+func TestPlanSessionQueueOrder(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeTestFiles(t, src, map[string]string{"a.txt": "aaaaaaa", "b.txt": "b", "c.txt": "ccc"})
+
+	session, err := PlanSessionQueue(context.Background(), models.Profile{From: src, To: dst}, false, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.RemainingFiles != 3 || session.RemainingBytes != 11 {
+		t.Errorf("remaining = %d files, %d bytes; want 3 files, 11 bytes", session.RemainingFiles, session.RemainingBytes)
+	}
+	if len(session.Queue) != 2 || session.Queue[0].Path != "b.txt" || session.Queue[1].Path != "c.txt" {
+		t.Errorf("queue = %+v, want b.txt then c.txt", session.Queue)
+	}
+}
@@ -0,0 +1,332 @@
+// Package simulated provides the "simulated" rclone backend: an in-memory
+// remote pre-filled with a generated file tree, with optional latency and
+// failure injection. It lets users try profiles and boards without a cloud
+// account, and lets tests drive real syncs end to end.
+package simulated
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/backend/memory"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/configmap"
+	"github.com/rclone/rclone/fs/config/configstruct"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/object"
+)
+
+// generatedModTime is the newest modification time given to generated files
+var generatedModTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// seeded records the stores already filled with their generated tree, so a
+// remote keeps its contents (and any changes synced to it) for the process
+var (
+	seededMu sync.Mutex
+	seeded   = map[string]bool{}
+)
+
+func init() {
+	fs.Register(&fs.RegInfo{
+		Name:        "simulated",
+		Description: "Simulated remote for demos and tests (in memory, nothing leaves this machine)",
+		NewFs:       NewFs,
+		Options: []fs.Option{{
+			Name:    "files",
+			Help:    "Number of files to generate.",
+			Default: 100,
+		}, {
+			Name:    "depth",
+			Help:    "Maximum folder depth of the generated files.",
+			Default: 2,
+		}, {
+			Name:    "file_size",
+			Help:    "Average size of the generated files.",
+			Default: fs.SizeSuffix(4 * 1024),
+		}, {
+			Name:     "seed",
+			Help:     "Seed of the generated tree; the same seed always generates the same files.",
+			Default:  1,
+			Advanced: true,
+		}, {
+			Name:     "latency",
+			Help:     "Delay added to every operation, e.g. 200ms.",
+			Default:  fs.Duration(0),
+			Advanced: true,
+		}, {
+			Name:     "error_rate",
+			Help:     "Fraction of operations that fail with a retryable error, from 0 to 1.",
+			Default:  0.0,
+			Advanced: true,
+		}},
+	})
+}
+
+// Options defines the configuration for this backend
+type Options struct {
+	Files     int           `config:"files"`
+	Depth     int           `config:"depth"`
+	FileSize  fs.SizeSuffix `config:"file_size"`
+	Seed      int64         `config:"seed"`
+	Latency   fs.Duration   `config:"latency"`
+	ErrorRate float64       `config:"error_rate"`
+}
+
+// Fs is a simulated remote, backed by rclone's memory backend
+type Fs struct {
+	fs.Fs                 // memory Fs holding the files
+	name     string       // name of this remote
+	root     string       // the path we are working on
+	opt      Options      // parsed config options
+	features *fs.Features // optional features
+
+	mu  sync.Mutex
+	rnd *rand.Rand // decides which operations fail
+}
+
+// Object is a file on a simulated remote
+type Object struct {
+	fs.Object
+	f *Fs
+}
+
+// NewFs constructs a simulated remote. Each remote name gets its own store,
+// filled with the generated tree the first time the remote is used.
+func NewFs(ctx context.Context, name, root string, m configmap.Mapper) (fs.Fs, error) {
+	opt := new(Options)
+	if err := configstruct.Set(m, opt); err != nil {
+		return nil, err
+	}
+	if opt.Files < 0 || opt.Depth < 0 || opt.FileSize < 0 {
+		return nil, fmt.Errorf("files, depth and file_size must not be negative")
+	}
+	if opt.ErrorRate < 0 || opt.ErrorRate > 1 {
+		return nil, fmt.Errorf("error_rate must be between 0 and 1, got %v", opt.ErrorRate)
+	}
+
+	store := storeName(name)
+	if err := seedStore(ctx, store, *opt); err != nil {
+		return nil, fmt.Errorf("failed to generate files: %w", err)
+	}
+
+	root = strings.Trim(root, "/")
+	inner, err := memory.NewFs(ctx, name, path.Join(store, root), configmap.Simple{})
+	if err != nil && err != fs.ErrorIsFile {
+		return nil, err
+	}
+	f := &Fs{
+		Fs:   inner,
+		name: name,
+		root: root,
+		opt:  *opt,
+		rnd:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	if err == fs.ErrorIsFile {
+		// memory pointed the Fs at the parent of the file
+		if f.root = path.Dir(root); f.root == "." {
+			f.root = ""
+		}
+	}
+	f.features = (&fs.Features{}).Fill(ctx, f)
+	return f, err
+}
+
+// storeName returns the memory bucket a remote's files live in
+func storeName(name string) string {
+	return "simulated-" + strings.NewReplacer(":", "", "/", "_").Replace(name)
+}
+
+// seedStore fills a store with the generated tree, once per process
+func seedStore(ctx context.Context, store string, opt Options) error {
+	seededMu.Lock()
+	defer seededMu.Unlock()
+	if seeded[store] {
+		return nil
+	}
+
+	dst, err := memory.NewFs(ctx, "simulated", store, configmap.Simple{})
+	if err != nil {
+		return err
+	}
+	for _, file := range generateTree(opt) {
+		info := object.NewStaticObjectInfo(file.remote, file.modTime, int64(len(file.data)), true, nil, dst)
+		if _, err := dst.Put(ctx, bytes.NewReader(file.data), info); err != nil {
+			return err
+		}
+	}
+	seeded[store] = true
+	return nil
+}
+
+// generatedFile is a file of the generated tree
+type generatedFile struct {
+	remote  string
+	modTime time.Time
+	data    []byte
+}
+
+// generateTree returns the files a simulated remote starts with. The tree
+// depends only on the options, so the same seed gives the same files.
+func generateTree(opt Options) []generatedFile {
+	rnd := rand.New(rand.NewSource(opt.Seed))
+	extensions := []string{"txt", "jpg", "pdf", "docx", "csv"}
+
+	files := make([]generatedFile, 0, opt.Files)
+	for i := 0; i < opt.Files; i++ {
+		dir := ""
+		for d := rnd.Intn(opt.Depth + 1); d > 0; d-- {
+			dir = path.Join(dir, fmt.Sprintf("folder-%d", rnd.Intn(4)))
+		}
+		name := fmt.Sprintf("file-%04d.%s", i, extensions[rnd.Intn(len(extensions))])
+		data := make([]byte, rnd.Int63n(2*int64(opt.FileSize)+1))
+		rnd.Read(data)
+		files = append(files, generatedFile{
+			remote:  path.Join(dir, name),
+			modTime: generatedModTime.Add(-time.Duration(rnd.Intn(365*24)) * time.Hour),
+			data:    data,
+		})
+	}
+	return files
+}
+
+// simulate waits for the configured latency and randomly fails the
+// operation at the configured error rate
+func (f *Fs) simulate(ctx context.Context, op string) error {
+	if f.opt.Latency > 0 {
+		select {
+		case <-time.After(time.Duration(f.opt.Latency)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if f.opt.ErrorRate > 0 {
+		f.mu.Lock()
+		fail := f.rnd.Float64() < f.opt.ErrorRate
+		f.mu.Unlock()
+		if fail {
+			return fserrors.RetryError(fmt.Errorf("simulated failure: %s", op))
+		}
+	}
+	return nil
+}
+
+// wrap returns a simulated object for an object of the memory Fs
+func (f *Fs) wrap(o fs.Object) fs.Object {
+	if o == nil {
+		return nil
+	}
+	return &Object{Object: o, f: f}
+}
+
+// Name of the remote (as passed into NewFs)
+func (f *Fs) Name() string {
+	return f.name
+}
+
+// Root of the remote (as passed into NewFs)
+func (f *Fs) Root() string {
+	return f.root
+}
+
+// String converts this Fs to a string
+func (f *Fs) String() string {
+	return fmt.Sprintf("Simulated root '%s'", f.root)
+}
+
+// Features returns the optional features of this Fs
+func (f *Fs) Features() *fs.Features {
+	return f.features
+}
+
+// List the objects and directories in dir into entries
+func (f *Fs) List(ctx context.Context, dir string) (fs.DirEntries, error) {
+	if err := f.simulate(ctx, "list "+dir); err != nil {
+		return nil, err
+	}
+	entries, err := f.Fs.List(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	for i, entry := range entries {
+		if o, ok := entry.(fs.Object); ok {
+			entries[i] = f.wrap(o)
+		}
+	}
+	return entries, nil
+}
+
+// NewObject finds the Object at remote
+func (f *Fs) NewObject(ctx context.Context, remote string) (fs.Object, error) {
+	if err := f.simulate(ctx, "stat "+remote); err != nil {
+		return nil, err
+	}
+	o, err := f.Fs.NewObject(ctx, remote)
+	return f.wrap(o), err
+}
+
+// Put uploads an object
+func (f *Fs) Put(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) (fs.Object, error) {
+	if err := f.simulate(ctx, "upload "+src.Remote()); err != nil {
+		return nil, err
+	}
+	o, err := f.Fs.Put(ctx, in, src, options...)
+	return f.wrap(o), err
+}
+
+// Mkdir creates a directory
+func (f *Fs) Mkdir(ctx context.Context, dir string) error {
+	if err := f.simulate(ctx, "mkdir "+dir); err != nil {
+		return err
+	}
+	return f.Fs.Mkdir(ctx, dir)
+}
+
+// Rmdir removes an empty directory
+func (f *Fs) Rmdir(ctx context.Context, dir string) error {
+	if err := f.simulate(ctx, "rmdir "+dir); err != nil {
+		return err
+	}
+	return f.Fs.Rmdir(ctx, dir)
+}
+
+// Fs returns the simulated remote this object is on
+func (o *Object) Fs() fs.Info {
+	return o.f
+}
+
+// Open opens the object for reading
+func (o *Object) Open(ctx context.Context, options ...fs.OpenOption) (io.ReadCloser, error) {
+	if err := o.f.simulate(ctx, "download "+o.Remote()); err != nil {
+		return nil, err
+	}
+	return o.Object.Open(ctx, options...)
+}
+
+// Update replaces the object's contents
+func (o *Object) Update(ctx context.Context, in io.Reader, src fs.ObjectInfo, options ...fs.OpenOption) error {
+	if err := o.f.simulate(ctx, "upload "+o.Remote()); err != nil {
+		return err
+	}
+	return o.Object.Update(ctx, in, src, options...)
+}
+
+// Remove deletes the object
+func (o *Object) Remove(ctx context.Context) error {
+	if err := o.f.simulate(ctx, "delete "+o.Remote()); err != nil {
+		return err
+	}
+	return o.Object.Remove(ctx)
+}
+
+// Check the interfaces are satisfied
+var (
+	_ fs.Fs     = (*Fs)(nil)
+	_ fs.Object = (*Object)(nil)
+)
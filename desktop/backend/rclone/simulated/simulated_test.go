@@ -0,0 +1,107 @@
+package simulated
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/fserrors"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/sync"
+)
+
+// countFiles returns the number of files below f's root
+func countFiles(t *testing.T, ctx context.Context, f fs.Fs) int {
+	t.Helper()
+	count := 0
+	err := operations.ListFn(ctx, f, func(o fs.Object) { count++ })
+	if err != nil {
+		t.Fatalf("listing %v: %v", f, err)
+	}
+	return count
+}
+
+func TestGenerateTreeIsDeterministic(t *testing.T) {
+	opt := Options{Files: 25, Depth: 3, FileSize: 512, Seed: 7}
+	a, b := generateTree(opt), generateTree(opt)
+	if len(a) != 25 {
+		t.Fatalf("generated %d files, want 25", len(a))
+	}
+	for i := range a {
+		if a[i].remote != b[i].remote || string(a[i].data) != string(b[i].data) || !a[i].modTime.Equal(b[i].modTime) {
+			t.Fatalf("file %d differs between runs: %s vs %s", i, a[i].remote, b[i].remote)
+		}
+	}
+
+	opt.Seed = 8
+	if c := generateTree(opt); c[0].remote == a[0].remote && string(c[0].data) == string(a[0].data) {
+		t.Error("a different seed should generate different files")
+	}
+}
+
+func TestSyncBetweenSimulatedRemotes(t *testing.T) {
+	ctx := context.Background()
+	src, err := fs.NewFs(ctx, ":simulated,files=30,depth=2,seed=3:")
+	if err != nil {
+		t.Fatalf("NewFs src: %v", err)
+	}
+	dst, err := fs.NewFs(ctx, ":simulated,files=0:backup")
+	if err != nil {
+		t.Fatalf("NewFs dst: %v", err)
+	}
+	if n := countFiles(t, ctx, src); n != 30 {
+		t.Fatalf("source has %d files, want 30", n)
+	}
+
+	if err := sync.Sync(ctx, dst, src, false); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if n := countFiles(t, ctx, dst); n != 30 {
+		t.Errorf("destination has %d files after sync, want 30", n)
+	}
+	if err := operations.Check(ctx, &operations.CheckOpt{Fdst: dst, Fsrc: src, OneWay: true}); err != nil {
+		t.Errorf("Check after sync: %v", err)
+	}
+
+	// The store outlives the Fs, like a real remote
+	again, err := fs.NewFs(ctx, ":simulated,files=0:backup")
+	if err != nil {
+		t.Fatalf("NewFs again: %v", err)
+	}
+	if n := countFiles(t, ctx, again); n != 30 {
+		t.Errorf("reopened destination has %d files, want 30", n)
+	}
+}
+
+func TestFailureInjection(t *testing.T) {
+	ctx := context.Background()
+	f, err := fs.NewFs(ctx, ":simulated,files=5,error_rate=1:")
+	if err != nil {
+		t.Fatalf("NewFs: %v", err)
+	}
+	_, err = f.List(ctx, "")
+	if err == nil {
+		t.Fatal("List should fail with error_rate=1")
+	}
+	if !fserrors.IsRetryError(err) {
+		t.Errorf("injected failure should be retryable, got %v", err)
+	}
+
+	if _, err := fs.NewFs(ctx, ":simulated,error_rate=2:"); err == nil {
+		t.Error("error_rate above 1 should be rejected")
+	}
+}
+
+func TestLatencyHonorsContext(t *testing.T) {
+	f, err := fs.NewFs(context.Background(), ":simulated,files=1,latency=1h:")
+	if err != nil {
+		t.Fatalf("NewFs: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := f.List(ctx, ""); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("List = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"desktop/backend/dto"
 	"fmt"
 	"log"
+	"path"
+	"sort"
 	"strings"
 	"time"
 
@@ -30,68 +32,28 @@ import (
 	_ "github.com/rclone/rclone/backend/iclouddrive"
 	_ "github.com/rclone/rclone/backend/onedrive"
 	_ "github.com/rclone/rclone/backend/yandex"
+
+	_ "desktop/backend/rclone/simulated"
 )
 
 func Sync(ctx context.Context, config beConfig.Config, task string, profile models.Profile, outStatus chan *dto.SyncStatusDTO, deltaSvc *delta.DeltaService) error {
-	// Initialize the config
-	fsConfig := fs.GetConfig(ctx)
-	if profile.Parallel > 0 {
-		fsConfig.Transfers = profile.Parallel
-		fsConfig.Checkers = profile.Parallel * 2
-	}
-
 	switch task {
 	case "pull":
 		profile.From, profile.To = profile.To, profile.From
 	}
 
-	srcFs, err := fs.NewFs(ctx, profile.From)
+	srcFs, err := newFs(ctx, profile.From)
 	if utils.HandleError(err, "Failed to initialize source filesystem", nil, nil) != nil {
 		return err
 	}
 
-	dstFs, err := fs.NewFs(ctx, profile.To)
+	dstFs, err := newFs(ctx, objectOptionsRemote(profile.To, profile))
 	if utils.HandleError(err, "Failed to initialize destination filesystem", nil, nil) != nil {
 		return err
 	}
 
-	// Set bandwidth limit
-	if profile.Bandwidth > 0 {
-		if err := utils.HandleError(fsConfig.BwLimit.Set(fmt.Sprint(profile.Bandwidth)+"M"), "Failed to set bandwidth limit", nil, nil); err != nil {
-			return err
-		}
-	}
-
-	// Set up filter rules (prefix with {{regexp:}} if UseRegex is enabled)
-	filterOpt := CopyFilterOpt(ctx)
-	for _, p := range profile.IncludedPaths {
-		if profile.UseRegex {
-			filterOpt.IncludeRule = append(filterOpt.IncludeRule, "{{regexp:}}"+p)
-		} else {
-			filterOpt.IncludeRule = append(filterOpt.IncludeRule, p)
-		}
-	}
-	for _, p := range profile.ExcludedPaths {
-		if profile.UseRegex {
-			filterOpt.ExcludeRule = append(filterOpt.ExcludeRule, "{{regexp:}}"+p)
-		} else {
-			filterOpt.ExcludeRule = append(filterOpt.ExcludeRule, p)
-		}
-	}
-	newFilter, err := filter.NewFilter(&filterOpt)
-	if err := utils.HandleError(err, "Invalid filters file", nil, func() {
-		ctx = filter.ReplaceConfig(ctx, newFilter)
-	}); err != nil {
-		return err
-	}
-
-	// Apply advanced profile options (filtering, safety, performance)
-	ctx, err = ApplyProfileOptions(ctx, profile)
+	ctx, err = applySyncOptions(ctx, profile)
 	if err != nil {
-		return fmt.Errorf("failed to apply profile options: %w", err)
-	}
-
-	if err := fsConfig.Reload(ctx); err != nil {
 		return err
 	}
 
@@ -100,15 +62,27 @@ func Sync(ctx context.Context, config beConfig.Config, task string, profile mode
 	dstKey := remoteKey(profile.To)
 	usedDelta := false
 	var drainedChanges []delta.FileChange
+	startedAt := time.Now()
+	run := delta.RunInfo{Mode: delta.RunModeFull}
+	var srcPrint delta.Fingerprint
+	var srcTrees, dstTrees *delta.SubtreeCheck
 
 	if deltaSvc != nil {
 		// Check if both sides report no changes → skip entirely
-		if deltaSvc.ShouldSkipSync(srcKey) && deltaSvc.ShouldSkipSync(dstKey) {
-			log.Printf("[delta] No changes detected on either side, skipping sync")
-			sendSkippedStatus(outStatus)
-			_ = deltaSvc.CommitDelta(srcKey)
-			_ = deltaSvc.CommitDelta(dstKey)
-			return nil
+		srcReason, fp := checkUnchanged(ctx, deltaSvc, srcFs, srcKey, profile.QuickCheck)
+		srcPrint = fp
+		quickReason := quickCheckReason("source", srcReason)
+		if srcReason == "" {
+			dstReason, _ := checkUnchanged(ctx, deltaSvc, dstFs, dstKey, profile.QuickCheck)
+			if dstReason == "" {
+				log.Printf("[delta] No changes detected on either side, skipping sync")
+				sendSkippedStatus(outStatus)
+				_ = deltaSvc.CommitDelta(srcKey)
+				_ = deltaSvc.CommitDelta(dstKey)
+				deltaSvc.RecordRun(srcKey, delta.RunInfo{Mode: delta.RunModeSkipped, Duration: time.Since(startedAt)})
+				return nil
+			}
+			quickReason = quickCheckReason("destination", dstReason)
 		}
 
 		// Try to get changes for filter scoping
@@ -122,9 +96,47 @@ func Sync(ctx context.Context, config beConfig.Config, task string, profile mode
 				log.Printf("[delta] Scoped sync to %d changed files", len(srcChanges.Changes))
 			}
 		}
+
+		if usedDelta {
+			run.Mode = delta.RunModeDelta
+			run.ChangesScoped = len(drainedChanges)
+		} else {
+			run.FallbackReason = quickReason
+			if run.FallbackReason == "" {
+				run.FallbackReason = fallbackReason(deltaSvc, srcKey, dstKey, srcChanges)
+			}
+			log.Printf("[delta] Running full sync: %s", run.FallbackReason)
+
+			// Leave out the subtrees unchanged on both sides, on remotes
+			// whose directory metadata shows changes below each directory.
+			// Excluded files would be deleted with delete_excluded, and
+			// probing a remote writes to it.
+			if !profile.DeleteExcluded && !profile.DryRun {
+				srcTrees = deltaSvc.CheckSubtrees(ctx, srcFs, srcKey)
+				if srcTrees != nil {
+					dstTrees = deltaSvc.CheckSubtrees(ctx, dstFs, dstKey)
+				}
+				if dstTrees != nil {
+					skipped := unchangedSubtrees(srcTrees.Unchanged, dstTrees.Unchanged)
+					if len(skipped) > 0 {
+						ctx = applySubtreeSkips(ctx, skipped)
+						run.SubtreesSkipped = len(skipped)
+						log.Printf("[delta] Skipping %d unchanged subtrees", len(skipped))
+					}
+				}
+			}
+		}
+	}
+
+	// Leave out unresolved conflicts, whatever the other rules include
+	if ctx, err = applySkipFiles(ctx, profile.SkipFiles); err != nil {
+		return err
 	}
 
 	syncErr := utils.RunRcloneWithRetryAndStats(ctx, true, false, outStatus, func() error {
+		if profile.DeferDeletes > 0 {
+			return utils.HandleError(syncDeferringDeletes(ctx, dstFs, srcFs, profile.DeferDeletes), "Sync failed", nil, nil)
+		}
 		return utils.HandleError(fssync.Sync(ctx, dstFs, srcFs, false), "Sync failed", nil, nil)
 	})
 
@@ -139,6 +151,16 @@ func Sync(ctx context.Context, config beConfig.Config, task string, profile mode
 				_ = deltaSvc.CommitFullSync(srcFs, srcKey)
 				_ = deltaSvc.CommitFullSync(dstFs, dstKey)
 			}
+			if profile.QuickCheck {
+				commitFingerprints(ctx, deltaSvc, srcFs, srcKey, srcPrint, dstFs, dstKey)
+			}
+			if dstTrees != nil {
+				// The source's tokens as taken before the sync, the destination's fresh
+				deltaSvc.CommitSubtrees(srcKey, srcTrees)
+				deltaSvc.CommitSubtrees(dstKey, deltaSvc.CheckSubtrees(ctx, dstFs, dstKey))
+			}
+			run.Duration = time.Since(startedAt)
+			deltaSvc.RecordRun(srcKey, run)
 		} else if usedDelta && len(drainedChanges) > 0 {
 			// Scoped delta sync failed — restore drained changes so they're
 			// not lost and will be picked up on the next sync attempt.
@@ -152,6 +174,143 @@ func Sync(ctx context.Context, config beConfig.Config, task string, profile mode
 	return syncErr
 }
 
+// applySyncOptions applies a profile's parallelism, bandwidth, filter rules
+// and advanced options to the task context
+func applySyncOptions(ctx context.Context, profile models.Profile) (context.Context, error) {
+	// Initialize the config
+	fsConfig := fs.GetConfig(ctx)
+	if profile.Parallel > 0 {
+		fsConfig.Transfers = profile.Parallel
+		fsConfig.Checkers = profile.Parallel * 2
+	}
+
+	// Set bandwidth limit
+	if profile.Bandwidth > 0 {
+		if err := utils.HandleError(fsConfig.BwLimit.Set(fmt.Sprint(profile.Bandwidth)+"M"), "Failed to set bandwidth limit", nil, nil); err != nil {
+			return ctx, err
+		}
+	}
+
+	// Set up filter rules (prefix with {{regexp:}} if UseRegex is enabled)
+	filterOpt := CopyFilterOpt(ctx)
+	for _, p := range profile.IncludedPaths {
+		if profile.UseRegex {
+			filterOpt.IncludeRule = append(filterOpt.IncludeRule, "{{regexp:}}"+p)
+		} else {
+			filterOpt.IncludeRule = append(filterOpt.IncludeRule, p)
+		}
+	}
+	for _, p := range profile.ExcludedPaths {
+		if profile.UseRegex {
+			filterOpt.ExcludeRule = append(filterOpt.ExcludeRule, "{{regexp:}}"+p)
+		} else {
+			filterOpt.ExcludeRule = append(filterOpt.ExcludeRule, p)
+		}
+	}
+	newFilter, err := filter.NewFilter(&filterOpt)
+	if err := utils.HandleError(err, "Invalid filters file", nil, func() {
+		ctx = filter.ReplaceConfig(ctx, newFilter)
+	}); err != nil {
+		return ctx, err
+	}
+
+	// Apply advanced profile options (filtering, safety, performance)
+	ctx, err = ApplyProfileOptions(ctx, profile)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to apply profile options: %w", err)
+	}
+
+	if err := fsConfig.Reload(ctx); err != nil {
+		return ctx, err
+	}
+	return ctx, nil
+}
+
+// checkUnchanged returns why a side of the sync needs syncing, or "" if it is
+// known to be unchanged. Remotes with ChangeNotify ask their watcher; others
+// use the listing fingerprint quick check when the profile enables it, and
+// return the fresh fingerprint.
+func checkUnchanged(ctx context.Context, deltaSvc *delta.DeltaService, f fs.Fs, key string, quickCheck bool) (string, delta.Fingerprint) {
+	if quickCheck && !delta.SupportsWatching(f) {
+		fp, reason := deltaSvc.QuickCheck(ctx, f, key)
+		return reason, fp
+	}
+	if deltaSvc.ShouldSkipSync(key) {
+		return "", nil
+	}
+	if reason := deltaSvc.FullSyncReason(key); reason != "" {
+		return reason, nil
+	}
+	return "changes detected", nil
+}
+
+// quickCheckReason labels a quick check result with the side it came from.
+// Watcher results are explained by fallbackReason instead.
+func quickCheckReason(side, reason string) string {
+	switch reason {
+	case delta.ReasonQuickCheckFailed, delta.ReasonNoFingerprint, delta.ReasonFingerprintChanged:
+		return side + ": " + reason
+	}
+	return ""
+}
+
+// commitFingerprints stores the listing fingerprints of quick-checked sides
+// after a successful sync: the source's as taken before the sync (so changes
+// made during the sync are picked up next time), the destination's fresh.
+func commitFingerprints(ctx context.Context, deltaSvc *delta.DeltaService, srcFs fs.Fs, srcKey string, srcPrint delta.Fingerprint, dstFs fs.Fs, dstKey string) {
+	if !delta.SupportsWatching(srcFs) {
+		deltaSvc.CommitFingerprint(srcKey, srcPrint)
+	}
+	if !delta.SupportsWatching(dstFs) {
+		if fp, err := delta.ComputeFingerprint(ctx, dstFs); err == nil {
+			deltaSvc.CommitFingerprint(dstKey, fp)
+		} else {
+			log.Printf("[delta] %s: failed to fingerprint after sync: %v", dstKey, err)
+		}
+	}
+}
+
+// fallbackReason explains why a sync couldn't be skipped or scoped by delta state
+func fallbackReason(deltaSvc *delta.DeltaService, srcKey, dstKey string, srcChanges *delta.ChangeSet) string {
+	if reason := deltaSvc.FullSyncReason(srcKey); reason != "" {
+		return "source: " + reason
+	}
+	switch {
+	case srcChanges == nil:
+		return "source changes unavailable"
+	case !srcChanges.HasChanges:
+		// Source is quiet, so the destination must have changed (or can't be trusted)
+		if reason := untrustedReason(deltaSvc, srcKey, dstKey); reason != "" {
+			return reason
+		}
+		return "destination changed"
+	case len(srcChanges.Changes) >= delta.MaxChangesBeforeFallback:
+		return fmt.Sprintf("%d changes exceed the delta limit of %d", len(srcChanges.Changes), delta.MaxChangesBeforeFallback)
+	default:
+		return "changes include the remote root"
+	}
+}
+
+// untrustedReason returns why either side's delta state can't be used, or ""
+func untrustedReason(deltaSvc *delta.DeltaService, srcKey, dstKey string) string {
+	if reason := deltaSvc.FullSyncReason(srcKey); reason != "" {
+		return "source: " + reason
+	}
+	if reason := deltaSvc.FullSyncReason(dstKey); reason != "" {
+		return "destination: " + reason
+	}
+	return ""
+}
+
+// SourceRemoteKey returns the delta key of the side a sync action reads from,
+// which is where its delta run info is recorded
+func SourceRemoteKey(action string, profile models.Profile) string {
+	if action == "pull" {
+		return remoteKey(profile.To)
+	}
+	return remoteKey(profile.From)
+}
+
 // remoteKey extracts a stable key from a remote path.
 // "gdrive:/data" → "gdrive:/data", "/local/path" → "local:/local/path"
 func remoteKey(path string) string {
@@ -162,33 +321,140 @@ func remoteKey(path string) string {
 }
 
 // applyScopeFilter injects include rules for changed paths and excludes everything else.
+// Returns ctx unchanged if the changes can't be expressed as a scope (e.g. the root changed).
 func applyScopeFilter(ctx context.Context, changes []delta.FileChange) context.Context {
+	rules := scopeFilterRules(changes)
+	if rules == nil {
+		return ctx
+	}
+
 	filterOpt := CopyFilterOpt(ctx)
+	filterOpt.IncludeRule = append(filterOpt.IncludeRule, rules...)
+
+	// Exclude everything not in the changeset
+	filterOpt.ExcludeRule = append(filterOpt.ExcludeRule, "**")
 
+	newFilter, err := filter.NewFilter(&filterOpt)
+	if err != nil {
+		log.Printf("[delta] Failed to build scope filter, using full sync: %v", err)
+		return ctx
+	}
+	return filter.ReplaceConfig(ctx, newFilter)
+}
+
+// scopeFilterRules builds the include rules that limit a sync to the changed
+// paths. For each change it emits the full chain of parent directories
+// ("/a/", "/a/b/") so rclone traverses down to deep changes, then the path
+// itself, plus everything below it for directories. Path segments are
+// escaped so names containing glob metacharacters match literally.
+// Returns nil if a change covers the whole remote.
+func scopeFilterRules(changes []delta.FileChange) []string {
+	var rules []string
 	seen := make(map[string]bool)
+	add := func(rule string) {
+		if !seen[rule] {
+			seen[rule] = true
+			rules = append(rules, rule)
+		}
+	}
+
 	for _, c := range changes {
-		if seen[c.Path] {
-			continue
+		// A rename needs both ends in scope so the old path is removed too
+		paths := []string{c.Path}
+		if c.OldPath != "" {
+			paths = append(paths, c.OldPath)
 		}
-		seen[c.Path] = true
 
-		if c.EntryType == fs.EntryDirectory {
-			filterOpt.IncludeRule = append(filterOpt.IncludeRule, "/"+c.Path+"/**")
+		for _, changed := range paths {
+			p := strings.Trim(path.Clean("/"+changed), "/")
+			if p == "" {
+				// The root itself changed; scoping can't narrow the sync
+				return nil
+			}
+
+			segments := strings.Split(p, "/")
+			for i := range segments {
+				segments[i] = escapeGlob(segments[i])
+			}
+
+			// Parent directory chain
+			prefix := ""
+			for _, seg := range segments[:len(segments)-1] {
+				prefix += "/" + seg
+				add(prefix + "/")
+			}
+
+			full := "/" + strings.Join(segments, "/")
+			if c.EntryType == fs.EntryDirectory {
+				add(full + "/")
+				add(full + "/**")
+			}
+			add(full)
 		}
-		filterOpt.IncludeRule = append(filterOpt.IncludeRule, "/"+c.Path)
 	}
+	return rules
+}
 
-	// Exclude everything not in the changeset
-	filterOpt.ExcludeRule = append(filterOpt.ExcludeRule, "**")
+// unchangedSubtrees returns the topmost directories unchanged on both sides,
+// given the topmost ones unchanged on each. Everything below an unchanged
+// directory is unchanged too.
+func unchangedSubtrees(src, dst []string) []string {
+	srcSet := make(map[string]bool, len(src))
+	for _, dir := range src {
+		srcSet[dir] = true
+	}
+	dstSet := make(map[string]bool, len(dst))
+	for _, dir := range dst {
+		dstSet[dir] = true
+	}
 
+	var both []string
+	for _, dir := range src {
+		if dstSet[dir] || delta.HasAncestor(dstSet, dir) {
+			both = append(both, dir)
+		}
+	}
+	for _, dir := range dst {
+		if !srcSet[dir] && delta.HasAncestor(srcSet, dir) {
+			both = append(both, dir)
+		}
+	}
+	sort.Strings(both)
+	return both
+}
+
+// applySubtreeSkips excludes the given directories, with everything below
+// them, from the sync's listings
+func applySubtreeSkips(ctx context.Context, dirs []string) context.Context {
+	filterOpt := CopyFilterOpt(ctx)
+	for _, dir := range dirs {
+		segments := strings.Split(dir, "/")
+		for i := range segments {
+			segments[i] = escapeGlob(segments[i])
+		}
+		filterOpt.FilterRule = append(filterOpt.FilterRule, "- /"+strings.Join(segments, "/")+"/**")
+	}
 	newFilter, err := filter.NewFilter(&filterOpt)
 	if err != nil {
-		log.Printf("[delta] Failed to build scope filter, using full sync: %v", err)
+		log.Printf("[delta] Failed to build subtree filter, checking everything: %v", err)
 		return ctx
 	}
 	return filter.ReplaceConfig(ctx, newFilter)
 }
 
+// escapeGlob escapes rclone glob metacharacters so a path segment matches literally
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '{', '}', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
 // sendSkippedStatus sends a status indicating the sync was skipped (no changes).
 func sendSkippedStatus(outStatus chan *dto.SyncStatusDTO) {
 	if outStatus == nil {
@@ -0,0 +1,207 @@
+package rclone
+
+import (
+	"context"
+	"desktop/backend/models"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/filter"
+	"github.com/rclone/rclone/fs/march"
+	"github.com/rclone/rclone/fs/operations"
+)
+
+// ErrConflictsUnsupported is returned by DetectConflicts when a side can't
+// tell when its files were modified
+var ErrConflictsUnsupported = errors.New("modification times aren't supported on both sides")
+
+// DetectConflicts lists the files a one-way sync of the profile would
+// overwrite although both copies changed after since: they differ and both
+// were modified after it. Paths in pending, conflicts found before, are
+// returned as settled once both copies match again. A pull is checked from
+// profile.To to profile.From.
+func DetectConflicts(ctx context.Context, profile models.Profile, pull bool, since time.Time, pending map[string]bool) ([]models.SyncConflict, []string, error) {
+	ctx, srcFs, dstFs, err := oneWayFs(ctx, profile, pull)
+	if err != nil {
+		return nil, nil, err
+	}
+	if srcFs.Precision() == fs.ModTimeNotSupported || dstFs.Precision() == fs.ModTimeNotSupported {
+		return nil, nil, ErrConflictsUnsupported
+	}
+
+	detector := &conflictDetector{since: since, pending: pending}
+	m := &march.March{Ctx: ctx, Fdst: dstFs, Fsrc: srcFs, Callback: detector}
+	if err := m.Run(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to check for conflicts: %w", err)
+	}
+	return detector.conflicts, detector.settled, nil
+}
+
+// conflictDetector collects the files changed on both sides of a sync
+type conflictDetector struct {
+	since     time.Time
+	pending   map[string]bool
+	mu        sync.Mutex
+	conflicts []models.SyncConflict
+	settled   []string
+}
+
+// SrcOnly descends into nothing: files on one side can't conflict
+func (d *conflictDetector) SrcOnly(src fs.DirEntry) bool {
+	return false
+}
+
+// DstOnly descends into nothing: files on one side can't conflict
+func (d *conflictDetector) DstOnly(dst fs.DirEntry) bool {
+	return false
+}
+
+// Match records files that differ and were modified on both sides
+func (d *conflictDetector) Match(ctx context.Context, dst, src fs.DirEntry) bool {
+	srcObj, ok := src.(fs.Object)
+	if !ok {
+		return true
+	}
+	dstObj, ok := dst.(fs.Object)
+	if !ok {
+		return false
+	}
+	differ := operations.NeedTransfer(ctx, dstObj, srcObj)
+	srcTime, dstTime := srcObj.ModTime(ctx), dstObj.ModTime(ctx)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch {
+	case !differ:
+		if d.pending[srcObj.Remote()] {
+			d.settled = append(d.settled, srcObj.Remote())
+		}
+	case srcTime.After(d.since) && dstTime.After(d.since):
+		d.conflicts = append(d.conflicts, models.SyncConflict{
+			Path:          srcObj.Remote(),
+			SourceSize:    srcObj.Size(),
+			SourceModTime: srcTime.UTC(),
+			DestSize:      dstObj.Size(),
+			DestModTime:   dstTime.UTC(),
+		})
+	}
+	return false
+}
+
+// ResolveConflict resolves the conflict a one-way sync of the profile found
+// at remote by keeping the source's version, the destination's, or both.
+// Keeping both renames the destination's version like bisyncs of the
+// profile name their conflict copies, and copies it to the source too so
+// later runs keep it.
+func ResolveConflict(ctx context.Context, profile models.Profile, pull bool, remote, resolution string) error {
+	ctx, srcFs, dstFs, err := oneWayFs(ctx, profile, pull)
+	if err != nil {
+		return err
+	}
+
+	switch resolution {
+	case models.ConflictKeepSource:
+		return operations.CopyFile(ctx, dstFs, srcFs, remote, remote)
+	case models.ConflictKeepDest:
+		return operations.CopyFile(ctx, srcFs, dstFs, remote, remote)
+	case models.ConflictKeepBoth:
+		dstObj, err := dstFs.NewObject(ctx, remote)
+		if errors.Is(err, fs.ErrorObjectNotFound) {
+			return operations.CopyFile(ctx, dstFs, srcFs, remote, remote)
+		}
+		if err != nil {
+			return err
+		}
+		copyPath, err := freeConflictCopyPath(ctx, profile, remote, srcFs, dstFs)
+		if err != nil {
+			return err
+		}
+		if err := operations.CopyFile(ctx, srcFs, dstFs, copyPath, remote); err != nil {
+			return fmt.Errorf("failed to copy the destination's version to %s: %w", copyPath, err)
+		}
+		if _, err := operations.Move(ctx, dstFs, nil, copyPath, dstObj); err != nil {
+			return fmt.Errorf("failed to rename the destination's version to %s: %w", copyPath, err)
+		}
+		return operations.CopyFile(ctx, dstFs, srcFs, remote, remote)
+	}
+	return fmt.Errorf("unknown conflict resolution %q", resolution)
+}
+
+// freeConflictCopyPath returns the first conflict copy name of remote that
+// is free on both sides
+func freeConflictCopyPath(ctx context.Context, profile models.Profile, remote string, fss ...fs.Fs) (string, error) {
+	host, now := conflictHostname(), time.Now()
+	for n := 1; n <= 100; n++ {
+		candidate := conflictCopyPath(profile, remote, host, now, n)
+		free := true
+		for _, f := range fss {
+			if _, err := f.NewObject(ctx, candidate); err == nil {
+				free = false
+				break
+			}
+		}
+		if free {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no free name for a conflict copy of %s", remote)
+}
+
+// conflictCopyPath returns the n-th name for a conflict copy of remote,
+// named like the copies bisyncs of profile keep, e.g.
+// "docs/report.docx.laptop-conflict1", in the profile's conflict folder if
+// it has one
+func conflictCopyPath(profile models.Profile, remote, host string, now time.Time, n int) string {
+	profile.ConflictSuffix = conflictSuffixBases(profile)[0]
+	suffix := conflictTimeGlob.ReplaceAllString(conflictSuffixFlag(profile, host, now), now.Format(conflictTimeFormat))
+	dir, name := path.Split(remote)
+	ext := ""
+	if profile.SuffixKeepExtension {
+		ext = path.Ext(name)
+		name = strings.TrimSuffix(name, ext)
+	}
+	return path.Join(conflictFolder(profile), dir, fmt.Sprintf("%s.%s%d%s", name, suffix, n, ext))
+}
+
+// applySkipFiles leaves files out of a sync, ahead of every other filter
+// rule. Include and exclude rules are turned into filter rules following
+// the skips, in the order rclone reads them, since include rules would
+// otherwise come first.
+func applySkipFiles(ctx context.Context, files []string) (context.Context, error) {
+	if len(files) == 0 {
+		return ctx, nil
+	}
+	filterOpt := CopyFilterOpt(ctx)
+	rules := make([]string, 0, len(files)+len(filterOpt.IncludeRule)+len(filterOpt.ExcludeRule)+len(filterOpt.FilterRule))
+	for _, file := range files {
+		segments := strings.Split(strings.Trim(file, "/"), "/")
+		for i := range segments {
+			segments[i] = escapeGlob(segments[i])
+		}
+		rules = append(rules, "- /"+strings.Join(segments, "/"))
+	}
+	for _, rule := range filterOpt.IncludeRule {
+		rules = append(rules, "+ "+rule)
+	}
+	for _, rule := range filterOpt.ExcludeRule {
+		rules = append(rules, "- "+rule)
+	}
+	// Keep rclone's implicit exclusion of everything not included: it
+	// follows the filter file, so leave one include rule that matches nothing
+	if len(filterOpt.IncludeRule) > 0 {
+		filterOpt.IncludeRule = []string{"{{regexp:}}$.^"}
+	}
+	filterOpt.ExcludeRule = nil
+	filterOpt.FilterRule = append(rules, filterOpt.FilterRule...)
+
+	newFilter, err := filter.NewFilter(&filterOpt)
+	if err != nil {
+		return ctx, fmt.Errorf("failed to leave out files: %w", err)
+	}
+	return filter.ReplaceConfig(ctx, newFilter), nil
+}
@@ -0,0 +1,136 @@
+package rclone
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/filter"
+)
+
+func TestConflictCopyPath(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		profile models.Profile
+		n       int
+		want    string
+	}{
+		{"rclone default", models.Profile{}, 1, "docs/report.docx.conflict1"},
+		{"first suffix", models.Profile{ConflictSuffix: "mine,theirs"}, 2, "docs/report.docx.mine2"},
+		{"extension kept", models.Profile{SuffixKeepExtension: true}, 1, "docs/report.conflict1.docx"},
+		{"host, time and folder", models.Profile{ConflictNaming: &models.ConflictNaming{Hostname: true, Timestamp: true, Folder: "_conflicts"}}, 1, "_conflicts/docs/report.docx.laptop-20240501-120000-conflict1"},
+	}
+	for _, tt := range tests {
+		got := conflictCopyPath(tt.profile, "docs/report.docx", "laptop", now, tt.n)
+		if got != tt.want {
+			t.Errorf("%s: conflictCopyPath() = %q, want %q", tt.name, got, tt.want)
+		}
+		// Listed as a conflict copy of the file, like bisync's copies
+		if original, ok := conflictOriginal(conflictCopyPattern(tt.profile), conflictFolder(tt.profile), got); !ok || original != "docs/report.docx" {
+			t.Errorf("%s: %q isn't recognised as a conflict copy", tt.name, got)
+		}
+	}
+}
+
+func TestDetectAndResolveConflicts(t *testing.T) {
+	// Its own config and filter, with the comparison settings it relies on
+	// reset, so global state left by other tests can't change what it sees
+	ctx, err := SimpleContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsConfig := fs.GetConfig(ctx)
+	fsConfig.IgnoreExisting = false
+	fsConfig.IgnoreTimes = false
+	fsConfig.UpdateOlder = false
+	fsConfig.SizeOnly = false
+	fsConfig.CheckSum = false
+	fsConfig.DryRun = false
+	fsConfig.Immutable = false
+	src, dst := t.TempDir(), t.TempDir()
+	writeTestFiles(t, src, map[string]string{"both.txt": "source", "source-only.txt": "new", "same.txt": "same"})
+	writeTestFiles(t, dst, map[string]string{"both.txt": "destination", "source-only.txt": "old", "same.txt": "same"})
+	since := time.Now().Add(-time.Hour)
+	old := since.Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dst, "source-only.txt"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Join(src, "same.txt"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(filepath.Join(dst, "same.txt"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	profile := models.Profile{From: src, To: dst}
+
+	conflicts, settled, err := DetectConflicts(ctx, profile, false, since, map[string]bool{"same.txt": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Path != "both.txt" || conflicts[0].DestSize != int64(len("destination")) {
+		t.Errorf("conflicts = %+v, want both.txt", conflicts)
+	}
+	if len(settled) != 1 || settled[0] != "same.txt" {
+		t.Errorf("settled = %v, want same.txt", settled)
+	}
+
+	if err := ResolveConflict(ctx, profile, false, "both.txt", models.ConflictKeepBoth); err != nil {
+		t.Fatal(err)
+	}
+	for dir, want := range map[string]string{
+		filepath.Join(dst, "both.txt"):           "source",
+		filepath.Join(dst, "both.txt.conflict1"): "destination",
+		filepath.Join(src, "both.txt.conflict1"): "destination",
+	} {
+		if data, err := os.ReadFile(dir); err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v, want %q", dir, data, err, want)
+		}
+	}
+
+	if err := ResolveConflict(ctx, profile, false, "source-only.txt", models.ConflictKeepDest); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(src, "source-only.txt")); string(data) != "old" {
+		t.Errorf("keeping the destination's version left the source at %q", data)
+	}
+	if err := ResolveConflict(ctx, profile, false, "both.txt", "keep_all"); err == nil {
+		t.Error("expected an error for an unknown resolution")
+	}
+}
+
+func TestApplySkipFiles(t *testing.T) {
+	ctx, err := SimpleContext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	filterOpt := CopyFilterOpt(ctx)
+	filterOpt.IncludeRule = []string{"*.txt"}
+	filterOpt.ExcludeRule = []string{"drafts/**"}
+	newFilter, err := filter.NewFilter(&filterOpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx = filter.ReplaceConfig(ctx, newFilter)
+
+	ctx, err = applySkipFiles(ctx, []string{"docs/a[1].txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fi := filter.GetConfig(ctx)
+	for remote, want := range map[string]bool{
+		"docs/a[1].txt": false,
+		"docs/b.txt":    true,
+		"c.doc":         false,
+		"drafts/d.txt":  true, // included before the exclude rule, as rclone reads them
+	} {
+		if got := fi.IncludeRemote(remote); got != want {
+			t.Errorf("IncludeRemote(%q) = %v, want %v", remote, got, want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package rclone
+
+import (
+	"context"
+	"desktop/backend/delta"
+	"desktop/backend/dto"
+	"desktop/backend/models"
+	"reflect"
+	"strings"
+	"testing"
+
+	beConfig "desktop/backend/config"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/filter"
+	"github.com/rclone/rclone/fs/operations"
+)
+
+func TestScopeFilterRules(t *testing.T) {
+	changes := []delta.FileChange{
+		{Path: "a/b/c/file.txt", EntryType: fs.EntryObject},
+		{Path: "a/b/other.txt", EntryType: fs.EntryObject},
+		{Path: "x/newdir", EntryType: fs.EntryDirectory},
+		{Path: "a/b/c/file.txt", EntryType: fs.EntryObject}, // duplicate
+	}
+
+	want := []string{
+		"/a/", "/a/b/", "/a/b/c/", "/a/b/c/file.txt",
+		"/a/b/other.txt",
+		"/x/", "/x/newdir/", "/x/newdir/**", "/x/newdir",
+	}
+	if got := scopeFilterRules(changes); !reflect.DeepEqual(got, want) {
+		t.Errorf("scopeFilterRules() =\n  %v\nwant\n  %v", got, want)
+	}
+
+	// A rename scopes both the new and the old path
+	renamed := []delta.FileChange{{Path: "docs/new.txt", OldPath: "old.txt", EntryType: fs.EntryObject, Type: delta.ChangeRenamed}}
+	if got, want := scopeFilterRules(renamed), []string{"/docs/", "/docs/new.txt", "/old.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("scopeFilterRules(rename) = %v, want %v", got, want)
+	}
+
+	// A change at the root can't be scoped
+	if got := scopeFilterRules([]delta.FileChange{{Path: "/", EntryType: fs.EntryDirectory}}); got != nil {
+		t.Errorf("expected nil rules for a root change, got %v", got)
+	}
+}
+
+func TestUnchangedSubtrees(t *testing.T) {
+	src := []string{"docs", "music/old", "photos/2023"}
+	dst := []string{"docs/sub", "music", "video"}
+
+	// docs is only unchanged below docs/sub on the destination, photos not at all
+	want := []string{"docs/sub", "music/old"}
+	if got := unchangedSubtrees(src, dst); !reflect.DeepEqual(got, want) {
+		t.Errorf("unchangedSubtrees() = %v, want %v", got, want)
+	}
+
+	ctx := applySubtreeSkips(context.Background(), want)
+	includeDir := filter.GetConfig(ctx).IncludeDirectory(ctx, nil)
+	for dir, included := range map[string]bool{"docs": true, "docs/sub": false, "docs/sub/deep": false, "music": true, "music/old": false, "music/new": true} {
+		if got, _ := includeDir(dir); got != included {
+			t.Errorf("IncludeDirectory(%q) = %v, want %v", dir, got, included)
+		}
+	}
+}
+
+// TestScopeFilterSemantics checks the generated rules with rclone's own filter
+// matching, for both file inclusion and directory traversal
+func TestScopeFilterSemantics(t *testing.T) {
+	changes := []delta.FileChange{
+		{Path: "deep/nested/path/to/file.txt", EntryType: fs.EntryObject},
+		{Path: "photos/2024", EntryType: fs.EntryDirectory},
+		{Path: "weird [1]/file*.txt", EntryType: fs.EntryObject},
+	}
+
+	ctx := applyScopeFilter(context.Background(), changes)
+	f := filter.GetConfig(ctx)
+	if f.InActive() {
+		t.Fatal("expected an active scope filter")
+	}
+
+	files := map[string]bool{
+		"deep/nested/path/to/file.txt":  true,
+		"deep/nested/path/to/other.txt": false,
+		"deep/sibling.txt":              false,
+		"photos/2024/jan/img.jpg":       true,
+		"photos/2023/img.jpg":           false,
+		"weird [1]/file*.txt":           true,
+		"weird [1]/fileX.txt":           false, // * must match literally
+		"weird 1/file*.txt":             false, // [1] must match literally
+		"unrelated.txt":                 false,
+	}
+	for remote, want := range files {
+		if got := f.IncludeRemote(remote); got != want {
+			t.Errorf("IncludeRemote(%q) = %v, want %v", remote, got, want)
+		}
+	}
+
+	includeDir := f.IncludeDirectory(ctx, nil)
+	dirs := map[string]bool{
+		"deep":                true,
+		"deep/nested":         true,
+		"deep/nested/path":    true,
+		"deep/nested/path/to": true,
+		"deep/other":          false,
+		"photos":              true,
+		"photos/2024":         true,
+		"photos/2024/jan":     true,
+		"photos/2023":         false,
+		"weird [1]":           true,
+		"unrelated":           false,
+	}
+	for dir, want := range dirs {
+		got, err := includeDir(dir)
+		if err != nil {
+			t.Fatalf("IncludeDirectory(%q) error: %v", dir, err)
+		}
+		if got != want {
+			t.Errorf("IncludeDirectory(%q) = %v, want %v", dir, got, want)
+		}
+	}
+}
+
+// TestSyncWithSimulatedRemotes runs a profile end to end between two
+// simulated remotes, with an exclude rule applied
+func TestSyncWithSimulatedRemotes(t *testing.T) {
+	ctx := context.Background()
+	profile := models.Profile{
+		Name:          "simulated",
+		From:          ":simulated,files=40,seed=11:",
+		To:            ":simulated,files=0:mirror",
+		ExcludedPaths: []string{"*.jpg"},
+	}
+
+	outStatus := make(chan *dto.SyncStatusDTO)
+	go func() {
+		for range outStatus {
+		}
+	}()
+	err := Sync(ctx, beConfig.Config{}, "push", profile, outStatus, nil)
+	close(outStatus)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	src, err := fs.NewFs(ctx, profile.From)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst, err := fs.NewFs(ctx, profile.To)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := 0
+	if err := operations.ListFn(ctx, src, func(o fs.Object) {
+		if !strings.HasSuffix(o.Remote(), ".jpg") {
+			want++
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	got := 0
+	if err := operations.ListFn(ctx, dst, func(o fs.Object) {
+		if strings.HasSuffix(o.Remote(), ".jpg") {
+			t.Errorf("excluded file %s was synced", o.Remote())
+		}
+		got++
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if want == 0 || got != want {
+		t.Errorf("destination has %d files, want %d", got, want)
+	}
+}
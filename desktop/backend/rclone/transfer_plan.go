@@ -0,0 +1,48 @@
+package rclone
+
+import (
+	"context"
+	"fmt"
+
+	"desktop/backend/models"
+)
+
+// transferOrderBy returns the --order-by of a transfer order. rclone orders
+// the files waiting in its transfer queue, so unless the profile has
+// CheckFirst the order is approximate across the whole run.
+func transferOrderBy(order string) string {
+	if order == models.TransferOrderLargest {
+		return "size,descending"
+	}
+	return "size,ascending"
+}
+
+// PlanTransfers lists the files a one-way sync of the profile would
+// transfer, in the order the run starts them: its session order for runs
+// in sessions, else its transfer order. The result counts all of them, but
+// its queue holds only the first limit files. A pull is planned from
+// profile.To to profile.From.
+func PlanTransfers(ctx context.Context, profile models.Profile, pull bool, limit int) (*models.TransferPlan, error) {
+	queue, err := planTransferQueue(ctx, profile, pull)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan the transfers: %w", err)
+	}
+	order := profile.TransferOrder
+	if profile.SessionTransfer != "" {
+		order = models.SessionOrderSmallest
+		if profile.SessionOrder == models.SessionOrderNewest {
+			order = models.SessionOrderNewest
+		}
+	}
+	sortTransferQueue(queue, order)
+
+	plan := &models.TransferPlan{Order: order, Files: int64(len(queue))}
+	for _, file := range queue {
+		plan.Bytes += file.Size
+	}
+	if len(queue) > limit {
+		queue = queue[:limit]
+	}
+	plan.Queue = queue
+	return plan, nil
+}
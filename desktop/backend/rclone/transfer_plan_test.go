@@ -0,0 +1,47 @@
+package rclone
+
+import (
+	"context"
+	"testing"
+
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/fs"
+)
+
+func TestPlanTransfersOrder(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeTestFiles(t, src, map[string]string{"a.txt": "aaaaaaa", "b.txt": "b", "c.txt": "ccc"})
+
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{models.TransferOrderSmallest, []string{"b.txt", "c.txt", "a.txt"}},
+		{models.TransferOrderLargest, []string{"a.txt", "c.txt", "b.txt"}},
+	}
+	for _, tt := range tests {
+		plan, err := PlanTransfers(context.Background(), models.Profile{From: src, To: dst, TransferOrder: tt.order}, false, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if plan.Order != tt.order || plan.Files != 3 || plan.Bytes != 11 || len(plan.Queue) != 3 {
+			t.Fatalf("%s: plan = %+v", tt.order, plan)
+		}
+		for i, path := range tt.want {
+			if plan.Queue[i].Path != path {
+				t.Errorf("%s: queue[%d] = %s, want %s", tt.order, i, plan.Queue[i].Path, path)
+			}
+		}
+	}
+}
+
+func TestTransferOrderBy(t *testing.T) {
+	ctx, ci := fs.AddConfig(context.Background())
+	if _, err := ApplyProfileOptions(ctx, models.Profile{TransferOrder: models.TransferOrderLargest}); err != nil {
+		t.Fatal(err)
+	}
+	if ci.OrderBy != "size,descending" {
+		t.Errorf("order by = %q, want size,descending", ci.OrderBy)
+	}
+}
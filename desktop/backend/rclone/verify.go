@@ -0,0 +1,272 @@
+package rclone
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"desktop/backend/delta"
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/backend/crypt"
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/filter"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/operations"
+	"github.com/rclone/rclone/fs/walk"
+)
+
+// maxVerifyPaths caps how many paths of each kind a verify report lists;
+// the counts include all of them
+const maxVerifyPaths = 1000
+
+// verifyConfidence is the confidence of the problem bound of sampled verifications
+const verifyConfidence = 0.95
+
+// verifyConfidenceZ is the normal quantile matching verifyConfidence
+const verifyConfidenceZ = 1.959964
+
+// pathCollector is an io.Writer collecting the paths rclone's check writes
+// one per line. Paths in sampled are counted separately.
+type pathCollector struct {
+	mu       sync.Mutex
+	paths    []string
+	count    int
+	sampled  map[string]bool
+	inSample int
+}
+
+func (c *pathCollector) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		if line != "" {
+			c.add(line)
+		}
+	}
+	return len(p), nil
+}
+
+// add collects a path
+func (c *pathCollector) add(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.count++
+	if c.sampled[path] {
+		c.inSample++
+	}
+	if len(c.paths) < maxVerifyPaths {
+		c.paths = append(c.paths, path)
+	}
+}
+
+// Verify compares a profile's source and destination without transferring
+// anything, like rclone check. Files are compared by hash when both sides
+// share one, by size otherwise; opts.Download compares their contents
+// instead, which finds silent corruption on remotes without hashes at the
+// cost of reading every file. An encrypted destination is checked like
+// rclone cryptcheck: the hash of each source file, encrypted with the
+// destination's nonce, is compared with the hash the remote stores. With opts.SamplePercent only that share of the source's files is
+// checked, plus the files the delta watchers saw change since recentSince.
+// Differences are returned in the report, not as an error.
+func Verify(ctx context.Context, profile models.Profile, opts models.VerifyOptions, deltaSvc *delta.DeltaService, recentSince time.Time) (*models.VerifyReport, error) {
+	fsConfig := fs.GetConfig(ctx)
+	if profile.Parallel > 0 {
+		fsConfig.Checkers = profile.Parallel
+	}
+
+	srcFs, err := fs.NewFs(ctx, profile.From)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize source filesystem: %w", err)
+	}
+	dstFs, err := fs.NewFs(ctx, profile.To)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize destination filesystem: %w", err)
+	}
+
+	ctx = applyFiltersAndBandwidth(ctx, fsConfig, profile)
+	ctx, err = ApplyProfileOptions(ctx, profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply profile options: %w", err)
+	}
+	if err := fsConfig.Reload(ctx); err != nil {
+		return nil, err
+	}
+
+	report := &models.VerifyReport{
+		ProfileName: profile.Name,
+		Source:      profile.From,
+		Destination: profile.To,
+		Download:    opts.Download,
+	}
+
+	var sampled map[string]bool
+	if opts.SamplePercent > 0 && opts.SamplePercent < 100 {
+		var files []string
+		report.Sample, sampled, files, err = selectVerifySample(ctx, srcFs, opts)
+		if err != nil {
+			return nil, err
+		}
+		if deltaSvc != nil {
+			fi := filter.GetConfig(ctx)
+			recent := make(map[string]bool)
+			for _, key := range []string{remoteKey(profile.From), remoteKey(profile.To)} {
+				for _, p := range deltaSvc.RecentChanges(key, recentSince) {
+					if !sampled[p] && !recent[p] && fi.IncludeRemote(p) {
+						recent[p] = true
+						files = append(files, p)
+					}
+				}
+			}
+			report.Sample.RecentFiles = len(recent)
+		}
+		if len(files) == 0 {
+			report.Sample.MaxProblemPercent = 100 * problemRateBound(0, 0)
+			return report, nil
+		}
+
+		// Only the chosen files are looked up, on both sides
+		filterOpt := CopyFilterOpt(ctx)
+		sampleFilter, err := filter.NewFilter(&filterOpt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build sample filter: %w", err)
+		}
+		for _, p := range files {
+			if err := sampleFilter.AddFile(p); err != nil {
+				return nil, fmt.Errorf("failed to build sample filter: %w", err)
+			}
+		}
+		ctx = filter.ReplaceConfig(ctx, sampleFilter)
+		fsConfig.NoTraverse = true
+	}
+
+	match := pathCollector{}
+	differ := pathCollector{sampled: sampled}
+	missingOnDst := pathCollector{sampled: sampled}
+	missingOnSrc := pathCollector{}
+	errored := pathCollector{sampled: sampled}
+	opt := &operations.CheckOpt{
+		Fsrc:         srcFs,
+		Fdst:         dstFs,
+		Match:        &match,
+		Differ:       &differ,
+		MissingOnDst: &missingOnDst,
+		MissingOnSrc: &missingOnSrc,
+		Error:        &errored,
+	}
+	cryptDst, _ := dstFs.(*crypt.Fs)
+	switch {
+	case opts.Download:
+		err = operations.CheckDownload(ctx, opt)
+	case cryptDst != nil && cryptDst.UnWrap().Hashes().GetOne() != hash.None:
+		report.CryptCheck = true
+		opt.Check = cryptCheckFn(cryptDst, cryptDst.UnWrap().Hashes().GetOne())
+		err = operations.CheckFn(ctx, opt)
+	default:
+		err = operations.Check(ctx, opt)
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	report.Matched = match.count
+	report.Differ, report.DifferCount = differ.paths, differ.count
+	report.MissingOnDst, report.MissingCount = missingOnDst.paths, missingOnDst.count
+	report.MissingOnSrc, report.ExtraCount = missingOnSrc.paths, missingOnSrc.count
+	report.Errors, report.ErrorCount = errored.paths, errored.count
+	if report.Sample != nil {
+		report.Sample.SampleProblems = differ.inSample + missingOnDst.inSample + errored.inSample
+		report.Sample.MaxProblemPercent = 100 * problemRateBound(report.Sample.SampleProblems, report.Sample.SampledFiles)
+	}
+
+	// Check fails whenever it finds differences; only other failures are errors
+	if err != nil && differ.count+missingOnDst.count+missingOnSrc.count+errored.count == 0 {
+		return report, err
+	}
+	return report, nil
+}
+
+// cryptCheckFn returns the check of rclone cryptcheck: whether the hash
+// the remote under fcrypt stores for an encrypted file matches the hash of
+// its source file encrypted the same way. Files without a hash on either
+// side are counted as unchecked by rclone.
+func cryptCheckFn(fcrypt *crypt.Fs, hashType hash.Type) func(ctx context.Context, dst, src fs.Object) (bool, bool, error) {
+	return func(ctx context.Context, dst, src fs.Object) (differ bool, noHash bool, err error) {
+		cryptDst, ok := dst.(*crypt.Object)
+		if !ok {
+			return true, false, fmt.Errorf("%s is not encrypted", dst.Remote())
+		}
+		underlyingHash, err := cryptDst.UnWrap().Hash(ctx, hashType)
+		if err != nil {
+			return true, false, fmt.Errorf("failed to read the hash of %s: %w", dst.Remote(), err)
+		}
+		if underlyingHash == "" {
+			return false, true, nil
+		}
+		cryptHash, err := fcrypt.ComputeHash(ctx, cryptDst, src, hashType)
+		if err != nil {
+			return true, false, fmt.Errorf("failed to compute the hash of %s: %w", src.Remote(), err)
+		}
+		if cryptHash == "" {
+			return false, true, nil
+		}
+		return cryptHash != underlyingHash, false, nil
+	}
+}
+
+// selectVerifySample lists the source and picks the files whose path,
+// hashed with the seed, falls in the sampled share. The same seed picks the
+// same files again while the source is unchanged, and each file is picked
+// independently of the others. Returns the sample's description and the
+// picked files, as a set and as a list.
+func selectVerifySample(ctx context.Context, srcFs fs.Fs, opts models.VerifyOptions) (*models.VerifySample, map[string]bool, []string, error) {
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	sample := &models.VerifySample{Seed: seed, Percent: opts.SamplePercent, Confidence: verifyConfidence}
+	sampled := make(map[string]bool)
+	var files []string
+
+	err := walk.ListR(ctx, srcFs, "", false, -1, walk.ListObjects, func(entries fs.DirEntries) error {
+		for _, entry := range entries {
+			p := entry.Remote()
+			sample.TotalFiles++
+			if inVerifySample(seed, p, opts.SamplePercent) {
+				sampled[p] = true
+				files = append(files, p)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to list source: %w", err)
+	}
+	sample.SampledFiles = len(files)
+	return sample, sampled, files, nil
+}
+
+// inVerifySample reports whether a path is in the sample the seed selects
+func inVerifySample(seed int64, path string, percent float64) bool {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(seed))
+	sum := sha256.Sum256(append(b[:], path...))
+	return float64(binary.BigEndian.Uint64(sum[:])>>11)/(1<<53) < percent/100
+}
+
+// problemRateBound returns the upper bound of the share of files with
+// problems, at verifyConfidence, given the problems found in a random
+// sample (Wilson score interval). It is 1 when nothing was sampled.
+func problemRateBound(problems, sampled int) float64 {
+	if sampled == 0 {
+		return 1
+	}
+	n := float64(sampled)
+	p := float64(problems) / n
+	z2 := verifyConfidenceZ * verifyConfidenceZ
+	bound := (p + z2/(2*n) + verifyConfidenceZ*math.Sqrt(p*(1-p)/n+z2/(4*n*n))) / (1 + z2/n)
+	return math.Min(bound, 1)
+}
@@ -0,0 +1,329 @@
+package rclone
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/hash"
+	"github.com/rclone/rclone/fs/walk"
+)
+
+// verifyDownloadHash is the hash contents are compared with when a
+// verification of several destinations reads them
+var verifyDownloadHash = hash.MD5
+
+// sourceSums are the hashes of a source file, computed once for all
+// destinations
+type sourceSums struct {
+	once sync.Once
+	sums map[hash.Type]string
+	err  error
+}
+
+// sourceHasher hashes each file of a source at most once, with every hash
+// type the destinations are compared with
+type sourceHasher struct {
+	types hash.Set
+	read  bool // hash the contents rather than ask the remote for each type
+
+	mu    sync.Mutex
+	files map[string]*sourceSums
+}
+
+// sums returns the hashes of a source file
+func (h *sourceHasher) sums(ctx context.Context, o fs.Object) (map[hash.Type]string, error) {
+	h.mu.Lock()
+	entry, ok := h.files[o.Remote()]
+	if !ok {
+		entry = &sourceSums{}
+		h.files[o.Remote()] = entry
+	}
+	h.mu.Unlock()
+
+	entry.once.Do(func() {
+		if h.read {
+			entry.sums, entry.err = hashContents(ctx, o, h.types)
+			return
+		}
+		entry.sums = make(map[hash.Type]string)
+		for _, t := range h.types.Array() {
+			sum, err := o.Hash(ctx, t)
+			if err != nil {
+				entry.err = err
+				return
+			}
+			entry.sums[t] = sum
+		}
+	})
+	return entry.sums, entry.err
+}
+
+// hashContents reads a file once and returns its hashes of the given types
+func hashContents(ctx context.Context, o fs.Object, types hash.Set) (map[hash.Type]string, error) {
+	hasher, err := hash.NewMultiHasherTypes(types)
+	if err != nil {
+		return nil, err
+	}
+	in, err := o.Open(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", o.Remote(), err)
+	}
+	defer in.Close()
+	if _, err := io.Copy(hasher, in); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", o.Remote(), err)
+	}
+	return hasher.Sums(), nil
+}
+
+// outOfDateFiles gathers the source files whose copy is out of date in
+// some destination
+type outOfDateFiles struct {
+	mu    sync.Mutex
+	files map[string]*models.VerifyFileCopy
+}
+
+// add records that dest's copy of path differs, is missing or couldn't be read
+func (f *outOfDateFiles) add(path, dest string, list func(*models.VerifyFileCopy) *[]string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, ok := f.files[path]
+	if !ok {
+		file = &models.VerifyFileCopy{Path: path}
+		f.files[path] = file
+	}
+	l := list(file)
+	*l = append(*l, dest)
+}
+
+// list returns the first maxVerifyPaths files by path, and how many there are
+func (f *outOfDateFiles) list() ([]models.VerifyFileCopy, int) {
+	paths := make([]string, 0, len(f.files))
+	for p := range f.files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	if len(paths) > maxVerifyPaths {
+		paths = paths[:maxVerifyPaths]
+	}
+	files := make([]models.VerifyFileCopy, len(paths))
+	for i, p := range paths {
+		files[i] = *f.files[p]
+		sort.Strings(files[i].Differ)
+		sort.Strings(files[i].Missing)
+		sort.Strings(files[i].Errors)
+	}
+	return files, len(f.files)
+}
+
+// VerifyDestinations verifies each of profile.Destinations() against the
+// source in one pass, without transferring anything. The source is listed
+// once and each of its files hashed, or read with opts.Download, at most
+// once, while the destinations are compared concurrently. Files are
+// compared like Verify does. A destination that couldn't be verified has
+// its report's ErrorMessage set; only a source that can't be read is an
+// error. Sampling isn't supported.
+func VerifyDestinations(ctx context.Context, profile models.Profile, opts models.VerifyOptions) (*models.MultiVerifyReport, error) {
+	fsConfig := fs.GetConfig(ctx)
+	if profile.Parallel > 0 {
+		fsConfig.Checkers = profile.Parallel
+	}
+
+	srcFs, err := fs.NewFs(ctx, profile.From)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize source filesystem: %w", err)
+	}
+	destinations := profile.Destinations()
+	dstFss := make([]fs.Fs, len(destinations))
+	dstErrs := make([]error, len(destinations))
+	for i, dest := range destinations {
+		dstFss[i], dstErrs[i] = fs.NewFs(ctx, dest)
+	}
+
+	ctx = applyFiltersAndBandwidth(ctx, fsConfig, profile)
+	ctx, err = ApplyProfileOptions(ctx, profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply profile options: %w", err)
+	}
+	if err := fsConfig.Reload(ctx); err != nil {
+		return nil, err
+	}
+
+	source, err := listVerifyObjects(ctx, srcFs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source: %w", err)
+	}
+
+	// Each destination is compared with the hash it shares with the
+	// source, and the source is hashed with all of them at once
+	hasher := &sourceHasher{files: make(map[string]*sourceSums)}
+	hashTypes := make([]hash.Type, len(destinations))
+	for i, f := range dstFss {
+		if dstErrs[i] != nil {
+			continue
+		}
+		hashTypes[i] = srcFs.Hashes().Overlap(f.Hashes()).GetOne()
+		if opts.Download {
+			hashTypes[i] = verifyDownloadHash
+		}
+		if hashTypes[i] != hash.None {
+			hasher.types.Add(hashTypes[i])
+		}
+	}
+	hasher.read = opts.Download || (srcFs.Features().IsLocal && hasher.types.Count() > 1)
+
+	result := &models.MultiVerifyReport{
+		ProfileName: profile.Name,
+		Source:      profile.From,
+		Download:    opts.Download,
+		SourceFiles: len(source),
+		Reports:     make([]models.VerifyReport, len(destinations)),
+	}
+	stale := &outOfDateFiles{files: make(map[string]*models.VerifyFileCopy)}
+	var wg sync.WaitGroup
+	for i, dest := range destinations {
+		report := &result.Reports[i]
+		*report = models.VerifyReport{ProfileName: profile.Name, Source: profile.From, Destination: dest, Download: opts.Download}
+		if dstErrs[i] != nil {
+			report.ErrorMessage = fmt.Sprintf("failed to initialize destination filesystem: %v", dstErrs[i])
+			continue
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := verifyDestination(ctx, source, dstFss[i], hashTypes[i], opts.Download, hasher, report, stale); err != nil {
+				report.ErrorMessage = err.Error()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	result.Files, result.FileCount = stale.list()
+	return result, nil
+}
+
+// listVerifyObjects lists the files of f, honouring the context's filters
+func listVerifyObjects(ctx context.Context, f fs.Fs) (map[string]fs.Object, error) {
+	objects := make(map[string]fs.Object)
+	err := walk.ListR(ctx, f, "", false, -1, walk.ListObjects, func(entries fs.DirEntries) error {
+		for _, entry := range entries {
+			if o, ok := entry.(fs.Object); ok {
+				objects[o.Remote()] = o
+			}
+		}
+		return nil
+	})
+	return objects, err
+}
+
+// verifyDestination compares the listed source with one destination,
+// filling its report and recording its out of date copies in stale
+func verifyDestination(ctx context.Context, source map[string]fs.Object, dstFs fs.Fs, ht hash.Type, download bool, hasher *sourceHasher, report *models.VerifyReport, stale *outOfDateFiles) error {
+	dest, err := listVerifyObjects(ctx, dstFs)
+	if err != nil {
+		return fmt.Errorf("failed to list destination: %w", err)
+	}
+
+	paths := make([]string, 0, len(source))
+	for p := range source {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var matched atomic.Int64
+	differ, missingOnDst, missingOnSrc, errored := pathCollector{}, pathCollector{}, pathCollector{}, pathCollector{}
+	queue := make(chan string)
+	var wg sync.WaitGroup
+	for range max(fs.GetConfig(ctx).Checkers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range queue {
+				dst, ok := dest[p]
+				if !ok {
+					missingOnDst.add(p)
+					stale.add(p, report.Destination, func(f *models.VerifyFileCopy) *[]string { return &f.Missing })
+					continue
+				}
+				same, err := compareCopy(ctx, source[p], dst, ht, download, hasher)
+				switch {
+				case err != nil:
+					fs.Errorf(dst, "failed to verify: %v", err)
+					errored.add(p)
+					stale.add(p, report.Destination, func(f *models.VerifyFileCopy) *[]string { return &f.Errors })
+				case !same:
+					differ.add(p)
+					stale.add(p, report.Destination, func(f *models.VerifyFileCopy) *[]string { return &f.Differ })
+				default:
+					matched.Add(1)
+				}
+			}
+		}()
+	}
+feed:
+	for _, p := range paths {
+		select {
+		case queue <- p:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	for p := range dest {
+		if _, ok := source[p]; !ok {
+			missingOnSrc.add(p)
+		}
+	}
+	for _, c := range []*pathCollector{&differ, &missingOnDst, &missingOnSrc, &errored} {
+		sort.Strings(c.paths)
+	}
+
+	report.Matched = int(matched.Load())
+	report.Differ, report.DifferCount = differ.paths, differ.count
+	report.MissingOnDst, report.MissingCount = missingOnDst.paths, missingOnDst.count
+	report.MissingOnSrc, report.ExtraCount = missingOnSrc.paths, missingOnSrc.count
+	report.Errors, report.ErrorCount = errored.paths, errored.count
+	return nil
+}
+
+// compareCopy reports whether dst is an identical copy of src: same size,
+// and same hash of type ht unless either side has none. With download the
+// destination's contents are hashed instead of asking the remote.
+func compareCopy(ctx context.Context, src, dst fs.Object, ht hash.Type, download bool, hasher *sourceHasher) (bool, error) {
+	if src.Size() >= 0 && dst.Size() >= 0 && src.Size() != dst.Size() {
+		return false, nil
+	}
+	if ht == hash.None {
+		return true, nil
+	}
+	srcSums, err := hasher.sums(ctx, src)
+	if err != nil {
+		return false, fmt.Errorf("failed to hash source: %w", err)
+	}
+	var dstSum string
+	if download {
+		var sums map[hash.Type]string
+		sums, err = hashContents(ctx, dst, hash.NewHashSet(ht))
+		dstSum = sums[ht]
+	} else {
+		dstSum, err = dst.Hash(ctx, ht)
+	}
+	if err != nil {
+		return false, err
+	}
+	if srcSums[ht] == "" || dstSum == "" {
+		return true, nil
+	}
+	return srcSums[ht] == dstSum, nil
+}
@@ -0,0 +1,156 @@
+package rclone
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"desktop/backend/models"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/config/obscure"
+	"github.com/rclone/rclone/fs/sync"
+)
+
+func TestVerify(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeTestFiles(t, src, map[string]string{"same.txt": "same", "changed.txt": "original", "missing.txt": "gone"})
+	writeTestFiles(t, dst, map[string]string{"same.txt": "same", "changed.txt": "corrupt!", "extra.txt": "old"})
+
+	profile := models.Profile{Name: "backup", From: src, To: dst, Parallel: 2}
+	for _, download := range []bool{false, true} {
+		report, err := Verify(context.Background(), profile, models.VerifyOptions{Download: download}, nil, time.Time{})
+		if err != nil {
+			t.Fatalf("Verify(download=%v) failed: %v", download, err)
+		}
+		if report.Matched != 1 || !slices.Equal(report.Differ, []string{"changed.txt"}) ||
+			!slices.Equal(report.MissingOnDst, []string{"missing.txt"}) || !slices.Equal(report.MissingOnSrc, []string{"extra.txt"}) {
+			t.Errorf("unexpected report (download=%v): %+v", download, report)
+		}
+		if report.Problems() != 2 {
+			t.Errorf("Problems() = %d, want 2", report.Problems())
+		}
+	}
+
+	if _, err := Verify(context.Background(), models.Profile{From: src, To: "nosuchremote:"}, models.VerifyOptions{}, nil, time.Time{}); err == nil {
+		t.Error("expected an unknown destination to fail")
+	}
+}
+
+func TestVerifyCryptCheck(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeTestFiles(t, src, map[string]string{"same.txt": "same", "changed.txt": "original"})
+
+	ctx := context.Background()
+	cryptPath := fmt.Sprintf(":crypt,remote='%s',password=%s:", dst, obscure.MustObscure("secret"))
+	srcFs, err := fs.NewFs(ctx, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cryptFs, err := fs.NewFs(ctx, cryptPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sync.CopyDir(ctx, cryptFs, srcFs, false); err != nil {
+		t.Fatal(err)
+	}
+	// Same size, so only the hashes tell the copies apart
+	writeTestFiles(t, src, map[string]string{"changed.txt": "0riginal"})
+
+	report, err := Verify(ctx, models.Profile{Name: "vault", From: src, To: cryptPath}, models.VerifyOptions{}, nil, time.Time{})
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if !report.CryptCheck || report.Matched != 1 || !slices.Equal(report.Differ, []string{"changed.txt"}) {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
+
+func TestVerifySampled(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	files := make(map[string]string)
+	for i := range 200 {
+		files[fmt.Sprintf("dir%d/file%d.txt", i%10, i)] = fmt.Sprint(i)
+	}
+	writeTestFiles(t, src, files)
+	writeTestFiles(t, dst, files)
+
+	// Corrupt a file the sample picks
+	opts := models.VerifyOptions{SamplePercent: 25, Seed: 42}
+	var corrupted string
+	for p := range files {
+		if inVerifySample(opts.Seed, p, opts.SamplePercent) {
+			corrupted = p
+			break
+		}
+	}
+	writeTestFiles(t, dst, map[string]string{corrupted: "corrupted"})
+
+	profile := models.Profile{Name: "archive", From: src, To: dst}
+	first, err := Verify(context.Background(), profile, opts, nil, time.Time{})
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	s := first.Sample
+	if s == nil || s.Seed != 42 || s.TotalFiles != 200 || s.SampledFiles == 0 || s.SampledFiles >= 200 {
+		t.Fatalf("unexpected sample: %+v", s)
+	}
+	if first.Matched != s.SampledFiles-1 || !slices.Equal(first.Differ, []string{corrupted}) || s.SampleProblems != 1 ||
+		s.MaxProblemPercent <= 0 || s.MaxProblemPercent >= 100 {
+		t.Errorf("unexpected report: %+v %+v", first, s)
+	}
+
+	// The same seed checks the same files
+	again, err := Verify(context.Background(), profile, opts, nil, time.Time{})
+	if err != nil || again.Sample.SampledFiles != s.SampledFiles {
+		t.Errorf("expected the seed to reproduce the sample, got %+v, %v", again.Sample, err)
+	}
+}
+
+func TestProblemRateBound(t *testing.T) {
+	if b := problemRateBound(0, 1000); math.Abs(b-0.0038) > 0.0002 {
+		t.Errorf("bound of 0/1000 = %v, want about 0.0038", b)
+	}
+	if b := problemRateBound(10, 100); b <= 0.1 || b >= 0.2 {
+		t.Errorf("bound of 10/100 = %v, want between 0.1 and 0.2", b)
+	}
+	if problemRateBound(0, 0) != 1 {
+		t.Error("nothing sampled should bound nothing")
+	}
+}
+
+func TestVerifyDestinations(t *testing.T) {
+	src, dst1, dst2 := t.TempDir(), t.TempDir(), t.TempDir()
+	files := map[string]string{"a.txt": "alpha", "dir/b.txt": "bravo"}
+	writeTestFiles(t, src, files)
+	writeTestFiles(t, dst1, files)
+	writeTestFiles(t, dst2, map[string]string{"a.txt": "ALPHA", "old.txt": "old"})
+
+	profile := models.Profile{Name: "321", From: src, To: dst1, FanOutTo: []string{dst2, "nosuchremote:"}, Parallel: 2}
+	for _, download := range []bool{false, true} {
+		result, err := VerifyDestinations(context.Background(), profile, models.VerifyOptions{Download: download})
+		if err != nil {
+			t.Fatalf("VerifyDestinations(download=%v) failed: %v", download, err)
+		}
+		if result.SourceFiles != 2 || len(result.Reports) != 3 {
+			t.Fatalf("unexpected result (download=%v): %+v", download, result)
+		}
+		if r := result.Reports[0]; r.Matched != 2 || r.Problems() != 0 || r.ErrorMessage != "" {
+			t.Errorf("up to date copy reported as %+v", r)
+		}
+		if r := result.Reports[1]; r.Matched != 0 || !slices.Equal(r.Differ, []string{"a.txt"}) ||
+			!slices.Equal(r.MissingOnDst, []string{"dir/b.txt"}) || !slices.Equal(r.MissingOnSrc, []string{"old.txt"}) {
+			t.Errorf("stale copy reported as %+v", r)
+		}
+		if result.Reports[2].ErrorMessage == "" {
+			t.Error("expected the unknown destination to fail")
+		}
+		want := []models.VerifyFileCopy{{Path: "a.txt", Differ: []string{dst2}}, {Path: "dir/b.txt", Missing: []string{dst2}}}
+		if result.FileCount != 2 || fmt.Sprint(result.Files) != fmt.Sprint(want) {
+			t.Errorf("out of date files = %+v, want %+v", result.Files, want)
+		}
+	}
+}
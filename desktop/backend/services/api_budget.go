@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/rclone"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	fsConfig "github.com/rclone/rclone/fs/config"
+)
+
+// apiUsageDayFormat is how days are stored in the api_usage table
+const apiUsageDayFormat = "2006-01-02"
+
+// APIUsage is the estimated number of API calls made to a provider in a day,
+// against its daily quota when one is known or configured
+type APIUsage struct {
+	Provider    string  `json:"provider"` // rclone backend type, e.g. "drive"
+	Day         string  `json:"day"`      // YYYY-MM-DD, local time
+	Calls       int64   `json:"calls"`
+	DailyQuota  int64   `json:"daily_quota,omitempty"`  // 0 when the provider has no known quota
+	UsedPercent float64 `json:"used_percent,omitempty"` // of DailyQuota
+}
+
+// parseAPIQuotas parses daily API quotas written as "provider=calls",
+// comma separated, e.g. "drive=20000,dropbox=5000"
+func parseAPIQuotas(value string) (map[string]int64, error) {
+	quotas := make(map[string]int64)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		provider, calls, ok := strings.Cut(part, "=")
+		provider = strings.TrimSpace(provider)
+		n, err := strconv.ParseInt(strings.TrimSpace(calls), 10, 64)
+		if !ok || provider == "" || err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid API quota %q: expected provider=calls", part)
+		}
+		quotas[provider] = n
+	}
+	return quotas, nil
+}
+
+// addAPICalls adds the API calls made between two counts to the task's
+// per-provider calls
+func (t *SyncTask) addAPICalls(before, after map[string]int64) {
+	for provider, count := range after {
+		if calls := count - before[provider]; calls > 0 {
+			if t.apiCalls == nil {
+				t.apiCalls = make(map[string]int64)
+			}
+			t.apiCalls[provider] += calls
+		}
+	}
+}
+
+// rememberAPICalls keeps the API calls of the task so the profile's next
+// history entry can include them
+func (s *SyncService) rememberAPICalls(task *SyncTask) {
+	if len(task.apiCalls) == 0 {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.apiCallRuns == nil {
+		s.apiCallRuns = make(map[string]map[string]int64)
+	}
+	s.apiCallRuns[task.Profile.Name] = task.apiCalls
+}
+
+// takeAPICalls returns and forgets the API calls of a profile's last run
+func (s *SyncService) takeAPICalls(profileName string) map[string]int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	calls := s.apiCallRuns[profileName]
+	delete(s.apiCallRuns, profileName)
+	return calls
+}
+
+// recordAPICalls adds the API calls made since it last ran to today's usage
+func (s *SyncService) recordAPICalls() {
+	s.apiCallsMu.Lock()
+	defer s.apiCallsMu.Unlock()
+
+	db, err := GetSharedDB()
+	if err != nil {
+		return
+	}
+	counts := rclone.APICallCounts()
+	day := time.Now().Format(apiUsageDayFormat)
+	for provider, count := range counts {
+		calls := count - s.apiCallsRecorded[provider]
+		if calls <= 0 {
+			continue
+		}
+		if _, err := db.Exec(`INSERT INTO api_usage (day, provider, calls) VALUES (?, ?, ?)
+			ON CONFLICT(day, provider) DO UPDATE SET calls = calls + excluded.calls`, day, provider, calls); err != nil {
+			log.Printf("[SyncService] Could not record API calls to %s: %v", provider, err)
+			return
+		}
+	}
+	s.apiCallsRecorded = counts
+}
+
+// GetAPIUsage returns the estimated API calls per provider of the last days
+// (1 = today only), newest first. Calls are counted from the HTTP requests
+// rclone makes, so they include listing, retries and rate-limited requests.
+func (s *SyncService) GetAPIUsage(ctx context.Context, days int) ([]APIUsage, error) {
+	if days < 1 {
+		days = 1
+	}
+	s.recordAPICalls()
+
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	since := time.Now().AddDate(0, 0, 1-days).Format(apiUsageDayFormat)
+	rows, err := db.Query("SELECT day, provider, calls FROM api_usage WHERE day >= ? ORDER BY day DESC, provider", since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API usage: %w", err)
+	}
+	defer rows.Close()
+
+	_, quotas := s.apiBudget()
+	usage := []APIUsage{}
+	for rows.Next() {
+		var u APIUsage
+		if err := rows.Scan(&u.Day, &u.Provider, &u.Calls); err != nil {
+			return nil, fmt.Errorf("failed to scan API usage: %w", err)
+		}
+		if quota := quotas[u.Provider]; quota > 0 {
+			u.DailyQuota = quota
+			u.UsedPercent = float64(u.Calls) / float64(quota) * 100
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+// apiBudget returns the percent of a daily quota from which scheduled runs
+// are deferred (0 = never), and the daily quotas in effect
+func (s *SyncService) apiBudget() (int, map[string]int64) {
+	if s.settingsService == nil {
+		return defaultAPIBudgetThreshold, rclone.KnownDailyQuotas
+	}
+	return s.settingsService.getAPIBudget()
+}
+
+// apiBudgetExhausted returns the first of the given remotes' providers whose
+// API calls today have reached the budget threshold, with the percent of its
+// quota used, or "" if none has
+func (s *SyncService) apiBudgetExhausted(remotes []string) (string, float64) {
+	threshold, quotas := s.apiBudget()
+	if threshold <= 0 {
+		return "", 0
+	}
+
+	var providers []string
+	for _, remote := range remotes {
+		if remote == "" {
+			continue
+		}
+		provider, _ := fsConfig.FileGetValue(remote, "type")
+		if quotas[provider] > 0 {
+			providers = append(providers, provider)
+		}
+	}
+	if len(providers) == 0 {
+		return "", 0
+	}
+	sort.Strings(providers)
+
+	usage, err := s.GetAPIUsage(context.Background(), 1)
+	if err != nil {
+		log.Printf("[SyncService] Could not check the API budget: %v", err)
+		return "", 0
+	}
+	for _, provider := range providers {
+		for _, u := range usage {
+			if u.Provider == provider && u.UsedPercent >= float64(threshold) {
+				return provider, u.UsedPercent
+			}
+		}
+	}
+	return "", 0
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"testing"
+
+	"github.com/rclone/rclone/fs/fshttp"
+)
+
+func TestParseAPIQuotas(t *testing.T) {
+	quotas, err := parseAPIQuotas(" drive=20000, dropbox = 5000,")
+	if err != nil || len(quotas) != 2 || quotas["drive"] != 20000 || quotas["dropbox"] != 5000 {
+		t.Errorf("parseAPIQuotas = %v, %v", quotas, err)
+	}
+	for _, bad := range []string{"drive", "drive=lots", "=10", "drive=-1"} {
+		if _, err := parseAPIQuotas(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestSyncService_APIUsage(t *testing.T) {
+	db, _ := GetSharedDB()
+	db.Exec("DELETE FROM api_usage")
+	ctx := context.Background()
+
+	settings := newTestSettingsService(t)
+	if err := settings.SetAPIBudget(ctx, 50, "dropbox=4"); err != nil {
+		t.Fatalf("SetAPIBudget: %v", err)
+	}
+	if err := settings.SetAPIBudget(ctx, 50, "dropbox"); err == nil {
+		t.Error("expected invalid quotas to be rejected")
+	}
+	s := NewSyncService(nil)
+	s.SetSettingsService(settings)
+	s.recordAPICalls()
+
+	// A run makes two calls to Dropbox's API
+	task := &SyncTask{Profile: models.Profile{Name: "photos"}}
+	before := map[string]int64{"dropbox": 5}
+	task.addAPICalls(before, map[string]int64{"dropbox": 7, "drive": 0})
+	s.rememberAPICalls(task)
+	if calls := s.takeAPICalls("photos"); len(calls) != 1 || calls["dropbox"] != 2 {
+		t.Errorf("API calls of the run = %v", calls)
+	}
+
+	fshttp.DefaultMetrics.StatusCode.WithLabelValues("api.dropboxapi.com", "POST", "200").Add(3)
+	usage, err := s.GetAPIUsage(ctx, 1)
+	if err != nil || len(usage) != 1 {
+		t.Fatalf("GetAPIUsage = %+v, %v", usage, err)
+	}
+	if u := usage[0]; u.Provider != "dropbox" || u.Calls != 3 || u.DailyQuota != 4 || u.UsedPercent != 75 {
+		t.Errorf("unexpected usage: %+v", u)
+	}
+
+	// Counted calls aren't added twice
+	if usage, _ := s.GetAPIUsage(ctx, 7); len(usage) != 1 || usage[0].Calls != 3 {
+		t.Errorf("usage after a second read = %+v", usage)
+	}
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// keychainService is the service name the app's keychain entries are stored under
+const keychainService = "ng-drive"
+
+// errKeychainNotFound is returned when the keychain has no entry for the app
+var errKeychainNotFound = errors.New("no unlock key in the OS keychain")
+
+// IsKeychainUnlockEnabled returns whether the app unlocks with a key kept
+// in the OS keychain instead of the password. Available before unlock.
+func (a *AuthService) IsKeychainUnlockEnabled(ctx context.Context) bool {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.authData != nil && a.authData.Enabled && a.authData.KeychainKey != ""
+}
+
+// EnableKeychainUnlock stores a key in the OS keychain (macOS Keychain,
+// Windows Credential Manager or the Secret Service through libsecret) that
+// unlocks the app at startup without the password, or the key file, on
+// this machine. auth.json only holds the file key sealed with it, so the
+// keychain entry alone unlocks nothing. Requires the app to be unlocked.
+func (a *AuthService) EnableKeychainUnlock(ctx context.Context, password string) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if _, err := a.verifyUnlockedLocked(password); err != nil {
+		return err
+	}
+
+	wrapKey := make([]byte, argon2KeyLen)
+	if _, err := rand.Read(wrapKey); err != nil {
+		return fmt.Errorf("failed to generate keychain key: %w", err)
+	}
+	defer zeroBytes(wrapKey)
+	sealed, err := a.sealUnlockKeyLocked(wrapKey)
+	if err != nil {
+		return err
+	}
+	if err := keychainSet(a.keychainAccount(), wrapKey); err != nil {
+		return fmt.Errorf("failed to store the key in the OS keychain: %w", err)
+	}
+
+	a.authData.KeychainKey = sealed
+	if err := a.saveAuthData(); err != nil {
+		a.authData.KeychainKey = ""
+		keychainDelete(a.keychainAccount())
+		return fmt.Errorf("failed to save auth data: %w", err)
+	}
+	log.Printf("AuthService: Keychain unlock enabled")
+	return nil
+}
+
+// DisableKeychainUnlock removes the key from the OS keychain, so unlocking
+// needs the password again
+func (a *AuthService) DisableKeychainUnlock(ctx context.Context) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.authData == nil || a.authData.KeychainKey == "" {
+		return nil
+	}
+	a.authData.KeychainKey = ""
+	if err := a.saveAuthData(); err != nil {
+		return fmt.Errorf("failed to save auth data: %w", err)
+	}
+	if err := keychainDelete(a.keychainAccount()); err != nil {
+		return fmt.Errorf("failed to remove the key from the OS keychain: %w", err)
+	}
+	log.Printf("AuthService: Keychain unlock disabled")
+	return nil
+}
+
+// UnlockWithKeychain unlocks with the key in the OS keychain, e.g. when the
+// automatic unlock at startup failed because the keychain was locked. On
+// failure the app stays locked for the password.
+func (a *AuthService) UnlockWithKeychain(ctx context.Context) error {
+	return a.unlockWithKeychain(ctx)
+}
+
+// unlockWithKeychain opens the file key sealed in auth.json with the key in
+// the OS keychain and decrypts all files
+func (a *AuthService) unlockWithKeychain(ctx context.Context) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.authData == nil || !a.authData.Enabled {
+		return fmt.Errorf("auth not enabled")
+	}
+	if a.unlocked {
+		return nil
+	}
+	if a.authData.KeychainKey == "" {
+		return fmt.Errorf("keychain unlock is not enabled")
+	}
+
+	wrapKey, err := keychainGet(a.keychainAccount())
+	if err != nil {
+		return err
+	}
+	defer zeroBytes(wrapKey)
+	key, keyFileData, err := openUnlockKey(a.authData.KeychainKey, wrapKey)
+	if err != nil {
+		return err
+	}
+	if a.authData.KeyFileHash != "" && !keyFileMatches(keyFileData, a.authData.KeyFileHash) {
+		zeroBytes(key)
+		zeroBytes(keyFileData)
+		return fmt.Errorf("the keychain entry is out of date")
+	}
+	if err := a.openLocked(ctx, key, keyFileData); err != nil {
+		zeroBytes(key)
+		zeroBytes(keyFileData)
+		return err
+	}
+	return nil
+}
+
+// resealKeychainKeyLocked seals the current file key again with the key in
+// the OS keychain after it changed. If that fails keychain unlock is turned
+// off, leaving the password (caller must hold lock).
+func (a *AuthService) resealKeychainKeyLocked() {
+	if a.authData.KeychainKey == "" {
+		return
+	}
+	wrapKey, err := keychainGet(a.keychainAccount())
+	var sealed string
+	if err == nil {
+		sealed, err = a.sealUnlockKeyLocked(wrapKey)
+		zeroBytes(wrapKey)
+	}
+	if err != nil {
+		log.Printf("AuthService: Keychain unlock disabled, failed to update it: %v", err)
+		keychainDelete(a.keychainAccount())
+	}
+	a.authData.KeychainKey = sealed
+	if err := a.saveAuthData(); err != nil {
+		log.Printf("AuthService: Failed to save auth data: %v", err)
+	}
+}
+
+// sealUnlockKeyLocked seals the file key, followed by the key file if any,
+// with wrapKey (caller must hold lock)
+func (a *AuthService) sealUnlockKeyLocked(wrapKey []byte) (string, error) {
+	if len(a.encKey) != argon2KeyLen {
+		return "", fmt.Errorf("the app must be unlocked")
+	}
+	payload := append(append([]byte(nil), a.encKey...), a.keyFile...)
+	defer zeroBytes(payload)
+	sealed, err := EncryptData(payload, wrapKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to seal the key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// openUnlockKey opens what sealUnlockKeyLocked sealed, returning the file
+// key and the key file (nil without one)
+func openUnlockKey(sealed string, wrapKey []byte) ([]byte, []byte, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid keychain unlock data")
+	}
+	payload, err := DecryptData(data, wrapKey)
+	if err != nil || len(payload) < argon2KeyLen {
+		return nil, nil, fmt.Errorf("the keychain entry doesn't match this app's data")
+	}
+	key := append([]byte(nil), payload[:argon2KeyLen]...)
+	var keyFileData []byte
+	if len(payload) > argon2KeyLen {
+		keyFileData = append([]byte(nil), payload[argon2KeyLen:]...)
+	}
+	zeroBytes(payload)
+	return key, keyFileData, nil
+}
+
+// keychainAccount returns the keychain account name of this app's data, so
+// installs with different config directories don't share an entry
+func (a *AuthService) keychainAccount() string {
+	sum := sha256.Sum256([]byte(a.authFilePath))
+	return "unlock-" + hex.EncodeToString(sum[:8])
+}
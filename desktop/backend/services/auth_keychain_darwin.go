@@ -0,0 +1,54 @@
+//go:build darwin
+
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// The key is a generic password in the login keychain, managed with the
+// security tool. Commands that carry the key are read from stdin with
+// "security -i" so the key never shows up in the process list.
+
+const securityTool = "/usr/bin/security"
+
+// securityNotFound is the exit status of security when no item matches
+const securityNotFound = 44
+
+func keychainSet(account string, secret []byte) error {
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -l %q -w %s\n",
+		keychainService, account, keychainService+" unlock key", base64.StdEncoding.EncodeToString(secret))
+	cmd := exec.Command(securityTool, "-i")
+	cmd.Stdin = strings.NewReader(command)
+	out, err := cmd.CombinedOutput()
+	if err != nil || bytes.Contains(out, []byte("error")) {
+		return fmt.Errorf("security add-generic-password failed: %v: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+func keychainGet(account string) ([]byte, error) {
+	out, err := exec.Command(securityTool, "find-generic-password", "-s", keychainService, "-a", account, "-w").Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == securityNotFound {
+		return nil, errKeychainNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("security find-generic-password failed: %w", err)
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(string(out)))
+}
+
+func keychainDelete(account string) error {
+	out, err := exec.Command(securityTool, "delete-generic-password", "-s", keychainService, "-a", account).CombinedOutput()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == securityNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("security delete-generic-password failed: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
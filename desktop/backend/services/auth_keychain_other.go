@@ -0,0 +1,50 @@
+//go:build !windows && !darwin
+
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// On Linux the key is stored with the Secret Service (GNOME Keyring, KWallet)
+// through libsecret's secret-tool, which reads the secret from stdin.
+
+const secretTool = "secret-tool"
+
+// keychainAttributes returns the attributes that identify the app's entry
+func keychainAttributes(account string) []string {
+	return []string{"service", keychainService, "account", account}
+}
+
+func keychainSet(account string, secret []byte) error {
+	args := append([]string{"store", "--label=" + keychainService + " unlock key"}, keychainAttributes(account)...)
+	cmd := exec.Command(secretTool, args...)
+	cmd.Stdin = strings.NewReader(base64.StdEncoding.EncodeToString(secret))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("secret-tool store failed: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+func keychainGet(account string) ([]byte, error) {
+	out, err := exec.Command(secretTool, append([]string{"lookup"}, keychainAttributes(account)...)...).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) == 0 {
+		// secret-tool exits with 1 and prints nothing when there is no match
+		return nil, errKeychainNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("secret-tool lookup failed: %w", err)
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(string(out)))
+}
+
+func keychainDelete(account string) error {
+	if out, err := exec.Command(secretTool, append([]string{"clear"}, keychainAttributes(account)...)...).CombinedOutput(); err != nil {
+		return fmt.Errorf("secret-tool clear failed: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestUnlockKeySealing(t *testing.T) {
+	wrapKey := bytes.Repeat([]byte{9}, argon2KeyLen)
+	for _, keyFile := range [][]byte{nil, bytes.Repeat([]byte{2}, keyFileLen)} {
+		a := &AuthService{encKey: bytes.Repeat([]byte{1}, argon2KeyLen), keyFile: keyFile}
+		sealed, err := a.sealUnlockKeyLocked(wrapKey)
+		if err != nil {
+			t.Fatalf("sealUnlockKeyLocked() error = %v", err)
+		}
+		key, gotKeyFile, err := openUnlockKey(sealed, wrapKey)
+		if err != nil || !bytes.Equal(key, a.encKey) || !bytes.Equal(gotKeyFile, keyFile) {
+			t.Errorf("openUnlockKey() = %x, %x, %v; want %x, %x", key, gotKeyFile, err, a.encKey, keyFile)
+		}
+		if _, _, err := openUnlockKey(sealed, bytes.Repeat([]byte{8}, argon2KeyLen)); err == nil {
+			t.Error("expected another keychain key to fail")
+		}
+	}
+
+	if _, err := (&AuthService{}).sealUnlockKeyLocked(wrapKey); err == nil {
+		t.Error("expected sealing to fail while locked")
+	}
+}
+
+func TestKeychainAccount(t *testing.T) {
+	a := &AuthService{authFilePath: "/home/a/.config/ng-drive/auth.json"}
+	b := &AuthService{authFilePath: "/home/b/.config/ng-drive/auth.json"}
+	if a.keychainAccount() == b.keychainAccount() {
+		t.Error("installs with different config directories share a keychain account")
+	}
+	if a.keychainAccount() != a.keychainAccount() {
+		t.Error("keychain account isn't stable")
+	}
+}
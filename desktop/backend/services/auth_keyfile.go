@@ -0,0 +1,343 @@
+package services
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Key file parameters
+const (
+	keyFileLen       = 64      // random bytes in a generated key file
+	maxKeyFileSize   = 1 << 20 // larger files are refused without reading them
+	recoveryCodeLen  = 20      // random bytes in a recovery code; 32 base32 characters
+	keyFileInfo      = "ng-drive key file"
+	recoveryCodeInfo = "ng-drive key file recovery"
+)
+
+// recoveryEncoding encodes recovery codes; they are shown in groups of 4
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// IsKeyFileRequired returns whether unlocking needs a key file besides the
+// password. Available before unlock.
+func (a *AuthService) IsKeyFileRequired(ctx context.Context) bool {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.authData != nil && a.authData.Enabled && a.authData.KeyFileHash != ""
+}
+
+// UnlockWithKeyFile verifies the password and the key file at keyFilePath,
+// e.g. on a USB stick, and decrypts all files
+func (a *AuthService) UnlockWithKeyFile(ctx context.Context, password, keyFilePath string) error {
+	data, err := readKeyFile(keyFilePath)
+	if err != nil {
+		return err
+	}
+	defer zeroBytes(data)
+
+	return a.unlock(ctx, password, func(d *AuthData) ([]byte, error) {
+		if !keyFileMatches(data, d.KeyFileHash) {
+			return nil, fmt.Errorf("incorrect key file")
+		}
+		return append([]byte(nil), data...), nil
+	})
+}
+
+// UnlockWithRecoveryCode unlocks with the password and the recovery code
+// shown when the key file was generated, for when the key file is lost. The
+// key file is recovered from the code; write it out again with
+// RestoreKeyFile, or replace it with GenerateKeyFile.
+func (a *AuthService) UnlockWithRecoveryCode(ctx context.Context, password, recoveryCode string) error {
+	return a.unlock(ctx, password, func(d *AuthData) ([]byte, error) {
+		data, err := openKeyFileRecovery(d.KeyFileRecovery, recoveryCode)
+		if err != nil || !keyFileMatches(data, d.KeyFileHash) {
+			return nil, fmt.Errorf("incorrect recovery code")
+		}
+		return data, nil
+	})
+}
+
+// GenerateKeyFile writes a new random key file to path and re-encrypts all
+// files so unlocking needs both the password and the key file. It replaces
+// the current key file, if any. Returns a recovery code to unlock with in
+// place of the key file (see UnlockWithRecoveryCode); it is only shown
+// here, and also returned with an error once the files need the new key
+// file. An existing file at path is only overwritten if it is the current
+// key file.
+func (a *AuthService) GenerateKeyFile(ctx context.Context, password, path string) (string, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	salt, err := a.verifyUnlockedLocked(password)
+	if err != nil {
+		return "", err
+	}
+	if existing, err := readKeyFile(path); err == nil {
+		current := keyFileMatches(existing, a.authData.KeyFileHash)
+		zeroBytes(existing)
+		if !current {
+			return "", fmt.Errorf("%s already exists and is not the current key file", path)
+		}
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	keyFile := make([]byte, keyFileLen)
+	if _, err := rand.Read(keyFile); err != nil {
+		return "", fmt.Errorf("failed to generate key file: %w", err)
+	}
+	recoveryCode, recovery, err := newKeyFileRecovery(keyFile)
+	if err != nil {
+		return "", err
+	}
+	newKey, err := deriveFileKey(password, salt, keyFile)
+	if err != nil {
+		return "", err
+	}
+	newHash := keyFileHash(keyFile)
+	resealRecovery, err := a.resealPasswordRecoveryLocked(newKey, keyFile)
+	if err != nil {
+		return "", err
+	}
+
+	// Write the key file next to its destination first, so a failure leaves
+	// the current key file in place
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, keyFile, 0600); err != nil {
+		return "", fmt.Errorf("failed to write key file: %w", err)
+	}
+
+	err = a.rekeyLocked(newKey, func(d *AuthData) {
+		d.KeyFileHash = newHash
+		d.KeyFileRecovery = recovery
+		resealRecovery(d)
+	})
+	if err != nil && a.authData.KeyFileHash != newHash {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	if err == nil {
+		zeroBytes(a.keyFile)
+		a.keyFile = keyFile
+		a.resealKeychainKeyLocked()
+	}
+	// The files need the new key file from here on, even if re-encrypting didn't complete
+	if renameErr := os.Rename(tmpPath, path); renameErr != nil {
+		return recoveryCode, fmt.Errorf("failed to move the new key file into place, it is at %s: %w", tmpPath, renameErr)
+	}
+	if err != nil {
+		return recoveryCode, err
+	}
+
+	log.Printf("AuthService: Key file generated")
+	return recoveryCode, nil
+}
+
+// RemoveKeyFile re-encrypts all files so unlocking needs only the password
+// again. The key file itself is left alone.
+func (a *AuthService) RemoveKeyFile(ctx context.Context, password string) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	salt, err := a.verifyUnlockedLocked(password)
+	if err != nil {
+		return err
+	}
+	if a.authData.KeyFileHash == "" {
+		return fmt.Errorf("no key file is set up")
+	}
+
+	newKey, err := deriveFileKey(password, salt, nil)
+	if err != nil {
+		return err
+	}
+	resealRecovery, err := a.resealPasswordRecoveryLocked(newKey, nil)
+	if err != nil {
+		return err
+	}
+	if err := a.rekeyLocked(newKey, func(d *AuthData) {
+		d.KeyFileHash = ""
+		d.KeyFileRecovery = ""
+		resealRecovery(d)
+	}); err != nil {
+		return err
+	}
+
+	zeroBytes(a.keyFile)
+	a.keyFile = nil
+	a.resealKeychainKeyLocked()
+	log.Printf("AuthService: Key file removed")
+	return nil
+}
+
+// RestoreKeyFile writes the current key file to path, which must not exist,
+// e.g. to a new USB stick after unlocking with the recovery code
+func (a *AuthService) RestoreKeyFile(ctx context.Context, password, path string) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if _, err := a.verifyUnlockedLocked(password); err != nil {
+		return err
+	}
+	if a.keyFile == nil {
+		return fmt.Errorf("no key file is set up")
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create key file: %w", err)
+	}
+	if _, err := f.Write(a.keyFile); err != nil {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("failed to write key file: %w", err)
+	}
+	return f.Close()
+}
+
+// verifyUnlockedLocked checks that auth is enabled, the app is unlocked and
+// the password is right, and returns the password's salt (caller must hold lock)
+func (a *AuthService) verifyUnlockedLocked(password string) ([]byte, error) {
+	if a.authData == nil || !a.authData.Enabled {
+		return nil, fmt.Errorf("auth not enabled")
+	}
+	if !a.unlocked {
+		return nil, fmt.Errorf("app must be unlocked to change the key file")
+	}
+	if !verifyPasswordHash(password, a.authData.PasswordHash) {
+		return nil, fmt.Errorf("incorrect password")
+	}
+	return extractSalt(a.authData.PasswordHash)
+}
+
+// deriveFileKey derives the key the files are encrypted with: the password
+// key, combined with the key file through HKDF-SHA256 when there is one
+func deriveFileKey(password string, salt, keyFile []byte) ([]byte, error) {
+	key := deriveKey(password, salt)
+	if keyFile == nil {
+		return key, nil
+	}
+	secret := append(append([]byte(nil), key...), keyFile...)
+	combined, err := hkdf.Key(sha256.New, secret, salt, keyFileInfo, argon2KeyLen)
+	zeroBytes(key)
+	zeroBytes(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return combined, nil
+}
+
+// readKeyFile reads a key file, refusing empty and oversized files
+func readKeyFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() || info.Size() == 0 || info.Size() > maxKeyFileSize {
+		return nil, fmt.Errorf("%s is not a key file", path)
+	}
+	return os.ReadFile(path)
+}
+
+// keyFileHash returns the hex SHA-256 of a key file
+func keyFileHash(keyFile []byte) string {
+	sum := sha256.Sum256(keyFile)
+	return hex.EncodeToString(sum[:])
+}
+
+// keyFileMatches reports whether a key file has the given hash
+func keyFileMatches(keyFile []byte, hash string) bool {
+	return hash != "" && subtle.ConstantTimeCompare([]byte(keyFileHash(keyFile)), []byte(hash)) == 1
+}
+
+// newKeyFileRecovery generates a recovery code and seals the key file with
+// it. Returns the code, formatted for display, and the sealed key file.
+func newKeyFileRecovery(keyFile []byte) (string, string, error) {
+	code, display, err := newRecoveryCode()
+	if err != nil {
+		return "", "", err
+	}
+	defer zeroBytes(code)
+	gcm, err := recoveryCipher(code)
+	if err != nil {
+		return "", "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, keyFile, nil)
+	return display, base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// newRecoveryCode generates a recovery code. Returns it, and formatted for
+// display in groups of 4.
+func newRecoveryCode() ([]byte, string, error) {
+	code := make([]byte, recoveryCodeLen)
+	if _, err := rand.Read(code); err != nil {
+		return nil, "", fmt.Errorf("failed to generate recovery code: %w", err)
+	}
+	encoded := recoveryEncoding.EncodeToString(code)
+	var groups []string
+	for i := 0; i < len(encoded); i += 4 {
+		groups = append(groups, encoded[i:min(i+4, len(encoded))])
+	}
+	return code, strings.Join(groups, "-"), nil
+}
+
+// parseRecoveryCode decodes a recovery code typed in any case, with or
+// without separators
+func parseRecoveryCode(recoveryCode string) ([]byte, error) {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(recoveryCode))
+	code, err := recoveryEncoding.DecodeString(normalized)
+	if err != nil || len(code) != recoveryCodeLen {
+		return nil, fmt.Errorf("invalid recovery code")
+	}
+	return code, nil
+}
+
+// openKeyFileRecovery recovers the key file sealed by newKeyFileRecovery.
+// The code may be typed in any case, with or without separators.
+func openKeyFileRecovery(sealed, recoveryCode string) ([]byte, error) {
+	code, err := parseRecoveryCode(recoveryCode)
+	if err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key file recovery data")
+	}
+	gcm, err := recoveryCipher(code)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid key file recovery data")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// recoveryCipher returns the AES-256-GCM cipher keyed by a recovery code
+func recoveryCipher(code []byte) (cipher.AEAD, error) {
+	key, err := hkdf.Key(sha256.New, code, nil, recoveryCodeInfo, argon2KeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive recovery key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDeriveFileKey(t *testing.T) {
+	salt := bytes.Repeat([]byte{1}, argon2SaltLen)
+	keyFile := bytes.Repeat([]byte{2}, keyFileLen)
+
+	plain, err := deriveFileKey("secret", salt, nil)
+	if err != nil {
+		t.Fatalf("deriveFileKey() error = %v", err)
+	}
+	if !bytes.Equal(plain, deriveKey("secret", salt)) {
+		t.Error("without a key file, the key should be the password key")
+	}
+
+	combined, err := deriveFileKey("secret", salt, keyFile)
+	if err != nil {
+		t.Fatalf("deriveFileKey() error = %v", err)
+	}
+	if len(combined) != argon2KeyLen || bytes.Equal(combined, plain) {
+		t.Errorf("combined key = %x, want a %d-byte key differing from the password key", combined, argon2KeyLen)
+	}
+	other, _ := deriveFileKey("secret", salt, bytes.Repeat([]byte{3}, keyFileLen))
+	if bytes.Equal(combined, other) {
+		t.Error("different key files derived the same key")
+	}
+}
+
+func TestKeyFileRecovery(t *testing.T) {
+	keyFile := bytes.Repeat([]byte{7}, keyFileLen)
+	code, sealed, err := newKeyFileRecovery(keyFile)
+	if err != nil {
+		t.Fatalf("newKeyFileRecovery() error = %v", err)
+	}
+
+	typed := strings.ToLower(strings.ReplaceAll(code, "-", " "))
+	got, err := openKeyFileRecovery(sealed, typed)
+	if err != nil || !bytes.Equal(got, keyFile) {
+		t.Fatalf("openKeyFileRecovery(%q) = %x, %v; want the key file", typed, got, err)
+	}
+	if !keyFileMatches(got, keyFileHash(keyFile)) || keyFileMatches(got, "") {
+		t.Error("keyFileMatches() should only match the key file's hash")
+	}
+
+	wrong, _, _ := newKeyFileRecovery(keyFile)
+	if _, err := openKeyFileRecovery(sealed, wrong); err == nil {
+		t.Error("openKeyFileRecovery() with another code should fail")
+	}
+	if _, err := openKeyFileRecovery(sealed, "not a code"); err == nil {
+		t.Error("openKeyFileRecovery() with a malformed code should fail")
+	}
+}
+
+func TestReadKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty")
+	os.WriteFile(empty, nil, 0600)
+	if _, err := readKeyFile(empty); err == nil {
+		t.Error("readKeyFile() of an empty file should fail")
+	}
+	if _, err := readKeyFile(dir); err == nil {
+		t.Error("readKeyFile() of a directory should fail")
+	}
+	if _, err := readKeyFile(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Errorf("readKeyFile() of a missing file error = %v, want not exist", err)
+	}
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log"
+)
+
+// passwordRecoveryInfo is the HKDF info the recovery key's cipher key is
+// derived with
+const passwordRecoveryInfo = "ng-drive password recovery"
+
+// HasRecoveryKey returns whether a recovery key can unlock the app in place
+// of the password. Available before unlock.
+func (a *AuthService) HasRecoveryKey(ctx context.Context) bool {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	return a.authData != nil && a.authData.Enabled && a.authData.PasswordRecovery != ""
+}
+
+// UnlockWithRecoveryKey unlocks with the recovery key shown when the
+// password was set up, for when the password is forgotten. It also stands
+// in for the key file. Set a new password with RegeneratePassword next. A
+// wrong key counts as a failed attempt.
+func (a *AuthService) UnlockWithRecoveryKey(ctx context.Context, recoveryKey string) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.authData == nil || !a.authData.Enabled {
+		return fmt.Errorf("auth not enabled")
+	}
+	if a.unlocked {
+		return nil
+	}
+	if a.authData.PasswordRecovery == "" {
+		return fmt.Errorf("no recovery key is set up")
+	}
+	if unlocked, err := a.throttleLocked(); unlocked || err != nil {
+		return err
+	}
+
+	key, keyFileData, err := openPasswordRecovery(a.authData.PasswordRecovery, recoveryKey)
+	if err != nil {
+		a.recordFailedAttempt()
+		return err
+	}
+	if a.authData.KeyFileHash != "" && !keyFileMatches(keyFileData, a.authData.KeyFileHash) {
+		zeroBytes(key)
+		zeroBytes(keyFileData)
+		return fmt.Errorf("the recovery key is out of date")
+	}
+
+	a.authData.FailedAttempts = 0
+	a.authData.LockoutUntil = ""
+	a.saveAuthData()
+
+	if err := a.openLocked(ctx, key, keyFileData); err != nil {
+		zeroBytes(key)
+		zeroBytes(keyFileData)
+		return err
+	}
+	return nil
+}
+
+// RegeneratePassword sets a new password, proven with the recovery key in
+// place of the current one, e.g. after UnlockWithRecoveryKey. The files are
+// re-encrypted like ChangePassword does, keeping the key file if any.
+// Returns a new recovery key, only shown here; the used one stops working.
+func (a *AuthService) RegeneratePassword(ctx context.Context, recoveryKey, newPassword string) (string, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.authData == nil || !a.authData.Enabled {
+		return "", fmt.Errorf("auth not enabled")
+	}
+	if !a.unlocked {
+		return "", fmt.Errorf("app must be unlocked to change password")
+	}
+	if a.authData.PasswordRecovery == "" {
+		return "", fmt.Errorf("no recovery key is set up")
+	}
+	key, keyFileData, err := openPasswordRecovery(a.authData.PasswordRecovery, recoveryKey)
+	if err != nil {
+		return "", err
+	}
+	zeroBytes(key)
+	zeroBytes(keyFileData)
+
+	if len(newPassword) < 4 {
+		return "", fmt.Errorf("new password must be at least 4 characters")
+	}
+
+	newSalt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(newSalt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	newKey, err := deriveFileKey(newPassword, newSalt, a.keyFile)
+	if err != nil {
+		return "", err
+	}
+	newHash := encodePasswordHash(newPassword, newSalt)
+	newRecoveryKey, setRecovery, err := newPasswordRecovery(newKey, a.keyFile)
+	if err != nil {
+		zeroBytes(newKey)
+		return "", err
+	}
+
+	if err := a.rekeyLocked(newKey, func(d *AuthData) {
+		d.PasswordHash = newHash
+		setRecovery(d)
+	}); err != nil {
+		return "", err
+	}
+	a.resealKeychainKeyLocked()
+
+	log.Printf("AuthService: Password reset with the recovery key")
+	return newRecoveryKey, nil
+}
+
+// RegenerateRecoveryKey replaces the recovery key, e.g. when it was lost or
+// the password was set up before recovery keys existed. Returns the new
+// key, only shown here; the previous one stops working.
+func (a *AuthService) RegenerateRecoveryKey(ctx context.Context, password string) (string, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if _, err := a.verifyUnlockedLocked(password); err != nil {
+		return "", err
+	}
+	recoveryKey, setRecovery, err := newPasswordRecovery(a.encKey, a.keyFile)
+	if err != nil {
+		return "", err
+	}
+
+	previous := *a.authData
+	setRecovery(a.authData)
+	if err := a.saveAuthData(); err != nil {
+		*a.authData = previous
+		return "", fmt.Errorf("failed to save auth data: %w", err)
+	}
+	log.Printf("AuthService: Recovery key regenerated")
+	return recoveryKey, nil
+}
+
+// newPasswordRecovery generates a recovery key and seals the file key and
+// key file (nil without one) with it. Returns the key formatted for display,
+// and a function storing what was sealed in the auth data.
+func newPasswordRecovery(fileKey, keyFile []byte) (string, func(*AuthData), error) {
+	code, display, err := newRecoveryCode()
+	if err != nil {
+		return "", nil, err
+	}
+	defer zeroBytes(code)
+	wrapKey, err := passwordRecoveryCipherKey(code)
+	if err != nil {
+		return "", nil, err
+	}
+	defer zeroBytes(wrapKey)
+	setRecovery, err := sealPasswordRecovery(wrapKey, fileKey, keyFile)
+	if err != nil {
+		return "", nil, err
+	}
+	return display, setRecovery, nil
+}
+
+// sealPasswordRecovery seals the file key, followed by the key file if any,
+// with the recovery key's cipher key, and that cipher key with the file key
+// so it can be sealed again after rekeying
+func sealPasswordRecovery(wrapKey, fileKey, keyFile []byte) (func(*AuthData), error) {
+	payload := append(append([]byte(nil), fileKey...), keyFile...)
+	defer zeroBytes(payload)
+	sealed, err := EncryptData(payload, wrapKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to seal the recovery data: %w", err)
+	}
+	sealedKey, err := EncryptData(wrapKey, fileKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to seal the recovery data: %w", err)
+	}
+	recovery := base64.StdEncoding.EncodeToString(sealed)
+	recoveryKey := base64.StdEncoding.EncodeToString(sealedKey)
+	return func(d *AuthData) {
+		d.PasswordRecovery = recovery
+		d.PasswordRecoveryKey = recoveryKey
+	}, nil
+}
+
+// resealPasswordRecoveryLocked prepares sealing the recovery data again for
+// a new file key and key file, so the recovery key keeps working once the
+// files are rekeyed. The returned function does nothing without a recovery
+// key, and removes it if it can't be sealed again (caller must hold lock).
+func (a *AuthService) resealPasswordRecoveryLocked(newKey, keyFile []byte) (func(*AuthData), error) {
+	if a.authData.PasswordRecoveryKey == "" {
+		return func(*AuthData) {}, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(a.authData.PasswordRecoveryKey)
+	var wrapKey []byte
+	if err == nil {
+		wrapKey, err = DecryptData(data, a.encKey)
+	}
+	if err != nil {
+		log.Printf("AuthService: Recovery key removed, failed to update it: %v", err)
+		return func(d *AuthData) {
+			d.PasswordRecovery = ""
+			d.PasswordRecoveryKey = ""
+		}, nil
+	}
+	defer zeroBytes(wrapKey)
+	return sealPasswordRecovery(wrapKey, newKey, keyFile)
+}
+
+// openPasswordRecovery opens the recovery data with a recovery key,
+// returning the file key and the key file (nil without one)
+func openPasswordRecovery(sealed, recoveryKey string) ([]byte, []byte, error) {
+	code, err := parseRecoveryCode(recoveryKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("incorrect recovery key")
+	}
+	defer zeroBytes(code)
+	wrapKey, err := passwordRecoveryCipherKey(code)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer zeroBytes(wrapKey)
+	key, keyFileData, err := openUnlockKey(sealed, wrapKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("incorrect recovery key")
+	}
+	return key, keyFileData, nil
+}
+
+// passwordRecoveryCipherKey derives the key the recovery data is sealed
+// with from a recovery key. Recovery keys are random, so no password
+// hashing is needed.
+func passwordRecoveryCipherKey(code []byte) ([]byte, error) {
+	key, err := hkdf.Key(sha256.New, code, nil, passwordRecoveryInfo, argon2KeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive recovery key: %w", err)
+	}
+	return key, nil
+}
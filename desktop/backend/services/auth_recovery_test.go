@@ -0,0 +1,46 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPasswordRecovery(t *testing.T) {
+	fileKey := bytes.Repeat([]byte{1}, argon2KeyLen)
+	keyFile := bytes.Repeat([]byte{2}, keyFileLen)
+	code, setRecovery, err := newPasswordRecovery(fileKey, keyFile)
+	if err != nil {
+		t.Fatalf("newPasswordRecovery() error = %v", err)
+	}
+	data := &AuthData{}
+	setRecovery(data)
+
+	key, gotKeyFile, err := openPasswordRecovery(data.PasswordRecovery, code)
+	if err != nil || !bytes.Equal(key, fileKey) || !bytes.Equal(gotKeyFile, keyFile) {
+		t.Fatalf("openPasswordRecovery() = %x, %x, %v; want the file key and key file", key, gotKeyFile, err)
+	}
+	wrong, _, _ := newPasswordRecovery(fileKey, keyFile)
+	if _, _, err := openPasswordRecovery(data.PasswordRecovery, wrong); err == nil {
+		t.Error("openPasswordRecovery() with another key should fail")
+	}
+
+	// Rekeying seals the same recovery key for the new file key
+	a := &AuthService{authData: data, encKey: fileKey}
+	newKey := bytes.Repeat([]byte{3}, argon2KeyLen)
+	reseal, err := a.resealPasswordRecoveryLocked(newKey, nil)
+	if err != nil {
+		t.Fatalf("resealPasswordRecoveryLocked() error = %v", err)
+	}
+	reseal(data)
+	key, gotKeyFile, err = openPasswordRecovery(data.PasswordRecovery, code)
+	if err != nil || !bytes.Equal(key, newKey) || gotKeyFile != nil {
+		t.Fatalf("after reseal openPasswordRecovery() = %x, %x, %v; want the new key only", key, gotKeyFile, err)
+	}
+
+	// Data sealed for another file key can't be resealed, so it's dropped
+	reseal, _ = a.resealPasswordRecoveryLocked(fileKey, nil)
+	reseal(data)
+	if data.PasswordRecovery != "" || data.PasswordRecoveryKey != "" {
+		t.Error("expected the recovery data removed when it can't be resealed")
+	}
+}
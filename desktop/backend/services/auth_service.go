@@ -25,8 +25,9 @@ import (
 
 // Auth event types
 const (
-	AuthLocked   events.EventType = "auth:locked"
-	AuthUnlocked events.EventType = "auth:unlocked"
+	AuthLocked          events.EventType = "auth:locked"
+	AuthUnlocked        events.EventType = "auth:unlocked"
+	AuthUnlockRequested events.EventType = "auth:unlock_requested" // the user opened the app from the tray while locked
 )
 
 // Argon2id parameters
@@ -52,6 +53,18 @@ type AuthData struct {
 	FailedAttempts int         `json:"failed_attempts"`
 	LockoutUntil   string      `json:"lockout_until"`
 	AppSettings    AppSettings `json:"app_settings"`
+
+	// Key file: when set, unlocking also needs the file (see GenerateKeyFile)
+	KeyFileHash     string `json:"key_file_hash,omitempty"`     // SHA-256 of the key file, hex
+	KeyFileRecovery string `json:"key_file_recovery,omitempty"` // the key file sealed with the recovery code, base64
+
+	// Keychain unlock: when set, the app unlocks without the password on
+	// this machine (see EnableKeychainUnlock)
+	KeychainKey string `json:"keychain_key,omitempty"` // the file key and key file sealed with the key in the OS keychain, base64
+
+	// Recovery key: unlocks in place of the password (see UnlockWithRecoveryKey)
+	PasswordRecovery    string `json:"password_recovery,omitempty"`     // the file key and key file sealed with the recovery key, base64
+	PasswordRecoveryKey string `json:"password_recovery_key,omitempty"` // the recovery key's cipher key sealed with the file key, base64, to reseal after rekeying
 }
 
 // LockoutStatus represents the current rate limit state
@@ -69,14 +82,18 @@ type AuthEvent struct {
 
 // AuthService manages password-based unlock, encryption, and rate limiting
 type AuthService struct {
-	app                 *application.App
-	appService          interface{ CompleteInitialization(context.Context) error }
-	notificationService *NotificationService
-	mutex               sync.RWMutex
-	unlocked            bool
-	encKey              []byte // derived encryption key, zeroed on lock
-	authData            *AuthData
-	authFilePath        string
+	app              *application.App
+	appService       interface{ CompleteInitialization(context.Context) error }
+	settingsService  *SettingsService
+	schedulerService *SchedulerService
+	syncService      *SyncService
+	mountService     *MountService
+	mutex            sync.RWMutex
+	unlocked         bool
+	encKey           []byte // derived encryption key, zeroed on lock
+	keyFile          []byte // contents of the key file while unlocked, zeroed on lock; nil without one
+	authData         *AuthData
+	authFilePath     string
 }
 
 // NewAuthService creates a new AuthService
@@ -96,9 +113,35 @@ func (a *AuthService) SetAppService(appService interface{ CompleteInitialization
 	a.appService = appService
 }
 
-// SetNotificationService sets the notification service reference
-func (a *AuthService) SetNotificationService(ns *NotificationService) {
-	a.notificationService = ns
+// SetSettingsService sets the settings service and mirrors tray/startup
+// settings to auth.json whenever they change, since they are needed before
+// unlock on the next start
+func (a *AuthService) SetSettingsService(ss *SettingsService) {
+	a.settingsService = ss
+	ss.Subscribe(func(change SettingsChange) {
+		// Loads run under a.mutex during unlock, and only read back what was saved
+		if !change.Loaded {
+			a.SyncAppSettings(change.New)
+		}
+	})
+}
+
+// SetSchedulerService sets the scheduler that starts, and catches up on
+// missed runs, once the app is unlocked
+func (a *AuthService) SetSchedulerService(ss *SchedulerService) {
+	a.schedulerService = ss
+}
+
+// SetSyncService sets the sync service that recovers runs the app last
+// exited during once the database is open
+func (a *AuthService) SetSyncService(ss *SyncService) {
+	a.syncService = ss
+}
+
+// SetMountService sets the mount service whose mounts are unmounted when
+// the app locks, as they can't be read without the config
+func (a *AuthService) SetMountService(ms *MountService) {
+	a.mountService = ms
 }
 
 // ServiceName returns the service name
@@ -125,9 +168,6 @@ func (a *AuthService) ServiceStartup(ctx context.Context, options application.Se
 	}
 	a.authData = authData
 
-	// Register settings syncer so tray/startup settings get saved to auth.json
-	SetAuthSettingsSyncer(a.SyncAppSettings)
-
 	// Crash recovery: clean up inconsistent state from interrupted encrypt/decrypt
 	a.recoverFromCrash(cfg)
 
@@ -139,8 +179,13 @@ func (a *AuthService) ServiceStartup(ctx context.Context, options application.Se
 		a.unlocked = true
 		a.emitAuthEvent(AuthUnlocked)
 		log.Printf("AuthService: No auth configured, app unlocked")
+	} else if err := a.unlockWithKeychain(ctx); err == nil {
+		log.Printf("AuthService: Unlocked with the OS keychain")
 	} else {
 		// Auth enabled - wait for unlock
+		if authData.KeychainKey != "" {
+			log.Printf("AuthService: Keychain unlock failed, falling back to the password: %v", err)
+		}
 		a.unlocked = false
 		a.emitAuthEvent(AuthLocked)
 		log.Printf("AuthService: Auth enabled, waiting for unlock")
@@ -153,14 +198,19 @@ func (a *AuthService) ServiceStartup(ctx context.Context, options application.Se
 // If auth is enabled, re-encrypt files and zero the key.
 func (a *AuthService) ServiceShutdown(ctx context.Context) error {
 	log.Printf("AuthService shutting down...")
+	a.lockOnExit()
+	return nil
+}
+
+// lockOnExit re-encrypts files and zeroes the key if auth is enabled and the
+// app is unlocked. ShutdownService calls it once nothing writes to the files.
+func (a *AuthService) lockOnExit() {
 	a.mutex.Lock()
 	defer a.mutex.Unlock()
 
 	if a.authData != nil && a.authData.Enabled && a.unlocked {
 		a.lockInternal()
 	}
-
-	return nil
 }
 
 // IsAuthEnabled returns whether password auth is configured
@@ -219,32 +269,39 @@ func (a *AuthService) GetPreUnlockSettings() AppSettings {
 }
 
 // SetupPassword sets up password authentication for the first time.
-// Encrypts all sensitive files and creates auth.json.
-func (a *AuthService) SetupPassword(ctx context.Context, password string) error {
+// Encrypts all sensitive files and creates auth.json. Returns a recovery key
+// that unlocks in place of the password (see UnlockWithRecoveryKey); it is
+// only shown here.
+func (a *AuthService) SetupPassword(ctx context.Context, password string) (string, error) {
 	a.mutex.Lock()
 	defer a.mutex.Unlock()
 
 	if a.authData != nil && a.authData.Enabled {
-		return fmt.Errorf("password already configured, use ChangePassword instead")
+		return "", fmt.Errorf("password already configured, use ChangePassword instead")
 	}
 
 	if len(password) < 4 {
-		return fmt.Errorf("password must be at least 4 characters")
+		return "", fmt.Errorf("password must be at least 4 characters")
 	}
 
 	// Generate salt and derive key
 	salt := make([]byte, argon2SaltLen)
 	if _, err := rand.Read(salt); err != nil {
-		return fmt.Errorf("failed to generate salt: %w", err)
+		return "", fmt.Errorf("failed to generate salt: %w", err)
 	}
 
 	key := deriveKey(password, salt)
 	hash := encodePasswordHash(password, salt)
+	recoveryKey, setRecovery, err := newPasswordRecovery(key, nil)
+	if err != nil {
+		zeroBytes(key)
+		return "", err
+	}
 
 	// Get current app settings from notification service (if DB is available)
 	var appSettings AppSettings
-	if a.notificationService != nil {
-		appSettings = a.notificationService.GetSettings(ctx)
+	if a.settingsService != nil {
+		appSettings = a.settingsService.GetSettings(ctx).preUnlock()
 	}
 
 	// NOTE: We do NOT encrypt files here. The DB connection is still open and
@@ -264,17 +321,26 @@ func (a *AuthService) SetupPassword(ctx context.Context, password string) error
 		LockoutUntil:   "",
 		AppSettings:    appSettings,
 	}
+	setRecovery(a.authData)
 	if err := a.saveAuthData(); err != nil {
-		return fmt.Errorf("failed to save auth data: %w", err)
+		return "", fmt.Errorf("failed to save auth data: %w", err)
 	}
 
 	a.encKey = key
 	log.Printf("AuthService: Password set up successfully")
-	return nil
+	return recoveryKey, nil
 }
 
-// Unlock verifies the password and decrypts all files
+// Unlock verifies the password and decrypts all files. An app set up with
+// a key file is unlocked with UnlockWithKeyFile instead.
 func (a *AuthService) Unlock(ctx context.Context, password string) error {
+	return a.unlock(ctx, password, nil)
+}
+
+// unlock verifies the password and, with a key file set up, the key file
+// that keyFile returns, then decrypts all files. Wrong key files count as
+// failed attempts like wrong passwords.
+func (a *AuthService) unlock(ctx context.Context, password string, keyFile func(*AuthData) ([]byte, error)) error {
 	a.mutex.Lock()
 	defer a.mutex.Unlock()
 
@@ -286,12 +352,63 @@ func (a *AuthService) Unlock(ctx context.Context, password string) error {
 		return nil // Already unlocked
 	}
 
+	if a.authData.KeyFileHash == "" && keyFile != nil {
+		return fmt.Errorf("no key file is set up")
+	}
+	if a.authData.KeyFileHash != "" && keyFile == nil {
+		return fmt.Errorf("a key file is required to unlock")
+	}
+
+	if unlocked, err := a.throttleLocked(); unlocked || err != nil {
+		return err
+	}
+
+	// Verify password
+	if !verifyPasswordHash(password, a.authData.PasswordHash) {
+		a.recordFailedAttempt()
+		return fmt.Errorf("incorrect password")
+	}
+
+	// Verify key file
+	var keyFileData []byte
+	if keyFile != nil {
+		data, err := keyFile(a.authData)
+		if err != nil {
+			a.recordFailedAttempt()
+			return err
+		}
+		keyFileData = data
+	}
+
+	// Password correct - derive key and decrypt
+	salt, err := extractSalt(a.authData.PasswordHash)
+	if err != nil {
+		return fmt.Errorf("failed to extract salt: %w", err)
+	}
+
+	key, err := deriveFileKey(password, salt, keyFileData)
+	if err != nil {
+		return err
+	}
+
+	// Reset failed attempts
+	a.authData.FailedAttempts = 0
+	a.authData.LockoutUntil = ""
+	a.saveAuthData()
+
+	return a.openLocked(ctx, key, keyFileData)
+}
+
+// throttleLocked enforces the lockout and the delay after failed attempts
+// before an unlock attempt. Returns true if the app was unlocked meanwhile
+// (caller must hold lock).
+func (a *AuthService) throttleLocked() (bool, error) {
 	// Check lockout
 	if a.authData.LockoutUntil != "" {
 		lockoutTime, err := time.Parse(time.RFC3339, a.authData.LockoutUntil)
 		if err == nil && time.Now().Before(lockoutTime) {
 			remaining := int(math.Ceil(time.Until(lockoutTime).Seconds()))
-			return fmt.Errorf("account locked, try again in %d seconds", remaining)
+			return false, fmt.Errorf("account locked, try again in %d seconds", remaining)
 		}
 		// Lockout expired, clear it
 		a.authData.LockoutUntil = ""
@@ -306,42 +423,20 @@ func (a *AuthService) Unlock(ctx context.Context, password string) error {
 		a.mutex.Lock()
 		// Re-check state after reacquiring lock (another goroutine may have unlocked)
 		if a.unlocked {
-			return nil
+			return true, nil
 		}
 	}
+	return false, nil
+}
 
-	// Verify password
-	if !verifyPasswordHash(password, a.authData.PasswordHash) {
-		a.authData.FailedAttempts++
-
-		if a.authData.FailedAttempts >= maxAttemptsBeforeLock {
-			a.authData.LockoutUntil = time.Now().Add(lockoutDuration).Format(time.RFC3339)
-			a.authData.FailedAttempts = 0
-			log.Printf("AuthService: Too many failed attempts, locked for %v", lockoutDuration)
-		}
-
-		a.saveAuthData()
-		return fmt.Errorf("incorrect password")
-	}
-
-	// Password correct - derive key and decrypt
-	salt, err := extractSalt(a.authData.PasswordHash)
-	if err != nil {
-		return fmt.Errorf("failed to extract salt: %w", err)
-	}
-
-	key := deriveKey(password, salt)
+// openLocked decrypts all files with key and initializes the app, leaving
+// it unlocked with key and keyFileData (caller must hold lock)
+func (a *AuthService) openLocked(ctx context.Context, key, keyFileData []byte) error {
 	cfg := GetSharedConfig()
-
 	if err := a.decryptConfigFiles(cfg, key); err != nil {
 		return fmt.Errorf("failed to decrypt files: %w", err)
 	}
 
-	// Reset failed attempts
-	a.authData.FailedAttempts = 0
-	a.authData.LockoutUntil = ""
-	a.saveAuthData()
-
 	// Initialize the app (DB, rclone, etc.)
 	if err := a.initializeApp(ctx); err != nil {
 		// Re-encrypt on failure to leave files in secure state
@@ -350,13 +445,44 @@ func (a *AuthService) Unlock(ctx context.Context, password string) error {
 	}
 
 	a.encKey = key
+	a.keyFile = keyFileData
 	a.unlocked = true
 	a.emitAuthEvent(AuthUnlocked)
 
+	// Start schedules that waited for unlock; in start-locked-hidden mode,
+	// runs missed while locked go first
+	if a.schedulerService != nil {
+		go a.schedulerService.wakeAfterUnlock(a.authData.AppSettings.StartLockedHidden)
+	}
+
 	log.Printf("AuthService: Unlocked successfully")
 	return nil
 }
 
+// recordFailedAttempt counts a failed unlock, and locks unlocking out after
+// too many (caller must hold lock)
+func (a *AuthService) recordFailedAttempt() {
+	a.authData.FailedAttempts++
+
+	if a.authData.FailedAttempts >= maxAttemptsBeforeLock {
+		a.authData.LockoutUntil = time.Now().Add(lockoutDuration).Format(time.RFC3339)
+		a.authData.FailedAttempts = 0
+		log.Printf("AuthService: Too many failed attempts, locked for %v", lockoutDuration)
+	}
+
+	a.saveAuthData()
+}
+
+// RequestUnlock asks the frontend to show the unlock prompt, e.g. when the
+// app was started locked and hidden and the user opens it from the tray.
+// Does nothing if the app is already unlocked.
+func (a *AuthService) RequestUnlock(ctx context.Context) {
+	if a.IsUnlocked(ctx) {
+		return
+	}
+	a.emitAuthEvent(AuthUnlockRequested)
+}
+
 // Lock re-encrypts all files and clears the key
 func (a *AuthService) Lock(ctx context.Context) error {
 	a.mutex.Lock()
@@ -366,6 +492,11 @@ func (a *AuthService) Lock(ctx context.Context) error {
 		return nil // Already locked
 	}
 
+	// Mounts would keep the remotes readable while locked
+	if a.mountService != nil {
+		a.mountService.unmountAll("the app locked")
+	}
+
 	a.lockInternal()
 	a.emitAuthEvent(AuthLocked)
 
@@ -401,25 +532,46 @@ func (a *AuthService) ChangePassword(ctx context.Context, oldPassword, newPasswo
 		return fmt.Errorf("failed to generate salt: %w", err)
 	}
 
-	newKey := deriveKey(newPassword, newSalt)
+	newKey, err := deriveFileKey(newPassword, newSalt, a.keyFile)
+	if err != nil {
+		return err
+	}
 	newHash := encodePasswordHash(newPassword, newSalt)
+	resealRecovery, err := a.resealPasswordRecoveryLocked(newKey, a.keyFile)
+	if err != nil {
+		return err
+	}
+
+	if err := a.rekeyLocked(newKey, func(d *AuthData) {
+		d.PasswordHash = newHash
+		resealRecovery(d)
+	}); err != nil {
+		return err
+	}
+	a.resealKeychainKeyLocked()
 
+	log.Printf("AuthService: Password changed successfully")
+	return nil
+}
+
+// rekeyLocked re-encrypts the files with newKey and saves the auth data as
+// changed by update, then reopens the database. If a step fails it restores
+// a working state where it can, and otherwise locks the app so the user
+// re-unlocks with the new credentials. The caller holds a.mutex.
+func (a *AuthService) rekeyLocked(newKey []byte, update func(*AuthData)) error {
 	// Close DB before re-encrypting
 	CloseDatabase()
 	ResetSharedDB()
 
 	cfg := GetSharedConfig()
-	oldHash := a.authData.PasswordHash
+	previous := *a.authData
 
 	// Encrypt with new key
 	if err := a.encryptConfigFiles(cfg, newKey); err != nil {
 		// Try to recover: decrypt with new key (files that were encrypted) and reopen DB
 		if decErr := a.decryptConfigFiles(cfg, newKey); decErr != nil {
-			// Recovery failed - files are in mixed state. Lock app so user re-unlocks with new password.
-			zeroBytes(a.encKey)
-			a.encKey = nil
-			a.unlocked = false
-			a.emitAuthEvent(AuthLocked)
+			// Recovery failed - files are in mixed state. Lock app so user re-unlocks with new credentials.
+			a.lockAfterFailedRekey()
 			return fmt.Errorf("failed to re-encrypt files and recovery failed, please restart and re-unlock: %w", err)
 		}
 		InitDatabase()
@@ -427,20 +579,17 @@ func (a *AuthService) ChangePassword(ctx context.Context, oldPassword, newPasswo
 	}
 
 	// Update auth data
-	a.authData.PasswordHash = newHash
+	update(a.authData)
 	if err := a.saveAuthData(); err != nil {
-		// Revert hash on failure
-		a.authData.PasswordHash = oldHash
+		// Revert auth data on failure
+		*a.authData = previous
 		if decErr := a.decryptConfigFiles(cfg, newKey); decErr != nil {
-			// Can't decrypt back — files encrypted with newKey, auth.json has oldHash.
-			// Force new hash into auth.json so user can re-unlock with new password.
-			a.authData.PasswordHash = newHash
+			// Can't decrypt back — files encrypted with newKey, auth.json has the old credentials.
+			// Force the new ones into auth.json so user can re-unlock with them.
+			update(a.authData)
 			a.saveAuthData() // best-effort
-			zeroBytes(a.encKey)
-			a.encKey = nil
-			a.unlocked = false
-			a.emitAuthEvent(AuthLocked)
-			return fmt.Errorf("failed to save auth data and recovery failed, please re-unlock with new password: %w", err)
+			a.lockAfterFailedRekey()
+			return fmt.Errorf("failed to save auth data and recovery failed, please re-unlock with the new credentials: %w", err)
 		}
 		InitDatabase()
 		return fmt.Errorf("failed to save auth data: %w", err)
@@ -448,37 +597,40 @@ func (a *AuthService) ChangePassword(ctx context.Context, oldPassword, newPasswo
 
 	// Decrypt with new key to restore working state
 	if err := a.decryptConfigFiles(cfg, newKey); err != nil {
-		// Files are encrypted with new key and auth.json has new hash.
-		// Mark as locked so user must re-unlock with new password.
-		zeroBytes(a.encKey)
-		a.encKey = nil
-		a.unlocked = false
-		a.emitAuthEvent(AuthLocked)
-		return fmt.Errorf("failed to decrypt files after password change, please re-unlock: %w", err)
+		// Files are encrypted with new key and auth.json has the new credentials.
+		// Mark as locked so user must re-unlock with them.
+		a.lockAfterFailedRekey()
+		return fmt.Errorf("failed to decrypt files after re-encrypting them, please re-unlock: %w", err)
 	}
 
 	// Re-init DB
 	if err := InitDatabase(); err != nil {
-		// DB init failed but files are decrypted and auth.json has new hash.
+		// DB init failed but files are decrypted and auth.json has the new credentials.
 		// Mark as locked so user must re-unlock to get a clean state.
-		zeroBytes(a.encKey)
-		a.encKey = nil
-		a.unlocked = false
-		a.emitAuthEvent(AuthLocked)
-		return fmt.Errorf("failed to re-initialize database after password change, please re-unlock: %w", err)
+		a.lockAfterFailedRekey()
+		return fmt.Errorf("failed to re-initialize database after re-encrypting files, please re-unlock: %w", err)
 	}
-	if a.notificationService != nil {
-		a.notificationService.LoadSettings()
+	if a.settingsService != nil {
+		a.settingsService.LoadSettings()
 	}
 
 	// Zero old key, set new
 	zeroBytes(a.encKey)
 	a.encKey = newKey
-
-	log.Printf("AuthService: Password changed successfully")
 	return nil
 }
 
+// lockAfterFailedRekey marks the app locked after rekeyLocked couldn't
+// restore a working state
+func (a *AuthService) lockAfterFailedRekey() {
+	zeroBytes(a.encKey)
+	a.encKey = nil
+	zeroBytes(a.keyFile)
+	a.keyFile = nil
+	a.unlocked = false
+	a.emitAuthEvent(AuthLocked)
+}
+
 // RemovePassword removes password protection and decrypts files permanently
 func (a *AuthService) RemovePassword(ctx context.Context, password string) error {
 	a.mutex.Lock()
@@ -501,12 +653,19 @@ func (a *AuthService) RemovePassword(ctx context.Context, password string) error
 	cfg := GetSharedConfig()
 	a.cleanupEncryptedFiles(cfg)
 
-	// Remove auth.json
+	// Remove auth.json, and the keychain entry with it
+	if a.authData.KeychainKey != "" {
+		if err := keychainDelete(a.keychainAccount()); err != nil {
+			log.Printf("AuthService: Failed to remove the keychain entry: %v", err)
+		}
+	}
 	os.Remove(a.authFilePath)
 
 	// Zero key
 	zeroBytes(a.encKey)
 	a.encKey = nil
+	zeroBytes(a.keyFile)
+	a.keyFile = nil
 
 	a.authData = &AuthData{Enabled: false}
 	a.unlocked = true
@@ -524,7 +683,7 @@ func (a *AuthService) SyncAppSettings(settings AppSettings) {
 		return
 	}
 
-	a.authData.AppSettings = settings
+	a.authData.AppSettings = settings.preUnlock()
 	a.saveAuthData()
 }
 
@@ -578,8 +737,8 @@ func (a *AuthService) initializeApp(ctx context.Context) error {
 	}
 
 	// Load settings from DB
-	if a.notificationService != nil {
-		a.notificationService.LoadSettings()
+	if a.settingsService != nil {
+		a.settingsService.LoadSettings()
 	}
 
 	// Complete App service initialization (rclone config, etc.)
@@ -589,6 +748,11 @@ func (a *AuthService) initializeApp(ctx context.Context) error {
 		}
 	}
 
+	// Record runs the app last exited during, and resume those their profiles ask to
+	if a.syncService != nil {
+		go a.syncService.recoverInterruptedRuns()
+	}
+
 	return nil
 }
 
@@ -612,6 +776,8 @@ func (a *AuthService) lockInternal() {
 	// Zero key
 	zeroBytes(a.encKey)
 	a.encKey = nil
+	zeroBytes(a.keyFile)
+	a.keyFile = nil
 	a.unlocked = false
 }
 
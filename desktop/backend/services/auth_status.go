@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/dto"
+	"time"
+)
+
+// LockScreenStatus is the progress of the app's runs that can be shown
+// while it is locked. It carries no profile, remote or file names.
+type LockScreenStatus struct {
+	Running    bool    `json:"running"`               // a run is in progress
+	Runs       int     `json:"runs"`                  // runs in progress
+	Queued     int     `json:"queued"`                // runs waiting for a slot or paused
+	Progress   float64 `json:"progress"`              // 0-100, across the runs in progress
+	ETASeconds int64   `json:"eta_seconds,omitempty"` // until the last run in progress finishes; 0 = unknown
+}
+
+// GetLockScreenStatus returns whether anything is running, how far along it
+// is and when it should finish. Unlike the other services it works while the
+// app is locked, so the tray and the lock screen can show progress of the
+// runs that carry on.
+func (a *AuthService) GetLockScreenStatus(ctx context.Context) LockScreenStatus {
+	if a.syncService == nil {
+		return LockScreenStatus{}
+	}
+	running, queued := a.syncService.runStatuses()
+	status := lockScreenStatus(running)
+	status.Queued = queued
+	return status
+}
+
+// runStatuses returns the latest counters of the tasks in progress (nil for
+// those that haven't reported yet) and how many tasks wait to run
+func (s *SyncService) runStatuses() ([]*dto.SyncStatusDTO, int) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var running []*dto.SyncStatusDTO
+	queued := 0
+	for _, task := range s.activeTasks {
+		switch task.Status {
+		case "starting", "running":
+			running = append(running, task.latestStatus())
+		case "queued", "paused":
+			queued++
+		}
+	}
+	return running, queued
+}
+
+// lockScreenStatus combines the counters of the runs in progress. Progress
+// is weighted by bytes when every run knows its total, and averaged
+// otherwise; the ETA is the longest one, as runs go on side by side.
+func lockScreenStatus(running []*dto.SyncStatusDTO) LockScreenStatus {
+	status := LockScreenStatus{Running: len(running) > 0, Runs: len(running)}
+	if len(running) == 0 {
+		return status
+	}
+
+	var bytes, totalBytes int64
+	var progress float64
+	byBytes := true
+	for _, st := range running {
+		if st == nil {
+			byBytes = false
+			continue
+		}
+		progress += st.Progress
+		if st.TotalBytes <= 0 {
+			byBytes = false
+		}
+		bytes += st.BytesTransferred
+		totalBytes += st.TotalBytes
+
+		// ETA is rclone's remaining time, formatted like "1h2m"; "-" before it is known
+		if eta, err := time.ParseDuration(st.ETA); err == nil && int64(eta.Seconds()) > status.ETASeconds {
+			status.ETASeconds = int64(eta.Seconds())
+		}
+	}
+	if byBytes {
+		status.Progress = float64(bytes) / float64(totalBytes) * 100
+	} else {
+		status.Progress = progress / float64(len(running))
+	}
+	return status
+}
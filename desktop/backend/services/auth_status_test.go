@@ -0,0 +1,31 @@
+package services
+
+import (
+	"desktop/backend/dto"
+	"testing"
+)
+
+func TestLockScreenStatus(t *testing.T) {
+	if got := lockScreenStatus(nil); got.Running || got.Runs != 0 {
+		t.Errorf("lockScreenStatus(nil) = %+v, want nothing running", got)
+	}
+
+	got := lockScreenStatus([]*dto.SyncStatusDTO{
+		{Progress: 50, BytesTransferred: 100, TotalBytes: 200, ETA: "2m30s"},
+		{Progress: 10, BytesTransferred: 100, TotalBytes: 800, ETA: "1h2m"},
+	})
+	if !got.Running || got.Runs != 2 {
+		t.Errorf("runs = %v, %d; want true, 2", got.Running, got.Runs)
+	}
+	if got.Progress != 20 {
+		t.Errorf("progress = %v, want 20 (weighted by bytes)", got.Progress)
+	}
+	if got.ETASeconds != 3720 {
+		t.Errorf("eta = %d, want 3720", got.ETASeconds)
+	}
+
+	got = lockScreenStatus([]*dto.SyncStatusDTO{{Progress: 40, ETA: "-"}, nil})
+	if got.Progress != 20 || got.ETASeconds != 0 {
+		t.Errorf("progress, eta = %v, %d; want 20, 0 (averaged, unknown)", got.Progress, got.ETASeconds)
+	}
+}
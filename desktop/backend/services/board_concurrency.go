@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/dto"
+	"desktop/backend/models"
+	"fmt"
+	"time"
+)
+
+// maxBoardOffsetMinutes caps a card's start offset and a board's window: a
+// scheduled run of a board spans at most a day
+const maxBoardOffsetMinutes = 24 * 60
+
+// columnLimit returns how many cards of a column may run at once (0 = unlimited)
+func columnLimit(board *models.Board) int {
+	if board.ExecutionMode == models.BoardModeSequential {
+		return 1
+	}
+	return board.MaxParallel
+}
+
+// edgeRemotes returns the remotes a card reads or writes. Local paths are not
+// included: they don't share a remote's rate limits.
+func edgeRemotes(board *models.Board, edge models.BoardEdge) []string {
+	var remotes []string
+	for _, node := range board.Nodes {
+		if node.Id != edge.SourceId && node.Id != edge.TargetId {
+			continue
+		}
+		if node.RemoteName == "" || node.RemoteName == "local" {
+			continue
+		}
+		if len(remotes) == 1 && remotes[0] == node.RemoteName {
+			continue
+		}
+		remotes = append(remotes, node.RemoteName)
+	}
+	return remotes
+}
+
+// runColumn runs the cards of one column in board order, at most limit at a
+// time (0 = unlimited). Two cards that use the same remote never run at the
+// same time, so a board doesn't compete with itself for a remote's rate limit;
+// the run slots of the sync queue still apply to every card that starts.
+// A card doesn't start before its startAt time (nil or zero = right away),
+// and the cards after it may start first meanwhile.
+// No further cards start once ctx is cancelled; they are left pending.
+func runColumn(ctx context.Context, edges []models.BoardEdge, limit int, remotesOf func(models.BoardEdge) []string, startAt func(models.BoardEdge) time.Time, run func(models.BoardEdge)) {
+	pending := append([]models.BoardEdge(nil), edges...)
+	busy := make(map[string]bool)
+	running := 0
+	done := make(chan []string)
+
+	for {
+		if ctx.Err() != nil {
+			pending = nil
+		}
+		now := time.Now()
+		var wake time.Time // earliest start of the cards not due yet
+		for i := 0; i < len(pending); {
+			if limit > 0 && running >= limit {
+				break
+			}
+			if startAt != nil {
+				if at := startAt(pending[i]); at.After(now) {
+					if wake.IsZero() || at.Before(wake) {
+						wake = at
+					}
+					i++
+					continue
+				}
+			}
+			remotes := remotesOf(pending[i])
+			if anyRemoteBusy(busy, remotes) {
+				i++
+				continue
+			}
+			for _, remote := range remotes {
+				busy[remote] = true
+			}
+			edge := pending[i]
+			pending = append(pending[:i], pending[i+1:]...)
+			running++
+			go func() {
+				run(edge)
+				done <- remotes
+			}()
+		}
+
+		// With nothing running, every remote is free, so the cards left
+		// pending are only waiting for their start time
+		if running == 0 && wake.IsZero() {
+			return
+		}
+		var timer *time.Timer
+		var due <-chan time.Time
+		var cancelled <-chan struct{}
+		if !wake.IsZero() {
+			timer = time.NewTimer(time.Until(wake))
+			due = timer.C
+			cancelled = ctx.Done()
+		}
+		select {
+		case remotes := <-done:
+			for _, remote := range remotes {
+				delete(busy, remote)
+			}
+			running--
+		case <-due:
+		case <-cancelled:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// cardStartTimes returns when the cards of a run started at start may start,
+// for the cards with a start offset. Offsets only apply to scheduled runs.
+func cardStartTimes(board *models.Board, start time.Time, scheduled bool) map[string]time.Time {
+	startAt := make(map[string]time.Time)
+	if !scheduled {
+		return startAt
+	}
+	for _, edge := range board.Edges {
+		if edge.StartOffsetMinutes > 0 {
+			startAt[edge.Id] = start.Add(time.Duration(edge.StartOffsetMinutes) * time.Minute)
+		}
+	}
+	return startAt
+}
+
+// anyRemoteBusy reports whether any of the remotes is in use
+func anyRemoteBusy(busy map[string]bool, remotes []string) bool {
+	for _, remote := range remotes {
+		if busy[remote] {
+			return true
+		}
+	}
+	return false
+}
+
+// GetBoardProgress returns the combined progress of a board's current or last run
+func (b *BoardService) GetBoardProgress(ctx context.Context, boardId string) (*dto.BoardProgressDTO, error) {
+	b.flowMutex.RLock()
+	flow, exists := b.activeFlows[boardId]
+	b.flowMutex.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("no active execution for board '%s'", boardId)
+	}
+	return flow.progress(), nil
+}
+
+// progress combines the card statuses with the counters of their sync tasks
+func (flow *FlowExecution) progress() *dto.BoardProgressDTO {
+	flow.StatusMu.Lock()
+	defer flow.StatusMu.Unlock()
+
+	progress := &dto.BoardProgressDTO{
+		BoardId:    flow.BoardId,
+		Status:     flow.Status.Status,
+		TotalCards: len(flow.Status.EdgeStatuses),
+	}
+	var done float64
+	for _, es := range flow.Status.EdgeStatuses {
+		var status *dto.SyncStatusDTO
+		if task := flow.tasks[es.EdgeId]; task != nil {
+			status = task.latestStatus()
+		}
+		if status != nil {
+			progress.FilesTransferred += status.FilesTransferred
+			progress.TotalFiles += status.TotalFiles
+			progress.BytesTransferred += status.BytesTransferred
+			progress.TotalBytes += status.TotalBytes
+			progress.Errors += status.Errors
+		}
+
+		switch es.Status {
+		case "running":
+			progress.RunningCards++
+			if status != nil {
+				done += status.Progress / 100
+			}
+		case "completed":
+			progress.CompletedCards++
+			done++
+		case "failed":
+			progress.FailedCards++
+			done++
+		case "skipped":
+			progress.SkippedCards++
+			done++
+		default:
+			progress.PendingCards++
+		}
+	}
+	if progress.TotalCards > 0 {
+		progress.Progress = done / float64(progress.TotalCards) * 100
+	}
+	return progress
+}
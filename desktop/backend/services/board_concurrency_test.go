@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/dto"
+	"desktop/backend/models"
+	"desktop/backend/utils"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestColumnLimit(t *testing.T) {
+	board := &models.Board{MaxParallel: 3}
+	if got := columnLimit(board); got != 3 {
+		t.Errorf("parallel limit = %d, want 3", got)
+	}
+	board.ExecutionMode = models.BoardModeSequential
+	if got := columnLimit(board); got != 1 {
+		t.Errorf("sequential limit = %d, want 1", got)
+	}
+}
+
+func TestEdgeRemotes(t *testing.T) {
+	board := &models.Board{
+		Nodes: []models.BoardNode{
+			{Id: "n1", RemoteName: "local", Path: "/data"},
+			{Id: "n2", RemoteName: "gdrive"},
+			{Id: "n3", RemoteName: "gdrive", Path: "backup"},
+		},
+	}
+
+	if got := edgeRemotes(board, models.BoardEdge{SourceId: "n1", TargetId: "n2"}); len(got) != 1 || got[0] != "gdrive" {
+		t.Errorf("local->gdrive remotes = %v, want [gdrive]", got)
+	}
+	if got := edgeRemotes(board, models.BoardEdge{SourceId: "n2", TargetId: "n3"}); len(got) != 1 {
+		t.Errorf("gdrive->gdrive remotes = %v, want one entry", got)
+	}
+}
+
+// runColumnTrace runs a column with sleeping cards and records the most cards
+// running at once, overall and per remote
+func runColumnTrace(ctx context.Context, edges []models.BoardEdge, limit int, remotes map[string][]string) (order []string, maxRunning int, remoteOverlap bool) {
+	var mu sync.Mutex
+	running := 0
+	busy := make(map[string]bool)
+
+	runColumn(ctx, edges, limit,
+		func(e models.BoardEdge) []string { return remotes[e.Id] },
+		nil,
+		func(e models.BoardEdge) {
+			mu.Lock()
+			order = append(order, e.Id)
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			for _, r := range remotes[e.Id] {
+				if busy[r] {
+					remoteOverlap = true
+				}
+				busy[r] = true
+			}
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			for _, r := range remotes[e.Id] {
+				delete(busy, r)
+			}
+			mu.Unlock()
+		})
+	return order, maxRunning, remoteOverlap
+}
+
+func TestRunColumn_Sequential(t *testing.T) {
+	edges := []models.BoardEdge{{Id: "e1"}, {Id: "e2"}, {Id: "e3"}}
+	order, maxRunning, _ := runColumnTrace(context.Background(), edges, 1, nil)
+
+	if maxRunning != 1 {
+		t.Errorf("max running = %d, want 1", maxRunning)
+	}
+	if len(order) != 3 || order[0] != "e1" || order[1] != "e2" || order[2] != "e3" {
+		t.Errorf("order = %v, want board order", order)
+	}
+}
+
+func TestRunColumn_MaxParallel(t *testing.T) {
+	edges := []models.BoardEdge{{Id: "e1"}, {Id: "e2"}, {Id: "e3"}, {Id: "e4"}}
+	order, maxRunning, _ := runColumnTrace(context.Background(), edges, 2, nil)
+
+	if len(order) != 4 {
+		t.Fatalf("ran %d cards, want 4", len(order))
+	}
+	if maxRunning != 2 {
+		t.Errorf("max running = %d, want 2", maxRunning)
+	}
+}
+
+func TestRunColumn_RemoteExclusive(t *testing.T) {
+	edges := []models.BoardEdge{{Id: "e1"}, {Id: "e2"}, {Id: "e3"}}
+	remotes := map[string][]string{
+		"e1": {"gdrive"},
+		"e2": {"gdrive", "s3"},
+		"e3": {"dropbox"},
+	}
+	order, maxRunning, overlap := runColumnTrace(context.Background(), edges, 0, remotes)
+
+	if len(order) != 3 {
+		t.Fatalf("ran %d cards, want 3", len(order))
+	}
+	if overlap {
+		t.Error("two cards used the same remote at the same time")
+	}
+	if maxRunning != 2 {
+		t.Errorf("max running = %d, want 2 (e3 alongside a gdrive card)", maxRunning)
+	}
+}
+
+func TestRunColumn_CancelledStartsNothing(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	order, _, _ := runColumnTrace(ctx, []models.BoardEdge{{Id: "e1"}, {Id: "e2"}}, 0, nil)
+	if len(order) != 0 {
+		t.Errorf("started %v after cancellation", order)
+	}
+}
+
+func TestRunColumn_StartTimes(t *testing.T) {
+	due := time.Now().Add(60 * time.Millisecond)
+	edges := []models.BoardEdge{{Id: "e1"}, {Id: "e2"}}
+	var order []string
+	var startedAt time.Time
+	runColumn(context.Background(), edges, 1,
+		func(e models.BoardEdge) []string { return nil },
+		func(e models.BoardEdge) time.Time {
+			if e.Id == "e1" {
+				return due
+			}
+			return time.Time{}
+		},
+		func(e models.BoardEdge) {
+			order = append(order, e.Id)
+			if e.Id == "e1" {
+				startedAt = time.Now()
+			}
+		})
+
+	if len(order) != 2 || order[0] != "e2" || order[1] != "e1" {
+		t.Errorf("order = %v, want e2 before the delayed e1", order)
+	}
+	if startedAt.Before(due) {
+		t.Errorf("e1 started %v before its start time", due.Sub(startedAt))
+	}
+}
+
+func TestRunColumn_CancelledWhileWaiting(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	started := false
+	returned := make(chan struct{})
+	go func() {
+		runColumn(ctx, []models.BoardEdge{{Id: "e1"}}, 0,
+			func(e models.BoardEdge) []string { return nil },
+			func(e models.BoardEdge) time.Time { return time.Now().Add(time.Hour) },
+			func(e models.BoardEdge) { started = true })
+		close(returned)
+	}()
+
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatal("runColumn kept waiting for a card's start time after cancellation")
+	}
+	if started {
+		t.Error("started a card before its start time")
+	}
+}
+
+func TestCardStartTimes(t *testing.T) {
+	board := &models.Board{Edges: []models.BoardEdge{{Id: "e1"}, {Id: "e2", StartOffsetMinutes: 60}}}
+	start := time.Date(2024, 5, 1, 2, 0, 0, 0, time.UTC)
+
+	if got := cardStartTimes(board, start, false); len(got) != 0 {
+		t.Errorf("manual run start times = %v, want none", got)
+	}
+	got := cardStartTimes(board, start, true)
+	if len(got) != 1 || !got["e2"].Equal(start.Add(time.Hour)) {
+		t.Errorf("scheduled run start times = %v, want e2 at 03:00", got)
+	}
+}
+
+func TestFlowExecution_Progress(t *testing.T) {
+	running := &SyncTask{}
+	running.setLastStatus(&dto.SyncStatusDTO{Progress: 50, BytesTransferred: 100, TotalBytes: 200, FilesTransferred: 1, TotalFiles: 2})
+	completed := &SyncTask{}
+	completed.setLastStatus(&dto.SyncStatusDTO{Progress: 100, BytesTransferred: 300, TotalBytes: 300, FilesTransferred: 3, TotalFiles: 3, Errors: 1})
+
+	flow := &FlowExecution{
+		BoardId: "board-1",
+		Status: &models.BoardExecutionStatus{
+			Status: "running",
+			EdgeStatuses: []models.EdgeExecutionStatus{
+				{EdgeId: "e1", Status: "completed"},
+				{EdgeId: "e2", Status: "running"},
+				{EdgeId: "e3", Status: "skipped"},
+				{EdgeId: "e4", Status: "pending"},
+			},
+		},
+		tasks: map[string]*SyncTask{"e1": completed, "e2": running},
+	}
+
+	p := flow.progress()
+	if p.TotalCards != 4 || p.CompletedCards != 1 || p.RunningCards != 1 || p.SkippedCards != 1 || p.PendingCards != 1 {
+		t.Errorf("card counts = %+v", p)
+	}
+	// (1 + 0.5 + 1 + 0) / 4
+	if p.Progress != 62.5 {
+		t.Errorf("progress = %v, want 62.5", p.Progress)
+	}
+	if p.BytesTransferred != 400 || p.TotalBytes != 500 || p.FilesTransferred != 4 || p.TotalFiles != 5 || p.Errors != 1 {
+		t.Errorf("counters = %+v", p)
+	}
+}
+
+func TestFlowExecution_RecordRetryBudget(t *testing.T) {
+	budget := &utils.RetryBudget{MaxRetries: 1}
+	flow := &FlowExecution{budget: budget, Status: &models.BoardExecutionStatus{EdgeStatuses: []models.EdgeExecutionStatus{
+		{EdgeId: "e1", Status: "failed"}, {EdgeId: "e2", Status: "skipped"}, {EdgeId: "e3", Status: "completed"},
+	}}}
+
+	budget.Take()
+	flow.recordRetryBudget()
+	if flow.Status.Retries != 1 || flow.Status.BudgetExhausted || flow.Status.Summary != "" {
+		t.Errorf("unexpected status with budget left: %+v", flow.Status)
+	}
+
+	budget.Take()
+	flow.recordRetryBudget()
+	if !flow.Status.BudgetExhausted || flow.Status.Summary != "Retry budget exhausted after 1 retries (0s): 1 card(s) failed, 1 skipped" {
+		t.Errorf("unexpected status once the budget ran out: %+v", flow.Status)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"desktop/backend/events"
 	"desktop/backend/models"
 	"desktop/backend/rclone"
+	"desktop/backend/utils"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -52,8 +53,15 @@ type FlowExecution struct {
 	BoardId      string
 	Cancel       context.CancelFunc
 	Status       *models.BoardExecutionStatus
-	StatusMu     sync.Mutex  // protects Status field from concurrent access
-	CleanupTimer *time.Timer // delayed cleanup timer; nil while running
+	StatusMu     sync.Mutex    // protects Status field from concurrent access
+	CleanupTimer *time.Timer   // delayed cleanup timer; nil while running
+	Done         chan struct{} // closed when the execution reaches a terminal state
+
+	tasks     map[string]*SyncTask // edge ID -> its sync task, for combined progress; protected by StatusMu
+	budget    *utils.RetryBudget   // retries shared by the cards; nil if the board has no retry budget
+	startAt   map[string]time.Time // edge ID -> when its start offset lets it start, in scheduled runs
+	windowEnd time.Time            // when a scheduled run stops starting cards; zero without a window
+	missed    bool                 // cards were skipped at the end of the window; protected by StatusMu
 }
 
 // NewBoardService creates a new board service
@@ -322,7 +330,7 @@ func (b *BoardService) DeleteBoard(ctx context.Context, boardId string) error {
 		return fmt.Errorf("board '%s' not found", boardId)
 	}
 
-	if err := b.deleteBoardFromDB(boardId); err != nil {
+	if err := b.deleteBoardFromDB(deletedBoard); err != nil {
 		b.boards = append(b.boards, deletedBoard)
 		return fmt.Errorf("failed to delete board: %w", err)
 	}
@@ -400,7 +408,16 @@ func (b *BoardService) ExecuteBoard(ctx context.Context, boardId string) (*model
 
 	// Compute execution layers
 	layers := b.computeExecutionLayers(board)
-	log.Printf("[BoardService] ExecuteBoard: computed %d execution layers", len(layers))
+	log.Printf("[BoardService] ExecuteBoard: computed %d execution layers (mode=%s maxParallel=%d)", len(layers), board.ExecutionMode, board.MaxParallel)
+
+	// Scheduled runs start cards at their offsets, within the board's window
+	startTime := time.Now()
+	scheduled := scheduleRunFromContext(ctx) != ""
+	startAt := cardStartTimes(board, startTime, scheduled)
+	var windowEnd time.Time
+	if scheduled && board.WindowMinutes > 0 {
+		windowEnd = startTime.Add(time.Duration(board.WindowMinutes) * time.Minute)
+	}
 
 	// Initialize execution status
 	edgeStatuses := make([]models.EdgeExecutionStatus, len(board.Edges))
@@ -409,23 +426,40 @@ func (b *BoardService) ExecuteBoard(ctx context.Context, boardId string) (*model
 			EdgeId: edge.Id,
 			Status: "pending",
 		}
+		if at, ok := startAt[edge.Id]; ok {
+			edgeStatuses[i].NotBefore = &at
+			edgeStatuses[i].Message = fmt.Sprintf("Starts at %s", at.Format("15:04"))
+		}
 	}
 
 	status := &models.BoardExecutionStatus{
 		BoardId:      boardId,
 		Status:       "running",
 		EdgeStatuses: edgeStatuses,
-		StartTime:    time.Now(),
+		StartTime:    startTime,
 	}
 
 	// Create cancellable context from Background (not from the Wails RPC context,
-	// which gets cancelled when the method call returns)
-	flowCtx, cancel := context.WithCancel(context.Background())
+	// which gets cancelled when the method call returns), keeping the caller's
+	// task priority so scheduled board runs yield to manual syncs, and the
+	// schedule run so its blocking applications can pause the board's syncs
+	baseCtx := withScheduleRun(WithTaskPriority(context.Background(), TaskPriorityFromContext(ctx)), scheduleRunFromContext(ctx))
+	var budget *utils.RetryBudget
+	if board.MaxRetries > 0 || board.MaxRetrySeconds > 0 {
+		budget = &utils.RetryBudget{MaxRetries: board.MaxRetries, MaxDuration: time.Duration(board.MaxRetrySeconds) * time.Second}
+		baseCtx = utils.WithRetryBudget(baseCtx, budget)
+	}
+	flowCtx, cancel := context.WithCancel(baseCtx)
 
 	flow := &FlowExecution{
-		BoardId: boardId,
-		Cancel:  cancel,
-		Status:  status,
+		BoardId:   boardId,
+		Cancel:    cancel,
+		Status:    status,
+		Done:      make(chan struct{}),
+		tasks:     make(map[string]*SyncTask),
+		budget:    budget,
+		startAt:   startAt,
+		windowEnd: windowEnd,
 	}
 
 	b.flowMutex.Lock()
@@ -433,6 +467,7 @@ func (b *BoardService) ExecuteBoard(ctx context.Context, boardId string) (*model
 	b.flowMutex.Unlock()
 
 	b.emitBoardEvent(events.BoardExecutionStarted, boardId, "", "running", "Board execution started")
+	sendRunWebhooks(board.Webhooks, flow.webhookPayload(board, models.RunWebhookStarted))
 
 	// Execute in goroutine
 	go b.executeFlow(flowCtx, board, layers, flow)
@@ -473,6 +508,30 @@ func (b *BoardService) GetBoardExecutionStatus(ctx context.Context, boardId stri
 	flow.StatusMu.Lock()
 	status := *flow.Status // return a copy
 	flow.StatusMu.Unlock()
+	status.Progress = flow.progress()
+	return &status, nil
+}
+
+// WaitForBoardExecution blocks until the board execution finishes and returns its final status
+func (b *BoardService) WaitForBoardExecution(ctx context.Context, boardId string) (*models.BoardExecutionStatus, error) {
+	b.flowMutex.RLock()
+	flow, exists := b.activeFlows[boardId]
+	b.flowMutex.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("no active execution for board '%s'", boardId)
+	}
+
+	select {
+	case <-flow.Done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	flow.StatusMu.Lock()
+	status := *flow.Status
+	flow.StatusMu.Unlock()
+	status.Progress = flow.progress()
 	return &status, nil
 }
 
@@ -485,6 +544,10 @@ func (b *BoardService) executeFlow(ctx context.Context, board *models.Board, lay
 		flow.Status.EndTime = &endTime
 		flow.StatusMu.Unlock()
 		log.Printf("[BoardService] executeFlow finished: boardId=%s finalStatus=%s", board.Id, flow.Status.Status)
+		sendRunWebhooks(board.Webhooks, flow.webhookPayload(board, models.RunWebhookFinished))
+		if flow.Done != nil {
+			close(flow.Done)
+		}
 		// Delay cleanup to give frontend polling time to catch the terminal status.
 		// The flow stays in activeFlows with its final status for a grace period.
 		// Store the timer so ExecuteBoard can cancel it for immediate re-execution.
@@ -526,29 +589,50 @@ func (b *BoardService) executeFlow(ctx context.Context, board *models.Board, lay
 			edgesToRun = append(edgesToRun, edge)
 		}
 
-		// Pass 2: Parallel execution (writes to failedNodes are protected by layerMu)
-		var wg sync.WaitGroup
+		// Pass 2: Run the column per the board's execution mode (writes to
+		// failedNodes are protected by layerMu)
 		var layerMu sync.Mutex
 		layerHasFailure := false
 
-		for _, edge := range edgesToRun {
-			wg.Add(1)
-			go func(e models.BoardEdge) {
-				defer wg.Done()
-
-				err := b.executeEdge(ctx, board, &e, flow)
-
-				if err != nil {
+		runColumn(ctx, edgesToRun, columnLimit(board),
+			func(e models.BoardEdge) []string { return edgeRemotes(board, e) },
+			func(e models.BoardEdge) time.Time { return flow.startAt[e.Id] },
+			func(e models.BoardEdge) {
+				// Cards that couldn't start within the board's window don't run
+				if !flow.windowEnd.IsZero() && time.Now().After(flow.windowEnd) {
+					msg := "Skipped: the board's window ended before it could start"
+					flow.StatusMu.Lock()
+					b.updateEdgeStatus(flow.Status, e.Id, "skipped", msg)
+					flow.Status.Summary = "Some cards couldn't start within the board's window"
+					flow.missed = true
+					flow.StatusMu.Unlock()
+					b.emitBoardEvent(events.BoardExecutionProgress, board.Id, e.Id, "skipped", msg)
+					layerMu.Lock()
+					failedNodes[e.TargetId] = true
+					layerMu.Unlock()
+					return
+				}
+				// Fail fast once the board's retry budget has run out
+				if _, _, exhausted := flow.budget.Usage(); exhausted {
+					flow.StatusMu.Lock()
+					b.updateEdgeStatus(flow.Status, e.Id, "skipped", "Skipped: board retry budget exhausted")
+					flow.StatusMu.Unlock()
+					b.emitBoardEvent(events.BoardExecutionProgress, board.Id, e.Id, "skipped", "Skipped: board retry budget exhausted")
+					layerMu.Lock()
+					failedNodes[e.TargetId] = true
+					layerMu.Unlock()
+					return
+				}
+				if err := b.executeEdge(ctx, board, &e, flow); err != nil {
 					layerMu.Lock()
 					layerHasFailure = true
 					failedNodes[e.TargetId] = true
 					layerMu.Unlock()
 				}
-			}(edge)
-		}
+			})
 
-		// After Wait(), failedNodes is safe to read in the next layer iteration
-		wg.Wait()
+		// runColumn returns once every card it started has finished, so
+		// failedNodes is safe to read in the next layer iteration
 
 		if layerHasFailure {
 			// Mark all downstream edges as skipped
@@ -558,9 +642,19 @@ func (b *BoardService) executeFlow(ctx context.Context, board *models.Board, lay
 		}
 	}
 
+	// Cancelled while the last column ran: cards it never started stay pending
+	if ctx.Err() != nil {
+		flow.StatusMu.Lock()
+		flow.Status.Status = "cancelled"
+		b.markRemainingSkipped(flow.Status, nil)
+		flow.StatusMu.Unlock()
+		b.emitBoardEvent(events.BoardExecutionCancelled, board.Id, "", "cancelled", "Board execution cancelled")
+		return
+	}
+
 	// Determine final status
 	flow.StatusMu.Lock()
-	hasFailure := false
+	hasFailure := flow.missed
 	for _, es := range flow.Status.EdgeStatuses {
 		if es.Status == "failed" {
 			hasFailure = true
@@ -573,10 +667,15 @@ func (b *BoardService) executeFlow(ctx context.Context, board *models.Board, lay
 	} else {
 		flow.Status.Status = "completed"
 	}
+	flow.recordRetryBudget()
+	summary := flow.Status.Summary
 	flow.StatusMu.Unlock()
 
 	if hasFailure {
-		b.emitBoardEvent(events.BoardExecutionFailed, board.Id, "", "failed", "Board execution completed with failures")
+		if summary == "" {
+			summary = "Board execution completed with failures"
+		}
+		b.emitBoardEvent(events.BoardExecutionFailed, board.Id, "", "failed", summary)
 		b.sendBoardNotification(board, false, flow.Status)
 	} else {
 		b.emitBoardEvent(events.BoardExecutionCompleted, board.Id, "", "completed", "Board execution completed successfully")
@@ -643,6 +742,19 @@ func (b *BoardService) executeEdge(ctx context.Context, board *models.Board, edg
 		return err
 	}
 
+	// Keep the task for the board's combined progress
+	task := b.syncService.getTask(result.TaskId)
+	flow.StatusMu.Lock()
+	if task != nil {
+		flow.tasks[edge.Id] = task
+	}
+	for i := range flow.Status.EdgeStatuses {
+		if flow.Status.EdgeStatuses[i].EdgeId == edge.Id {
+			flow.Status.EdgeStatuses[i].TaskId = result.TaskId
+		}
+	}
+	flow.StatusMu.Unlock()
+
 	// Wait for task completion
 	log.Printf("[BoardService] executeEdge: waiting for task %d to complete", result.TaskId)
 	err = b.syncService.WaitForTask(ctx, result.TaskId)
@@ -669,13 +781,18 @@ func (b *BoardService) executeEdge(ctx context.Context, board *models.Board, edg
 
 // buildRemotePath constructs rclone path from a board node
 func (b *BoardService) buildRemotePath(node *models.BoardNode) string {
-	if node.RemoteName == "local" || node.RemoteName == "" {
-		return node.Path
+	return joinRemotePath(node.RemoteName, node.Path)
+}
+
+// joinRemotePath constructs an rclone path from a remote name and a path on it
+func joinRemotePath(remoteName, path string) string {
+	if remoteName == "local" || remoteName == "" {
+		return path
 	}
-	if node.Path == "" {
-		return node.RemoteName + ":"
+	if path == "" {
+		return remoteName + ":"
 	}
-	return node.RemoteName + ":" + node.Path
+	return remoteName + ":" + path
 }
 
 // computeExecutionLayers groups edges into execution layers using topological sort
@@ -815,6 +932,25 @@ func (b *BoardService) validateBoard(board *models.Board) error {
 		nodeIds[node.Id] = true
 	}
 
+	switch board.ExecutionMode {
+	case "", models.BoardModeParallel, models.BoardModeSequential:
+		// valid
+	default:
+		return fmt.Errorf("invalid execution mode '%s'", board.ExecutionMode)
+	}
+	if board.MaxParallel < 0 {
+		return fmt.Errorf("max parallel cannot be negative")
+	}
+	if board.MaxRetries < 0 || board.MaxRetrySeconds < 0 {
+		return fmt.Errorf("retry budget cannot be negative")
+	}
+	if board.WindowMinutes < 0 || board.WindowMinutes > maxBoardOffsetMinutes {
+		return fmt.Errorf("window must be between 0 and %d minutes", maxBoardOffsetMinutes)
+	}
+	if err := validateRunWebhooks(board.Webhooks); err != nil {
+		return err
+	}
+
 	// Validate edges reference valid nodes
 	edgeIds := make(map[string]bool)
 	for _, edge := range board.Edges {
@@ -843,12 +979,45 @@ func (b *BoardService) validateBoard(board *models.Board) error {
 		default:
 			return fmt.Errorf("edge '%s' has invalid action '%s'", edge.Id, edge.Action)
 		}
+
+		if edge.StartOffsetMinutes < 0 || edge.StartOffsetMinutes > maxBoardOffsetMinutes {
+			return fmt.Errorf("edge '%s' start offset must be between 0 and %d minutes", edge.Id, maxBoardOffsetMinutes)
+		}
+		if board.WindowMinutes > 0 && edge.StartOffsetMinutes >= board.WindowMinutes {
+			return fmt.Errorf("edge '%s' starts %d minutes into the run, after the board's %d-minute window", edge.Id, edge.StartOffsetMinutes, board.WindowMinutes)
+		}
 	}
 
 	// Check for cycles
 	return b.detectCycles(board)
 }
 
+// recordRetryBudget copies the retry budget's use into the execution status,
+// summarizing the run's failure if the budget ran out. Caller must hold StatusMu.
+func (flow *FlowExecution) recordRetryBudget() {
+	if flow.budget == nil {
+		return
+	}
+	retries, spent, exhausted := flow.budget.Usage()
+	flow.Status.Retries = retries
+	flow.Status.RetrySeconds = int(spent.Seconds())
+	flow.Status.BudgetExhausted = exhausted
+	if !exhausted {
+		return
+	}
+	failed, skipped := 0, 0
+	for _, es := range flow.Status.EdgeStatuses {
+		switch es.Status {
+		case "failed":
+			failed++
+		case "skipped":
+			skipped++
+		}
+	}
+	flow.Status.Summary = fmt.Sprintf("Retry budget exhausted after %d retries (%s): %d card(s) failed, %d skipped",
+		retries, spent.Round(time.Second), failed, skipped)
+}
+
 // updateEdgeStatus updates the status of an edge in the execution status
 func (b *BoardService) updateEdgeStatus(status *models.BoardExecutionStatus, edgeId, newStatus, message string) {
 	for i := range status.EdgeStatuses {
@@ -1030,7 +1199,8 @@ func (b *BoardService) loadBoardsFromDB() ([]models.Board, error) {
 	}
 
 	rows, err := db.Query(`SELECT id, name, description, created_at, updated_at,
-		schedule_enabled, cron_expr, last_run, next_run, last_result
+		schedule_enabled, cron_expr, last_run, next_run, last_result,
+		execution_mode, max_parallel, max_retries, max_retry_seconds, webhooks, window_minutes
 		FROM boards ORDER BY name`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query boards: %w", err)
@@ -1043,10 +1213,17 @@ func (b *BoardService) loadBoardsFromDB() ([]models.Board, error) {
 		var createdAt, updatedAt string
 		var scheduleEnabled int
 		var lastRun, nextRun *string
+		var webhooks string
 		if err := rows.Scan(&board.Id, &board.Name, &board.Description, &createdAt, &updatedAt,
-			&scheduleEnabled, &board.CronExpr, &lastRun, &nextRun, &board.LastResult); err != nil {
+			&scheduleEnabled, &board.CronExpr, &lastRun, &nextRun, &board.LastResult,
+			&board.ExecutionMode, &board.MaxParallel, &board.MaxRetries, &board.MaxRetrySeconds, &webhooks, &board.WindowMinutes); err != nil {
 			return nil, fmt.Errorf("failed to scan board: %w", err)
 		}
+		if webhooks != "" {
+			if err := json.Unmarshal([]byte(webhooks), &board.Webhooks); err != nil {
+				log.Printf("[BoardService] Warning: failed to unmarshal webhooks for board %s: %v", board.Id, err)
+			}
+		}
 		board.ScheduleEnabled = scheduleEnabled != 0
 		if t, err := time.Parse(time.RFC3339, createdAt); err == nil {
 			board.CreatedAt = t
@@ -1125,7 +1302,7 @@ func (b *BoardService) loadBoardEdgesFromDB(boardId string) ([]models.BoardEdge,
 		return nil, err
 	}
 
-	rows, err := db.Query("SELECT id, source_id, target_id, action, sync_config FROM board_edges WHERE board_id = ?", boardId)
+	rows, err := db.Query("SELECT id, source_id, target_id, action, sync_config, start_offset_minutes FROM board_edges WHERE board_id = ?", boardId)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query board edges: %w", err)
 	}
@@ -1135,7 +1312,7 @@ func (b *BoardService) loadBoardEdgesFromDB(boardId string) ([]models.BoardEdge,
 	for rows.Next() {
 		var edge models.BoardEdge
 		var syncConfigJSON string
-		if err := rows.Scan(&edge.Id, &edge.SourceId, &edge.TargetId, &edge.Action, &syncConfigJSON); err != nil {
+		if err := rows.Scan(&edge.Id, &edge.SourceId, &edge.TargetId, &edge.Action, &syncConfigJSON, &edge.StartOffsetMinutes); err != nil {
 			return nil, fmt.Errorf("failed to scan board edge: %w", err)
 		}
 		if syncConfigJSON != "" && syncConfigJSON != "{}" {
@@ -1164,13 +1341,24 @@ func (b *BoardService) saveBoardToDB(board models.Board) error {
 	}
 	defer tx.Rollback()
 
+	webhooksJSON := ""
+	if len(board.Webhooks) > 0 {
+		data, err := json.Marshal(board.Webhooks)
+		if err != nil {
+			return fmt.Errorf("failed to marshal webhooks: %w", err)
+		}
+		webhooksJSON = string(data)
+	}
+
 	// Upsert the board
-	_, err = tx.Exec(`INSERT OR REPLACE INTO boards (id, name, description, created_at, updated_at, schedule_enabled, cron_expr, last_run, next_run, last_result)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+	_, err = tx.Exec(`INSERT OR REPLACE INTO boards (id, name, description, created_at, updated_at, schedule_enabled, cron_expr, last_run, next_run, last_result,
+		execution_mode, max_parallel, max_retries, max_retry_seconds, webhooks, window_minutes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		board.Id, board.Name, board.Description,
 		board.CreatedAt.UTC().Format(time.RFC3339), board.UpdatedAt.UTC().Format(time.RFC3339),
 		boolToInt(board.ScheduleEnabled), board.CronExpr,
-		timePtrToNullable(board.LastRun), timePtrToNullable(board.NextRun), board.LastResult)
+		timePtrToNullable(board.LastRun), timePtrToNullable(board.NextRun), board.LastResult,
+		board.ExecutionMode, board.MaxParallel, board.MaxRetries, board.MaxRetrySeconds, webhooksJSON, board.WindowMinutes)
 	if err != nil {
 		return fmt.Errorf("failed to save board: %w", err)
 	}
@@ -1201,9 +1389,9 @@ func (b *BoardService) saveBoardToDB(board models.Board) error {
 			log.Printf("[BoardService] Warning: failed to marshal sync_config for edge %s: %v", edge.Id, jsonErr)
 			syncConfigJSON = []byte("{}")
 		}
-		if _, err := tx.Exec(`INSERT INTO board_edges (id, board_id, source_id, target_id, action, sync_config)
-			VALUES (?, ?, ?, ?, ?, ?)`,
-			edge.Id, board.Id, edge.SourceId, edge.TargetId, edge.Action, string(syncConfigJSON)); err != nil {
+		if _, err := tx.Exec(`INSERT INTO board_edges (id, board_id, source_id, target_id, action, sync_config, start_offset_minutes)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			edge.Id, board.Id, edge.SourceId, edge.TargetId, edge.Action, string(syncConfigJSON), edge.StartOffsetMinutes); err != nil {
 			return fmt.Errorf("failed to save edge: %w", err)
 		}
 	}
@@ -1212,13 +1400,9 @@ func (b *BoardService) saveBoardToDB(board models.Board) error {
 }
 
 // deleteBoardFromDB removes a board and all its nodes/edges (via CASCADE)
-func (b *BoardService) deleteBoardFromDB(boardId string) error {
-	db, err := GetSharedDB()
-	if err != nil {
-		return err
-	}
-	_, err = db.Exec("DELETE FROM boards WHERE id = ?", boardId)
-	return err
+// and moves it to the trash
+func (b *BoardService) deleteBoardFromDB(board models.Board) error {
+	return deleteWithTrash(models.TrashBoard, board.Id, board.Name, board, "DELETE FROM boards WHERE id = ?", board.Id)
 }
 
 // sendBoardNotification sends a desktop notification for board execution completion/failure
@@ -1233,6 +1417,7 @@ func (b *BoardService) sendBoardNotification(board *models.Board, success bool,
 	}
 
 	var title, body string
+	severity := NotifySeverityInfo
 	if success {
 		title = "Board Execution Completed"
 		completedCount := 0
@@ -1244,6 +1429,7 @@ func (b *BoardService) sendBoardNotification(board *models.Board, success bool,
 		body = fmt.Sprintf("Board \"%s\" completed successfully. %d sync(s) executed.", boardName, completedCount)
 	} else {
 		title = "Board Execution Failed"
+		severity = NotifySeverityError
 		failedCount := 0
 		for _, es := range status.EdgeStatuses {
 			if es.Status == "failed" {
@@ -1251,10 +1437,13 @@ func (b *BoardService) sendBoardNotification(board *models.Board, success bool,
 			}
 		}
 		body = fmt.Sprintf("Board \"%s\" completed with %d failure(s).", boardName, failedCount)
+		if status.Summary != "" {
+			body = fmt.Sprintf("Board \"%s\" failed. %s.", boardName, status.Summary)
+		}
 	}
 
 	// Send notification (context.Background() since flow context may be cancelled)
-	if err := b.notificationService.SendNotification(context.Background(), title, body); err != nil {
+	if err := b.notificationService.SendCategoryNotification(context.Background(), NotifyCategoryBoard, severity, title, body); err != nil {
 		log.Printf("Failed to send board notification: %v", err)
 	}
 }
@@ -0,0 +1,405 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"desktop/backend/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// templateVariablePattern matches ${NAME} placeholders in board templates
+var templateVariablePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// validTemplateVariableName matches names usable in ${NAME} placeholders
+var validTemplateVariableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// templateStructuralKeys are board JSON keys whose values are never templated:
+// IDs, edge wiring, sync actions and timestamps
+var templateStructuralKeys = map[string]bool{
+	"id": true, "source_id": true, "target_id": true, "action": true,
+	"created_at": true, "updated_at": true,
+}
+
+// GetBoardTemplates returns all board templates, ordered by name
+func (b *BoardService) GetBoardTemplates(ctx context.Context) ([]models.BoardTemplate, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT id, name, description, variables, board, created_at, updated_at
+		FROM board_templates ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query board templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []models.BoardTemplate{}
+	for rows.Next() {
+		tmpl, err := scanBoardTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *tmpl)
+	}
+	return templates, rows.Err()
+}
+
+// SaveBoardTemplate creates a template (when its ID is empty) or updates an
+// existing one. Placeholders used in the board but not declared are added
+// to the template's variables.
+func (b *BoardService) SaveBoardTemplate(ctx context.Context, tmpl models.BoardTemplate) (*models.BoardTemplate, error) {
+	tmpl.Name = strings.TrimSpace(tmpl.Name)
+	if tmpl.Name == "" {
+		return nil, fmt.Errorf("template name is required")
+	}
+	declared := make(map[string]bool)
+	for _, v := range tmpl.Variables {
+		if !validTemplateVariableName.MatchString(v.Name) {
+			return nil, fmt.Errorf("invalid variable name '%s': use letters, digits and underscores", v.Name)
+		}
+		if declared[v.Name] {
+			return nil, fmt.Errorf("duplicate variable '%s'", v.Name)
+		}
+		declared[v.Name] = true
+	}
+	used, err := boardTemplateVariableNames(tmpl.Board)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range used {
+		if !declared[name] {
+			tmpl.Variables = append(tmpl.Variables, models.BoardTemplateVariable{Name: name})
+		}
+	}
+	if tmpl.Variables == nil {
+		tmpl.Variables = []models.BoardTemplateVariable{}
+	}
+
+	existing, err := b.GetBoardTemplates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range existing {
+		if other.Name == tmpl.Name && other.Id != tmpl.Id {
+			return nil, fmt.Errorf("template with name '%s' already exists", tmpl.Name)
+		}
+		if other.Id == tmpl.Id {
+			tmpl.CreatedAt = other.CreatedAt
+		}
+	}
+	if tmpl.Id == "" {
+		tmpl.Id = "template-" + uuid.New().String()
+	}
+	if tmpl.CreatedAt.IsZero() {
+		tmpl.CreatedAt = time.Now()
+	}
+	tmpl.UpdatedAt = time.Now()
+
+	// Templates hold structure only: no schedule, run state or passwords
+	tmpl.Board = templateBoard(tmpl.Board)
+
+	if err := saveBoardTemplateToDB(tmpl); err != nil {
+		return nil, err
+	}
+	log.Printf("[BoardService] Board template '%s' saved with %d variables", tmpl.Name, len(tmpl.Variables))
+	return &tmpl, nil
+}
+
+// CreateTemplateFromBoard saves an existing board as a template. Each entry
+// of replacements maps a variable name to the literal text it stands for in
+// the board, e.g. {"CLIENT_NAME": "Acme"} turns "Acme backup" into
+// "${CLIENT_NAME} backup".
+func (b *BoardService) CreateTemplateFromBoard(ctx context.Context, boardId, name string, replacements map[string]string) (*models.BoardTemplate, error) {
+	board, err := b.GetBoard(ctx, boardId)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := models.BoardTemplate{
+		Name:        name,
+		Description: board.Description,
+		Board:       templateBoard(*board),
+	}
+
+	// Replace longer literals first so one that contains another wins
+	names := make([]string, 0, len(replacements))
+	for varName, literal := range replacements {
+		if !validTemplateVariableName.MatchString(varName) {
+			return nil, fmt.Errorf("invalid variable name '%s': use letters, digits and underscores", varName)
+		}
+		if literal == "" {
+			return nil, fmt.Errorf("variable '%s' needs the text it replaces", varName)
+		}
+		names = append(names, varName)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return len(replacements[names[i]]) > len(replacements[names[j]])
+	})
+	pairs := make([]string, 0, 2*len(names))
+	for _, varName := range names {
+		pairs = append(pairs, replacements[varName], "${"+varName+"}")
+		tmpl.Variables = append(tmpl.Variables, models.BoardTemplateVariable{Name: varName, Default: replacements[varName]})
+	}
+	if len(pairs) > 0 {
+		replacer := strings.NewReplacer(pairs...)
+		if err := mapBoardStrings(&tmpl.Board, replacer.Replace); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.SaveBoardTemplate(ctx, tmpl)
+}
+
+// DeleteBoardTemplate removes a board template
+func (b *BoardService) DeleteBoardTemplate(ctx context.Context, templateId string) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	result, err := db.Exec("DELETE FROM board_templates WHERE id = ?", templateId)
+	if err != nil {
+		return fmt.Errorf("failed to delete board template: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("board template '%s' not found", templateId)
+	}
+	return nil
+}
+
+// InstantiateBoardTemplate creates a new board from a template, substituting
+// each ${VARIABLE} with its value (or the variable's default). Nodes and
+// edges get fresh IDs so the same template can be used any number of times.
+func (b *BoardService) InstantiateBoardTemplate(ctx context.Context, templateId string, values map[string]string) (*models.Board, error) {
+	tmpl, err := loadBoardTemplateFromDB(templateId)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make(map[string]string)
+	for _, v := range tmpl.Variables {
+		if v.Default != "" {
+			resolved[v.Name] = v.Default
+		}
+	}
+	for name, value := range values {
+		if value != "" {
+			resolved[name] = value
+		}
+	}
+
+	board, err := substituteBoardTemplate(tmpl.Board, resolved)
+	if err != nil {
+		return nil, err
+	}
+	board.Id = "board-" + uuid.New().String()
+	board.CreatedAt = time.Time{}
+	reassignBoardIds(&board)
+
+	if err := b.AddBoard(ctx, board); err != nil {
+		return nil, err
+	}
+	log.Printf("[BoardService] Board '%s' created from template '%s'", board.Name, tmpl.Name)
+	return b.GetBoard(ctx, board.Id)
+}
+
+// substituteBoardTemplate fills in a template board's placeholders. It fails
+// if any placeholder has no value, naming every missing variable.
+func substituteBoardTemplate(board models.Board, values map[string]string) (models.Board, error) {
+	missing := make(map[string]bool)
+	err := mapBoardStrings(&board, func(s string) string {
+		return templateVariablePattern.ReplaceAllStringFunc(s, func(placeholder string) string {
+			name := templateVariablePattern.FindStringSubmatch(placeholder)[1]
+			value, ok := values[name]
+			if !ok {
+				missing[name] = true
+				return placeholder
+			}
+			return value
+		})
+	})
+	if err != nil {
+		return board, err
+	}
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return board, fmt.Errorf("missing values for template variables: %s", strings.Join(names, ", "))
+	}
+	return board, nil
+}
+
+// boardTemplateVariableNames returns the placeholder names used in a board, sorted
+func boardTemplateVariableNames(board models.Board) ([]string, error) {
+	seen := make(map[string]bool)
+	err := mapBoardStrings(&board, func(s string) string {
+		for _, match := range templateVariablePattern.FindAllStringSubmatch(s, -1) {
+			seen[match[1]] = true
+		}
+		return s
+	})
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// mapBoardStrings applies fn to every text value in a board, including the
+// edges' sync settings, by round-tripping the board through JSON. Structural
+// values (see templateStructuralKeys) are left alone.
+func mapBoardStrings(board *models.Board, fn func(string) string) error {
+	data, err := json.Marshal(board)
+	if err != nil {
+		return fmt.Errorf("failed to encode board: %w", err)
+	}
+	var tree interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return fmt.Errorf("failed to decode board: %w", err)
+	}
+	tree = mapJSONStrings(tree, fn)
+	if data, err = json.Marshal(tree); err != nil {
+		return fmt.Errorf("failed to encode board: %w", err)
+	}
+	var mapped models.Board
+	if err := json.Unmarshal(data, &mapped); err != nil {
+		return fmt.Errorf("failed to decode board: %w", err)
+	}
+	*board = mapped
+	return nil
+}
+
+// mapJSONStrings applies fn to every string value (not key) in a decoded
+// JSON tree, skipping the values of structural keys
+func mapJSONStrings(v interface{}, fn func(string) string) interface{} {
+	switch v := v.(type) {
+	case string:
+		return fn(v)
+	case []interface{}:
+		for i := range v {
+			v[i] = mapJSONStrings(v[i], fn)
+		}
+	case map[string]interface{}:
+		for k := range v {
+			if !templateStructuralKeys[k] {
+				v[k] = mapJSONStrings(v[k], fn)
+			}
+		}
+	}
+	return v
+}
+
+// templateBoard returns the structure of a board to keep in a template
+func templateBoard(board models.Board) models.Board {
+	board.Id = ""
+	board.ScheduleEnabled = false
+	board.CronExpr = ""
+	board.LastRun = nil
+	board.NextRun = nil
+	board.LastResult = ""
+	board.CreatedAt = time.Time{}
+	board.UpdatedAt = time.Time{}
+	board.Nodes = append([]models.BoardNode{}, board.Nodes...)
+	board.Edges = append([]models.BoardEdge{}, board.Edges...)
+	for i := range board.Edges {
+		board.Edges[i].SyncConfig.StripEncryptPasswords()
+	}
+	return board
+}
+
+// reassignBoardIds gives a board's nodes and edges new IDs, keeping the
+// edges connected to the same nodes
+func reassignBoardIds(board *models.Board) {
+	nodeIds := make(map[string]string, len(board.Nodes))
+	for i := range board.Nodes {
+		id := "node-" + uuid.New().String()
+		nodeIds[board.Nodes[i].Id] = id
+		board.Nodes[i].Id = id
+	}
+	for i := range board.Edges {
+		board.Edges[i].Id = "edge-" + uuid.New().String()
+		board.Edges[i].SourceId = nodeIds[board.Edges[i].SourceId]
+		board.Edges[i].TargetId = nodeIds[board.Edges[i].TargetId]
+	}
+}
+
+// ============ SQLite Persistence ============
+
+// scanBoardTemplate reads one board_templates row
+func scanBoardTemplate(row interface{ Scan(...interface{}) error }) (*models.BoardTemplate, error) {
+	var tmpl models.BoardTemplate
+	var variablesJSON, boardJSON, createdAt, updatedAt string
+	if err := row.Scan(&tmpl.Id, &tmpl.Name, &tmpl.Description, &variablesJSON, &boardJSON, &createdAt, &updatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(variablesJSON), &tmpl.Variables); err != nil {
+		return nil, fmt.Errorf("failed to parse variables of template '%s': %w", tmpl.Name, err)
+	}
+	if err := json.Unmarshal([]byte(boardJSON), &tmpl.Board); err != nil {
+		return nil, fmt.Errorf("failed to parse board of template '%s': %w", tmpl.Name, err)
+	}
+	if t, err := time.Parse(time.RFC3339, createdAt); err == nil {
+		tmpl.CreatedAt = t
+	}
+	if t, err := time.Parse(time.RFC3339, updatedAt); err == nil {
+		tmpl.UpdatedAt = t
+	}
+	return &tmpl, nil
+}
+
+// loadBoardTemplateFromDB loads a single board template
+func loadBoardTemplateFromDB(templateId string) (*models.BoardTemplate, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	row := db.QueryRow(`SELECT id, name, description, variables, board, created_at, updated_at
+		FROM board_templates WHERE id = ?`, templateId)
+	tmpl, err := scanBoardTemplate(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("board template '%s' not found", templateId)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load board template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// saveBoardTemplateToDB upserts a board template
+func saveBoardTemplateToDB(tmpl models.BoardTemplate) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	variablesJSON, err := json.Marshal(tmpl.Variables)
+	if err != nil {
+		return fmt.Errorf("failed to encode template variables: %w", err)
+	}
+	boardJSON, err := json.Marshal(tmpl.Board)
+	if err != nil {
+		return fmt.Errorf("failed to encode template board: %w", err)
+	}
+	_, err = db.Exec(`INSERT OR REPLACE INTO board_templates (id, name, description, variables, board, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		tmpl.Id, tmpl.Name, tmpl.Description, string(variablesJSON), string(boardJSON),
+		tmpl.CreatedAt.UTC().Format(time.RFC3339), tmpl.UpdatedAt.UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("failed to save board template: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"strings"
+	"testing"
+)
+
+func makeTemplateBoard() models.Board {
+	return models.Board{
+		Name: "${CLIENT_NAME} backup",
+		Nodes: []models.BoardNode{
+			{Id: "n1", RemoteName: "local", Path: "/clients/${CLIENT_NAME}", Label: "Source"},
+			{Id: "n2", RemoteName: "${DEST_REMOTE}", Path: "backups/${CLIENT_NAME}", Label: "${DEST_REMOTE}"},
+		},
+		Edges: []models.BoardEdge{
+			{Id: "e1", SourceId: "n1", TargetId: "n2", Action: "push", SyncConfig: models.Profile{ExcludedPaths: []string{"${CLIENT_NAME}/tmp/**"}}},
+		},
+	}
+}
+
+func TestBoardTemplateVariableNames(t *testing.T) {
+	names, err := boardTemplateVariableNames(makeTemplateBoard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, ",") != "CLIENT_NAME,DEST_REMOTE" {
+		t.Errorf("names = %v, want [CLIENT_NAME DEST_REMOTE]", names)
+	}
+}
+
+func TestSubstituteBoardTemplate(t *testing.T) {
+	board, err := substituteBoardTemplate(makeTemplateBoard(), map[string]string{
+		"CLIENT_NAME": `Acme "West"`,
+		"DEST_REMOTE": "s3",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if board.Name != `Acme "West" backup` {
+		t.Errorf("name = %q", board.Name)
+	}
+	if board.Nodes[1].RemoteName != "s3" || board.Nodes[1].Path != `backups/Acme "West"` {
+		t.Errorf("target node = %+v", board.Nodes[1])
+	}
+	if board.Edges[0].SyncConfig.ExcludedPaths[0] != `Acme "West"/tmp/**` {
+		t.Errorf("exclude = %q", board.Edges[0].SyncConfig.ExcludedPaths[0])
+	}
+	if board.Edges[0].Action != "push" || board.Edges[0].SourceId != "n1" {
+		t.Errorf("edge structure changed: %+v", board.Edges[0])
+	}
+}
+
+func TestSubstituteBoardTemplate_MissingValues(t *testing.T) {
+	_, err := substituteBoardTemplate(makeTemplateBoard(), map[string]string{})
+	if err == nil {
+		t.Fatal("expected error for missing values")
+	}
+	if !strings.Contains(err.Error(), "CLIENT_NAME, DEST_REMOTE") {
+		t.Errorf("error = %v, want both missing variables named", err)
+	}
+}
+
+func TestReassignBoardIds(t *testing.T) {
+	board := makeTemplateBoard()
+	reassignBoardIds(&board)
+
+	if !strings.HasPrefix(board.Nodes[0].Id, "node-") || !strings.HasPrefix(board.Edges[0].Id, "edge-") {
+		t.Errorf("unexpected IDs: node %q edge %q", board.Nodes[0].Id, board.Edges[0].Id)
+	}
+	if board.Edges[0].SourceId != board.Nodes[0].Id || board.Edges[0].TargetId != board.Nodes[1].Id {
+		t.Error("edge no longer connects the same nodes")
+	}
+}
+
+func TestBoardService_InstantiateBoardTemplate(t *testing.T) {
+	bs := newTestBoardService(t)
+	db, _ := GetSharedDB()
+	db.Exec("DELETE FROM board_templates")
+	ctx := context.Background()
+
+	tmpl, err := bs.SaveBoardTemplate(ctx, models.BoardTemplate{
+		Name:      "Client backup",
+		Variables: []models.BoardTemplateVariable{{Name: "DEST_REMOTE", Default: "gdrive"}},
+		Board:     makeTemplateBoard(),
+	})
+	if err != nil {
+		t.Fatalf("SaveBoardTemplate failed: %v", err)
+	}
+	// CLIENT_NAME is used but was not declared
+	if len(tmpl.Variables) != 2 {
+		t.Errorf("variables = %+v, want DEST_REMOTE and CLIENT_NAME", tmpl.Variables)
+	}
+
+	for _, client := range []string{"Acme", "Globex"} {
+		board, err := bs.InstantiateBoardTemplate(ctx, tmpl.Id, map[string]string{"CLIENT_NAME": client})
+		if err != nil {
+			t.Fatalf("InstantiateBoardTemplate(%s) failed: %v", client, err)
+		}
+		if board.Name != client+" backup" || board.Nodes[1].RemoteName != "gdrive" {
+			t.Errorf("board = %+v", board)
+		}
+	}
+
+	boards, _ := bs.GetBoards(ctx)
+	if len(boards) != 2 {
+		t.Errorf("got %d boards, want 2", len(boards))
+	}
+
+	if _, err := bs.InstantiateBoardTemplate(ctx, tmpl.Id, nil); err == nil {
+		t.Error("expected error when CLIENT_NAME has no value")
+	}
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// cacheDirLocations are the cache locations stored in directories, which can
+// be moved and capped. The listing cache lives in the database.
+var cacheDirLocations = []string{models.CacheLocationTemp, models.CacheLocationRclone, models.CacheLocationStaging}
+
+// GetCacheLocations returns the temp, cache and staging locations with their
+// directories, size caps and current usage
+func (s *SettingsService) GetCacheLocations(ctx context.Context) []models.CacheLocation {
+	configured := s.cacheLocations()
+
+	locations := make([]models.CacheLocation, 0, len(cacheDirLocations)+1)
+	for _, id := range cacheDirLocations {
+		loc := models.CacheLocation{
+			Id:          id,
+			Path:        cacheLocationPath(id, configured[id]),
+			DefaultPath: rclone.DefaultCacheLocationPath(id),
+			MaxSize:     configured[id].MaxSize,
+		}
+		var err error
+		if loc.UsedBytes, loc.Files, err = rclone.CacheLocationUsage(id, loc.Path); err != nil {
+			log.Printf("Warning: Could not measure %s cache at %s: %v", id, loc.Path, err)
+		}
+		locations = append(locations, loc)
+	}
+
+	listing := models.CacheLocation{Id: models.CacheLocationListing}
+	var err error
+	if listing.UsedBytes, listing.Files, err = listingCacheUsage(); err != nil {
+		log.Printf("Warning: Could not measure listing cache: %v", err)
+	}
+	return append(locations, listing)
+}
+
+// SetCacheLocation moves a cache location to another directory and/or sets
+// its size cap. An empty path restores the default directory. Files already
+// in the old directory stay there. Returns warnings, e.g. when moving the
+// rclone cache leaves bisync state behind.
+func (s *SettingsService) SetCacheLocation(ctx context.Context, id string, setting models.CacheLocationSetting) ([]string, error) {
+	if !isCacheDirLocation(id) {
+		return nil, fmt.Errorf("cache location '%s' cannot be configured", id)
+	}
+	if setting.MaxSize != "" {
+		if _, err := parseCacheSize(setting.MaxSize); err != nil {
+			return nil, err
+		}
+	}
+	if setting.Path != "" {
+		setting.Path = filepath.Clean(setting.Path)
+	}
+
+	var previous string
+	if _, err := s.update(func(_ int64, next *AppSettings) error {
+		previous = cacheLocationPath(id, next.CacheLocations[id])
+		if next.CacheLocations == nil {
+			next.CacheLocations = make(map[string]models.CacheLocationSetting)
+		}
+		next.CacheLocations[id] = setting
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	warnings := []string{}
+	if current := cacheLocationPath(id, setting); current != previous {
+		log.Printf("Cache location '%s' moved from %s to %s", id, previous, current)
+		if id == models.CacheLocationRclone {
+			if _, err := os.Stat(filepath.Join(previous, "bisync")); err == nil {
+				warnings = append(warnings, fmt.Sprintf("bisync state stays in %s; move its bisync folder to %s or resync bisync profiles", previous, current))
+			}
+		}
+	}
+	s.enforceCacheCaps()
+	return warnings, nil
+}
+
+// ClearCaches empties the given cache locations (all of them if ids is empty)
+// and reports the space reclaimed in each. Directory locations are skipped
+// while syncs are running, since those may be using their files. Bisync
+// state in the rclone cache is kept.
+func (s *SettingsService) ClearCaches(ctx context.Context, ids []string) ([]models.CacheClearResult, error) {
+	if len(ids) == 0 {
+		ids = append(append([]string{}, cacheDirLocations...), models.CacheLocationListing)
+	}
+	for _, id := range ids {
+		if !isCacheDirLocation(id) && id != models.CacheLocationListing {
+			return nil, fmt.Errorf("unknown cache location '%s'", id)
+		}
+	}
+
+	configured := s.cacheLocations()
+	busy := s.syncService != nil && s.syncService.hasActiveTasks()
+
+	results := make([]models.CacheClearResult, 0, len(ids))
+	for _, id := range ids {
+		result := models.CacheClearResult{Id: id}
+		if id == models.CacheLocationListing {
+			size, count, _ := listingCacheUsage()
+			if err := clearListingCache(""); err != nil {
+				result.Error = err.Error()
+			} else {
+				result.ReclaimedBytes, result.FilesRemoved = size, count
+			}
+			results = append(results, result)
+			continue
+		}
+
+		result.Path = cacheLocationPath(id, configured[id])
+		if busy {
+			result.Error = "syncs are running; try again when they have finished"
+			results = append(results, result)
+			continue
+		}
+		var err error
+		result.ReclaimedBytes, result.FilesRemoved, err = rclone.TrimCacheLocation(id, result.Path, 0)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		log.Printf("Cleared %s cache at %s: %d files, %d bytes", id, result.Path, result.FilesRemoved, result.ReclaimedBytes)
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// enforceCacheCaps trims cache locations above their size cap, oldest files
+// first. It does nothing while syncs are running.
+func (s *SettingsService) enforceCacheCaps() {
+	if s.syncService != nil && s.syncService.hasActiveTasks() {
+		return
+	}
+	configured := s.cacheLocations()
+
+	for _, id := range cacheDirLocations {
+		setting := configured[id]
+		if setting.MaxSize == "" {
+			continue
+		}
+		maxBytes, err := parseCacheSize(setting.MaxSize)
+		if err != nil || maxBytes <= 0 {
+			continue
+		}
+		dir := cacheLocationPath(id, setting)
+		reclaimed, removed, err := rclone.TrimCacheLocation(id, dir, maxBytes)
+		if err != nil {
+			log.Printf("Warning: Could not trim %s cache at %s: %v", id, dir, err)
+		}
+		if removed > 0 {
+			log.Printf("Trimmed %s cache at %s to %s: removed %d files, %d bytes", id, dir, setting.MaxSize, removed, reclaimed)
+		}
+	}
+}
+
+// applyCacheLocations points rclone at the configured cache directories
+func applyCacheLocations(locations map[string]models.CacheLocationSetting) error {
+	paths := make(map[string]string, len(locations))
+	for id, setting := range locations {
+		paths[id] = setting.Path
+	}
+	return rclone.ApplyCacheLocations(paths)
+}
+
+// cacheLocationPath returns the directory a cache location uses
+func cacheLocationPath(id string, setting models.CacheLocationSetting) string {
+	if setting.Path != "" {
+		return setting.Path
+	}
+	return rclone.DefaultCacheLocationPath(id)
+}
+
+// cacheSettingKey splits a "cache_<id>_path" or "cache_<id>_max_size" settings key
+func cacheSettingKey(key string) (id, field string, ok bool) {
+	for _, id := range cacheDirLocations {
+		prefix := "cache_" + id + "_"
+		if strings.HasPrefix(key, prefix) {
+			return id, strings.TrimPrefix(key, prefix), true
+		}
+	}
+	return "", "", false
+}
+
+// isCacheDirLocation reports whether id is a cache location stored in a directory
+func isCacheDirLocation(id string) bool {
+	for _, l := range cacheDirLocations {
+		if l == id {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCacheSize parses a size cap such as "10G"
+func parseCacheSize(size string) (int64, error) {
+	var parsed fs.SizeSuffix
+	if err := parsed.Set(size); err != nil || parsed < 0 {
+		return 0, fmt.Errorf("invalid cache size %q", size)
+	}
+	return int64(parsed), nil
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/delta"
+	"desktop/backend/events"
+	"desktop/backend/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// maxChangeFeedEvents caps how many change feed events are kept for GetChangeFeed
+const maxChangeFeedEvents = 1000
+
+// maxChangeFeedFileSize is the size from which the change feed file is
+// rotated to <path>.1, replacing the previous one
+const maxChangeFeedFileSize = 10 << 20
+
+// changeFeed holds the latest changes the delta watchers detected
+type changeFeed struct {
+	mu      sync.Mutex
+	seq     int64
+	events  []models.ChangeFeedEvent // the latest maxChangeFeedEvents, oldest first
+	fileErr bool                     // writing the file failed; logged once until it works again
+}
+
+// changeFeedEvent normalizes a change a watcher of remoteKey detected
+func changeFeedEvent(remoteKey string, change delta.FileChange) models.ChangeFeedEvent {
+	event := models.ChangeFeedEvent{
+		Remote:     strings.TrimPrefix(remoteKey, "local:"),
+		Path:       change.Path,
+		OldPath:    change.OldPath,
+		IsDir:      change.EntryType == fs.EntryDirectory,
+		DetectedAt: change.DetectedAt,
+	}
+	if event.DetectedAt.IsZero() {
+		event.DetectedAt = time.Now()
+	}
+	event.FullPath = event.Remote
+	if paths := changedRemotePaths(remoteKey, change); len(paths) > 0 {
+		event.FullPath = paths[0]
+	}
+
+	switch {
+	case change.Type == delta.ChangeDeleted:
+		event.Type = models.ChangeFeedDeleted
+	case change.Type == delta.ChangeRenamed:
+		event.Type = models.ChangeFeedRenamed
+	case change.Created:
+		event.Type = models.ChangeFeedCreated
+	default:
+		event.Type = models.ChangeFeedModified
+	}
+	return event
+}
+
+// publishChange adds a change a watcher detected to the change feed: it is
+// kept for GetChangeFeed, appended to the change feed file if one is set and
+// emitted as delta:change
+func (s *SyncService) publishChange(remoteKey string, change delta.FileChange) {
+	feed := &s.changeFeed
+	feed.mu.Lock()
+	feed.seq++
+	event := changeFeedEvent(remoteKey, change)
+	event.Seq = feed.seq
+	feed.events = append(feed.events, event)
+	if len(feed.events) > maxChangeFeedEvents {
+		feed.events = feed.events[len(feed.events)-maxChangeFeedEvents:]
+	}
+
+	// Written under the lock so lines stay in sequence
+	if path := s.changeFeedPath(); path != "" {
+		err := appendChangeFeedLine(path, event)
+		if err != nil && !feed.fileErr {
+			log.Printf("warning: failed to write the change feed to %s: %v", path, err)
+		}
+		feed.fileErr = err != nil
+	}
+	feed.mu.Unlock()
+
+	s.emitDeltaEvent(events.DeltaChange, remoteKey, event)
+}
+
+// changeFeedPath returns the file the change feed is written to, or "" if none
+func (s *SyncService) changeFeedPath() string {
+	if s.settingsService == nil {
+		return ""
+	}
+	return s.settingsService.GetChangeFeedPath(context.Background())
+}
+
+// appendChangeFeedLine appends an event to a JSON Lines file, rotating it
+// first when it would grow beyond maxChangeFeedFileSize
+func appendChangeFeedLine(path string, event models.ChangeFeedEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if info, err := os.Stat(path); err == nil && info.Size()+int64(len(line)) > maxChangeFeedFileSize {
+		if err := os.Rename(path, path+".1"); err != nil {
+			return fmt.Errorf("failed to rotate: %w", err)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// GetChangeFeed returns the changes the delta watchers detected after the
+// event numbered after, oldest first and at most limit (0 = all kept). The
+// latest 1000 events since the app started are kept; when the first one
+// returned isn't after+1, the ones in between were missed.
+func (s *SyncService) GetChangeFeed(ctx context.Context, after int64, limit int) []models.ChangeFeedEvent {
+	feed := &s.changeFeed
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+
+	result := []models.ChangeFeedEvent{}
+	for _, event := range feed.events {
+		if event.Seq <= after {
+			continue
+		}
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+		result = append(result, event)
+	}
+	return result
+}
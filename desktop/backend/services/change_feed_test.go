@@ -0,0 +1,103 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"desktop/backend/delta"
+	"desktop/backend/models"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+)
+
+func TestChangeFeedEvent(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		remoteKey string
+		change    delta.FileChange
+		want      models.ChangeFeedEvent
+	}{
+		{
+			name:      "modified",
+			remoteKey: "gdrive:Photos",
+			change:    delta.FileChange{Path: "2024/a.jpg", EntryType: fs.EntryObject, Type: delta.ChangeModified, DetectedAt: at},
+			want:      models.ChangeFeedEvent{Remote: "gdrive:Photos", Path: "2024/a.jpg", FullPath: "gdrive:Photos/2024/a.jpg", Type: "modified", DetectedAt: at},
+		},
+		{
+			name:      "created at the root",
+			remoteKey: "dropbox:",
+			change:    delta.FileChange{Path: "a.txt", EntryType: fs.EntryObject, Type: delta.ChangeModified, Created: true, DetectedAt: at},
+			want:      models.ChangeFeedEvent{Remote: "dropbox:", Path: "a.txt", FullPath: "dropbox:a.txt", Type: "created", DetectedAt: at},
+		},
+		{
+			name:      "renamed",
+			remoteKey: "gdrive:Photos",
+			change:    delta.FileChange{Path: "b.jpg", OldPath: "a.jpg", EntryType: fs.EntryObject, Type: delta.ChangeRenamed, DetectedAt: at},
+			want:      models.ChangeFeedEvent{Remote: "gdrive:Photos", Path: "b.jpg", OldPath: "a.jpg", FullPath: "gdrive:Photos/b.jpg", Type: "renamed", DetectedAt: at},
+		},
+		{
+			name:      "deleted local directory",
+			remoteKey: "local:/home/user/docs",
+			change:    delta.FileChange{Path: "old", EntryType: fs.EntryDirectory, Type: delta.ChangeDeleted, DetectedAt: at},
+			want:      models.ChangeFeedEvent{Remote: "/home/user/docs", Path: "old", FullPath: "/home/user/docs/old", Type: "deleted", IsDir: true, DetectedAt: at},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := changeFeedEvent(tt.remoteKey, tt.change); got != tt.want {
+				t.Errorf("changeFeedEvent() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncService_ChangeFeed(t *testing.T) {
+	settings := NewSettingsService(nil)
+	s := NewSyncService(nil)
+	s.settingsService = settings
+	ctx := context.Background()
+
+	feedPath := filepath.Join(t.TempDir(), "changes.jsonl")
+	if err := validateChangeFeedPath(feedPath); err != nil {
+		t.Fatalf("validateChangeFeedPath: %v", err)
+	}
+	if err := validateChangeFeedPath("changes.jsonl"); err == nil {
+		t.Error("expected a relative path to be refused")
+	}
+	settings.settings.ChangeFeedPath = feedPath
+
+	for _, p := range []string{"a.txt", "b.txt", "c.txt"} {
+		s.publishChange("gdrive:docs", delta.FileChange{Path: p, EntryType: fs.EntryObject, Type: delta.ChangeModified})
+	}
+
+	events := s.GetChangeFeed(ctx, 1, 0)
+	if len(events) != 2 || events[0].Seq != 2 || events[0].Path != "b.txt" {
+		t.Fatalf("events after 1 = %+v", events)
+	}
+	if events := s.GetChangeFeed(ctx, 0, 1); len(events) != 1 || events[0].Seq != 1 {
+		t.Errorf("first event = %+v", events)
+	}
+
+	f, err := os.Open(feedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var lines []models.ChangeFeedEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event models.ChangeFeedEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, event)
+	}
+	if len(lines) != 3 || lines[2].Seq != 3 || lines[2].FullPath != "gdrive:docs/c.txt" {
+		t.Errorf("change feed file = %+v", lines)
+	}
+}
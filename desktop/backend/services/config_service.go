@@ -2,10 +2,14 @@ package services
 
 import (
 	"context"
+	"database/sql"
 	"desktop/backend/config"
 	"desktop/backend/events"
 	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"desktop/backend/utils"
 	"desktop/backend/validation"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -23,6 +27,10 @@ type ConfigService struct {
 	mutex       sync.RWMutex
 	initialized bool
 	validator   *validation.ProfileValidator
+
+	// Dependencies
+	schedulerService *SchedulerService
+	historyService   *HistoryService
 }
 
 // NewConfigService creates a new config service
@@ -45,6 +53,16 @@ func (c *ConfigService) SetApp(app *application.App) {
 	}
 }
 
+// SetSchedulerService sets the scheduler whose schedules follow merged profiles
+func (c *ConfigService) SetSchedulerService(schedulerService *SchedulerService) {
+	c.schedulerService = schedulerService
+}
+
+// SetHistoryService sets the history that exclusion suggestions are drawn from
+func (c *ConfigService) SetHistoryService(historyService *HistoryService) {
+	c.historyService = historyService
+}
+
 // ServiceName returns the name of the service
 func (c *ConfigService) ServiceName() string {
 	return "ConfigService"
@@ -83,13 +101,10 @@ func (c *ConfigService) initializeConfig(ctx context.Context) error {
 		}
 		c.configInfo.WorkingDir = shared.WorkingDir
 	} else {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			homeDir = "."
-		}
+		configDir := utils.ConfigDir()
 		c.configInfo.EnvConfig = config.Config{
-			ProfileFilePath: filepath.Join(homeDir, ".config", "gn-drive", "profiles.json"),
-			RcloneFilePath:  filepath.Join(homeDir, ".config", "gn-drive", "rclone.conf"),
+			ProfileFilePath: filepath.Join(configDir, "profiles.json"),
+			RcloneFilePath:  filepath.Join(configDir, "rclone.conf"),
 		}
 		wd, _ := os.Getwd()
 		c.configInfo.WorkingDir = wd
@@ -261,7 +276,7 @@ func (c *ConfigService) DeleteProfile(ctx context.Context, profileName string) e
 	}
 
 	// Delete from database
-	if err := c.deleteProfileFromDB(profileName); err != nil {
+	if err := c.deleteProfileFromDB(deletedProfile); err != nil {
 		// Rollback in-memory
 		c.configInfo.Profiles = append(c.configInfo.Profiles, deletedProfile)
 		return fmt.Errorf("failed to delete profile: %w", err)
@@ -276,7 +291,26 @@ func (c *ConfigService) DeleteProfile(ctx context.Context, profileName string) e
 
 // validateProfile validates a profile using the comprehensive validator
 func (c *ConfigService) validateProfile(profile models.Profile) error {
-	return c.validator.ValidateProfile(profile)
+	if err := c.validator.ValidateProfile(profile); err != nil {
+		return err
+	}
+	if err := validateRunWebhooks(profile.FreshnessWebhooks); err != nil {
+		return &validation.ValidationError{Field: "freshness_webhooks", Message: err.Error()}
+	}
+	if err := validateRemoteHooks(profile.RemoteHooks); err != nil {
+		return &validation.ValidationError{Field: "remote_hooks", Message: err.Error()}
+	}
+	if _, err := rclone.CheckRawFlags(profile.RawFlags); err != nil {
+		return &validation.ValidationError{Field: "raw_flags", Message: err.Error()}
+	}
+	return nil
+}
+
+// CheckRawFlags checks raw rclone flags for a profile against rclone's flag
+// registry, labelling each with its risk, so they can be reviewed before
+// the profile is saved
+func (c *ConfigService) CheckRawFlags(ctx context.Context, flags []string) ([]models.RawFlag, error) {
+	return rclone.CheckRawFlags(flags)
 }
 
 // saveProfiles saves a single profile to the database (INSERT or UPDATE)
@@ -285,12 +319,54 @@ func (c *ConfigService) saveProfileToDB(p models.Profile) error {
 	if err != nil {
 		return err
 	}
+	return saveProfile(db, p)
+}
 
-	_, err = db.Exec(`INSERT OR REPLACE INTO profiles (name, from_path, to_path, included_paths, excluded_paths,
+// saveProfile writes a profile through the database or a transaction
+func saveProfile(db interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}, p models.Profile) error {
+	freshnessWebhooks := ""
+	if len(p.FreshnessWebhooks) > 0 {
+		data, err := json.Marshal(p.FreshnessWebhooks)
+		if err != nil {
+			return fmt.Errorf("failed to marshal freshness webhooks: %w", err)
+		}
+		freshnessWebhooks = string(data)
+	}
+	remoteHooks := ""
+	if len(p.RemoteHooks) > 0 {
+		data, err := json.Marshal(p.RemoteHooks)
+		if err != nil {
+			return fmt.Errorf("failed to marshal remote hooks: %w", err)
+		}
+		remoteHooks = string(data)
+	}
+	conflictNaming := ""
+	if p.ConflictNaming != nil {
+		data, err := json.Marshal(p.ConflictNaming)
+		if err != nil {
+			return fmt.Errorf("failed to marshal conflict naming: %w", err)
+		}
+		conflictNaming = string(data)
+	}
+	unknownFields := ""
+	if len(p.UnknownFields) > 0 {
+		data, err := json.Marshal(p.UnknownFields)
+		if err != nil {
+			return fmt.Errorf("failed to marshal unknown fields: %w", err)
+		}
+		unknownFields = string(data)
+	}
+	_, err := db.Exec(`INSERT OR REPLACE INTO profiles (name, from_path, to_path, included_paths, excluded_paths,
 		bandwidth, parallel, backup_path, cache_path, min_size, max_size, filter_from_file,
 		exclude_if_present, use_regex, max_delete, immutable, conflict_resolution,
-		multi_thread_streams, buffer_size, retries, low_level_retries, max_duration)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		multi_thread_streams, buffer_size, retries, low_level_retries, max_duration, notify_mode, quick_check,
+		bind_address, ip_family, fan_out_to, fan_out_mode, resume_interrupted,
+		storage_class, upload_headers, server_side_encryption, sse_kms_key_id, failover_to, write_manifest,
+		session_transfer, session_order, freshness_target, freshness_webhooks, transfer_order, locked_files,
+		compress_dest, compress_mode, remote_hooks, conflict_loser, conflict_suffix, conflict_naming, defer_deletes, detect_conflicts, raw_flags, unknown_fields)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		p.Name, p.From, p.To,
 		marshalStringSlice(p.IncludedPaths), marshalStringSlice(p.ExcludedPaths),
 		p.Bandwidth, p.Parallel, p.BackupPath, p.CachePath,
@@ -298,18 +374,20 @@ func (c *ConfigService) saveProfileToDB(p models.Profile) error {
 		boolToInt(p.UseRegex), intPtrToNullable(p.MaxDelete), boolToInt(p.Immutable),
 		p.ConflictResolution, intPtrToNullable(p.MultiThreadStreams),
 		p.BufferSize,
-		intPtrToNullable(p.Retries), intPtrToNullable(p.LowLevelRetries), p.MaxDuration)
+		intPtrToNullable(p.Retries), intPtrToNullable(p.LowLevelRetries), p.MaxDuration, p.NotifyMode,
+		boolToInt(p.QuickCheck), p.BindAddress, p.IPFamily, marshalStringSlice(p.FanOutTo), p.FanOutMode, p.ResumeInterrupted,
+		p.StorageClass, marshalStringSlice(p.UploadHeaders), p.ServerSideEncryption, p.SSEKMSKeyId, p.FailoverTo, boolToInt(p.WriteManifest),
+		p.SessionTransfer, p.SessionOrder, p.FreshnessTarget, freshnessWebhooks, p.TransferOrder, p.LockedFiles,
+		boolToInt(p.CompressDest), p.CompressMode, remoteHooks, p.ConflictLoser, p.ConflictSuffix, conflictNaming, p.DeferDeletes, boolToInt(p.DetectConflicts), marshalStringSlice(p.RawFlags), unknownFields)
 	return err
 }
 
-// deleteProfileFromDB deletes a profile from the database
-func (c *ConfigService) deleteProfileFromDB(name string) error {
-	db, err := GetSharedDB()
-	if err != nil {
-		return err
-	}
-	_, err = db.Exec("DELETE FROM profiles WHERE name = ?", name)
-	return err
+// deleteProfileFromDB deletes a profile from the database and moves it to
+// the trash. Its history rows keep the profile name, so they link up again
+// when it is restored.
+func (c *ConfigService) deleteProfileFromDB(p models.Profile) error {
+	p.StripEncryptPasswords()
+	return deleteWithTrash(models.TrashProfile, p.Name, p.Name, p, "DELETE FROM profiles WHERE name = ?", p.Name)
 }
 
 // loadProfilesFromDB loads all profiles from the database
@@ -322,7 +400,11 @@ func (c *ConfigService) loadProfilesFromDB() ([]models.Profile, error) {
 	rows, err := db.Query(`SELECT name, from_path, to_path, included_paths, excluded_paths,
 		bandwidth, parallel, backup_path, cache_path, min_size, max_size, filter_from_file,
 		exclude_if_present, use_regex, max_delete, immutable, conflict_resolution,
-		multi_thread_streams, buffer_size, retries, low_level_retries, max_duration
+		multi_thread_streams, buffer_size, retries, low_level_retries, max_duration, notify_mode, quick_check,
+		bind_address, ip_family, fan_out_to, fan_out_mode, resume_interrupted,
+		storage_class, upload_headers, server_side_encryption, sse_kms_key_id, failover_to, write_manifest,
+		session_transfer, session_order, freshness_target, freshness_webhooks, transfer_order, locked_files,
+		compress_dest, compress_mode, remote_hooks, conflict_loser, conflict_suffix, conflict_naming, defer_deletes, detect_conflicts, raw_flags, unknown_fields
 		FROM profiles ORDER BY name`)
 	if err != nil {
 		return nil, err
@@ -332,8 +414,8 @@ func (c *ConfigService) loadProfilesFromDB() ([]models.Profile, error) {
 	var profiles []models.Profile
 	for rows.Next() {
 		var p models.Profile
-		var includedPaths, excludedPaths string
-		var useRegex, immutable int
+		var includedPaths, excludedPaths, fanOutTo, uploadHeaders, freshnessWebhooks, remoteHooks, conflictNaming, rawFlags, unknownFields string
+		var useRegex, immutable, quickCheck, writeManifest, compressDest, detectConflicts int
 		var maxDelete, multiThreadStreams, retries, lowLevelRetries *int
 
 		if err := rows.Scan(&p.Name, &p.From, &p.To, &includedPaths, &excludedPaths,
@@ -341,14 +423,45 @@ func (c *ConfigService) loadProfilesFromDB() ([]models.Profile, error) {
 			&p.MinSize, &p.MaxSize, &p.FilterFromFile, &p.ExcludeIfPresent,
 			&useRegex, &maxDelete, &immutable, &p.ConflictResolution,
 			&multiThreadStreams, &p.BufferSize,
-			&retries, &lowLevelRetries, &p.MaxDuration); err != nil {
+			&retries, &lowLevelRetries, &p.MaxDuration, &p.NotifyMode, &quickCheck,
+			&p.BindAddress, &p.IPFamily, &fanOutTo, &p.FanOutMode, &p.ResumeInterrupted,
+			&p.StorageClass, &uploadHeaders, &p.ServerSideEncryption, &p.SSEKMSKeyId, &p.FailoverTo, &writeManifest,
+			&p.SessionTransfer, &p.SessionOrder, &p.FreshnessTarget, &freshnessWebhooks, &p.TransferOrder, &p.LockedFiles,
+			&compressDest, &p.CompressMode, &remoteHooks, &p.ConflictLoser, &p.ConflictSuffix, &conflictNaming, &p.DeferDeletes, &detectConflicts, &rawFlags, &unknownFields); err != nil {
 			return nil, fmt.Errorf("failed to scan profile: %w", err)
 		}
 
 		p.IncludedPaths = unmarshalStringSlice(includedPaths)
 		p.ExcludedPaths = unmarshalStringSlice(excludedPaths)
+		p.FanOutTo = unmarshalStringSlice(fanOutTo)
+		p.UploadHeaders = unmarshalStringSlice(uploadHeaders)
+		p.RawFlags = unmarshalStringSlice(rawFlags)
+		if freshnessWebhooks != "" {
+			if err := json.Unmarshal([]byte(freshnessWebhooks), &p.FreshnessWebhooks); err != nil {
+				log.Printf("Warning: failed to unmarshal freshness webhooks of profile '%s': %v", p.Name, err)
+			}
+		}
+		if remoteHooks != "" {
+			if err := json.Unmarshal([]byte(remoteHooks), &p.RemoteHooks); err != nil {
+				log.Printf("Warning: failed to unmarshal remote hooks of profile '%s': %v", p.Name, err)
+			}
+		}
+		if conflictNaming != "" {
+			if err := json.Unmarshal([]byte(conflictNaming), &p.ConflictNaming); err != nil {
+				log.Printf("Warning: failed to unmarshal conflict naming of profile '%s': %v", p.Name, err)
+			}
+		}
+		if unknownFields != "" {
+			if err := json.Unmarshal([]byte(unknownFields), &p.UnknownFields); err != nil {
+				log.Printf("Warning: failed to unmarshal unknown fields of profile '%s': %v", p.Name, err)
+			}
+		}
 		p.UseRegex = useRegex != 0
 		p.Immutable = immutable != 0
+		p.QuickCheck = quickCheck != 0
+		p.WriteManifest = writeManifest != 0
+		p.CompressDest = compressDest != 0
+		p.DetectConflicts = detectConflicts != 0
 		p.MaxDelete = maxDelete
 		p.MultiThreadStreams = multiThreadStreams
 		p.Retries = retries
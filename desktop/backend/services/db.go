@@ -80,6 +80,30 @@ func InitDatabase() error {
 	// Add new columns to profiles table
 	migrateProfilesNewColumns(db)
 
+	// Add schedule target and overlap policy columns
+	migrateSchedulesNewColumns(db)
+
+	// Add classified error code, delta run info and transfer report to history
+	migrateHistoryNewColumns(db)
+
+	// Add delta run counters to delta_state
+	migrateDeltaStateNewColumns(db)
+
+	// Move pending delta changes saved as JSON into delta_changes
+	migrateDeltaPendingChanges(db)
+
+	// Add board execution mode, parallelism, retry budget and run webhooks
+	migrateBoardsNewColumns(db)
+
+	// Add flow step type and per-type step settings columns
+	migrateOperationsNewColumns(db)
+
+	// Add flow run webhooks
+	migrateFlowsNewColumns(db)
+
+	// Add how verifications compared encrypted destinations
+	migrateVerifyReportsNewColumns(db)
+
 	migrateFromJSON(db)
 	return nil
 }
@@ -150,6 +174,12 @@ func createAllTables(db *sql.DB) error {
 			created_at   TEXT NOT NULL DEFAULT (datetime('now'))
 		);
 
+		-- When the scheduler last ran, to catch up schedules missed while the app was closed
+		CREATE TABLE IF NOT EXISTS scheduler_heartbeat (
+			id       INTEGER PRIMARY KEY CHECK (id = 1),
+			alive_at TEXT NOT NULL
+		);
+
 		-- Operation history (capped at 1000 rows)
 		CREATE TABLE IF NOT EXISTS history (
 			id                TEXT PRIMARY KEY,
@@ -203,6 +233,16 @@ func createAllTables(db *sql.DB) error {
 			FOREIGN KEY (board_id) REFERENCES boards(id) ON DELETE CASCADE
 		);
 
+		CREATE TABLE IF NOT EXISTS board_templates (
+			id          TEXT PRIMARY KEY,
+			name        TEXT NOT NULL DEFAULT '',
+			description TEXT NOT NULL DEFAULT '',
+			variables   TEXT NOT NULL DEFAULT '[]',
+			board       TEXT NOT NULL DEFAULT '{}',
+			created_at  TEXT NOT NULL DEFAULT (datetime('now')),
+			updated_at  TEXT NOT NULL DEFAULT (datetime('now'))
+		);
+
 		-- Flows
 		CREATE TABLE IF NOT EXISTS flows (
 			id               TEXT PRIMARY KEY,
@@ -231,6 +271,171 @@ func createAllTables(db *sql.DB) error {
 		);
 		CREATE INDEX IF NOT EXISTS idx_operations_flow_id ON operations(flow_id);
 
+		-- Secrets vault (values used by flow steps, e.g. API tokens)
+		CREATE TABLE IF NOT EXISTS secrets (
+			name       TEXT PRIMARY KEY,
+			value      TEXT NOT NULL DEFAULT '',
+			updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+		);
+
+		-- Sync runs in progress, kept until they end; rows left at startup are runs the app exited during
+		CREATE TABLE IF NOT EXISTS running_tasks (
+			id           INTEGER PRIMARY KEY AUTOINCREMENT,
+			session      TEXT NOT NULL,
+			task_id      INTEGER NOT NULL,
+			action       TEXT NOT NULL,
+			tab_id       TEXT NOT NULL DEFAULT '',
+			profile      TEXT NOT NULL,
+			failed_files TEXT NOT NULL DEFAULT '[]',
+			started_at   TEXT NOT NULL,
+			updated_at   TEXT NOT NULL
+		);
+
+		-- Hashes of files that are slow to hash (local disks), reused while the file is unchanged
+		CREATE TABLE IF NOT EXISTS checksum_cache (
+			path      TEXT NOT NULL,
+			hash_type TEXT NOT NULL,
+			size      INTEGER NOT NULL,
+			mod_time  TEXT NOT NULL,
+			hash      TEXT NOT NULL,
+			PRIMARY KEY (path, hash_type)
+		);
+
+		-- Reports of restores of backups into local folders (capped at 100 rows)
+		CREATE TABLE IF NOT EXISTS restore_reports (
+			id              INTEGER PRIMARY KEY AUTOINCREMENT,
+			profile_name    TEXT NOT NULL DEFAULT '',
+			source          TEXT NOT NULL DEFAULT '',
+			target          TEXT NOT NULL DEFAULT '',
+			status          TEXT NOT NULL DEFAULT '',
+			files_restored  INTEGER NOT NULL DEFAULT 0,
+			bytes_restored  INTEGER NOT NULL DEFAULT 0,
+			manifest_run_id TEXT NOT NULL DEFAULT '',
+			hash_type       TEXT NOT NULL DEFAULT '',
+			verified        INTEGER NOT NULL DEFAULT 0,
+			mismatched      TEXT NOT NULL DEFAULT '[]',
+			mismatch_count  INTEGER NOT NULL DEFAULT 0,
+			missing         TEXT NOT NULL DEFAULT '[]',
+			missing_count   INTEGER NOT NULL DEFAULT 0,
+			error_message   TEXT NOT NULL DEFAULT '',
+			start_time      TEXT NOT NULL DEFAULT '',
+			end_time        TEXT NOT NULL DEFAULT ''
+		);
+
+		-- Profiles synced in capped sessions, with the files left for the next session
+		CREATE TABLE IF NOT EXISTS transfer_sessions (
+			profile_name       TEXT NOT NULL,
+			action             TEXT NOT NULL,
+			session_order      TEXT NOT NULL DEFAULT '',
+			status             TEXT NOT NULL DEFAULT '',
+			sessions           INTEGER NOT NULL DEFAULT 0,
+			last_session_files INTEGER NOT NULL DEFAULT 0,
+			last_session_bytes INTEGER NOT NULL DEFAULT 0,
+			remaining_files    INTEGER NOT NULL DEFAULT 0,
+			remaining_bytes    INTEGER NOT NULL DEFAULT 0,
+			queue              TEXT NOT NULL DEFAULT '[]',
+			started_at         TEXT NOT NULL DEFAULT '',
+			updated_at         TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (profile_name, action)
+		);
+
+		-- Profiles with a freshness target: since when they are watched and, while overdue, how far their alert escalated
+		CREATE TABLE IF NOT EXISTS freshness_alerts (
+			profile_name  TEXT PRIMARY KEY,
+			level         TEXT NOT NULL DEFAULT '',
+			watched_since TEXT NOT NULL DEFAULT ''
+		);
+
+		-- Deleted profiles, boards, flows and schedules, restorable until purged
+		CREATE TABLE IF NOT EXISTS trash (
+			kind       TEXT NOT NULL,
+			id         TEXT NOT NULL,
+			name       TEXT NOT NULL DEFAULT '',
+			data       TEXT NOT NULL,
+			deleted_at TEXT NOT NULL,
+			PRIMARY KEY (kind, id)
+		);
+
+		-- Path variables of profile paths, and the local path each stands for on this machine
+		CREATE TABLE IF NOT EXISTS path_variables (
+			name TEXT PRIMARY KEY,
+			path TEXT NOT NULL
+		);
+
+		-- Settings sync with other installations (one row when set up): where the archive is,
+		-- and the hashes of both sides' configuration at the last sync
+		CREATE TABLE IF NOT EXISTS settings_sync (
+			id                INTEGER PRIMARY KEY CHECK (id = 1),
+			remote_path       TEXT NOT NULL,
+			password          TEXT NOT NULL,
+			machine_id        TEXT NOT NULL,
+			interval_minutes  INTEGER NOT NULL DEFAULT 0,
+			local_hash        TEXT NOT NULL DEFAULT '',
+			remote_hash       TEXT NOT NULL DEFAULT '',
+			local_changed_at  TEXT NOT NULL DEFAULT '',
+			last_sync_at      TEXT NOT NULL DEFAULT '',
+			last_result       TEXT NOT NULL DEFAULT '',
+			last_error        TEXT NOT NULL DEFAULT '',
+			warnings          TEXT NOT NULL DEFAULT '',
+			conflict          TEXT NOT NULL DEFAULT ''
+		);
+
+		-- Profiles that failed over, until their primary destination is synced again
+		CREATE TABLE IF NOT EXISTS failover_reconciliations (
+			profile_name  TEXT PRIMARY KEY,
+			profile       TEXT NOT NULL,
+			primary_path  TEXT NOT NULL,
+			fallback_path TEXT NOT NULL,
+			reason        TEXT NOT NULL DEFAULT '',
+			since         TEXT NOT NULL
+		);
+
+		-- Estimated API calls per provider per day, for the daily API budget
+		CREATE TABLE IF NOT EXISTS api_usage (
+			day      TEXT NOT NULL,
+			provider TEXT NOT NULL,
+			calls    INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (day, provider)
+		);
+
+		-- Provider-reported usage per remote against the bytes written to it since the last check
+		CREATE TABLE IF NOT EXISTS remote_usage (
+			remote        TEXT PRIMARY KEY,
+			used          INTEGER NOT NULL DEFAULT 0,
+			written       INTEGER NOT NULL DEFAULT 0,
+			since         TEXT NOT NULL,
+			usage_delta   INTEGER NOT NULL DEFAULT 0,
+			written_bytes INTEGER NOT NULL DEFAULT 0,
+			flagged       INTEGER NOT NULL DEFAULT 0,
+			period_start  TEXT,
+			checked_at    TEXT NOT NULL
+		);
+
+		-- Files pushes and pulls left out because they changed on both sides since the last run, until resolved
+		CREATE TABLE IF NOT EXISTS sync_conflicts (
+			id              INTEGER PRIMARY KEY AUTOINCREMENT,
+			profile_name    TEXT NOT NULL,
+			action          TEXT NOT NULL,
+			profile         TEXT NOT NULL,
+			source          TEXT NOT NULL,
+			dest            TEXT NOT NULL,
+			path            TEXT NOT NULL,
+			source_size     INTEGER NOT NULL DEFAULT 0,
+			source_mod_time TEXT NOT NULL DEFAULT '',
+			dest_size       INTEGER NOT NULL DEFAULT 0,
+			dest_mod_time   TEXT NOT NULL DEFAULT '',
+			detected_at     TEXT NOT NULL,
+			UNIQUE (profile_name, action, path)
+		);
+
+		-- When the conflict check of each push or pull of a profile last started before a successful run
+		CREATE TABLE IF NOT EXISTS conflict_baselines (
+			profile_name TEXT NOT NULL,
+			action       TEXT NOT NULL,
+			since        TEXT NOT NULL,
+			PRIMARY KEY (profile_name, action)
+		);
+
 		-- Delta sync state (tracks watcher/change-notification state per remote endpoint)
 		CREATE TABLE IF NOT EXISTS delta_state (
 			remote_key     TEXT PRIMARY KEY,
@@ -241,6 +446,159 @@ func createAllTables(db *sql.DB) error {
 			created_at     TEXT NOT NULL DEFAULT (datetime('now')),
 			updated_at     TEXT NOT NULL DEFAULT (datetime('now'))
 		);
+
+		-- Changes delta watchers collected that no sync picked up yet, flushed
+		-- periodically so they survive restarts and crashes
+		CREATE TABLE IF NOT EXISTS delta_changes (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			remote_key  TEXT NOT NULL,
+			path        TEXT NOT NULL,
+			old_path    TEXT NOT NULL DEFAULT '',
+			entry_type  INTEGER NOT NULL DEFAULT 0,
+			change_type INTEGER NOT NULL DEFAULT 0,
+			object_id   TEXT NOT NULL DEFAULT '',
+			created     INTEGER NOT NULL DEFAULT 0,
+			detected_at TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_delta_changes_remote_key ON delta_changes(remote_key, id);
+
+		-- Storage lifecycle rules (move old files to another remote)
+		CREATE TABLE IF NOT EXISTS lifecycle_rules (
+			id             TEXT PRIMARY KEY,
+			name           TEXT NOT NULL,
+			source_path    TEXT NOT NULL,
+			target_path    TEXT NOT NULL,
+			min_age        TEXT NOT NULL,
+			included_paths TEXT NOT NULL DEFAULT '[]',
+			excluded_paths TEXT NOT NULL DEFAULT '[]',
+			max_files      INTEGER NOT NULL DEFAULT 0,
+			max_bytes      TEXT NOT NULL DEFAULT '',
+			last_run       TEXT,
+			last_result    TEXT NOT NULL DEFAULT '',
+			created_at     TEXT NOT NULL DEFAULT (datetime('now'))
+		);
+
+		-- Files moved by lifecycle rules, kept for later retrieval
+		CREATE TABLE IF NOT EXISTS lifecycle_moves (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			rule_id     TEXT NOT NULL,
+			rule_name   TEXT NOT NULL DEFAULT '',
+			path        TEXT NOT NULL,
+			source_path TEXT NOT NULL,
+			target_path TEXT NOT NULL,
+			size        INTEGER NOT NULL DEFAULT 0,
+			mod_time    TEXT NOT NULL DEFAULT '',
+			moved_at    TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_lifecycle_moves_rule_id ON lifecycle_moves(rule_id);
+
+		-- Reports of read-only verifications of profiles' destinations
+		CREATE TABLE IF NOT EXISTS verify_reports (
+			id             INTEGER PRIMARY KEY AUTOINCREMENT,
+			schedule_id    TEXT NOT NULL DEFAULT '',
+			profile_name   TEXT NOT NULL,
+			source         TEXT NOT NULL DEFAULT '',
+			destination    TEXT NOT NULL DEFAULT '',
+			download       INTEGER NOT NULL DEFAULT 0,
+			crypt_check    INTEGER NOT NULL DEFAULT 0,
+			sample         TEXT NOT NULL DEFAULT '',
+			status         TEXT NOT NULL,
+			matched        INTEGER NOT NULL DEFAULT 0,
+			differ         TEXT NOT NULL DEFAULT '[]',
+			differ_count   INTEGER NOT NULL DEFAULT 0,
+			missing_on_dst TEXT NOT NULL DEFAULT '[]',
+			missing_count  INTEGER NOT NULL DEFAULT 0,
+			missing_on_src TEXT NOT NULL DEFAULT '[]',
+			extra_count    INTEGER NOT NULL DEFAULT 0,
+			errors         TEXT NOT NULL DEFAULT '[]',
+			error_count    INTEGER NOT NULL DEFAULT 0,
+			error_message  TEXT NOT NULL DEFAULT '',
+			start_time     TEXT NOT NULL,
+			end_time       TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_verify_reports_profile_name ON verify_reports(profile_name);
+
+		-- Reports of read-only checks of whether profiles' destinations still mirror their sources
+		CREATE TABLE IF NOT EXISTS drift_reports (
+			id             INTEGER PRIMARY KEY AUTOINCREMENT,
+			schedule_id    TEXT NOT NULL DEFAULT '',
+			profile_name   TEXT NOT NULL,
+			source         TEXT NOT NULL DEFAULT '',
+			destination    TEXT NOT NULL DEFAULT '',
+			status         TEXT NOT NULL,
+			matched        INTEGER NOT NULL DEFAULT 0,
+			new_count      INTEGER NOT NULL DEFAULT 0,
+			changed_count  INTEGER NOT NULL DEFAULT 0,
+			extra_count    INTEGER NOT NULL DEFAULT 0,
+			error_count    INTEGER NOT NULL DEFAULT 0,
+			drifting_since TEXT,
+			error_message  TEXT NOT NULL DEFAULT '',
+			start_time     TEXT NOT NULL,
+			end_time       TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_drift_reports_profile_name ON drift_reports(profile_name);
+
+		-- Differences between profiles' sources and destinations, from the drift check that first found them until one no longer does
+		CREATE TABLE IF NOT EXISTS drift_files (
+			profile_name TEXT NOT NULL,
+			path         TEXT NOT NULL,
+			kind         TEXT NOT NULL,
+			first_seen   TEXT NOT NULL,
+			last_seen    TEXT NOT NULL,
+			PRIMARY KEY (profile_name, path)
+		);
+
+		-- Remote directory listings kept for offline browsing
+		CREATE TABLE IF NOT EXISTS listing_cache (
+			remote     TEXT NOT NULL,
+			path       TEXT NOT NULL,
+			entries    TEXT NOT NULL DEFAULT '[]',
+			fetched_at TEXT NOT NULL,
+			stale      INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (remote, path)
+		);
+
+		-- Snoozed or dismissed notification categories
+		CREATE TABLE IF NOT EXISTS notification_snoozes (
+			category   TEXT PRIMARY KEY,
+			until      TEXT NOT NULL DEFAULT '',
+			severity   TEXT NOT NULL DEFAULT 'info',
+			created_at TEXT NOT NULL
+		);
+
+		-- Daily totals of history entries rolled up by maintenance or the history cap
+		CREATE TABLE IF NOT EXISTS history_daily (
+			day               TEXT NOT NULL,
+			profile_name      TEXT NOT NULL DEFAULT '',
+			action            TEXT NOT NULL DEFAULT '',
+			runs              INTEGER NOT NULL DEFAULT 0,
+			completed         INTEGER NOT NULL DEFAULT 0,
+			failed            INTEGER NOT NULL DEFAULT 0,
+			cancelled         INTEGER NOT NULL DEFAULT 0,
+			files_transferred INTEGER NOT NULL DEFAULT 0,
+			bytes_transferred INTEGER NOT NULL DEFAULT 0,
+			errors            INTEGER NOT NULL DEFAULT 0,
+			duration_ms       INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (day, profile_name, action)
+		);
+
+		-- Host keys and certificates of self-hosted remotes' servers that were trusted
+		CREATE TABLE IF NOT EXISTS remote_pins (
+			remote              TEXT PRIMARY KEY,
+			kind                TEXT NOT NULL,
+			address             TEXT NOT NULL,
+			fingerprint         TEXT NOT NULL,
+			host_key            TEXT NOT NULL DEFAULT '',
+			known_hosts_managed INTEGER NOT NULL DEFAULT 0,
+			pinned_at           TEXT NOT NULL
+		);
+
+		-- Database size measured by each maintenance run
+		CREATE TABLE IF NOT EXISTS database_size_samples (
+			measured_at TEXT PRIMARY KEY,
+			size_bytes  INTEGER NOT NULL DEFAULT 0,
+			free_bytes  INTEGER NOT NULL DEFAULT 0
+		);
 	`)
 	return err
 }
@@ -318,8 +676,8 @@ func migrateProfiles(db *sql.DB, cfg *SharedConfig) {
 		return
 	}
 
-	var profiles []models.Profile
-	if err := json.Unmarshal(data, &profiles); err != nil {
+	profiles, err := decodeProfileList(data, "profiles.json")
+	if err != nil {
 		log.Printf("Warning: failed to parse profiles.json for migration: %v", err)
 		return
 	}
@@ -765,6 +1123,33 @@ func migrateProfilesNewColumns(db *sql.DB) {
 		{"check_access", "INTEGER NOT NULL DEFAULT 0"},
 		{"conflict_loser", "TEXT NOT NULL DEFAULT ''"},
 		{"conflict_suffix", "TEXT NOT NULL DEFAULT ''"},
+		{"notify_mode", "TEXT NOT NULL DEFAULT ''"},
+		{"quick_check", "INTEGER NOT NULL DEFAULT 0"},
+		{"bind_address", "TEXT NOT NULL DEFAULT ''"},
+		{"ip_family", "TEXT NOT NULL DEFAULT ''"},
+		{"fan_out_to", "TEXT NOT NULL DEFAULT '[]'"},
+		{"fan_out_mode", "TEXT NOT NULL DEFAULT ''"},
+		{"resume_interrupted", "TEXT NOT NULL DEFAULT ''"},
+		{"storage_class", "TEXT NOT NULL DEFAULT ''"},
+		{"upload_headers", "TEXT NOT NULL DEFAULT '[]'"},
+		{"server_side_encryption", "TEXT NOT NULL DEFAULT ''"},
+		{"sse_kms_key_id", "TEXT NOT NULL DEFAULT ''"},
+		{"failover_to", "TEXT NOT NULL DEFAULT ''"},
+		{"write_manifest", "INTEGER NOT NULL DEFAULT 0"},
+		{"session_transfer", "TEXT NOT NULL DEFAULT ''"},
+		{"session_order", "TEXT NOT NULL DEFAULT ''"},
+		{"freshness_target", "TEXT NOT NULL DEFAULT ''"},
+		{"freshness_webhooks", "TEXT NOT NULL DEFAULT ''"},
+		{"transfer_order", "TEXT NOT NULL DEFAULT ''"},
+		{"locked_files", "TEXT NOT NULL DEFAULT ''"},
+		{"compress_dest", "INTEGER NOT NULL DEFAULT 0"},
+		{"compress_mode", "TEXT NOT NULL DEFAULT ''"},
+		{"remote_hooks", "TEXT NOT NULL DEFAULT ''"},
+		{"conflict_naming", "TEXT NOT NULL DEFAULT ''"},
+		{"defer_deletes", "INTEGER NOT NULL DEFAULT 0"},
+		{"detect_conflicts", "INTEGER NOT NULL DEFAULT 0"},
+		{"raw_flags", "TEXT NOT NULL DEFAULT '[]'"},
+		{"unknown_fields", "TEXT NOT NULL DEFAULT ''"},
 	}
 	for _, col := range newCols {
 		// Errors are expected for columns that already exist; silently ignore
@@ -772,6 +1157,144 @@ func migrateProfilesNewColumns(db *sql.DB) {
 	}
 }
 
+// migrateSchedulesNewColumns adds time zone, target, overlap and catch-up policy, tag, suspension, blocking process and sync window columns to the schedules table.
+func migrateSchedulesNewColumns(db *sql.DB) {
+	newCols := []struct{ name, typeDef string }{
+		{"timezone", "TEXT NOT NULL DEFAULT ''"},
+		{"target_type", "TEXT NOT NULL DEFAULT 'profile'"},
+		{"target_id", "TEXT NOT NULL DEFAULT ''"},
+		{"overlap_policy", "TEXT NOT NULL DEFAULT 'skip'"},
+		{"catch_up", "TEXT NOT NULL DEFAULT 'skip'"},
+		{"tags", "TEXT NOT NULL DEFAULT '[]'"},
+		{"suspended_remote", "TEXT NOT NULL DEFAULT ''"},
+		{"blocking_processes", "TEXT NOT NULL DEFAULT '[]'"},
+		{"pause_for_processes", "INTEGER NOT NULL DEFAULT 0"},
+		{"urgent", "INTEGER NOT NULL DEFAULT 0"},
+		{"sample_percent", "REAL NOT NULL DEFAULT 0"},
+		{"window_minutes", "INTEGER NOT NULL DEFAULT 0"},
+		{"split_across_windows", "INTEGER NOT NULL DEFAULT 0"},
+		{"paused_until", "TEXT"},
+	}
+	for _, col := range newCols {
+		// Errors are expected for columns that already exist; silently ignore
+		db.Exec(fmt.Sprintf("ALTER TABLE schedules ADD COLUMN %s %s", col.name, col.typeDef))
+	}
+}
+
+// migrateHistoryNewColumns adds the classified error code, delta run, transfer report, fan-out destination, import source, API call, failover, locked file, compression, remote hook, raw flag, label and note columns to the history table.
+func migrateHistoryNewColumns(db *sql.DB) {
+	newCols := []struct{ name, typeDef string }{
+		{"error_code", "TEXT NOT NULL DEFAULT ''"},
+		{"delta_mode", "TEXT NOT NULL DEFAULT ''"},
+		{"delta_changes", "INTEGER NOT NULL DEFAULT 0"},
+		{"delta_reason", "TEXT NOT NULL DEFAULT ''"},
+		{"delta_time_saved_ms", "INTEGER NOT NULL DEFAULT 0"},
+		{"transfer_report", "TEXT NOT NULL DEFAULT ''"},
+		{"destinations", "TEXT NOT NULL DEFAULT ''"},
+		{"source", "TEXT NOT NULL DEFAULT ''"},
+		{"api_calls", "TEXT NOT NULL DEFAULT ''"},
+		{"failover", "TEXT NOT NULL DEFAULT ''"},
+		{"locked_files", "TEXT NOT NULL DEFAULT ''"},
+		{"compression", "TEXT NOT NULL DEFAULT ''"},
+		{"remote_hooks", "TEXT NOT NULL DEFAULT ''"},
+		{"raw_flags", "TEXT NOT NULL DEFAULT ''"},
+		{"labels", "TEXT NOT NULL DEFAULT ''"},
+		{"note", "TEXT NOT NULL DEFAULT ''"},
+	}
+	for _, col := range newCols {
+		// Errors are expected for columns that already exist; silently ignore
+		db.Exec(fmt.Sprintf("ALTER TABLE history ADD COLUMN %s %s", col.name, col.typeDef))
+	}
+}
+
+// migrateDeltaStateNewColumns adds per-remote delta run counters to the delta_state table.
+func migrateDeltaStateNewColumns(db *sql.DB) {
+	newCols := []struct{ name, typeDef string }{
+		{"full_runs", "INTEGER NOT NULL DEFAULT 0"},
+		{"delta_runs", "INTEGER NOT NULL DEFAULT 0"},
+		{"skipped_runs", "INTEGER NOT NULL DEFAULT 0"},
+		{"last_full_duration_ms", "INTEGER NOT NULL DEFAULT 0"},
+		{"time_saved_ms", "INTEGER NOT NULL DEFAULT 0"},
+		{"last_mode", "TEXT NOT NULL DEFAULT ''"},
+		{"last_reason", "TEXT NOT NULL DEFAULT ''"},
+		{"fingerprint", "TEXT NOT NULL DEFAULT ''"},
+		{"pending_changes", "TEXT NOT NULL DEFAULT ''"},
+		{"subtree_probe", "TEXT NOT NULL DEFAULT ''"},
+		{"subtree_tokens", "TEXT NOT NULL DEFAULT ''"},
+	}
+	for _, col := range newCols {
+		// Errors are expected for columns that already exist; silently ignore
+		db.Exec(fmt.Sprintf("ALTER TABLE delta_state ADD COLUMN %s %s", col.name, col.typeDef))
+	}
+}
+
+// migrateDeltaPendingChanges moves the pending changes earlier versions
+// saved as JSON in delta_state into the delta_changes table.
+func migrateDeltaPendingChanges(db *sql.DB) {
+	_, err := db.Exec(`
+		INSERT INTO delta_changes (remote_key, path, old_path, entry_type, change_type, object_id, created, detected_at)
+		SELECT d.remote_key, json_extract(c.value, '$.Path'), COALESCE(json_extract(c.value, '$.OldPath'), ''),
+			COALESCE(json_extract(c.value, '$.EntryType'), 0), COALESCE(json_extract(c.value, '$.Type'), 0),
+			COALESCE(json_extract(c.value, '$.ObjectID'), ''), COALESCE(json_extract(c.value, '$.Created'), 0),
+			COALESCE(json_extract(c.value, '$.DetectedAt'), '')
+		FROM delta_state d, json_each(d.pending_changes) c
+		WHERE d.pending_changes != '' ORDER BY d.remote_key, c.key`)
+	if err != nil {
+		log.Printf("warning: failed to migrate pending delta changes: %v", err)
+		return
+	}
+	db.Exec(`UPDATE delta_state SET pending_changes = '' WHERE pending_changes != ''`)
+}
+
+// migrateBoardsNewColumns adds execution mode, parallelism, retry budget, run webhook and run window columns to the boards table.
+func migrateBoardsNewColumns(db *sql.DB) {
+	newCols := []struct{ name, typeDef string }{
+		{"execution_mode", "TEXT NOT NULL DEFAULT ''"},
+		{"max_parallel", "INTEGER NOT NULL DEFAULT 0"},
+		{"max_retries", "INTEGER NOT NULL DEFAULT 0"},
+		{"max_retry_seconds", "INTEGER NOT NULL DEFAULT 0"},
+		{"webhooks", "TEXT NOT NULL DEFAULT ''"},
+		{"window_minutes", "INTEGER NOT NULL DEFAULT 0"},
+	}
+	for _, col := range newCols {
+		// Errors are expected for columns that already exist; silently ignore
+		db.Exec(fmt.Sprintf("ALTER TABLE boards ADD COLUMN %s %s", col.name, col.typeDef))
+	}
+	// Cards' start offsets within scheduled runs
+	db.Exec("ALTER TABLE board_edges ADD COLUMN start_offset_minutes INTEGER NOT NULL DEFAULT 0")
+}
+
+// migrateOperationsNewColumns adds step type and per-type step settings columns to the operations table.
+func migrateOperationsNewColumns(db *sql.DB) {
+	newCols := []struct{ name, typeDef string }{
+		{"type", "TEXT NOT NULL DEFAULT ''"},
+		{"http_config", "TEXT NOT NULL DEFAULT ''"},
+		{"wait_config", "TEXT NOT NULL DEFAULT ''"},
+		{"archive_config", "TEXT NOT NULL DEFAULT ''"},
+	}
+	for _, col := range newCols {
+		// Errors are expected for columns that already exist; silently ignore
+		db.Exec(fmt.Sprintf("ALTER TABLE operations ADD COLUMN %s %s", col.name, col.typeDef))
+	}
+}
+
+// migrateFlowsNewColumns adds the run webhooks column to the flows table.
+func migrateFlowsNewColumns(db *sql.DB) {
+	newCols := []struct{ name, typeDef string }{
+		{"webhooks", "TEXT NOT NULL DEFAULT ''"},
+	}
+	for _, col := range newCols {
+		// Errors are expected for columns that already exist; silently ignore
+		db.Exec(fmt.Sprintf("ALTER TABLE flows ADD COLUMN %s %s", col.name, col.typeDef))
+	}
+}
+
+// migrateVerifyReportsNewColumns adds the cryptcheck column to the verify_reports table.
+func migrateVerifyReportsNewColumns(db *sql.DB) {
+	// Errors are expected for columns that already exist; silently ignore
+	db.Exec("ALTER TABLE verify_reports ADD COLUMN crypt_check INTEGER NOT NULL DEFAULT 0")
+}
+
 // ============ Helpers ============
 
 func boolToStr(b bool) string {
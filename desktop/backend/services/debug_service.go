@@ -0,0 +1,298 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// runtimeCallPath is where the frontend's binding calls are POSTed
+const runtimeCallPath = "/wails/runtime"
+
+// recentCallDurations is how many of a method's last call durations are kept
+// for its percentiles
+const recentCallDurations = 128
+
+// DebugService measures the calls the frontend makes to the backend
+// services: how often each method is called, how long it takes and how often
+// it fails, so a slow UI can be traced to the calls behind it. Calls are
+// measured by ServiceMetricsMiddleware as they pass through the asset server.
+type DebugService struct {
+	app *application.App
+
+	mutex   sync.Mutex
+	methods map[string]*methodMetrics // "Service.Method" -> metrics
+	names   map[uint32]string         // binding ID -> "Service.Method"
+	since   time.Time
+}
+
+// methodMetrics accumulates the calls of one bound method
+type methodMetrics struct {
+	calls     int64
+	errors    int64
+	total     time.Duration
+	max       time.Duration
+	recent    []time.Duration // ring buffer of the last recentCallDurations calls
+	next      int
+	lastCall  time.Time
+	lastError string
+}
+
+// ServiceMetrics are the measured calls of the backend services
+type ServiceMetrics struct {
+	Since    time.Time           `json:"since"`
+	Services []ServiceCallTotals `json:"services"` // by total time, slowest first
+	Methods  []MethodCallMetrics `json:"methods"`  // by total time, slowest first
+}
+
+// ServiceCallTotals sums the calls to one service's methods
+type ServiceCallTotals struct {
+	Service string  `json:"service"`
+	Calls   int64   `json:"calls"`
+	Errors  int64   `json:"errors"`
+	TotalMs float64 `json:"total_ms"`
+	MaxMs   float64 `json:"max_ms"`
+}
+
+// MethodCallMetrics are the calls to one bound method
+type MethodCallMetrics struct {
+	Service   string    `json:"service"`
+	Method    string    `json:"method"`
+	Calls     int64     `json:"calls"`
+	Errors    int64     `json:"errors"`
+	TotalMs   float64   `json:"total_ms"`
+	AvgMs     float64   `json:"avg_ms"`
+	P95Ms     float64   `json:"p95_ms"` // over the last 128 calls
+	MaxMs     float64   `json:"max_ms"`
+	LastCall  time.Time `json:"last_call"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// NewDebugService creates a new debug service
+func NewDebugService(app *application.App) *DebugService {
+	return &DebugService{
+		app:     app,
+		methods: make(map[string]*methodMetrics),
+		names:   make(map[uint32]string),
+		since:   time.Now(),
+	}
+}
+
+// SetApp sets the application reference
+func (d *DebugService) SetApp(app *application.App) {
+	d.app = app
+}
+
+// ServiceName returns the name of the service
+func (d *DebugService) ServiceName() string {
+	return "DebugService"
+}
+
+// TrackServices learns the binding IDs of the services' methods, so calls
+// the frontend makes by ID are reported by name. Wails derives a method's ID
+// from its fully qualified name.
+func (d *DebugService) TrackServices(bound []application.Service) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for _, service := range bound {
+		ptrType := reflect.TypeOf(service.Instance())
+		if ptrType == nil || ptrType.Kind() != reflect.Pointer {
+			continue
+		}
+		namedType := ptrType.Elem()
+		for i := range ptrType.NumMethod() {
+			method := ptrType.Method(i).Name
+			fqn := fmt.Sprintf("%s.%s.%s", namedType.PkgPath(), namedType.Name(), method)
+			h := fnv.New32a()
+			h.Write([]byte(fqn))
+			d.names[h.Sum32()] = namedType.Name() + "." + method
+		}
+	}
+}
+
+// GetServiceMetrics returns the calls measured since startup or the last
+// reset, per service and per method
+func (d *DebugService) GetServiceMetrics(ctx context.Context) (*ServiceMetrics, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	result := &ServiceMetrics{Since: d.since, Services: []ServiceCallTotals{}, Methods: []MethodCallMetrics{}}
+	totals := make(map[string]*ServiceCallTotals)
+	for name, m := range d.methods {
+		service, method, _ := strings.Cut(name, ".")
+		mm := MethodCallMetrics{
+			Service:   service,
+			Method:    method,
+			Calls:     m.calls,
+			Errors:    m.errors,
+			TotalMs:   durationMs(m.total),
+			P95Ms:     durationMs(percentile(m.recent, 0.95)),
+			MaxMs:     durationMs(m.max),
+			LastCall:  m.lastCall,
+			LastError: m.lastError,
+		}
+		if m.calls > 0 {
+			mm.AvgMs = mm.TotalMs / float64(m.calls)
+		}
+		result.Methods = append(result.Methods, mm)
+
+		t := totals[service]
+		if t == nil {
+			t = &ServiceCallTotals{Service: service}
+			totals[service] = t
+		}
+		t.Calls += mm.Calls
+		t.Errors += mm.Errors
+		t.TotalMs += mm.TotalMs
+		t.MaxMs = max(t.MaxMs, mm.MaxMs)
+	}
+	for _, t := range totals {
+		result.Services = append(result.Services, *t)
+	}
+	sort.Slice(result.Methods, func(i, j int) bool { return result.Methods[i].TotalMs > result.Methods[j].TotalMs })
+	sort.Slice(result.Services, func(i, j int) bool { return result.Services[i].TotalMs > result.Services[j].TotalMs })
+	return result, nil
+}
+
+// ResetServiceMetrics clears the measured calls, e.g. before reproducing a slowdown
+func (d *DebugService) ResetServiceMetrics(ctx context.Context) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.methods = make(map[string]*methodMetrics)
+	d.since = time.Now()
+	return nil
+}
+
+// recordCall adds a call to a method's metrics. errMsg is empty if it succeeded.
+func (d *DebugService) recordCall(name string, duration time.Duration, errMsg string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	m := d.methods[name]
+	if m == nil {
+		m = &methodMetrics{}
+		d.methods[name] = m
+	}
+	m.calls++
+	m.total += duration
+	m.max = max(m.max, duration)
+	m.lastCall = time.Now()
+	if len(m.recent) < recentCallDurations {
+		m.recent = append(m.recent, duration)
+	} else {
+		m.recent[m.next] = duration
+		m.next = (m.next + 1) % recentCallDurations
+	}
+	if errMsg != "" {
+		m.errors++
+		m.lastError = errMsg
+	}
+}
+
+// methodName names the method a binding call is for, from its name or ID
+func (d *DebugService) methodName(methodName string, methodID uint32) string {
+	if methodName != "" {
+		// Calls by name use the fully qualified "package.Service.Method"
+		parts := strings.Split(methodName, ".")
+		if len(parts) >= 2 {
+			return parts[len(parts)-2] + "." + parts[len(parts)-1]
+		}
+		return methodName
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if name, ok := d.names[methodID]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown.%d", methodID)
+}
+
+// ServiceMetricsMiddleware measures the binding calls that pass through the
+// asset server for debug. Other requests pass through untouched.
+func ServiceMetricsMiddleware(debug *DebugService) application.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			if req.URL.Path != runtimeCallPath || req.Method != http.MethodPost {
+				next.ServeHTTP(rw, req)
+				return
+			}
+			body, err := io.ReadAll(req.Body)
+			req.Body.Close()
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			if err != nil {
+				next.ServeHTTP(rw, req)
+				return
+			}
+
+			var call struct {
+				Object int `json:"object"`
+				Method int `json:"method"`
+				Args   struct {
+					MethodName string `json:"methodName"`
+					MethodID   uint32 `json:"methodID"`
+				} `json:"args"`
+			}
+			if json.Unmarshal(body, &call) != nil || call.Object != 0 || call.Method != application.CallBinding {
+				next.ServeHTTP(rw, req)
+				return
+			}
+
+			recorder := &callRecorder{ResponseWriter: rw, status: http.StatusOK}
+			start := time.Now()
+			next.ServeHTTP(recorder, req)
+			errMsg := ""
+			if recorder.status != http.StatusOK {
+				errMsg = strings.TrimSpace(recorder.errBody.String())
+				if errMsg == "" {
+					errMsg = http.StatusText(recorder.status)
+				}
+			}
+			debug.recordCall(debug.methodName(call.Args.MethodName, call.Args.MethodID), time.Since(start), errMsg)
+		})
+	}
+}
+
+// callRecorder notes the status of a binding call's response, and the start
+// of its body when the call failed
+type callRecorder struct {
+	http.ResponseWriter
+	status  int
+	errBody bytes.Buffer
+}
+
+func (r *callRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *callRecorder) Write(p []byte) (int, error) {
+	if r.status != http.StatusOK && r.errBody.Len() < maxHTTPStepErrorBody {
+		r.errBody.Write(p[:min(len(p), maxHTTPStepErrorBody-r.errBody.Len())])
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// percentile returns the p-th percentile of durations
+func percentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[int(p*float64(len(sorted)-1))]
+}
+
+// durationMs converts a duration to fractional milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
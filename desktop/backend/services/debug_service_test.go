@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"hash/fnv"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+func TestServiceMetricsMiddleware(t *testing.T) {
+	debug := NewDebugService(nil)
+	debug.TrackServices([]application.Service{application.NewService(&TrashService{})})
+
+	h := fnv.New32a()
+	h.Write([]byte("desktop/backend/services.TrashService.GetTrash"))
+	getTrashID := strconv.FormatUint(uint64(h.Sum32()), 10)
+
+	handler := ServiceMetricsMiddleware(debug)(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if strings.Contains(req.URL.RawQuery, "fail") {
+			rw.WriteHeader(http.StatusUnprocessableEntity)
+			rw.Write([]byte("profile not found"))
+			return
+		}
+		rw.Write([]byte("{}"))
+	}))
+	call := func(query, body string) {
+		req := httptest.NewRequest(http.MethodPost, "/wails/runtime?"+query, strings.NewReader(body))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	call("", `{"object":0,"method":0,"args":{"call-id":"1","methodID":`+getTrashID+`,"args":[]}}`)
+	call("", `{"object":0,"method":0,"args":{"call-id":"2","methodID":`+getTrashID+`,"args":[]}}`)
+	call("fail", `{"object":0,"method":0,"args":{"call-id":"3","methodName":"desktop/backend/services.ConfigService.GetProfile","args":["x"]}}`)
+	// Runtime calls that aren't binding calls aren't measured
+	call("", `{"object":6,"method":0,"args":{}}`)
+
+	metrics, err := debug.GetServiceMetrics(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metrics.Methods) != 2 || len(metrics.Services) != 2 {
+		t.Fatalf("metrics = %+v, want 2 methods of 2 services", metrics)
+	}
+	for _, m := range metrics.Methods {
+		switch m.Service + "." + m.Method {
+		case "TrashService.GetTrash":
+			if m.Calls != 2 || m.Errors != 0 {
+				t.Errorf("GetTrash = %+v, want 2 calls without errors", m)
+			}
+		case "ConfigService.GetProfile":
+			if m.Calls != 1 || m.Errors != 1 || m.LastError != "profile not found" {
+				t.Errorf("GetProfile = %+v, want 1 failed call", m)
+			}
+		default:
+			t.Errorf("unexpected method %s.%s", m.Service, m.Method)
+		}
+	}
+
+	debug.ResetServiceMetrics(context.Background())
+	if metrics, _ := debug.GetServiceMetrics(context.Background()); len(metrics.Methods) != 0 {
+		t.Errorf("expected no metrics after a reset, got %+v", metrics.Methods)
+	}
+}
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"desktop/backend/dto"
+	"desktop/backend/models"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HistoryExportSchemaVersion is the version of the history export schema
+// documented in docs/API.md. Bump it when columns are renamed, removed or
+// change meaning; adding columns at the end keeps the version.
+const HistoryExportSchemaVersion = 1
+
+// History export formats
+const (
+	HistoryExportCSV   = "csv"
+	HistoryExportJSONL = "jsonl"
+)
+
+// History export record types
+const (
+	HistoryRecordRun  = "run"
+	HistoryRecordFile = "file"
+)
+
+// HistoryExportFilter selects the history exported by ExportHistory
+type HistoryExportFilter struct {
+	ProfileName string     `json:"profile_name,omitempty"`
+	Status      string     `json:"status,omitempty"`  // "completed", "failed" or "cancelled"; empty = all
+	Since       *time.Time `json:"since,omitempty"`   // runs started at or after
+	Until       *time.Time `json:"until,omitempty"`   // runs started before
+	Records     string     `json:"records,omitempty"` // "runs", "files" or empty for both; CSV holds one kind and defaults to runs
+}
+
+// HistoryRunRecord is one run in a history export
+type HistoryRunRecord struct {
+	SchemaVersion    int    `json:"schema_version"`
+	Record           string `json:"record"` // always "run"
+	RunId            string `json:"run_id"`
+	ProfileName      string `json:"profile_name"`
+	Action           string `json:"action"`
+	Status           string `json:"status"`
+	StartTime        string `json:"start_time"` // RFC3339
+	EndTime          string `json:"end_time"`   // RFC3339
+	DurationMs       int64  `json:"duration_ms"`
+	FilesTransferred int64  `json:"files_transferred"`
+	BytesTransferred int64  `json:"bytes_transferred"`
+	Errors           int    `json:"errors"`
+	ErrorCode        string `json:"error_code"`
+	ErrorMessage     string `json:"error_message"`
+	DeltaMode        string `json:"delta_mode"` // "full", "delta", "skipped" or empty
+	Source           string `json:"source"`     // tool an imported run came from, e.g. "rsync"; empty for runs made here
+}
+
+// HistoryFileRecord is one file of a run's transfer report in a history
+// export. Only the files the report kept (slowest, largest, most retried)
+// are recorded per run.
+type HistoryFileRecord struct {
+	SchemaVersion int     `json:"schema_version"`
+	Record        string  `json:"record"` // always "file"
+	RunId         string  `json:"run_id"`
+	ProfileName   string  `json:"profile_name"`
+	Path          string  `json:"path"`
+	Size          int64   `json:"size"`
+	DurationMs    int64   `json:"duration_ms"`
+	Speed         float64 `json:"speed"` // bytes per second
+	Attempts      int     `json:"attempts"`
+	Failed        bool    `json:"failed"`
+	ReportedAs    string  `json:"reported_as"` // report lists the file is in, e.g. "slowest;largest"
+}
+
+var historyRunColumns = []string{
+	"schema_version", "record", "run_id", "profile_name", "action", "status", "start_time", "end_time",
+	"duration_ms", "files_transferred", "bytes_transferred", "errors", "error_code", "error_message", "delta_mode",
+	"source",
+}
+
+var historyFileColumns = []string{
+	"schema_version", "record", "run_id", "profile_name", "path", "size", "duration_ms", "speed",
+	"attempts", "failed", "reported_as",
+}
+
+// ExportHistory exports run history as CSV or JSON Lines, oldest run first.
+// JSON Lines holds run and file records, told apart by their "record" field;
+// CSV holds one kind, chosen by filter.Records.
+func (e *ExportService) ExportHistory(ctx context.Context, format string, filter HistoryExportFilter) ([]byte, error) {
+	if e.historyService == nil {
+		return nil, fmt.Errorf("history service not available")
+	}
+	switch filter.Records {
+	case "", "runs", "files":
+	default:
+		return nil, fmt.Errorf("unknown history records %q: expected runs or files", filter.Records)
+	}
+
+	entries, err := e.historyService.GetHistory(ctx, maxHistoryEntries, 0)
+	if err != nil {
+		return nil, err
+	}
+	entries = filterHistory(entries, filter)
+
+	switch format {
+	case HistoryExportCSV:
+		if filter.Records == "files" {
+			var files []HistoryFileRecord
+			for _, entry := range entries {
+				files = append(files, historyFileRecords(entry)...)
+			}
+			return writeHistoryCSV(historyFileColumns, files, historyFileRow)
+		}
+		runs := make([]HistoryRunRecord, 0, len(entries))
+		for _, entry := range entries {
+			runs = append(runs, historyRunRecord(entry))
+		}
+		return writeHistoryCSV(historyRunColumns, runs, historyRunRow)
+	case HistoryExportJSONL:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for _, entry := range entries {
+			if filter.Records != "files" {
+				if err := enc.Encode(historyRunRecord(entry)); err != nil {
+					return nil, err
+				}
+			}
+			if filter.Records != "runs" {
+				for _, f := range historyFileRecords(entry) {
+					if err := enc.Encode(f); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unknown history export format %q: expected csv or jsonl", format)
+}
+
+// ExportHistoryToFile exports run history to a local file
+func (e *ExportService) ExportHistoryToFile(ctx context.Context, filePath, format string, filter HistoryExportFilter) error {
+	data, err := e.ExportHistory(ctx, format, filter)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(filePath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write history export: %w", err)
+	}
+
+	log.Printf("ExportService: Exported history to %s (%d bytes)", filePath, len(data))
+	return nil
+}
+
+// filterHistory keeps the entries matching filter, oldest first
+func filterHistory(entries []models.HistoryEntry, filter HistoryExportFilter) []models.HistoryEntry {
+	kept := make([]models.HistoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		if filter.ProfileName != "" && entry.ProfileName != filter.ProfileName {
+			continue
+		}
+		if filter.Status != "" && entry.Status != filter.Status {
+			continue
+		}
+		if filter.Since != nil && entry.StartTime.Before(*filter.Since) {
+			continue
+		}
+		if filter.Until != nil && !entry.StartTime.Before(*filter.Until) {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].StartTime.Before(kept[j].StartTime) })
+	return kept
+}
+
+// historyRunRecord converts a history entry to its export record
+func historyRunRecord(entry models.HistoryEntry) HistoryRunRecord {
+	run := HistoryRunRecord{
+		SchemaVersion:    HistoryExportSchemaVersion,
+		Record:           HistoryRecordRun,
+		RunId:            entry.Id,
+		ProfileName:      entry.ProfileName,
+		Action:           entry.Action,
+		Status:           entry.Status,
+		StartTime:        entry.StartTime.Format(time.RFC3339),
+		EndTime:          entry.EndTime.Format(time.RFC3339),
+		DurationMs:       entry.EndTime.Sub(entry.StartTime).Milliseconds(),
+		FilesTransferred: entry.FilesTransferred,
+		BytesTransferred: entry.BytesTransferred,
+		Errors:           entry.Errors,
+		ErrorMessage:     entry.ErrorMessage,
+		Source:           entry.Source,
+	}
+	if entry.ErrorInfo != nil {
+		run.ErrorCode = entry.ErrorInfo.Code
+	}
+	if entry.Delta != nil {
+		run.DeltaMode = entry.Delta.Mode
+	}
+	return run
+}
+
+// historyFileRecords returns the file records of a run's transfer report,
+// one per file even when it is in several report lists
+func historyFileRecords(entry models.HistoryEntry) []HistoryFileRecord {
+	if entry.Report == nil {
+		return nil
+	}
+	var records []HistoryFileRecord
+	index := map[string]int{}
+	add := func(list string, files []dto.FileReportEntry) {
+		for _, f := range files {
+			if i, ok := index[f.Name]; ok {
+				records[i].ReportedAs += ";" + list
+				continue
+			}
+			index[f.Name] = len(records)
+			records = append(records, HistoryFileRecord{
+				SchemaVersion: HistoryExportSchemaVersion,
+				Record:        HistoryRecordFile,
+				RunId:         entry.Id,
+				ProfileName:   entry.ProfileName,
+				Path:          f.Name,
+				Size:          f.Size,
+				DurationMs:    f.DurationMs,
+				Speed:         f.Speed,
+				Attempts:      f.Attempts,
+				Failed:        f.Failed,
+				ReportedAs:    list,
+			})
+		}
+	}
+	add("slowest", entry.Report.Slowest)
+	add("largest", entry.Report.Largest)
+	add("most_retried", entry.Report.MostRetried)
+	return records
+}
+
+func historyRunRow(r HistoryRunRecord) []string {
+	return []string{
+		strconv.Itoa(r.SchemaVersion), r.Record, r.RunId, r.ProfileName, r.Action, r.Status, r.StartTime, r.EndTime,
+		strconv.FormatInt(r.DurationMs, 10), strconv.FormatInt(r.FilesTransferred, 10), strconv.FormatInt(r.BytesTransferred, 10),
+		strconv.Itoa(r.Errors), r.ErrorCode, csvSafe(r.ErrorMessage), r.DeltaMode,
+		r.Source,
+	}
+}
+
+func historyFileRow(f HistoryFileRecord) []string {
+	return []string{
+		strconv.Itoa(f.SchemaVersion), f.Record, f.RunId, f.ProfileName, csvSafe(f.Path), strconv.FormatInt(f.Size, 10),
+		strconv.FormatInt(f.DurationMs, 10), strconv.FormatFloat(f.Speed, 'f', -1, 64),
+		strconv.Itoa(f.Attempts), strconv.FormatBool(f.Failed), f.ReportedAs,
+	}
+}
+
+// writeHistoryCSV writes a header row and one row per record
+func writeHistoryCSV[T any](columns []string, records []T, row func(T) []string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(columns); err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		if err := w.Write(row(r)); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// csvSafe stops spreadsheets from evaluating a value as a formula
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
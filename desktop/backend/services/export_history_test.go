@@ -0,0 +1,128 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"desktop/backend/dto"
+	"desktop/backend/models"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func newTestHistoryExport(t *testing.T) *ExportService {
+	t.Helper()
+	h := newTestHistoryService(t)
+	ctx := context.Background()
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	entries := []models.HistoryEntry{
+		{
+			Id: "run-1", ProfileName: "photos", Action: "push", Status: "completed",
+			StartTime: start, EndTime: start.Add(90 * time.Second), FilesTransferred: 3, BytesTransferred: 4096,
+			Report: &dto.TransferReport{
+				Slowest: []dto.FileReportEntry{{Name: "a.jpg", Size: 2048, DurationMs: 900, Attempts: 1}},
+				Largest: []dto.FileReportEntry{{Name: "a.jpg", Size: 2048, DurationMs: 900, Attempts: 1}, {Name: "=b.raw", Size: 1024, Attempts: 2}},
+			},
+		},
+		{
+			Id: "run-2", ProfileName: "photos", Action: "push", Status: "failed",
+			StartTime: start.Add(time.Hour), EndTime: start.Add(time.Hour + time.Minute),
+			ErrorMessage: "googleapi: Error 403: storageQuotaExceeded",
+		},
+		{
+			Id: "run-3", ProfileName: "docs", Action: "pull", Status: "completed",
+			StartTime: start.Add(2 * time.Hour), EndTime: start.Add(2*time.Hour + time.Second),
+		},
+	}
+	for _, entry := range entries {
+		if err := h.AddEntry(ctx, entry); err != nil {
+			t.Fatalf("AddEntry: %v", err)
+		}
+	}
+	e := NewExportService(nil)
+	e.SetHistoryService(h)
+	return e
+}
+
+func TestExportService_ExportHistoryCSV(t *testing.T) {
+	e := newTestHistoryExport(t)
+	ctx := context.Background()
+
+	data, err := e.ExportHistory(ctx, HistoryExportCSV, HistoryExportFilter{ProfileName: "photos"})
+	if err != nil {
+		t.Fatalf("ExportHistory: %v", err)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want header + 2 runs", len(rows))
+	}
+	if len(rows[0]) != len(historyRunColumns) || rows[0][0] != "schema_version" {
+		t.Errorf("unexpected header %v", rows[0])
+	}
+	if rows[1][2] != "run-1" || rows[2][2] != "run-2" {
+		t.Errorf("runs should be oldest first, got %s, %s", rows[1][2], rows[2][2])
+	}
+	if rows[1][8] != "90000" {
+		t.Errorf("duration_ms = %s, want 90000", rows[1][8])
+	}
+	if rows[2][12] != "QUOTA_EXCEEDED" {
+		t.Errorf("error_code = %q, want QUOTA_EXCEEDED", rows[2][12])
+	}
+
+	// File records, one per file, with formula-like values escaped
+	data, err = e.ExportHistory(ctx, HistoryExportCSV, HistoryExportFilter{Records: "files"})
+	if err != nil {
+		t.Fatalf("ExportHistory files: %v", err)
+	}
+	rows, _ = csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if len(rows) != 3 {
+		t.Fatalf("got %d file rows, want header + 2 files", len(rows))
+	}
+	if rows[1][4] != "a.jpg" || rows[1][10] != "slowest;largest" {
+		t.Errorf("a.jpg row = %v, want it reported as slowest;largest", rows[1])
+	}
+	if rows[2][4] != "'=b.raw" {
+		t.Errorf("path = %q, want formula escaped", rows[2][4])
+	}
+}
+
+func TestExportService_ExportHistoryJSONL(t *testing.T) {
+	e := newTestHistoryExport(t)
+	since := time.Date(2026, 3, 1, 10, 30, 0, 0, time.UTC)
+
+	data, err := e.ExportHistory(context.Background(), HistoryExportJSONL, HistoryExportFilter{Since: &since, Status: "completed"})
+	if err != nil {
+		t.Fatalf("ExportHistory: %v", err)
+	}
+	var records []map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var record map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 1 || records[0]["run_id"] != "run-3" || records[0]["record"] != HistoryRecordRun {
+		t.Fatalf("records = %v, want only run-3", records)
+	}
+	if records[0]["schema_version"] != float64(HistoryExportSchemaVersion) {
+		t.Errorf("schema_version = %v", records[0]["schema_version"])
+	}
+}
+
+func TestExportService_ExportHistoryRejectsUnknownFormat(t *testing.T) {
+	e := newTestHistoryExport(t)
+	if _, err := e.ExportHistory(context.Background(), "xlsx", HistoryExportFilter{}); err == nil {
+		t.Error("unknown format should be rejected")
+	}
+	if _, err := e.ExportHistory(context.Background(), HistoryExportCSV, HistoryExportFilter{Records: "schedules"}); err == nil {
+		t.Error("unknown record type should be rejected")
+	}
+}
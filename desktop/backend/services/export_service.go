@@ -22,13 +22,15 @@ import (
 
 // Binary format constants
 const (
-	MagicBytes      = "NSDRIVE"
-	FormatVersion   = uint8(1)
-	SectionBoards   = uint8(0x01)
-	SectionRemotes  = uint8(0x02)
-	SectionSettings = uint8(0x03)
-	SectionManifest = uint8(0x04)
-	EOFMarker       = uint8(0xFF)
+	MagicBytes       = "NSDRIVE"
+	FormatVersion    = uint8(1)
+	SectionBoards    = uint8(0x01)
+	SectionRemotes   = uint8(0x02)
+	SectionSettings  = uint8(0x03)
+	SectionManifest  = uint8(0x04)
+	SectionProfiles  = uint8(0x05) // written by settings sync
+	SectionSchedules = uint8(0x06) // written by settings sync
+	EOFMarker        = uint8(0xFF)
 )
 
 // Export flags
@@ -42,6 +44,15 @@ const (
 type ExportService struct {
 	app   *application.App
 	mutex sync.RWMutex
+
+	// Dependencies used by status snapshots
+	historyService   *HistoryService
+	schedulerService *SchedulerService
+
+	// Settings sync
+	configService *ConfigService
+	syncMutex     sync.Mutex    // serializes syncs
+	stopSync      chan struct{} // closed on shutdown
 }
 
 // ExportOptions configures what to export
@@ -49,8 +60,8 @@ type ExportOptions struct {
 	IncludeBoards   bool   `json:"include_boards"`
 	IncludeRemotes  bool   `json:"include_remotes"`
 	IncludeSettings bool   `json:"include_settings"`
-	ExcludeTokens   bool   `json:"exclude_tokens"`    // Export remotes without sensitive tokens
-	EncryptPassword string `json:"encrypt_password"`   // If set, encrypt the export file
+	ExcludeTokens   bool   `json:"exclude_tokens"`   // Export remotes without sensitive tokens
+	EncryptPassword string `json:"encrypt_password"` // If set, encrypt the export file
 }
 
 // ExportManifest contains metadata about the export
@@ -61,6 +72,11 @@ type ExportManifest struct {
 	BoardCount  int       `json:"board_count"`
 	RemoteCount int       `json:"remote_count"`
 	Checksum    uint32    `json:"checksum"`
+
+	// Settings sync archives: the installation that wrote it, and when its
+	// configuration last changed
+	MachineId string     `json:"machine_id,omitempty"`
+	ChangedAt *time.Time `json:"changed_at,omitempty"`
 }
 
 // RemoteExport represents a remote for export (may exclude sensitive data)
@@ -90,6 +106,21 @@ func (e *ExportService) SetApp(app *application.App) {
 	e.app = app
 }
 
+// SetHistoryService sets the history service used for status snapshots
+func (e *ExportService) SetHistoryService(historyService *HistoryService) {
+	e.historyService = historyService
+}
+
+// SetSchedulerService sets the scheduler service used for status snapshots
+func (e *ExportService) SetSchedulerService(schedulerService *SchedulerService) {
+	e.schedulerService = schedulerService
+}
+
+// SetConfigService sets the config service whose profiles settings sync keeps in step
+func (e *ExportService) SetConfigService(configService *ConfigService) {
+	e.configService = configService
+}
+
 // ServiceName returns the name of the service
 func (e *ExportService) ServiceName() string {
 	return "ExportService"
@@ -98,12 +129,17 @@ func (e *ExportService) ServiceName() string {
 // ServiceStartup is called when the service starts
 func (e *ExportService) ServiceStartup(ctx context.Context, options application.ServiceOptions) error {
 	log.Printf("ExportService starting up...")
+	e.stopSync = make(chan struct{})
+	go e.watchSettingsSync()
 	return nil
 }
 
 // ServiceShutdown is called when the service shuts down
 func (e *ExportService) ServiceShutdown(ctx context.Context) error {
 	log.Printf("ExportService shutting down...")
+	if e.stopSync != nil {
+		close(e.stopSync)
+	}
 	return nil
 }
 
@@ -137,8 +173,6 @@ func (e *ExportService) ExportToBytes(ctx context.Context, options ExportOptions
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
 
-	var buf bytes.Buffer
-
 	// Build flags
 	flags := FlagCompressed
 	if options.ExcludeTokens {
@@ -155,10 +189,7 @@ func (e *ExportService) ExportToBytes(ctx context.Context, options ExportOptions
 	}
 
 	// Collect data sections
-	var sections []struct {
-		sectionType uint8
-		data        []byte
-	}
+	var sections []exportSection
 
 	// Export boards
 	if options.IncludeBoards {
@@ -168,24 +199,11 @@ func (e *ExportService) ExportToBytes(ctx context.Context, options ExportOptions
 				return nil, fmt.Errorf("failed to get boards: %w", err)
 			}
 			if len(boards) > 0 {
-				boardsJSON, err := json.Marshal(boards)
+				sectionData, err := sealExportSection(boards, encKey)
 				if err != nil {
-					return nil, fmt.Errorf("failed to marshal boards: %w", err)
+					return nil, fmt.Errorf("failed to export boards: %w", err)
 				}
-				sectionData, err := compressData(boardsJSON)
-				if err != nil {
-					return nil, fmt.Errorf("failed to compress boards: %w", err)
-				}
-				if encKey != nil {
-					sectionData, err = EncryptData(sectionData, encKey)
-					if err != nil {
-						return nil, fmt.Errorf("failed to encrypt boards: %w", err)
-					}
-				}
-				sections = append(sections, struct {
-					sectionType uint8
-					data        []byte
-				}{SectionBoards, sectionData})
+				sections = append(sections, exportSection{SectionBoards, sectionData})
 			}
 		}
 	}
@@ -194,24 +212,11 @@ func (e *ExportService) ExportToBytes(ctx context.Context, options ExportOptions
 	if options.IncludeRemotes {
 		remotes := e.getRemotesForExport(options.ExcludeTokens)
 		if len(remotes) > 0 {
-			remotesJSON, err := json.Marshal(remotes)
-			if err != nil {
-				return nil, fmt.Errorf("failed to marshal remotes: %w", err)
-			}
-			sectionData, err := compressData(remotesJSON)
+			sectionData, err := sealExportSection(remotes, encKey)
 			if err != nil {
-				return nil, fmt.Errorf("failed to compress remotes: %w", err)
+				return nil, fmt.Errorf("failed to export remotes: %w", err)
 			}
-			if encKey != nil {
-				sectionData, err = EncryptData(sectionData, encKey)
-				if err != nil {
-					return nil, fmt.Errorf("failed to encrypt remotes: %w", err)
-				}
-			}
-			sections = append(sections, struct {
-				sectionType uint8
-				data        []byte
-			}{SectionRemotes, sectionData})
+			sections = append(sections, exportSection{SectionRemotes, sectionData})
 		}
 	}
 
@@ -231,24 +236,50 @@ func (e *ExportService) ExportToBytes(ctx context.Context, options ExportOptions
 		manifest.RemoteCount = len(fsConfig.GetRemotes())
 	}
 
-	manifestJSON, err := json.Marshal(manifest)
+	manifestData, err := sealExportSection(manifest, encKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+		return nil, fmt.Errorf("failed to export manifest: %w", err)
 	}
-	manifestData, err := compressData(manifestJSON)
+	sections = append(sections, exportSection{SectionManifest, manifestData})
+
+	data, err := encodeExportArchive(flags, encSalt, sections)
 	if err != nil {
-		return nil, fmt.Errorf("failed to compress manifest: %w", err)
+		return nil, err
+	}
+	log.Printf("ExportService: Exported %d sections, total size: %d bytes", len(sections), len(data))
+	return data, nil
+}
+
+// exportSection is a section of an export archive, as written to it
+type exportSection struct {
+	sectionType uint8
+	data        []byte
+}
+
+// sealExportSection marshals a section's content, compresses it and, with a
+// key, encrypts it
+func sealExportSection(v any, encKey []byte) ([]byte, error) {
+	jsonData, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal: %w", err)
+	}
+	sectionData, err := compressData(jsonData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress: %w", err)
 	}
 	if encKey != nil {
-		manifestData, err = EncryptData(manifestData, encKey)
+		sectionData, err = EncryptData(sectionData, encKey)
 		if err != nil {
-			return nil, fmt.Errorf("failed to encrypt manifest: %w", err)
+			return nil, fmt.Errorf("failed to encrypt: %w", err)
 		}
 	}
-	sections = append(sections, struct {
-		sectionType uint8
-		data        []byte
-	}{SectionManifest, manifestData})
+	return sectionData, nil
+}
+
+// encodeExportArchive writes the header, the sections and the EOF marker of
+// an export archive. encSalt is the salt of the encryption key, if any.
+func encodeExportArchive(flags uint32, encSalt []byte, sections []exportSection) ([]byte, error) {
+	var buf bytes.Buffer
 
 	// Calculate checksum of all section data
 	var allData bytes.Buffer
@@ -291,8 +322,6 @@ func (e *ExportService) ExportToBytes(ctx context.Context, options ExportOptions
 
 	// EOF marker
 	buf.WriteByte(EOFMarker)
-
-	log.Printf("ExportService: Exported %d sections, total size: %d bytes", len(sections), buf.Len())
 	return buf.Bytes(), nil
 }
 
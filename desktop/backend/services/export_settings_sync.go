@@ -0,0 +1,880 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"desktop/backend/validation"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rclone/rclone/fs/fspath"
+)
+
+// settingsSyncCheckInterval is how often settings sync checks whether a
+// sync is due
+const settingsSyncCheckInterval = time.Minute
+
+// settingsSnapshot is the configuration settings sync keeps in step between
+// installations, without machine-specific paths and run state
+type settingsSnapshot struct {
+	Profiles  []models.Profile
+	Boards    []models.Board
+	Schedules []models.ScheduleEntry
+}
+
+// settingsSyncState is the settings_sync row
+type settingsSyncState struct {
+	remotePath      string
+	password        string
+	machineId       string
+	intervalMinutes int
+	localHash       string // local configuration at the last sync
+	remoteHash      string // archive at the last sync
+	localChangedAt  string // when a local change since the last sync was first seen
+	lastSyncAt      string
+	lastResult      string
+	lastError       string
+	warnings        []string
+	conflict        *models.SettingsSyncConflictInfo
+}
+
+// GetSettingsSync returns how settings sync is set up and how its last sync
+// went. RemotePath is empty when it isn't set up.
+func (e *ExportService) GetSettingsSync(ctx context.Context) (*models.SettingsSync, error) {
+	st, err := loadSettingsSyncState()
+	if err != nil {
+		return nil, err
+	}
+	if st == nil {
+		return &models.SettingsSync{}, nil
+	}
+	cfg := &models.SettingsSync{
+		RemotePath:      st.remotePath,
+		HasPassword:     st.password != "",
+		IntervalMinutes: st.intervalMinutes,
+		MachineId:       st.machineId,
+		LastResult:      st.lastResult,
+		LastError:       st.lastError,
+		Warnings:        st.warnings,
+		Conflict:        st.conflict != nil,
+	}
+	if t, err := time.Parse(time.RFC3339, st.lastSyncAt); err == nil {
+		cfg.LastSyncAt = &t
+	}
+	return cfg, nil
+}
+
+// SetSettingsSync sets up settings sync: profiles, boards and schedules are
+// kept in step with other installations through an archive at RemotePath,
+// encrypted with Password (kept if empty when changing other settings).
+// Changing the path or password makes the next sync a first one again.
+func (e *ExportService) SetSettingsSync(ctx context.Context, cfg models.SettingsSync) error {
+	if _, name, err := fspath.Split(cfg.RemotePath); err != nil || name == "" {
+		return fmt.Errorf("remote path must name a file, e.g. \"gdrive:ng-drive/settings.nsd\"")
+	}
+	if cfg.IntervalMinutes < 0 {
+		return fmt.Errorf("interval must not be negative")
+	}
+
+	e.syncMutex.Lock()
+	defer e.syncMutex.Unlock()
+
+	st, err := loadSettingsSyncState()
+	if err != nil {
+		return err
+	}
+	if st == nil {
+		st = &settingsSyncState{machineId: uuid.New().String()}
+	}
+	if cfg.Password == "" && st.password == "" {
+		return fmt.Errorf("a password is required to encrypt the settings archive")
+	}
+	if cfg.Password == "" {
+		cfg.Password = st.password
+	}
+	if cfg.RemotePath != st.remotePath || cfg.Password != st.password {
+		st.localHash, st.remoteHash, st.localChangedAt = "", "", ""
+		st.conflict = nil
+	}
+	st.remotePath = cfg.RemotePath
+	st.password = cfg.Password
+	st.intervalMinutes = cfg.IntervalMinutes
+	return saveSettingsSyncState(st)
+}
+
+// DisableSettingsSync stops settings sync. The archive is left alone.
+func (e *ExportService) DisableSettingsSync(ctx context.Context) error {
+	e.syncMutex.Lock()
+	defer e.syncMutex.Unlock()
+
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("DELETE FROM settings_sync")
+	return err
+}
+
+// SyncSettingsNow syncs the configuration with the archive: a side that
+// changed since the last sync is copied to the other one. When both
+// changed, the side that changed last wins, and the other side's version
+// is kept for GetSettingsSyncConflict.
+func (e *ExportService) SyncSettingsNow(ctx context.Context) (*models.SettingsSync, error) {
+	e.syncMutex.Lock()
+	st, err := loadSettingsSyncState()
+	if err == nil && st == nil {
+		err = fmt.Errorf("settings sync is not set up")
+	}
+	if err == nil {
+		err = e.runSettingsSync(ctx, st, nil)
+	}
+	e.syncMutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return e.GetSettingsSync(ctx)
+}
+
+// GetSettingsSyncConflict returns the items that differed when both sides
+// last changed since a sync, or nil if there is no conflict
+func (e *ExportService) GetSettingsSyncConflict(ctx context.Context) (*models.SettingsSyncConflictInfo, error) {
+	st, err := loadSettingsSyncState()
+	if err != nil || st == nil {
+		return nil, err
+	}
+	return st.conflict, nil
+}
+
+// ResolveSettingsSyncConflict takes the chosen side of conflict items, then
+// syncs the result to the archive. Items without a choice keep the winner's
+// version.
+func (e *ExportService) ResolveSettingsSyncConflict(ctx context.Context, choices []models.SettingsSyncChoice) (*models.SettingsSync, error) {
+	e.syncMutex.Lock()
+	st, err := loadSettingsSyncState()
+	if err == nil && (st == nil || st.conflict == nil) {
+		err = fmt.Errorf("there is no settings sync conflict")
+	}
+	if err == nil {
+		err = e.runSettingsSync(ctx, st, choices)
+	}
+	e.syncMutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return e.GetSettingsSync(ctx)
+}
+
+// watchSettingsSync syncs settings every IntervalMinutes until the service
+// shuts down. Checks are skipped while the database is unavailable.
+func (e *ExportService) watchSettingsSync() {
+	ticker := time.NewTicker(settingsSyncCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.stopSync:
+			return
+		case <-ticker.C:
+		}
+		st, err := loadSettingsSyncState()
+		if err != nil || st == nil || st.intervalMinutes <= 0 {
+			continue
+		}
+		if last, err := time.Parse(time.RFC3339, st.lastSyncAt); err == nil && time.Since(last) < time.Duration(st.intervalMinutes)*time.Minute {
+			continue
+		}
+		if _, err := e.SyncSettingsNow(context.Background()); err != nil {
+			log.Printf("[SettingsSync] %v", err)
+		}
+	}
+}
+
+// runSettingsSync syncs, or with choices resolves the conflict, and records
+// the outcome. Errors of the sync itself are recorded as a failed result;
+// the returned error is about the state.
+func (e *ExportService) runSettingsSync(ctx context.Context, st *settingsSyncState, choices []models.SettingsSyncChoice) error {
+	var result string
+	var warnings []string
+	var err error
+	if choices != nil {
+		result, warnings, err = e.resolveSettings(ctx, st, choices)
+	} else {
+		result, warnings, err = e.syncSettings(ctx, st)
+	}
+	st.lastSyncAt = time.Now().UTC().Format(time.RFC3339)
+	st.lastResult, st.lastError, st.warnings = result, "", warnings
+	if err != nil {
+		st.lastResult, st.lastError = models.SettingsSyncFailed, err.Error()
+		log.Printf("[SettingsSync] Sync failed: %v", err)
+	} else {
+		log.Printf("[SettingsSync] Sync %s", result)
+	}
+	return saveSettingsSyncState(st)
+}
+
+// syncSettings compares both sides with the last sync and brings them in step
+func (e *ExportService) syncSettings(ctx context.Context, st *settingsSyncState) (string, []string, error) {
+	local, err := e.collectSettings(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	localHash := local.hash()
+	if localHash == st.localHash {
+		st.localChangedAt = ""
+	} else if st.localChangedAt == "" {
+		st.localChangedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	data, err := rclone.DownloadBytes(ctx, st.remotePath)
+	if err != nil {
+		return "", nil, err
+	}
+	if data == nil {
+		return models.SettingsSyncPushed, nil, e.pushSettings(ctx, st, local)
+	}
+	parsed, err := parseExportData(data, st.password)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read the settings archive: %w", err)
+	}
+	remote := settingsSnapshot{Profiles: parsed.profiles, Boards: parsed.boards, Schedules: parsed.schedules}
+	remoteHash := remote.hash()
+
+	localChanged := localHash != st.localHash
+	remoteChanged := remoteHash != st.remoteHash
+	switch {
+	case localHash == remoteHash:
+		st.localHash, st.remoteHash, st.localChangedAt = localHash, remoteHash, ""
+		return models.SettingsSyncUpToDate, nil, nil
+
+	case st.localHash == "" && st.remoteHash == "":
+		// First sync: keep what either side has, and this side's version of
+		// what both have
+		merged, items := mergeSettings(local, remote)
+		warnings := e.applySettings(ctx, local, merged)
+		result := models.SettingsSyncMerged
+		if len(items) > 0 {
+			result = models.SettingsSyncConflict
+			st.conflict = newSettingsConflict(models.SettingsSyncLocal, st, parsed.manifest, items)
+		}
+		return result, warnings, e.pushApplied(ctx, st, remoteHash)
+
+	case localChanged && remoteChanged:
+		items := diffSettings(local, remote)
+		if settingsRemoteNewer(st.localChangedAt, parsed.manifest) {
+			st.conflict = newSettingsConflict(models.SettingsSyncRemote, st, parsed.manifest, items)
+			return models.SettingsSyncConflict, e.pullSettings(ctx, st, local, remote, remoteHash), nil
+		}
+		st.conflict = newSettingsConflict(models.SettingsSyncLocal, st, parsed.manifest, items)
+		return models.SettingsSyncConflict, nil, e.pushSettings(ctx, st, local)
+
+	case remoteChanged:
+		return models.SettingsSyncPulled, e.pullSettings(ctx, st, local, remote, remoteHash), nil
+
+	case localChanged:
+		return models.SettingsSyncPushed, nil, e.pushSettings(ctx, st, local)
+	}
+	return models.SettingsSyncUpToDate, nil, nil
+}
+
+// resolveSettings applies the chosen sides of the conflict items here and
+// writes the result to the archive
+func (e *ExportService) resolveSettings(ctx context.Context, st *settingsSyncState, choices []models.SettingsSyncChoice) (string, []string, error) {
+	local, err := e.collectSettings(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	target, err := resolveSettingsConflict(local, st.conflict, choices)
+	if err != nil {
+		return "", nil, err
+	}
+	warnings := e.applySettings(ctx, local, target)
+	st.conflict = nil
+	st.localChangedAt = time.Now().UTC().Format(time.RFC3339)
+	return models.SettingsSyncPushed, warnings, e.pushApplied(ctx, st, "")
+}
+
+// pullSettings applies the archive's configuration here
+func (e *ExportService) pullSettings(ctx context.Context, st *settingsSyncState, local, remote settingsSnapshot, remoteHash string) []string {
+	warnings := e.applySettings(ctx, local, remote)
+	if applied, err := e.collectSettings(ctx); err == nil {
+		st.localHash = applied.hash()
+	}
+	st.remoteHash = remoteHash
+	st.localChangedAt = ""
+	return warnings
+}
+
+// pushApplied writes the configuration as it is here after applying
+// changes, unless it already matches the archive
+func (e *ExportService) pushApplied(ctx context.Context, st *settingsSyncState, remoteHash string) error {
+	applied, err := e.collectSettings(ctx)
+	if err != nil {
+		return err
+	}
+	if hash := applied.hash(); hash == remoteHash {
+		st.localHash, st.remoteHash, st.localChangedAt = hash, remoteHash, ""
+		return nil
+	}
+	return e.pushSettings(ctx, st, applied)
+}
+
+// pushSettings writes the local configuration to the archive
+func (e *ExportService) pushSettings(ctx context.Context, st *settingsSyncState, local settingsSnapshot) error {
+	changedAt := time.Now().UTC()
+	if t, err := time.Parse(time.RFC3339, st.localChangedAt); err == nil {
+		changedAt = t
+	}
+	data, err := encodeSettingsArchive(local, st.password, st.machineId, changedAt)
+	if err != nil {
+		return err
+	}
+	if err := rclone.UploadBytes(ctx, st.remotePath, data); err != nil {
+		return err
+	}
+	hash := local.hash()
+	st.localHash, st.remoteHash, st.localChangedAt = hash, hash, ""
+	return nil
+}
+
+// encodeSettingsArchive writes a configuration as an encrypted export archive
+func encodeSettingsArchive(snapshot settingsSnapshot, password, machineId string, changedAt time.Time) ([]byte, error) {
+	encKey, encSalt := DeriveExportKey(password)
+	defer zeroBytes(encKey)
+
+	manifest := ExportManifest{
+		Version:    fmt.Sprintf("%d", FormatVersion),
+		AppVersion: "1.0.0",
+		ExportDate: time.Now(),
+		BoardCount: len(snapshot.Boards),
+		MachineId:  machineId,
+		ChangedAt:  &changedAt,
+	}
+	var sections []exportSection
+	for _, section := range []struct {
+		sectionType uint8
+		content     any
+	}{
+		{SectionProfiles, snapshot.Profiles},
+		{SectionBoards, snapshot.Boards},
+		{SectionSchedules, snapshot.Schedules},
+		{SectionManifest, manifest},
+	} {
+		data, err := sealExportSection(section.content, encKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export settings: %w", err)
+		}
+		sections = append(sections, exportSection{section.sectionType, data})
+	}
+	return encodeExportArchive(FlagCompressed|FlagEncrypted, encSalt, sections)
+}
+
+// collectSettings returns the local configuration as settings sync sees it
+func (e *ExportService) collectSettings(ctx context.Context) (settingsSnapshot, error) {
+	var snapshot settingsSnapshot
+	if e.configService != nil {
+		profiles, err := e.configService.GetProfiles(ctx)
+		if err != nil {
+			return snapshot, fmt.Errorf("failed to get profiles: %w", err)
+		}
+		for _, p := range profiles {
+			snapshot.Profiles = append(snapshot.Profiles, syncedProfile(p))
+		}
+	}
+	if boardService := GetBoardService(); boardService != nil {
+		boards, err := boardService.GetBoards(ctx)
+		if err != nil {
+			return snapshot, fmt.Errorf("failed to get boards: %w", err)
+		}
+		for _, b := range boards {
+			snapshot.Boards = append(snapshot.Boards, syncedBoard(b))
+		}
+	}
+	if e.schedulerService != nil {
+		schedules, err := e.schedulerService.GetSchedules(ctx)
+		if err != nil {
+			return snapshot, fmt.Errorf("failed to get schedules: %w", err)
+		}
+		for _, s := range schedules {
+			snapshot.Schedules = append(snapshot.Schedules, syncedSchedule(s))
+		}
+	}
+	snapshot.sort()
+	return snapshot, nil
+}
+
+// applySettings makes the local configuration match target, keeping local
+// paths and run state. Returns the items that couldn't be applied.
+func (e *ExportService) applySettings(ctx context.Context, local, target settingsSnapshot) []string {
+	var warnings []string
+	warn := func(kind, name string, err error) {
+		warnings = append(warnings, fmt.Sprintf("%s '%s': %v", kind, name, err))
+	}
+
+	changes := diffSettings(local, target)
+	changed := make(map[[2]string]bool)
+	for _, item := range changes {
+		changed[[2]string{item.Kind, item.Id}] = true
+	}
+
+	// Profiles first: boards and schedules refer to them
+	if e.configService != nil {
+		profiles, _ := e.configService.GetProfiles(ctx)
+		existing := make(map[string]models.Profile)
+		for _, p := range profiles {
+			existing[p.Name] = p
+		}
+		for _, p := range target.Profiles {
+			if !changed[[2]string{models.SettingsSyncProfile, p.Name}] {
+				continue
+			}
+			var err error
+			if current, ok := existing[p.Name]; ok {
+				err = e.configService.UpdateProfile(ctx, withLocalProfilePaths(p, current))
+			} else {
+				err = e.configService.AddProfile(ctx, p)
+			}
+			if err != nil {
+				warn("Profile", p.Name, err)
+			}
+		}
+	}
+	boardService := GetBoardService()
+	if boardService != nil {
+		boards, _ := boardService.GetBoards(ctx)
+		existing := make(map[string]models.Board)
+		for _, b := range boards {
+			existing[b.Id] = b
+		}
+		for _, b := range target.Boards {
+			if !changed[[2]string{models.SettingsSyncBoard, b.Id}] {
+				continue
+			}
+			var err error
+			if current, ok := existing[b.Id]; ok {
+				err = boardService.UpdateBoard(ctx, withLocalBoardState(b, current))
+			} else {
+				err = boardService.AddBoard(ctx, b)
+			}
+			if err != nil {
+				warn("Board", b.Name, err)
+			}
+		}
+	}
+	if e.schedulerService != nil {
+		schedules, _ := e.schedulerService.GetSchedules(ctx)
+		existing := make(map[string]models.ScheduleEntry)
+		for _, s := range schedules {
+			existing[s.Id] = s
+		}
+		for _, s := range target.Schedules {
+			if !changed[[2]string{models.SettingsSyncSchedule, s.Id}] {
+				continue
+			}
+			var err error
+			if current, ok := existing[s.Id]; ok {
+				err = e.schedulerService.UpdateSchedule(ctx, withLocalScheduleState(s, current))
+			} else {
+				err = e.schedulerService.AddSchedule(ctx, s)
+			}
+			if err != nil {
+				warn("Schedule", s.Id, err)
+			}
+		}
+	}
+
+	// Deletions last, each kind before what it refers to
+	for _, kind := range []string{models.SettingsSyncSchedule, models.SettingsSyncBoard, models.SettingsSyncProfile} {
+		for _, item := range changes {
+			if item.Kind != kind || item.Remote != nil {
+				continue
+			}
+			var err error
+			switch {
+			case kind == models.SettingsSyncSchedule && e.schedulerService != nil:
+				err = e.schedulerService.DeleteSchedule(ctx, item.Id)
+			case kind == models.SettingsSyncBoard && boardService != nil:
+				err = boardService.DeleteBoard(ctx, item.Id)
+			case kind == models.SettingsSyncProfile && e.configService != nil:
+				err = e.configService.DeleteProfile(ctx, item.Id)
+			}
+			if err != nil {
+				warn(strings.ToUpper(kind[:1])+kind[1:], item.Name, err)
+			}
+		}
+	}
+	return warnings
+}
+
+// hash fingerprints a configuration
+func (s settingsSnapshot) hash() string {
+	data, _ := json.Marshal(s)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// sort orders the items by their IDs, so equal configurations hash the same
+func (s *settingsSnapshot) sort() {
+	sort.Slice(s.Profiles, func(i, j int) bool { return s.Profiles[i].Name < s.Profiles[j].Name })
+	sort.Slice(s.Boards, func(i, j int) bool { return s.Boards[i].Id < s.Boards[j].Id })
+	sort.Slice(s.Schedules, func(i, j int) bool { return s.Schedules[i].Id < s.Schedules[j].Id })
+}
+
+// items returns the configuration as conflict items, keyed by kind and ID
+func (s settingsSnapshot) items() map[[2]string]models.SettingsSyncItem {
+	items := make(map[[2]string]models.SettingsSyncItem)
+	add := func(kind, id, name string, v any) {
+		data, _ := json.Marshal(v)
+		items[[2]string{kind, id}] = models.SettingsSyncItem{Kind: kind, Id: id, Name: name, Local: data}
+	}
+	for _, p := range s.Profiles {
+		add(models.SettingsSyncProfile, p.Name, p.Name, p)
+	}
+	for _, b := range s.Boards {
+		add(models.SettingsSyncBoard, b.Id, b.Name, b)
+	}
+	for _, sc := range s.Schedules {
+		add(models.SettingsSyncSchedule, sc.Id, sc.ProfileName, sc)
+	}
+	return items
+}
+
+// diffSettings returns the items that differ between two configurations,
+// ordered by kind and ID; Local is nil for items only remote has, and
+// Remote nil for items only local has
+func diffSettings(local, remote settingsSnapshot) []models.SettingsSyncItem {
+	localItems, remoteItems := local.items(), remote.items()
+	var diff []models.SettingsSyncItem
+	for key, item := range localItems {
+		other, ok := remoteItems[key]
+		if ok && string(other.Local) == string(item.Local) {
+			continue
+		}
+		if ok {
+			item.Remote = other.Local
+			item.Name = other.Name
+		}
+		diff = append(diff, item)
+	}
+	for key, item := range remoteItems {
+		if _, ok := localItems[key]; !ok {
+			item.Remote, item.Local = item.Local, nil
+			diff = append(diff, item)
+		}
+	}
+	sort.Slice(diff, func(i, j int) bool {
+		if diff[i].Kind != diff[j].Kind {
+			return diff[i].Kind < diff[j].Kind
+		}
+		return diff[i].Id < diff[j].Id
+	})
+	return diff
+}
+
+// mergeSettings combines two configurations that were never synced: items
+// either side has are kept, local ones when both have them. Returns the
+// combination and the items both have in different versions.
+func mergeSettings(local, remote settingsSnapshot) (settingsSnapshot, []models.SettingsSyncItem) {
+	var conflicts []models.SettingsSyncItem
+	merged := local
+	merged.Profiles = append([]models.Profile(nil), local.Profiles...)
+	merged.Boards = append([]models.Board(nil), local.Boards...)
+	merged.Schedules = append([]models.ScheduleEntry(nil), local.Schedules...)
+	for _, item := range diffSettings(local, remote) {
+		switch {
+		case item.Local != nil && item.Remote != nil:
+			conflicts = append(conflicts, item)
+		case item.Local == nil:
+			merged.add(item.Kind, item.Remote)
+		}
+	}
+	merged.sort()
+	return merged, conflicts
+}
+
+// resolveSettingsConflict returns the local configuration with the chosen
+// sides of the conflict items
+func resolveSettingsConflict(local settingsSnapshot, conflict *models.SettingsSyncConflictInfo, choices []models.SettingsSyncChoice) (settingsSnapshot, error) {
+	target := local
+	target.Profiles = append([]models.Profile(nil), local.Profiles...)
+	target.Boards = append([]models.Board(nil), local.Boards...)
+	target.Schedules = append([]models.ScheduleEntry(nil), local.Schedules...)
+	for _, choice := range choices {
+		var item *models.SettingsSyncItem
+		for i := range conflict.Items {
+			if conflict.Items[i].Kind == choice.Kind && conflict.Items[i].Id == choice.Id {
+				item = &conflict.Items[i]
+			}
+		}
+		if item == nil {
+			return target, fmt.Errorf("%s '%s' is not part of the conflict", choice.Kind, choice.Id)
+		}
+		var version json.RawMessage
+		switch choice.Side {
+		case models.SettingsSyncLocal:
+			version = item.Local
+		case models.SettingsSyncRemote:
+			version = item.Remote
+		default:
+			return target, fmt.Errorf("side must be %q or %q", models.SettingsSyncLocal, models.SettingsSyncRemote)
+		}
+		target.remove(item.Kind, item.Id)
+		if version != nil {
+			target.add(item.Kind, version)
+		}
+	}
+	target.sort()
+	return target, nil
+}
+
+// add adds an item from its JSON
+func (s *settingsSnapshot) add(kind string, data json.RawMessage) {
+	switch kind {
+	case models.SettingsSyncProfile:
+		var p models.Profile
+		if json.Unmarshal(data, &p) == nil {
+			s.Profiles = append(s.Profiles, p)
+		}
+	case models.SettingsSyncBoard:
+		var b models.Board
+		if json.Unmarshal(data, &b) == nil {
+			s.Boards = append(s.Boards, b)
+		}
+	case models.SettingsSyncSchedule:
+		var sc models.ScheduleEntry
+		if json.Unmarshal(data, &sc) == nil {
+			s.Schedules = append(s.Schedules, sc)
+		}
+	}
+}
+
+// remove removes an item
+func (s *settingsSnapshot) remove(kind, id string) {
+	switch kind {
+	case models.SettingsSyncProfile:
+		for i := range s.Profiles {
+			if s.Profiles[i].Name == id {
+				s.Profiles = append(s.Profiles[:i], s.Profiles[i+1:]...)
+				return
+			}
+		}
+	case models.SettingsSyncBoard:
+		for i := range s.Boards {
+			if s.Boards[i].Id == id {
+				s.Boards = append(s.Boards[:i], s.Boards[i+1:]...)
+				return
+			}
+		}
+	case models.SettingsSyncSchedule:
+		for i := range s.Schedules {
+			if s.Schedules[i].Id == id {
+				s.Schedules = append(s.Schedules[:i], s.Schedules[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// newSettingsConflict records a conflict and which side won it
+func newSettingsConflict(winner string, st *settingsSyncState, manifest *ExportManifest, items []models.SettingsSyncItem) *models.SettingsSyncConflictInfo {
+	conflict := &models.SettingsSyncConflictInfo{DetectedAt: time.Now(), Winner: winner, Items: items}
+	if t, err := time.Parse(time.RFC3339, st.localChangedAt); err == nil {
+		conflict.LocalChangedAt = &t
+	}
+	if manifest != nil {
+		conflict.RemoteChangedAt = manifest.ChangedAt
+		conflict.RemoteMachine = manifest.MachineId
+	}
+	return conflict
+}
+
+// settingsRemoteNewer reports whether the archive changed after the local
+// configuration did. Without a time on either side, the archive wins.
+func settingsRemoteNewer(localChangedAt string, manifest *ExportManifest) bool {
+	local, err := time.Parse(time.RFC3339, localChangedAt)
+	if err != nil || manifest == nil || manifest.ChangedAt == nil {
+		return true
+	}
+	return !manifest.ChangedAt.Before(local)
+}
+
+// isLocalPath reports whether a profile path is on this machine rather than
+// on a remote. Paths starting with a path variable are resolved on each
+// machine, so they aren't local to this one.
+func isLocalPath(path string) bool {
+	if _, _, ok := validation.SplitPathVariable(path); ok || path == "" {
+		return false
+	}
+	parsed, err := fspath.Parse(path)
+	return err == nil && parsed.ConfigString == ""
+}
+
+// localOnly returns "" for local paths, which differ between machines
+func localOnly(path string) string {
+	if isLocalPath(path) {
+		return ""
+	}
+	return path
+}
+
+// syncedProfile returns a profile without its machine-specific settings:
+// local paths and the network interface
+func syncedProfile(p models.Profile) models.Profile {
+	p.From = localOnly(p.From)
+	p.To = localOnly(p.To)
+	p.BackupPath = localOnly(p.BackupPath)
+	p.CachePath = localOnly(p.CachePath)
+	p.FilterFromFile = localOnly(p.FilterFromFile)
+	p.FailoverTo = localOnly(p.FailoverTo)
+	if len(p.FanOutTo) > 0 {
+		fanOut := make([]string, len(p.FanOutTo))
+		for i, dst := range p.FanOutTo {
+			fanOut[i] = localOnly(dst)
+		}
+		p.FanOutTo = fanOut
+	}
+	p.BindAddress = ""
+	return p
+}
+
+// withLocalProfilePaths returns a synced profile with the machine-specific
+// settings it doesn't have taken from the local profile
+func withLocalProfilePaths(p, local models.Profile) models.Profile {
+	keep := func(synced *string, localValue string) {
+		if *synced == "" {
+			*synced = localValue
+		}
+	}
+	keep(&p.From, local.From)
+	keep(&p.To, local.To)
+	keep(&p.BackupPath, local.BackupPath)
+	keep(&p.CachePath, local.CachePath)
+	keep(&p.FilterFromFile, local.FilterFromFile)
+	keep(&p.FailoverTo, local.FailoverTo)
+	for i := range p.FanOutTo {
+		if i < len(local.FanOutTo) {
+			keep(&p.FanOutTo[i], local.FanOutTo[i])
+		}
+	}
+	p.BindAddress = local.BindAddress
+	return p
+}
+
+// syncedBoard returns a board without local paths and run state
+func syncedBoard(b models.Board) models.Board {
+	b.UpdatedAt = time.Time{}
+	b.LastRun, b.NextRun, b.LastResult = nil, nil, ""
+	b.Nodes = append([]models.BoardNode(nil), b.Nodes...)
+	for i := range b.Nodes {
+		if b.Nodes[i].RemoteName == "local" {
+			b.Nodes[i].Path = ""
+		}
+	}
+	b.Edges = append([]models.BoardEdge(nil), b.Edges...)
+	for i := range b.Edges {
+		b.Edges[i].SyncConfig = syncedProfile(b.Edges[i].SyncConfig)
+	}
+	return b
+}
+
+// withLocalBoardState returns a synced board with the local board's paths
+// and run state
+func withLocalBoardState(b, local models.Board) models.Board {
+	b.LastRun, b.NextRun, b.LastResult = local.LastRun, local.NextRun, local.LastResult
+	for i := range b.Nodes {
+		for _, n := range local.Nodes {
+			if n.Id == b.Nodes[i].Id && b.Nodes[i].Path == "" {
+				b.Nodes[i].Path = n.Path
+			}
+		}
+	}
+	for i := range b.Edges {
+		for _, edge := range local.Edges {
+			if edge.Id == b.Edges[i].Id {
+				b.Edges[i].SyncConfig = withLocalProfilePaths(b.Edges[i].SyncConfig, edge.SyncConfig)
+			}
+		}
+	}
+	return b
+}
+
+// syncedSchedule returns a schedule without its run state
+func syncedSchedule(s models.ScheduleEntry) models.ScheduleEntry {
+	s.LastRun, s.NextRun, s.LastResult = nil, nil, ""
+	s.SuspendedRemote, s.PausedUntil = "", nil
+	return s
+}
+
+// withLocalScheduleState returns a synced schedule with the local
+// schedule's run state
+func withLocalScheduleState(s, local models.ScheduleEntry) models.ScheduleEntry {
+	s.LastRun, s.NextRun, s.LastResult = local.LastRun, local.NextRun, local.LastResult
+	s.SuspendedRemote, s.PausedUntil = local.SuspendedRemote, local.PausedUntil
+	return s
+}
+
+// loadSettingsSyncState reads the settings sync row, or nil if settings
+// sync isn't set up
+func loadSettingsSyncState() (*settingsSyncState, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	var st settingsSyncState
+	var warnings, conflict string
+	err = db.QueryRow(`SELECT remote_path, password, machine_id, interval_minutes, local_hash, remote_hash,
+		local_changed_at, last_sync_at, last_result, last_error, warnings, conflict
+		FROM settings_sync WHERE id = 1`).Scan(&st.remotePath, &st.password, &st.machineId, &st.intervalMinutes,
+		&st.localHash, &st.remoteHash, &st.localChangedAt, &st.lastSyncAt, &st.lastResult, &st.lastError, &warnings, &conflict)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if warnings != "" {
+		json.Unmarshal([]byte(warnings), &st.warnings)
+	}
+	if conflict != "" {
+		st.conflict = &models.SettingsSyncConflictInfo{}
+		if err := json.Unmarshal([]byte(conflict), st.conflict); err != nil {
+			log.Printf("Warning: Could not parse the settings sync conflict: %v", err)
+			st.conflict = nil
+		}
+	}
+	return &st, nil
+}
+
+// saveSettingsSyncState writes the settings sync row
+func saveSettingsSyncState(st *settingsSyncState) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	var warnings, conflict string
+	if len(st.warnings) > 0 {
+		data, _ := json.Marshal(st.warnings)
+		warnings = string(data)
+	}
+	if st.conflict != nil {
+		data, err := json.Marshal(st.conflict)
+		if err != nil {
+			return err
+		}
+		conflict = string(data)
+	}
+	_, err = db.Exec(`INSERT OR REPLACE INTO settings_sync (id, remote_path, password, machine_id, interval_minutes,
+		local_hash, remote_hash, local_changed_at, last_sync_at, last_result, last_error, warnings, conflict)
+		VALUES (1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		st.remotePath, st.password, st.machineId, st.intervalMinutes, st.localHash, st.remoteHash,
+		st.localChangedAt, st.lastSyncAt, st.lastResult, st.lastError, warnings, conflict)
+	return err
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"desktop/backend/models"
+	"testing"
+	"time"
+)
+
+func TestSyncedProfile(t *testing.T) {
+	p := models.Profile{
+		Name:        "docs",
+		From:        "/home/me/Documents",
+		To:          "gdrive:Documents",
+		FanOutTo:    []string{"/mnt/backup", "s3:bucket/docs"},
+		BindAddress: "eth0",
+	}
+	synced := syncedProfile(p)
+	if synced.From != "" || synced.To != "gdrive:Documents" || synced.BindAddress != "" {
+		t.Errorf("syncedProfile() = from %q, to %q, bind %q", synced.From, synced.To, synced.BindAddress)
+	}
+	if synced.FanOutTo[0] != "" || synced.FanOutTo[1] != "s3:bucket/docs" {
+		t.Errorf("syncedProfile() fan-out = %q", synced.FanOutTo)
+	}
+	if p.FanOutTo[0] != "/mnt/backup" {
+		t.Error("syncedProfile() changed the original profile")
+	}
+	if synced := syncedProfile(models.Profile{From: "${DOCS_DIR}/work"}); synced.From != "${DOCS_DIR}/work" {
+		t.Errorf("syncedProfile() dropped a path variable: %q", synced.From)
+	}
+
+	other := models.Profile{Name: "docs", From: "/Users/me/Documents", To: "gdrive:Old", FanOutTo: []string{"/Volumes/Backup", "s3:old"}, BindAddress: "en0"}
+	restored := withLocalProfilePaths(synced, other)
+	if restored.From != "/Users/me/Documents" || restored.To != "gdrive:Documents" || restored.BindAddress != "en0" {
+		t.Errorf("withLocalProfilePaths() = from %q, to %q, bind %q", restored.From, restored.To, restored.BindAddress)
+	}
+	if restored.FanOutTo[0] != "/Volumes/Backup" || restored.FanOutTo[1] != "s3:bucket/docs" {
+		t.Errorf("withLocalProfilePaths() fan-out = %q", restored.FanOutTo)
+	}
+}
+
+func TestSettingsSnapshotHash(t *testing.T) {
+	a := settingsSnapshot{Profiles: []models.Profile{{Name: "b"}, {Name: "a"}}}
+	b := settingsSnapshot{Profiles: []models.Profile{{Name: "a"}, {Name: "b"}}}
+	a.sort()
+	b.sort()
+	if a.hash() != b.hash() {
+		t.Error("hash() depends on the order of the items")
+	}
+
+	now := time.Now()
+	withRun := syncedSchedule(models.ScheduleEntry{Id: "s1", LastRun: &now, LastResult: "success"})
+	withoutRun := syncedSchedule(models.ScheduleEntry{Id: "s1"})
+	if (settingsSnapshot{Schedules: []models.ScheduleEntry{withRun}}).hash() != (settingsSnapshot{Schedules: []models.ScheduleEntry{withoutRun}}).hash() {
+		t.Error("hash() depends on run state")
+	}
+}
+
+func TestDiffAndMergeSettings(t *testing.T) {
+	local := settingsSnapshot{Profiles: []models.Profile{
+		{Name: "both", To: "gdrive:a"},
+		{Name: "local-only", To: "gdrive:l"},
+		{Name: "same", To: "gdrive:s"},
+	}}
+	remote := settingsSnapshot{Profiles: []models.Profile{
+		{Name: "both", To: "gdrive:b"},
+		{Name: "remote-only", To: "gdrive:r"},
+		{Name: "same", To: "gdrive:s"},
+	}}
+
+	diff := diffSettings(local, remote)
+	if len(diff) != 3 {
+		t.Fatalf("diffSettings() returned %d items, want 3", len(diff))
+	}
+	for _, item := range diff {
+		switch item.Id {
+		case "both":
+			if item.Local == nil || item.Remote == nil {
+				t.Errorf("diffSettings() %q should have both sides", item.Id)
+			}
+		case "local-only":
+			if item.Remote != nil {
+				t.Errorf("diffSettings() %q should have no remote side", item.Id)
+			}
+		case "remote-only":
+			if item.Local != nil {
+				t.Errorf("diffSettings() %q should have no local side", item.Id)
+			}
+		default:
+			t.Errorf("diffSettings() returned unchanged item %q", item.Id)
+		}
+	}
+
+	merged, conflicts := mergeSettings(local, remote)
+	if len(merged.Profiles) != 4 {
+		t.Errorf("mergeSettings() kept %d profiles, want 4", len(merged.Profiles))
+	}
+	if len(conflicts) != 1 || conflicts[0].Id != "both" {
+		t.Errorf("mergeSettings() conflicts = %v, want \"both\"", conflicts)
+	}
+	for _, p := range merged.Profiles {
+		if p.Name == "both" && p.To != "gdrive:a" {
+			t.Errorf("mergeSettings() kept %q for a profile both have, want the local version", p.To)
+		}
+	}
+
+	conflict := &models.SettingsSyncConflictInfo{Items: diff}
+	resolved, err := resolveSettingsConflict(local, conflict, []models.SettingsSyncChoice{
+		{Kind: models.SettingsSyncProfile, Id: "both", Side: models.SettingsSyncRemote},
+		{Kind: models.SettingsSyncProfile, Id: "local-only", Side: models.SettingsSyncRemote},
+		{Kind: models.SettingsSyncProfile, Id: "remote-only", Side: models.SettingsSyncRemote},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.hash() != remote.hash() {
+		t.Errorf("resolveSettingsConflict() taking every remote side = %+v, want the remote configuration", resolved.Profiles)
+	}
+	if _, err := resolveSettingsConflict(local, conflict, []models.SettingsSyncChoice{{Kind: models.SettingsSyncProfile, Id: "same", Side: models.SettingsSyncLocal}}); err == nil {
+		t.Error("resolveSettingsConflict() accepted an item that is not part of the conflict")
+	}
+}
+
+func TestSettingsRemoteNewer(t *testing.T) {
+	local := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	earlier, later := local.Add(-time.Hour), local.Add(time.Hour)
+	if settingsRemoteNewer(local.Format(time.RFC3339), &ExportManifest{ChangedAt: &earlier}) {
+		t.Error("an earlier remote change won")
+	}
+	if !settingsRemoteNewer(local.Format(time.RFC3339), &ExportManifest{ChangedAt: &later}) {
+		t.Error("a later remote change lost")
+	}
+	if !settingsRemoteNewer(local.Format(time.RFC3339), &ExportManifest{}) {
+		t.Error("a remote change without a time lost")
+	}
+}
@@ -0,0 +1,393 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"fmt"
+	"html/template"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultSnapshotHistoryLimit is the number of recent runs included when no limit is given
+const defaultSnapshotHistoryLimit = 50
+
+// defaultSnapshotValidHours is how long a snapshot stays valid when no
+// validity is given, and maxSnapshotValidHours the longest it can be
+const (
+	defaultSnapshotValidHours = 7 * 24
+	maxSnapshotValidHours     = 90 * 24
+)
+
+// StatusSnapshot is the data rendered into the HTML status snapshot.
+// It is built from an allow-list of names, paths, statuses and counters —
+// never error messages, remote configuration, sync options or credentials.
+// The page stops showing the status once ExpiresAt has passed, but only in
+// a browser running its script: the data stays in the HTML source.
+type StatusSnapshot struct {
+	GeneratedAt time.Time          `json:"generated_at"`
+	ExpiresAt   time.Time          `json:"expires_at"`
+	Boards      []BoardSnapshot    `json:"boards"`
+	Schedules   []ScheduleSnapshot `json:"schedules"`
+	Runs        []RunSnapshot      `json:"runs"`
+	Stats       *StatsSnapshot     `json:"stats,omitempty"`
+}
+
+// BoardSnapshot summarizes a board and its latest execution
+type BoardSnapshot struct {
+	Name    string         `json:"name"`
+	Status  string         `json:"status"` // active execution status, or the last scheduled result
+	LastRun *time.Time     `json:"last_run,omitempty"`
+	Edges   []EdgeSnapshot `json:"edges"`
+}
+
+// EdgeSnapshot summarizes a single board edge
+type EdgeSnapshot struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Action string `json:"action"`
+	Status string `json:"status,omitempty"`
+}
+
+// ScheduleSnapshot summarizes a schedule
+type ScheduleSnapshot struct {
+	Target     string     `json:"target"`
+	CronExpr   string     `json:"cron_expr"`
+	Enabled    bool       `json:"enabled"`
+	LastRun    *time.Time `json:"last_run,omitempty"`
+	NextRun    *time.Time `json:"next_run,omitempty"`
+	LastResult string     `json:"last_result,omitempty"`
+}
+
+// RunSnapshot summarizes a history entry, without its error message
+type RunSnapshot struct {
+	ProfileName      string    `json:"profile_name"`
+	Action           string    `json:"action"`
+	Status           string    `json:"status"`
+	StartTime        time.Time `json:"start_time"`
+	Duration         string    `json:"duration"`
+	FilesTransferred int64     `json:"files_transferred"`
+	BytesTransferred int64     `json:"bytes_transferred"`
+	Errors           int       `json:"errors"`
+}
+
+// StatsSnapshot summarizes the run counters of the history
+type StatsSnapshot struct {
+	TotalOperations int   `json:"total_operations"`
+	SuccessCount    int   `json:"success_count"`
+	FailureCount    int   `json:"failure_count"`
+	CancelledCount  int   `json:"cancelled_count"`
+	TotalBytes      int64 `json:"total_bytes"`
+}
+
+// RenderStatusSnapshot renders board, schedule and run status into a single
+// self-contained HTML page (inline CSS and script, no external assets),
+// valid for validHours (0 for a week, at most 90 days). Past that, the page
+// shows that it expired instead of the status. The expiry is cosmetic: a
+// static page has no key it could lose, so the status can still be read
+// from the source. Delete the page to take it back.
+func (e *ExportService) RenderStatusSnapshot(ctx context.Context, historyLimit, validHours int) (string, error) {
+	snapshot, err := e.buildStatusSnapshot(ctx, historyLimit, validHours)
+	if err != nil {
+		return "", err
+	}
+	return renderStatusSnapshot(snapshot)
+}
+
+// renderStatusSnapshot renders a status snapshot into its HTML page
+func renderStatusSnapshot(snapshot *StatusSnapshot) (string, error) {
+	var buf bytes.Buffer
+	if err := statusSnapshotTemplate.Execute(&buf, snapshot); err != nil {
+		return "", fmt.Errorf("failed to render status snapshot: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// ExportStatusSnapshotToFile renders the status snapshot and writes it to a local file
+func (e *ExportService) ExportStatusSnapshotToFile(ctx context.Context, filePath string, historyLimit, validHours int) error {
+	html, err := e.RenderStatusSnapshot(ctx, historyLimit, validHours)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(filePath, []byte(html), 0644); err != nil {
+		return fmt.Errorf("failed to write status snapshot: %w", err)
+	}
+
+	log.Printf("ExportService: Wrote status snapshot to %s (%d bytes)", filePath, len(html))
+	return nil
+}
+
+// UploadStatusSnapshot renders the status snapshot and uploads it to an rclone
+// remote path, e.g. "gdrive:status/index.html".
+func (e *ExportService) UploadStatusSnapshot(ctx context.Context, remotePath string, historyLimit, validHours int) error {
+	html, err := e.RenderStatusSnapshot(ctx, historyLimit, validHours)
+	if err != nil {
+		return err
+	}
+
+	opCtx, err := rclone.SimpleContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to initialize rclone config: %w", err)
+	}
+	if err := rclone.UploadBytes(opCtx, remotePath, []byte(html)); err != nil {
+		return err
+	}
+
+	log.Printf("ExportService: Uploaded status snapshot to %s (%d bytes)", redactRemotePath(remotePath), len(html))
+	return nil
+}
+
+// buildStatusSnapshot collects the snapshot data from boards, schedules and history
+func (e *ExportService) buildStatusSnapshot(ctx context.Context, historyLimit, validHours int) (*StatusSnapshot, error) {
+	if historyLimit <= 0 {
+		historyLimit = defaultSnapshotHistoryLimit
+	}
+	if validHours < 0 || validHours > maxSnapshotValidHours {
+		return nil, fmt.Errorf("a snapshot can be valid for at most %d hours", maxSnapshotValidHours)
+	}
+	if validHours == 0 {
+		validHours = defaultSnapshotValidHours
+	}
+
+	now := time.Now()
+	snapshot := &StatusSnapshot{
+		GeneratedAt: now,
+		ExpiresAt:   now.Add(time.Duration(validHours) * time.Hour),
+		Boards:      []BoardSnapshot{},
+		Schedules:   []ScheduleSnapshot{},
+		Runs:        []RunSnapshot{},
+	}
+
+	boardNames := make(map[string]string)
+	if boardService := GetBoardService(); boardService != nil {
+		boards, err := boardService.GetBoards(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load boards: %w", err)
+		}
+		for _, board := range boards {
+			boardNames[board.Id] = board.Name
+			var execStatus *models.BoardExecutionStatus
+			if status, err := boardService.GetBoardExecutionStatus(ctx, board.Id); err == nil {
+				execStatus = status
+			}
+			snapshot.Boards = append(snapshot.Boards, snapshotBoard(board, execStatus))
+		}
+	}
+
+	if e.schedulerService != nil {
+		schedules, err := e.schedulerService.GetSchedules(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load schedules: %w", err)
+		}
+		for _, s := range schedules {
+			target := scheduleTargetLabel(s)
+			if name, ok := boardNames[s.TargetId]; ok && scheduleTargetType(s) == "board" {
+				target = name
+			}
+			snapshot.Schedules = append(snapshot.Schedules, ScheduleSnapshot{
+				Target:     fmt.Sprintf("%s: %s", scheduleTargetType(s), target),
+				CronExpr:   s.CronExpr,
+				Enabled:    isScheduleActive(s),
+				LastRun:    s.LastRun,
+				NextRun:    s.NextRun,
+				LastResult: s.LastResult,
+			})
+		}
+	}
+
+	if e.historyService != nil {
+		history, err := e.historyService.GetHistory(ctx, historyLimit, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load history: %w", err)
+		}
+		for _, entry := range history {
+			snapshot.Runs = append(snapshot.Runs, snapshotRun(entry))
+		}
+
+		stats, err := e.historyService.GetStats(ctx)
+		if err == nil {
+			snapshot.Stats = &StatsSnapshot{
+				TotalOperations: stats.TotalOperations,
+				SuccessCount:    stats.SuccessCount,
+				FailureCount:    stats.FailureCount,
+				CancelledCount:  stats.CancelledCount,
+				TotalBytes:      stats.TotalBytes,
+			}
+		}
+	}
+
+	return snapshot, nil
+}
+
+// snapshotBoard converts a board and its optional execution status into a BoardSnapshot
+func snapshotBoard(board models.Board, execStatus *models.BoardExecutionStatus) BoardSnapshot {
+	bs := BoardSnapshot{
+		Name:    board.Name,
+		Status:  board.LastResult,
+		LastRun: board.LastRun,
+		Edges:   []EdgeSnapshot{},
+	}
+
+	edgeStatuses := make(map[string]models.EdgeExecutionStatus)
+	if execStatus != nil {
+		bs.Status = execStatus.Status
+		startTime := execStatus.StartTime
+		bs.LastRun = &startTime
+		for _, es := range execStatus.EdgeStatuses {
+			edgeStatuses[es.EdgeId] = es
+		}
+	}
+
+	nodes := make(map[string]models.BoardNode, len(board.Nodes))
+	for _, n := range board.Nodes {
+		nodes[n.Id] = n
+	}
+
+	for _, edge := range board.Edges {
+		source, target := nodes[edge.SourceId], nodes[edge.TargetId]
+		es := EdgeSnapshot{
+			From:   redactRemotePath(joinRemotePath(source.RemoteName, source.Path)),
+			To:     redactRemotePath(joinRemotePath(target.RemoteName, target.Path)),
+			Action: edge.Action,
+		}
+		if status, ok := edgeStatuses[edge.Id]; ok {
+			es.Status = status.Status
+		}
+		bs.Edges = append(bs.Edges, es)
+	}
+	return bs
+}
+
+// snapshotRun converts a history entry into a RunSnapshot. Error messages
+// are left out: they can carry paths, URLs and host names.
+func snapshotRun(entry models.HistoryEntry) RunSnapshot {
+	return RunSnapshot{
+		ProfileName:      entry.ProfileName,
+		Action:           entry.Action,
+		Status:           entry.Status,
+		StartTime:        entry.StartTime,
+		Duration:         entry.Duration,
+		FilesTransferred: entry.FilesTransferred,
+		BytesTransferred: entry.BytesTransferred,
+		Errors:           entry.Errors,
+	}
+}
+
+// redactRemotePath strips inline backend parameters from connection-string
+// remotes (":sftp,host=x,pass=y:/path" -> ":sftp:/path"), which may carry
+// credentials. Named remotes and local paths are returned unchanged.
+func redactRemotePath(p string) string {
+	if !strings.HasPrefix(p, ":") {
+		return p
+	}
+	end := strings.Index(p[1:], ":")
+	if end < 0 {
+		return p
+	}
+	backend := p[1 : end+1]
+	if i := strings.Index(backend, ","); i >= 0 {
+		backend = backend[:i]
+	}
+	return ":" + backend + p[end+1:]
+}
+
+var statusSnapshotTemplate = template.Must(template.New("snapshot").Funcs(template.FuncMap{
+	"fmtTime": func(t interface{}) string {
+		switch v := t.(type) {
+		case time.Time:
+			if v.IsZero() {
+				return "—"
+			}
+			return v.Local().Format("2006-01-02 15:04")
+		case *time.Time:
+			if v == nil || v.IsZero() {
+				return "—"
+			}
+			return v.Local().Format("2006-01-02 15:04")
+		}
+		return "—"
+	},
+	"fmtBytes": func(n int64) string {
+		const unit = 1024
+		if n < unit {
+			return fmt.Sprintf("%d B", n)
+		}
+		div, exp := int64(unit), 0
+		for m := n / unit; m >= unit; m /= unit {
+			div *= unit
+			exp++
+		}
+		return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>GN Drive status</title>
+<style>
+body{font-family:-apple-system,BlinkMacSystemFont,"Segoe UI",Roboto,sans-serif;margin:2rem;color:#1f2328;background:#fff}
+h1{font-size:1.5rem;margin-bottom:.25rem}
+h2{font-size:1.15rem;margin-top:2rem;border-bottom:1px solid #d0d7de;padding-bottom:.25rem}
+.muted{color:#656d76;font-size:.9rem}
+table{border-collapse:collapse;width:100%;margin-top:.5rem;font-size:.9rem}
+th,td{text-align:left;padding:.35rem .6rem;border-bottom:1px solid #eaeef2;vertical-align:top}
+th{background:#f6f8fa}
+.status{font-weight:600}
+.completed,.success{color:#1a7f37}
+.failed{color:#cf222e}
+.running{color:#0969da}
+.cancelled,.skipped{color:#9a6700}
+</style>
+</head>
+<body>
+<h1>GN Drive status</h1>
+<div class="muted">Generated {{fmtTime .GeneratedAt}} &middot; valid until {{fmtTime .ExpiresAt}}</div>
+<p id="expired" hidden>This status page expired on {{fmtTime .ExpiresAt}}. Ask for a new one.</p>
+<div id="status">
+{{with .Stats}}
+<p>{{.TotalOperations}} runs &middot; {{.SuccessCount}} succeeded &middot; {{.FailureCount}} failed &middot; {{.CancelledCount}} cancelled &middot; {{fmtBytes .TotalBytes}} transferred</p>
+{{end}}
+<h2>Boards</h2>
+{{if .Boards}}{{range .Boards}}
+<h3>{{.Name}} <span class="status {{.Status}}">{{if .Status}}{{.Status}}{{else}}never run{{end}}</span></h3>
+<div class="muted">Last run: {{fmtTime .LastRun}}</div>
+<table>
+<tr><th>From</th><th>To</th><th>Action</th><th>Status</th></tr>
+{{range .Edges}}<tr><td>{{.From}}</td><td>{{.To}}</td><td>{{.Action}}</td><td><span class="status {{.Status}}">{{.Status}}</span></td></tr>
+{{end}}</table>
+{{end}}{{else}}<p class="muted">No boards.</p>{{end}}
+<h2>Schedules</h2>
+{{if .Schedules}}<table>
+<tr><th>Target</th><th>Cron</th><th>Enabled</th><th>Last run</th><th>Next run</th><th>Result</th></tr>
+{{range .Schedules}}<tr><td>{{.Target}}</td><td>{{.CronExpr}}</td><td>{{if .Enabled}}yes{{else}}no{{end}}</td><td>{{fmtTime .LastRun}}</td><td>{{fmtTime .NextRun}}</td><td><span class="status {{.LastResult}}">{{.LastResult}}</span></td></tr>
+{{end}}</table>{{else}}<p class="muted">No schedules.</p>{{end}}
+<h2>Recent runs</h2>
+{{if .Runs}}<table>
+<tr><th>Profile</th><th>Action</th><th>Status</th><th>Started</th><th>Duration</th><th>Files</th><th>Size</th><th>Errors</th></tr>
+{{range .Runs}}<tr><td>{{.ProfileName}}</td><td>{{.Action}}</td><td><span class="status {{.Status}}">{{.Status}}</span></td><td>{{fmtTime .StartTime}}</td><td>{{.Duration}}</td><td>{{.FilesTransferred}}</td><td>{{fmtBytes .BytesTransferred}}</td><td>{{.Errors}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">No runs recorded.</p>{{end}}
+</div>
+<script>
+(function () {
+  var expiresAt = {{.ExpiresAt.UnixMilli}};
+  function expire() {
+    document.getElementById("status").remove();
+    document.getElementById("expired").hidden = false;
+  }
+  var left = expiresAt - Date.now();
+  if (left <= 0) { expire(); } else if (left < 2147483647) { setTimeout(expire, left); }
+})();
+</script>
+</body>
+</html>
+`))
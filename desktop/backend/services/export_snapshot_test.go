@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderStatusSnapshot(t *testing.T) {
+	board := models.Board{
+		Name: "Photos",
+		Nodes: []models.BoardNode{
+			{Id: "a", RemoteName: "local", Path: "/photos"},
+			{Id: "b", RemoteName: ":sftp,host=nas.lan,pass=secret", Path: "/backup"},
+		},
+		Edges: []models.BoardEdge{{Id: "e1", SourceId: "a", TargetId: "b", Action: "push"}},
+	}
+	execStatus := &models.BoardExecutionStatus{
+		Status:       "failed",
+		StartTime:    time.Now(),
+		EdgeStatuses: []models.EdgeExecutionStatus{{EdgeId: "e1", Status: "failed", Message: "dial tcp nas.lan:22: connection refused"}},
+	}
+	run := snapshotRun(models.HistoryEntry{
+		Id: "h1", ProfileName: "photos", Action: "push", Status: "failed", Errors: 1,
+		ErrorMessage: "open /home/alice/photos/raw: permission denied",
+	})
+
+	now := time.Now()
+	snapshot := &StatusSnapshot{
+		GeneratedAt: now,
+		ExpiresAt:   now.Add(24 * time.Hour),
+		Boards:      []BoardSnapshot{snapshotBoard(board, execStatus)},
+		Runs:        []RunSnapshot{run},
+		Stats:       &StatsSnapshot{TotalOperations: 1, FailureCount: 1},
+	}
+	html, err := renderStatusSnapshot(snapshot)
+	if err != nil {
+		t.Fatalf("renderStatusSnapshot failed: %v", err)
+	}
+
+	for _, want := range []string{"Photos", ":sftp:/backup", "photos", "valid until", strconv.FormatInt(snapshot.ExpiresAt.UnixMilli(), 10)} {
+		if !strings.Contains(html, want) {
+			t.Errorf("snapshot is missing %q", want)
+		}
+	}
+	for _, leak := range []string{"secret", "nas.lan", "connection refused", "/home/alice", "permission denied"} {
+		if strings.Contains(html, leak) {
+			t.Errorf("snapshot leaks %q", leak)
+		}
+	}
+}
+
+func TestBuildStatusSnapshot_Validity(t *testing.T) {
+	e := NewExportService(nil)
+	ctx := context.Background()
+
+	snapshot, err := e.buildStatusSnapshot(ctx, 0, 0)
+	if err != nil {
+		t.Fatalf("buildStatusSnapshot failed: %v", err)
+	}
+	if got := snapshot.ExpiresAt.Sub(snapshot.GeneratedAt); got != defaultSnapshotValidHours*time.Hour {
+		t.Errorf("default validity = %v, want %d hours", got, defaultSnapshotValidHours)
+	}
+
+	snapshot, err = e.buildStatusSnapshot(ctx, 0, 2)
+	if err != nil {
+		t.Fatalf("buildStatusSnapshot failed: %v", err)
+	}
+	if got := snapshot.ExpiresAt.Sub(snapshot.GeneratedAt); got != 2*time.Hour {
+		t.Errorf("validity = %v, want 2h", got)
+	}
+
+	for _, hours := range []int{-1, maxSnapshotValidHours + 1} {
+		if _, err := e.buildStatusSnapshot(ctx, 0, hours); err == nil {
+			t.Errorf("validity of %d hours was accepted", hours)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Verify report export formats
+const (
+	VerifyExportCSV  = "csv"
+	VerifyExportJSON = "json"
+)
+
+// VerifyFileRecord is one file of a verify report in a CSV export
+type VerifyFileRecord struct {
+	ReportId    int64
+	ProfileName string
+	Difference  string // "differ", "missing_on_dst", "missing_on_src" or "error"
+	Path        string
+}
+
+var verifyFileColumns = []string{"report_id", "profile_name", "difference", "path"}
+
+// ExportVerifyReport exports a recorded verify report as JSON, the report
+// itself, or as CSV, one row per file found different, missing on either
+// side or unreadable. Like the report, each list holds at most the first
+// 1000 files; the JSON export has the full counts.
+func (e *ExportService) ExportVerifyReport(ctx context.Context, reportId int64, format string) ([]byte, error) {
+	report, err := getVerifyReport(reportId)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case VerifyExportCSV:
+		return writeHistoryCSV(verifyFileColumns, verifyFileRecords(report), verifyFileRow)
+	case VerifyExportJSON:
+		return json.MarshalIndent(report, "", "  ")
+	}
+	return nil, fmt.Errorf("unknown verify report export format %q: expected csv or json", format)
+}
+
+// ExportVerifyReportToFile exports a recorded verify report to a local file
+func (e *ExportService) ExportVerifyReportToFile(ctx context.Context, filePath string, reportId int64, format string) error {
+	data, err := e.ExportVerifyReport(ctx, reportId, format)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(filePath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write verify report export: %w", err)
+	}
+
+	log.Printf("ExportService: Exported verify report %d to %s (%d bytes)", reportId, filePath, len(data))
+	return nil
+}
+
+// verifyFileRecords returns the files listed in a verify report
+func verifyFileRecords(report *models.VerifyReport) []VerifyFileRecord {
+	var records []VerifyFileRecord
+	add := func(difference string, paths []string) {
+		for _, p := range paths {
+			records = append(records, VerifyFileRecord{
+				ReportId:    report.Id,
+				ProfileName: report.ProfileName,
+				Difference:  difference,
+				Path:        p,
+			})
+		}
+	}
+	add("differ", report.Differ)
+	add("missing_on_dst", report.MissingOnDst)
+	add("missing_on_src", report.MissingOnSrc)
+	add("error", report.Errors)
+	return records
+}
+
+func verifyFileRow(f VerifyFileRecord) []string {
+	return []string{strconv.FormatInt(f.ReportId, 10), f.ProfileName, f.Difference, csvSafe(f.Path)}
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"desktop/backend/models"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestExportService_ExportVerifyReport(t *testing.T) {
+	start := time.Now().Truncate(time.Second)
+	report := &models.VerifyReport{
+		ProfileName: "vault", Status: "problems", CryptCheck: true, Matched: 5,
+		Differ: []string{"=sum.xlsx"}, DifferCount: 1,
+		MissingOnDst: []string{"a.txt", "b.txt"}, MissingCount: 2,
+		MissingOnSrc: []string{"old.txt"}, ExtraCount: 1,
+		StartTime: start, EndTime: start.Add(time.Minute),
+	}
+	if err := saveVerifyReport(report); err != nil {
+		t.Fatalf("saveVerifyReport failed: %v", err)
+	}
+	e := NewExportService(nil)
+	ctx := context.Background()
+
+	data, err := e.ExportVerifyReport(ctx, report.Id, VerifyExportCSV)
+	if err != nil {
+		t.Fatalf("CSV export failed: %v", err)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(rows) != 5 || rows[0][2] != "difference" {
+		t.Fatalf("expected a header and 4 files, got %v", rows)
+	}
+	if rows[1][2] != "differ" || rows[1][3] != "'=sum.xlsx" || rows[4][2] != "missing_on_src" || rows[4][3] != "old.txt" {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+
+	data, err = e.ExportVerifyReport(ctx, report.Id, VerifyExportJSON)
+	if err != nil {
+		t.Fatalf("JSON export failed: %v", err)
+	}
+	var exported models.VerifyReport
+	if err := json.Unmarshal(data, &exported); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if exported.Id != report.Id || !exported.CryptCheck || exported.MissingCount != 2 || exported.Problems() != 3 {
+		t.Errorf("unexpected exported report: %+v", exported)
+	}
+
+	if _, err := e.ExportVerifyReport(ctx, report.Id, "xml"); err == nil {
+		t.Error("expected an unknown format to fail")
+	}
+	if _, err := e.ExportVerifyReport(ctx, -1, VerifyExportJSON); err == nil {
+		t.Error("expected an unknown report to fail")
+	}
+}
+
+func TestVerifyHistoryEntry(t *testing.T) {
+	start := time.Now()
+	report := &models.VerifyReport{Id: 7, ProfileName: "vault", Status: "problems", DifferCount: 1, MissingCount: 2,
+		StartTime: start, EndTime: start.Add(time.Minute)}
+
+	entry := verifyHistoryEntry(report)
+	if entry.Id != "verify-7" || entry.Action != "verify" || entry.Status != "failed" || entry.Errors != 3 {
+		t.Errorf("unexpected entry for a report with problems: %+v", entry)
+	}
+	if len(entry.Labels) != 1 || entry.Labels[0] != models.LabelVerifyPrefix+"7" {
+		t.Errorf("labels = %v, want the report's label", entry.Labels)
+	}
+
+	report.Status, report.DifferCount, report.MissingCount = "ok", 0, 0
+	if entry := verifyHistoryEntry(report); entry.Status != "completed" || entry.ErrorMessage != "" {
+		t.Errorf("unexpected entry for a clean report: %+v", entry)
+	}
+}
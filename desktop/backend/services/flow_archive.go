@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// runArchiveStep packs the step's local directory into an archive in a
+// staging directory owned by the run and hands it to the next sync step
+func runArchiveStep(ctx context.Context, op models.Operation, run *flowRun, lookupSecret func(string) (string, error)) error {
+	step := op.Archive
+	if step == nil || strings.TrimSpace(step.SourcePath) == "" {
+		return fmt.Errorf("archive step has no source directory")
+	}
+	ext, err := rclone.ArchiveExtension(step.Format)
+	if err != nil {
+		return err
+	}
+
+	name := rclone.ArchiveName(step.SourcePath, time.Now())
+	if step.Name != "" {
+		if name, err = expandRunVariables(step.Name, run.variables); err != nil {
+			return fmt.Errorf("name: %w", err)
+		}
+		if strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+			return fmt.Errorf("invalid archive name '%s'", name)
+		}
+	}
+	name += ext
+
+	opt := rclone.ArchiveOptions{
+		Format:        step.Format,
+		IncludedPaths: step.IncludedPaths,
+		ExcludedPaths: step.ExcludedPaths,
+	}
+	if step.PasswordSecret != "" {
+		if opt.Password, err = lookupSecret(step.PasswordSecret); err != nil {
+			return fmt.Errorf("password: %w", err)
+		}
+		name += rclone.EncryptedArchiveSuffix
+	}
+
+	staging := rclone.StagingDir()
+	if err := os.MkdirAll(staging, 0700); err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	dir, err := os.MkdirTemp(staging, "archive-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	run.tempDirs = append(run.tempDirs, dir)
+
+	path := filepath.Join(dir, name)
+	count, err := rclone.CreateArchive(ctx, step.SourcePath, path, opt)
+	if err != nil {
+		return err
+	}
+	log.Printf("[FlowService] Archived %d files from %s to %s", count, step.SourcePath, path)
+	run.setArchive(path)
+	return nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/fs/fshttp"
+)
+
+// defaultHTTPStepTimeout applies to HTTP steps that don't set a timeout
+const defaultHTTPStepTimeout = 30 * time.Second
+
+// maxHTTPStepErrorBody caps how much of a failed response is quoted in the error
+const maxHTTPStepErrorBody = 512
+
+// expandRunVariables substitutes ${VARIABLE} placeholders in s. It fails if
+// a placeholder has no value, naming every unknown variable.
+func expandRunVariables(s string, variables map[string]string) (string, error) {
+	missing := make(map[string]bool)
+	expanded := templateVariablePattern.ReplaceAllStringFunc(s, func(placeholder string) string {
+		name := templateVariablePattern.FindStringSubmatch(placeholder)[1]
+		value, ok := variables[name]
+		if !ok {
+			missing[name] = true
+			return placeholder
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("unknown run variables: %s", strings.Join(names, ", "))
+	}
+	return expanded, nil
+}
+
+// buildHTTPStepRequest builds the request of an HTTP step, expanding run
+// variables and reading secret header values through lookupSecret
+func buildHTTPStepRequest(ctx context.Context, step *models.HTTPStep, variables map[string]string, lookupSecret func(string) (string, error)) (*http.Request, error) {
+	rawURL, err := expandRunVariables(step.URL, variables)
+	if err != nil {
+		return nil, fmt.Errorf("url: %w", err)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid url '%s': must be an absolute http(s) URL", rawURL)
+	}
+	body, err := expandRunVariables(step.Body, variables)
+	if err != nil {
+		return nil, fmt.Errorf("body: %w", err)
+	}
+
+	method := strings.ToUpper(strings.TrimSpace(step.Method))
+	if method == "" {
+		method = http.MethodPost
+	}
+	var bodyReader io.Reader
+	if body != "" {
+		bodyReader = strings.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for _, h := range step.Headers {
+		name := strings.TrimSpace(h.Name)
+		if name == "" {
+			return nil, fmt.Errorf("header name is required")
+		}
+		value := h.Value
+		if h.Secret != "" {
+			if value, err = lookupSecret(h.Secret); err != nil {
+				return nil, fmt.Errorf("header '%s': %w", name, err)
+			}
+		} else if value, err = expandRunVariables(value, variables); err != nil {
+			return nil, fmt.Errorf("header '%s': %w", name, err)
+		}
+		req.Header.Set(name, value)
+	}
+	return req, nil
+}
+
+// runHTTPStep performs an HTTP step. Any non-2xx response fails the step.
+// The request goes through rclone's HTTP transport so the app-wide proxy,
+// CA and bind settings apply.
+func runHTTPStep(ctx context.Context, op models.Operation, variables map[string]string, lookupSecret func(string) (string, error)) error {
+	if op.HTTP == nil || strings.TrimSpace(op.HTTP.URL) == "" {
+		return fmt.Errorf("http step has no url")
+	}
+
+	timeout := defaultHTTPStepTimeout
+	if op.HTTP.TimeoutSeconds > 0 {
+		timeout = time.Duration(op.HTTP.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := buildHTTPStepRequest(ctx, op.HTTP, variables, lookupSecret)
+	if err != nil {
+		return err
+	}
+
+	resp, err := fshttp.NewClient(ctx).Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", req.Method, req.URL.Redacted(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxHTTPStepErrorBody))
+		return fmt.Errorf("%s %s returned %s: %s", req.Method, req.URL.Redacted(), resp.Status, strings.TrimSpace(string(snippet)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	log.Printf("[FlowService] HTTP step %s %s returned %s", req.Method, req.URL.Redacted(), resp.Status)
+	return nil
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func fakeSecrets(secrets map[string]string) func(string) (string, error) {
+	return func(name string) (string, error) {
+		value, ok := secrets[name]
+		if !ok {
+			return "", fmt.Errorf("secret '%s' not found", name)
+		}
+		return value, nil
+	}
+}
+
+func TestExpandRunVariables(t *testing.T) {
+	got, err := expandRunVariables(`{"flow":"${FLOW_NAME}","step":${STEP_NUMBER}}`, map[string]string{
+		"FLOW_NAME":   "Nightly",
+		"STEP_NUMBER": "2",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != `{"flow":"Nightly","step":2}` {
+		t.Errorf("expanded = %q", got)
+	}
+
+	_, err = expandRunVariables("${NOPE} ${FLOW_NAME} ${ALSO_NOPE}", map[string]string{"FLOW_NAME": "x"})
+	if err == nil || !strings.Contains(err.Error(), "ALSO_NOPE, NOPE") {
+		t.Errorf("err = %v, want both unknown variables named", err)
+	}
+}
+
+func TestBuildHTTPStepRequest(t *testing.T) {
+	step := &models.HTTPStep{
+		URL: "https://tickets.example.com/api/${FLOW_ID}",
+		Headers: []models.HTTPHeader{
+			{Name: "Authorization", Value: "ignored", Secret: "ticket-token"},
+			{Name: "X-Flow", Value: "${FLOW_NAME}"},
+		},
+		Body: "done: ${FLOW_NAME}",
+	}
+	vars := map[string]string{"FLOW_ID": "f1", "FLOW_NAME": "Nightly"}
+
+	req, err := buildHTTPStepRequest(context.Background(), step, vars, fakeSecrets(map[string]string{"ticket-token": "Bearer abc"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != http.MethodPost {
+		t.Errorf("method = %s, want POST by default", req.Method)
+	}
+	if req.URL.String() != "https://tickets.example.com/api/f1" {
+		t.Errorf("url = %s", req.URL)
+	}
+	if req.Header.Get("Authorization") != "Bearer abc" {
+		t.Errorf("Authorization = %q, want the secret value", req.Header.Get("Authorization"))
+	}
+	if req.Header.Get("X-Flow") != "Nightly" {
+		t.Errorf("X-Flow = %q", req.Header.Get("X-Flow"))
+	}
+	body, _ := io.ReadAll(req.Body)
+	if string(body) != "done: Nightly" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestBuildHTTPStepRequest_Errors(t *testing.T) {
+	cases := []struct {
+		name string
+		step models.HTTPStep
+	}{
+		{"relative url", models.HTTPStep{URL: "/hook"}},
+		{"bad scheme", models.HTTPStep{URL: "ftp://example.com/hook"}},
+		{"unknown variable", models.HTTPStep{URL: "https://example.com/${MISSING}"}},
+		{"missing secret", models.HTTPStep{URL: "https://example.com", Headers: []models.HTTPHeader{{Name: "Authorization", Secret: "nope"}}}},
+		{"empty header name", models.HTTPStep{URL: "https://example.com", Headers: []models.HTTPHeader{{Value: "x"}}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := buildHTTPStepRequest(context.Background(), &tc.step, nil, fakeSecrets(nil)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestRunHTTPStep(t *testing.T) {
+	var gotMethod, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		if r.URL.Path == "/fail" {
+			http.Error(w, "ticket queue closed", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	vars := map[string]string{"FLOW_NAME": "Nightly"}
+	op := models.Operation{Type: models.OperationTypeHTTP, HTTP: &models.HTTPStep{
+		URL:    server.URL + "/ok",
+		Method: "put",
+		Body:   "${FLOW_NAME} finished",
+	}}
+	if err := runHTTPStep(context.Background(), op, vars, fakeSecrets(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if gotMethod != http.MethodPut || gotBody != "Nightly finished" {
+		t.Errorf("server got %s %q", gotMethod, gotBody)
+	}
+
+	op.HTTP.URL = server.URL + "/fail"
+	err := runHTTPStep(context.Background(), op, vars, fakeSecrets(nil))
+	if err == nil || !strings.Contains(err.Error(), "503") || !strings.Contains(err.Error(), "ticket queue closed") {
+		t.Errorf("err = %v, want status and response body", err)
+	}
+}
+
+func TestSecretVault(t *testing.T) {
+	ctx := context.Background()
+	svc := NewSecretService(nil)
+
+	if err := svc.SetSecret(ctx, " webhook-token ", "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	defer svc.DeleteSecret(ctx, "webhook-token")
+
+	value, err := lookupSecret("webhook-token")
+	if err != nil || value != "s3cret" {
+		t.Fatalf("lookupSecret = %q, %v", value, err)
+	}
+	secrets, err := svc.GetSecrets(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secrets) != 1 || secrets[0].Name != "webhook-token" {
+		t.Errorf("secrets = %+v", secrets)
+	}
+	if err := svc.SetSecret(ctx, "empty", ""); err == nil {
+		t.Error("expected error for empty value")
+	}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"desktop/backend/models"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// flowRun holds the state of one backend run of a flow: the run variables
+// available to steps as ${VARIABLE} placeholders and the archive produced by
+// an archive step for the next sync step
+type flowRun struct {
+	variables   map[string]string
+	archivePath string   // pending archive, consumed by the next sync step
+	tempDirs    []string // removed by cleanup
+}
+
+// newFlowRun creates the run variables for a run of flow starting now
+func newFlowRun(flow *models.Flow) *flowRun {
+	return &flowRun{
+		variables: map[string]string{
+			"FLOW_ID":        flow.Id,
+			"FLOW_NAME":      flow.Name,
+			"RUN_STARTED_AT": time.Now().UTC().Format(time.RFC3339),
+			"STEP_COUNT":     strconv.Itoa(len(flow.Operations)),
+		},
+	}
+}
+
+// startStep updates the run variables for the step at index i
+func (r *flowRun) startStep(i int) {
+	r.variables["STEP_NUMBER"] = strconv.Itoa(i + 1)
+}
+
+// setArchive records an archive for the next sync step
+func (r *flowRun) setArchive(path string) {
+	r.archivePath = path
+	r.variables["ARCHIVE_PATH"] = path
+	r.variables["ARCHIVE_NAME"] = filepath.Base(path)
+}
+
+// syncProfile builds the profile and action of a sync step. If an archive
+// step ran before it, the step uploads only that archive: its source is
+// replaced by the archive's directory, filtered to the archive, so files
+// already at the target are kept.
+func (r *flowRun) syncProfile(flow *models.Flow, op models.Operation) (models.Profile, string, error) {
+	profile := op.SyncConfig
+	action := op.Action
+	if action == "" {
+		action = string(ActionPush)
+	}
+
+	if r.archivePath != "" {
+		if op.TargetRemote == "" {
+			return profile, "", fmt.Errorf("operation '%s' has no target for the archive", op.Id)
+		}
+		profile.From = filepath.Dir(r.archivePath)
+		profile.To = joinRemotePath(op.TargetRemote, op.TargetPath)
+		profile.IncludedPaths = []string{"/" + filepath.Base(r.archivePath)}
+		profile.ExcludedPaths = nil
+		profile.UseRegex = false
+		if profile.Name == "" {
+			profile.Name = fmt.Sprintf("%s: %s->%s", flow.Name, filepath.Base(r.archivePath), op.TargetRemote)
+		}
+		r.archivePath = ""
+		return profile, string(ActionPush), nil
+	}
+
+	if op.SourceRemote == "" || op.TargetRemote == "" {
+		return profile, "", fmt.Errorf("operation '%s' has invalid remotes", op.Id)
+	}
+	profile.From = joinRemotePath(op.SourceRemote, op.SourcePath)
+	profile.To = joinRemotePath(op.TargetRemote, op.TargetPath)
+	if profile.Name == "" {
+		profile.Name = fmt.Sprintf("%s: %s->%s", flow.Name, op.SourceRemote, op.TargetRemote)
+	}
+	return profile, action, nil
+}
+
+// cleanup removes the run's temp files
+func (r *flowRun) cleanup() {
+	for _, dir := range r.tempDirs {
+		if err := os.RemoveAll(dir); err != nil {
+			log.Printf("[FlowService] Failed to remove temp dir %s: %v", dir, err)
+		}
+	}
+	r.tempDirs = nil
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"desktop/backend/models"
+	"path/filepath"
+	"testing"
+)
+
+func TestFlowRunSyncProfile(t *testing.T) {
+	flow := &models.Flow{Id: "f1", Name: "Nightly"}
+	op := models.Operation{
+		Id: "op1", SourceRemote: "local", SourcePath: "/data", TargetRemote: "gdrive", TargetPath: "backup",
+		SyncConfig: models.Profile{ExcludedPaths: []string{"*.tmp"}},
+	}
+	run := newFlowRun(flow)
+
+	profile, action, err := run.syncProfile(flow, op)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.From != "/data" || profile.To != "gdrive:backup" || action != string(ActionPush) {
+		t.Errorf("profile = %s -> %s (%s)", profile.From, profile.To, action)
+	}
+
+	archive := filepath.Join(t.TempDir(), "data-20240102-150405.zip")
+	run.setArchive(archive)
+	op.Action = string(ActionPull)
+	profile, action, err = run.syncProfile(flow, op)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.From != filepath.Dir(archive) || profile.To != "gdrive:backup" || action != string(ActionPush) {
+		t.Errorf("archive profile = %s -> %s (%s)", profile.From, profile.To, action)
+	}
+	if len(profile.IncludedPaths) != 1 || profile.IncludedPaths[0] != "/data-20240102-150405.zip" || profile.ExcludedPaths != nil {
+		t.Errorf("filters = %v / %v, want only the archive", profile.IncludedPaths, profile.ExcludedPaths)
+	}
+	if run.variables["ARCHIVE_NAME"] != "data-20240102-150405.zip" {
+		t.Errorf("ARCHIVE_NAME = %q", run.variables["ARCHIVE_NAME"])
+	}
+
+	// The archive feeds only the next sync step
+	profile, _, _ = run.syncProfile(flow, op)
+	if profile.From != "/data" {
+		t.Errorf("second step source = %s, want its own source", profile.From)
+	}
+}
@@ -17,6 +17,9 @@ type FlowService struct {
 	app         *application.App
 	mutex       sync.RWMutex
 	initialized bool
+
+	// Dependencies
+	syncService *SyncService
 }
 
 // Singleton instance for cross-service access
@@ -47,6 +50,11 @@ func (s *FlowService) SetApp(app *application.App) {
 	s.app = app
 }
 
+// SetSyncService sets the sync service used to run flows in the backend
+func (s *FlowService) SetSyncService(syncService *SyncService) {
+	s.syncService = syncService
+}
+
 // ServiceName returns the name of the service
 func (s *FlowService) ServiceName() string {
 	return "FlowService"
@@ -103,7 +111,11 @@ func (s *FlowService) GetFlows(ctx context.Context) ([]models.Flow, error) {
 	}
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
+	return s.loadFlows()
+}
 
+// loadFlows reads all flows with their operations; the caller holds the lock
+func (s *FlowService) loadFlows() ([]models.Flow, error) {
 	db, err := GetSharedDB()
 	if err != nil {
 		return nil, err
@@ -111,7 +123,7 @@ func (s *FlowService) GetFlows(ctx context.Context) ([]models.Flow, error) {
 
 	// Query flows
 	rows, err := db.Query(`
-		SELECT id, name, is_collapsed, schedule_enabled, cron_expr, sort_order, created_at, updated_at
+		SELECT id, name, is_collapsed, schedule_enabled, cron_expr, sort_order, webhooks, created_at, updated_at
 		FROM flows ORDER BY sort_order
 	`)
 	if err != nil {
@@ -123,9 +135,15 @@ func (s *FlowService) GetFlows(ctx context.Context) ([]models.Flow, error) {
 	for rows.Next() {
 		var f models.Flow
 		var isCollapsed, scheduleEnabled int
-		if err := rows.Scan(&f.Id, &f.Name, &isCollapsed, &scheduleEnabled, &f.CronExpr, &f.SortOrder, &f.CreatedAt, &f.UpdatedAt); err != nil {
+		var webhooks string
+		if err := rows.Scan(&f.Id, &f.Name, &isCollapsed, &scheduleEnabled, &f.CronExpr, &f.SortOrder, &webhooks, &f.CreatedAt, &f.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan flow: %w", err)
 		}
+		if webhooks != "" {
+			if err := json.Unmarshal([]byte(webhooks), &f.Webhooks); err != nil {
+				log.Printf("warning: failed to unmarshal webhooks for flow %s: %v", f.Id, err)
+			}
+		}
 		f.IsCollapsed = isCollapsed != 0
 		f.ScheduleEnabled = scheduleEnabled != 0
 		f.Operations = []models.Operation{}
@@ -150,7 +168,8 @@ func (s *FlowService) GetFlows(ctx context.Context) ([]models.Flow, error) {
 	return flows, nil
 }
 
-// SaveFlows replaces all flows and operations atomically
+// SaveFlows replaces all flows and operations atomically. Flows left out
+// of flows are moved to the trash.
 func (s *FlowService) SaveFlows(ctx context.Context, flows []models.Flow) error {
 	if err := s.ensureInitialized(); err != nil {
 		return err
@@ -163,12 +182,31 @@ func (s *FlowService) SaveFlows(ctx context.Context, flows []models.Flow) error
 		return err
 	}
 
+	existing, err := s.loadFlows()
+	if err != nil {
+		return err
+	}
+
 	tx, err := db.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
+	// Trash removed flows
+	kept := make(map[string]bool, len(flows))
+	for _, f := range flows {
+		kept[f.Id] = true
+	}
+	for _, f := range existing {
+		if kept[f.Id] {
+			continue
+		}
+		if err := trashItem(tx, models.TrashFlow, f.Id, f.Name, f); err != nil {
+			return err
+		}
+	}
+
 	// Clear existing data
 	if _, err := tx.Exec("DELETE FROM operations"); err != nil {
 		return fmt.Errorf("failed to clear operations: %w", err)
@@ -181,8 +219,8 @@ func (s *FlowService) SaveFlows(ctx context.Context, flows []models.Flow) error
 
 	// Insert flows
 	flowStmt, err := tx.Prepare(`
-		INSERT INTO flows (id, name, is_collapsed, schedule_enabled, cron_expr, sort_order, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO flows (id, name, is_collapsed, schedule_enabled, cron_expr, sort_order, webhooks, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare flow insert: %w", err)
@@ -191,8 +229,8 @@ func (s *FlowService) SaveFlows(ctx context.Context, flows []models.Flow) error
 
 	// Insert operations
 	opStmt, err := tx.Prepare(`
-		INSERT INTO operations (id, flow_id, source_remote, source_path, target_remote, target_path, action, sync_config, is_expanded, sort_order)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO operations (id, flow_id, type, source_remote, source_path, target_remote, target_path, action, sync_config, http_config, wait_config, archive_config, is_expanded, sort_order)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`)
 	if err != nil {
 		return fmt.Errorf("failed to prepare operation insert: %w", err)
@@ -213,7 +251,19 @@ func (s *FlowService) SaveFlows(ctx context.Context, flows []models.Flow) error
 			createdAt = now
 		}
 
-		if _, err := flowStmt.Exec(f.Id, f.Name, isCollapsed, scheduleEnabled, f.CronExpr, i, createdAt, now); err != nil {
+		if err := validateRunWebhooks(f.Webhooks); err != nil {
+			return fmt.Errorf("flow %s: %w", f.Name, err)
+		}
+		webhooksJSON := ""
+		if len(f.Webhooks) > 0 {
+			data, err := json.Marshal(f.Webhooks)
+			if err != nil {
+				return fmt.Errorf("failed to marshal webhooks for flow %s: %w", f.Id, err)
+			}
+			webhooksJSON = string(data)
+		}
+
+		if _, err := flowStmt.Exec(f.Id, f.Name, isCollapsed, scheduleEnabled, f.CronExpr, i, webhooksJSON, createdAt, now); err != nil {
 			return fmt.Errorf("failed to insert flow %s: %w", f.Id, err)
 		}
 
@@ -224,12 +274,36 @@ func (s *FlowService) SaveFlows(ctx context.Context, flows []models.Flow) error
 			if err != nil {
 				return fmt.Errorf("failed to marshal sync_config for operation %s: %w", op.Id, err)
 			}
+			httpConfigJSON := ""
+			if op.HTTP != nil {
+				data, err := json.Marshal(op.HTTP)
+				if err != nil {
+					return fmt.Errorf("failed to marshal http config for operation %s: %w", op.Id, err)
+				}
+				httpConfigJSON = string(data)
+			}
+			waitConfigJSON := ""
+			if op.Wait != nil {
+				data, err := json.Marshal(op.Wait)
+				if err != nil {
+					return fmt.Errorf("failed to marshal wait config for operation %s: %w", op.Id, err)
+				}
+				waitConfigJSON = string(data)
+			}
+			archiveConfigJSON := ""
+			if op.Archive != nil {
+				data, err := json.Marshal(op.Archive)
+				if err != nil {
+					return fmt.Errorf("failed to marshal archive config for operation %s: %w", op.Id, err)
+				}
+				archiveConfigJSON = string(data)
+			}
 			isExpanded := 0
 			if op.IsExpanded {
 				isExpanded = 1
 			}
 
-			if _, err := opStmt.Exec(op.Id, f.Id, op.SourceRemote, op.SourcePath, op.TargetRemote, op.TargetPath, op.Action, string(syncConfigJSON), isExpanded, j); err != nil {
+			if _, err := opStmt.Exec(op.Id, f.Id, op.Type, op.SourceRemote, op.SourcePath, op.TargetRemote, op.TargetPath, op.Action, string(syncConfigJSON), httpConfigJSON, waitConfigJSON, archiveConfigJSON, isExpanded, j); err != nil {
 				return fmt.Errorf("failed to insert operation %s: %w", op.Id, err)
 			}
 		}
@@ -247,6 +321,101 @@ func (s *FlowService) SaveFlows(ctx context.Context, flows []models.Flow) error
 	return nil
 }
 
+// restoreFlow adds a flow from the trash back after the existing flows
+func (s *FlowService) restoreFlow(ctx context.Context, flow models.Flow) error {
+	flows, err := s.GetFlows(ctx)
+	if err != nil {
+		return err
+	}
+	for _, f := range flows {
+		if f.Id == flow.Id {
+			return fmt.Errorf("flow '%s' already exists", flow.Id)
+		}
+	}
+	return s.SaveFlows(ctx, append(flows, flow))
+}
+
+// RunFlow executes a flow's operations sequentially in the backend, stopping
+// at the first failure. Used when no frontend is available to drive the flow
+// (schedules, tray-only mode). Blocks until the flow finishes. The flow's
+// webhooks are told when the run starts and how it finished.
+func (s *FlowService) RunFlow(ctx context.Context, flowId string) error {
+
+	flows, err := s.GetFlows(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load flows: %w", err)
+	}
+
+	var flow *models.Flow
+	for i := range flows {
+		if flows[i].Id == flowId {
+			flow = &flows[i]
+			break
+		}
+	}
+	if flow == nil {
+		return fmt.Errorf("flow '%s' not found", flowId)
+	}
+
+	run := newFlowRun(flow)
+	defer run.cleanup()
+	payload := newFlowRunPayload(flow)
+	sendRunWebhooks(flow.Webhooks, payload.event(models.RunWebhookStarted))
+	for i, op := range flow.Operations {
+		run.startStep(i)
+		task, err := s.runStep(ctx, flow, op, run)
+		payload.endStep(i, err, task)
+		if err != nil {
+			payload.finish(ctx, err)
+			sendRunWebhooks(flow.Webhooks, payload.event(models.RunWebhookFinished))
+			return err
+		}
+	}
+	payload.finish(ctx, nil)
+	sendRunWebhooks(flow.Webhooks, payload.event(models.RunWebhookFinished))
+	return nil
+}
+
+// runStep runs one operation of a backend flow run, returning the sync task
+// it ran, if any
+func (s *FlowService) runStep(ctx context.Context, flow *models.Flow, op models.Operation, run *flowRun) (*SyncTask, error) {
+	switch op.Type {
+	case models.OperationTypeHTTP:
+		if err := runHTTPStep(ctx, op, run.variables, lookupSecret); err != nil {
+			return nil, fmt.Errorf("operation '%s' failed: %w", op.Id, err)
+		}
+		return nil, nil
+	case models.OperationTypeWait:
+		if err := runWaitStep(ctx, op); err != nil {
+			return nil, fmt.Errorf("operation '%s' failed: %w", op.Id, err)
+		}
+		return nil, nil
+	case models.OperationTypeArchive:
+		if err := runArchiveStep(ctx, op, run, lookupSecret); err != nil {
+			return nil, fmt.Errorf("operation '%s' failed: %w", op.Id, err)
+		}
+		return nil, nil
+	}
+
+	if s.syncService == nil {
+		return nil, fmt.Errorf("sync service not available")
+	}
+	profile, action, err := run.syncProfile(flow, op)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.syncService.StartSync(ctx, action, profile, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start operation '%s': %w", op.Id, err)
+	}
+	task := s.syncService.getTask(result.TaskId)
+	if err := s.syncService.WaitForTask(ctx, result.TaskId); err != nil {
+		return task, fmt.Errorf("operation '%s' failed: %w", op.Id, err)
+	}
+	return task, nil
+}
+
 // OnRemoteDeleted cleans up operations referencing a deleted remote
 func (s *FlowService) OnRemoteDeleted(ctx context.Context, remoteName string) error {
 	if err := s.ensureInitialized(); err != nil {
@@ -285,8 +454,8 @@ func (s *FlowService) getOperationsForFlow(flowId string) ([]models.Operation, e
 	}
 
 	rows, err := db.Query(`
-		SELECT id, flow_id, source_remote, source_path, target_remote, target_path, action,
-		       sync_config, is_expanded, sort_order
+		SELECT id, flow_id, type, source_remote, source_path, target_remote, target_path, action,
+		       sync_config, http_config, wait_config, archive_config, is_expanded, sort_order
 		FROM operations WHERE flow_id = ? ORDER BY sort_order
 	`, flowId)
 	if err != nil {
@@ -297,10 +466,10 @@ func (s *FlowService) getOperationsForFlow(flowId string) ([]models.Operation, e
 	var ops []models.Operation
 	for rows.Next() {
 		var op models.Operation
-		var syncConfigJSON string
+		var syncConfigJSON, httpConfigJSON, waitConfigJSON, archiveConfigJSON string
 		var isExpanded int
-		if err := rows.Scan(&op.Id, &op.FlowId, &op.SourceRemote, &op.SourcePath, &op.TargetRemote, &op.TargetPath, &op.Action,
-			&syncConfigJSON, &isExpanded, &op.SortOrder); err != nil {
+		if err := rows.Scan(&op.Id, &op.FlowId, &op.Type, &op.SourceRemote, &op.SourcePath, &op.TargetRemote, &op.TargetPath, &op.Action,
+			&syncConfigJSON, &httpConfigJSON, &waitConfigJSON, &archiveConfigJSON, &isExpanded, &op.SortOrder); err != nil {
 			return nil, fmt.Errorf("failed to scan operation: %w", err)
 		}
 		if syncConfigJSON != "" && syncConfigJSON != "{}" {
@@ -308,6 +477,24 @@ func (s *FlowService) getOperationsForFlow(flowId string) ([]models.Operation, e
 				log.Printf("warning: failed to unmarshal sync_config for operation %s: %v", op.Id, err)
 			}
 		}
+		if httpConfigJSON != "" {
+			op.HTTP = &models.HTTPStep{}
+			if err := json.Unmarshal([]byte(httpConfigJSON), op.HTTP); err != nil {
+				log.Printf("warning: failed to unmarshal http_config for operation %s: %v", op.Id, err)
+			}
+		}
+		if waitConfigJSON != "" {
+			op.Wait = &models.WaitStep{}
+			if err := json.Unmarshal([]byte(waitConfigJSON), op.Wait); err != nil {
+				log.Printf("warning: failed to unmarshal wait_config for operation %s: %v", op.Id, err)
+			}
+		}
+		if archiveConfigJSON != "" {
+			op.Archive = &models.ArchiveStep{}
+			if err := json.Unmarshal([]byte(archiveConfigJSON), op.Archive); err != nil {
+				log.Printf("warning: failed to unmarshal archive_config for operation %s: %v", op.Id, err)
+			}
+		}
 		op.IsExpanded = isExpanded != 0
 		ops = append(ops, op)
 	}
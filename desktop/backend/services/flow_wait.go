@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Wait step defaults
+const (
+	defaultWaitTimeout = time.Hour
+	defaultWaitPoll    = 30 * time.Second
+	minWaitPoll        = time.Second
+)
+
+// waitCheck reports whether a wait condition holds. When it doesn't, the
+// returned reason (or error) says why, for the timeout message.
+type waitCheck func(ctx context.Context) (ok bool, reason string, err error)
+
+// runWaitStep performs a wait step: a fixed delay, or polling its condition
+// until it holds or the step times out
+func runWaitStep(ctx context.Context, op models.Operation) error {
+	step := op.Wait
+	if step == nil {
+		return fmt.Errorf("wait step has no settings")
+	}
+
+	if step.Condition == models.WaitConditionDelay {
+		if step.DelaySeconds <= 0 {
+			return fmt.Errorf("wait step needs a delay")
+		}
+		log.Printf("[FlowService] Waiting %ds", step.DelaySeconds)
+		select {
+		case <-time.After(time.Duration(step.DelaySeconds) * time.Second):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	check, err := waitConditionCheck(step)
+	if err != nil {
+		return err
+	}
+	timeout := defaultWaitTimeout
+	if step.TimeoutSeconds > 0 {
+		timeout = time.Duration(step.TimeoutSeconds) * time.Second
+	}
+	poll := defaultWaitPoll
+	if step.PollSeconds > 0 {
+		poll = time.Duration(step.PollSeconds) * time.Second
+	}
+	if poll < minWaitPoll {
+		poll = minWaitPoll
+	}
+
+	log.Printf("[FlowService] Waiting for %s on '%s' (timeout %s)", step.Condition, step.Remote, timeout)
+	return waitUntil(ctx, timeout, poll, check)
+}
+
+// waitConditionCheck returns the check for a wait step's condition
+func waitConditionCheck(step *models.WaitStep) (waitCheck, error) {
+	remote := strings.TrimSuffix(strings.TrimSpace(step.Remote), ":")
+	if remote == "" {
+		return nil, fmt.Errorf("wait condition '%s' needs a remote", step.Condition)
+	}
+
+	switch step.Condition {
+	case models.WaitConditionRemoteReachable:
+		return func(ctx context.Context) (bool, string, error) {
+			if err := rclone.Reachable(ctx, remote); err != nil {
+				return false, "", err
+			}
+			return true, "", nil
+		}, nil
+
+	case models.WaitConditionFileExists:
+		if strings.TrimSpace(step.Path) == "" {
+			return nil, fmt.Errorf("wait condition '%s' needs a path", step.Condition)
+		}
+		remotePath := joinRemotePath(remote, step.Path)
+		return func(ctx context.Context) (bool, string, error) {
+			exists, err := rclone.PathExists(ctx, remotePath)
+			if err != nil {
+				return false, "", err
+			}
+			return exists, fmt.Sprintf("%s does not exist", remotePath), nil
+		}, nil
+
+	case models.WaitConditionFreeSpace:
+		if step.MinFreeBytes <= 0 {
+			return nil, fmt.Errorf("wait condition '%s' needs a minimum free space", step.Condition)
+		}
+		return func(ctx context.Context) (bool, string, error) {
+			quota, err := rclone.About(ctx, remote)
+			if err != nil {
+				return false, "", err
+			}
+			return quota.Free >= step.MinFreeBytes,
+				fmt.Sprintf("%d bytes free, need %d", quota.Free, step.MinFreeBytes), nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown wait condition '%s'", step.Condition)
+}
+
+// waitUntil calls check every poll interval until it succeeds. Check errors
+// (e.g. a remote that is still offline) count as "not yet". After timeout it
+// fails with the last reason the condition didn't hold.
+func waitUntil(ctx context.Context, timeout, poll time.Duration, check waitCheck) error {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		ok, reason, err := check(ctx)
+		if ok && err == nil {
+			return nil
+		}
+		if err != nil {
+			reason = err.Error()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline.C:
+			return fmt.Errorf("condition not met after %s: %s", timeout, reason)
+		case <-time.After(poll):
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWaitUntil_ConditionMet(t *testing.T) {
+	calls := 0
+	err := waitUntil(context.Background(), time.Second, time.Millisecond, func(ctx context.Context) (bool, string, error) {
+		calls++
+		if calls == 1 {
+			return false, "", errors.New("nas offline")
+		}
+		return calls >= 3, "not yet", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestWaitUntil_Timeout(t *testing.T) {
+	err := waitUntil(context.Background(), 20*time.Millisecond, time.Millisecond, func(ctx context.Context) (bool, string, error) {
+		return false, "", errors.New("nas offline")
+	})
+	if err == nil || !strings.Contains(err.Error(), "nas offline") {
+		t.Errorf("err = %v, want timeout naming the last reason", err)
+	}
+}
+
+func TestWaitUntil_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := waitUntil(ctx, time.Minute, time.Minute, func(ctx context.Context) (bool, string, error) {
+		return false, "not yet", nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestRunWaitStep_Delay(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	op := models.Operation{Type: models.OperationTypeWait, Wait: &models.WaitStep{DelaySeconds: 60}}
+	if err := runWaitStep(ctx, op); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want the delay to stop on cancel", err)
+	}
+}
+
+func TestWaitConditionCheck_Validation(t *testing.T) {
+	cases := []struct {
+		name string
+		step models.WaitStep
+	}{
+		{"no remote", models.WaitStep{Condition: models.WaitConditionRemoteReachable}},
+		{"file without path", models.WaitStep{Condition: models.WaitConditionFileExists, Remote: "nas"}},
+		{"free space without minimum", models.WaitStep{Condition: models.WaitConditionFreeSpace, Remote: "nas"}},
+		{"unknown condition", models.WaitStep{Condition: "moon_phase", Remote: "nas"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := waitConditionCheck(&tc.step); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	if _, err := waitConditionCheck(&models.WaitStep{Condition: models.WaitConditionFileExists, Remote: "nas:", Path: "ready.flag"}); err != nil {
+		t.Errorf("valid file condition: %v", err)
+	}
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/events"
+	"desktop/backend/models"
+	"desktop/backend/validation"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxHistoryLabels      = 20
+	maxHistoryLabelLength = 64
+	maxHistoryNoteLength  = 4000
+)
+
+type runLabelsKey struct{}
+
+// withRunLabels adds labels to the history entries of the syncs started
+// under ctx, e.g. the run a retry retries
+func withRunLabels(ctx context.Context, labels ...string) context.Context {
+	if len(labels) == 0 {
+		return ctx
+	}
+	all := append(append([]string{}, runLabelsFromContext(ctx)...), labels...)
+	return context.WithValue(ctx, runLabelsKey{}, all)
+}
+
+// runLabelsFromContext returns the labels added with withRunLabels
+func runLabelsFromContext(ctx context.Context) []string {
+	if ctx == nil {
+		return nil
+	}
+	labels, _ := ctx.Value(runLabelsKey{}).([]string)
+	return labels
+}
+
+// taskLabels are the automatic labels of a task's run
+type taskLabels struct {
+	taskId int
+	labels []string
+}
+
+// rememberRunLabels keeps why and how the task ran so the profile's next
+// history entry can be labelled. Call after rememberDeltaRun.
+func (s *SyncService) rememberRunLabels(task *SyncTask) {
+	labels := runLabelsFromContext(task.parentCtx)
+	if scheduleId := scheduleRunFromContext(task.parentCtx); scheduleId != "" {
+		labels = append(labels, models.LabelSchedulePrefix+scheduleId)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if run := s.deltaRuns[task.Profile.Name]; run != nil {
+		switch run.Mode {
+		case "delta":
+			labels = append(labels, models.LabelDeltaScoped)
+		case "skipped":
+			labels = append(labels, models.LabelDeltaSkipped)
+		}
+	}
+	if s.runLabels == nil {
+		s.runLabels = make(map[string]*taskLabels)
+	}
+	s.runLabels[task.Profile.Name] = &taskLabels{taskId: task.Id, labels: labels}
+}
+
+// takeRunLabels returns and forgets the task and labels of a profile's last
+// run; the task is 0 if there is none
+func (s *SyncService) takeRunLabels(profileName string) (int, []string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	run := s.runLabels[profileName]
+	delete(s.runLabels, profileName)
+	if run == nil {
+		return 0, nil
+	}
+	return run.taskId, run.labels
+}
+
+// noteRunHistoryEntry records the history entry of a task, so a retry of
+// its failed files can be labelled with it
+func (s *SyncService) noteRunHistoryEntry(taskId int, entryId string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if run := s.failedRuns[taskId]; run != nil {
+		run.HistoryId = entryId
+	}
+}
+
+// mergeHistoryLabels returns the labels of both lists without duplicates,
+// in order
+func mergeHistoryLabels(a, b []string) []string {
+	var merged []string
+	seen := make(map[string]bool)
+	for _, label := range append(append([]string{}, a...), b...) {
+		if label != "" && !seen[label] {
+			seen[label] = true
+			merged = append(merged, label)
+		}
+	}
+	return merged
+}
+
+// normalizeHistoryLabels trims labels set by the user and drops duplicates
+func normalizeHistoryLabels(labels []string) ([]string, error) {
+	normalized := make([]string, 0, len(labels))
+	for _, label := range labels {
+		label = strings.TrimSpace(label)
+		if label == "" {
+			return nil, &validation.ValidationError{Field: "labels", Message: "cannot contain an empty label"}
+		}
+		if utf8.RuneCountInString(label) > maxHistoryLabelLength {
+			return nil, &validation.ValidationError{Field: "labels", Message: fmt.Sprintf("label %q is longer than %d characters", label, maxHistoryLabelLength)}
+		}
+		normalized = append(normalized, label)
+	}
+	normalized = mergeHistoryLabels(normalized, nil)
+	if len(normalized) > maxHistoryLabels {
+		return nil, &validation.ValidationError{Field: "labels", Message: fmt.Sprintf("cannot have more than %d labels", maxHistoryLabels)}
+	}
+	return normalized, nil
+}
+
+// SetHistoryLabels replaces the labels of a history entry, the automatic
+// ones included, and returns the updated entry
+func (h *HistoryService) SetHistoryLabels(ctx context.Context, id string, labels []string) (*models.HistoryEntry, error) {
+	labels, err := normalizeHistoryLabels(labels)
+	if err != nil {
+		return nil, err
+	}
+	return h.updateHistoryEntry(id, func(e *models.HistoryEntry) {
+		e.Labels = labels
+	})
+}
+
+// SetHistoryNote sets the note of a history entry; an empty note removes it.
+// Returns the updated entry.
+func (h *HistoryService) SetHistoryNote(ctx context.Context, id string, note string) (*models.HistoryEntry, error) {
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > maxHistoryNoteLength {
+		return nil, &validation.ValidationError{Field: "note", Message: fmt.Sprintf("cannot be longer than %d characters", maxHistoryNoteLength)}
+	}
+	return h.updateHistoryEntry(id, func(e *models.HistoryEntry) {
+		e.Note = note
+	})
+}
+
+// updateHistoryEntry changes a stored history entry and emits history:updated
+func (h *HistoryService) updateHistoryEntry(id string, update func(*models.HistoryEntry)) (*models.HistoryEntry, error) {
+	if err := h.ensureInitialized(); err != nil {
+		return nil, err
+	}
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	rows, err := db.Query(`SELECT id, profile_name, action, status, start_time, end_time,
+		duration, files_transferred, bytes_transferred, errors, error_message, error_code,
+		delta_mode, delta_changes, delta_reason, delta_time_saved_ms, transfer_report, destinations, source, api_calls, failover, locked_files, compression, remote_hooks, raw_flags, labels, note
+		FROM history WHERE id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history entry: %w", err)
+	}
+	entries, err := h.scanHistoryRows(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("history entry %s not found", id)
+	}
+
+	entry := entries[0]
+	update(&entry)
+	if err := h.saveHistoryEntryToDB(entry); err != nil {
+		return nil, fmt.Errorf("failed to save history: %w", err)
+	}
+	h.emitHistoryEvent(events.HistoryUpdated, entry)
+	return &entry, nil
+}
+
+// historyLabelsJSON is the labels column of the history table as a JSON
+// array; entries without labels store an empty string
+const historyLabelsJSON = "CASE WHEN labels = '' THEN '[]' ELSE labels END"
+
+// GetHistoryByLabel returns the history entries with a label, newest first.
+// A label ending in ":", like "schedule:", matches every label it starts.
+func (h *HistoryService) GetHistoryByLabel(ctx context.Context, label string, limit, offset int) ([]models.HistoryEntry, error) {
+	if err := h.ensureInitialized(); err != nil {
+		return nil, err
+	}
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return nil, &validation.ValidationError{Field: "label", Message: "cannot be empty"}
+	}
+	match := "value = ?"
+	if strings.HasSuffix(label, ":") {
+		match = "substr(value, 1, length(?1)) = ?1"
+	}
+	rows, err := db.Query(`SELECT id, profile_name, action, status, start_time, end_time,
+		duration, files_transferred, bytes_transferred, errors, error_message, error_code,
+		delta_mode, delta_changes, delta_reason, delta_time_saved_ms, transfer_report, destinations, source, api_calls, failover, locked_files, compression, remote_hooks, raw_flags, labels, note
+		FROM history WHERE EXISTS (SELECT 1 FROM json_each(`+historyLabelsJSON+`) WHERE `+match+`)
+		ORDER BY start_time DESC LIMIT ? OFFSET ?`, label, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history by label: %w", err)
+	}
+	defer rows.Close()
+
+	return h.scanHistoryRows(rows)
+}
+
+// GetHistoryLabels returns the labels of the history entries with how many
+// entries have each, most used first
+func (h *HistoryService) GetHistoryLabels(ctx context.Context) ([]models.HistoryLabelCount, error) {
+	if err := h.ensureInitialized(); err != nil {
+		return nil, err
+	}
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT l.value, COUNT(*) FROM history, json_each(` + historyLabelsJSON + `) AS l
+		GROUP BY l.value ORDER BY COUNT(*) DESC, l.value`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history labels: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []models.HistoryLabelCount{}
+	for rows.Next() {
+		var c models.HistoryLabelCount
+		if err := rows.Scan(&c.Label, &c.Entries); err != nil {
+			return nil, fmt.Errorf("failed to scan history label: %w", err)
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistoryService_Labels(t *testing.T) {
+	h := newTestHistoryService(t)
+	s := &SyncService{
+		deltaRuns:  map[string]*models.DeltaRun{"photos": {Mode: "delta", ChangesScoped: 3}},
+		failedRuns: map[int]*failedRun{7: {Files: []string{"a.jpg"}}},
+	}
+	h.syncService = s
+	ctx := context.Background()
+
+	add := func(id string, labels ...string) {
+		entry := models.HistoryEntry{
+			Id: id, ProfileName: "photos", Action: "push", Status: "failed",
+			StartTime: time.Now(), EndTime: time.Now(), Labels: labels,
+		}
+		if err := h.AddEntry(ctx, entry); err != nil {
+			t.Fatalf("AddEntry failed: %v", err)
+		}
+	}
+
+	// A scheduled delta run that failed
+	s.rememberRunLabels(&SyncTask{Id: 7, Profile: models.Profile{Name: "photos"}, parentCtx: withScheduleRun(ctx, "nightly")})
+	add("run-1", "flaky wifi", models.LabelDeltaScoped)
+	if got := s.failedRuns[7].HistoryId; got != "run-1" {
+		t.Errorf("expected the failed run to know its history entry, got %q", got)
+	}
+
+	// Its retry
+	delete(s.deltaRuns, "photos")
+	retryCtx := withRunLabels(ctx, models.LabelRetryOfPrefix+s.failedRuns[7].HistoryId)
+	s.rememberRunLabels(&SyncTask{Id: 8, Profile: models.Profile{Name: "photos"}, parentCtx: retryCtx})
+	add("run-2")
+
+	entries, err := h.GetHistory(ctx, 10, 0)
+	if err != nil {
+		t.Fatalf("GetHistory failed: %v", err)
+	}
+	labels := map[string]string{}
+	for _, e := range entries {
+		labels[e.Id] = strings.Join(e.Labels, ",")
+	}
+	if labels["run-1"] != "schedule:nightly,delta-scoped,flaky wifi" || labels["run-2"] != "retry-of:run-1" {
+		t.Errorf("labels = %v", labels)
+	}
+
+	for label, want := range map[string]int{"delta-scoped": 1, "schedule:": 1, "retry-of:run-1": 1, "schedule:daily": 0, "retry": 0} {
+		found, err := h.GetHistoryByLabel(ctx, label, 10, 0)
+		if err != nil {
+			t.Fatalf("GetHistoryByLabel(%q) failed: %v", label, err)
+		}
+		if len(found) != want {
+			t.Errorf("GetHistoryByLabel(%q) = %d entries, want %d", label, len(found), want)
+		}
+	}
+
+	updated, err := h.SetHistoryLabels(ctx, "run-2", []string{" checked ", "retry-of:run-1", "checked"})
+	if err != nil {
+		t.Fatalf("SetHistoryLabels failed: %v", err)
+	}
+	if strings.Join(updated.Labels, ",") != "checked,retry-of:run-1" {
+		t.Errorf("labels = %q", updated.Labels)
+	}
+	if _, err := h.SetHistoryLabels(ctx, "run-2", []string{" "}); err == nil {
+		t.Error("expected an empty label to be refused")
+	}
+	if _, err := h.SetHistoryNote(ctx, "run-1", "Router was rebooted during the run"); err != nil {
+		t.Fatalf("SetHistoryNote failed: %v", err)
+	}
+	if _, err := h.SetHistoryNote(ctx, "missing", "note"); err == nil {
+		t.Error("expected an unknown entry to be refused")
+	}
+
+	found, err := h.GetHistoryByLabel(ctx, "flaky wifi", 10, 0)
+	if err != nil || len(found) != 1 || found[0].Note != "Router was rebooted during the run" {
+		t.Fatalf("expected the noted entry, got %+v (%v)", found, err)
+	}
+
+	counts, err := h.GetHistoryLabels(ctx)
+	if err != nil {
+		t.Fatalf("GetHistoryLabels failed: %v", err)
+	}
+	if len(counts) != 5 {
+		t.Errorf("label counts = %+v, want 5 labels", counts)
+	}
+}
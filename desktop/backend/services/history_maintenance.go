@@ -0,0 +1,416 @@
+package services
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"desktop/backend/models"
+	"desktop/backend/utils"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+const (
+	// historyMaintenanceInterval is how often maintenance runs; it first runs
+	// historyMaintenanceDelay after startup unless it ran less than an interval ago
+	historyMaintenanceInterval = 24 * time.Hour
+	historyMaintenanceDelay    = 5 * time.Minute
+
+	// historyDetailRetention is how long entries keep their per-file transfer report
+	historyDetailRetention = 30 * 24 * time.Hour
+	// historyRollupAge is when entries are rolled up into daily totals
+	historyRollupAge = 180 * 24 * time.Hour
+
+	// logCompressAge is when rotated log files are compressed, and
+	// logRetention when compressed ones are deleted
+	logCompressAge = 24 * time.Hour
+	logRetention   = 180 * 24 * time.Hour
+
+	// databaseSizeSamples is how many size samples are kept
+	databaseSizeSamples = 365
+	// vacuumFreeRatio is the share of unused pages above which the database is vacuumed
+	vacuumFreeRatio = 0.25
+)
+
+var (
+	// rotatedLogPattern matches log files rotated with a timestamp, like
+	// gn-drive-frontend.log.20240102-150405, and their compressed copies
+	rotatedLogPattern    = regexp.MustCompile(`^(desktop|gn-drive-[a-z]+)\.log\.\d{8}-\d{6}$`)
+	compressedLogPattern = regexp.MustCompile(`^(desktop|gn-drive-[a-z]+)\.log\.\d{8}-\d{6}\.gz$`)
+)
+
+// historyDayRollup adds the history entries matching a condition to the
+// daily totals. Days already rolled up are added to.
+const historyDayRollup = `INSERT INTO history_daily (day, profile_name, action, runs, completed, failed, cancelled,
+		files_transferred, bytes_transferred, errors, duration_ms)
+	SELECT substr(start_time, 1, 10), profile_name, action, COUNT(*),
+		SUM(status = 'completed'), SUM(status = 'failed'), SUM(status = 'cancelled'),
+		SUM(files_transferred), SUM(bytes_transferred), SUM(errors),
+		SUM(MAX(0, COALESCE(CAST(ROUND((julianday(end_time) - julianday(start_time)) * 86400000) AS INTEGER), 0)))
+	FROM history WHERE %s
+	GROUP BY substr(start_time, 1, 10), profile_name, action
+	ON CONFLICT (day, profile_name, action) DO UPDATE SET
+		runs = runs + excluded.runs,
+		completed = completed + excluded.completed,
+		failed = failed + excluded.failed,
+		cancelled = cancelled + excluded.cancelled,
+		files_transferred = files_transferred + excluded.files_transferred,
+		bytes_transferred = bytes_transferred + excluded.bytes_transferred,
+		errors = errors + excluded.errors,
+		duration_ms = duration_ms + excluded.duration_ms`
+
+// rollUpHistory moves the history entries matching where into the daily
+// totals, in one transaction. Returns how many entries were rolled up.
+func rollUpHistory(db *sql.DB, where string, args ...interface{}) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(fmt.Sprintf(historyDayRollup, where), args...); err != nil {
+		return 0, fmt.Errorf("failed to roll up history: %w", err)
+	}
+	res, err := tx.Exec("DELETE FROM history WHERE "+where, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete rolled up history: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// watchMaintenance runs maintenance every historyMaintenanceInterval until
+// the service shuts down
+func (h *HistoryService) watchMaintenance(stop <-chan struct{}) {
+	delay := historyMaintenanceDelay
+	if last, err := lastMaintenanceTime(); err == nil && !last.IsZero() {
+		delay = max(delay, time.Until(last.Add(historyMaintenanceInterval)))
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-timer.C:
+		}
+		if _, err := h.RunMaintenance(context.Background()); err != nil {
+			log.Printf("Warning: History maintenance failed: %v", err)
+		}
+		timer.Reset(historyMaintenanceInterval)
+	}
+}
+
+// RunMaintenance compacts the history and log files now, as the daily
+// maintenance does: per-file transfer reports older than 30 days are
+// dropped, entries older than 180 days are rolled up into daily totals,
+// rotated log files are compressed and old ones deleted, the database is
+// vacuumed when a quarter of it is unused and its size is recorded
+func (h *HistoryService) RunMaintenance(ctx context.Context) (*models.MaintenanceReport, error) {
+	if err := h.ensureInitialized(); err != nil {
+		return nil, err
+	}
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+
+	h.maintenanceMu.Lock()
+	defer h.maintenanceMu.Unlock()
+
+	now := time.Now()
+	report := &models.MaintenanceReport{RanAt: now}
+	fail := func(format string, args ...interface{}) {
+		msg := fmt.Sprintf(format, args...)
+		log.Printf("Warning: History maintenance: %s", msg)
+		report.Errors = append(report.Errors, msg)
+	}
+	if report.SizeBefore, _, err = databaseSize(db); err != nil {
+		return nil, err
+	}
+
+	detailCutoff := now.Add(-historyDetailRetention).UTC().Format(time.RFC3339)
+	if res, err := db.Exec("UPDATE history SET transfer_report = '' WHERE transfer_report != '' AND start_time < ?", detailCutoff); err != nil {
+		fail("failed to drop old transfer reports: %v", err)
+	} else {
+		report.DetailsStripped, _ = res.RowsAffected()
+	}
+
+	rollupCutoff := now.Add(-historyRollupAge).UTC().Format(time.RFC3339)
+	if report.EntriesRolledUp, err = rollUpHistory(db, "start_time < ?", rollupCutoff); err != nil {
+		fail("%v", err)
+	}
+
+	report.LogsCompressed, report.LogsDeleted, err = compactLogFiles(filepath.Dir(utils.LogFilePath("desktop.log")), now)
+	if err != nil {
+		fail("%v", err)
+	}
+
+	size, free, err := databaseSize(db)
+	if err == nil && size > 0 && float64(free)/float64(size) >= vacuumFreeRatio {
+		if _, err := db.Exec("VACUUM"); err != nil {
+			fail("failed to vacuum the database: %v", err)
+		} else {
+			report.Vacuumed = true
+			size, free, err = databaseSize(db)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	report.SizeAfter = size
+
+	if _, err := db.Exec("INSERT OR REPLACE INTO database_size_samples (measured_at, size_bytes, free_bytes) VALUES (?, ?, ?)",
+		now.UTC().Format(time.RFC3339), size, free); err != nil {
+		fail("failed to record the database size: %v", err)
+	}
+	db.Exec(`DELETE FROM database_size_samples WHERE measured_at NOT IN (
+		SELECT measured_at FROM database_size_samples ORDER BY measured_at DESC LIMIT ?
+	)`, databaseSizeSamples)
+
+	log.Printf("History maintenance: dropped %d transfer reports, rolled up %d entries, compressed %d and deleted %d log files, database %d -> %d bytes",
+		report.DetailsStripped, report.EntriesRolledUp, report.LogsCompressed, report.LogsDeleted, report.SizeBefore, report.SizeAfter)
+	h.lastMaintenance = report
+	return report, nil
+}
+
+// GetDailyHistory returns the daily totals of rolled up history entries,
+// newest first, for one profile or, with an empty name, for all
+func (h *HistoryService) GetDailyHistory(ctx context.Context, profileName string) ([]models.HistoryDay, error) {
+	if err := h.ensureInitialized(); err != nil {
+		return nil, err
+	}
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`SELECT day, profile_name, action, runs, completed, failed, cancelled,
+		files_transferred, bytes_transferred, errors, duration_ms
+		FROM history_daily WHERE ? = '' OR profile_name = ?
+		ORDER BY day DESC, profile_name, action`, profileName, profileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query daily history: %w", err)
+	}
+	defer rows.Close()
+
+	days := []models.HistoryDay{}
+	for rows.Next() {
+		var d models.HistoryDay
+		if err := rows.Scan(&d.Day, &d.ProfileName, &d.Action, &d.Runs, &d.Completed, &d.Failed, &d.Cancelled,
+			&d.FilesTransferred, &d.BytesTransferred, &d.Errors, &d.DurationMs); err != nil {
+			return nil, fmt.Errorf("failed to scan daily history: %w", err)
+		}
+		days = append(days, d)
+	}
+	return days, rows.Err()
+}
+
+// GetDatabaseStats returns the size of the database, the rows of its
+// tables and how its size changed over the maintenance runs
+func (h *HistoryService) GetDatabaseStats(ctx context.Context) (*models.DatabaseStats, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	stats := &models.DatabaseStats{Tables: []models.TableRows{}, Samples: []models.DatabaseSizeSample{}}
+	if stats.SizeBytes, stats.FreeBytes, err = databaseSize(db); err != nil {
+		return nil, err
+	}
+
+	tables, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	var names []string
+	for tables.Next() {
+		var name string
+		if err := tables.Scan(&name); err == nil {
+			names = append(names, name)
+		}
+	}
+	tables.Close()
+	for _, name := range names {
+		t := models.TableRows{Table: name}
+		if err := db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %q", name)).Scan(&t.Rows); err != nil {
+			return nil, fmt.Errorf("failed to count rows of %s: %w", name, err)
+		}
+		stats.Tables = append(stats.Tables, t)
+	}
+	sort.Slice(stats.Tables, func(i, j int) bool { return stats.Tables[i].Rows > stats.Tables[j].Rows })
+
+	rows, err := db.Query("SELECT measured_at, size_bytes, free_bytes FROM database_size_samples ORDER BY measured_at")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query database size samples: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s models.DatabaseSizeSample
+		var measuredAt string
+		if err := rows.Scan(&measuredAt, &s.SizeBytes, &s.FreeBytes); err != nil {
+			return nil, fmt.Errorf("failed to scan database size sample: %w", err)
+		}
+		s.MeasuredAt, _ = time.Parse(time.RFC3339, measuredAt)
+		stats.Samples = append(stats.Samples, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	stats.GrowthPerDay = databaseGrowthPerDay(stats.Samples, time.Now())
+
+	h.maintenanceMu.Lock()
+	stats.LastMaintenance = h.lastMaintenance
+	h.maintenanceMu.Unlock()
+	return stats, nil
+}
+
+// databaseGrowthPerDay returns how many bytes of data the database gained
+// per day over the samples of the last 30 days. Free pages don't count.
+func databaseGrowthPerDay(samples []models.DatabaseSizeSample, now time.Time) int64 {
+	var first *models.DatabaseSizeSample
+	for i := range samples {
+		if now.Sub(samples[i].MeasuredAt) <= 30*24*time.Hour {
+			first = &samples[i]
+			break
+		}
+	}
+	if first == nil {
+		return 0
+	}
+	last := samples[len(samples)-1]
+	days := last.MeasuredAt.Sub(first.MeasuredAt).Hours() / 24
+	if days < 1 {
+		return 0
+	}
+	used := func(s models.DatabaseSizeSample) int64 { return s.SizeBytes - s.FreeBytes }
+	return int64(float64(used(last)-used(*first)) / days)
+}
+
+// databaseSize returns the size of the database and of its unused pages
+func databaseSize(db *sql.DB) (size, free int64, err error) {
+	var pages, pageSize, freePages int64
+	if err := db.QueryRow("PRAGMA page_count").Scan(&pages); err != nil {
+		return 0, 0, fmt.Errorf("failed to read the database size: %w", err)
+	}
+	if err := db.QueryRow("PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, 0, fmt.Errorf("failed to read the database size: %w", err)
+	}
+	if err := db.QueryRow("PRAGMA freelist_count").Scan(&freePages); err != nil {
+		return 0, 0, fmt.Errorf("failed to read the database size: %w", err)
+	}
+	return pages * pageSize, freePages * pageSize, nil
+}
+
+// lastMaintenanceTime returns when maintenance last recorded the database size
+func lastMaintenanceTime() (time.Time, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return time.Time{}, err
+	}
+	var measuredAt sql.NullString
+	if err := db.QueryRow("SELECT MAX(measured_at) FROM database_size_samples").Scan(&measuredAt); err != nil || !measuredAt.Valid {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339, measuredAt.String)
+}
+
+// compactLogFiles compresses the rotated log files in dir that haven't
+// changed for logCompressAge and deletes compressed ones older than
+// logRetention. The logs being written to are left alone.
+func compactLogFiles(dir string, now time.Time) (compressed, deleted int, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read the log folder: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		switch {
+		case compressedLogPattern.MatchString(entry.Name()) && now.Sub(info.ModTime()) > logRetention:
+			if err := os.Remove(path); err != nil {
+				log.Printf("Warning: Could not delete old log file %s: %v", path, err)
+				continue
+			}
+			deleted++
+		case rotatedLogPattern.MatchString(entry.Name()) && now.Sub(info.ModTime()) > logCompressAge:
+			if err := gzipLogFile(path, info.ModTime()); err != nil {
+				log.Printf("Warning: Could not compress log file %s: %v", path, err)
+				continue
+			}
+			compressed++
+		}
+	}
+	return compressed, deleted, nil
+}
+
+// gzipLogFile replaces a log file with a gzip copy that keeps its
+// modification time, so its retention counts from when it was written
+func gzipLogFile(path string, modTime time.Time) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := path + ".gz.tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	zw.Name = filepath.Base(path)
+	zw.ModTime = modTime
+	if _, err = io.Copy(zw, in); err == nil {
+		err = zw.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	os.Chtimes(path+".gz", modTime, modTime)
+	in.Close()
+	return os.Remove(path)
+}
+
+// mergeHistoryDays moves the daily totals of profile from to profile to,
+// adding them to days to already has
+func mergeHistoryDays(tx *sql.Tx, from, to string) error {
+	if _, err := tx.Exec(`INSERT INTO history_daily (day, profile_name, action, runs, completed, failed, cancelled,
+			files_transferred, bytes_transferred, errors, duration_ms)
+		SELECT day, ?, action, runs, completed, failed, cancelled, files_transferred, bytes_transferred, errors, duration_ms
+		FROM history_daily WHERE profile_name = ?
+		ON CONFLICT (day, profile_name, action) DO UPDATE SET
+			runs = runs + excluded.runs,
+			completed = completed + excluded.completed,
+			failed = failed + excluded.failed,
+			cancelled = cancelled + excluded.cancelled,
+			files_transferred = files_transferred + excluded.files_transferred,
+			bytes_transferred = bytes_transferred + excluded.bytes_transferred,
+			errors = errors + excluded.errors,
+			duration_ms = duration_ms + excluded.duration_ms`, to, from); err != nil {
+		return err
+	}
+	_, err := tx.Exec("DELETE FROM history_daily WHERE profile_name = ?", from)
+	return err
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/dto"
+	"desktop/backend/models"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHistoryMaintenance(t *testing.T) {
+	h := newTestHistoryService(t)
+	db, _ := GetSharedDB()
+	db.Exec("DELETE FROM history_daily")
+	ctx := context.Background()
+
+	add := func(id string, age time.Duration, status string) {
+		start := time.Now().Add(-age)
+		err := h.saveHistoryEntryToDB(models.HistoryEntry{
+			Id: id, ProfileName: "photos", Action: "push", Status: status,
+			StartTime: start, EndTime: start.Add(time.Minute),
+			FilesTransferred: 10, BytesTransferred: 1000,
+			Report: &dto.TransferReport{Largest: []dto.FileReportEntry{{Name: "a.jpg", Size: 1000}}},
+		})
+		if err != nil {
+			t.Fatalf("saveHistoryEntryToDB: %v", err)
+		}
+	}
+	add("old-1", 200*24*time.Hour, "completed")
+	add("old-2", 200*24*time.Hour+time.Hour, "failed")
+	add("month", 40*24*time.Hour, "completed")
+	add("recent", time.Hour, "completed")
+
+	report, err := h.RunMaintenance(ctx)
+	if err != nil {
+		t.Fatalf("RunMaintenance: %v", err)
+	}
+	if report.EntriesRolledUp != 2 || report.DetailsStripped != 3 {
+		t.Errorf("report = %+v, want 2 entries rolled up and 3 reports dropped", report)
+	}
+
+	entries, err := h.GetHistory(ctx, 10, 0)
+	if err != nil {
+		t.Fatalf("GetHistory: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries left, got %d", len(entries))
+	}
+	for _, e := range entries {
+		if (e.Report != nil) != (e.Id == "recent") {
+			t.Errorf("entry %s: report = %v, want one only on the recent entry", e.Id, e.Report)
+		}
+	}
+
+	days, err := h.GetDailyHistory(ctx, "photos")
+	if err != nil {
+		t.Fatalf("GetDailyHistory: %v", err)
+	}
+	runs := 0
+	for _, d := range days {
+		runs += d.Runs
+	}
+	if runs != 2 {
+		t.Errorf("daily history = %+v, want 2 runs", days)
+	}
+
+	stats, err := h.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("GetStats: %v", err)
+	}
+	if stats.TotalOperations != 4 || stats.FailureCount != 1 || stats.TotalBytes != 4000 {
+		t.Errorf("stats = %+v, want the rolled up runs counted", stats)
+	}
+
+	dbStats, err := h.GetDatabaseStats(ctx)
+	if err != nil {
+		t.Fatalf("GetDatabaseStats: %v", err)
+	}
+	if dbStats.SizeBytes == 0 || len(dbStats.Samples) == 0 || dbStats.LastMaintenance != report {
+		t.Errorf("database stats = %+v", dbStats)
+	}
+}
+
+func TestCompactLogFiles(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	write := func(name string, age time.Duration) {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("log line\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, now.Add(-age), now.Add(-age))
+	}
+	write("gn-drive-frontend.log", 48*time.Hour)                        // being written to
+	write("gn-drive-frontend.log.20240101-120000", 48*time.Hour)        // rotated
+	write("gn-drive-frontend.log.20240102-120000", time.Hour)           // rotated today
+	write("gn-drive-frontend.log.20230101-120000.gz", 200*24*time.Hour) // expired
+	write("notes.log.20240101-120000", 48*time.Hour)                    // not ours
+
+	compressed, deleted, err := compactLogFiles(dir, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compressed != 1 || deleted != 1 {
+		t.Errorf("compressed %d and deleted %d, want 1 and 1", compressed, deleted)
+	}
+	for _, name := range []string{"gn-drive-frontend.log", "gn-drive-frontend.log.20240101-120000.gz", "gn-drive-frontend.log.20240102-120000", "notes.log.20240101-120000"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s: %v", name, err)
+		}
+	}
+	for _, name := range []string{"gn-drive-frontend.log.20240101-120000", "gn-drive-frontend.log.20230101-120000.gz"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			t.Errorf("expected %s to be gone", name)
+		}
+	}
+}
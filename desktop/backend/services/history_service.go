@@ -2,6 +2,9 @@ package services
 
 import (
 	"context"
+	"desktop/backend/dto"
+	"encoding/json"
+	apperrors "desktop/backend/errors"
 	"desktop/backend/events"
 	"desktop/backend/models"
 	"fmt"
@@ -20,6 +23,14 @@ type HistoryService struct {
 	eventBus    *events.WailsEventBus
 	mutex       sync.RWMutex
 	initialized bool
+
+	// Dependencies
+	syncService *SyncService
+
+	// Daily maintenance of the database and log files
+	maintenanceMu   sync.Mutex
+	lastMaintenance *models.MaintenanceReport
+	stopMaintenance chan struct{}
 }
 
 // NewHistoryService creates a new history service
@@ -39,15 +50,23 @@ func (h *HistoryService) SetApp(app *application.App) {
 	}
 }
 
+// SetSyncService sets the sync service that provides per-run delta info and transfer reports
+func (h *HistoryService) SetSyncService(syncService *SyncService) {
+	h.syncService = syncService
+}
+
 // ServiceName returns the name of the service
 func (h *HistoryService) ServiceName() string {
 	return "HistoryService"
 }
 
 // ServiceStartup is called when the service starts.
-// Initialization is deferred to first access to speed up app startup.
+// Initialization is deferred to first access to speed up app startup;
+// maintenance starts in the background.
 func (h *HistoryService) ServiceStartup(ctx context.Context, options application.ServiceOptions) error {
 	log.Printf("HistoryService starting up (lazy init)...")
+	h.stopMaintenance = make(chan struct{})
+	go h.watchMaintenance(h.stopMaintenance)
 	return nil
 }
 
@@ -65,6 +84,10 @@ func (h *HistoryService) ensureInitialized() error {
 // ServiceShutdown is called when the service shuts down
 func (h *HistoryService) ServiceShutdown(ctx context.Context) error {
 	log.Printf("HistoryService shutting down...")
+	if h.stopMaintenance != nil {
+		close(h.stopMaintenance)
+		h.stopMaintenance = nil
+	}
 	return nil
 }
 
@@ -82,8 +105,58 @@ func (h *HistoryService) initialize() error {
 	return nil
 }
 
-// AddEntry adds a new history entry (capped at maxHistoryEntries)
+// AddEntry adds a new history entry (capped at maxHistoryEntries).
+// Recognised error messages are classified into ErrorInfo, and the delta
+// info, transfer report, fan-out destination results, API calls, failover,
+// locked files and compression of the profile's last run, and the remote
+// hook results and raw rclone flags of the entry's task (the profile's last
+// run if it has none), are attached if the caller didn't set them. A failed
+// run whose message isn't recognised is classified as FILE_LOCKED when
+// locked files are left. The automatic labels of the run are added to the
+// caller's.
 func (h *HistoryService) AddEntry(ctx context.Context, entry models.HistoryEntry) error {
+	taskId := entry.TaskId
+	if h.syncService != nil {
+		lastTaskId, labels := h.syncService.takeRunLabels(entry.ProfileName)
+		if taskId == 0 {
+			taskId = lastTaskId
+		}
+		entry.Labels = mergeHistoryLabels(labels, entry.Labels)
+	}
+	if entry.LockedFiles == nil && h.syncService != nil {
+		entry.LockedFiles = h.syncService.takeLockedFiles(entry.ProfileName)
+	}
+	if entry.ErrorInfo == nil {
+		entry.ErrorInfo = apperrors.ClassifyRcloneError(entry.ErrorMessage)
+	}
+	if entry.ErrorInfo == nil && entry.Status == "failed" && entry.LockedFiles != nil && len(entry.LockedFiles.Files) > 0 {
+		entry.ErrorInfo = apperrors.LookupErrorInfo(string(apperrors.FileLocked))
+	}
+	if entry.Delta == nil && h.syncService != nil {
+		entry.Delta = h.syncService.takeDeltaRun(entry.ProfileName)
+	}
+	if entry.Report == nil && h.syncService != nil {
+		entry.Report = h.syncService.takeTransferReport(entry.ProfileName)
+	}
+	if entry.Destinations == nil && h.syncService != nil {
+		entry.Destinations = h.syncService.takeDestinationResults(entry.ProfileName)
+	}
+	if entry.APICalls == nil && h.syncService != nil {
+		entry.APICalls = h.syncService.takeAPICalls(entry.ProfileName)
+	}
+	if entry.Failover == nil && h.syncService != nil {
+		entry.Failover = h.syncService.takeFailover(entry.ProfileName)
+	}
+	if entry.Compression == nil && h.syncService != nil {
+		entry.Compression = h.syncService.takeCompression(entry.ProfileName)
+	}
+	if entry.RemoteHooks == nil && h.syncService != nil {
+		entry.RemoteHooks = h.syncService.takeRemoteHookResults(taskId)
+	}
+	if entry.RawFlags == nil && h.syncService != nil {
+		entry.RawFlags = h.syncService.takeRawFlags(taskId)
+	}
+
 	h.mutex.Lock()
 	defer h.mutex.Unlock()
 
@@ -98,6 +171,9 @@ func (h *HistoryService) AddEntry(ctx context.Context, entry models.HistoryEntry
 	if err := h.saveHistoryEntryToDB(entry); err != nil {
 		return fmt.Errorf("failed to save history: %w", err)
 	}
+	if taskId != 0 {
+		h.syncService.noteRunHistoryEntry(taskId, entry.Id)
+	}
 
 	// Enforce cap
 	h.enforceHistoryCap()
@@ -106,6 +182,44 @@ func (h *HistoryService) AddEntry(ctx context.Context, entry models.HistoryEntry
 	return nil
 }
 
+// addRecordedEntries saves runs recorded elsewhere: imported from another
+// tool's logs, interrupted by the app exiting, or verifications. Unlike AddEntry it
+// attaches nothing from this app's current runs. Entries whose id is
+// already stored are left alone; it returns how many were added.
+func (h *HistoryService) addRecordedEntries(entries []models.HistoryEntry) (int, error) {
+	if err := h.ensureInitialized(); err != nil {
+		return 0, err
+	}
+	db, err := GetSharedDB()
+	if err != nil {
+		return 0, err
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	added := 0
+	for _, entry := range entries {
+		var exists int
+		if err := db.QueryRow("SELECT COUNT(*) FROM history WHERE id = ?", entry.Id).Scan(&exists); err != nil {
+			return added, fmt.Errorf("failed to check history: %w", err)
+		}
+		if exists > 0 {
+			continue
+		}
+		if entry.ErrorInfo == nil {
+			entry.ErrorInfo = apperrors.ClassifyRcloneError(entry.ErrorMessage)
+		}
+		if err := h.saveHistoryEntryToDB(entry); err != nil {
+			return added, fmt.Errorf("failed to save history: %w", err)
+		}
+		added++
+		h.emitHistoryEvent(events.HistoryAdded, entry)
+	}
+	h.enforceHistoryCap()
+	return added, nil
+}
+
 // GetHistory returns history entries with pagination
 func (h *HistoryService) GetHistory(ctx context.Context, limit, offset int) ([]models.HistoryEntry, error) {
 	if err := h.ensureInitialized(); err != nil {
@@ -118,7 +232,8 @@ func (h *HistoryService) GetHistory(ctx context.Context, limit, offset int) ([]m
 	}
 
 	rows, err := db.Query(`SELECT id, profile_name, action, status, start_time, end_time,
-		duration, files_transferred, bytes_transferred, errors, error_message
+		duration, files_transferred, bytes_transferred, errors, error_message, error_code,
+		delta_mode, delta_changes, delta_reason, delta_time_saved_ms, transfer_report, destinations, source, api_calls, failover, locked_files, compression, remote_hooks, raw_flags, labels, note
 		FROM history ORDER BY start_time DESC LIMIT ? OFFSET ?`, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query history: %w", err)
@@ -144,7 +259,8 @@ func (h *HistoryService) GetHistoryForProfile(ctx context.Context, profileName s
 	}
 
 	rows, err := db.Query(`SELECT id, profile_name, action, status, start_time, end_time,
-		duration, files_transferred, bytes_transferred, errors, error_message
+		duration, files_transferred, bytes_transferred, errors, error_message, error_code,
+		delta_mode, delta_changes, delta_reason, delta_time_saved_ms, transfer_report, destinations, source, api_calls, failover, locked_files, compression, remote_hooks, raw_flags, labels, note
 		FROM history WHERE profile_name = ? ORDER BY start_time DESC`, profileName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query history for profile: %w", err)
@@ -177,6 +293,7 @@ func (h *HistoryService) GetStats(ctx context.Context) (*models.AggregateStats,
 		COALESCE(SUM(CASE WHEN status = 'completed' THEN 1 ELSE 0 END), 0),
 		COALESCE(SUM(CASE WHEN status = 'failed' THEN 1 ELSE 0 END), 0),
 		COALESCE(SUM(CASE WHEN status = 'cancelled' THEN 1 ELSE 0 END), 0),
+		COALESCE(SUM(CASE WHEN status = 'interrupted' THEN 1 ELSE 0 END), 0),
 		COALESCE(SUM(bytes_transferred), 0),
 		COALESCE(SUM(files_transferred), 0)
 		FROM history`).Scan(
@@ -184,6 +301,7 @@ func (h *HistoryService) GetStats(ctx context.Context) (*models.AggregateStats,
 		&stats.SuccessCount,
 		&stats.FailureCount,
 		&stats.CancelledCount,
+		&stats.InterruptedCount,
 		&stats.TotalBytes,
 		&stats.TotalFiles,
 	)
@@ -191,13 +309,32 @@ func (h *HistoryService) GetStats(ctx context.Context) (*models.AggregateStats,
 		return nil, fmt.Errorf("failed to get history stats: %w", err)
 	}
 
-	// Compute average duration from all duration strings
+	// Add the entries rolled up into daily totals
+	var days models.AggregateStats
+	var dayRuns int
+	var dayDurationMs int64
+	err = db.QueryRow(`SELECT
+		COALESCE(SUM(runs), 0), COALESCE(SUM(completed), 0), COALESCE(SUM(failed), 0), COALESCE(SUM(cancelled), 0),
+		COALESCE(SUM(bytes_transferred), 0), COALESCE(SUM(files_transferred), 0), COALESCE(SUM(duration_ms), 0)
+		FROM history_daily`).Scan(&dayRuns, &days.SuccessCount, &days.FailureCount, &days.CancelledCount,
+		&days.TotalBytes, &days.TotalFiles, &dayDurationMs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily history stats: %w", err)
+	}
+	stats.TotalOperations += dayRuns
+	stats.SuccessCount += days.SuccessCount
+	stats.FailureCount += days.FailureCount
+	stats.CancelledCount += days.CancelledCount
+	stats.TotalBytes += days.TotalBytes
+	stats.TotalFiles += days.TotalFiles
+
+	// Compute average duration from all duration strings and the daily totals
 	if stats.TotalOperations > 0 {
 		rows, err := db.Query("SELECT duration FROM history WHERE duration != ''")
 		if err == nil {
 			defer rows.Close()
-			var totalDuration time.Duration
-			var count int
+			totalDuration := time.Duration(dayDurationMs) * time.Millisecond
+			count := dayRuns
 			for rows.Next() {
 				var dur string
 				if err := rows.Scan(&dur); err == nil {
@@ -231,6 +368,9 @@ func (h *HistoryService) ClearHistory(ctx context.Context) error {
 	if _, err := db.Exec("DELETE FROM history"); err != nil {
 		return fmt.Errorf("failed to clear history: %w", err)
 	}
+	if _, err := db.Exec("DELETE FROM history_daily"); err != nil {
+		return fmt.Errorf("failed to clear daily history: %w", err)
+	}
 
 	h.emitHistoryEvent(events.HistoryCleared, nil)
 	return nil
@@ -243,25 +383,115 @@ func (h *HistoryService) saveHistoryEntryToDB(e models.HistoryEntry) error {
 		return err
 	}
 
+	errorCode := ""
+	if e.ErrorInfo != nil {
+		errorCode = e.ErrorInfo.Code
+	}
+	var deltaRun models.DeltaRun
+	if e.Delta != nil {
+		deltaRun = *e.Delta
+	}
+	report := ""
+	if e.Report != nil {
+		data, err := json.Marshal(e.Report)
+		if err != nil {
+			return fmt.Errorf("failed to marshal transfer report: %w", err)
+		}
+		report = string(data)
+	}
+	destinations := ""
+	if len(e.Destinations) > 0 {
+		data, err := json.Marshal(e.Destinations)
+		if err != nil {
+			return fmt.Errorf("failed to marshal destination results: %w", err)
+		}
+		destinations = string(data)
+	}
+	apiCalls := ""
+	if len(e.APICalls) > 0 {
+		data, err := json.Marshal(e.APICalls)
+		if err != nil {
+			return fmt.Errorf("failed to marshal API calls: %w", err)
+		}
+		apiCalls = string(data)
+	}
+	failover := ""
+	if e.Failover != nil {
+		data, err := json.Marshal(e.Failover)
+		if err != nil {
+			return fmt.Errorf("failed to marshal failover: %w", err)
+		}
+		failover = string(data)
+	}
+	lockedFiles := ""
+	if e.LockedFiles != nil {
+		data, err := json.Marshal(e.LockedFiles)
+		if err != nil {
+			return fmt.Errorf("failed to marshal locked files: %w", err)
+		}
+		lockedFiles = string(data)
+	}
+	compression := ""
+	if e.Compression != nil {
+		data, err := json.Marshal(e.Compression)
+		if err != nil {
+			return fmt.Errorf("failed to marshal compression: %w", err)
+		}
+		compression = string(data)
+	}
+	remoteHooks := ""
+	if len(e.RemoteHooks) > 0 {
+		data, err := json.Marshal(e.RemoteHooks)
+		if err != nil {
+			return fmt.Errorf("failed to marshal remote hooks: %w", err)
+		}
+		remoteHooks = string(data)
+	}
+	rawFlags := ""
+	if len(e.RawFlags) > 0 {
+		data, err := json.Marshal(e.RawFlags)
+		if err != nil {
+			return fmt.Errorf("failed to marshal raw flags: %w", err)
+		}
+		rawFlags = string(data)
+	}
+	labels := ""
+	if len(e.Labels) > 0 {
+		data, err := json.Marshal(e.Labels)
+		if err != nil {
+			return fmt.Errorf("failed to marshal labels: %w", err)
+		}
+		labels = string(data)
+	}
+
 	_, err = db.Exec(`INSERT OR REPLACE INTO history (id, profile_name, action, status, start_time, end_time,
-		duration, files_transferred, bytes_transferred, errors, error_message)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		duration, files_transferred, bytes_transferred, errors, error_message, error_code,
+		delta_mode, delta_changes, delta_reason, delta_time_saved_ms, transfer_report, destinations, source, api_calls, failover, locked_files, compression, remote_hooks, raw_flags, labels, note)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		e.Id, e.ProfileName, e.Action, e.Status,
 		e.StartTime.UTC().Format(time.RFC3339), e.EndTime.UTC().Format(time.RFC3339),
-		e.Duration, e.FilesTransferred, e.BytesTransferred, e.Errors, e.ErrorMessage)
+		e.Duration, e.FilesTransferred, e.BytesTransferred, e.Errors, e.ErrorMessage, errorCode,
+		deltaRun.Mode, deltaRun.ChangesScoped, deltaRun.FallbackReason, deltaRun.TimeSavedMs, report, destinations, e.Source, apiCalls, failover, lockedFiles, compression, remoteHooks, rawFlags, labels, e.Note)
 	return err
 }
 
-// enforceHistoryCap deletes oldest entries exceeding the max count
+// enforceHistoryCap rolls the oldest entries exceeding the max count up
+// into daily totals
 func (h *HistoryService) enforceHistoryCap() {
 	db, err := GetSharedDB()
 	if err != nil {
 		return
 	}
 
-	_, _ = db.Exec(`DELETE FROM history WHERE id NOT IN (
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM history").Scan(&count); err != nil || count <= maxHistoryEntries {
+		return
+	}
+	if _, err := rollUpHistory(db, `id NOT IN (
 		SELECT id FROM history ORDER BY start_time DESC LIMIT ?
-	)`, maxHistoryEntries)
+	)`, maxHistoryEntries); err != nil {
+		log.Printf("Warning: Could not cap history: %v", err)
+	}
 }
 
 // scanHistoryRows scans rows into HistoryEntry slice
@@ -269,11 +499,74 @@ func (h *HistoryService) scanHistoryRows(rows interface{ Next() bool; Scan(...in
 	var entries []models.HistoryEntry
 	for rows.Next() {
 		var e models.HistoryEntry
-		var startTime, endTime string
+		var startTime, endTime, errorCode, report, destinations, apiCalls, failover, lockedFiles, compression, remoteHooks, rawFlags, labels string
+		var deltaRun models.DeltaRun
 		if err := rows.Scan(&e.Id, &e.ProfileName, &e.Action, &e.Status, &startTime, &endTime,
-			&e.Duration, &e.FilesTransferred, &e.BytesTransferred, &e.Errors, &e.ErrorMessage); err != nil {
+			&e.Duration, &e.FilesTransferred, &e.BytesTransferred, &e.Errors, &e.ErrorMessage, &errorCode,
+			&deltaRun.Mode, &deltaRun.ChangesScoped, &deltaRun.FallbackReason, &deltaRun.TimeSavedMs, &report, &destinations, &e.Source, &apiCalls, &failover, &lockedFiles, &compression, &remoteHooks, &rawFlags, &labels, &e.Note); err != nil {
 			return nil, fmt.Errorf("failed to scan history entry: %w", err)
 		}
+		if report != "" {
+			var r dto.TransferReport
+			if err := json.Unmarshal([]byte(report), &r); err == nil {
+				e.Report = &r
+			}
+		}
+		if destinations != "" {
+			if err := json.Unmarshal([]byte(destinations), &e.Destinations); err != nil {
+				log.Printf("warning: failed to parse destinations of history entry %s: %v", e.Id, err)
+			}
+		}
+		if apiCalls != "" {
+			if err := json.Unmarshal([]byte(apiCalls), &e.APICalls); err != nil {
+				log.Printf("warning: failed to parse API calls of history entry %s: %v", e.Id, err)
+			}
+		}
+		if failover != "" {
+			e.Failover = &models.FailoverRun{}
+			if err := json.Unmarshal([]byte(failover), e.Failover); err != nil {
+				log.Printf("warning: failed to parse failover of history entry %s: %v", e.Id, err)
+				e.Failover = nil
+			}
+		}
+		if lockedFiles != "" {
+			e.LockedFiles = &models.LockedFilesRun{}
+			if err := json.Unmarshal([]byte(lockedFiles), e.LockedFiles); err != nil {
+				log.Printf("warning: failed to parse locked files of history entry %s: %v", e.Id, err)
+				e.LockedFiles = nil
+			}
+		}
+		if compression != "" {
+			e.Compression = &models.CompressionRun{}
+			if err := json.Unmarshal([]byte(compression), e.Compression); err != nil {
+				log.Printf("warning: failed to parse compression of history entry %s: %v", e.Id, err)
+				e.Compression = nil
+			}
+		}
+		if remoteHooks != "" {
+			if err := json.Unmarshal([]byte(remoteHooks), &e.RemoteHooks); err != nil {
+				log.Printf("warning: failed to parse remote hooks of history entry %s: %v", e.Id, err)
+			}
+		}
+		if rawFlags != "" {
+			if err := json.Unmarshal([]byte(rawFlags), &e.RawFlags); err != nil {
+				log.Printf("warning: failed to parse raw flags of history entry %s: %v", e.Id, err)
+			}
+		}
+		if labels != "" {
+			if err := json.Unmarshal([]byte(labels), &e.Labels); err != nil {
+				log.Printf("warning: failed to parse labels of history entry %s: %v", e.Id, err)
+			}
+		}
+		if deltaRun.Mode != "" {
+			e.Delta = &deltaRun
+		}
+		if errorCode != "" {
+			e.ErrorInfo = apperrors.LookupErrorInfo(errorCode)
+		} else {
+			// Entries recorded before classification existed
+			e.ErrorInfo = apperrors.ClassifyRcloneError(e.ErrorMessage)
+		}
 		if t, err := time.Parse(time.RFC3339, startTime); err == nil {
 			e.StartTime = t
 		}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"desktop/backend/dto"
 	"desktop/backend/models"
 	"fmt"
 	"testing"
@@ -51,6 +52,102 @@ func TestHistoryService_AddEntry(t *testing.T) {
 	}
 }
 
+func TestHistoryService_ErrorClassification(t *testing.T) {
+	h := newTestHistoryService(t)
+	ctx := context.Background()
+
+	entry := models.HistoryEntry{
+		Id:           "failed-1",
+		ProfileName:  "photos",
+		Action:       "push",
+		Status:       "failed",
+		StartTime:    time.Now(),
+		EndTime:      time.Now(),
+		ErrorMessage: "googleapi: Error 403: The user's Drive storage quota has been exceeded., storageQuotaExceeded",
+	}
+	if err := h.AddEntry(ctx, entry); err != nil {
+		t.Fatalf("AddEntry failed: %v", err)
+	}
+
+	entries, err := h.GetHistory(ctx, 10, 0)
+	if err != nil {
+		t.Fatalf("GetHistory failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].ErrorInfo == nil {
+		t.Fatalf("expected a classified entry, got %+v", entries)
+	}
+	if entries[0].ErrorInfo.Code != "QUOTA_EXCEEDED" {
+		t.Errorf("expected QUOTA_EXCEEDED, got %q", entries[0].ErrorInfo.Code)
+	}
+}
+
+func TestHistoryService_DeltaRun(t *testing.T) {
+	h := newTestHistoryService(t)
+	h.syncService = &SyncService{deltaRuns: map[string]*models.DeltaRun{
+		"photos": {Mode: "delta", ChangesScoped: 12, TimeSavedMs: 90000},
+	}}
+	ctx := context.Background()
+
+	entry := models.HistoryEntry{
+		Id:          "delta-1",
+		ProfileName: "photos",
+		Action:      "push",
+		Status:      "completed",
+		StartTime:   time.Now(),
+		EndTime:     time.Now(),
+	}
+	if err := h.AddEntry(ctx, entry); err != nil {
+		t.Fatalf("AddEntry failed: %v", err)
+	}
+
+	entries, err := h.GetHistory(ctx, 10, 0)
+	if err != nil {
+		t.Fatalf("GetHistory failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Delta == nil {
+		t.Fatalf("expected an entry with delta info, got %+v", entries)
+	}
+	if d := entries[0].Delta; d.Mode != "delta" || d.ChangesScoped != 12 || d.TimeSavedMs != 90000 {
+		t.Errorf("unexpected delta info: %+v", d)
+	}
+
+	// The run info is consumed by the first entry
+	if run := h.syncService.takeDeltaRun("photos"); run != nil {
+		t.Errorf("expected delta run to be consumed, got %+v", run)
+	}
+}
+
+func TestHistoryService_TransferReport(t *testing.T) {
+	h := newTestHistoryService(t)
+	h.syncService = &SyncService{transferReports: map[string]*dto.TransferReport{
+		"photos": {Slowest: []dto.FileReportEntry{{Name: "big.mov", Size: 1 << 30, DurationMs: 60000, Attempts: 2}}},
+	}}
+	ctx := context.Background()
+
+	entry := models.HistoryEntry{
+		Id:          "report-1",
+		ProfileName: "photos",
+		Action:      "push",
+		Status:      "completed",
+		StartTime:   time.Now(),
+		EndTime:     time.Now(),
+	}
+	if err := h.AddEntry(ctx, entry); err != nil {
+		t.Fatalf("AddEntry failed: %v", err)
+	}
+
+	entries, err := h.GetHistory(ctx, 10, 0)
+	if err != nil {
+		t.Fatalf("GetHistory failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Report == nil || len(entries[0].Report.Slowest) != 1 {
+		t.Fatalf("expected an entry with a transfer report, got %+v", entries)
+	}
+	if e := entries[0].Report.Slowest[0]; e.Name != "big.mov" || e.DurationMs != 60000 || e.Attempts != 2 {
+		t.Errorf("unexpected report entry: %+v", e)
+	}
+}
+
 func TestHistoryService_MaxEntries(t *testing.T) {
 	h := newTestHistoryService(t)
 	ctx := context.Background()
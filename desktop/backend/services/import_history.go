@@ -0,0 +1,342 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"desktop/backend/models"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// History import formats
+const (
+	HistoryImportRcloneJSON = "rclone-json" // rclone --use-json-log output
+	HistoryImportRsync      = "rsync"       // rsync --log-file or --itemize-changes output
+)
+
+// historyImportRunGap splits an rclone log into runs: rclone logs carry no
+// run id, so lines further apart than this belong to different runs
+const historyImportRunGap = 30 * time.Minute
+
+// HistoryImportOptions configures a history import
+type HistoryImportOptions struct {
+	Format      string     `json:"format,omitempty"`   // "rclone-json" or "rsync"; empty detects it from the log
+	ProfileName string     `json:"profile_name"`       // profile the imported runs are recorded under
+	Action      string     `json:"action,omitempty"`   // action recorded for the runs; default "push"
+	RunTime     *time.Time `json:"run_time,omitempty"` // when a log without timestamps ran; default the file's modification time
+	DryRun      bool       `json:"dry_run,omitempty"`  // parse and return the runs without saving them
+}
+
+// HistoryImportResult shows the runs found in a log and how many were saved
+type HistoryImportResult struct {
+	Format   string                `json:"format"`
+	Runs     []models.HistoryEntry `json:"runs"`
+	Added    int                   `json:"added"`
+	Skipped  int                   `json:"skipped"` // runs already imported from the same log
+	Warnings []string              `json:"warnings"`
+}
+
+// ImportHistoryFromFile imports past runs from another tool's log file
+func (i *ImportService) ImportHistoryFromFile(ctx context.Context, filePath string, options HistoryImportOptions) (*HistoryImportResult, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if options.RunTime == nil {
+		if info, err := os.Stat(filePath); err == nil {
+			modTime := info.ModTime()
+			options.RunTime = &modTime
+		}
+	}
+	return i.ImportHistoryFromBytes(ctx, data, options)
+}
+
+// ImportHistoryFromBytes imports past runs from another tool's log, so the
+// record of backups made before switching to this app is kept. Each run
+// becomes a history entry marked with the tool it came from; importing the
+// same log again adds nothing.
+func (i *ImportService) ImportHistoryFromBytes(ctx context.Context, data []byte, options HistoryImportOptions) (*HistoryImportResult, error) {
+	if options.ProfileName == "" {
+		return nil, fmt.Errorf("profile name is required")
+	}
+	if options.Action == "" {
+		options.Action = "push"
+	}
+	if options.Format == "" {
+		options.Format = detectHistoryImportFormat(data)
+	}
+
+	result := &HistoryImportResult{Format: options.Format, Warnings: []string{}}
+	var runs []models.HistoryEntry
+	switch options.Format {
+	case HistoryImportRcloneJSON:
+		runs, result.Warnings = parseRcloneJSONLog(data, options)
+	case HistoryImportRsync:
+		runs, result.Warnings = parseRsyncLog(data, options)
+	default:
+		return nil, fmt.Errorf("unknown history import format %q: expected rclone-json or rsync", options.Format)
+	}
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("no runs found in the %s log", options.Format)
+	}
+	result.Runs = runs
+	if options.DryRun {
+		return result, nil
+	}
+
+	if i.historyService == nil {
+		return nil, fmt.Errorf("history service not available")
+	}
+	added, err := i.historyService.addRecordedEntries(runs)
+	if err != nil {
+		return nil, err
+	}
+	result.Added = added
+	result.Skipped = len(runs) - added
+
+	log.Printf("ImportService: Imported %d runs from %s log for profile %s (%d already imported)",
+		added, options.Format, options.ProfileName, result.Skipped)
+	return result, nil
+}
+
+// detectHistoryImportFormat tells rclone JSON logs from rsync logs by their first line
+func detectHistoryImportFormat(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "{") {
+			return HistoryImportRcloneJSON
+		}
+		return HistoryImportRsync
+	}
+	return HistoryImportRsync
+}
+
+// importedRun accumulates the log lines of one imported run
+type importedRun struct {
+	start, end time.Time
+	files      int64
+	bytes      int64
+	errors     int
+	lastError  string
+	failed     bool
+}
+
+// entry converts the run to a history entry. The id depends only on the
+// run, so a run imported twice keeps one entry.
+func (r *importedRun) entry(source string, options HistoryImportOptions) models.HistoryEntry {
+	status := "completed"
+	if r.failed || r.errors > 0 {
+		status = "failed"
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%s|%s", source, options.ProfileName,
+		r.start.UTC().Format(time.RFC3339Nano), r.end.UTC().Format(time.RFC3339Nano))))
+	return models.HistoryEntry{
+		Id:               "import-" + source + "-" + hex.EncodeToString(sum[:8]),
+		ProfileName:      options.ProfileName,
+		Action:           options.Action,
+		Status:           status,
+		StartTime:        r.start,
+		EndTime:          r.end,
+		Duration:         r.end.Sub(r.start).Round(time.Second).String(),
+		FilesTransferred: r.files,
+		BytesTransferred: r.bytes,
+		Errors:           r.errors,
+		ErrorMessage:     r.lastError,
+		Source:           source,
+	}
+}
+
+// rcloneLogLine is a line of rclone's --use-json-log output
+type rcloneLogLine struct {
+	Time   time.Time `json:"time"`
+	Level  string    `json:"level"`
+	Msg    string    `json:"msg"`
+	Object string    `json:"object"`
+	Stats  *struct {
+		Bytes       int64   `json:"bytes"`
+		Transfers   int64   `json:"transfers"`
+		Errors      int     `json:"errors"`
+		FatalError  bool    `json:"fatalError"`
+		LastError   string  `json:"lastError"`
+		ElapsedTime float64 `json:"elapsedTime"`
+	} `json:"stats"`
+}
+
+// parseRcloneJSONLog splits an rclone JSON log into runs. A run ends at a
+// gap of historyImportRunGap, at rclone's start-up line, or when the stats
+// counters start again from zero. Files are counted from the "Copied" and
+// "Moved" lines, unless the run's last stats line reports more.
+func parseRcloneJSONLog(data []byte, options HistoryImportOptions) ([]models.HistoryEntry, []string) {
+	var runs []models.HistoryEntry
+	warnings := []string{}
+	var run *importedRun
+	var lastElapsed float64
+	var statsFiles, statsBytes int64
+	var statsErrors int
+	finish := func() {
+		if run == nil {
+			return
+		}
+		run.files = max(run.files, statsFiles)
+		run.bytes = max(run.bytes, statsBytes)
+		run.errors = max(run.errors, statsErrors)
+		runs = append(runs, run.entry("rclone", options))
+		run = nil
+	}
+
+	skipped := 0
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var line rcloneLogLine
+		if err := json.Unmarshal([]byte(text), &line); err != nil || line.Time.IsZero() {
+			skipped++
+			continue
+		}
+
+		starting := strings.Contains(line.Msg, "starting with parameters")
+		restarted := line.Stats != nil && line.Stats.ElapsedTime < lastElapsed
+		if run != nil && (starting || restarted || line.Time.Sub(run.end) > historyImportRunGap) {
+			finish()
+		}
+		if run == nil {
+			run = &importedRun{start: line.Time}
+			lastElapsed, statsFiles, statsBytes, statsErrors = 0, 0, 0, 0
+		}
+		run.end = line.Time
+
+		msg := strings.TrimSpace(line.Msg)
+		if strings.Contains(msg, "Copied (") || strings.Contains(msg, "Moved (") {
+			run.files++
+		}
+		if strings.EqualFold(line.Level, "error") {
+			run.errors++
+			run.lastError = msg
+			if line.Object != "" && !strings.HasPrefix(msg, line.Object) {
+				run.lastError = line.Object + ": " + msg
+			}
+		}
+		if line.Stats != nil {
+			lastElapsed = line.Stats.ElapsedTime
+			statsFiles, statsBytes, statsErrors = line.Stats.Transfers, line.Stats.Bytes, line.Stats.Errors
+			if line.Stats.FatalError {
+				run.failed = true
+			}
+			if line.Stats.LastError != "" {
+				run.lastError = line.Stats.LastError
+			}
+		}
+	}
+	finish()
+
+	if err := scanner.Err(); err != nil {
+		warnings = append(warnings, fmt.Sprintf("Stopped reading the log early: %v", err))
+	}
+	if skipped > 0 {
+		warnings = append(warnings, fmt.Sprintf("Skipped %d lines that are not rclone JSON log lines", skipped))
+	}
+	return runs, warnings
+}
+
+var (
+	// rsyncLogLine is a --log-file line: "2024/01/02 03:04:05 [1234] message"
+	rsyncLogLine = regexp.MustCompile(`^(\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2}) \[(\d+)\] (.*)$`)
+	// rsyncItemized is an --itemize-changes line: update type, file type, attributes, path
+	rsyncItemized = regexp.MustCompile(`^([<>ch.])([fdLDS])\S{7,9} (.+)$`)
+	// rsyncSummary is the closing "sent N bytes  received N bytes" line
+	rsyncSummary = regexp.MustCompile(`sent ([\d,]+) bytes\s+received ([\d,]+) bytes`)
+)
+
+// parseRsyncLog splits an rsync log into runs, one per rsync process in
+// --log-file output. Output without timestamps is one run at
+// options.RunTime. Files are the transferred regular files of the itemized
+// lines; bytes are the larger of the summary's sent and received counts,
+// which is the direction the data went.
+func parseRsyncLog(data []byte, options HistoryImportOptions) ([]models.HistoryEntry, []string) {
+	warnings := []string{}
+	runTime := time.Now()
+	if options.RunTime != nil {
+		runTime = *options.RunTime
+	}
+
+	var order []string
+	runs := map[string]*importedRun{}
+	seen := map[string]bool{} // runs with any itemized, summary or error line
+	untimed := false
+	for _, text := range strings.Split(string(data), "\n") {
+		text = strings.TrimRight(text, "\r")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		pid, at, msg := "", runTime, text
+		if m := rsyncLogLine.FindStringSubmatch(text); m != nil {
+			t, err := time.ParseInLocation("2006/01/02 15:04:05", m[1], time.Local)
+			if err == nil {
+				pid, at, msg = m[2], t, m[3]
+			}
+		}
+		if pid == "" {
+			untimed = true
+		}
+		run, ok := runs[pid]
+		if !ok {
+			run = &importedRun{start: at}
+			runs[pid] = run
+			order = append(order, pid)
+		}
+		run.end = at
+
+		switch {
+		case rsyncItemized.MatchString(msg):
+			m := rsyncItemized.FindStringSubmatch(msg)
+			if (m[1] == "<" || m[1] == ">") && m[2] == "f" {
+				run.files++
+			}
+			seen[pid] = true
+		case strings.HasPrefix(msg, "*deleting "):
+			seen[pid] = true
+		case rsyncSummary.MatchString(msg):
+			m := rsyncSummary.FindStringSubmatch(msg)
+			sent, _ := strconv.ParseInt(strings.ReplaceAll(m[1], ",", ""), 10, 64)
+			received, _ := strconv.ParseInt(strings.ReplaceAll(m[2], ",", ""), 10, 64)
+			run.bytes = max(sent, received)
+			seen[pid] = true
+		case strings.HasPrefix(msg, "rsync error:"):
+			run.failed = true
+			run.lastError = msg
+			seen[pid] = true
+		case strings.HasPrefix(msg, "rsync:"):
+			run.errors++
+			run.lastError = msg
+			seen[pid] = true
+		}
+	}
+
+	var entries []models.HistoryEntry
+	for _, pid := range order {
+		if seen[pid] {
+			entries = append(entries, runs[pid].entry("rsync", options))
+		}
+	}
+	if untimed && seen[""] && options.RunTime == nil {
+		warnings = append(warnings, "The log has no timestamps; its run was recorded at the time of import")
+	}
+	return entries, warnings
+}
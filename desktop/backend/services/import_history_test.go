@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testRcloneJSONLog = `{"time":"2025-06-01T02:00:00.000000+00:00","level":"info","msg":"Copied (new)","object":"a.jpg","objectType":"*local.Object"}
+{"time":"2025-06-01T02:00:03.000000+00:00","level":"info","msg":"Copied (replaced existing)","object":"b.jpg","objectType":"*local.Object"}
+{"time":"2025-06-01T02:00:05.000000+00:00","level":"info","msg":"\nTransferred: 3 MiB","stats":{"bytes":3145728,"transfers":2,"errors":0,"elapsedTime":5.1}}
+not a json line
+{"time":"2025-06-02T02:00:00.000000+00:00","level":"error","msg":"Failed to copy: permission denied","object":"c.jpg","objectType":"*local.Object"}
+{"time":"2025-06-02T02:00:02.000000+00:00","level":"info","msg":"\nTransferred: 0 B","stats":{"bytes":0,"transfers":0,"errors":1,"elapsedTime":2.0,"lastError":"permission denied"}}
+`
+
+const testRsyncLog = `2025/06/01 02:00:00 [4242] building file list
+2025/06/01 02:00:01 [4242] >f+++++++++ photos/a.jpg
+2025/06/01 02:00:02 [4242] >f.st...... photos/b.jpg
+2025/06/01 02:00:02 [4242] cd+++++++++ photos/new/
+2025/06/01 02:00:03 [4242] *deleting   photos/old.jpg
+2025/06/01 02:00:04 [4242] sent 1,024 bytes  received 5,000,000 bytes  total size 9,000,000
+2025/06/02 02:00:00 [4300] building file list
+2025/06/02 02:00:01 [4300] rsync: send_files failed to open "photos/c.jpg": Permission denied (13)
+2025/06/02 02:00:02 [4300] rsync error: some files/attrs were not transferred (see previous errors) (code 23)
+`
+
+func TestParseRcloneJSONLog(t *testing.T) {
+	runs, warnings := parseRcloneJSONLog([]byte(testRcloneJSONLog), HistoryImportOptions{ProfileName: "photos", Action: "push"})
+	if len(runs) != 2 {
+		t.Fatalf("got %d runs, want 2", len(runs))
+	}
+	if runs[0].Status != "completed" || runs[0].FilesTransferred != 2 || runs[0].BytesTransferred != 3145728 {
+		t.Errorf("first run = %+v", runs[0])
+	}
+	if runs[0].Source != "rclone" || runs[0].Duration != "5s" {
+		t.Errorf("first run source %q, duration %q", runs[0].Source, runs[0].Duration)
+	}
+	if runs[1].Status != "failed" || runs[1].Errors != 1 || runs[1].ErrorMessage != "permission denied" {
+		t.Errorf("second run = %+v", runs[1])
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "1 lines") {
+		t.Errorf("warnings = %v", warnings)
+	}
+}
+
+func TestParseRsyncLog(t *testing.T) {
+	runs, warnings := parseRsyncLog([]byte(testRsyncLog), HistoryImportOptions{ProfileName: "photos", Action: "push"})
+	if len(runs) != 2 {
+		t.Fatalf("got %d runs, want 2", len(runs))
+	}
+	if runs[0].Status != "completed" || runs[0].FilesTransferred != 2 || runs[0].BytesTransferred != 5000000 {
+		t.Errorf("first run = %+v", runs[0])
+	}
+	if runs[1].Status != "failed" || runs[1].Errors != 1 || !strings.HasPrefix(runs[1].ErrorMessage, "rsync error:") {
+		t.Errorf("second run = %+v", runs[1])
+	}
+	if len(warnings) != 0 {
+		t.Errorf("warnings = %v", warnings)
+	}
+}
+
+func TestParseRsyncLog_NoTimestamps(t *testing.T) {
+	runTime := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	itemized := ">f+++++++++ a.txt\n>f+++++++++ b.txt\n.d..t...... ./\n"
+	runs, _ := parseRsyncLog([]byte(itemized), HistoryImportOptions{ProfileName: "docs", Action: "push", RunTime: &runTime})
+	if len(runs) != 1 || runs[0].FilesTransferred != 2 || !runs[0].StartTime.Equal(runTime) {
+		t.Fatalf("runs = %+v", runs)
+	}
+}
+
+func TestDetectHistoryImportFormat(t *testing.T) {
+	if got := detectHistoryImportFormat([]byte(testRcloneJSONLog)); got != HistoryImportRcloneJSON {
+		t.Errorf("rclone log detected as %q", got)
+	}
+	if got := detectHistoryImportFormat([]byte(testRsyncLog)); got != HistoryImportRsync {
+		t.Errorf("rsync log detected as %q", got)
+	}
+}
+
+func TestImportHistoryFromBytes(t *testing.T) {
+	h := newTestHistoryService(t)
+	i := NewImportService(nil)
+	i.SetHistoryService(h)
+	ctx := context.Background()
+	options := HistoryImportOptions{ProfileName: "photos"}
+
+	result, err := i.ImportHistoryFromBytes(ctx, []byte(testRsyncLog), options)
+	if err != nil {
+		t.Fatalf("ImportHistoryFromBytes: %v", err)
+	}
+	if result.Format != HistoryImportRsync || result.Added != 2 || result.Skipped != 0 {
+		t.Errorf("result = %+v", result)
+	}
+
+	entries, err := h.GetHistoryForProfile(ctx, "photos")
+	if err != nil {
+		t.Fatalf("GetHistoryForProfile: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Source != "rsync" || entries[0].Action != "push" {
+		t.Fatalf("entries = %+v", entries)
+	}
+
+	result, err = i.ImportHistoryFromBytes(ctx, []byte(testRsyncLog), options)
+	if err != nil {
+		t.Fatalf("second import: %v", err)
+	}
+	if result.Added != 0 || result.Skipped != 2 {
+		t.Errorf("second import added %d, skipped %d; want 0, 2", result.Added, result.Skipped)
+	}
+}
+
+func TestImportHistoryFromBytes_Errors(t *testing.T) {
+	i := NewImportService(nil)
+	ctx := context.Background()
+	if _, err := i.ImportHistoryFromBytes(ctx, []byte(testRsyncLog), HistoryImportOptions{}); err == nil {
+		t.Error("expected an error without a profile name")
+	}
+	if _, err := i.ImportHistoryFromBytes(ctx, []byte(testRsyncLog), HistoryImportOptions{ProfileName: "p", Format: "robocopy"}); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	if _, err := i.ImportHistoryFromBytes(ctx, []byte("building file list\n"), HistoryImportOptions{ProfileName: "p"}); err == nil {
+		t.Error("expected an error for a log without runs")
+	}
+}
@@ -0,0 +1,340 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"desktop/backend/models"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Profile import formats
+const (
+	ProfileImportCSV  = "csv"
+	ProfileImportJSON = "json"
+)
+
+// ProfileImportDefinition is one folder pair in a bulk profile import: a
+// JSON array entry, or a CSV row with the same names as header columns
+// ("from" and "to" are accepted for source and destination). In CSV, filter
+// rules are separated by semicolons.
+type ProfileImportDefinition struct {
+	Name        string   `json:"name,omitempty"`     // default: the source folder's name
+	Source      string   `json:"source"`             // profile From
+	Destination string   `json:"destination"`        // profile To
+	Schedule    string   `json:"schedule,omitempty"` // cron expression; adds an enabled schedule
+	Action      string   `json:"action,omitempty"`   // action the schedule runs: "pull", "push" (default), "bi" or "bi-resync"
+	Include     []string `json:"include,omitempty"`
+	Exclude     []string `json:"exclude,omitempty"`
+}
+
+// ProfileImportOptions configures a bulk profile import
+type ProfileImportOptions struct {
+	Format string `json:"format,omitempty"`  // "csv" or "json"; empty detects it from the file
+	DryRun bool   `json:"dry_run,omitempty"` // validate and return what would be created without creating it
+}
+
+// ProfileImportRow is the outcome of one definition
+type ProfileImportRow struct {
+	Row      int                   `json:"row"` // CSV line or 1-based JSON array index
+	Name     string                `json:"name"`
+	Profile  *models.Profile       `json:"profile,omitempty"`  // the profile created, or that would be
+	Schedule *models.ScheduleEntry `json:"schedule,omitempty"` // its schedule, when the row has one
+	Created  bool                  `json:"created"`
+	Errors   []string              `json:"errors"` // why the row was skipped, or its schedule failed
+}
+
+// ProfileImportResult shows the outcome of each definition in a bulk profile import
+type ProfileImportResult struct {
+	Format  string             `json:"format"`
+	DryRun  bool               `json:"dry_run"`
+	Rows    []ProfileImportRow `json:"rows"`
+	Created int                `json:"created"`
+	Failed  int                `json:"failed"` // rows with errors
+}
+
+// profileImportColumns maps CSV header names to definition fields
+var profileImportColumns = map[string]string{
+	"name":        "name",
+	"source":      "source",
+	"from":        "source",
+	"destination": "destination",
+	"to":          "destination",
+	"schedule":    "schedule",
+	"action":      "action",
+	"include":     "include",
+	"exclude":     "exclude",
+}
+
+// ImportProfilesFromFile creates profiles from a CSV or JSON file
+func (i *ImportService) ImportProfilesFromFile(ctx context.Context, filePath string, options ProfileImportOptions) (*ProfileImportResult, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return i.ImportProfilesFromBytes(ctx, data, options)
+}
+
+// ImportProfilesFromBytes creates a profile, and optionally a schedule, for
+// each definition in a CSV or JSON file. Every row is validated on its own:
+// rows with errors are reported and skipped, the others are created. With
+// DryRun nothing is created, so the result previews the import.
+func (i *ImportService) ImportProfilesFromBytes(ctx context.Context, data []byte, options ProfileImportOptions) (*ProfileImportResult, error) {
+	if i.configService == nil {
+		return nil, fmt.Errorf("config service not available")
+	}
+	if options.Format == "" {
+		options.Format = detectProfileImportFormat(data)
+	}
+
+	var defs []ProfileImportDefinition
+	var rowNumbers []int
+	var err error
+	switch options.Format {
+	case ProfileImportCSV:
+		defs, rowNumbers, err = parseProfileImportCSV(data)
+	case ProfileImportJSON:
+		defs, err = parseProfileImportJSON(data)
+		for n := range defs {
+			rowNumbers = append(rowNumbers, n+1)
+		}
+	default:
+		return nil, fmt.Errorf("unknown profile import format %q: expected csv or json", options.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(defs) == 0 {
+		return nil, fmt.Errorf("no profiles found in the %s file", options.Format)
+	}
+
+	existing, err := i.configService.GetProfiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, p := range existing {
+		taken[p.Name] = true
+	}
+
+	result := &ProfileImportResult{Format: options.Format, DryRun: options.DryRun, Rows: []ProfileImportRow{}}
+	for n, def := range defs {
+		row := i.prepareProfileImport(def, taken)
+		row.Row = rowNumbers[n]
+		if len(row.Errors) == 0 {
+			taken[row.Name] = true
+			if !options.DryRun {
+				i.createProfileImport(ctx, &row)
+			}
+		}
+		if row.Created {
+			result.Created++
+		}
+		if len(row.Errors) > 0 {
+			result.Failed++
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	if !options.DryRun {
+		log.Printf("ImportService: Imported %d of %d profiles from %s (%d with errors)",
+			result.Created, len(defs), options.Format, result.Failed)
+	}
+	return result, nil
+}
+
+// prepareProfileImport builds and validates the profile and schedule of a
+// definition; taken holds the profile names already in use
+func (i *ImportService) prepareProfileImport(def ProfileImportDefinition, taken map[string]bool) ProfileImportRow {
+	name := strings.TrimSpace(def.Name)
+	if name == "" {
+		name = profileImportName(def.Source)
+	}
+	row := ProfileImportRow{Name: name, Errors: []string{}}
+
+	profile := models.Profile{
+		Name:          name,
+		From:          strings.TrimSpace(def.Source),
+		To:            strings.TrimSpace(def.Destination),
+		IncludedPaths: def.Include,
+		ExcludedPaths: def.Exclude,
+	}
+	if err := i.configService.validateProfile(profile); err != nil {
+		row.Errors = append(row.Errors, err.Error())
+	}
+	if taken[name] {
+		row.Errors = append(row.Errors, fmt.Sprintf("profile with name '%s' already exists", name))
+	}
+	row.Profile = &profile
+
+	action := strings.TrimSpace(def.Action)
+	if def.Schedule == "" {
+		if action != "" {
+			row.Errors = append(row.Errors, "action is only used with a schedule")
+		}
+		return row
+	}
+	if action == "" {
+		action = string(ActionPush)
+	}
+	entry := models.ScheduleEntry{
+		Id:          "schedule-" + uuid.New().String(),
+		ProfileName: name,
+		Action:      action,
+		CronExpr:    strings.TrimSpace(def.Schedule),
+		Enabled:     true,
+	}
+	switch SyncAction(action) {
+	case ActionPull, ActionPush, ActionBi, ActionBiResync:
+	default:
+		row.Errors = append(row.Errors, fmt.Sprintf("invalid action %q: expected pull, push, bi or bi-resync", action))
+	}
+	if err := validateScheduleEntry(entry); err != nil {
+		row.Errors = append(row.Errors, err.Error())
+	}
+	row.Schedule = &entry
+	return row
+}
+
+// createProfileImport adds a validated row's profile and schedule
+func (i *ImportService) createProfileImport(ctx context.Context, row *ProfileImportRow) {
+	if err := i.configService.AddProfile(ctx, *row.Profile); err != nil {
+		row.Errors = append(row.Errors, err.Error())
+		return
+	}
+	row.Created = true
+	if row.Schedule == nil {
+		return
+	}
+	if i.schedulerService == nil {
+		row.Errors = append(row.Errors, "profile created without its schedule: scheduler service not available")
+		return
+	}
+	row.Schedule.CreatedAt = time.Now()
+	if err := i.schedulerService.AddSchedule(ctx, *row.Schedule); err != nil {
+		row.Errors = append(row.Errors, fmt.Sprintf("profile created without its schedule: %v", err))
+	}
+}
+
+// profileImportName names a profile after the last folder of its source
+func profileImportName(source string) string {
+	source = strings.TrimRight(strings.TrimSpace(source), `/\`)
+	if i := strings.LastIndexAny(source, `/\:`); i >= 0 {
+		source = source[i+1:]
+	}
+	return source
+}
+
+// detectProfileImportFormat tells JSON from CSV by the first character
+func detectProfileImportFormat(data []byte) string {
+	trimmed := bytes.TrimLeft(data, " \t\r\n\ufeff")
+	if len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+		return ProfileImportJSON
+	}
+	return ProfileImportCSV
+}
+
+// parseProfileImportJSON reads a JSON array of definitions
+func parseProfileImportJSON(data []byte) ([]ProfileImportDefinition, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var defs []ProfileImportDefinition
+	if err := dec.Decode(&defs); err != nil {
+		return nil, fmt.Errorf("invalid JSON: expected an array of profile definitions: %w", err)
+	}
+	return defs, nil
+}
+
+// parseProfileImportCSV reads definitions from a CSV file with a header
+// row, returning the line each definition starts on
+func parseProfileImportCSV(data []byte) ([]ProfileImportDefinition, []int, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	r.TrimLeadingSpace = true
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	columns := make([]string, len(header))
+	seen := make(map[string]bool)
+	for n, h := range header {
+		field, ok := profileImportColumns[strings.ToLower(strings.TrimSpace(h))]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown CSV column %q: expected name, source, destination, schedule, action, include or exclude", h)
+		}
+		if seen[field] {
+			return nil, nil, fmt.Errorf("CSV column %q appears twice", h)
+		}
+		seen[field] = true
+		columns[n] = field
+	}
+	if !seen["source"] || !seen["destination"] {
+		return nil, nil, fmt.Errorf("CSV header must have source and destination columns")
+	}
+
+	var defs []ProfileImportDefinition
+	var lines []int
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		line, _ := r.FieldPos(0)
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		if len(record) > len(columns) {
+			return nil, nil, fmt.Errorf("CSV line %d has %d fields, the header has %d", line, len(record), len(columns))
+		}
+		var def ProfileImportDefinition
+		for n, value := range record {
+			value = strings.TrimSpace(value)
+			switch columns[n] {
+			case "name":
+				def.Name = value
+			case "source":
+				def.Source = value
+			case "destination":
+				def.Destination = value
+			case "schedule":
+				def.Schedule = value
+			case "action":
+				def.Action = value
+			case "include":
+				def.Include = splitProfileImportRules(value)
+			case "exclude":
+				def.Exclude = splitProfileImportRules(value)
+			}
+		}
+		defs = append(defs, def)
+		lines = append(lines, line)
+	}
+	return defs, lines, nil
+}
+
+// splitProfileImportRules splits semicolon-separated filter rules
+func splitProfileImportRules(value string) []string {
+	var rules []string
+	for _, rule := range strings.Split(value, ";") {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+const testProfileImportCSV = "\ufeffName,From,To,Schedule,Exclude\n" +
+	"docs,/home/user/docs,gdrive:docs,0 2 * * *,*.tmp; ~$*\n" +
+	"\n" +
+	",/home/user/photos/,gdrive:photos,,\n"
+
+func TestParseProfileImportCSV(t *testing.T) {
+	defs, lines, err := parseProfileImportCSV([]byte(testProfileImportCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(defs) != 2 || lines[0] != 2 || lines[1] != 4 {
+		t.Fatalf("got %d definitions on lines %v, want 2 on lines 2 and 4", len(defs), lines)
+	}
+	if defs[0].Source != "/home/user/docs" || defs[0].Destination != "gdrive:docs" || defs[0].Schedule != "0 2 * * *" {
+		t.Errorf("first definition = %+v", defs[0])
+	}
+	if len(defs[0].Exclude) != 2 || defs[0].Exclude[1] != "~$*" {
+		t.Errorf("excludes = %q", defs[0].Exclude)
+	}
+
+	if _, _, err := parseProfileImportCSV([]byte("source,target\n/a,gdrive:a\n")); err == nil || !strings.Contains(err.Error(), "target") {
+		t.Errorf("expected an unknown column error, got %v", err)
+	}
+	if _, _, err := parseProfileImportCSV([]byte("name,source\ndocs,/a\n")); err == nil {
+		t.Error("expected a header without a destination column to be refused")
+	}
+}
+
+func TestParseProfileImportJSON(t *testing.T) {
+	defs, err := parseProfileImportJSON([]byte(`[{"source": "/a", "destination": "gdrive:a", "include": ["/reports/**"]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(defs) != 1 || len(defs[0].Include) != 1 {
+		t.Errorf("definitions = %+v", defs)
+	}
+	if _, err := parseProfileImportJSON([]byte(`[{"source": "/a", "destinaton": "gdrive:a"}]`)); err == nil {
+		t.Error("expected a misspelled field to be refused")
+	}
+}
+
+func TestDetectProfileImportFormat(t *testing.T) {
+	if got := detectProfileImportFormat([]byte("\n  [{}]")); got != ProfileImportJSON {
+		t.Errorf("JSON detected as %q", got)
+	}
+	if got := detectProfileImportFormat([]byte(testProfileImportCSV)); got != ProfileImportCSV {
+		t.Errorf("CSV detected as %q", got)
+	}
+}
+
+func TestProfileImportName(t *testing.T) {
+	for source, want := range map[string]string{
+		"/home/user/photos/": "photos",
+		`C:\Users\me\Docs`:   "Docs",
+		"gdrive:Backups":     "Backups",
+		"gdrive:":            "",
+	} {
+		if got := profileImportName(source); got != want {
+			t.Errorf("profileImportName(%q) = %q, want %q", source, got, want)
+		}
+	}
+}
+
+func TestImportProfilesDryRun(t *testing.T) {
+	ctx := context.Background()
+	i := NewImportService(nil)
+	i.SetConfigService(NewConfigService(nil))
+
+	data := `[
+		{"name": "import-docs", "source": "/home/user/docs", "destination": "gdrive:docs", "schedule": "0 2 * * *"},
+		{"name": "import-docs", "source": "/home/user/other", "destination": "gdrive:other"},
+		{"name": "import-bad", "source": "/home/user/bad", "destination": "gdrive:bad", "schedule": "daily"}
+	]`
+	result, err := i.ImportProfilesFromBytes(ctx, []byte(data), ProfileImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Format != ProfileImportJSON || result.Created != 0 || result.Failed != 2 {
+		t.Fatalf("result = %+v", result)
+	}
+	if first := result.Rows[0]; len(first.Errors) != 0 || first.Schedule == nil || first.Schedule.Action != "push" {
+		t.Errorf("first row = %+v", first)
+	}
+	if second := result.Rows[1]; len(second.Errors) != 1 || !strings.Contains(second.Errors[0], "already exists") {
+		t.Errorf("expected the repeated name to be refused, got %v", second.Errors)
+	}
+	if third := result.Rows[2]; len(third.Errors) != 1 || !strings.Contains(third.Errors[0], "cron") {
+		t.Errorf("expected the bad schedule to be refused, got %v", third.Errors)
+	}
+
+	profiles, err := i.configService.GetProfiles(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range profiles {
+		if p.Name == "import-docs" {
+			t.Error("a dry run created a profile")
+		}
+	}
+}
@@ -20,8 +20,13 @@ import (
 
 // ImportService handles importing configuration data
 type ImportService struct {
-	app   *application.App
-	mutex sync.RWMutex
+	app            *application.App
+	mutex          sync.RWMutex
+	historyService *HistoryService
+
+	// Services bulk profile imports create profiles and schedules through
+	configService    *ConfigService
+	schedulerService *SchedulerService
 }
 
 // ImportOptions configures how to import
@@ -66,10 +71,12 @@ type ImportResult struct {
 
 // parsedExport holds parsed export data
 type parsedExport struct {
-	manifest *ExportManifest
-	boards   []models.Board
-	remotes  []RemoteExport
-	flags    uint32
+	manifest  *ExportManifest
+	boards    []models.Board
+	remotes   []RemoteExport
+	profiles  []models.Profile       // settings sync archives only
+	schedules []models.ScheduleEntry // settings sync archives only
+	flags     uint32
 }
 
 // NewImportService creates a new import service
@@ -84,6 +91,21 @@ func (i *ImportService) SetApp(app *application.App) {
 	i.app = app
 }
 
+// SetHistoryService sets the history service that imported runs are saved to
+func (i *ImportService) SetHistoryService(historyService *HistoryService) {
+	i.historyService = historyService
+}
+
+// SetConfigService sets the config service that imported profiles are added to
+func (i *ImportService) SetConfigService(configService *ConfigService) {
+	i.configService = configService
+}
+
+// SetSchedulerService sets the scheduler service that imported schedules are added to
+func (i *ImportService) SetSchedulerService(schedulerService *SchedulerService) {
+	i.schedulerService = schedulerService
+}
+
 // ServiceName returns the name of the service
 func (i *ImportService) ServiceName() string {
 	return "ImportService"
@@ -140,7 +162,7 @@ func (i *ImportService) validateImportData(ctx context.Context, data []byte, pas
 		return preview, nil
 	}
 
-	parsed, err := i.parseExportData(data, password)
+	parsed, err := parseExportData(data, password)
 	if err != nil {
 		preview.Errors = append(preview.Errors, fmt.Sprintf("Invalid file format: %v", err))
 		return preview, nil
@@ -188,7 +210,7 @@ func (i *ImportService) ImportFromBytes(ctx context.Context, data []byte, option
 		Errors:   []string{},
 	}
 
-	parsed, err := i.parseExportData(data, options.Password)
+	parsed, err := parseExportData(data, options.Password)
 	if err != nil {
 		return nil, fmt.Errorf("invalid file format: %w", err)
 	}
@@ -291,7 +313,7 @@ func isExportEncrypted(data []byte) (bool, error) {
 }
 
 // parseExportData parses binary export data, decrypting if needed
-func (i *ImportService) parseExportData(data []byte, password string) (*parsedExport, error) {
+func parseExportData(data []byte, password string) (*parsedExport, error) {
 	if len(data) < 32 {
 		return nil, fmt.Errorf("file too small")
 	}
@@ -424,6 +446,20 @@ func (i *ImportService) parseExportData(data []byte, password string) (*parsedEx
 			}
 			parsed.manifest = &manifest
 
+		case SectionProfiles:
+			profiles, err := decodeProfileList(jsonData, "the settings sync archive")
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse profiles: %w", err)
+			}
+			parsed.profiles = profiles
+
+		case SectionSchedules:
+			var schedules []models.ScheduleEntry
+			if err := json.Unmarshal(jsonData, &schedules); err != nil {
+				return nil, fmt.Errorf("failed to parse schedules: %w", err)
+			}
+			parsed.schedules = schedules
+
 		case SectionSettings:
 			// Future use
 		}
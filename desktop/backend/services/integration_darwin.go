@@ -0,0 +1,166 @@
+//go:build darwin
+
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The Finder entry is a Quick Action (Automator service) that opens a
+// gn-drive://sync-folder URL for each selected folder. The URL scheme itself
+// is declared in the app's Info.plist.
+
+// quickActionDir returns the path of the Quick Action bundle
+func quickActionDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "Library", "Services", integrationMenuLabel+".workflow"), nil
+}
+
+const quickActionScript = `for f in "$@"; do
+  enc=$(/usr/bin/osascript -l JavaScript -e 'function run(a){return encodeURIComponent(a[0])}' "$f")
+  /usr/bin/open "` + IntegrationURLScheme + `://sync-folder?path=$enc"
+done`
+
+const quickActionInfoPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>NSServices</key>
+	<array>
+		<dict>
+			<key>NSMenuItem</key>
+			<dict>
+				<key>default</key>
+				<string>` + integrationMenuLabel + `</string>
+			</dict>
+			<key>NSMessage</key>
+			<string>runWorkflowAsService</string>
+			<key>NSRequiredContext</key>
+			<dict>
+				<key>NSApplicationIdentifier</key>
+				<string>com.apple.finder</string>
+			</dict>
+			<key>NSSendFileTypes</key>
+			<array>
+				<string>public.folder</string>
+			</array>
+		</dict>
+	</array>
+</dict>
+</plist>
+`
+
+const quickActionDocument = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>AMApplicationBuild</key>
+	<string>521</string>
+	<key>AMApplicationVersion</key>
+	<string>2.10</string>
+	<key>AMDocumentVersion</key>
+	<string>2</string>
+	<key>actions</key>
+	<array>
+		<dict>
+			<key>action</key>
+			<dict>
+				<key>AMAccepts</key>
+				<dict>
+					<key>Container</key>
+					<string>List</string>
+					<key>Optional</key>
+					<true/>
+					<key>Types</key>
+					<array>
+						<string>com.apple.cocoa.string</string>
+					</array>
+				</dict>
+				<key>AMActionVersion</key>
+				<string>2.0.3</string>
+				<key>AMParameterProperties</key>
+				<dict>
+					<key>COMMAND_STRING</key>
+					<dict/>
+					<key>inputMethod</key>
+					<dict/>
+					<key>shell</key>
+					<dict/>
+				</dict>
+				<key>ActionBundlePath</key>
+				<string>/System/Library/Automator/Run Shell Script.action</string>
+				<key>ActionName</key>
+				<string>Run Shell Script</string>
+				<key>ActionParameters</key>
+				<dict>
+					<key>COMMAND_STRING</key>
+					<string>%s</string>
+					<key>inputMethod</key>
+					<integer>1</integer>
+					<key>shell</key>
+					<string>/bin/sh</string>
+				</dict>
+				<key>BundleIdentifier</key>
+				<string>com.apple.RunShellScript</string>
+				<key>CFBundleVersion</key>
+				<string>2.0.3</string>
+				<key>Class Name</key>
+				<string>RunShellScriptAction</string>
+			</dict>
+		</dict>
+	</array>
+	<key>workflowMetaData</key>
+	<dict>
+		<key>serviceInputTypeIdentifier</key>
+		<string>com.apple.Automator.fileSystemObject.folder</string>
+		<key>serviceApplicationBundleID</key>
+		<string>com.apple.finder</string>
+		<key>workflowTypeIdentifier</key>
+		<string>com.apple.Automator.servicesMenu</string>
+	</dict>
+</dict>
+</plist>
+`
+
+func registerShellIntegration(exe string) error {
+	dir, err := quickActionDir()
+	if err != nil {
+		return err
+	}
+	contents := filepath.Join(dir, "Contents")
+	if err := os.MkdirAll(contents, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(contents, "Info.plist"), []byte(quickActionInfoPlist), 0644); err != nil {
+		return err
+	}
+	document := fmt.Sprintf(quickActionDocument, xmlEscape(quickActionScript))
+	return os.WriteFile(filepath.Join(contents, "document.wflow"), []byte(document), 0644)
+}
+
+func unregisterShellIntegration() error {
+	dir, err := quickActionDir()
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+func isShellIntegrationRegistered() bool {
+	dir, err := quickActionDir()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(dir, "Contents", "document.wflow"))
+	return err == nil
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,61 @@
+//go:build !windows && !darwin
+
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// On Linux the context menu entry is a Nautilus script, which shows up under
+// "Scripts" when right-clicking a folder.
+
+// nautilusScriptPath returns the path of the Nautilus script
+func nautilusScriptPath() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "nautilus", "scripts", integrationMenuLabel), nil
+}
+
+func registerShellIntegration(exe string) error {
+	path, err := nautilusScriptPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	script := fmt.Sprintf(`#!/bin/sh
+printf '%%s\n' "$NAUTILUS_SCRIPT_SELECTED_FILE_PATHS" | while IFS= read -r f; do
+  [ -n "$f" ] && %s %s "$f" &
+done
+`, shellQuote(exe), SyncFolderFlag)
+	return os.WriteFile(path, []byte(script), 0755)
+}
+
+func unregisterShellIntegration() error {
+	path, err := nautilusScriptPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func isShellIntegrationRegistered() bool {
+	path, err := nautilusScriptPath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,509 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/events"
+	"desktop/backend/models"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// IntegrationURLScheme is the custom URL scheme the app registers with the OS
+const IntegrationURLScheme = "gn-drive"
+
+// SyncFolderFlag is the command-line flag the shell context menu launches the app with
+const SyncFolderFlag = "--sync-folder"
+
+// RunBoardFlag is the command-line flag that runs a board by id or name
+const RunBoardFlag = "--run-board"
+
+// RunFlowFlag is the command-line flag that runs a flow by id or name
+const RunFlowFlag = "--run-flow"
+
+// OpenURLFlag is the command-line flag the URL scheme launches the app with
+// on Windows. The URL after it is the only argument read.
+const OpenURLFlag = "--open-url"
+
+// integrationMenuLabel is the label shown in Finder/Explorer/Nautilus context menus
+const integrationMenuLabel = "Sync with gn-drive"
+
+// maxFolderRequests caps how many unanswered folder requests are kept
+const maxFolderRequests = 20
+
+// FolderRequest is a local folder handed to the app by the OS context menu,
+// waiting for the user to create or run a profile for it
+type FolderRequest struct {
+	Id               string    `json:"id"`
+	Folder           string    `json:"folder"`
+	MatchingProfiles []string  `json:"matching_profiles"` // profiles whose source is this folder
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// IntegrationService connects the app to the OS shell: it registers the
+// "Sync this folder" context menu entry and handles the folders it passes in
+// via the URL scheme or command line.
+type IntegrationService struct {
+	app      *application.App
+	eventBus *events.WailsEventBus
+	mutex    sync.RWMutex
+	requests []FolderRequest
+
+	// Dependencies
+	configService *ConfigService
+	syncService   *SyncService
+}
+
+// NewIntegrationService creates a new integration service
+func NewIntegrationService(app *application.App) *IntegrationService {
+	return &IntegrationService{
+		app:      app,
+		requests: []FolderRequest{},
+	}
+}
+
+// SetApp sets the application reference for events
+func (i *IntegrationService) SetApp(app *application.App) {
+	i.app = app
+	if bus := GetSharedEventBus(); bus != nil {
+		i.eventBus = bus
+	} else {
+		i.eventBus = events.NewEventBus(app)
+	}
+}
+
+// SetConfigService sets the config service used to look up and create profiles
+func (i *IntegrationService) SetConfigService(configService *ConfigService) {
+	i.configService = configService
+}
+
+// SetSyncService sets the sync service used to run profiles
+func (i *IntegrationService) SetSyncService(syncService *SyncService) {
+	i.syncService = syncService
+}
+
+// ServiceName returns the name of the service
+func (i *IntegrationService) ServiceName() string {
+	return "IntegrationService"
+}
+
+// ServiceStartup is called when the service starts
+func (i *IntegrationService) ServiceStartup(ctx context.Context, options application.ServiceOptions) error {
+	log.Printf("IntegrationService starting up...")
+	return nil
+}
+
+// ServiceShutdown is called when the service shuts down
+func (i *IntegrationService) ServiceShutdown(ctx context.Context) error {
+	log.Printf("IntegrationService shutting down...")
+	return nil
+}
+
+// RegisterShellIntegration adds the "Sync with gn-drive" entry to the OS
+// file manager's folder context menu for the current user.
+func (i *IntegrationService) RegisterShellIntegration(ctx context.Context) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate executable: %w", err)
+	}
+	if err := registerShellIntegration(exe); err != nil {
+		return fmt.Errorf("failed to register shell integration: %w", err)
+	}
+	log.Printf("IntegrationService: Registered shell integration for %s", exe)
+	return nil
+}
+
+// UnregisterShellIntegration removes the context menu entry
+func (i *IntegrationService) UnregisterShellIntegration(ctx context.Context) error {
+	if err := unregisterShellIntegration(); err != nil {
+		return fmt.Errorf("failed to unregister shell integration: %w", err)
+	}
+	log.Printf("IntegrationService: Unregistered shell integration")
+	return nil
+}
+
+// IsShellIntegrationRegistered returns whether the context menu entry is installed
+func (i *IntegrationService) IsShellIntegrationRegistered(ctx context.Context) bool {
+	return isShellIntegrationRegistered()
+}
+
+// HandleURL handles a gn-drive:// URL opened by the OS.
+// Currently supports gn-drive://sync-folder?path=<folder>.
+func (i *IntegrationService) HandleURL(rawURL string) error {
+	folder, err := parseIntegrationURL(rawURL)
+	if err != nil {
+		return err
+	}
+	_, err = i.HandleFolder(context.Background(), folder)
+	return err
+}
+
+// HandleArgs handles command-line arguments passed by the shell context menu
+// (--sync-folder <path>), by the OS when opening a gn-drive:// URL, or to run
+// a board or flow (--run-board <id|name>, --run-flow <id|name>, or
+// "run board <id|name>" / "run flow <id|name>").
+// Returns true if any argument was handled.
+func (i *IntegrationService) HandleArgs(args []string) bool {
+	return i.handleArgs(args, "")
+}
+
+// HandleSecondInstance handles the arguments of a second launch of the app,
+// which exited and handed them over. workingDir is the directory it was
+// started in, for relative folder paths. The main window is brought up
+// when there was nothing to run.
+func (i *IntegrationService) HandleSecondInstance(args []string, workingDir string) {
+	log.Printf("IntegrationService: App launched again with %v", args)
+	if i.handleArgs(args, workingDir) {
+		return
+	}
+	if tray := GetTrayService(); tray != nil {
+		tray.showWindow()
+	}
+}
+
+// handleArgs handles command-line arguments, taking relative folder paths
+// from workingDir ("" for this process's own)
+func (i *IntegrationService) handleArgs(args []string, workingDir string) bool {
+	handled := false
+	for _, cmd := range parseLaunchArgs(args) {
+		var err error
+		switch cmd.kind {
+		case SyncFolderFlag:
+			folder := cmd.value
+			if workingDir != "" && !filepath.IsAbs(folder) {
+				folder = filepath.Join(workingDir, folder)
+			}
+			_, err = i.HandleFolder(context.Background(), folder)
+		case IntegrationURLScheme:
+			err = i.HandleURL(cmd.value)
+		case RunBoardFlag:
+			err = i.runBoard(cmd.value)
+		case RunFlowFlag:
+			err = i.runFlow(cmd.value)
+		}
+		if err != nil {
+			log.Printf("IntegrationService: Failed to handle argument %s %q: %v", cmd.kind, cmd.value, err)
+		}
+		handled = true
+	}
+	return handled
+}
+
+// launchArg is a command found in the command-line arguments: kind is the
+// flag it was given with (the URL scheme for URLs) and value its argument
+type launchArg struct {
+	kind  string
+	value string
+}
+
+// parseLaunchArgs picks the commands the app handles out of command-line
+// arguments, ignoring others like --config-dir. A URL, after --open-url or
+// on its own, is handled alone: a crafted URL can break out of the quotes
+// of the launch command, so the arguments around it aren't trusted.
+func parseLaunchArgs(args []string) []launchArg {
+	var cmds []launchArg
+	for idx := 0; idx < len(args); idx++ {
+		arg := args[idx]
+		if arg == OpenURLFlag {
+			if idx+1 < len(args) && strings.HasPrefix(args[idx+1], IntegrationURLScheme+"://") {
+				return []launchArg{{kind: IntegrationURLScheme, value: args[idx+1]}}
+			}
+			return nil
+		}
+		if strings.HasPrefix(arg, IntegrationURLScheme+"://") {
+			return []launchArg{{kind: IntegrationURLScheme, value: arg}}
+		}
+		// "run board <id>" is the same as "--run-board <id>"
+		if arg == "run" && idx+2 < len(args) && (args[idx+1] == "board" || args[idx+1] == "flow") {
+			cmds = append(cmds, launchArg{kind: "--run-" + args[idx+1], value: args[idx+2]})
+			idx += 2
+			continue
+		}
+		for _, flag := range []string{SyncFolderFlag, RunBoardFlag, RunFlowFlag} {
+			if arg == flag && idx+1 < len(args) {
+				idx++
+				cmds = append(cmds, launchArg{kind: flag, value: args[idx]})
+				break
+			}
+			if value, ok := strings.CutPrefix(arg, flag+"="); ok {
+				cmds = append(cmds, launchArg{kind: flag, value: value})
+				break
+			}
+		}
+	}
+	return cmds
+}
+
+// runBoard starts the board with the given id or name
+func (i *IntegrationService) runBoard(ref string) error {
+	boardService := GetBoardService()
+	if boardService == nil {
+		return fmt.Errorf("board service not available")
+	}
+	boards, err := boardService.GetBoards(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, board := range boards {
+		if board.Id == ref || strings.EqualFold(board.Name, ref) {
+			log.Printf("IntegrationService: Running board %q from the command line", board.Name)
+			_, err := boardService.ExecuteBoard(context.Background(), board.Id)
+			return err
+		}
+	}
+	return fmt.Errorf("board '%s' not found", ref)
+}
+
+// runFlow runs the flow with the given id or name, like the tray menu does
+func (i *IntegrationService) runFlow(ref string) error {
+	flowService := GetFlowService()
+	if flowService == nil {
+		return fmt.Errorf("flow service not available")
+	}
+	flows, err := flowService.GetFlows(context.Background())
+	if err != nil {
+		return err
+	}
+	for _, flow := range flows {
+		if flow.Id == ref || strings.EqualFold(flow.Name, ref) {
+			log.Printf("IntegrationService: Running flow %q from the command line", flow.Name)
+			if tray := GetTrayService(); tray != nil {
+				tray.executeFlow(flow.Id)
+				return nil
+			}
+			go func() {
+				if err := flowService.RunFlow(context.Background(), flow.Id); err != nil {
+					log.Printf("IntegrationService: Flow %s failed: %v", flow.Id, err)
+				}
+			}()
+			return nil
+		}
+	}
+	return fmt.Errorf("flow '%s' not found", ref)
+}
+
+// HandleFolder records a folder request, emits an event so the frontend can
+// offer to create or run a profile for it, and brings the main window up.
+func (i *IntegrationService) HandleFolder(ctx context.Context, folder string) (*FolderRequest, error) {
+	folder, err := normalizeLocalFolder(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	var matching []string
+	if i.configService != nil {
+		profiles, err := i.configService.GetProfiles(ctx)
+		if err != nil {
+			log.Printf("IntegrationService: Failed to load profiles: %v", err)
+		}
+		matching = profilesForFolder(profiles, folder)
+	}
+
+	req := FolderRequest{
+		Id:               uuid.New().String(),
+		Folder:           folder,
+		MatchingProfiles: matching,
+		CreatedAt:        time.Now(),
+	}
+
+	i.mutex.Lock()
+	i.requests = append(i.requests, req)
+	if len(i.requests) > maxFolderRequests {
+		i.requests = i.requests[len(i.requests)-maxFolderRequests:]
+	}
+	i.mutex.Unlock()
+
+	log.Printf("IntegrationService: Folder requested %s (%d matching profiles)", folder, len(matching))
+	i.emitIntegrationEvent(events.IntegrationFolderRequested, req.Id, req)
+
+	if tray := GetTrayService(); tray != nil {
+		tray.showWindow()
+	}
+	return &req, nil
+}
+
+// GetPendingFolderRequests returns folder requests the user hasn't answered yet
+func (i *IntegrationService) GetPendingFolderRequests(ctx context.Context) []FolderRequest {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	result := make([]FolderRequest, len(i.requests))
+	copy(result, i.requests)
+	return result
+}
+
+// DismissFolderRequest drops a folder request without acting on it
+func (i *IntegrationService) DismissFolderRequest(ctx context.Context, requestId string) error {
+	_, err := i.takeRequest(requestId)
+	return err
+}
+
+// CreateProfileForFolder creates a profile with the requested folder as its
+// source. The destination and options come from the given profile.
+func (i *IntegrationService) CreateProfileForFolder(ctx context.Context, requestId string, profile models.Profile) error {
+	if i.configService == nil {
+		return fmt.Errorf("config service not available")
+	}
+
+	req, err := i.findRequest(requestId)
+	if err != nil {
+		return err
+	}
+
+	profile.From = req.Folder
+	if profile.Name == "" {
+		profile.Name = filepath.Base(req.Folder)
+	}
+	if err := i.configService.AddProfile(ctx, profile); err != nil {
+		return err
+	}
+
+	_, err = i.takeRequest(requestId)
+	return err
+}
+
+// RunProfileForFolder starts an existing profile for a folder request
+func (i *IntegrationService) RunProfileForFolder(ctx context.Context, requestId, profileName, action string) (*SyncResult, error) {
+	if i.configService == nil || i.syncService == nil {
+		return nil, fmt.Errorf("sync service not available")
+	}
+
+	if _, err := i.findRequest(requestId); err != nil {
+		return nil, err
+	}
+
+	profiles, err := i.configService.GetProfiles(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load profiles: %w", err)
+	}
+
+	var profile *models.Profile
+	for idx := range profiles {
+		if profiles[idx].Name == profileName {
+			profile = &profiles[idx]
+			break
+		}
+	}
+	if profile == nil {
+		return nil, fmt.Errorf("profile '%s' not found", profileName)
+	}
+
+	if action == "" {
+		action = string(ActionPush)
+	}
+	result, err := i.syncService.StartSync(ctx, action, *profile, "")
+	if err != nil {
+		return nil, err
+	}
+
+	i.takeRequest(requestId)
+	return result, nil
+}
+
+// findRequest returns a copy of a pending folder request
+func (i *IntegrationService) findRequest(requestId string) (FolderRequest, error) {
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	for _, req := range i.requests {
+		if req.Id == requestId {
+			return req, nil
+		}
+	}
+	return FolderRequest{}, fmt.Errorf("folder request '%s' not found", requestId)
+}
+
+// takeRequest removes and returns a pending folder request
+func (i *IntegrationService) takeRequest(requestId string) (FolderRequest, error) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	for idx, req := range i.requests {
+		if req.Id == requestId {
+			i.requests = append(i.requests[:idx], i.requests[idx+1:]...)
+			return req, nil
+		}
+	}
+	return FolderRequest{}, fmt.Errorf("folder request '%s' not found", requestId)
+}
+
+// parseIntegrationURL extracts the folder from a gn-drive://sync-folder?path=... URL
+func parseIntegrationURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != IntegrationURLScheme {
+		return "", fmt.Errorf("unsupported URL scheme '%s'", u.Scheme)
+	}
+
+	// gn-drive://sync-folder?path=... parses the command as the host;
+	// gn-drive:sync-folder?path=... as the opaque part
+	command := u.Host
+	if command == "" {
+		command = u.Opaque
+	}
+	if command == "" {
+		command = strings.Trim(u.Path, "/")
+	}
+	if command != "sync-folder" {
+		return "", fmt.Errorf("unsupported command '%s'", command)
+	}
+
+	folder := u.Query().Get("path")
+	if folder == "" {
+		return "", fmt.Errorf("missing folder path")
+	}
+	return folder, nil
+}
+
+// normalizeLocalFolder cleans a folder path and checks that it is an existing local directory
+func normalizeLocalFolder(folder string) (string, error) {
+	if folder == "" {
+		return "", fmt.Errorf("folder path is empty")
+	}
+	abs, err := filepath.Abs(folder)
+	if err != nil {
+		return "", fmt.Errorf("invalid folder path: %w", err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", fmt.Errorf("folder not accessible: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("'%s' is not a folder", abs)
+	}
+	return abs, nil
+}
+
+// profilesForFolder returns the names of profiles whose source is the given local folder
+func profilesForFolder(profiles []models.Profile, folder string) []string {
+	var names []string
+	for _, p := range profiles {
+		// Skip remote sources; a single-letter "remote" is a Windows drive
+		if p.From == "" || len(parseRemoteName(p.From)) > 1 {
+			continue
+		}
+		if filepath.Clean(p.From) == filepath.Clean(folder) {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// emitIntegrationEvent emits an integration event via the unified EventBus
+func (i *IntegrationService) emitIntegrationEvent(eventType events.EventType, requestId string, data interface{}) {
+	event := events.NewIntegrationEvent(eventType, requestId, data)
+	if i.eventBus != nil {
+		if err := i.eventBus.EmitIntegrationEvent(event); err != nil {
+			log.Printf("Failed to emit integration event: %v", err)
+		}
+	} else if i.app != nil {
+		i.app.Event.Emit("tofe", event)
+	}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseIntegrationURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{"gn-drive://sync-folder?path=%2FUsers%2Fme%2FPhotos", "/Users/me/Photos", false},
+		{"gn-drive://sync-folder?path=C%3A%5CData%20Files", `C:\Data Files`, false},
+		{"gn-drive:sync-folder?path=%2Ftmp", "/tmp", false},
+		{"gn-drive://sync-folder", "", true},
+		{"gn-drive://open-log?path=%2Ftmp", "", true},
+		{"https://sync-folder?path=%2Ftmp", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseIntegrationURL(tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseIntegrationURL(%q) error = %v, wantErr %v", tt.url, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseIntegrationURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestIntegrationService_HandleFolder(t *testing.T) {
+	ctx := context.Background()
+	svc := NewIntegrationService(nil)
+	dir := t.TempDir()
+
+	req, err := svc.HandleFolder(ctx, dir)
+	if err != nil {
+		t.Fatalf("HandleFolder failed: %v", err)
+	}
+	if req.Folder != dir {
+		t.Errorf("Folder = %q, want %q", req.Folder, dir)
+	}
+	if pending := svc.GetPendingFolderRequests(ctx); len(pending) != 1 {
+		t.Fatalf("Expected 1 pending request, got %d", len(pending))
+	}
+
+	if err := svc.DismissFolderRequest(ctx, req.Id); err != nil {
+		t.Fatalf("DismissFolderRequest failed: %v", err)
+	}
+	if pending := svc.GetPendingFolderRequests(ctx); len(pending) != 0 {
+		t.Errorf("Expected no pending requests, got %d", len(pending))
+	}
+
+	// Files and missing paths are rejected
+	file := dir + string(os.PathSeparator) + "file.txt"
+	if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.HandleFolder(ctx, file); err == nil {
+		t.Error("Expected error for a file path")
+	}
+	if _, err := svc.HandleFolder(ctx, dir+"/missing"); err == nil {
+		t.Error("Expected error for a missing folder")
+	}
+}
+
+func TestProfilesForFolder(t *testing.T) {
+	profiles := []models.Profile{
+		{Name: "photos", From: "/home/me/Photos/"},
+		{Name: "docs", From: "/home/me/Docs"},
+		{Name: "remote", From: "gdrive:/home/me/Photos"},
+	}
+
+	got := profilesForFolder(profiles, "/home/me/Photos")
+	if len(got) != 1 || got[0] != "photos" {
+		t.Errorf("profilesForFolder = %v, want [photos]", got)
+	}
+}
+
+func TestParseLaunchArgs(t *testing.T) {
+	args := []string{
+		"--config-dir", "/tmp/cfg",
+		"run", "board", "Nightly backup",
+		"--run-flow=photos",
+		"--sync-folder", "Documents",
+		"run", "profile", "x",
+	}
+	want := []launchArg{
+		{RunBoardFlag, "Nightly backup"},
+		{RunFlowFlag, "photos"},
+		{SyncFolderFlag, "Documents"},
+	}
+	if got := parseLaunchArgs(args); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseLaunchArgs = %+v, want %+v", got, want)
+	}
+	if got := parseLaunchArgs([]string{"--tray-only"}); len(got) != 0 {
+		t.Errorf("expected no commands, got %+v", got)
+	}
+
+	// A URL is handled alone, so one that breaks out of its quotes can't add commands
+	url := launchArg{IntegrationURLScheme, `gn-drive://sync-folder?path=%2Ftmp"`}
+	for _, tt := range []struct {
+		args []string
+		want []launchArg
+	}{
+		{[]string{OpenURLFlag, url.value, RunFlowFlag, "wipe"}, []launchArg{url}},
+		{[]string{url.value, "--sync-folder", "/"}, []launchArg{url}},
+		{[]string{OpenURLFlag, RunFlowFlag, "wipe"}, nil},
+		{[]string{OpenURLFlag}, nil},
+	} {
+		if got := parseLaunchArgs(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseLaunchArgs(%q) = %+v, want %+v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestIntegrationService_HandleSecondInstanceFolder(t *testing.T) {
+	ctx := context.Background()
+	svc := NewIntegrationService(nil)
+	dir := t.TempDir()
+	if err := os.Mkdir(dir+string(os.PathSeparator)+"Photos", 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Relative folders are taken from the second instance's working directory
+	svc.HandleSecondInstance([]string{SyncFolderFlag, "Photos"}, dir)
+	pending := svc.GetPendingFolderRequests(ctx)
+	if len(pending) != 1 || pending[0].Folder != dir+string(os.PathSeparator)+"Photos" {
+		t.Errorf("unexpected folder requests: %+v", pending)
+	}
+}
@@ -0,0 +1,74 @@
+//go:build windows
+
+package services
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+)
+
+// Per-user registry keys for the folder context menu and URL scheme
+const (
+	regFolderMenuKey     = `HKCU\Software\Classes\Directory\shell\GnDrive`
+	regBackgroundMenuKey = `HKCU\Software\Classes\Directory\Background\shell\GnDrive`
+	regURLSchemeKey      = `HKCU\Software\Classes\` + IntegrationURLScheme
+)
+
+func registerShellIntegration(exe string) error {
+	entries := []struct{ key, name, value string }{
+		{regFolderMenuKey, "", integrationMenuLabel},
+		{regFolderMenuKey, "Icon", exe},
+		{regFolderMenuKey + `\command`, "", fmt.Sprintf(`"%s" %s "%%1"`, exe, SyncFolderFlag)},
+		// Right-click on the background of an open folder
+		{regBackgroundMenuKey, "", integrationMenuLabel},
+		{regBackgroundMenuKey, "Icon", exe},
+		{regBackgroundMenuKey + `\command`, "", fmt.Sprintf(`"%s" %s "%%V"`, exe, SyncFolderFlag)},
+		// gn-drive:// URL scheme
+		{regURLSchemeKey, "", "URL:" + IntegrationURLScheme},
+		{regURLSchemeKey, "URL Protocol", ""},
+		{regURLSchemeKey + `\shell\open\command`, "", fmt.Sprintf(`"%s" %s "%%1"`, exe, OpenURLFlag)},
+	}
+	for _, e := range entries {
+		args := []string{"add", e.key, "/f", "/d", e.value}
+		if e.name == "" {
+			args = append(args, "/ve")
+		} else {
+			args = append(args, "/v", e.name)
+		}
+		if err := runReg(args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unregisterShellIntegration() error {
+	var firstErr error
+	for _, key := range []string{regFolderMenuKey, regBackgroundMenuKey, regURLSchemeKey} {
+		if !regKeyExists(key) {
+			continue
+		}
+		if err := runReg("delete", key, "/f"); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func isShellIntegrationRegistered() bool {
+	return regKeyExists(regFolderMenuKey + `\command`)
+}
+
+func regKeyExists(key string) bool {
+	return runReg("query", key) == nil
+}
+
+func runReg(args ...string) error {
+	cmd := exec.Command("reg", args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("reg %s: %w: %s", args[0], err, out)
+	}
+	return nil
+}
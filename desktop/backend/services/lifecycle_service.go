@@ -0,0 +1,548 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"desktop/backend/validation"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rclone/rclone/fs"
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// defaultLifecycleMaxFiles caps how many files a lifecycle rule moves per run
+// when the rule doesn't set its own cap
+const defaultLifecycleMaxFiles = 1000
+
+// lifecycleParallel is the number of parallel transfers of a lifecycle move
+const lifecycleParallel = 4
+
+// LifecycleService manages storage lifecycle rules: moving files older than a
+// given age to another remote with the move operation, and recording what was
+// moved so it can be found and brought back later.
+type LifecycleService struct {
+	app              *application.App
+	operationService *OperationService
+	runMutex         sync.Mutex // one lifecycle move at a time
+}
+
+// Singleton instance for cross-service access
+var lifecycleServiceInstance *LifecycleService
+var lifecycleServiceOnce sync.Once
+
+// GetLifecycleService returns the singleton LifecycleService instance
+func GetLifecycleService() *LifecycleService {
+	return lifecycleServiceInstance
+}
+
+// SetLifecycleServiceInstance sets the singleton instance (called from main.go)
+func SetLifecycleServiceInstance(ls *LifecycleService) {
+	lifecycleServiceOnce.Do(func() {
+		lifecycleServiceInstance = ls
+	})
+}
+
+// NewLifecycleService creates a new lifecycle service
+func NewLifecycleService(app *application.App) *LifecycleService {
+	return &LifecycleService{
+		app: app,
+	}
+}
+
+// SetApp sets the application reference
+func (l *LifecycleService) SetApp(app *application.App) {
+	l.app = app
+}
+
+// SetOperationService sets the operation service that runs the moves
+func (l *LifecycleService) SetOperationService(operationService *OperationService) {
+	l.operationService = operationService
+}
+
+// ServiceName returns the name of the service
+func (l *LifecycleService) ServiceName() string {
+	return "LifecycleService"
+}
+
+// GetLifecycleRules returns all lifecycle rules ordered by name
+func (l *LifecycleService) GetLifecycleRules(ctx context.Context) ([]models.LifecycleRule, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT id, name, source_path, target_path, min_age, included_paths, excluded_paths,
+		max_files, max_bytes, last_run, last_result, created_at
+		FROM lifecycle_rules ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lifecycle rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []models.LifecycleRule{}
+	for rows.Next() {
+		var r models.LifecycleRule
+		var included, excluded, createdAt string
+		var lastRun *string
+		if err := rows.Scan(&r.Id, &r.Name, &r.SourcePath, &r.TargetPath, &r.MinAge, &included, &excluded,
+			&r.MaxFiles, &r.MaxBytes, &lastRun, &r.LastResult, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan lifecycle rule: %w", err)
+		}
+		r.IncludedPaths = unmarshalStringSlice(included)
+		r.ExcludedPaths = unmarshalStringSlice(excluded)
+		if lastRun != nil {
+			if t, err := time.Parse(time.RFC3339, *lastRun); err == nil {
+				r.LastRun = &t
+			}
+		}
+		if t, err := time.Parse(time.RFC3339, createdAt); err == nil {
+			r.CreatedAt = t
+		}
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
+
+// GetLifecycleRule returns a single lifecycle rule
+func (l *LifecycleService) GetLifecycleRule(ctx context.Context, ruleId string) (*models.LifecycleRule, error) {
+	rules, err := l.GetLifecycleRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rules {
+		if rules[i].Id == ruleId {
+			return &rules[i], nil
+		}
+	}
+	return nil, fmt.Errorf("lifecycle rule '%s' not found", ruleId)
+}
+
+// SaveLifecycleRule creates or updates a lifecycle rule. A rule without an id
+// is created with a new one.
+func (l *LifecycleService) SaveLifecycleRule(ctx context.Context, rule models.LifecycleRule) (*models.LifecycleRule, error) {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if err := validateLifecycleRule(rule); err != nil {
+		return nil, err
+	}
+
+	if rule.Id == "" {
+		rule.Id = "lifecycle-" + uuid.New().String()
+		rule.CreatedAt = time.Now()
+		rule.LastRun = nil
+		rule.LastResult = ""
+	} else if existing, err := l.GetLifecycleRule(ctx, rule.Id); err == nil {
+		rule.CreatedAt = existing.CreatedAt
+		rule.LastRun = existing.LastRun
+		rule.LastResult = existing.LastResult
+	} else if rule.CreatedAt.IsZero() {
+		rule.CreatedAt = time.Now()
+	}
+
+	if err := saveLifecycleRuleToDB(rule); err != nil {
+		return nil, fmt.Errorf("failed to save lifecycle rule: %w", err)
+	}
+	log.Printf("Lifecycle rule '%s' saved: %s -> %s older than %s", rule.Name, rule.SourcePath, rule.TargetPath, rule.MinAge)
+	return &rule, nil
+}
+
+// DeleteLifecycleRule removes a lifecycle rule. The record of files it moved is kept.
+func (l *LifecycleService) DeleteLifecycleRule(ctx context.Context, ruleId string) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	res, err := db.Exec("DELETE FROM lifecycle_rules WHERE id = ?", ruleId)
+	if err != nil {
+		return fmt.Errorf("failed to delete lifecycle rule: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("lifecycle rule '%s' not found", ruleId)
+	}
+	return nil
+}
+
+// PreviewLifecycleRule lists the files the rule would move if it ran now (dry run)
+func (l *LifecycleService) PreviewLifecycleRule(ctx context.Context, ruleId string) (*models.LifecyclePreview, error) {
+	rule, err := l.GetLifecycleRule(ctx, ruleId)
+	if err != nil {
+		return nil, err
+	}
+	return lifecycleCandidates(ctx, *rule)
+}
+
+// RunLifecycleRule moves the files the rule selects, within its safety caps,
+// and returns the record of the files that were moved
+func (l *LifecycleService) RunLifecycleRule(ctx context.Context, ruleId string) ([]models.LifecycleMove, error) {
+	if l.operationService == nil {
+		return nil, fmt.Errorf("operation service not available")
+	}
+	rule, err := l.GetLifecycleRule(ctx, ruleId)
+	if err != nil {
+		return nil, err
+	}
+
+	l.runMutex.Lock()
+	defer l.runMutex.Unlock()
+
+	moved, err := l.runRule(ctx, *rule)
+
+	result := "success"
+	switch {
+	case err != nil:
+		result = "failed"
+	case len(moved) == 0:
+		result = "nothing to move"
+	}
+	now := time.Now()
+	rule.LastRun = &now
+	rule.LastResult = result
+	if saveErr := saveLifecycleRuleToDB(*rule); saveErr != nil {
+		log.Printf("warning: failed to save last run of lifecycle rule '%s': %v", rule.Name, saveErr)
+	}
+	return moved, err
+}
+
+// runRule moves the rule's candidates and records the ones that reached the target
+func (l *LifecycleService) runRule(ctx context.Context, rule models.LifecycleRule) ([]models.LifecycleMove, error) {
+	preview, err := lifecycleCandidates(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+	if len(preview.Files) == 0 {
+		log.Printf("Lifecycle rule '%s': nothing to move", rule.Name)
+		return []models.LifecycleMove{}, nil
+	}
+	if preview.Capped {
+		log.Printf("Lifecycle rule '%s': moving %d of %d matching files (safety cap reached)",
+			rule.Name, preview.TotalFiles, preview.MatchedFiles)
+	}
+
+	paths := make([]string, len(preview.Files))
+	for i, f := range preview.Files {
+		paths[i] = f.Path
+	}
+
+	// Pin the move to the previewed files; the age filter still applies in
+	// case a file changed since it was listed
+	profile := lifecycleMoveProfile(rule.Name, rule.SourcePath, rule.TargetPath, paths)
+	profile.MinAge = rule.MinAge
+	moveErr := l.operationService.runOperation(ctx, "move", profile, "")
+
+	// Record what reached the target even if the move partly failed
+	arrived, err := existingFiles(ctx, rule.TargetPath, paths)
+	if err != nil {
+		if moveErr != nil {
+			return nil, moveErr
+		}
+		return nil, fmt.Errorf("failed to verify moved files: %w", err)
+	}
+
+	now := time.Now()
+	moved := []models.LifecycleMove{}
+	for _, f := range preview.Files {
+		if !arrived[f.Path] {
+			continue
+		}
+		moved = append(moved, models.LifecycleMove{
+			RuleId:     rule.Id,
+			RuleName:   rule.Name,
+			Path:       f.Path,
+			SourcePath: rule.SourcePath,
+			TargetPath: rule.TargetPath,
+			Size:       f.Size,
+			ModTime:    f.ModTime,
+			MovedAt:    now,
+		})
+	}
+	if err := saveLifecycleMoves(moved); err != nil {
+		return moved, fmt.Errorf("failed to record moved files: %w", err)
+	}
+	log.Printf("Lifecycle rule '%s': moved %d files to %s", rule.Name, len(moved), rule.TargetPath)
+
+	if moveErr != nil {
+		return moved, fmt.Errorf("move failed after %d of %d files: %w", len(moved), len(paths), moveErr)
+	}
+	return moved, nil
+}
+
+// GetLifecycleMoves returns the recorded moves of a rule, or of all rules if
+// ruleId is empty, most recent first
+func (l *LifecycleService) GetLifecycleMoves(ctx context.Context, ruleId string) ([]models.LifecycleMove, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT id, rule_id, rule_name, path, source_path, target_path, size, mod_time, moved_at
+		FROM lifecycle_moves`
+	var args []interface{}
+	if ruleId != "" {
+		query += " WHERE rule_id = ?"
+		args = append(args, ruleId)
+	}
+	query += " ORDER BY moved_at DESC, id DESC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lifecycle moves: %w", err)
+	}
+	defer rows.Close()
+
+	moves := []models.LifecycleMove{}
+	for rows.Next() {
+		var m models.LifecycleMove
+		var movedAt string
+		if err := rows.Scan(&m.Id, &m.RuleId, &m.RuleName, &m.Path, &m.SourcePath, &m.TargetPath,
+			&m.Size, &m.ModTime, &movedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan lifecycle move: %w", err)
+		}
+		if t, err := time.Parse(time.RFC3339, movedAt); err == nil {
+			m.MovedAt = t
+		}
+		moves = append(moves, m)
+	}
+	return moves, rows.Err()
+}
+
+// RestoreLifecycleMoves moves the given recorded files back to where the rule
+// took them from and drops their records. Returns how many files came back.
+func (l *LifecycleService) RestoreLifecycleMoves(ctx context.Context, moveIds []int64) (int, error) {
+	if l.operationService == nil {
+		return 0, fmt.Errorf("operation service not available")
+	}
+	all, err := l.GetLifecycleMoves(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+	wanted := make(map[int64]bool, len(moveIds))
+	for _, id := range moveIds {
+		wanted[id] = true
+	}
+
+	// Group by source/target pair so each pair is one move back
+	type pair struct{ source, target string }
+	groups := make(map[pair][]models.LifecycleMove)
+	var order []pair
+	for _, m := range all {
+		if !wanted[m.Id] {
+			continue
+		}
+		p := pair{m.SourcePath, m.TargetPath}
+		if _, ok := groups[p]; !ok {
+			order = append(order, p)
+		}
+		groups[p] = append(groups[p], m)
+	}
+	if len(order) == 0 {
+		return 0, fmt.Errorf("no recorded moves selected")
+	}
+
+	l.runMutex.Lock()
+	defer l.runMutex.Unlock()
+
+	restored := 0
+	var errs []error
+	for _, p := range order {
+		moves := groups[p]
+		paths := make([]string, len(moves))
+		for i, m := range moves {
+			paths[i] = m.Path
+		}
+
+		moveErr := l.operationService.runOperation(ctx, "move",
+			lifecycleMoveProfile("restore", p.target, p.source, paths), "")
+		back, err := existingFiles(ctx, p.source, paths)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to verify restored files in %s: %w", p.source, err))
+			continue
+		}
+
+		var ids []int64
+		for _, m := range moves {
+			if back[m.Path] {
+				ids = append(ids, m.Id)
+			}
+		}
+		if err := deleteLifecycleMoves(ids); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update move records: %w", err))
+		}
+		restored += len(ids)
+		if moveErr != nil {
+			errs = append(errs, fmt.Errorf("restore to %s failed: %w", p.source, moveErr))
+		}
+	}
+	return restored, errors.Join(errs...)
+}
+
+// lifecycleMoveProfile builds the profile of a move operation restricted to the given files
+func lifecycleMoveProfile(name, from, to string, paths []string) models.Profile {
+	profile := models.Profile{
+		Name:          "lifecycle-" + name,
+		From:          from,
+		To:            to,
+		Parallel:      lifecycleParallel,
+		IncludedPaths: make([]string, len(paths)),
+	}
+	for i, p := range paths {
+		profile.IncludedPaths[i] = "/" + escapeFilterGlob(p)
+	}
+	return profile
+}
+
+// lifecycleCandidates lists what the rule would move, within its safety caps
+func lifecycleCandidates(ctx context.Context, rule models.LifecycleRule) (*models.LifecyclePreview, error) {
+	maxFiles := rule.MaxFiles
+	if maxFiles <= 0 {
+		maxFiles = defaultLifecycleMaxFiles
+	}
+	var maxBytes int64
+	if rule.MaxBytes != "" {
+		var size fs.SizeSuffix
+		if err := size.Set(rule.MaxBytes); err != nil {
+			return nil, fmt.Errorf("invalid max_bytes %q: %w", rule.MaxBytes, err)
+		}
+		maxBytes = int64(size)
+	}
+
+	opCtx, err := rclone.SimpleContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize rclone config: %w", err)
+	}
+	return rclone.LifecycleCandidates(opCtx, rule, maxFiles, maxBytes)
+}
+
+// existingFiles reports which of paths exist under remotePath
+func existingFiles(ctx context.Context, remotePath string, paths []string) (map[string]bool, error) {
+	opCtx, err := rclone.SimpleContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize rclone config: %w", err)
+	}
+	return rclone.ExistingFiles(opCtx, remotePath, paths)
+}
+
+// validateLifecycleRule checks a rule's paths, age and safety caps
+func validateLifecycleRule(rule models.LifecycleRule) error {
+	if rule.Name == "" {
+		return &validation.ValidationError{Field: "name", Message: "cannot be empty"}
+	}
+	v := validation.NewProfileValidator()
+	if err := v.ValidateRclonePath(rule.SourcePath, "source_path"); err != nil {
+		return err
+	}
+	if err := v.ValidateRclonePath(rule.TargetPath, "target_path"); err != nil {
+		return err
+	}
+	if pathsOverlap(rule.SourcePath, rule.TargetPath) {
+		return &validation.ValidationError{Field: "target_path", Message: "cannot be the source path or overlap with it"}
+	}
+
+	if rule.MinAge == "" {
+		return &validation.ValidationError{Field: "min_age", Message: "is required"}
+	}
+	var age fs.Duration
+	if err := age.Set(rule.MinAge); err != nil || age <= 0 {
+		return &validation.ValidationError{Field: "min_age", Message: fmt.Sprintf("invalid age %q (e.g. 30d, 1y)", rule.MinAge)}
+	}
+
+	if rule.MaxFiles < 0 {
+		return &validation.ValidationError{Field: "max_files", Message: "cannot be negative"}
+	}
+	if err := v.ValidateSizeSuffix(rule.MaxBytes, "max_bytes"); err != nil {
+		return err
+	}
+	if err := v.ValidatePaths(rule.IncludedPaths, "included_paths"); err != nil {
+		return err
+	}
+	return v.ValidatePaths(rule.ExcludedPaths, "excluded_paths")
+}
+
+// pathsOverlap reports whether a and b are the same path or one contains the other
+func pathsOverlap(a, b string) bool {
+	remoteA, dirA := listingCacheKey(a)
+	remoteB, dirB := listingCacheKey(b)
+	if remoteA != remoteB {
+		return false
+	}
+	if dirA == dirB || dirA == "" || dirB == "" {
+		return true
+	}
+	return strings.HasPrefix(dirA, strings.TrimSuffix(dirB, "/")+"/") ||
+		strings.HasPrefix(dirB, strings.TrimSuffix(dirA, "/")+"/")
+}
+
+// ============ Persistence ============
+
+// saveLifecycleRuleToDB inserts or replaces a lifecycle rule
+func saveLifecycleRuleToDB(r models.LifecycleRule) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`INSERT OR REPLACE INTO lifecycle_rules (id, name, source_path, target_path, min_age,
+		included_paths, excluded_paths, max_files, max_bytes, last_run, last_result, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.Id, r.Name, r.SourcePath, r.TargetPath, r.MinAge,
+		marshalStringSlice(r.IncludedPaths), marshalStringSlice(r.ExcludedPaths),
+		r.MaxFiles, r.MaxBytes, timePtrToNullable(r.LastRun), r.LastResult,
+		r.CreatedAt.UTC().Format(time.RFC3339))
+	return err
+}
+
+// saveLifecycleMoves records moved files in one transaction
+func saveLifecycleMoves(moves []models.LifecycleMove) error {
+	if len(moves) == 0 {
+		return nil
+	}
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO lifecycle_moves (rule_id, rule_name, path, source_path, target_path, size, mod_time, moved_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, m := range moves {
+		if _, err := stmt.Exec(m.RuleId, m.RuleName, m.Path, m.SourcePath, m.TargetPath, m.Size, m.ModTime,
+			m.MovedAt.UTC().Format(time.RFC3339)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// deleteLifecycleMoves drops the records of files that were brought back
+func deleteLifecycleMoves(ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, id := range ids {
+		if _, err := tx.Exec("DELETE FROM lifecycle_moves WHERE id = ?", id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
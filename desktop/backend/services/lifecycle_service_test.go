@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"testing"
+	"time"
+)
+
+func TestValidateLifecycleRule(t *testing.T) {
+	base := models.LifecycleRule{Name: "archive", SourcePath: "remoteA:/projects", TargetPath: "remoteB:/archive", MinAge: "1y"}
+
+	tests := []struct {
+		name    string
+		mutate  func(r *models.LifecycleRule)
+		wantErr bool
+	}{
+		{"valid", func(r *models.LifecycleRule) {}, false},
+		{"same remote elsewhere", func(r *models.LifecycleRule) { r.TargetPath = "remoteA:/archive" }, false},
+		{"missing name", func(r *models.LifecycleRule) { r.Name = "" }, true},
+		{"missing age", func(r *models.LifecycleRule) { r.MinAge = "" }, true},
+		{"bad age", func(r *models.LifecycleRule) { r.MinAge = "soon" }, true},
+		{"target inside source", func(r *models.LifecycleRule) { r.TargetPath = "remoteA:projects/old" }, true},
+		{"source inside target", func(r *models.LifecycleRule) { r.TargetPath = "remoteA:" }, true},
+		{"negative cap", func(r *models.LifecycleRule) { r.MaxFiles = -1 }, true},
+		{"bad size cap", func(r *models.LifecycleRule) { r.MaxBytes = "lots" }, true},
+	}
+
+	for _, tt := range tests {
+		r := base
+		tt.mutate(&r)
+		if err := validateLifecycleRule(r); (err != nil) != tt.wantErr {
+			t.Errorf("%s: validateLifecycleRule() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestLifecycleRulesAndMovesPersistence(t *testing.T) {
+	svc := NewLifecycleService(nil)
+	ctx := context.Background()
+
+	saved, err := svc.SaveLifecycleRule(ctx, models.LifecycleRule{
+		Name: "old projects", SourcePath: "remoteA:/projects", TargetPath: "remoteB:/archive", MinAge: "365d",
+		ExcludedPaths: []string{"*.tmp"}, MaxFiles: 10,
+	})
+	if err != nil {
+		t.Fatalf("SaveLifecycleRule: %v", err)
+	}
+	if saved.Id == "" {
+		t.Fatal("expected the new rule to get an id")
+	}
+	defer svc.DeleteLifecycleRule(ctx, saved.Id)
+
+	got, err := svc.GetLifecycleRule(ctx, saved.Id)
+	if err != nil {
+		t.Fatalf("GetLifecycleRule: %v", err)
+	}
+	if got.MinAge != "365d" || got.MaxFiles != 10 || len(got.ExcludedPaths) != 1 {
+		t.Errorf("rule not round-tripped: %+v", got)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	moves := []models.LifecycleMove{
+		{RuleId: saved.Id, RuleName: saved.Name, Path: "2020/a.psd", SourcePath: saved.SourcePath, TargetPath: saved.TargetPath, Size: 5, MovedAt: now},
+		{RuleId: saved.Id, RuleName: saved.Name, Path: "2020/b.psd", SourcePath: saved.SourcePath, TargetPath: saved.TargetPath, Size: 7, MovedAt: now},
+	}
+	if err := saveLifecycleMoves(moves); err != nil {
+		t.Fatalf("saveLifecycleMoves: %v", err)
+	}
+
+	recorded, err := svc.GetLifecycleMoves(ctx, saved.Id)
+	if err != nil {
+		t.Fatalf("GetLifecycleMoves: %v", err)
+	}
+	if len(recorded) != 2 || recorded[0].Id == 0 || !recorded[0].MovedAt.Equal(now) {
+		t.Fatalf("unexpected recorded moves: %+v", recorded)
+	}
+
+	if err := deleteLifecycleMoves([]int64{recorded[0].Id}); err != nil {
+		t.Fatalf("deleteLifecycleMoves: %v", err)
+	}
+	if err := svc.DeleteLifecycleRule(ctx, saved.Id); err != nil {
+		t.Fatalf("DeleteLifecycleRule: %v", err)
+	}
+	recorded, _ = svc.GetLifecycleMoves(ctx, saved.Id)
+	if len(recorded) != 1 {
+		t.Errorf("expected the remaining move record to outlive the rule, got %+v", recorded)
+	}
+}
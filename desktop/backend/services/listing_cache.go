@@ -0,0 +1,168 @@
+package services
+
+import (
+	"database/sql"
+	"desktop/backend/delta"
+	"desktop/backend/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/fs/fspath"
+)
+
+// listingCacheTTL is how long a cached directory listing is served without
+// asking the remote again
+const listingCacheTTL = 5 * time.Minute
+
+// listingCacheKey splits a remote path into the remote name and a normalized
+// directory path, so "gdrive:/a/b/" and "gdrive:a/b" share a cache entry.
+// Local paths have an empty remote name and keep their leading slash.
+func listingCacheKey(remotePath string) (string, string) {
+	remote, dir, err := fspath.SplitFs(remotePath)
+	if err != nil {
+		remote, dir = "", remotePath
+	}
+	if remote != "" {
+		return remote, strings.Trim(dir, "/")
+	}
+	if trimmed := strings.TrimRight(dir, "/"); trimmed != "" {
+		dir = trimmed
+	}
+	return remote, dir
+}
+
+// listingParent returns the directory containing dir, as produced by listingCacheKey
+func listingParent(remote, dir string) string {
+	parent := path.Dir(dir)
+	if remote != "" && (parent == "." || parent == "/") {
+		return ""
+	}
+	return parent
+}
+
+// loadCachedListing returns the cached listing of a directory, or nil if it was never cached
+func loadCachedListing(remotePath string) (*models.DirListing, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+
+	remote, dir := listingCacheKey(remotePath)
+	var entries, fetchedAt string
+	var stale int
+	err = db.QueryRow("SELECT entries, fetched_at, stale FROM listing_cache WHERE remote = ? AND path = ?",
+		remote, dir).Scan(&entries, &fetchedAt, &stale)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query listing cache: %w", err)
+	}
+
+	listing := &models.DirListing{Path: remotePath, Cached: true}
+	if err := json.Unmarshal([]byte(entries), &listing.Entries); err != nil {
+		return nil, fmt.Errorf("failed to parse cached listing of %s: %w", remotePath, err)
+	}
+	if t, err := time.Parse(time.RFC3339, fetchedAt); err == nil {
+		listing.FetchedAt = t
+	}
+	listing.Stale = stale != 0 || time.Since(listing.FetchedAt) > listingCacheTTL
+	return listing, nil
+}
+
+// saveCachedListing stores a freshly fetched directory listing
+func saveCachedListing(remotePath string, entries []models.FileEntry, fetchedAt time.Time) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal listing: %w", err)
+	}
+	remote, dir := listingCacheKey(remotePath)
+	_, err = db.Exec(`INSERT OR REPLACE INTO listing_cache (remote, path, entries, fetched_at, stale)
+		VALUES (?, ?, ?, ?, 0)`, remote, dir, string(data), fetchedAt.UTC().Format(time.RFC3339))
+	return err
+}
+
+// invalidateCachedListings marks the cached listings affected by a change at
+// remotePath as stale: the path itself, everything below it and its parent.
+// Stale listings are kept so they can still be shown while offline.
+func invalidateCachedListings(remotePath string) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+
+	remote, dir := listingCacheKey(remotePath)
+	if dir == "" || dir == "/" {
+		_, err = db.Exec("UPDATE listing_cache SET stale = 1 WHERE remote = ?", remote)
+		return err
+	}
+	prefix := dir + "/"
+	_, err = db.Exec(`UPDATE listing_cache SET stale = 1
+		WHERE remote = ? AND (path = ? OR path = ? OR substr(path, 1, length(?)) = ?)`,
+		remote, dir, listingParent(remote, dir), prefix, prefix)
+	return err
+}
+
+// clearListingCache removes the cached listings of a remote, or of every remote if remoteName is empty
+func clearListingCache(remoteName string) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+
+	if remoteName == "" {
+		_, err = db.Exec("DELETE FROM listing_cache")
+		return err
+	}
+	remote, _ := listingCacheKey(strings.TrimSuffix(remoteName, ":") + ":")
+	_, err = db.Exec("DELETE FROM listing_cache WHERE remote = ?", remote)
+	return err
+}
+
+// listingCacheUsage returns the size of the cached listings and how many directories they cover
+func listingCacheUsage() (int64, int, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return 0, 0, err
+	}
+	var size int64
+	var count int
+	err = db.QueryRow("SELECT COALESCE(SUM(LENGTH(entries)), 0), COUNT(*) FROM listing_cache").Scan(&size, &count)
+	return size, count, err
+}
+
+// changedRemotePaths returns the remote paths touched by a change a delta
+// watcher reported for remoteKey (see rclone.SourceRemoteKey)
+func changedRemotePaths(remoteKey string, change delta.FileChange) []string {
+	root := strings.TrimPrefix(remoteKey, "local:")
+	remote, rootPath, err := fspath.SplitFs(root)
+	if err != nil {
+		return nil
+	}
+
+	paths := []string{remote + path.Join(rootPath, change.Path)}
+	if change.OldPath != "" {
+		paths = append(paths, remote+path.Join(rootPath, change.OldPath))
+	}
+	return paths
+}
+
+// invalidateListingsForChange is the delta change handler that keeps the
+// listing cache in step with changes watchers see on remotes
+func invalidateListingsForChange(remoteKey string, change delta.FileChange) {
+	for _, p := range changedRemotePaths(remoteKey, change) {
+		if err := invalidateCachedListings(p); err != nil {
+			log.Printf("warning: failed to invalidate cached listings of %s: %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"desktop/backend/delta"
+	"desktop/backend/models"
+	"testing"
+	"time"
+)
+
+func TestListingCacheKey(t *testing.T) {
+	tests := []struct {
+		in, remote, dir string
+	}{
+		{"gdrive:", "gdrive:", ""},
+		{"gdrive:/docs/", "gdrive:", "docs"},
+		{"gdrive:docs/2024", "gdrive:", "docs/2024"},
+		{"/home/user/", "", "/home/user"},
+		{"/", "", "/"},
+	}
+
+	for _, tt := range tests {
+		remote, dir := listingCacheKey(tt.in)
+		if remote != tt.remote || dir != tt.dir {
+			t.Errorf("listingCacheKey(%q) = %q, %q, want %q, %q", tt.in, remote, dir, tt.remote, tt.dir)
+		}
+	}
+}
+
+func TestChangedRemotePaths(t *testing.T) {
+	got := changedRemotePaths("gdrive:/data", delta.FileChange{Path: "b.txt", OldPath: "old/a.txt"})
+	if len(got) != 2 || got[0] != "gdrive:/data/b.txt" || got[1] != "gdrive:/data/old/a.txt" {
+		t.Errorf("unexpected paths for a rename: %v", got)
+	}
+
+	got = changedRemotePaths("local:/home/user", delta.FileChange{Path: "notes.txt"})
+	if len(got) != 1 || got[0] != "/home/user/notes.txt" {
+		t.Errorf("unexpected paths for a local change: %v", got)
+	}
+}
+
+func TestListingCacheInvalidation(t *testing.T) {
+	if err := clearListingCache(""); err != nil {
+		t.Fatalf("clearListingCache: %v", err)
+	}
+
+	entries := []models.FileEntry{{Path: "docs/a.txt", Name: "a.txt", Size: 3}}
+	for _, p := range []string{"gdrive:", "gdrive:docs", "gdrive:docs/sub", "gdrive:photos", "s3:docs"} {
+		if err := saveCachedListing(p, entries, time.Now()); err != nil {
+			t.Fatalf("saveCachedListing(%s): %v", p, err)
+		}
+	}
+
+	listing, err := loadCachedListing("gdrive:/docs/")
+	if err != nil || listing == nil {
+		t.Fatalf("expected a cached listing, got %v, %v", listing, err)
+	}
+	if listing.Stale || !listing.Cached || len(listing.Entries) != 1 {
+		t.Errorf("expected a fresh cached listing, got %+v", listing)
+	}
+
+	invalidateListingsForChange("gdrive:", delta.FileChange{Path: "docs/new.txt"})
+
+	stale := map[string]bool{}
+	for _, p := range []string{"gdrive:", "gdrive:docs", "gdrive:docs/sub", "gdrive:photos", "s3:docs"} {
+		listing, err := loadCachedListing(p)
+		if err != nil || listing == nil {
+			t.Fatalf("loadCachedListing(%s): %v, %v", p, listing, err)
+		}
+		stale[p] = listing.Stale
+	}
+	want := map[string]bool{"gdrive:": false, "gdrive:docs": true, "gdrive:docs/sub": false, "gdrive:photos": false, "s3:docs": false}
+	for p, w := range want {
+		if stale[p] != w {
+			t.Errorf("%s: stale = %v, want %v", p, stale[p], w)
+		}
+	}
+
+	// A changed directory invalidates everything below it
+	if err := invalidateCachedListings("gdrive:docs"); err != nil {
+		t.Fatalf("invalidateCachedListings: %v", err)
+	}
+	if listing, _ := loadCachedListing("gdrive:docs/sub"); listing == nil || !listing.Stale {
+		t.Errorf("expected the subdirectory listing to be stale, got %+v", listing)
+	}
+
+	if err := clearListingCache("gdrive"); err != nil {
+		t.Fatalf("clearListingCache: %v", err)
+	}
+	if listing, _ := loadCachedListing("gdrive:docs"); listing != nil {
+		t.Errorf("expected gdrive listings to be cleared, got %+v", listing)
+	}
+	if listing, _ := loadCachedListing("s3:docs"); listing == nil {
+		t.Error("expected other remotes' listings to be kept")
+	}
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"desktop/backend/models"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// defaultListingPageSize is how many entries a page of BrowseFilesPage
+// holds unless asked otherwise
+const defaultListingPageSize = 500
+
+// maxListingPageSize caps the entries of a page
+const maxListingPageSize = 5000
+
+// maxListingViews is how many ordered and filtered listings are kept in
+// memory for the pages after the first
+const maxListingViews = 8
+
+// listingView is a directory listing ordered and filtered for paging
+type listingView struct {
+	listing *models.DirListing
+	usedAt  time.Time
+}
+
+// listingCursor is the last entry of a page; the next page starts after it
+type listingCursor struct {
+	IsDir   bool   `json:"d,omitempty"`
+	Name    string `json:"n"`
+	Size    int64  `json:"s,omitempty"`
+	ModTime string `json:"t,omitempty"`
+}
+
+// BrowseFilesPage returns a page of a directory listing for the file
+// browser, for directories too large to send whole. The first page lists
+// the directory like BrowseFiles, through the listing cache; the pages
+// after it are served from that listing, ordered and filtered by name here.
+// A cursor holds the last entry of its page, so paging carries on in order
+// even when the listing was refreshed in between.
+func (o *OperationService) BrowseFilesPage(ctx context.Context, remotePath string, query models.DirPageQuery) (*models.DirPage, error) {
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaultListingPageSize
+	}
+	if limit < 0 || limit > maxListingPageSize {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxListingPageSize)
+	}
+	switch query.OrderBy {
+	case "":
+		query.OrderBy = models.ListOrderName
+	case models.ListOrderName, models.ListOrderSize, models.ListOrderModTime:
+	default:
+		return nil, fmt.Errorf("order must be one of: %s, %s, %s", models.ListOrderName, models.ListOrderSize, models.ListOrderModTime)
+	}
+	var after *listingCursor
+	if query.Cursor != "" {
+		cursor, err := decodeListingCursor(query.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor: %w", err)
+		}
+		after = cursor
+	}
+
+	listing, err := o.listingView(ctx, remotePath, query, after == nil)
+	if err != nil {
+		return nil, err
+	}
+	entries := listing.Entries
+	start := 0
+	if after != nil {
+		less := listingLess(query.OrderBy, query.Descending)
+		last := after.entry()
+		start = sort.Search(len(entries), func(i int) bool { return less(last, entries[i]) })
+	}
+	end := min(start+limit, len(entries))
+
+	page := &models.DirPage{
+		Path:      remotePath,
+		Entries:   entries[start:end],
+		Total:     len(entries),
+		FetchedAt: listing.FetchedAt,
+		Cached:    listing.Cached,
+		Stale:     listing.Stale,
+		Error:     listing.Error,
+	}
+	if end < len(entries) {
+		page.NextCursor = encodeListingCursor(entries[end-1])
+	}
+	return page, nil
+}
+
+// listingView returns a directory's listing ordered and filtered for
+// query. The first page lists the directory again (or reads it from the
+// listing cache); later pages reuse the view of the first while it is kept.
+func (o *OperationService) listingView(ctx context.Context, remotePath string, query models.DirPageQuery, first bool) (*models.DirListing, error) {
+	filter := strings.ToLower(query.Filter)
+	key := strings.Join([]string{remotePath, query.OrderBy, strconv.FormatBool(query.Descending), filter}, "\x00")
+	if !first {
+		o.viewsMu.Lock()
+		view, ok := o.listingViews[key]
+		if ok {
+			view.usedAt = time.Now()
+		}
+		o.viewsMu.Unlock()
+		if ok {
+			return view.listing, nil
+		}
+	}
+
+	listing, err := o.BrowseFiles(ctx, remotePath, first && query.Refresh)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]models.FileEntry, 0, len(listing.Entries))
+	for _, e := range listing.Entries {
+		if filter == "" || strings.Contains(strings.ToLower(e.Name), filter) {
+			entries = append(entries, e)
+		}
+	}
+	less := listingLess(query.OrderBy, query.Descending)
+	sort.Slice(entries, func(i, j int) bool { return less(entries[i], entries[j]) })
+	ordered := *listing
+	ordered.Entries = entries
+
+	o.viewsMu.Lock()
+	defer o.viewsMu.Unlock()
+	if o.listingViews == nil {
+		o.listingViews = make(map[string]*listingView)
+	}
+	if _, ok := o.listingViews[key]; !ok && len(o.listingViews) >= maxListingViews {
+		oldest := ""
+		for k, v := range o.listingViews {
+			if oldest == "" || v.usedAt.Before(o.listingViews[oldest].usedAt) {
+				oldest = k
+			}
+		}
+		delete(o.listingViews, oldest)
+	}
+	o.listingViews[key] = &listingView{listing: &ordered, usedAt: time.Now()}
+	return &ordered, nil
+}
+
+// listingLess returns the order of a paged listing: directories first,
+// then by the order's key, then by name ignoring case, then by name
+func listingLess(orderBy string, descending bool) func(a, b models.FileEntry) bool {
+	return func(a, b models.FileEntry) bool {
+		if a.IsDir != b.IsDir {
+			return a.IsDir
+		}
+		var c int
+		switch orderBy {
+		case models.ListOrderSize:
+			c = cmp.Compare(a.Size, b.Size)
+		case models.ListOrderModTime:
+			c = strings.Compare(a.ModTime, b.ModTime)
+		}
+		if c == 0 {
+			c = compareFold(a.Name, b.Name)
+		}
+		if c == 0 {
+			c = strings.Compare(a.Name, b.Name)
+		}
+		if descending {
+			return c > 0
+		}
+		return c < 0
+	}
+}
+
+// compareFold compares two names ignoring case, without allocating
+func compareFold(a, b string) int {
+	for a != "" && b != "" {
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		if la, lb := unicode.ToLower(ra), unicode.ToLower(rb); la != lb {
+			return cmp.Compare(la, lb)
+		}
+		a, b = a[na:], b[nb:]
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// entry returns the entry the cursor stands for, for ordering
+func (c *listingCursor) entry() models.FileEntry {
+	return models.FileEntry{IsDir: c.IsDir, Name: c.Name, Size: c.Size, ModTime: c.ModTime}
+}
+
+// encodeListingCursor returns the cursor of the page ending with e
+func encodeListingCursor(e models.FileEntry) string {
+	data, _ := json.Marshal(listingCursor{IsDir: e.IsDir, Name: e.Name, Size: e.Size, ModTime: e.ModTime})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeListingCursor reads a cursor made by encodeListingCursor
+func decodeListingCursor(cursor string) (*listingCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	var c listingCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
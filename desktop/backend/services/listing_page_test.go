@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBrowseFilesPage(t *testing.T) {
+	const dir = "pagetest:big"
+	if err := clearListingCache("pagetest"); err != nil {
+		t.Fatalf("clearListingCache: %v", err)
+	}
+	var entries []models.FileEntry
+	for i := 24; i >= 0; i-- {
+		name := fmt.Sprintf("file%02d.txt", i)
+		if i%5 == 0 {
+			name = fmt.Sprintf("Report%02d.txt", i)
+		}
+		entries = append(entries, models.FileEntry{Path: "big/" + name, Name: name, Size: int64(i % 3)})
+	}
+	entries = append(entries, models.FileEntry{Path: "big/zdir", Name: "zdir", IsDir: true})
+	if err := saveCachedListing(dir, entries, time.Now()); err != nil {
+		t.Fatalf("saveCachedListing: %v", err)
+	}
+
+	o := NewOperationService(nil)
+	ctx := context.Background()
+
+	// Page through the whole listing
+	var names []string
+	query := models.DirPageQuery{Limit: 10}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("paging didn't end")
+		}
+		page, err := o.BrowseFilesPage(ctx, dir, query)
+		if err != nil {
+			t.Fatalf("BrowseFilesPage: %v", err)
+		}
+		if page.Total != 26 || !page.Cached {
+			t.Errorf("expected 26 cached entries, got %d (cached %v)", page.Total, page.Cached)
+		}
+		for _, e := range page.Entries {
+			names = append(names, e.Name)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	if len(names) != 26 || names[0] != "zdir" || names[1] != "file01.txt" || names[25] != "Report20.txt" {
+		t.Errorf("unexpected order: %v", names)
+	}
+	for i := 2; i < len(names); i++ {
+		if compareFold(names[i-1], names[i]) >= 0 {
+			t.Errorf("%s listed before %s", names[i-1], names[i])
+		}
+	}
+
+	// Filter by name and order by size, largest first
+	page, err := o.BrowseFilesPage(ctx, dir, models.DirPageQuery{Filter: "report", OrderBy: models.ListOrderSize, Descending: true})
+	if err != nil {
+		t.Fatalf("BrowseFilesPage: %v", err)
+	}
+	if page.Total != 5 || page.NextCursor != "" {
+		t.Fatalf("expected one page of 5 reports, got %d, cursor %q", page.Total, page.NextCursor)
+	}
+	for i := 1; i < len(page.Entries); i++ {
+		if page.Entries[i-1].Size < page.Entries[i].Size {
+			t.Errorf("expected the largest reports first, got %v", page.Entries)
+		}
+	}
+
+	if _, err := o.BrowseFilesPage(ctx, dir, models.DirPageQuery{Cursor: "not a cursor"}); err == nil {
+		t.Error("expected an invalid cursor to be rejected")
+	}
+	if _, err := o.BrowseFilesPage(ctx, dir, models.DirPageQuery{OrderBy: "type"}); err == nil {
+		t.Error("expected an unknown order to be rejected")
+	}
+}
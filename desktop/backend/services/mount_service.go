@@ -0,0 +1,352 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/events"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rclone/rclone/cmd/mountlib"
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// mountWriteTimeout is how long unmounting waits for files open for
+// writing to be closed
+const mountWriteTimeout = 10 * time.Second
+
+// windowsDriveMount matches the mount points WinFsp assigns itself: a drive
+// letter like "X:", or "*" for the first free one
+var windowsDriveMount = regexp.MustCompile(`^([A-Za-z]:|\*)$`)
+
+// MountService mounts remotes as local drives through rclone's VFS, so any
+// app can open their files. Mounts last until they are unmounted, the app
+// locks or the app exits.
+type MountService struct {
+	app      *application.App
+	eventBus *events.WailsEventBus
+	mutex    sync.Mutex
+	mounts   map[string]*activeMount // by mount point
+	epoch    int                     // counts unmountAll calls, so mounts finishing after one are undone
+}
+
+// activeMount is a mount made by MountService
+type activeMount struct {
+	info  models.MountInfo
+	mount *rclone.Mount
+}
+
+// NewMountService creates a new mount service
+func NewMountService(app *application.App) *MountService {
+	return &MountService{
+		app:    app,
+		mounts: make(map[string]*activeMount),
+	}
+}
+
+// SetApp sets the application reference for events
+func (m *MountService) SetApp(app *application.App) {
+	m.app = app
+	if bus := GetSharedEventBus(); bus != nil {
+		m.eventBus = bus
+	} else {
+		m.eventBus = events.NewEventBus(app)
+	}
+}
+
+// ServiceName returns the name of the service
+func (m *MountService) ServiceName() string {
+	return "MountService"
+}
+
+// ServiceStartup is called when the service starts
+func (m *MountService) ServiceStartup(ctx context.Context, options application.ServiceOptions) error {
+	log.Printf("MountService starting up...")
+	return nil
+}
+
+// ServiceShutdown is called when the service shuts down. ShutdownService
+// has normally unmounted everything by then.
+func (m *MountService) ServiceShutdown(ctx context.Context) error {
+	log.Printf("MountService shutting down...")
+	m.unmountAll("the app is exiting")
+	return nil
+}
+
+// IsMountSupported reports whether remotes can be mounted on this system:
+// it needs FUSE on Linux, and on Windows WinFsp and a build with mounting
+func (m *MountService) IsMountSupported(ctx context.Context) bool {
+	return rclone.MountSupported()
+}
+
+// Mount mounts remotePath, e.g. "gdrive:" or "vault:photos", read/write at
+// mountPoint. Crypt remotes are mounted decrypted. On Windows mountPoint is
+// a drive letter like "X:", "*" for the first free letter, or a folder that
+// doesn't exist yet; elsewhere it is an empty folder, created if missing.
+func (m *MountService) Mount(ctx context.Context, remotePath, mountPoint string, opts models.MountOptions) (*models.MountInfo, error) {
+	if !strings.Contains(remotePath, ":") || filepath.VolumeName(remotePath) != "" {
+		return nil, fmt.Errorf("%q is not a remote path", remotePath)
+	}
+	if opts.CacheMode == "" {
+		opts.CacheMode = models.MountCacheWrites
+	}
+	switch opts.CacheMode {
+	case models.MountCacheOff, models.MountCacheMinimal, models.MountCacheWrites, models.MountCacheFull:
+	default:
+		return nil, fmt.Errorf("cache mode must be one of: off, minimal, writes, full")
+	}
+	if opts.CacheMaxSizeMB < 0 || opts.CacheMaxAgeMin < 0 {
+		return nil, fmt.Errorf("cache size and age can't be negative")
+	}
+	if err := checkMountPoint(mountPoint); err != nil {
+		return nil, err
+	}
+
+	// Reserve the mount point while mounting, which can take a while
+	m.mutex.Lock()
+	if _, exists := m.mounts[mountPoint]; exists {
+		m.mutex.Unlock()
+		return nil, fmt.Errorf("something is already mounted at %s", mountPoint)
+	}
+	entry := &activeMount{info: models.MountInfo{
+		RemotePath: remotePath,
+		MountPoint: mountPoint,
+		Options:    opts,
+		Status:     models.MountStatusMounting,
+	}}
+	m.mounts[mountPoint] = entry
+	epoch := m.epoch
+	m.mutex.Unlock()
+
+	mount, err := m.mountRemote(ctx, remotePath, mountPoint, opts)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if err == nil && m.epoch != epoch {
+		// The app locked or started exiting while mounting
+		if unmountErr := mount.Unmount(0); unmountErr != nil {
+			log.Printf("[MountService] %v", unmountErr)
+		}
+		err = fmt.Errorf("mounting %s was cancelled: the app locked or is exiting", remotePath)
+	}
+	if err != nil {
+		delete(m.mounts, mountPoint)
+		return nil, err
+	}
+	entry.mount = mount
+	entry.info.Status = models.MountStatusMounted
+	entry.info.MountedAt = time.Now()
+	info := entry.info
+
+	log.Printf("[MountService] Mounted %s at %s (cache %s)", remotePath, mountPoint, opts.CacheMode)
+	m.emitMountEvent(events.MountMounted, info)
+	go m.watchMount(entry)
+	return &info, nil
+}
+
+// mountRemote mounts remotePath with the rclone config. The mount outlives
+// the call, so it doesn't end with ctx.
+func (m *MountService) mountRemote(ctx context.Context, remotePath, mountPoint string, opts models.MountOptions) (*rclone.Mount, error) {
+	mountCtx, err := rclone.SimpleContext(context.WithoutCancel(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize rclone config: %w", err)
+	}
+	mount, err := rclone.MountRemote(mountCtx, remotePath, mountPoint, rclone.MountConfig{
+		CacheMode:    opts.CacheMode,
+		CacheMaxSize: int64(opts.CacheMaxSizeMB) << 20,
+		CacheMaxAge:  time.Duration(opts.CacheMaxAgeMin) * time.Minute,
+		ReadOnly:     opts.ReadOnly,
+		VolumeName:   opts.VolumeName,
+	})
+	if errors.Is(err, rclone.ErrMountUnsupported) {
+		return nil, fmt.Errorf("%w: install %s", err, mountDriver())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to mount %s at %s: %w", remotePath, mountPoint, err)
+	}
+	return mount, nil
+}
+
+// checkMountPoint checks that mountPoint can be mounted at, creating the
+// folder where the mount needs one
+func checkMountPoint(mountPoint string) error {
+	if mountPoint == "" {
+		return fmt.Errorf("mount point is required")
+	}
+	if runtime.GOOS == "windows" {
+		if windowsDriveMount.MatchString(mountPoint) {
+			return nil
+		}
+		if !filepath.IsAbs(mountPoint) {
+			return fmt.Errorf("mount point must be a drive letter or an absolute path")
+		}
+		// WinFsp creates the folder itself and won't mount on an existing one
+		if _, err := os.Stat(mountPoint); err == nil {
+			return fmt.Errorf("%s already exists: mount at a folder that doesn't exist yet", mountPoint)
+		}
+		return nil
+	}
+
+	if !filepath.IsAbs(mountPoint) {
+		return fmt.Errorf("mount point must be an absolute path")
+	}
+	if err := os.MkdirAll(mountPoint, 0o755); err != nil {
+		return fmt.Errorf("failed to create mount point: %w", err)
+	}
+	if err := mountlib.CheckMountEmpty(mountPoint); err != nil {
+		return fmt.Errorf("mount point must be an empty folder: %w", err)
+	}
+	return nil
+}
+
+// mountDriver names what mounting needs on this system
+func mountDriver() string {
+	switch runtime.GOOS {
+	case "windows":
+		return "WinFsp (winfsp.dev)"
+	case "darwin":
+		return "macFUSE or FUSE-T"
+	default:
+		return "FUSE (fuse3)"
+	}
+}
+
+// Unmount unmounts the remote mounted at mountPoint, once the files open
+// for writing on it are closed or after a few seconds
+func (m *MountService) Unmount(ctx context.Context, mountPoint string) error {
+	m.mutex.Lock()
+	entry, exists := m.mounts[mountPoint]
+	if !exists {
+		m.mutex.Unlock()
+		return fmt.Errorf("nothing is mounted at %s", mountPoint)
+	}
+	if entry.info.Status != models.MountStatusMounted {
+		m.mutex.Unlock()
+		return fmt.Errorf("%s is still %s", mountPoint, entry.info.Status)
+	}
+	entry.info.Status = models.MountStatusUnmounting
+	m.mutex.Unlock()
+
+	return m.unmount(entry, "")
+}
+
+// unmount unmounts a mount and forgets it. reason says why when the user
+// didn't ask for it.
+func (m *MountService) unmount(entry *activeMount, reason string) error {
+	err := entry.mount.Unmount(mountWriteTimeout)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if err != nil {
+		// Still mounted: let the user try again
+		entry.info.Status = models.MountStatusMounted
+		return err
+	}
+	delete(m.mounts, entry.info.MountPoint)
+	info := m.mountInfoLocked(entry)
+	info.Error = reason
+	if reason != "" {
+		log.Printf("[MountService] Unmounted %s: %s", entry.info.MountPoint, reason)
+	} else {
+		log.Printf("[MountService] Unmounted %s", entry.info.MountPoint)
+	}
+	m.emitMountEvent(events.MountUnmounted, info)
+	return nil
+}
+
+// unmountAll unmounts every mount, e.g. before the app locks its config or
+// exits
+func (m *MountService) unmountAll(reason string) {
+	m.mutex.Lock()
+	m.epoch++
+	var entries []*activeMount
+	for _, entry := range m.mounts {
+		if entry.info.Status == models.MountStatusMounted {
+			entry.info.Status = models.MountStatusUnmounting
+			entries = append(entries, entry)
+		}
+	}
+	m.mutex.Unlock()
+
+	var wg sync.WaitGroup
+	for _, entry := range entries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := m.unmount(entry, reason); err != nil {
+				log.Printf("[MountService] %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// watchMount forgets a mount that ended without being unmounted by the
+// app, e.g. when it was unmounted from a terminal or the file manager
+func (m *MountService) watchMount(entry *activeMount) {
+	err := <-entry.mount.Done()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.mounts[entry.info.MountPoint] != entry || entry.info.Status != models.MountStatusMounted {
+		return // unmounted by the app
+	}
+	delete(m.mounts, entry.info.MountPoint)
+	info := m.mountInfoLocked(entry)
+	info.Error = "unmounted outside the app"
+	if err != nil {
+		info.Error = err.Error()
+	}
+	log.Printf("[MountService] %s was unmounted: %s", entry.info.MountPoint, info.Error)
+	entry.mount.Release()
+	m.emitMountEvent(events.MountUnmounted, info)
+}
+
+// GetMounts returns the remotes mounted, with the state of their caches
+func (m *MountService) GetMounts(ctx context.Context) []models.MountInfo {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	mounts := make([]models.MountInfo, 0, len(m.mounts))
+	for _, entry := range m.mounts {
+		mounts = append(mounts, m.mountInfoLocked(entry))
+	}
+	sort.Slice(mounts, func(i, j int) bool { return mounts[i].MountPoint < mounts[j].MountPoint })
+	return mounts
+}
+
+// mountInfoLocked returns a mount's info with the state of its cache.
+// Caller must hold m.mutex.
+func (m *MountService) mountInfoLocked(entry *activeMount) models.MountInfo {
+	info := entry.info
+	if entry.mount != nil {
+		stats := entry.mount.Stats()
+		info.UploadsInProgress = stats.UploadsInProgress
+		info.UploadsQueued = stats.UploadsQueued
+		info.CachedFiles = stats.CachedFiles
+		info.CacheBytes = stats.CacheBytes
+	}
+	return info
+}
+
+// emitMountEvent emits a mount event
+func (m *MountService) emitMountEvent(eventType events.EventType, info models.MountInfo) {
+	event := events.NewMountEvent(eventType, info.MountPoint, info)
+	if m.eventBus != nil {
+		if err := m.eventBus.EmitMountEvent(event); err != nil {
+			log.Printf("Failed to emit mount event: %v", err)
+		}
+	} else if m.app != nil {
+		m.app.Event.Emit("tofe", event)
+	}
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestMountService_RejectsInvalidMounts(t *testing.T) {
+	m := NewMountService(nil)
+	ctx := context.Background()
+	mountPoint := filepath.Join(t.TempDir(), "drive")
+
+	if _, err := m.Mount(ctx, "/home/user/docs", mountPoint, models.MountOptions{}); err == nil {
+		t.Error("expected a local path to be rejected")
+	}
+	if _, err := m.Mount(ctx, "gdrive:", mountPoint, models.MountOptions{CacheMode: "everything"}); err == nil {
+		t.Error("expected an unknown cache mode to be rejected")
+	}
+	if _, err := m.Mount(ctx, "gdrive:", "drive", models.MountOptions{}); err == nil {
+		t.Error("expected a relative mount point to be rejected")
+	}
+	if err := m.Unmount(ctx, mountPoint); err == nil {
+		t.Error("expected unmounting nothing to fail")
+	}
+	if mounts := m.GetMounts(ctx); len(mounts) != 0 {
+		t.Errorf("expected no mounts, got %v", mounts)
+	}
+}
+
+func TestCheckMountPoint(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		if err := checkMountPoint("X:"); err != nil {
+			t.Errorf("expected a drive letter to be accepted: %v", err)
+		}
+		if err := checkMountPoint(t.TempDir()); err == nil {
+			t.Error("expected an existing folder to be rejected")
+		}
+		return
+	}
+
+	dir := filepath.Join(t.TempDir(), "drive")
+	if err := checkMountPoint(dir); err != nil {
+		t.Fatalf("expected a missing folder to be created: %v", err)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Fatalf("expected %s to be created", dir)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "file.txt"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkMountPoint(dir); err == nil {
+		t.Error("expected a folder with files to be rejected")
+	}
+}
@@ -2,44 +2,108 @@ package services
 
 import (
 	"context"
+	"desktop/backend/events"
+	"desktop/backend/models"
+	"fmt"
 	"log"
-	"os"
+	"strings"
 	"sync"
+	"time"
 
-	"github.com/emersion/go-autostart"
+	"github.com/google/uuid"
 	"github.com/wailsapp/wails/v3/pkg/application"
 )
 
-// AppSettings holds persisted application settings
-type AppSettings struct {
-	NotificationsEnabled    bool `json:"notifications_enabled"`
-	DebugMode               bool `json:"debug_mode"`
-	MinimizeToTray          bool `json:"minimize_to_tray"`
-	StartAtLogin            bool `json:"start_at_login"`
-	MinimizeToTrayOnStartup bool `json:"minimize_to_tray_on_startup"`
+// Notification action IDs handled by HandleNotificationAction
+const (
+	NotifyActionRetryFailed    = "retry_failed"
+	NotifyActionOpenLog        = "open_log"
+	NotifyActionPauseSchedules = "pause_schedules"
+	NotifyActionSnooze         = "snooze"
+)
+
+// pauseSchedulesDuration is how long the "pause schedules" action pauses for
+const pauseSchedulesDuration = time.Hour
+
+// snoozeActionDuration is how long the "snooze" action silences a notification category for
+const snoozeActionDuration = 24 * time.Hour
+
+// maxActionNotifications caps how many notifications keep their actions available
+const maxActionNotifications = 50
+
+// NotificationAction is a button offered with a notification in the app.
+// OS notifications don't carry buttons.
+type NotificationAction struct {
+	Id    string `json:"id"` // one of the NotifyAction* constants
+	Label string `json:"label"`
+}
+
+// ActionNotification is a notification whose actions can be invoked after it was sent
+type ActionNotification struct {
+	Id         string               `json:"id"`
+	Title      string               `json:"title"`
+	Body       string               `json:"body"`
+	Actions    []NotificationAction `json:"actions"`
+	TaskId     int                  `json:"task_id,omitempty"`     // sync task the notification is about
+	NotifyMode string               `json:"notify_mode,omitempty"` // notify mode of the task's profile, see ShouldNotify
+	ErrorInfo  *models.ErrorInfo    `json:"error_info,omitempty"`  // classified failure with suggested remediation
+	Category   string               `json:"category,omitempty"`    // see NotifyCategory*; derived from ErrorInfo when empty
+	Severity   string               `json:"severity,omitempty"`    // see NotifySeverity*; error when empty
+	TabId      string               `json:"tab_id,omitempty"`
+	CreatedAt  time.Time            `json:"created_at"`
 }
 
-// NotificationService handles desktop notifications and app settings persistence
+// NotificationService handles desktop notifications
 type NotificationService struct {
 	app      *application.App
-	settings AppSettings
+	eventBus *events.WailsEventBus
 	mutex    sync.RWMutex
+
+	// Settings holding whether notifications are enabled
+	settingsService *SettingsService
+
+	// Notifications with actions still available, oldest first
+	actionNotifications []ActionNotification
+
+	// Latest severity sent per notification category, see SnoozeNotifications
+	lastSeverity map[string]string
+
+	// Dependencies that notification actions are routed to
+	syncService      *SyncService
+	schedulerService *SchedulerService
 }
 
 // NewNotificationService creates a new notification service
 func NewNotificationService(app *application.App) *NotificationService {
 	return &NotificationService{
-		app: app,
-		settings: AppSettings{
-			NotificationsEnabled: true,
-			DebugMode:            false,
-		},
+		app:             app,
+		settingsService: NewSettingsService(app),
 	}
 }
 
 // SetApp sets the application reference
 func (n *NotificationService) SetApp(app *application.App) {
 	n.app = app
+	if bus := GetSharedEventBus(); bus != nil {
+		n.eventBus = bus
+	} else {
+		n.eventBus = events.NewEventBus(app)
+	}
+}
+
+// SetSettingsService sets the settings service holding whether notifications are enabled
+func (n *NotificationService) SetSettingsService(settingsService *SettingsService) {
+	n.settingsService = settingsService
+}
+
+// SetSyncService sets the sync service that handles "retry failed files"
+func (n *NotificationService) SetSyncService(syncService *SyncService) {
+	n.syncService = syncService
+}
+
+// SetSchedulerService sets the scheduler service that handles "pause schedules"
+func (n *NotificationService) SetSchedulerService(schedulerService *SchedulerService) {
+	n.schedulerService = schedulerService
 }
 
 // ServiceName returns the name of the service
@@ -47,10 +111,7 @@ func (n *NotificationService) ServiceName() string {
 	return "NotificationService"
 }
 
-// ServiceStartup is called when the service starts.
-// Note: LoadSettings() is NOT called here because when auth is enabled,
-// the DB is encrypted and not available yet. AuthService.initializeApp()
-// will call LoadSettings() after decryption.
+// ServiceStartup is called when the service starts
 func (n *NotificationService) ServiceStartup(ctx context.Context, options application.ServiceOptions) error {
 	log.Printf("NotificationService starting up...")
 	return nil
@@ -64,11 +125,7 @@ func (n *NotificationService) ServiceShutdown(ctx context.Context) error {
 
 // SendNotification sends a desktop notification
 func (n *NotificationService) SendNotification(ctx context.Context, title, body string) error {
-	n.mutex.RLock()
-	enabled := n.settings.NotificationsEnabled
-	n.mutex.RUnlock()
-
-	if !enabled {
+	if !n.settingsService.IsNotificationsEnabled(ctx) {
 		return nil
 	}
 
@@ -79,177 +136,220 @@ func (n *NotificationService) SendNotification(ctx context.Context, title, body
 	return nil
 }
 
-// SetEnabled enables or disables notifications
-func (n *NotificationService) SetEnabled(ctx context.Context, enabled bool) {
-	n.mutex.Lock()
-	n.settings.NotificationsEnabled = enabled
-	n.mutex.Unlock()
-	n.saveSetting("notifications_enabled", boolToStr(enabled))
-}
-
-// IsEnabled returns whether notifications are enabled
-func (n *NotificationService) IsEnabled(ctx context.Context) bool {
-	n.mutex.RLock()
-	defer n.mutex.RUnlock()
-	return n.settings.NotificationsEnabled
-}
-
-// SetDebugMode enables or disables debug mode
-func (n *NotificationService) SetDebugMode(ctx context.Context, enabled bool) {
-	n.mutex.Lock()
-	n.settings.DebugMode = enabled
-	n.mutex.Unlock()
-	n.saveSetting("debug_mode", boolToStr(enabled))
+// SendCategoryNotification sends a desktop notification unless its category
+// is snoozed or dismissed (see SnoozeNotifications)
+func (n *NotificationService) SendCategoryNotification(ctx context.Context, category, severity, title, body string) error {
+	if n.notificationSnoozed(category, severity) {
+		return nil
+	}
+	return n.SendNotification(ctx, title, body)
 }
 
-// IsDebugMode returns whether debug mode is enabled
-func (n *NotificationService) IsDebugMode(ctx context.Context) bool {
-	n.mutex.RLock()
-	defer n.mutex.RUnlock()
-	return n.settings.DebugMode
+// SendInAppNotification shows a notification in the app only, via a
+// notification:sent event without actions, unless its category is snoozed
+// or dismissed
+func (n *NotificationService) SendInAppNotification(ctx context.Context, category, severity, title, body string) {
+	if n.notificationSnoozed(category, severity) {
+		return
+	}
+	notification := ActionNotification{
+		Id:        uuid.New().String(),
+		Title:     title,
+		Body:      body,
+		Actions:   []NotificationAction{},
+		Category:  category,
+		Severity:  severity,
+		CreatedAt: time.Now(),
+	}
+	n.emitNotificationEvent(events.NotificationSent, notification.Id, notification)
 }
 
-// SetMinimizeToTray enables or disables minimize to tray on close
-func (n *NotificationService) SetMinimizeToTray(ctx context.Context, enabled bool) {
-	n.mutex.Lock()
-	n.settings.MinimizeToTray = enabled
-	n.mutex.Unlock()
-	n.saveSetting("minimize_to_tray", boolToStr(enabled))
-}
+// ShouldNotify resolves whether a run of a profile should produce a
+// notification. Precedence, highest first:
+//  1. the profile's notify mode: "off" never notifies, "failures" notifies
+//     only on failure, "all" always notifies - even if notifications are
+//     disabled globally
+//  2. the global notifications setting, when the profile mode is empty
+func (n *NotificationService) ShouldNotify(ctx context.Context, notifyMode string, success bool) bool {
+	switch notifyMode {
+	case "off":
+		return false
+	case "failures":
+		return !success
+	case "all":
+		return true
+	}
 
-// IsMinimizeToTray returns whether minimize to tray is enabled
-func (n *NotificationService) IsMinimizeToTray(ctx context.Context) bool {
-	n.mutex.RLock()
-	defer n.mutex.RUnlock()
-	return n.settings.MinimizeToTray
+	return n.settingsService.IsNotificationsEnabled(ctx)
 }
 
-// GetSettings returns all current app settings
-func (n *NotificationService) GetSettings(ctx context.Context) AppSettings {
-	n.mutex.RLock()
-	defer n.mutex.RUnlock()
-	return n.settings
-}
+// SendProfileNotification sends a notification about a profile run,
+// honoring the profile's notify mode (see ShouldNotify) and snoozed categories
+func (n *NotificationService) SendProfileNotification(ctx context.Context, notifyMode string, success bool, title, body string) error {
+	if !n.ShouldNotify(ctx, notifyMode, success) {
+		return nil
+	}
+	category, severity := NotifyCategorySyncCompleted, NotifySeverityInfo
+	if !success {
+		category, severity = NotifyCategorySyncFailed, NotifySeverityError
+	}
+	if n.notificationSnoozed(category, severity) {
+		return nil
+	}
 
-// SetStartAtLogin enables or disables starting the app at login
-func (n *NotificationService) SetStartAtLogin(ctx context.Context, enabled bool) error {
-	// Get executable path
-	execPath, err := os.Executable()
-	if err != nil {
-		log.Printf("Failed to get executable path: %v", err)
+	if err := sendPlatformNotification(title, body); err != nil {
+		log.Printf("Failed to send notification: %v", err)
 		return err
 	}
+	return nil
+}
 
-	app := &autostart.App{
-		Name:        "GN Drive",
-		DisplayName: "GN Drive",
-		Exec:        []string{execPath},
+// SendActionNotification sends a desktop notification with follow-up actions.
+// Action notifications report failures, so the notification's NotifyMode is
+// resolved as a failed run. The actions are in-app only: the frontend shows
+// them on a toast from the notification:sent event, and they stay invocable
+// through HandleNotificationAction until the notification is acted on or
+// evicted. The OS notification has no buttons; its body names the actions
+// waiting in the app. Notifications in a snoozed category are dropped.
+func (n *NotificationService) SendActionNotification(ctx context.Context, notification ActionNotification) (string, error) {
+	if !n.ShouldNotify(ctx, notification.NotifyMode, false) {
+		return "", nil
 	}
-
-	if enabled {
-		if err := app.Enable(); err != nil {
-			log.Printf("Failed to enable start at login: %v", err)
-			return err
-		}
-	} else {
-		if err := app.Disable(); err != nil {
-			log.Printf("Failed to disable start at login: %v", err)
-			return err
-		}
+	if notification.Category == "" {
+		notification.Category = failureCategory(notification.ErrorInfo)
+	}
+	if notification.Severity == "" {
+		notification.Severity = NotifySeverityError
+	}
+	if n.notificationSnoozed(notification.Category, notification.Severity) {
+		return "", nil
 	}
 
 	n.mutex.Lock()
-	n.settings.StartAtLogin = enabled
+	notification.Id = uuid.New().String()
+	notification.CreatedAt = time.Now()
+	n.actionNotifications = append(n.actionNotifications, notification)
+	if len(n.actionNotifications) > maxActionNotifications {
+		n.actionNotifications = n.actionNotifications[len(n.actionNotifications)-maxActionNotifications:]
+	}
 	n.mutex.Unlock()
-	n.saveSetting("start_at_login", boolToStr(enabled))
 
-	return nil
-}
+	n.emitNotificationEvent(events.NotificationSent, notification.Id, notification)
 
-// IsStartAtLogin returns whether start at login is enabled
-func (n *NotificationService) IsStartAtLogin(ctx context.Context) bool {
-	n.mutex.RLock()
-	defer n.mutex.RUnlock()
-	return n.settings.StartAtLogin
+	if err := sendPlatformNotification(notification.Title, platformActionBody(notification)); err != nil {
+		log.Printf("Failed to send notification: %v", err)
+		return notification.Id, err
+	}
+	return notification.Id, nil
 }
 
-// SetMinimizeToTrayOnStartup enables or disables minimizing to tray on startup
-func (n *NotificationService) SetMinimizeToTrayOnStartup(ctx context.Context, enabled bool) {
-	n.mutex.Lock()
-	n.settings.MinimizeToTrayOnStartup = enabled
-	n.mutex.Unlock()
-	n.saveSetting("minimize_to_tray_on_startup", boolToStr(enabled))
+// platformActionBody is the body of an action notification's OS
+// notification, which can't show the actions: it names them instead
+func platformActionBody(notification ActionNotification) string {
+	if len(notification.Actions) == 0 {
+		return notification.Body
+	}
+	labels := make([]string, len(notification.Actions))
+	for i, a := range notification.Actions {
+		labels[i] = a.Label
+	}
+	return notification.Body + "\nIn GN Drive: " + strings.Join(labels, ", ")
 }
 
-// IsMinimizeToTrayOnStartup returns whether minimize to tray on startup is enabled
-func (n *NotificationService) IsMinimizeToTrayOnStartup(ctx context.Context) bool {
+// GetActionNotifications returns notifications whose actions are still available, newest first
+func (n *NotificationService) GetActionNotifications(ctx context.Context) []ActionNotification {
 	n.mutex.RLock()
 	defer n.mutex.RUnlock()
-	return n.settings.MinimizeToTrayOnStartup
+
+	result := make([]ActionNotification, len(n.actionNotifications))
+	for i, notification := range n.actionNotifications {
+		result[len(result)-1-i] = notification
+	}
+	return result
 }
 
-// LoadSettings loads settings from the database. Exported for early loading in main.go.
-func (n *NotificationService) LoadSettings() {
-	db, err := GetSharedDB()
-	if err != nil {
-		log.Printf("Warning: Could not get database for settings: %v", err)
-		return
+// HandleNotificationAction runs an action offered by a notification and
+// removes the notification from the pending list on success.
+func (n *NotificationService) HandleNotificationAction(ctx context.Context, notificationId, actionId string) error {
+	n.mutex.RLock()
+	var notification *ActionNotification
+	for i := range n.actionNotifications {
+		if n.actionNotifications[i].Id == notificationId {
+			copied := n.actionNotifications[i]
+			notification = &copied
+			break
+		}
 	}
+	n.mutex.RUnlock()
 
-	rows, err := db.Query("SELECT key, value FROM settings")
-	if err != nil {
-		log.Printf("Warning: Could not load settings: %v", err)
-		return
+	if notification == nil {
+		return fmt.Errorf("notification '%s' not found", notificationId)
 	}
-	defer rows.Close()
 
-	n.mutex.Lock()
-	defer n.mutex.Unlock()
+	offered := false
+	for _, a := range notification.Actions {
+		if a.Id == actionId {
+			offered = true
+			break
+		}
+	}
+	if !offered {
+		return fmt.Errorf("action '%s' is not offered by notification '%s'", actionId, notificationId)
+	}
 
-	for rows.Next() {
-		var key, value string
-		if err := rows.Scan(&key, &value); err != nil {
-			continue
+	switch actionId {
+	case NotifyActionRetryFailed:
+		if n.syncService == nil {
+			return fmt.Errorf("sync service not available")
+		}
+		if _, err := n.syncService.RetryFailedFiles(ctx, notification.TaskId); err != nil {
+			return fmt.Errorf("failed to retry failed files: %w", err)
+		}
+	case NotifyActionOpenLog:
+		// The frontend navigates to the run's log on the notification:action event
+		if ts := GetTrayService(); ts != nil {
+			ts.showWindow()
+		}
+	case NotifyActionPauseSchedules:
+		if n.schedulerService == nil {
+			return fmt.Errorf("scheduler service not available")
+		}
+		if _, err := n.schedulerService.PauseSchedules(ctx, pauseSchedulesDuration); err != nil {
+			return fmt.Errorf("failed to pause schedules: %w", err)
 		}
-		switch key {
-		case "notifications_enabled":
-			n.settings.NotificationsEnabled = value == "true"
-		case "debug_mode":
-			n.settings.DebugMode = value == "true"
-		case "minimize_to_tray":
-			n.settings.MinimizeToTray = value == "true"
-		case "start_at_login":
-			n.settings.StartAtLogin = value == "true"
-		case "minimize_to_tray_on_startup":
-			n.settings.MinimizeToTrayOnStartup = value == "true"
+	case NotifyActionSnooze:
+		if err := n.SnoozeNotifications(ctx, notification.Category, snoozeActionDuration); err != nil {
+			return err
 		}
+	default:
+		return fmt.Errorf("unknown notification action '%s'", actionId)
 	}
-}
 
-// authSettingsSyncer is set by AuthService to sync settings to auth.json
-var authSettingsSyncer func(AppSettings)
-
-// SetAuthSettingsSyncer sets the callback for syncing settings to auth.json
-func SetAuthSettingsSyncer(syncer func(AppSettings)) {
-	authSettingsSyncer = syncer
-}
-
-func (n *NotificationService) saveSetting(key, value string) {
-	db, err := GetSharedDB()
-	if err != nil {
-		log.Printf("Warning: Could not get database for saving setting: %v", err)
-		return
+	n.mutex.Lock()
+	for i := range n.actionNotifications {
+		if n.actionNotifications[i].Id == notificationId {
+			n.actionNotifications = append(n.actionNotifications[:i], n.actionNotifications[i+1:]...)
+			break
+		}
 	}
+	n.mutex.Unlock()
 
-	if _, err := db.Exec("INSERT OR REPLACE INTO settings (key, value) VALUES (?, ?)", key, value); err != nil {
-		log.Printf("Warning: Could not save setting %s: %v", key, err)
-	}
+	n.emitNotificationEvent(events.NotificationAction, notificationId, map[string]interface{}{
+		"action":  actionId,
+		"task_id": notification.TaskId,
+		"tab_id":  notification.TabId,
+	})
+	return nil
+}
 
-	// Sync tray/startup settings to auth.json (needed before unlock on next start)
-	if authSettingsSyncer != nil {
-		n.mutex.RLock()
-		settings := n.settings
-		n.mutex.RUnlock()
-		authSettingsSyncer(settings)
+// emitNotificationEvent emits a notification event
+func (n *NotificationService) emitNotificationEvent(eventType events.EventType, notificationId string, data interface{}) {
+	event := events.NewNotificationEvent(eventType, notificationId, data)
+	if n.eventBus != nil {
+		if err := n.eventBus.EmitNotificationEvent(event); err != nil {
+			log.Printf("Failed to emit notification event: %v", err)
+		}
+	} else if n.app != nil {
+		n.app.Event.Emit("tofe", event)
 	}
 }
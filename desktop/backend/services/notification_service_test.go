@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNotificationService_ShouldNotify(t *testing.T) {
+	ctx := context.Background()
+	svc := NewNotificationService(nil)
+
+	tests := []struct {
+		globalEnabled bool
+		mode          string
+		success       bool
+		want          bool
+	}{
+		// Empty mode follows the global setting
+		{true, "", true, true},
+		{true, "", false, true},
+		{false, "", true, false},
+		{false, "", false, false},
+		// "off" silences the profile even when enabled globally
+		{true, "off", true, false},
+		{true, "off", false, false},
+		// "failures" only notifies on failure, regardless of global setting
+		{true, "failures", true, false},
+		{false, "failures", false, true},
+		// "all" notifies even when disabled globally
+		{false, "all", true, true},
+		{false, "all", false, true},
+	}
+
+	for _, tt := range tests {
+		svc.settingsService.settings.NotificationsEnabled = tt.globalEnabled
+		if got := svc.ShouldNotify(ctx, tt.mode, tt.success); got != tt.want {
+			t.Errorf("ShouldNotify(global=%v, mode=%q, success=%v) = %v, want %v",
+				tt.globalEnabled, tt.mode, tt.success, got, tt.want)
+		}
+	}
+}
+
+func TestPlatformActionBody(t *testing.T) {
+	tests := []struct {
+		actions []NotificationAction
+		want    string
+	}{
+		{nil, "Sync failed"},
+		{[]NotificationAction{{Id: NotifyActionOpenLog, Label: "Open log"}}, "Sync failed\nIn GN Drive: Open log"},
+		{[]NotificationAction{{Id: NotifyActionRetryFailed, Label: "Retry"}, {Id: NotifyActionPauseSchedules, Label: "Pause schedules"}}, "Sync failed\nIn GN Drive: Retry, Pause schedules"},
+	}
+	for _, tt := range tests {
+		if got := platformActionBody(ActionNotification{Body: "Sync failed", Actions: tt.actions}); got != tt.want {
+			t.Errorf("platformActionBody(%v) = %q, want %q", tt.actions, got, tt.want)
+		}
+	}
+}
+
+func TestNotificationService_Snooze(t *testing.T) {
+	ctx := context.Background()
+	svc := NewNotificationService(nil)
+	category := "quota_exceeded"
+	defer svc.ClearNotificationSnooze(ctx, category)
+
+	if svc.notificationSnoozed(category, NotifySeverityWarning) {
+		t.Fatal("category should not be snoozed before SnoozeNotifications")
+	}
+	if err := svc.SnoozeNotifications(ctx, category, time.Hour); err != nil {
+		t.Fatalf("SnoozeNotifications: %v", err)
+	}
+	if !svc.notificationSnoozed(category, NotifySeverityWarning) {
+		t.Error("warning should be held back while snoozed")
+	}
+	if !svc.notificationSnoozed(category, NotifySeverityInfo) {
+		t.Error("info should be held back while snoozed")
+	}
+
+	// A more severe notification breaks through and ends the snooze
+	if svc.notificationSnoozed(category, NotifySeverityError) {
+		t.Error("error should alert despite a warning snooze")
+	}
+	if svc.notificationSnoozed(category, NotifySeverityWarning) {
+		t.Error("snooze should be cleared after a more severe notification")
+	}
+
+	// Dismissal lasts until cleared
+	if err := svc.DismissNotifications(ctx, category); err != nil {
+		t.Fatalf("DismissNotifications: %v", err)
+	}
+	snoozes, err := svc.GetNotificationSnoozes(ctx)
+	if err != nil || len(snoozes) != 1 || !snoozes[0].Dismissed {
+		t.Fatalf("GetNotificationSnoozes = %+v, %v; want one dismissed category", snoozes, err)
+	}
+	if !svc.notificationSnoozed(category, NotifySeverityWarning) {
+		t.Error("warning should be held back while dismissed")
+	}
+	if err := svc.ClearNotificationSnooze(ctx, category); err != nil {
+		t.Fatalf("ClearNotificationSnooze: %v", err)
+	}
+	if svc.notificationSnoozed(category, NotifySeverityWarning) {
+		t.Error("category should alert again after clearing")
+	}
+}
+
+func TestNotificationService_SnoozeExpires(t *testing.T) {
+	ctx := context.Background()
+	svc := NewNotificationService(nil)
+	category := "token_expired"
+	defer svc.ClearNotificationSnooze(ctx, category)
+
+	if err := svc.saveNotificationSnooze(category, time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)); err != nil {
+		t.Fatalf("saveNotificationSnooze: %v", err)
+	}
+	if svc.notificationSnoozed(category, NotifySeverityInfo) {
+		t.Error("expired snooze should not hold notifications back")
+	}
+	if snoozes, _ := svc.GetNotificationSnoozes(ctx); len(snoozes) != 0 {
+		t.Errorf("expired snooze should be removed, got %+v", snoozes)
+	}
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"desktop/backend/models"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Notification categories. Classified sync failures use their error code
+// in lower case instead, e.g. "quota_exceeded", so a recurring cause can be
+// snoozed without silencing other failures.
+const (
+	NotifyCategorySyncCompleted  = "sync_completed"
+	NotifyCategorySyncFailed     = "sync_failed"
+	NotifyCategoryBoard          = "board"
+	NotifyCategoryVerify         = "verify"
+	NotifyCategoryScheduleWindow = "schedule_window"
+	NotifyCategoryFreshness      = "freshness"
+	NotifyCategoryUsageDrift     = "usage_drift"
+	NotifyCategoryMirrorDrift    = "mirror_drift"
+)
+
+// Notification severities, lowest first
+const (
+	NotifySeverityInfo    = "info"
+	NotifySeverityWarning = "warning"
+	NotifySeverityError   = "error"
+)
+
+// NotificationSnooze is a notification category that is silenced until a
+// time, or dismissed until cleared
+type NotificationSnooze struct {
+	Category  string    `json:"category"`
+	Until     time.Time `json:"until,omitempty"` // zero = dismissed
+	Dismissed bool      `json:"dismissed"`
+	Severity  string    `json:"severity"` // highest severity silenced; worse notifications still alert
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// severityRank orders severities; unknown ones rank as info
+func severityRank(severity string) int {
+	switch severity {
+	case NotifySeverityWarning:
+		return 1
+	case NotifySeverityError:
+		return 2
+	}
+	return 0
+}
+
+// failureCategory returns the category of a failure notification
+func failureCategory(errorInfo *models.ErrorInfo) string {
+	if errorInfo != nil && errorInfo.Code != "" {
+		return strings.ToLower(errorInfo.Code)
+	}
+	return NotifyCategorySyncFailed
+}
+
+// SnoozeNotifications silences a notification category for the given
+// duration. Notifications of a higher severity than the latest one seen in
+// the category still alert, and end the snooze.
+func (n *NotificationService) SnoozeNotifications(ctx context.Context, category string, duration time.Duration) error {
+	if duration <= 0 {
+		return fmt.Errorf("snooze duration must be positive")
+	}
+	return n.saveNotificationSnooze(category, time.Now().Add(duration).UTC().Format(time.RFC3339))
+}
+
+// DismissNotifications silences a notification category until it is cleared
+// with ClearNotificationSnooze or a more severe notification arrives
+func (n *NotificationService) DismissNotifications(ctx context.Context, category string) error {
+	return n.saveNotificationSnooze(category, "")
+}
+
+// ClearNotificationSnooze lets a snoozed or dismissed category alert again
+func (n *NotificationService) ClearNotificationSnooze(ctx context.Context, category string) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec("DELETE FROM notification_snoozes WHERE category = ?", category); err != nil {
+		return fmt.Errorf("failed to clear snooze of %s: %w", category, err)
+	}
+	return nil
+}
+
+// GetNotificationSnoozes returns the categories currently snoozed or dismissed
+func (n *NotificationService) GetNotificationSnoozes(ctx context.Context) ([]NotificationSnooze, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query("SELECT category, until, severity, created_at FROM notification_snoozes ORDER BY category")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification snoozes: %w", err)
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	snoozes := []NotificationSnooze{}
+	for rows.Next() {
+		var snooze NotificationSnooze
+		var until, createdAt string
+		if err := rows.Scan(&snooze.Category, &until, &snooze.Severity, &createdAt); err != nil {
+			return nil, err
+		}
+		if until == "" {
+			snooze.Dismissed = true
+		} else if snooze.Until, err = time.Parse(time.RFC3339, until); err != nil || !snooze.Until.After(now) {
+			continue
+		}
+		snooze.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		snoozes = append(snoozes, snooze)
+	}
+	return snoozes, rows.Err()
+}
+
+// saveNotificationSnooze stores a snooze ending at until ("" = dismissed).
+// It silences notifications up to the severity last seen in the category.
+func (n *NotificationService) saveNotificationSnooze(category, until string) error {
+	if category == "" {
+		return fmt.Errorf("notification category is required")
+	}
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+
+	n.mutex.RLock()
+	severity := n.lastSeverity[category]
+	n.mutex.RUnlock()
+	if severity == "" {
+		severity = NotifySeverityInfo
+	}
+
+	if _, err := db.Exec(`INSERT OR REPLACE INTO notification_snoozes (category, until, severity, created_at)
+		VALUES (?, ?, ?, ?)`, category, until, severity, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("failed to snooze %s notifications: %w", category, err)
+	}
+	return nil
+}
+
+// notificationSnoozed reports whether a notification should be held back
+// because its category is snoozed or dismissed, or because the user is
+// presenting and it isn't an error. Expired snoozes are removed, and a
+// notification more severe than the snooze covers ends it.
+func (n *NotificationService) notificationSnoozed(category, severity string) bool {
+	if category == "" {
+		return false
+	}
+	n.mutex.Lock()
+	if n.lastSeverity == nil {
+		n.lastSeverity = make(map[string]string)
+	}
+	n.lastSeverity[category] = severity
+	n.mutex.Unlock()
+
+	if severity != NotifySeverityError && n.syncService != nil && n.syncService.IsPresenting(context.Background()) {
+		log.Printf("Notification held back: %s while presenting", category)
+		return true
+	}
+
+	db, err := GetSharedDB()
+	if err != nil {
+		return false
+	}
+	var until, snoozed string
+	err = db.QueryRow("SELECT until, severity FROM notification_snoozes WHERE category = ?", category).Scan(&until, &snoozed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false
+	}
+	if err != nil {
+		log.Printf("Warning: Could not check notification snooze for %s: %v", category, err)
+		return false
+	}
+
+	expired := false
+	if until != "" {
+		t, err := time.Parse(time.RFC3339, until)
+		expired = err != nil || !t.After(time.Now())
+	}
+	if expired || severityRank(severity) > severityRank(snoozed) {
+		if _, err := db.Exec("DELETE FROM notification_snoozes WHERE category = ?", category); err != nil {
+			log.Printf("Warning: Could not clear notification snooze for %s: %v", category, err)
+		}
+		return false
+	}
+	log.Printf("Notification held back: %s is snoozed", category)
+	return true
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"desktop/backend/models"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// maxDriftReports caps how many drift reports are kept per profile
+const maxDriftReports = 100
+
+// DetectDrift checks whether a profile's destination still mirrors its
+// source, comparing them like VerifyProfile without transferring or fixing
+// anything. Files only in the source are reported as new, files that
+// differ as changed and files only in the destination as extra. Each
+// difference is tracked from the check that first found it until a check
+// no longer does, so the report's drifting_since tells when the mirror
+// started diverging. Status is "in_sync", "drifted" or "failed" (the check
+// couldn't run, also returned as an error); every check is recorded.
+func (o *OperationService) DetectDrift(ctx context.Context, profile models.Profile) (*models.DriftReport, error) {
+	return o.detectDrift(ctx, profile, "")
+}
+
+// detectDrift runs DetectDrift for a schedule, or for no schedule if
+// scheduleId is empty
+func (o *OperationService) detectDrift(ctx context.Context, profile models.Profile, scheduleId string) (*models.DriftReport, error) {
+	start := time.Now()
+	profile, err := resolvePathVariables(profile)
+	var verify *models.VerifyReport
+	if err == nil {
+		verify, err = o.runVerify(ctx, profile, models.VerifyOptions{})
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	report := &models.DriftReport{
+		ScheduleId:  scheduleId,
+		ProfileName: profile.Name,
+		Source:      profile.From,
+		Destination: profile.To,
+		StartTime:   start,
+		EndTime:     time.Now(),
+	}
+	if err == nil {
+		report.Matched = verify.Matched
+		report.NewCount = verify.MissingCount
+		report.ChangedCount = verify.DifferCount
+		report.ExtraCount = verify.ExtraCount
+		report.ErrorCount = verify.ErrorCount
+		report.Files, err = trackDriftFiles(profile.Name, verify, report.EndTime)
+	}
+	switch {
+	case err != nil:
+		report.Status = "failed"
+		report.ErrorMessage = err.Error()
+	case report.Differences() > 0:
+		report.Status = "drifted"
+		for _, f := range report.Files {
+			if report.DriftingSince == nil || f.FirstSeen.Before(*report.DriftingSince) {
+				firstSeen := f.FirstSeen
+				report.DriftingSince = &firstSeen
+			}
+		}
+	default:
+		report.Status = "in_sync"
+	}
+
+	if saveErr := saveDriftReport(report); saveErr != nil {
+		log.Printf("warning: failed to record drift report of %s: %v", profile.Name, saveErr)
+	}
+	log.Printf("Checked %s for drift: %s (%d new, %d changed, %d extra)",
+		profile.Name, report.Status, report.NewCount, report.ChangedCount, report.ExtraCount)
+	if err != nil {
+		return report, fmt.Errorf("drift check failed: %w", err)
+	}
+	return report, nil
+}
+
+// trackDriftFiles records the differences a check found at seen and
+// forgets those it no longer finds, returning the profile's differences
+// oldest first. Differences keep when they were first found. The report
+// lists at most maxVerifyPaths files of a kind, so differences of a kind
+// with more aren't forgotten when unlisted.
+func trackDriftFiles(profileName string, verify *models.VerifyReport, seen time.Time) ([]models.DriftFile, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	known, err := queryDriftFiles(db, profileName)
+	if err != nil {
+		return nil, err
+	}
+	byPath := make(map[string]models.DriftFile, len(known))
+	for _, f := range known {
+		byPath[f.Path] = f
+	}
+
+	seen = seen.UTC().Truncate(time.Second)
+	found := make(map[string]models.DriftFile)
+	complete := make(map[string]bool)
+	for _, kind := range []struct {
+		name  string
+		paths []string
+		count int
+	}{
+		{models.DriftNew, verify.MissingOnDst, verify.MissingCount},
+		{models.DriftChanged, verify.Differ, verify.DifferCount},
+		{models.DriftExtra, verify.MissingOnSrc, verify.ExtraCount},
+	} {
+		complete[kind.name] = len(kind.paths) == kind.count
+		for _, p := range kind.paths {
+			f := models.DriftFile{Path: p, Kind: kind.name, FirstSeen: seen, LastSeen: seen}
+			if prev, ok := byPath[p]; ok {
+				f.FirstSeen = prev.FirstSeen
+			}
+			found[p] = f
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	for _, f := range known {
+		if _, ok := found[f.Path]; ok {
+			continue
+		}
+		if complete[f.Kind] {
+			if _, err := tx.Exec("DELETE FROM drift_files WHERE profile_name = ? AND path = ?", profileName, f.Path); err != nil {
+				return nil, fmt.Errorf("failed to forget drifted file: %w", err)
+			}
+			continue
+		}
+		found[f.Path] = f
+	}
+	for _, f := range found {
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO drift_files (profile_name, path, kind, first_seen, last_seen)
+			VALUES (?, ?, ?, ?, ?)`,
+			profileName, f.Path, f.Kind, f.FirstSeen.UTC().Format(time.RFC3339), f.LastSeen.UTC().Format(time.RFC3339)); err != nil {
+			return nil, fmt.Errorf("failed to record drifted file: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	files := make([]models.DriftFile, 0, len(found))
+	for _, f := range found {
+		files = append(files, f)
+	}
+	sortDriftFiles(files)
+	return files, nil
+}
+
+// sortDriftFiles sorts differences oldest first, then by path
+func sortDriftFiles(files []models.DriftFile) {
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].FirstSeen.Equal(files[j].FirstSeen) {
+			return files[i].FirstSeen.Before(files[j].FirstSeen)
+		}
+		return files[i].Path < files[j].Path
+	})
+}
+
+// GetDriftFiles returns the differences between a profile's source and
+// destination found by its last drift check, oldest first, with when
+// they were first found
+func (o *OperationService) GetDriftFiles(ctx context.Context, profileName string) ([]models.DriftFile, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	files, err := queryDriftFiles(db, profileName)
+	if err != nil {
+		return nil, err
+	}
+	sortDriftFiles(files)
+	return files, nil
+}
+
+// queryDriftFiles returns the recorded differences of a profile
+func queryDriftFiles(db *sql.DB, profileName string) ([]models.DriftFile, error) {
+	rows, err := db.Query("SELECT path, kind, first_seen, last_seen FROM drift_files WHERE profile_name = ?", profileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query drifted files: %w", err)
+	}
+	defer rows.Close()
+
+	files := []models.DriftFile{}
+	for rows.Next() {
+		var f models.DriftFile
+		var firstSeen, lastSeen string
+		if err := rows.Scan(&f.Path, &f.Kind, &firstSeen, &lastSeen); err != nil {
+			return nil, fmt.Errorf("failed to scan drifted file: %w", err)
+		}
+		f.FirstSeen, _ = time.Parse(time.RFC3339, firstSeen)
+		f.LastSeen, _ = time.Parse(time.RFC3339, lastSeen)
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
+// GetDriftReports returns the recorded drift reports of a profile, or of
+// all profiles if profileName is empty, most recent first, without their
+// files
+func (o *OperationService) GetDriftReports(ctx context.Context, profileName string, limit int) ([]models.DriftReport, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = maxDriftReports
+	}
+
+	query := `SELECT id, schedule_id, profile_name, source, destination, status, matched,
+		new_count, changed_count, extra_count, error_count, drifting_since, error_message, start_time, end_time
+		FROM drift_reports`
+	var args []interface{}
+	if profileName != "" {
+		query += " WHERE profile_name = ?"
+		args = append(args, profileName)
+	}
+	query += " ORDER BY start_time DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query drift reports: %w", err)
+	}
+	defer rows.Close()
+
+	reports := []models.DriftReport{}
+	for rows.Next() {
+		var r models.DriftReport
+		var driftingSince sql.NullString
+		var startTime, endTime string
+		if err := rows.Scan(&r.Id, &r.ScheduleId, &r.ProfileName, &r.Source, &r.Destination, &r.Status, &r.Matched,
+			&r.NewCount, &r.ChangedCount, &r.ExtraCount, &r.ErrorCount, &driftingSince, &r.ErrorMessage, &startTime, &endTime); err != nil {
+			return nil, fmt.Errorf("failed to scan drift report: %w", err)
+		}
+		if driftingSince.Valid {
+			if t, err := time.Parse(time.RFC3339, driftingSince.String); err == nil {
+				r.DriftingSince = &t
+			}
+		}
+		r.StartTime, _ = time.Parse(time.RFC3339, startTime)
+		r.EndTime, _ = time.Parse(time.RFC3339, endTime)
+		reports = append(reports, r)
+	}
+	return reports, rows.Err()
+}
+
+// saveDriftReport records a drift report, dropping the profile's oldest
+// reports beyond maxDriftReports
+func saveDriftReport(r *models.DriftReport) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+
+	result, err := db.Exec(`INSERT INTO drift_reports (schedule_id, profile_name, source, destination, status, matched,
+		new_count, changed_count, extra_count, error_count, drifting_since, error_message, start_time, end_time)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.ScheduleId, r.ProfileName, r.Source, r.Destination, r.Status, r.Matched,
+		r.NewCount, r.ChangedCount, r.ExtraCount, r.ErrorCount, timePtrToNullable(r.DriftingSince), r.ErrorMessage,
+		r.StartTime.UTC().Format(time.RFC3339), r.EndTime.UTC().Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+	r.Id, _ = result.LastInsertId()
+
+	_, _ = db.Exec(`DELETE FROM drift_reports WHERE profile_name = ? AND id NOT IN (
+		SELECT id FROM drift_reports WHERE profile_name = ? ORDER BY id DESC LIMIT ?
+	)`, r.ProfileName, r.ProfileName, maxDriftReports)
+	return nil
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"testing"
+	"time"
+)
+
+func TestTrackDriftFiles(t *testing.T) {
+	db, _ := GetSharedDB()
+	db.Exec("DELETE FROM drift_files")
+	first := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	second := first.Add(30 * time.Minute)
+
+	files, err := trackDriftFiles("mirror", &models.VerifyReport{
+		MissingOnDst: []string{"a.txt"}, MissingCount: 1,
+		Differ: []string{"b.txt"}, DifferCount: 1,
+	}, first)
+	if err != nil || len(files) != 2 {
+		t.Fatalf("first check: got %+v, %v", files, err)
+	}
+
+	// b.txt was synced; c.txt appeared on the destination; more extra files
+	// were found than listed, so unlisted ones stay tracked
+	files, err = trackDriftFiles("mirror", &models.VerifyReport{
+		MissingOnDst: []string{"a.txt"}, MissingCount: 1,
+		MissingOnSrc: []string{"c.txt"}, ExtraCount: 2,
+	}, second)
+	if err != nil {
+		t.Fatalf("second check failed: %v", err)
+	}
+	if len(files) != 2 || files[0].Path != "a.txt" || !files[0].FirstSeen.Equal(first) || !files[0].LastSeen.Equal(second) ||
+		files[1].Path != "c.txt" || files[1].Kind != models.DriftExtra || !files[1].FirstSeen.Equal(second) {
+		t.Errorf("unexpected files after the second check: %+v", files)
+	}
+
+	o := NewOperationService(nil)
+	if stored, err := o.GetDriftFiles(context.Background(), "mirror"); err != nil || len(stored) != 2 || stored[0].Path != "a.txt" {
+		t.Errorf("GetDriftFiles = %+v, %v", stored, err)
+	}
+	if other, _ := o.GetDriftFiles(context.Background(), "other"); len(other) != 0 {
+		t.Errorf("expected no files for another profile, got %+v", other)
+	}
+}
+
+func TestOperationService_DriftReports(t *testing.T) {
+	db, _ := GetSharedDB()
+	db.Exec("DELETE FROM drift_reports")
+	o := NewOperationService(nil)
+	start := time.Now().Add(-time.Minute).Truncate(time.Second)
+	since := start.Add(-24 * time.Hour).UTC()
+
+	for i, status := range []string{"in_sync", "drifted"} {
+		r := &models.DriftReport{ProfileName: "mirror", Status: status, Matched: 4,
+			StartTime: start.Add(time.Duration(i) * time.Second), EndTime: start.Add(time.Duration(i+1) * time.Second)}
+		if status == "drifted" {
+			r.ChangedCount, r.DriftingSince = 1, &since
+		}
+		if err := saveDriftReport(r); err != nil {
+			t.Fatalf("saveDriftReport failed: %v", err)
+		}
+	}
+
+	reports, err := o.GetDriftReports(context.Background(), "mirror", 0)
+	if err != nil || len(reports) != 2 {
+		t.Fatalf("expected 2 reports, got %+v, %v", reports, err)
+	}
+	if r := reports[0]; r.Status != "drifted" || r.Differences() != 1 || r.DriftingSince == nil || !r.DriftingSince.Equal(since) {
+		t.Errorf("unexpected latest report: %+v", r)
+	}
+	if reports[1].DriftingSince != nil {
+		t.Errorf("in sync report has drifting_since %v", reports[1].DriftingSince)
+	}
+}
+
+func TestStartedDrifting(t *testing.T) {
+	end := time.Now()
+	now := end.UTC().Truncate(time.Second)
+	earlier := now.Add(-time.Hour)
+
+	if !startedDrifting(&models.DriftReport{DriftingSince: &now, EndTime: end}) {
+		t.Error("differences all found by this check should start drifting")
+	}
+	if startedDrifting(&models.DriftReport{DriftingSince: &earlier, EndTime: end}) {
+		t.Error("differences found before should not start drifting again")
+	}
+	if startedDrifting(&models.DriftReport{EndTime: end}) {
+		t.Error("an in sync report should not start drifting")
+	}
+}
@@ -27,6 +27,9 @@ type OperationTask struct {
 	StartTime time.Time
 	EndTime   *time.Time
 	Status    string
+	Done      chan error `json:"-"` // receives the result, then closed, when the operation ends
+
+	scheduleId string // the schedule whose run this is; see trackScheduleRun
 }
 
 // OperationService handles non-sync rclone operations (copy, move, check, dedupe, file browser, etc.)
@@ -37,6 +40,10 @@ type OperationService struct {
 	taskCounter int
 	mutex       sync.RWMutex
 	envConfig   beConfig.Config
+	syncService *SyncService
+
+	viewsMu      sync.Mutex
+	listingViews map[string]*listingView // ordered and filtered listings paged by BrowseFilesPage
 }
 
 // NewOperationService creates a new operation service
@@ -66,6 +73,12 @@ func (o *OperationService) SetEnvConfig(config beConfig.Config) {
 	}
 }
 
+// SetSyncService sets the sync service whose delta watchers sampled
+// verifications take recently changed files from
+func (o *OperationService) SetSyncService(syncService *SyncService) {
+	o.syncService = syncService
+}
+
 // ServiceName returns the name of the service
 func (o *OperationService) ServiceName() string {
 	return "OperationService"
@@ -119,12 +132,53 @@ func (o *OperationService) ListFiles(ctx context.Context, remotePath string, rec
 	return rclone.ListFiles(opCtx, remotePath, recursive)
 }
 
+// BrowseFiles lists a directory for the file browser through the listing
+// cache. A cached listing younger than the cache TTL is returned as is unless
+// refresh is set; otherwise the remote is listed and the cache updated. When
+// the remote can't be listed (offline, rate-limited) the last cached listing
+// is returned marked stale, with the listing error.
+func (o *OperationService) BrowseFiles(ctx context.Context, remotePath string, refresh bool) (*models.DirListing, error) {
+	cached, err := loadCachedListing(remotePath)
+	if err != nil {
+		log.Printf("warning: failed to load cached listing of %s: %v", remotePath, err)
+		cached = nil
+	}
+	if cached != nil && !cached.Stale && !refresh {
+		return cached, nil
+	}
+
+	fetchedAt := time.Now()
+	entries, err := o.ListFiles(ctx, remotePath, false)
+	if err != nil {
+		if cached == nil || ctx.Err() != nil {
+			return nil, err
+		}
+		cached.Stale = true
+		cached.Error = err.Error()
+		return cached, nil
+	}
+
+	if err := saveCachedListing(remotePath, entries, fetchedAt); err != nil {
+		log.Printf("warning: failed to cache listing of %s: %v", remotePath, err)
+	}
+	return &models.DirListing{Path: remotePath, Entries: entries, FetchedAt: fetchedAt}, nil
+}
+
+// ClearListingCache drops the cached listings of a remote, or of all remotes if remoteName is empty
+func (o *OperationService) ClearListingCache(ctx context.Context, remoteName string) error {
+	if err := clearListingCache(remoteName); err != nil {
+		return fmt.Errorf("failed to clear listing cache: %w", err)
+	}
+	return nil
+}
+
 // DeleteFile deletes a single file at the given remote path
 func (o *OperationService) DeleteFile(ctx context.Context, remotePath string) error {
 	opCtx, err := rclone.SimpleContext(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to initialize rclone config: %w", err)
 	}
+	defer o.invalidateListings(remotePath)
 	return rclone.DeleteFile(opCtx, remotePath)
 }
 
@@ -134,6 +188,7 @@ func (o *OperationService) PurgeDir(ctx context.Context, remotePath string) erro
 	if err != nil {
 		return fmt.Errorf("failed to initialize rclone config: %w", err)
 	}
+	defer o.invalidateListings(remotePath)
 	return rclone.Purge(opCtx, remotePath)
 }
 
@@ -143,9 +198,17 @@ func (o *OperationService) MakeDir(ctx context.Context, remotePath string) error
 	if err != nil {
 		return fmt.Errorf("failed to initialize rclone config: %w", err)
 	}
+	defer o.invalidateListings(remotePath)
 	return rclone.Mkdir(opCtx, remotePath)
 }
 
+// invalidateListings marks cached listings stale after the file browser changed remotePath
+func (o *OperationService) invalidateListings(remotePath string) {
+	if err := invalidateCachedListings(remotePath); err != nil {
+		log.Printf("warning: failed to invalidate cached listings of %s: %v", remotePath, err)
+	}
+}
+
 // GetAbout returns quota information for the given remote
 func (o *OperationService) GetAbout(ctx context.Context, remoteName string) (*models.QuotaInfo, error) {
 	opCtx, err := rclone.SimpleContext(ctx)
@@ -198,6 +261,24 @@ func (o *OperationService) GetActiveTasks(ctx context.Context) (map[int]*Operati
 
 // startOperation starts an async operation
 func (o *OperationService) startOperation(ctx context.Context, operation string, profile models.Profile, tabId string) (int, error) {
+	profile, err := resolvePathVariables(profile)
+	if err != nil {
+		return 0, err
+	}
+	return o.launchOperation(ctx, operation, profile, tabId).Id, nil
+}
+
+// runOperation runs an operation like startOperation and waits for it to end
+func (o *OperationService) runOperation(ctx context.Context, operation string, profile models.Profile, tabId string) error {
+	profile, err := resolvePathVariables(profile)
+	if err != nil {
+		return err
+	}
+	return <-o.launchOperation(ctx, operation, profile, tabId).Done
+}
+
+// launchOperation registers an operation task and runs it in the background
+func (o *OperationService) launchOperation(ctx context.Context, operation string, profile models.Profile, tabId string) *OperationTask {
 	o.mutex.Lock()
 	o.taskCounter++
 	taskId := o.taskCounter
@@ -211,6 +292,7 @@ func (o *OperationService) startOperation(ctx context.Context, operation string,
 		Cancel:    cancel,
 		StartTime: time.Now(),
 		Status:    "starting",
+		Done:      make(chan error, 1),
 	}
 
 	o.activeTasks[taskId] = task
@@ -219,20 +301,83 @@ func (o *OperationService) startOperation(ctx context.Context, operation string,
 	o.emitOperationEvent(events.OperationStarted, tabId, operation, "starting", fmt.Sprintf("Starting %s operation", operation))
 
 	go o.executeOperation(taskCtx, task)
-	return taskId, nil
+	return task
+}
+
+// trackScheduleRun registers a schedule's run as an operation task, so
+// overlapping triggers of the schedule, StopOperation and shutdown find it
+// like any other operation. The returned context is cancelled with the task;
+// finish records the run's result and removes the task.
+func (o *OperationService) trackScheduleRun(ctx context.Context, scheduleId string) (context.Context, func(error)) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.taskCounter++
+	taskCtx, cancel := context.WithCancel(ctx)
+
+	task := &OperationTask{
+		Id:         o.taskCounter,
+		Operation:  "schedule",
+		Cancel:     cancel,
+		StartTime:  time.Now(),
+		Status:     "running",
+		Done:       make(chan error, 1),
+		scheduleId: scheduleId,
+	}
+	o.activeTasks[task.Id] = task
+
+	finish := func(err error) {
+		cancel()
+		o.mutex.Lock()
+		if o.activeTasks[task.Id] == task {
+			delete(o.activeTasks, task.Id)
+		}
+		o.mutex.Unlock()
+		task.Done <- err
+		close(task.Done)
+	}
+	return taskCtx, finish
+}
+
+// scheduleRunTask returns the task of a schedule's run in flight, or nil
+func (o *OperationService) scheduleRunTask(scheduleId string) *OperationTask {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	for _, task := range o.activeTasks {
+		if task.scheduleId == scheduleId {
+			return task
+		}
+	}
+	return nil
+}
+
+// scheduleRunTasks returns the tasks of all schedule runs in flight
+func (o *OperationService) scheduleRunTasks() []*OperationTask {
+	o.mutex.RLock()
+	defer o.mutex.RUnlock()
+	var tasks []*OperationTask
+	for _, task := range o.activeTasks {
+		if task.scheduleId != "" {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
 }
 
 // executeOperation runs the operation asynchronously
 func (o *OperationService) executeOperation(ctx context.Context, task *OperationTask) {
+	var opErr error
 	defer func() {
 		o.mutex.Lock()
 		delete(o.activeTasks, task.Id)
 		o.mutex.Unlock()
+		task.Done <- opErr
+		close(task.Done)
 	}()
 
 	// Initialize rclone config with isolated context
 	ctx, err := rclone.NewTaskContext(ctx, task.Id)
 	if err != nil {
+		opErr = fmt.Errorf("failed to initialize rclone config: %w", err)
 		o.handleOperationError(task, fmt.Sprintf("Failed to initialize rclone config: %v", err))
 		return
 	}
@@ -240,10 +385,12 @@ func (o *OperationService) executeOperation(ctx context.Context, task *Operation
 	// Apply on-the-fly crypt wrapping if configured
 	cryptCleanup, err := rclone.ApplyCryptWrapping(ctx, &task.Profile)
 	if err != nil {
+		opErr = fmt.Errorf("failed to setup encryption: %w", err)
 		o.handleOperationError(task, fmt.Sprintf("Failed to setup encryption: %v", err))
 		return
 	}
 	defer cryptCleanup()
+	rclone.ApplyCompressWrapping(&task.Profile)
 
 	outStatus := make(chan *dto.SyncStatusDTO, 100)
 
@@ -313,6 +460,7 @@ func (o *OperationService) executeOperation(ctx context.Context, task *Operation
 	select {
 	case <-ctx.Done():
 		task.Status = "cancelled"
+		opErr = ctx.Err()
 		o.emitOperationEvent(events.OperationFailed, task.TabId, task.Operation, "cancelled", "Operation was cancelled")
 		return
 	default:
@@ -320,6 +468,7 @@ func (o *OperationService) executeOperation(ctx context.Context, task *Operation
 
 	if err != nil {
 		task.Status = "failed"
+		opErr = err
 		o.handleOperationError(task, fmt.Sprintf("Operation failed: %v", err))
 		return
 	}
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"desktop/backend/delta"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// maxVerifyReports caps how many verify reports are kept per profile
+const maxVerifyReports = 100
+
+// VerifyProfile verifies a profile's destination against its source without
+// transferring anything, and records the report. opts.Download compares the
+// files' contents instead of their hashes or sizes. opts.SamplePercent
+// checks only a reproducible random share of the files, plus the files
+// changed since the profile was last verified, and estimates how many files
+// could have problems. Files that differ, are missing from the destination
+// or can't be read are reported with status "problems"; a verification that
+// couldn't run returns an error and is recorded with status "failed".
+func (o *OperationService) VerifyProfile(ctx context.Context, profile models.Profile, opts models.VerifyOptions) (*models.VerifyReport, error) {
+	return o.verifyProfile(ctx, profile, opts, "")
+}
+
+// verifyProfile runs VerifyProfile for a schedule, or for no schedule if
+// scheduleId is empty
+func (o *OperationService) verifyProfile(ctx context.Context, profile models.Profile, opts models.VerifyOptions, scheduleId string) (*models.VerifyReport, error) {
+	if opts.SamplePercent < 0 || opts.SamplePercent > 100 {
+		return nil, fmt.Errorf("sample percent must be between 0 and 100")
+	}
+	start := time.Now()
+	profile, err := resolvePathVariables(profile)
+	var report *models.VerifyReport
+	if err == nil {
+		report, err = o.runVerify(ctx, profile, opts)
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if report == nil {
+		report = &models.VerifyReport{ProfileName: profile.Name, Source: profile.From, Destination: profile.To, Download: opts.Download}
+	}
+	report.ScheduleId = scheduleId
+	report.StartTime = start
+	report.EndTime = time.Now()
+	switch {
+	case err != nil:
+		report.Status = "failed"
+		report.ErrorMessage = err.Error()
+	case report.Problems() > 0:
+		report.Status = "problems"
+	default:
+		report.Status = "ok"
+	}
+
+	if saveErr := saveVerifyReport(report); saveErr != nil {
+		log.Printf("warning: failed to record verify report of %s: %v", profile.Name, saveErr)
+	}
+	log.Printf("Verified %s: %s (%d matched, %d differ, %d missing, %d errors)",
+		profile.Name, report.Status, report.Matched, report.DifferCount, report.MissingCount, report.ErrorCount)
+	if err != nil {
+		return report, fmt.Errorf("verification failed: %w", err)
+	}
+	return report, nil
+}
+
+// runVerify sets up an isolated rclone context, with encryption when the
+// profile uses it, and runs the verification. Sampled verifications also
+// check the files changed since the profile's last verification.
+func (o *OperationService) runVerify(ctx context.Context, profile models.Profile, opts models.VerifyOptions) (*models.VerifyReport, error) {
+	opCtx, err := rclone.SimpleContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize rclone config: %w", err)
+	}
+	cryptCleanup, err := rclone.ApplyCryptWrapping(opCtx, &profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup encryption: %w", err)
+	}
+	defer cryptCleanup()
+	rclone.ApplyCompressWrapping(&profile)
+
+	var deltaSvc *delta.DeltaService
+	if o.syncService != nil {
+		deltaSvc = o.syncService.deltaSvc
+	}
+	var since time.Time
+	if reports, err := o.GetVerifyReports(ctx, profile.Name, 0); err == nil {
+		for _, r := range reports {
+			if r.Status != "failed" {
+				since = r.StartTime
+				break
+			}
+		}
+	}
+	return rclone.Verify(opCtx, profile, opts, deltaSvc, since)
+}
+
+// VerifyDestinations verifies all of a profile's destinations, To and the
+// fan-out destinations, against its source in one pass: the source is read
+// once and the destinations are compared concurrently. Each destination's
+// report is recorded like VerifyProfile's, and the consolidated report
+// lists the copies and files that are out of date. Status is "ok" when
+// every copy is up to date, "problems" when some copy has problems or
+// couldn't be verified, and "failed" when the source couldn't be read,
+// also returned as an error. Sampling isn't supported.
+func (o *OperationService) VerifyDestinations(ctx context.Context, profile models.Profile, opts models.VerifyOptions) (*models.MultiVerifyReport, error) {
+	return o.verifyDestinations(ctx, profile, opts, "")
+}
+
+// verifyDestinations runs VerifyDestinations for a schedule, or for no
+// schedule if scheduleId is empty
+func (o *OperationService) verifyDestinations(ctx context.Context, profile models.Profile, opts models.VerifyOptions, scheduleId string) (*models.MultiVerifyReport, error) {
+	if opts.SamplePercent != 0 && opts.SamplePercent != 100 {
+		return nil, fmt.Errorf("sampling is not supported when verifying several destinations")
+	}
+	start := time.Now()
+	profile, err := resolvePathVariables(profile)
+	var result *models.MultiVerifyReport
+	if err == nil {
+		result, err = o.runVerifyDestinations(ctx, profile, opts)
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if result == nil {
+		result = &models.MultiVerifyReport{ProfileName: profile.Name, Source: profile.From, Download: opts.Download}
+		for _, dest := range profile.Destinations() {
+			result.Reports = append(result.Reports, models.VerifyReport{
+				ProfileName: profile.Name, Source: profile.From, Destination: dest, Download: opts.Download, ErrorMessage: err.Error(),
+			})
+		}
+	}
+	result.StartTime = start
+	result.EndTime = time.Now()
+
+	for i := range result.Reports {
+		report := &result.Reports[i]
+		report.ScheduleId = scheduleId
+		report.StartTime = result.StartTime
+		report.EndTime = result.EndTime
+		switch {
+		case report.ErrorMessage != "":
+			report.Status = "failed"
+		case report.Problems() > 0:
+			report.Status = "problems"
+		default:
+			report.Status = "ok"
+		}
+		if report.Status != "ok" {
+			result.OutOfDate = append(result.OutOfDate, report.Destination)
+		}
+		if saveErr := saveVerifyReport(report); saveErr != nil {
+			log.Printf("warning: failed to record verify report of %s: %v", profile.Name, saveErr)
+		}
+	}
+	switch {
+	case err != nil:
+		result.Status = "failed"
+	case len(result.OutOfDate) > 0:
+		result.Status = "problems"
+	default:
+		result.Status = "ok"
+	}
+
+	log.Printf("Verified %d destinations of %s: %s (%d out of date)", len(result.Reports), profile.Name, result.Status, len(result.OutOfDate))
+	if err != nil {
+		return result, fmt.Errorf("verification failed: %w", err)
+	}
+	return result, nil
+}
+
+// runVerifyDestinations sets up an isolated rclone context, with source
+// encryption when the profile uses it, and verifies the destinations.
+// Fan-out profiles can't encrypt or compress their destinations.
+func (o *OperationService) runVerifyDestinations(ctx context.Context, profile models.Profile, opts models.VerifyOptions) (*models.MultiVerifyReport, error) {
+	opCtx, err := rclone.SimpleContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize rclone config: %w", err)
+	}
+	original := profile
+	cryptCleanup, err := rclone.ApplyCryptWrapping(opCtx, &profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup encryption: %w", err)
+	}
+	defer cryptCleanup()
+	rclone.ApplyCompressWrapping(&profile)
+
+	result, err := rclone.VerifyDestinations(opCtx, profile, opts)
+	if result == nil {
+		return nil, err
+	}
+	// Report the profile's paths, not the temporary wrapping remotes
+	names := make(map[string]string)
+	for i, dest := range profile.Destinations() {
+		names[dest] = original.Destinations()[i]
+		result.Reports[i].Source = original.From
+		result.Reports[i].Destination = original.Destinations()[i]
+	}
+	result.Source = original.From
+	for i := range result.Files {
+		for _, list := range [][]string{result.Files[i].Differ, result.Files[i].Missing, result.Files[i].Errors} {
+			for j, dest := range list {
+				list[j] = names[dest]
+			}
+		}
+	}
+	return result, err
+}
+
+// GetVerifyReports returns the recorded verify reports of a profile, or of
+// all profiles if profileName is empty, most recent first
+func (o *OperationService) GetVerifyReports(ctx context.Context, profileName string, limit int) ([]models.VerifyReport, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = maxVerifyReports
+	}
+
+	query := verifyReportColumns
+	var args []interface{}
+	if profileName != "" {
+		query += " WHERE profile_name = ?"
+		args = append(args, profileName)
+	}
+	query += " ORDER BY start_time DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+	return queryVerifyReports(db, query, args...)
+}
+
+// getVerifyReport returns the recorded verify report with the given ID
+func getVerifyReport(id int64) (*models.VerifyReport, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	reports, err := queryVerifyReports(db, verifyReportColumns+" WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(reports) == 0 {
+		return nil, fmt.Errorf("verify report %d not found", id)
+	}
+	return &reports[0], nil
+}
+
+// verifyReportColumns selects the columns queryVerifyReports scans
+const verifyReportColumns = `SELECT id, schedule_id, profile_name, source, destination, download, crypt_check, sample, status, matched,
+		differ, differ_count, missing_on_dst, missing_count, missing_on_src, extra_count,
+		errors, error_count, error_message, start_time, end_time
+		FROM verify_reports`
+
+// queryVerifyReports runs a query selecting verifyReportColumns
+func queryVerifyReports(db *sql.DB, query string, args ...interface{}) ([]models.VerifyReport, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query verify reports: %w", err)
+	}
+	defer rows.Close()
+
+	reports := []models.VerifyReport{}
+	for rows.Next() {
+		var r models.VerifyReport
+		var download, cryptCheck int
+		var sample, differ, missingOnDst, missingOnSrc, errs, startTime, endTime string
+		if err := rows.Scan(&r.Id, &r.ScheduleId, &r.ProfileName, &r.Source, &r.Destination, &download, &cryptCheck, &sample, &r.Status, &r.Matched,
+			&differ, &r.DifferCount, &missingOnDst, &r.MissingCount, &missingOnSrc, &r.ExtraCount,
+			&errs, &r.ErrorCount, &r.ErrorMessage, &startTime, &endTime); err != nil {
+			return nil, fmt.Errorf("failed to scan verify report: %w", err)
+		}
+		r.Download = download != 0
+		r.CryptCheck = cryptCheck != 0
+		if sample != "" {
+			r.Sample = &models.VerifySample{}
+			if err := json.Unmarshal([]byte(sample), r.Sample); err != nil {
+				r.Sample = nil
+			}
+		}
+		r.Differ = unmarshalStringSlice(differ)
+		r.MissingOnDst = unmarshalStringSlice(missingOnDst)
+		r.MissingOnSrc = unmarshalStringSlice(missingOnSrc)
+		r.Errors = unmarshalStringSlice(errs)
+		r.StartTime, _ = time.Parse(time.RFC3339, startTime)
+		r.EndTime, _ = time.Parse(time.RFC3339, endTime)
+		reports = append(reports, r)
+	}
+	return reports, rows.Err()
+}
+
+// saveVerifyReport records a verify report, dropping the profile's oldest
+// reports beyond maxVerifyReports
+func saveVerifyReport(r *models.VerifyReport) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+
+	sample := ""
+	if r.Sample != nil {
+		data, err := json.Marshal(r.Sample)
+		if err != nil {
+			return err
+		}
+		sample = string(data)
+	}
+	result, err := db.Exec(`INSERT INTO verify_reports (schedule_id, profile_name, source, destination, download, crypt_check, sample, status, matched,
+		differ, differ_count, missing_on_dst, missing_count, missing_on_src, extra_count,
+		errors, error_count, error_message, start_time, end_time)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.ScheduleId, r.ProfileName, r.Source, r.Destination, boolToInt(r.Download), boolToInt(r.CryptCheck), sample, r.Status, r.Matched,
+		marshalStringSlice(r.Differ), r.DifferCount, marshalStringSlice(r.MissingOnDst), r.MissingCount,
+		marshalStringSlice(r.MissingOnSrc), r.ExtraCount, marshalStringSlice(r.Errors), r.ErrorCount,
+		r.ErrorMessage, r.StartTime.UTC().Format(time.RFC3339), r.EndTime.UTC().Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+	r.Id, _ = result.LastInsertId()
+
+	_, _ = db.Exec(`DELETE FROM verify_reports WHERE profile_name = ? AND id NOT IN (
+		SELECT id FROM verify_reports WHERE profile_name = ? ORDER BY id DESC LIMIT ?
+	)`, r.ProfileName, r.ProfileName, maxVerifyReports)
+	return nil
+}
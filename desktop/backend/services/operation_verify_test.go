@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"testing"
+	"time"
+)
+
+func TestOperationService_VerifyReports(t *testing.T) {
+	db, _ := GetSharedDB()
+	db.Exec("DELETE FROM verify_reports")
+	o := NewOperationService(nil)
+	ctx := context.Background()
+
+	start := time.Now().Add(-time.Minute).Truncate(time.Second)
+	for i, status := range []string{"ok", "problems"} {
+		r := &models.VerifyReport{ScheduleId: "weekly", ProfileName: "docs", Status: status, Matched: 3,
+			StartTime: start.Add(time.Duration(i) * time.Second), EndTime: start.Add(time.Duration(i+1) * time.Second)}
+		if status == "problems" {
+			r.Differ, r.DifferCount = []string{"a.txt"}, 1
+			r.MissingOnDst, r.MissingCount = []string{"b.txt"}, 1
+		}
+		if err := saveVerifyReport(r); err != nil {
+			t.Fatalf("saveVerifyReport failed: %v", err)
+		}
+	}
+	saveVerifyReport(&models.VerifyReport{ProfileName: "photos", Status: "failed", StartTime: start, EndTime: start})
+
+	reports, err := o.GetVerifyReports(ctx, "docs", 0)
+	if err != nil || len(reports) != 2 {
+		t.Fatalf("expected 2 reports of docs, got %+v, %v", reports, err)
+	}
+	latest := reports[0]
+	if latest.Status != "problems" || latest.Problems() != 2 || latest.ScheduleId != "weekly" ||
+		len(latest.Differ) != 1 || latest.MissingOnDst[0] != "b.txt" || !latest.StartTime.Equal(start.Add(time.Second)) {
+		t.Errorf("unexpected latest report: %+v", latest)
+	}
+	if all, _ := o.GetVerifyReports(ctx, "", 1); len(all) != 1 {
+		t.Errorf("expected the limit to apply, got %d reports", len(all))
+	}
+}
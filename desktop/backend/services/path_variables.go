@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"desktop/backend/validation"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// GetPathVariables returns the path variables defined on this machine and
+// those profiles use without a definition here, sorted by name
+func (c *ConfigService) GetPathVariables(ctx context.Context) ([]models.PathVariable, error) {
+	defined, err := loadPathVariables()
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := c.GetProfiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]*models.PathVariable)
+	for name, path := range defined {
+		byName[name] = &models.PathVariable{Name: name, Path: path, Profiles: []string{}}
+	}
+	for _, p := range profiles {
+		for _, name := range profilePathVariables(p) {
+			v, ok := byName[name]
+			if !ok {
+				v = &models.PathVariable{Name: name, Profiles: []string{}}
+				byName[name] = v
+			}
+			v.Profiles = append(v.Profiles, p.Name)
+		}
+	}
+
+	variables := make([]models.PathVariable, 0, len(byName))
+	for _, v := range byName {
+		variables = append(variables, *v)
+	}
+	sort.Slice(variables, func(i, j int) bool { return variables[i].Name < variables[j].Name })
+	return variables, nil
+}
+
+// SetPathVariable defines what a path variable stands for on this machine,
+// e.g. "PHOTOS_DIR" as "/Users/me/Pictures" on a Mac and "D:\Photos" on a
+// Windows PC. path must be an absolute local path.
+func (c *ConfigService) SetPathVariable(ctx context.Context, name, path string) error {
+	if err := validation.ValidatePathVariableName(name); err != nil {
+		return err
+	}
+	if !filepath.IsAbs(path) || strings.Contains(path, "\x00") {
+		return fmt.Errorf("path variable '%s' must be an absolute local path", name)
+	}
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec("INSERT OR REPLACE INTO path_variables (name, path) VALUES (?, ?)", name, path); err != nil {
+		return fmt.Errorf("failed to save path variable: %w", err)
+	}
+	log.Printf("Path variable '%s' set to %s", name, path)
+	return nil
+}
+
+// DeletePathVariable removes a path variable's definition on this machine.
+// Runs of profiles that use it fail until it is defined again.
+func (c *ConfigService) DeletePathVariable(ctx context.Context, name string) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec("DELETE FROM path_variables WHERE name = ?", name); err != nil {
+		return fmt.Errorf("failed to delete path variable: %w", err)
+	}
+	return nil
+}
+
+// loadPathVariables reads this machine's path variables
+func loadPathVariables() (map[string]string, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query("SELECT name, path FROM path_variables")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	variables := make(map[string]string)
+	for rows.Next() {
+		var name, path string
+		if err := rows.Scan(&name, &path); err != nil {
+			return nil, err
+		}
+		variables[name] = path
+	}
+	return variables, rows.Err()
+}
+
+// profilePaths returns pointers to a profile's local or remote paths
+func profilePaths(p *models.Profile) []*string {
+	paths := []*string{&p.From, &p.To, &p.FailoverTo, &p.BackupPath, &p.CachePath, &p.FilterFromFile}
+	for i := range p.FanOutTo {
+		paths = append(paths, &p.FanOutTo[i])
+	}
+	return paths
+}
+
+// profilePathVariables returns the path variables a profile's paths use
+func profilePathVariables(p models.Profile) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, path := range profilePaths(&p) {
+		if name, _, ok := validation.SplitPathVariable(*path); ok && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// resolvePathVariables returns the profile with the path variables in its
+// paths replaced by what they stand for on this machine
+func resolvePathVariables(profile models.Profile) (models.Profile, error) {
+	if len(profilePathVariables(profile)) == 0 {
+		return profile, nil
+	}
+	variables, err := loadPathVariables()
+	if err != nil {
+		return profile, fmt.Errorf("failed to load path variables: %w", err)
+	}
+	return expandPathVariables(profile, variables)
+}
+
+// expandPathVariables replaces the path variables in a profile's paths
+func expandPathVariables(profile models.Profile, variables map[string]string) (models.Profile, error) {
+	profile.FanOutTo = append([]string(nil), profile.FanOutTo...)
+	for _, path := range profilePaths(&profile) {
+		name, rest, ok := validation.SplitPathVariable(*path)
+		if !ok {
+			continue
+		}
+		value, defined := variables[name]
+		if !defined {
+			return profile, fmt.Errorf("path variable ${%s} of profile '%s' is not defined on this machine", name, profile.Name)
+		}
+		if rest != "" {
+			value = strings.TrimRight(value, `/\`) + rest
+		}
+		*path = value
+	}
+	return profile, nil
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"desktop/backend/models"
+	"reflect"
+	"testing"
+)
+
+func TestExpandPathVariables(t *testing.T) {
+	profile := models.Profile{
+		Name:     "photos",
+		From:     "${PHOTOS_DIR}/2024",
+		To:       "gdrive:Photos",
+		FanOutTo: []string{"${BACKUP_DIR}", "s3:photos"},
+	}
+	if got := profilePathVariables(profile); !reflect.DeepEqual(got, []string{"PHOTOS_DIR", "BACKUP_DIR"}) {
+		t.Errorf("profilePathVariables() = %v", got)
+	}
+
+	resolved, err := expandPathVariables(profile, map[string]string{"PHOTOS_DIR": `D:\Photos\`, "BACKUP_DIR": "/Volumes/Backup"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.From != `D:\Photos/2024` || resolved.To != "gdrive:Photos" {
+		t.Errorf("expandPathVariables() = from %q, to %q", resolved.From, resolved.To)
+	}
+	if resolved.FanOutTo[0] != "/Volumes/Backup" || resolved.FanOutTo[1] != "s3:photos" {
+		t.Errorf("expandPathVariables() fan-out = %q", resolved.FanOutTo)
+	}
+	if profile.FanOutTo[0] != "${BACKUP_DIR}" {
+		t.Error("expandPathVariables() changed the original profile")
+	}
+
+	if _, err := expandPathVariables(profile, map[string]string{"PHOTOS_DIR": "/photos"}); err == nil {
+		t.Error("expandPathVariables() accepted an undefined variable")
+	}
+}
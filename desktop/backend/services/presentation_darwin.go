@@ -0,0 +1,43 @@
+//go:build darwin
+
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// presentationActive reports whether Do Not Disturb or another Focus is on.
+// macOS turns Focus on by itself while sharing the screen or playing a game
+// full screen, if the user allows it. The Focus state lives in the Do Not
+// Disturb assertion store.
+func presentationActive() (bool, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return false, err
+	}
+	data, err := os.ReadFile(filepath.Join(home, "Library", "DoNotDisturb", "DB", "Assertions.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil // macOS before Monterey, or Focus never used
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var store struct {
+		Data []struct {
+			StoreAssertionRecords []json.RawMessage `json:"storeAssertionRecords"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &store); err != nil {
+		return false, err
+	}
+	for _, d := range store.Data {
+		if len(d.StoreAssertionRecords) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
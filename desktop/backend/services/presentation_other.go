@@ -0,0 +1,22 @@
+//go:build !windows && !darwin
+
+package services
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// presentationActive reports whether GNOME's Do Not Disturb is on. Other
+// desktops have no common way to tell, and are never considered presenting.
+func presentationActive() (bool, error) {
+	gsettings, err := exec.LookPath("gsettings")
+	if err != nil {
+		return false, nil
+	}
+	out, err := exec.Command(gsettings, "get", "org.gnome.desktop.notifications", "show-banners").Output()
+	if err != nil {
+		return false, nil // schema not installed: not GNOME
+	}
+	return strings.TrimSpace(string(out)) == "false", nil
+}
@@ -0,0 +1,36 @@
+//go:build windows
+
+package services
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var procSHQueryUserNotificationState = syscall.NewLazyDLL("shell32.dll").NewProc("SHQueryUserNotificationState")
+
+// QUERY_USER_NOTIFICATION_STATE values during which the user shouldn't be disturbed
+const (
+	qunsBusy                 = 2 // a full-screen application is running
+	qunsRunningD3DFullScreen = 3 // a full-screen Direct3D application (e.g. a game) is running
+	qunsPresentationMode     = 4 // presentation mode is on
+)
+
+// presentationActive reports whether the user is presenting or running a
+// full-screen application
+func presentationActive() (bool, error) {
+	if err := procSHQueryUserNotificationState.Find(); err != nil {
+		return false, err
+	}
+	var state int32
+	hr, _, _ := procSHQueryUserNotificationState.Call(uintptr(unsafe.Pointer(&state)))
+	if hr != 0 {
+		return false, fmt.Errorf("SHQueryUserNotificationState failed: 0x%x", hr)
+	}
+	switch state {
+	case qunsBusy, qunsRunningD3DFullScreen, qunsPresentationMode:
+		return true, nil
+	}
+	return false, nil
+}
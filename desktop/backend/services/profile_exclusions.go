@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/dto"
+	"desktop/backend/models"
+	"desktop/backend/validation"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+)
+
+const (
+	// exclusionRuns is how many of a profile's latest sync runs exclusion
+	// suggestions are drawn from
+	exclusionRuns = 50
+	// minFailingRuns is how many runs a file must fail in to suggest excluding it
+	minFailingRuns = 3
+	// minChurnRuns is how many runs must transfer a file, or temp files of
+	// the same kind, to suggest excluding it as churn
+	minChurnRuns = 3
+	// churnShare is the share of the runs that must transfer a file that
+	// isn't a temp file for it to count as churn
+	churnShare = 0.8
+	// volumeShare is the share of the transferred bytes a file must make up
+	// to suggest excluding it for its volume
+	volumeShare = 0.5
+	// maxSuggestionPaths caps the example files of a suggestion
+	maxSuggestionPaths = 10
+)
+
+// tempFilePatterns match temporary and lock files that editors and tools
+// create and remove as they work; they are suggested as patterns rather
+// than file by file
+var tempFilePatterns = []string{
+	"*.tmp", "*.temp", "*.swp", "*.part", "*.crdownload", "*.lock",
+	"~$*", ".~lock.*#", ".DS_Store", "Thumbs.db",
+}
+
+// SuggestExclusions analyzes a profile's recent sync runs for files that
+// keep failing, temp and lock files or other files transferred again run
+// after run, and files that make up most of the transferred data, and
+// suggests exclude rules for them. Rules the profile already has aren't
+// suggested again. Runs only report their slowest, largest and most-retried
+// files, so files that never stood out in a run aren't seen.
+func (c *ConfigService) SuggestExclusions(ctx context.Context, profileName string) ([]models.ExclusionSuggestion, error) {
+	profile, err := c.findProfile(ctx, profileName)
+	if err != nil {
+		return nil, err
+	}
+	if c.historyService == nil {
+		return []models.ExclusionSuggestion{}, nil
+	}
+	entries, err := c.historyService.GetHistoryForProfile(ctx, profileName)
+	if err != nil {
+		return nil, err
+	}
+	return suggestExclusions(profile, entries), nil
+}
+
+// AcceptExclusions adds exclude rules, such as the ones SuggestExclusions
+// returned, to a profile's excluded paths and saves it. Rules it already
+// has are skipped. Returns the updated profile.
+func (c *ConfigService) AcceptExclusions(ctx context.Context, profileName string, rules []string) (*models.Profile, error) {
+	profile, err := c.findProfile(ctx, profileName)
+	if err != nil {
+		return nil, err
+	}
+	if profile.UseRegex {
+		return nil, &validation.ValidationError{Field: "use_regex", Message: "the profile's filters are regular expressions; add the rules by hand"}
+	}
+
+	before := len(profile.ExcludedPaths)
+	profile.ExcludedPaths = appendUnique(append([]string{}, profile.ExcludedPaths...), rules...)
+	if len(profile.ExcludedPaths) == before {
+		return &profile, nil
+	}
+	if err := c.UpdateProfile(ctx, profile); err != nil {
+		return nil, err
+	}
+	log.Printf("Added %d suggested exclusions to profile '%s'", len(profile.ExcludedPaths)-before, profileName)
+	return &profile, nil
+}
+
+// findProfile returns a copy of the named profile
+func (c *ConfigService) findProfile(ctx context.Context, name string) (models.Profile, error) {
+	profiles, err := c.GetProfiles(ctx)
+	if err != nil {
+		return models.Profile{}, err
+	}
+	for _, p := range profiles {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return models.Profile{}, fmt.Errorf("profile '%s' not found", name)
+}
+
+// fileRunStats is how a file fared in the analyzed runs
+type fileRunStats struct {
+	failed      int
+	transferred int
+	bytes       int64
+}
+
+// suggestExclusions works out exclude rules from a profile's history,
+// newest entries first. Files failing in a run are suggested before churn,
+// churn before volume, and each rule only once.
+func suggestExclusions(profile models.Profile, entries []models.HistoryEntry) []models.ExclusionSuggestion {
+	files := make(map[string]*fileRunStats)
+	patternRuns := make(map[string]int) // temp file pattern -> runs that transferred a match
+	runs := 0
+	var totalBytes int64
+	for _, e := range entries {
+		switch SyncAction(e.Action) {
+		case ActionPull, ActionPush, ActionBi, ActionBiResync:
+		default:
+			continue
+		}
+		if runs == exclusionRuns {
+			break
+		}
+		runs++
+		totalBytes += e.BytesTransferred
+		if e.Report == nil {
+			continue
+		}
+
+		// A file can be in several of a report's lists
+		seen := make(map[string]dto.FileReportEntry)
+		for _, list := range [][]dto.FileReportEntry{e.Report.Slowest, e.Report.Largest, e.Report.MostRetried} {
+			for _, f := range list {
+				seen[f.Name] = f
+			}
+		}
+		matched := make(map[string]bool)
+		for name, f := range seen {
+			st := files[name]
+			if st == nil {
+				st = &fileRunStats{}
+				files[name] = st
+			}
+			if f.Failed {
+				st.failed++
+				continue
+			}
+			st.transferred++
+			st.bytes += f.Size
+			if pattern := tempFilePattern(name); pattern != "" && !matched[pattern] {
+				matched[pattern] = true
+				patternRuns[pattern]++
+			}
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var suggestions []models.ExclusionSuggestion
+	suggested := make(map[string]bool)
+	for _, rule := range profile.ExcludedPaths {
+		suggested[rule] = true
+	}
+	add := func(s models.ExclusionSuggestion) {
+		if !suggested[s.Rule] {
+			suggested[s.Rule] = true
+			suggestions = append(suggestions, s)
+		}
+	}
+
+	for _, name := range names {
+		if st := files[name]; st.failed >= minFailingRuns {
+			add(models.ExclusionSuggestion{
+				Rule: "/" + escapeFilterGlob(name), Reason: models.ExclusionFailing, Paths: []string{name}, Runs: st.failed,
+				Detail: fmt.Sprintf("failed in %d of the last %d runs", st.failed, runs),
+			})
+		}
+	}
+
+	for _, pattern := range tempFilePatterns {
+		if patternRuns[pattern] < minChurnRuns {
+			continue
+		}
+		s := models.ExclusionSuggestion{
+			Rule: pattern, Reason: models.ExclusionChurn, Runs: patternRuns[pattern],
+			Detail: fmt.Sprintf("temporary or lock files transferred by %d of the last %d runs", patternRuns[pattern], runs),
+		}
+		for _, name := range names {
+			if st := files[name]; st.transferred > 0 && tempFilePattern(name) == pattern {
+				s.Bytes += st.bytes
+				if len(s.Paths) < maxSuggestionPaths {
+					s.Paths = append(s.Paths, name)
+				}
+			}
+		}
+		add(s)
+	}
+	for _, name := range names {
+		st := files[name]
+		if tempFilePattern(name) != "" || st.transferred < minChurnRuns || float64(st.transferred) < churnShare*float64(runs) {
+			continue
+		}
+		add(models.ExclusionSuggestion{
+			Rule: "/" + escapeFilterGlob(name), Reason: models.ExclusionChurn, Paths: []string{name}, Runs: st.transferred, Bytes: st.bytes,
+			Detail: fmt.Sprintf("transferred again by %d of the last %d runs", st.transferred, runs),
+		})
+	}
+
+	for _, name := range names {
+		st := files[name]
+		if st.transferred < 2 || totalBytes <= 0 || float64(st.bytes) < volumeShare*float64(totalBytes) {
+			continue
+		}
+		add(models.ExclusionSuggestion{
+			Rule: "/" + escapeFilterGlob(name), Reason: models.ExclusionVolume, Paths: []string{name}, Runs: st.transferred, Bytes: st.bytes,
+			Detail: fmt.Sprintf("%d%% of the data the last %d runs transferred", st.bytes*100/totalBytes, runs),
+		})
+	}
+
+	if suggestions == nil {
+		suggestions = []models.ExclusionSuggestion{}
+	}
+	return suggestions
+}
+
+// tempFilePattern returns the pattern of tempFilePatterns the file's name
+// matches, or ""
+func tempFilePattern(name string) string {
+	base := path.Base(name)
+	for _, pattern := range tempFilePatterns {
+		if ok, _ := path.Match(pattern, base); ok {
+			return pattern
+		}
+	}
+	return ""
+}
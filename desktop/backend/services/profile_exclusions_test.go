@@ -0,0 +1,53 @@
+package services
+
+import (
+	"desktop/backend/dto"
+	"desktop/backend/models"
+	"testing"
+)
+
+func TestSuggestExclusions(t *testing.T) {
+	run := func(bytes int64, files ...dto.FileReportEntry) models.HistoryEntry {
+		return models.HistoryEntry{Action: "push", Status: "completed", BytesTransferred: bytes, Report: &dto.TransferReport{Largest: files}}
+	}
+	locked := dto.FileReportEntry{Name: "db/app.sqlite", Size: 10, Failed: true}
+	tmp := func(name string) dto.FileReportEntry { return dto.FileReportEntry{Name: name, Size: 1} }
+	video := dto.FileReportEntry{Name: "video/raw.mov", Size: 900}
+
+	entries := []models.HistoryEntry{
+		run(1000, locked, tmp("docs/~$report.docx"), video),
+		run(1000, locked, tmp("docs/a.tmp"), video),
+		run(100, locked, tmp("docs/b.tmp")),
+		run(100, tmp("notes/c.tmp"), tmp("docs/~$other.docx")),
+		{Action: "copy", Report: &dto.TransferReport{Largest: []dto.FileReportEntry{locked}}}, // not a sync
+	}
+
+	got := suggestExclusions(models.Profile{}, entries)
+	want := []struct{ rule, reason string }{
+		{"/db/app.sqlite", models.ExclusionFailing},
+		{"*.tmp", models.ExclusionChurn},
+		{"/video/raw.mov", models.ExclusionVolume},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d suggestions %+v, want %d", len(got), got, len(want))
+	}
+	for i, w := range want {
+		if got[i].Rule != w.rule || got[i].Reason != w.reason {
+			t.Errorf("suggestion %d = %s (%s), want %s (%s)", i, got[i].Rule, got[i].Reason, w.rule, w.reason)
+		}
+	}
+	if got[0].Runs != 3 {
+		t.Errorf("failing runs = %d, want 3", got[0].Runs)
+	}
+	if len(got[1].Paths) != 3 || got[1].Runs != 3 {
+		t.Errorf("churn paths = %v over %d runs, want 3 over 3", got[1].Paths, got[1].Runs)
+	}
+
+	// Rules the profile already has aren't suggested again
+	got = suggestExclusions(models.Profile{ExcludedPaths: []string{"*.tmp"}}, entries)
+	for _, s := range got {
+		if s.Rule == "*.tmp" {
+			t.Errorf("suggested existing rule %s", s.Rule)
+		}
+	}
+}
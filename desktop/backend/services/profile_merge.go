@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/events"
+	"desktop/backend/models"
+	"desktop/backend/validation"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// DetectProfileOverlaps reports pairs of profiles that do redundant work or
+// whose deletion rules conflict
+func (c *ConfigService) DetectProfileOverlaps(ctx context.Context) ([]models.ProfileOverlap, error) {
+	profiles, err := c.GetProfiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return validation.DetectProfileOverlaps(profiles), nil
+}
+
+// PreviewProfileMerge shows the profile that merging mergeNames into keepName
+// would produce, and how many history entries and schedules would follow it
+func (c *ConfigService) PreviewProfileMerge(ctx context.Context, keepName string, mergeNames []string) (*models.ProfileMergePreview, error) {
+	if _, err := c.GetProfiles(ctx); err != nil {
+		return nil, err
+	}
+	c.mutex.RLock()
+	preview, err := c.buildMergePreview(keepName, mergeNames)
+	c.mutex.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	preview.HistoryEntries, preview.Schedules = countProfileReferences(mergeNames)
+	return preview, nil
+}
+
+// MergeProfiles folds the profiles in mergeNames into keepName: their filters
+// are combined into it, their history entries and schedules are reassigned to
+// it, and they are deleted
+func (c *ConfigService) MergeProfiles(ctx context.Context, keepName string, mergeNames []string) (*models.Profile, error) {
+	if _, err := c.GetProfiles(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	preview, err := c.buildMergePreview(keepName, mergeNames)
+	if err != nil {
+		c.mutex.Unlock()
+		return nil, err
+	}
+	merged := preview.Profile
+	if err := c.validateProfile(merged); err != nil {
+		c.mutex.Unlock()
+		return nil, fmt.Errorf("invalid merged profile: %w", err)
+	}
+	if err := c.applyMerge(merged, preview.Removed); err != nil {
+		c.mutex.Unlock()
+		return nil, err
+	}
+	c.mutex.Unlock()
+
+	if c.schedulerService != nil {
+		if n, err := c.schedulerService.reassignProfileSchedules(preview.Removed, merged.Name); err != nil {
+			log.Printf("Warning: merged profiles into '%s' but only reassigned %d schedules: %v", merged.Name, n, err)
+		}
+	}
+
+	c.emitConfigEvent(events.ProfileUpdated, merged.Name, merged)
+	for _, name := range preview.Removed {
+		c.emitConfigEvent(events.ProfileDeleted, name, nil)
+	}
+	log.Printf("Merged profiles %s into '%s'", strings.Join(preview.Removed, ", "), merged.Name)
+	return &merged, nil
+}
+
+// buildMergePreview resolves the profiles and combines their settings.
+// Caller must hold c.mutex.
+func (c *ConfigService) buildMergePreview(keepName string, mergeNames []string) (*models.ProfileMergePreview, error) {
+	byName := make(map[string]models.Profile, len(c.configInfo.Profiles))
+	for _, p := range c.configInfo.Profiles {
+		byName[p.Name] = p
+	}
+
+	keep, ok := byName[keepName]
+	if !ok {
+		return nil, fmt.Errorf("profile '%s' not found", keepName)
+	}
+	others := make([]models.Profile, 0, len(mergeNames))
+	seen := make(map[string]bool)
+	for _, name := range mergeNames {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		p, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("profile '%s' not found", name)
+		}
+		others = append(others, p)
+	}
+	if err := validation.ValidateMerge(keep, others); err != nil {
+		return nil, err
+	}
+
+	merged, notes := mergeProfileSettings(keep, others)
+	removed := make([]string, len(others))
+	for i, p := range others {
+		removed[i] = p.Name
+	}
+	return &models.ProfileMergePreview{Profile: merged, Removed: removed, Notes: notes}, nil
+}
+
+// applyMerge saves the merged profile, moves the history of the removed
+// profiles to it and deletes them, in one transaction. Caller must hold c.mutex.
+func (c *ConfigService) applyMerge(merged models.Profile, removed []string) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := saveProfile(tx, merged); err != nil {
+		return fmt.Errorf("failed to save merged profile: %w", err)
+	}
+	for _, name := range removed {
+		if _, err := tx.Exec("UPDATE history SET profile_name = ? WHERE profile_name = ?", merged.Name, name); err != nil {
+			return fmt.Errorf("failed to reassign history of '%s': %w", name, err)
+		}
+		if err := mergeHistoryDays(tx, name, merged.Name); err != nil {
+			return fmt.Errorf("failed to reassign daily history of '%s': %w", name, err)
+		}
+		if _, err := tx.Exec("DELETE FROM profiles WHERE name = ?", name); err != nil {
+			return fmt.Errorf("failed to delete profile '%s': %w", name, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	drop := make(map[string]bool, len(removed))
+	for _, name := range removed {
+		drop[name] = true
+	}
+	kept := c.configInfo.Profiles[:0]
+	for _, p := range c.configInfo.Profiles {
+		switch {
+		case p.Name == merged.Name:
+			kept = append(kept, merged)
+		case !drop[p.Name]:
+			kept = append(kept, p)
+		}
+	}
+	c.configInfo.Profiles = kept
+	return nil
+}
+
+// countProfileReferences counts the history entries and profile schedules of the given profiles
+func countProfileReferences(names []string) (int, int) {
+	db, err := GetSharedDB()
+	if err != nil || len(names) == 0 {
+		return 0, 0
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(names)), ",")
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		args[i] = name
+	}
+
+	var history, schedules int
+	_ = db.QueryRow("SELECT COUNT(*) FROM history WHERE profile_name IN ("+placeholders+")", args...).Scan(&history)
+	_ = db.QueryRow("SELECT COUNT(*) FROM schedules WHERE target_type = 'profile' AND profile_name IN ("+placeholders+")", args...).Scan(&schedules)
+	return history, schedules
+}
+
+// mergeProfileSettings combines the filters of profiles that sync the same
+// paths so the result transfers everything any of them did. Other settings
+// come from keep; differences are listed in the returned notes.
+func mergeProfileSettings(keep models.Profile, others []models.Profile) (models.Profile, []string) {
+	merged := keep
+	notes := []string{}
+	all := append([]models.Profile{keep}, others...)
+
+	// Included paths: the union, or everything if any profile syncs everything
+	merged.IncludedPaths = []string{}
+	for _, p := range all {
+		if len(p.IncludedPaths) == 0 {
+			if len(keep.IncludedPaths) > 0 {
+				notes = append(notes, fmt.Sprintf("'%s' has no included paths, so the merged profile syncs everything", p.Name))
+			}
+			merged.IncludedPaths = []string{}
+			break
+		}
+		merged.IncludedPaths = appendUnique(merged.IncludedPaths, p.IncludedPaths...)
+	}
+
+	// Excluded paths: only those every profile excludes
+	merged.ExcludedPaths = []string{}
+	for _, rule := range keep.ExcludedPaths {
+		excludedByAll := true
+		for _, p := range others {
+			if !containsString(p.ExcludedPaths, rule) {
+				excludedByAll = false
+				notes = append(notes, fmt.Sprintf("dropped exclude '%s': '%s' syncs those files", rule, p.Name))
+				break
+			}
+		}
+		if excludedByAll {
+			merged.ExcludedPaths = append(merged.ExcludedPaths, rule)
+		}
+	}
+
+	// Deleting excluded files is only kept if every profile did it
+	for _, p := range others {
+		if merged.DeleteExcluded && !p.DeleteExcluded {
+			merged.DeleteExcluded = false
+			notes = append(notes, fmt.Sprintf("turned off deleting excluded files: '%s' doesn't delete them", p.Name))
+		}
+	}
+
+	merged.FanOutTo = append([]string(nil), keep.FanOutTo...)
+	for _, p := range others {
+		merged.FanOutTo = appendUnique(merged.FanOutTo, p.FanOutTo...)
+	}
+
+	// Settings that can't be combined keep the value of keep
+	fields := []struct {
+		label string
+		value func(models.Profile) string
+	}{
+		{"min size", func(p models.Profile) string { return p.MinSize }},
+		{"max size", func(p models.Profile) string { return p.MaxSize }},
+		{"min age", func(p models.Profile) string { return p.MinAge }},
+		{"max age", func(p models.Profile) string { return p.MaxAge }},
+		{"filter file", func(p models.Profile) string { return p.FilterFromFile }},
+		{"exclude-if-present marker", func(p models.Profile) string { return p.ExcludeIfPresent }},
+		{"conflict resolution", func(p models.Profile) string { return p.ConflictResolution }},
+	}
+	for _, f := range fields {
+		for _, p := range others {
+			if f.value(p) != f.value(keep) {
+				notes = append(notes, fmt.Sprintf("kept %s %q from '%s'; '%s' used %q", f.label, f.value(keep), keep.Name, p.Name, f.value(p)))
+			}
+		}
+	}
+
+	return merged, notes
+}
+
+// appendUnique appends the values not already in list
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		if !containsString(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"testing"
+	"time"
+)
+
+func TestMergeProfileSettings(t *testing.T) {
+	keep := models.Profile{
+		Name: "docs", From: "/home/user/docs", To: "gdrive:backup",
+		IncludedPaths: []string{"/reports/**"}, ExcludedPaths: []string{"*.tmp", "*.bak"},
+		DeleteExcluded: true, MinSize: "1k",
+	}
+	other := models.Profile{
+		Name: "docs-2", From: "/home/user/docs", To: "gdrive:backup",
+		IncludedPaths: []string{"/invoices/**", "/reports/**"}, ExcludedPaths: []string{"*.tmp"},
+		FanOutTo: []string{"s3:backup"},
+	}
+
+	merged, notes := mergeProfileSettings(keep, []models.Profile{other})
+
+	if len(merged.IncludedPaths) != 2 || merged.IncludedPaths[1] != "/invoices/**" {
+		t.Errorf("expected the union of included paths, got %v", merged.IncludedPaths)
+	}
+	if len(merged.ExcludedPaths) != 1 || merged.ExcludedPaths[0] != "*.tmp" {
+		t.Errorf("expected only the shared exclude, got %v", merged.ExcludedPaths)
+	}
+	if merged.DeleteExcluded {
+		t.Error("expected deleting excluded files to be turned off")
+	}
+	if len(merged.FanOutTo) != 1 || len(keep.FanOutTo) != 0 {
+		t.Errorf("expected fan-out destinations to be combined without touching keep, got %v / %v", merged.FanOutTo, keep.FanOutTo)
+	}
+	// dropped *.bak, delete-excluded off, min size differs
+	if len(notes) != 3 {
+		t.Errorf("expected 3 notes, got %v", notes)
+	}
+
+	other.IncludedPaths = nil
+	merged, _ = mergeProfileSettings(keep, []models.Profile{other})
+	if len(merged.IncludedPaths) != 0 {
+		t.Errorf("expected a profile syncing everything to clear the includes, got %v", merged.IncludedPaths)
+	}
+}
+
+func TestMergeProfilesReassignsHistory(t *testing.T) {
+	ctx := context.Background()
+	svc := NewConfigService(nil)
+
+	keep := models.Profile{Name: "merge-keep", From: "/home/user/docs", To: "gdrive:backup", Parallel: 4, ExcludedPaths: []string{"*.tmp"}}
+	dup := models.Profile{Name: "merge-dup", From: "/home/user/docs/", To: "gdrive:/backup", Parallel: 4, IncludedPaths: []string{"/a/**"}}
+	for _, p := range []models.Profile{keep, dup} {
+		if err := svc.AddProfile(ctx, p); err != nil {
+			t.Fatalf("AddProfile(%s): %v", p.Name, err)
+		}
+	}
+	defer svc.DeleteProfile(ctx, keep.Name)
+
+	history := NewHistoryService(nil)
+	defer func() {
+		db, _ := GetSharedDB()
+		db.Exec("DELETE FROM history WHERE id = ?", "merge-h1")
+	}()
+	now := time.Now()
+	if err := history.AddEntry(ctx, models.HistoryEntry{Id: "merge-h1", ProfileName: dup.Name, Action: "push", Status: "completed", StartTime: now, EndTime: now}); err != nil {
+		t.Fatalf("AddEntry: %v", err)
+	}
+
+	overlaps, err := svc.DetectProfileOverlaps(ctx)
+	if err != nil {
+		t.Fatalf("DetectProfileOverlaps: %v", err)
+	}
+	found := false
+	for _, o := range overlaps {
+		if o.Kind == models.OverlapDuplicate && o.Mergeable {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the duplicate to be detected, got %+v", overlaps)
+	}
+
+	preview, err := svc.PreviewProfileMerge(ctx, keep.Name, []string{dup.Name})
+	if err != nil {
+		t.Fatalf("PreviewProfileMerge: %v", err)
+	}
+	if preview.HistoryEntries != 1 || len(preview.Removed) != 1 {
+		t.Errorf("unexpected preview: %+v", preview)
+	}
+
+	merged, err := svc.MergeProfiles(ctx, keep.Name, []string{dup.Name})
+	if err != nil {
+		t.Fatalf("MergeProfiles: %v", err)
+	}
+	if len(merged.ExcludedPaths) != 0 {
+		t.Errorf("expected the exclude to be dropped, got %v", merged.ExcludedPaths)
+	}
+
+	profiles, _ := svc.GetProfiles(ctx)
+	for _, p := range profiles {
+		if p.Name == dup.Name {
+			t.Error("expected the merged profile to be deleted")
+		}
+	}
+	if history, _ := countProfileReferences([]string{keep.Name}); history != 1 {
+		t.Errorf("expected the history entry to follow the kept profile, got %d", history)
+	}
+}
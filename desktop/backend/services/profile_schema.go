@@ -0,0 +1,383 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"desktop/backend/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// ProfileJSONImportOptions configures an import of profile JSON
+type ProfileJSONImportOptions struct {
+	Overwrite bool `json:"overwrite,omitempty"` // replace profiles with the same name instead of skipping them
+	DryRun    bool `json:"dry_run,omitempty"`   // read and validate without saving
+}
+
+// ProfileJSONImportResult is the outcome of an import of profile JSON
+type ProfileJSONImportResult struct {
+	SchemaVersion int                         `json:"schema_version"` // of the document read
+	DryRun        bool                        `json:"dry_run"`
+	Warnings      []string                    `json:"warnings"` // about the whole document
+	Profiles      []models.ProfileSchemaEntry `json:"profiles"`
+	Added         int                         `json:"added"`
+	Updated       int                         `json:"updated"`
+	Failed        int                         `json:"failed"` // profiles with errors
+}
+
+// profileDeprecation is a profile field older schemas wrote that this
+// version reads differently. convert returns the value to read instead, or
+// nil to drop the field, and the warning to report, if any.
+type profileDeprecation struct {
+	field   string
+	convert func(raw json.RawMessage) (json.RawMessage, string, error)
+}
+
+// profileDeprecations are the deprecated profile fields, in the order
+// they're handled
+var profileDeprecations = []profileDeprecation{
+	{"fast_list", func(json.RawMessage) (json.RawMessage, string, error) {
+		return nil, "fast_list is no longer a profile setting and was ignored", nil
+	}},
+	{"bandwidth", convertProfileBandwidth},
+}
+
+// profileDefaults are the values profile JSON without a field reads as,
+// where they aren't the field's zero value
+var profileDefaults = map[string]json.RawMessage{
+	"parallel":       json.RawMessage(`16`),
+	"included_paths": json.RawMessage(`[]`),
+	"excluded_paths": json.RawMessage(`[]`),
+}
+
+// profileJSONFields are the JSON names of the Profile fields
+var profileJSONFields = sync.OnceValue(func() map[string]bool {
+	fields := make(map[string]bool)
+	t := reflect.TypeOf(models.Profile{})
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+})
+
+// ExportProfilesJSON returns profiles as a versioned JSON document, without
+// their encryption passwords. names selects the profiles; empty exports all.
+func (c *ConfigService) ExportProfilesJSON(ctx context.Context, names []string) (string, error) {
+	profiles, err := c.GetProfiles(ctx)
+	if err != nil {
+		return "", err
+	}
+	if len(names) > 0 {
+		byName := make(map[string]models.Profile, len(profiles))
+		for _, p := range profiles {
+			byName[p.Name] = p
+		}
+		profiles = profiles[:0]
+		for _, name := range names {
+			p, ok := byName[name]
+			if !ok {
+				return "", fmt.Errorf("profile '%s' not found", name)
+			}
+			profiles = append(profiles, p)
+		}
+	}
+
+	doc := struct {
+		SchemaVersion int               `json:"schema_version"`
+		Profiles      []json.RawMessage `json:"profiles"`
+	}{SchemaVersion: models.ProfileSchemaVersion, Profiles: []json.RawMessage{}}
+	for _, p := range profiles {
+		p.StripEncryptPasswords()
+		data, err := encodeProfileJSON(p)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode profile '%s': %w", p.Name, err)
+		}
+		doc.Profiles = append(doc.Profiles, data)
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ImportProfilesJSON adds the profiles of profile JSON: a document
+// ExportProfilesJSON wrote, or the array of profiles, or single profile,
+// older versions wrote. Deprecated fields are converted or dropped with a
+// warning, left out fields get their defaults and fields of newer versions
+// are kept as they are. Every profile is validated on its own: profiles with
+// errors are reported and skipped, the others are saved. With DryRun
+// nothing is saved, so the result previews the import.
+func (c *ConfigService) ImportProfilesJSON(ctx context.Context, data string, options ProfileJSONImportOptions) (*ProfileJSONImportResult, error) {
+	version, entries, err := decodeProfileDocument([]byte(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no profiles found in the JSON")
+	}
+
+	existing, err := c.GetProfiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, p := range existing {
+		taken[p.Name] = true
+	}
+
+	result := &ProfileJSONImportResult{SchemaVersion: version, DryRun: options.DryRun, Warnings: []string{}, Profiles: entries}
+	if version > models.ProfileSchemaVersion {
+		result.Warnings = append(result.Warnings, fmt.Sprintf(
+			"written by a newer version (schema %d, this version reads %d): fields it doesn't know are kept as they are",
+			version, models.ProfileSchemaVersion))
+	}
+	seen := make(map[string]bool, len(entries))
+	for n := range result.Profiles {
+		entry := &result.Profiles[n]
+		if entry.Profile != nil {
+			if err := c.validateProfile(*entry.Profile); err != nil {
+				entry.Errors = append(entry.Errors, err.Error())
+			}
+			if seen[entry.Name] {
+				entry.Errors = append(entry.Errors, fmt.Sprintf("profile '%s' appears more than once", entry.Name))
+			}
+			seen[entry.Name] = true
+			if taken[entry.Name] && !options.Overwrite {
+				entry.Errors = append(entry.Errors, fmt.Sprintf("profile with name '%s' already exists", entry.Name))
+			}
+		}
+		if len(entry.Errors) > 0 {
+			result.Failed++
+			continue
+		}
+		if !options.DryRun {
+			if taken[entry.Name] {
+				err = c.UpdateProfile(ctx, *entry.Profile)
+			} else {
+				err = c.AddProfile(ctx, *entry.Profile)
+			}
+			if err != nil {
+				entry.Errors = append(entry.Errors, err.Error())
+				result.Failed++
+				continue
+			}
+		}
+		if taken[entry.Name] {
+			result.Updated++
+		} else {
+			result.Added++
+		}
+	}
+
+	if !options.DryRun {
+		log.Printf("ConfigService: Imported %d new and %d updated profiles of %d from schema %d JSON (%d with errors)",
+			result.Added, result.Updated, len(entries), version, result.Failed)
+	}
+	return result, nil
+}
+
+// decodeProfileDocument reads profile JSON: a models.ProfileDocument, or the
+// array of profiles, or single profile, of schema 1. It fails only if the
+// JSON isn't one of them; the problems of each profile are in its entry.
+func decodeProfileDocument(data []byte) (int, []models.ProfileSchemaEntry, error) {
+	data = bytes.TrimLeft(data, " \t\r\n\ufeff")
+	version := 1
+	var raws []json.RawMessage
+	switch {
+	case bytes.HasPrefix(data, []byte("[")):
+		if err := json.Unmarshal(data, &raws); err != nil {
+			return 0, nil, fmt.Errorf("invalid profile JSON: %w", err)
+		}
+	case bytes.HasPrefix(data, []byte("{")):
+		var doc map[string]json.RawMessage
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return 0, nil, fmt.Errorf("invalid profile JSON: %w", err)
+		}
+		profiles, ok := doc["profiles"]
+		if !ok {
+			raws = []json.RawMessage{data}
+			break
+		}
+		if raw, ok := doc["schema_version"]; ok {
+			if err := json.Unmarshal(raw, &version); err != nil || version < 1 {
+				return 0, nil, fmt.Errorf("invalid profile JSON: schema_version must be a version number, got %s", raw)
+			}
+		}
+		if err := json.Unmarshal(profiles, &raws); err != nil {
+			return 0, nil, fmt.Errorf("invalid profile JSON: profiles must be an array: %w", err)
+		}
+	default:
+		return 0, nil, fmt.Errorf("invalid profile JSON: expected a profile export, or an array of profiles")
+	}
+
+	entries := make([]models.ProfileSchemaEntry, len(raws))
+	for n, raw := range raws {
+		entry := models.ProfileSchemaEntry{Index: n + 1, Warnings: []string{}, UnknownFields: []string{}, Errors: []string{}}
+		p, warnings, unknown, err := decodeProfileJSON(raw)
+		if err != nil {
+			entry.Errors = append(entry.Errors, err.Error())
+		} else {
+			entry.Name = p.Name
+			entry.Profile = &p
+		}
+		entry.Warnings = append(entry.Warnings, warnings...)
+		entry.UnknownFields = append(entry.UnknownFields, unknown...)
+		entries[n] = entry
+	}
+	return version, entries, nil
+}
+
+// decodeProfileJSON reads one profile of profile JSON. Deprecated fields
+// are converted or dropped, with a warning each, fields the JSON leaves out
+// get their defaults, and fields Profile doesn't have are kept in
+// UnknownFields; unknown names the fields kept.
+func decodeProfileJSON(data []byte) (p models.Profile, warnings, unknown []string, err error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		return p, nil, nil, fmt.Errorf("expected a profile object")
+	}
+
+	// Fields kept from a newer version are written back at the top level
+	// by exports, but are nested when a profile is stored as it is
+	if raw, ok := fields["unknown_fields"]; ok {
+		var kept map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &kept); err != nil {
+			return p, nil, nil, fmt.Errorf("unknown_fields: expected an object")
+		}
+		delete(fields, "unknown_fields")
+		for name, value := range kept {
+			if _, ok := fields[name]; !ok {
+				fields[name] = value
+			}
+		}
+	}
+
+	for _, d := range profileDeprecations {
+		raw, ok := fields[d.field]
+		if !ok {
+			continue
+		}
+		value, warning, err := d.convert(raw)
+		if err != nil {
+			return p, warnings, nil, fmt.Errorf("%s: %w", d.field, err)
+		}
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
+		if value == nil {
+			delete(fields, d.field)
+		} else {
+			fields[d.field] = value
+		}
+	}
+
+	kept := make(map[string]json.RawMessage)
+	for name, value := range fields {
+		if !profileJSONFields()[name] {
+			kept[name] = value
+			unknown = append(unknown, name)
+			delete(fields, name)
+		}
+	}
+	sort.Strings(unknown)
+	for name, value := range profileDefaults {
+		if _, ok := fields[name]; !ok {
+			fields[name] = value
+		}
+	}
+
+	known, err := json.Marshal(fields)
+	if err != nil {
+		return p, warnings, unknown, err
+	}
+	if err := json.Unmarshal(known, &p); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return p, warnings, unknown, fmt.Errorf("%s: expected %s, got %s", typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+		return p, warnings, unknown, err
+	}
+	if len(kept) > 0 {
+		p.UnknownFields = kept
+	}
+	return p, warnings, unknown, nil
+}
+
+// decodeProfileList reads stored profile JSON, such as profiles.json or the
+// profiles of a settings sync archive, logging the warnings of older and
+// newer schemas. It fails on the first profile that can't be read.
+func decodeProfileList(data []byte, source string) ([]models.Profile, error) {
+	_, entries, err := decodeProfileDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	profiles := make([]models.Profile, 0, len(entries))
+	for _, entry := range entries {
+		if len(entry.Errors) > 0 {
+			return nil, fmt.Errorf("profile %d: %s", entry.Index, strings.Join(entry.Errors, "; "))
+		}
+		for _, warning := range entry.Warnings {
+			log.Printf("Warning: profile '%s' of %s: %s", entry.Name, source, warning)
+		}
+		profiles = append(profiles, *entry.Profile)
+	}
+	return profiles, nil
+}
+
+// encodeProfileJSON writes a profile with the fields kept in UnknownFields
+// back at the top level, after the others
+func encodeProfileJSON(p models.Profile) (json.RawMessage, error) {
+	kept := p.UnknownFields
+	p.UnknownFields = nil
+	data, err := json.Marshal(p)
+	if err != nil || len(kept) == 0 {
+		return data, err
+	}
+	names := make([]string, 0, len(kept))
+	for name := range kept {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.Write(data[:len(data)-1])
+	for _, name := range names {
+		key, _ := json.Marshal(name)
+		buf.WriteByte(',')
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(kept[name])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// convertProfileBandwidth reads a bandwidth written as an rclone size, like
+// "5M", which schema 1 accepted, as the number of MB/s it is now
+func convertProfileBandwidth(raw json.RawMessage) (json.RawMessage, string, error) {
+	var size string
+	if json.Unmarshal(raw, &size) != nil {
+		return raw, "", nil
+	}
+	var limit fs.SizeSuffix
+	if err := limit.Set(size); err != nil {
+		return nil, "", fmt.Errorf("expected a number of MB/s, got %q", size)
+	}
+	mbps := max(int64(limit), 0) / int64(fs.Mebi)
+	if int64(limit) > 0 && mbps == 0 {
+		mbps = 1
+	}
+	return json.RawMessage(fmt.Sprint(mbps)), fmt.Sprintf("bandwidth %q is now a number of MB/s and was read as %d", size, mbps), nil
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDecodeProfileJSON(t *testing.T) {
+	tests := []struct {
+		name         string
+		json         string
+		want         func(models.Profile) bool
+		wantWarnings int
+		wantUnknown  []string
+		wantErr      string
+	}{
+		{
+			name: "defaults",
+			json: `{"name": "docs", "from": "/docs", "to": "gdrive:docs"}`,
+			want: func(p models.Profile) bool {
+				return p.Parallel == 16 && p.IncludedPaths != nil && p.ExcludedPaths != nil && p.UnknownFields == nil
+			},
+		},
+		{
+			name:         "deprecated fields",
+			json:         `{"name": "docs", "from": "/docs", "to": "gdrive:docs", "bandwidth": "5M", "fast_list": true, "parallel": 4}`,
+			want:         func(p models.Profile) bool { return p.Bandwidth == 5 && p.Parallel == 4 },
+			wantWarnings: 2,
+		},
+		{
+			name: "bandwidth as a number",
+			json: `{"name": "docs", "from": "/docs", "to": "gdrive:docs", "bandwidth": 10}`,
+			want: func(p models.Profile) bool { return p.Bandwidth == 10 },
+		},
+		{
+			name: "unknown fields",
+			json: `{"name": "docs", "from": "/docs", "to": "gdrive:docs", "verify_after": {"sample": 10}, "unknown_fields": {"tags": ["work"]}}`,
+			want: func(p models.Profile) bool {
+				return string(p.UnknownFields["verify_after"]) == `{"sample": 10}` && string(p.UnknownFields["tags"]) == `["work"]`
+			},
+			wantUnknown: []string{"tags", "verify_after"},
+		},
+		{"wrong type", `{"name": "docs", "parallel": "many"}`, nil, 0, nil, "parallel: expected int, got string"},
+		{"bad bandwidth", `{"name": "docs", "bandwidth": "fast"}`, nil, 0, nil, "bandwidth: expected a number of MB/s"},
+		{"not an object", `["docs"]`, nil, 0, nil, "expected a profile object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, warnings, unknown, err := decodeProfileJSON([]byte(tt.json))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.want(p) {
+				t.Errorf("profile = %+v", p)
+			}
+			if len(warnings) != tt.wantWarnings {
+				t.Errorf("warnings = %q, want %d", warnings, tt.wantWarnings)
+			}
+			if strings.Join(unknown, ",") != strings.Join(tt.wantUnknown, ",") {
+				t.Errorf("unknown fields = %q, want %q", unknown, tt.wantUnknown)
+			}
+		})
+	}
+}
+
+func TestImportProfilesJSON(t *testing.T) {
+	ctx := context.Background()
+	svc := NewConfigService(nil)
+
+	// A profile of schema 1, and one written by a newer version
+	old := `[{"name": "schema-old", "from": "/home/user/old", "to": "gdrive:old", "bandwidth": "2M", "fast_list": true}]`
+	newer := `{"schema_version": 9, "profiles": [
+		{"name": "schema-new", "from": "/home/user/new", "to": "gdrive:new", "parallel": 4, "verify_after": {"sample": 10}},
+		{"name": "schema-new", "from": "/home/user/again", "to": "gdrive:again"},
+		{"name": "schema-bad", "from": "", "to": "gdrive:bad"}
+	]}`
+
+	result, err := svc.ImportProfilesJSON(ctx, old, ProfileJSONImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SchemaVersion != 1 || result.Added != 1 || len(result.Profiles[0].Warnings) != 2 {
+		t.Fatalf("schema 1 import = %+v", result)
+	}
+	result, err = svc.ImportProfilesJSON(ctx, newer, ProfileJSONImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Added != 1 || result.Failed != 2 || len(result.Warnings) != 1 {
+		t.Fatalf("newer import = %+v", result)
+	}
+	if dup := result.Profiles[1].Errors; len(dup) != 1 || !strings.Contains(dup[0], "more than once") {
+		t.Errorf("expected the repeated name to be refused, got %v", dup)
+	}
+
+	// Importing again only updates with Overwrite
+	result, err = svc.ImportProfilesJSON(ctx, old, ProfileJSONImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Failed != 1 {
+		t.Errorf("expected the existing profile to be refused, got %+v", result.Profiles[0])
+	}
+	result, err = svc.ImportProfilesJSON(ctx, old, ProfileJSONImportOptions{Overwrite: true, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Updated != 1 {
+		t.Errorf("expected the existing profile to be updated, got %+v", result.Profiles[0])
+	}
+
+	// The field of the newer version survives the database and an export
+	reloaded := NewConfigService(nil)
+	exported, err := reloaded.ExportProfilesJSON(ctx, []string{"schema-new", "schema-old"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		SchemaVersion int                          `json:"schema_version"`
+		Profiles      []map[string]json.RawMessage `json:"profiles"`
+	}
+	if err := json.Unmarshal([]byte(exported), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.SchemaVersion != models.ProfileSchemaVersion || len(doc.Profiles) != 2 {
+		t.Fatalf("export = %s", exported)
+	}
+	if verify := strings.Join(strings.Fields(string(doc.Profiles[0]["verify_after"])), ""); verify != `{"sample":10}` || doc.Profiles[0]["unknown_fields"] != nil {
+		t.Errorf("expected verify_after at the top level of the export, got %s", exported)
+	}
+	if string(doc.Profiles[1]["bandwidth"]) != "2" {
+		t.Errorf("expected the converted bandwidth, got %s", doc.Profiles[1]["bandwidth"])
+	}
+
+	if _, err := reloaded.ExportProfilesJSON(ctx, []string{"schema-missing"}); err == nil {
+		t.Error("expected an unknown profile to be refused")
+	}
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/rclone"
+	"desktop/backend/validation"
+	"fmt"
+	"log"
+	"strings"
+
+	fsConfig "github.com/rclone/rclone/fs/config"
+)
+
+// rclone config keys for custom API clients and the per-remote user agent.
+// "override." applies a global rclone option to this remote only.
+const (
+	clientIdKey     = "client_id"
+	clientSecretKey = "client_secret"
+	userAgentKey    = "override.user_agent"
+	tokenKey        = "token"
+)
+
+// clientIdDocs links to the guide for creating an API client, per remote type
+var clientIdDocs = map[string]string{
+	"drive":    "https://rclone.org/drive/#making-your-own-client-id",
+	"onedrive": "https://rclone.org/onedrive/#getting-your-own-client-id-and-key",
+	"dropbox":  "https://rclone.org/dropbox/#get-your-own-dropbox-app-id",
+}
+
+// userAgentDocs explains per-remote overrides of global options such as the user agent
+const userAgentDocs = "https://rclone.org/docs/#override-var"
+
+// RemoteClientConfig describes a remote's API client and user agent settings.
+// The client secret is never returned, only whether one is set.
+type RemoteClientConfig struct {
+	Remote               string `json:"remote"`
+	Type                 string `json:"type"`
+	SupportsCustomClient bool   `json:"supports_custom_client"` // Drive, OneDrive and Dropbox
+	ClientId             string `json:"client_id,omitempty"`
+	HasClientSecret      bool   `json:"has_client_secret"`
+	UserAgent            string `json:"user_agent,omitempty"`
+	ClientIdDocsURL      string `json:"client_id_docs_url,omitempty"`
+	UserAgentDocsURL     string `json:"user_agent_docs_url"`
+	ReconnectRequired    bool   `json:"reconnect_required,omitempty"` // the client changed; the remote must be re-authorized
+}
+
+// RemoteClientUpdate is the input for SetRemoteClientConfig. An empty
+// ClientId removes the custom client (and its secret); an empty
+// ClientSecret keeps the stored one while ClientId stays the same, and
+// removes it otherwise. An empty UserAgent restores the default.
+type RemoteClientUpdate struct {
+	ClientId     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	UserAgent    string `json:"user_agent"`
+}
+
+// GetRemoteClientConfig returns a remote's custom API client and user agent settings
+func (r *RemoteService) GetRemoteClientConfig(ctx context.Context, name string) (*RemoteClientConfig, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	remoteType, err := r.remoteType(name)
+	if err != nil {
+		return nil, err
+	}
+	return readRemoteClientConfig(name, remoteType), nil
+}
+
+// SetRemoteClientConfig sets a remote's custom OAuth client ID/secret and
+// user agent in the rclone config, so heavy users can move off the shared
+// client's rate limits. Tokens issued for another client can't be refreshed
+// with the new one, so the result reports when the remote must be re-authorized.
+func (r *RemoteService) SetRemoteClientConfig(ctx context.Context, name string, update RemoteClientUpdate) (*RemoteClientConfig, error) {
+	update.ClientId = strings.TrimSpace(update.ClientId)
+	update.ClientSecret = strings.TrimSpace(update.ClientSecret)
+	update.UserAgent = strings.TrimSpace(update.UserAgent)
+	if strings.ContainsAny(update.UserAgent, "\r\n") {
+		return nil, &validation.ValidationError{Field: "user_agent", Message: "cannot contain line breaks"}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	remoteType, err := r.remoteType(name)
+	if err != nil {
+		return nil, err
+	}
+	_, supported := clientIdDocs[remoteType]
+	if !supported && (update.ClientId != "" || update.ClientSecret != "") {
+		return nil, &validation.ValidationError{Field: "client_id", Message: fmt.Sprintf("not supported for remote type '%s'", remoteType)}
+	}
+
+	oldClientId, _ := fsConfig.FileGetValue(name, clientIdKey)
+	if supported {
+		if update.ClientId == "" {
+			fsConfig.FileDeleteKey(name, clientIdKey)
+			fsConfig.FileDeleteKey(name, clientSecretKey)
+		} else {
+			fsConfig.FileSetValue(name, clientIdKey, update.ClientId)
+			if update.ClientSecret != "" {
+				fsConfig.FileSetValue(name, clientSecretKey, update.ClientSecret)
+			} else if update.ClientId != oldClientId {
+				// The stored secret belongs to the old client
+				fsConfig.FileDeleteKey(name, clientSecretKey)
+			}
+		}
+	}
+	if update.UserAgent == "" {
+		fsConfig.FileDeleteKey(name, userAgentKey)
+	} else {
+		fsConfig.FileSetValue(name, userAgentKey, update.UserAgent)
+	}
+	fsConfig.SaveConfig()
+
+	// Cached filesystems still hold the old client
+	rclone.ClearFsCache()
+
+	result := readRemoteClientConfig(name, remoteType)
+	if _, hasToken := fsConfig.FileGetValue(name, tokenKey); hasToken && oldClientId != result.ClientId {
+		result.ReconnectRequired = true
+	}
+
+	log.Printf("Remote '%s' client settings updated (custom client: %v, user agent: %q)", name, result.ClientId != "", result.UserAgent)
+	return result, nil
+}
+
+// remoteType returns the type of a configured remote. Caller must hold r.mutex.
+func (r *RemoteService) remoteType(name string) (string, error) {
+	for _, existing := range fsConfig.GetRemotes() {
+		if existing.Name == name {
+			return existing.Type, nil
+		}
+	}
+	return "", fmt.Errorf("remote '%s' not found", name)
+}
+
+// readRemoteClientConfig reads a remote's client settings from the rclone config
+func readRemoteClientConfig(name, remoteType string) *RemoteClientConfig {
+	cfg := &RemoteClientConfig{
+		Remote:           name,
+		Type:             remoteType,
+		UserAgentDocsURL: userAgentDocs,
+	}
+	cfg.ClientIdDocsURL, cfg.SupportsCustomClient = clientIdDocs[remoteType]
+	if cfg.SupportsCustomClient {
+		cfg.ClientId, _ = fsConfig.FileGetValue(name, clientIdKey)
+		secret, _ := fsConfig.FileGetValue(name, clientSecretKey)
+		cfg.HasClientSecret = secret != ""
+	}
+	cfg.UserAgent, _ = fsConfig.FileGetValue(name, userAgentKey)
+	return cfg
+}
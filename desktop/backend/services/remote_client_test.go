@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	fsConfig "github.com/rclone/rclone/fs/config"
+)
+
+func TestRemoteService_ClientConfig(t *testing.T) {
+	fsConfig.FileSetValue("gdrive", "type", "drive")
+	fsConfig.FileSetValue("gdrive", "token", `{"access_token":"x"}`)
+	fsConfig.FileSetValue("nas", "type", "sftp")
+	defer fsConfig.DeleteRemote("gdrive")
+	defer fsConfig.DeleteRemote("nas")
+
+	r := NewRemoteService(nil)
+	ctx := context.Background()
+
+	// Steps run in order against the same config
+	tests := []struct {
+		name          string
+		remote        string
+		update        RemoteClientUpdate
+		wantErr       bool
+		wantClientId  string
+		wantSecret    string // stored client secret
+		wantReconnect bool
+		wantUserAgent string
+	}{
+		{"custom client on an authorized remote", "gdrive", RemoteClientUpdate{ClientId: "id-1", ClientSecret: "secret-1"}, false, "id-1", "secret-1", true, ""},
+		{"blank secret keeps the stored one", "gdrive", RemoteClientUpdate{ClientId: "id-1", UserAgent: "backup/1.0"}, false, "id-1", "secret-1", false, "backup/1.0"},
+		{"new client id without a secret drops the old one", "gdrive", RemoteClientUpdate{ClientId: " id-2 "}, false, "id-2", "", true, ""},
+		{"new secret for the same client", "gdrive", RemoteClientUpdate{ClientId: "id-2", ClientSecret: "secret-2"}, false, "id-2", "secret-2", false, ""},
+		{"new secret replaces the stored one", "gdrive", RemoteClientUpdate{ClientId: "id-2", ClientSecret: "secret-3"}, false, "id-2", "secret-3", false, ""},
+		{"new client id with its secret", "gdrive", RemoteClientUpdate{ClientId: "id-3", ClientSecret: "secret-4"}, false, "id-3", "secret-4", true, ""},
+		{"empty client id removes the client", "gdrive", RemoteClientUpdate{}, false, "", "", true, ""},
+		{"line breaks in the user agent", "gdrive", RemoteClientUpdate{UserAgent: "a\r\nX-Evil: 1"}, true, "", "", false, ""},
+		{"user agent on an unsupported type", "nas", RemoteClientUpdate{UserAgent: "backup/1.0"}, false, "", "", false, "backup/1.0"},
+		{"client id on an unsupported type", "nas", RemoteClientUpdate{ClientId: "id-1"}, true, "", "", false, ""},
+		{"client secret on an unsupported type", "nas", RemoteClientUpdate{ClientSecret: "secret-1"}, true, "", "", false, ""},
+		{"unknown remote", "missing", RemoteClientUpdate{UserAgent: "backup/1.0"}, true, "", "", false, ""},
+	}
+	for _, tt := range tests {
+		result, err := r.SetRemoteClientConfig(ctx, tt.remote, tt.update)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %+v", tt.name, result)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		secret, _ := fsConfig.FileGetValue(tt.remote, clientSecretKey)
+		if result.ClientId != tt.wantClientId || secret != tt.wantSecret || result.HasClientSecret != (tt.wantSecret != "") {
+			t.Errorf("%s: client = %q, secret = %q (has %v), want %q, %q", tt.name, result.ClientId, secret, result.HasClientSecret, tt.wantClientId, tt.wantSecret)
+		}
+		if result.ReconnectRequired != tt.wantReconnect {
+			t.Errorf("%s: reconnect required = %v, want %v", tt.name, result.ReconnectRequired, tt.wantReconnect)
+		}
+		if result.UserAgent != tt.wantUserAgent {
+			t.Errorf("%s: user agent = %q, want %q", tt.name, result.UserAgent, tt.wantUserAgent)
+		}
+
+		got, err := r.GetRemoteClientConfig(ctx, tt.remote)
+		if err != nil || got.ClientId != result.ClientId || got.HasClientSecret != result.HasClientSecret || got.UserAgent != result.UserAgent {
+			t.Errorf("%s: GetRemoteClientConfig = %+v, %v; want it to match %+v", tt.name, got, err, result)
+		}
+	}
+
+	cfg, err := r.GetRemoteClientConfig(ctx, "nas")
+	if err != nil || cfg.SupportsCustomClient || cfg.ClientIdDocsURL != "" {
+		t.Errorf("GetRemoteClientConfig(nas) = %+v, %v; want no custom client support", cfg, err)
+	}
+	if _, err := r.GetRemoteClientConfig(ctx, "missing"); err == nil {
+		t.Error("expected an unknown remote to fail")
+	}
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"fmt"
+
+	fsConfig "github.com/rclone/rclone/fs/config"
+)
+
+// driveScopeKey is the rclone config key of a Google Drive remote's OAuth scope
+const driveScopeKey = "scope"
+
+// GetDriveScopes returns the scopes a Google Drive remote can be created with
+func (r *RemoteService) GetDriveScopes(ctx context.Context) []models.DriveScope {
+	return rclone.DriveScopes
+}
+
+// GetDriveComputers lists the computers backed up to a Google Drive remote by
+// Google's Backup & Sync. Their paths can be browsed and used as the source
+// of pull profiles.
+func (r *RemoteService) GetDriveComputers(ctx context.Context, name string) ([]models.DriveComputer, error) {
+	r.mutex.RLock()
+	remoteType, err := r.remoteType(name)
+	scope, _ := fsConfig.FileGetValue(name, driveScopeKey)
+	r.mutex.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	if remoteType != "drive" {
+		return nil, fmt.Errorf("remote '%s' is not a Google Drive remote", name)
+	}
+	if !rclone.DriveScopeSeesComputers(scope) {
+		return nil, fmt.Errorf("remote '%s' is authorized with scope '%s', which can't see the Computers root; reconnect it with full or read-only access", name, scope)
+	}
+
+	opCtx, err := rclone.SimpleContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize rclone config: %w", err)
+	}
+	return rclone.ListDriveComputers(opCtx, name)
+}
+
+// validateRemoteOptions checks options that rclone would otherwise accept
+// and only fail on when the remote is used
+func validateRemoteOptions(remoteType string, config map[string]string) error {
+	if remoteType == "drive" {
+		if err := rclone.ValidateDriveScope(config[driveScopeKey]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
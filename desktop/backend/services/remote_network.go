@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"log"
+
+	fsConfig "github.com/rclone/rclone/fs/config"
+)
+
+// RemoteNetworkConfig is a remote's own network settings, which take
+// precedence over the app-wide ones
+type RemoteNetworkConfig struct {
+	Remote   string                 `json:"remote"`
+	Settings models.NetworkSettings `json:"settings"`
+	Warnings []string               `json:"warnings,omitempty"`
+}
+
+// GetRemoteNetworkSettings returns a remote's proxy, CA bundle, TLS verification and bind address overrides
+func (r *RemoteService) GetRemoteNetworkSettings(ctx context.Context, name string) (*RemoteNetworkConfig, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if _, err := r.remoteType(name); err != nil {
+		return nil, err
+	}
+	settings := rclone.NetworkSettingsFromOverrides(func(key string) (string, bool) {
+		return fsConfig.FileGetValue(name, key)
+	})
+	return &RemoteNetworkConfig{Remote: name, Settings: settings, Warnings: rclone.NetworkWarnings(settings)}, nil
+}
+
+// SetRemoteNetworkSettings stores proxy, CA bundle, TLS verification and
+// bind address overrides for one remote in the rclone config. Empty settings
+// fall back to the app-wide network settings.
+func (r *RemoteService) SetRemoteNetworkSettings(ctx context.Context, name string, settings models.NetworkSettings) (*RemoteNetworkConfig, error) {
+	if err := rclone.ValidateNetworkSettings(settings); err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, err := r.remoteType(name); err != nil {
+		return nil, err
+	}
+	overrides, err := rclone.NetworkOverrides(settings)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range overrides {
+		if value == "" {
+			fsConfig.FileDeleteKey(name, key)
+		} else {
+			fsConfig.FileSetValue(name, key, value)
+		}
+	}
+	fsConfig.SaveConfig()
+	rclone.ClearFsCache()
+
+	if settings.InsecureSkipVerify {
+		log.Printf("WARNING: TLS certificate verification disabled for remote '%s'", name)
+	}
+	log.Printf("Remote '%s' network settings updated", name)
+	return &RemoteNetworkConfig{Remote: name, Settings: settings, Warnings: rclone.NetworkWarnings(settings)}, nil
+}
@@ -0,0 +1,403 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"desktop/backend/events"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	fsConfig "github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/fspath"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// probeRemotePin reads the host key or certificate a server presents;
+// replaced in tests
+var probeRemotePin = rclone.ProbePin
+
+// remoteConfigGetter reads a remote's rclone config values
+func remoteConfigGetter(name string) func(key string) string {
+	return func(key string) string {
+		value, _ := fsConfig.FileGetValue(name, key)
+		return value
+	}
+}
+
+// GetRemotePins returns the pinned remotes
+func (r *RemoteService) GetRemotePins(ctx context.Context) ([]models.RemotePin, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query("SELECT remote, kind, address, fingerprint, pinned_at FROM remote_pins ORDER BY remote")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pins := []models.RemotePin{}
+	for rows.Next() {
+		var pin models.RemotePin
+		var pinnedAt string
+		if err := rows.Scan(&pin.Remote, &pin.Kind, &pin.Address, &pin.Fingerprint, &pinnedAt); err != nil {
+			return nil, err
+		}
+		pin.PinnedAt, _ = time.Parse(time.RFC3339, pinnedAt)
+		pins = append(pins, pin)
+	}
+	return pins, rows.Err()
+}
+
+// PinRemote trusts the host key of an sftp remote's server, or the
+// certificate of an https webdav remote's server, as it presents it now.
+// Runs using the remote then fail if it presents a different one, until
+// the change is accepted with AcceptRemotePin. An sftp remote without its
+// own known_hosts_file is also given the app's, so rclone checks the key on
+// every connection.
+func (r *RemoteService) PinRemote(ctx context.Context, name string) (*models.RemotePinStatus, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, err := r.remoteType(name); err != nil {
+		return nil, err
+	}
+	if pin, err := loadRemotePin(name); err != nil {
+		return nil, err
+	} else if pin != nil {
+		return nil, fmt.Errorf("remote '%s' is already pinned; accept a changed fingerprint with AcceptRemotePin", name)
+	}
+	kind, address, err := rclone.PinTarget(remoteConfigGetter(name))
+	if err != nil {
+		return nil, fmt.Errorf("remote '%s' can't be pinned: %w", name, err)
+	}
+	probe, err := probeRemotePin(ctx, kind, address)
+	if err != nil {
+		return nil, err
+	}
+	pin := models.RemotePin{Remote: name, Kind: kind, Address: address, Fingerprint: probe.Fingerprint, PinnedAt: time.Now().UTC()}
+	if err := savePinnedRemote(pin, probe); err != nil {
+		return nil, err
+	}
+	log.Printf("Remote '%s' pinned to %s %s", name, kind, probe.Fingerprint)
+	return pinStatus(pin, probe, nil), nil
+}
+
+// CheckRemotePin compares what a remote's server presents now with its pin.
+// An unpinned remote is reported as such, with what its server presents.
+// A changed pin is also emitted as remote:pin_changed.
+func (r *RemoteService) CheckRemotePin(ctx context.Context, name string) (*models.RemotePinStatus, error) {
+	pin, err := loadRemotePin(name)
+	if err != nil {
+		return nil, err
+	}
+	if pin == nil {
+		kind, address, err := rclone.PinTarget(remoteConfigGetter(name))
+		if err != nil {
+			return nil, fmt.Errorf("remote '%s' can't be pinned: %w", name, err)
+		}
+		probe, err := probeRemotePin(ctx, kind, address)
+		status := pinStatus(models.RemotePin{Remote: name, Kind: kind, Address: address}, probe, err)
+		if status.Status == models.PinStatusMatch {
+			status.Status = models.PinStatusUnpinned
+		}
+		return status, nil
+	}
+	return checkRemotePin(ctx, *pin), nil
+}
+
+// AcceptRemotePin trusts the host key or certificate a pinned remote's
+// server presents after a change, e.g. once the server was rebuilt.
+// fingerprint is the one the user reviewed; it must be what the server
+// presents now.
+func (r *RemoteService) AcceptRemotePin(ctx context.Context, name, fingerprint string) (*models.RemotePinStatus, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	pin, err := loadRemotePin(name)
+	if err != nil {
+		return nil, err
+	}
+	if pin == nil {
+		return nil, fmt.Errorf("remote '%s' is not pinned", name)
+	}
+	// The address may have changed with the server
+	kind, address, err := rclone.PinTarget(remoteConfigGetter(name))
+	if err != nil {
+		return nil, fmt.Errorf("remote '%s' can't be pinned: %w", name, err)
+	}
+	probe, err := probeRemotePin(ctx, kind, address)
+	if err != nil {
+		return nil, err
+	}
+	if probe.Fingerprint != fingerprint {
+		return nil, fmt.Errorf("remote '%s' presents %s now, not %s", name, probe.Fingerprint, fingerprint)
+	}
+	previous := pin.Fingerprint
+	pin.Kind, pin.Address, pin.Fingerprint, pin.PinnedAt = kind, address, probe.Fingerprint, time.Now().UTC()
+	if err := savePinnedRemote(*pin, probe); err != nil {
+		return nil, err
+	}
+	log.Printf("Remote '%s' pin changed from %s to %s", name, previous, probe.Fingerprint)
+	return pinStatus(*pin, probe, nil), nil
+}
+
+// UnpinRemote stops checking a remote's host key or certificate
+func (r *RemoteService) UnpinRemote(ctx context.Context, name string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	pin, err := loadRemotePin(name)
+	if err != nil {
+		return err
+	}
+	if pin == nil {
+		return fmt.Errorf("remote '%s' is not pinned", name)
+	}
+	return deleteRemotePin(name)
+}
+
+// loadRemotePin returns a remote's pin, or nil if it isn't pinned
+func loadRemotePin(name string) (*models.RemotePin, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	var pin models.RemotePin
+	var pinnedAt string
+	err = db.QueryRow("SELECT remote, kind, address, fingerprint, pinned_at FROM remote_pins WHERE remote = ?", name).
+		Scan(&pin.Remote, &pin.Kind, &pin.Address, &pin.Fingerprint, &pinnedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	pin.PinnedAt, _ = time.Parse(time.RFC3339, pinnedAt)
+	return &pin, nil
+}
+
+// savePinnedRemote stores a pin and, for sftp remotes, updates the app's
+// known_hosts file
+func savePinnedRemote(pin models.RemotePin, probe *rclone.PinProbe) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	managed := 0
+	if previous, err := loadRemotePin(pin.Remote); err != nil {
+		return err
+	} else if previous != nil && pinnedKnownHostsManaged(db, pin.Remote) {
+		managed = 1
+	}
+	get := remoteConfigGetter(pin.Remote)
+	if pin.Kind == models.PinSSHHostKey && get("known_hosts_file") == "" {
+		managed = 1
+	}
+	if _, err := db.Exec(`INSERT OR REPLACE INTO remote_pins (remote, kind, address, fingerprint, host_key, known_hosts_managed, pinned_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		pin.Remote, pin.Kind, pin.Address, pin.Fingerprint, probe.HostKey, managed, pin.PinnedAt.Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("failed to save the pin: %w", err)
+	}
+	if pin.Kind != models.PinSSHHostKey {
+		return nil
+	}
+	if err := writePinnedKnownHosts(db); err != nil {
+		return err
+	}
+	if managed == 1 && get("known_hosts_file") == "" {
+		fsConfig.FileSetValue(pin.Remote, "known_hosts_file", pinnedKnownHostsPath())
+		fsConfig.SaveConfig()
+		rclone.ClearFsCache()
+	}
+	return nil
+}
+
+// deleteRemotePin removes a remote's pin, and the app's known_hosts_file
+// from its config if the pin set it. Removing a pin that doesn't exist is
+// not an error.
+func deleteRemotePin(name string) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	managed := pinnedKnownHostsManaged(db, name)
+	if _, err := db.Exec("DELETE FROM remote_pins WHERE remote = ?", name); err != nil {
+		return fmt.Errorf("failed to delete the pin: %w", err)
+	}
+	if managed {
+		if file, ok := fsConfig.FileGetValue(name, "known_hosts_file"); ok && file == pinnedKnownHostsPath() {
+			fsConfig.FileDeleteKey(name, "known_hosts_file")
+			fsConfig.SaveConfig()
+			rclone.ClearFsCache()
+		}
+	}
+	return writePinnedKnownHosts(db)
+}
+
+// pinnedKnownHostsManaged reports whether a remote's pin set its known_hosts_file
+func pinnedKnownHostsManaged(db *sql.DB, name string) bool {
+	var managed int
+	_ = db.QueryRow("SELECT known_hosts_managed FROM remote_pins WHERE remote = ?", name).Scan(&managed)
+	return managed != 0
+}
+
+// pinnedKnownHostsPath is the known_hosts file holding the pinned host keys
+func pinnedKnownHostsPath() string {
+	cfg := GetSharedConfig()
+	if cfg == nil {
+		return ""
+	}
+	return filepath.Join(cfg.ConfigDir, "known_hosts")
+}
+
+// writePinnedKnownHosts rewrites the app's known_hosts file with the host
+// keys of the pinned sftp remotes
+func writePinnedKnownHosts(db *sql.DB) error {
+	path := pinnedKnownHostsPath()
+	if path == "" {
+		return fmt.Errorf("shared config not set")
+	}
+	rows, err := db.Query("SELECT address, host_key FROM remote_pins WHERE kind = ? AND host_key != '' ORDER BY remote", models.PinSSHHostKey)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var address, hostKey string
+		if err := rows.Scan(&address, &hostKey); err != nil {
+			return err
+		}
+		key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostKey))
+		if err != nil {
+			log.Printf("Warning: skipping an unreadable pinned host key of %s: %v", address, err)
+			continue
+		}
+		lines = append(lines, knownhosts.Line([]string{knownhosts.Normalize(address)}, key))
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	content := ""
+	if len(lines) > 0 {
+		content = strings.Join(lines, "\n") + "\n"
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// checkRemotePin compares what a pinned remote's server presents now with
+// its pin, and emits remote:pin_changed when it differs
+func checkRemotePin(ctx context.Context, pin models.RemotePin) *models.RemotePinStatus {
+	probe, err := probeRemotePin(ctx, pin.Kind, pin.Address)
+	status := pinStatus(pin, probe, err)
+	if status.Status == models.PinStatusChanged {
+		log.Printf("WARNING: remote '%s' presents %s, pinned %s", pin.Remote, status.Current, status.Pinned)
+		emitPinChanged(status)
+	}
+	return status
+}
+
+// pinStatus describes a probe of a remote's server against its pin
+func pinStatus(pin models.RemotePin, probe *rclone.PinProbe, err error) *models.RemotePinStatus {
+	status := &models.RemotePinStatus{
+		Remote:    pin.Remote,
+		Kind:      pin.Kind,
+		Address:   pin.Address,
+		Pinned:    pin.Fingerprint,
+		CheckedAt: time.Now(),
+	}
+	if err != nil {
+		status.Status = models.PinStatusUnreachable
+		status.Error = err.Error()
+		return status
+	}
+	status.Current = probe.Fingerprint
+	status.KeyType = probe.KeyType
+	if cert := probe.Certificate; cert != nil {
+		status.Subject = cert.Subject.String()
+		status.Issuer = cert.Issuer.String()
+		notAfter := cert.NotAfter
+		status.NotAfter = &notAfter
+	}
+	status.Status = models.PinStatusMatch
+	if pin.Fingerprint != "" && probe.Fingerprint != pin.Fingerprint {
+		status.Status = models.PinStatusChanged
+	}
+	return status
+}
+
+// emitPinChanged emits remote:pin_changed so the user can review the change
+func emitPinChanged(status *models.RemotePinStatus) {
+	bus := GetSharedEventBus()
+	if bus == nil {
+		return
+	}
+	if err := bus.EmitRemoteEvent(events.NewRemoteEvent(events.RemotePinChanged, status.Remote, status)); err != nil {
+		log.Printf("Failed to emit remote event: %v", err)
+	}
+}
+
+// pinnedRemoteNames returns the remotes an rclone path goes through: its
+// remote and the remotes wrapped by crypt, compress or alias remotes
+func pinnedRemoteNames(path string) []string {
+	parsed, err := fspath.Parse(path)
+	if err != nil || parsed.Name == "" || strings.HasPrefix(parsed.Name, ":") {
+		return nil
+	}
+	names := []string{parsed.Name}
+	// Bounded, in case remotes wrap each other
+	for i := 0; i < 5; i++ {
+		wrapped, ok := fsConfig.FileGetValue(names[len(names)-1], "remote")
+		if !ok {
+			break
+		}
+		next, err := fspath.Parse(wrapped)
+		if err != nil || next.Name == "" || strings.HasPrefix(next.Name, ":") {
+			break
+		}
+		names = append(names, next.Name)
+	}
+	return names
+}
+
+// checkRemotePins checks the pinned remotes a task goes through before it
+// runs, so a server presenting a different host key or certificate fails
+// the run with *rclone.PinChangedError instead of being trusted. Servers
+// that can't be reached are left to the run to report.
+func (s *SyncService) checkRemotePins(ctx context.Context, task *SyncTask) error {
+	seen := map[string]bool{}
+	for _, path := range append([]string{task.Profile.From}, task.Profile.Destinations()...) {
+		for _, name := range pinnedRemoteNames(path) {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			pin, err := loadRemotePin(name)
+			if err != nil {
+				log.Printf("Warning: failed to load the pin of remote '%s': %v", name, err)
+				continue
+			}
+			if pin == nil {
+				continue
+			}
+			status := checkRemotePin(ctx, *pin)
+			if status.Status == models.PinStatusChanged {
+				return &rclone.PinChangedError{Remote: name, Kind: pin.Kind, Pinned: status.Pinned, Current: status.Current}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	fsConfig "github.com/rclone/rclone/fs/config"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestRemoteService_Pins(t *testing.T) {
+	newProbe := func() *rclone.PinProbe {
+		public, _, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := ssh.NewPublicKey(public)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &rclone.PinProbe{
+			Fingerprint: ssh.FingerprintSHA256(key),
+			KeyType:     key.Type(),
+			HostKey:     strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))),
+		}
+	}
+	presented := newProbe()
+	defer func(orig func(context.Context, string, string) (*rclone.PinProbe, error)) { probeRemotePin = orig }(probeRemotePin)
+	probeRemotePin = func(ctx context.Context, kind, address string) (*rclone.PinProbe, error) {
+		if address != "nas.local:22" {
+			return nil, errors.New("unexpected address " + address)
+		}
+		return presented, nil
+	}
+
+	fsConfig.FileSetValue("nas", "type", "sftp")
+	fsConfig.FileSetValue("nas", "host", "nas.local")
+	fsConfig.FileSetValue("secret", "type", "crypt")
+	fsConfig.FileSetValue("secret", "remote", "nas:encrypted")
+	defer fsConfig.DeleteRemote("nas")
+	defer fsConfig.DeleteRemote("secret")
+
+	r := NewRemoteService(nil)
+	s := NewSyncService(nil)
+	ctx := context.Background()
+	task := &SyncTask{Profile: models.Profile{From: "/home/user/docs", To: "secret:backup"}}
+
+	status, err := r.CheckRemotePin(ctx, "nas")
+	if err != nil || status.Status != models.PinStatusUnpinned || status.Current != presented.Fingerprint {
+		t.Fatalf("CheckRemotePin(unpinned) = %+v, %v", status, err)
+	}
+	if status, err = r.PinRemote(ctx, "nas"); err != nil || status.Status != models.PinStatusMatch {
+		t.Fatalf("PinRemote() = %+v, %v", status, err)
+	}
+	if _, err := r.PinRemote(ctx, "nas"); err == nil {
+		t.Error("expected pinning a pinned remote again to be refused")
+	}
+	if file, _ := fsConfig.FileGetValue("nas", "known_hosts_file"); file != pinnedKnownHostsPath() {
+		t.Errorf("known_hosts_file = %q, want the app's", file)
+	}
+	if data, err := os.ReadFile(pinnedKnownHostsPath()); err != nil || !strings.Contains(string(data), "nas.local "+presented.HostKey) {
+		t.Errorf("known_hosts = %q, %v", data, err)
+	}
+	if err := s.checkRemotePins(ctx, task); err != nil {
+		t.Fatalf("checkRemotePins(match) = %v", err)
+	}
+
+	// The server was rebuilt
+	pinned := presented.Fingerprint
+	presented = newProbe()
+	var changed *rclone.PinChangedError
+	if err := s.checkRemotePins(ctx, task); !errors.As(err, &changed) || changed.Remote != "nas" || changed.Pinned != pinned {
+		t.Fatalf("checkRemotePins(changed) = %v, want *rclone.PinChangedError", err)
+	}
+	if _, err := r.AcceptRemotePin(ctx, "nas", pinned); err == nil {
+		t.Error("expected accepting a fingerprint the server doesn't present to be refused")
+	}
+	if status, err = r.AcceptRemotePin(ctx, "nas", presented.Fingerprint); err != nil || status.Status != models.PinStatusMatch {
+		t.Fatalf("AcceptRemotePin() = %+v, %v", status, err)
+	}
+	if err := s.checkRemotePins(ctx, task); err != nil {
+		t.Errorf("checkRemotePins(accepted) = %v", err)
+	}
+
+	if err := r.UnpinRemote(ctx, "nas"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fsConfig.FileGetValue("nas", "known_hosts_file"); ok {
+		t.Error("expected the app's known_hosts_file to be removed with the pin")
+	}
+	if pins, err := r.GetRemotePins(ctx); err != nil || len(pins) != 0 {
+		t.Errorf("pins = %+v, %v", pins, err)
+	}
+}
@@ -124,6 +124,9 @@ func (r *RemoteService) AddRemote(ctx context.Context, name, remoteType string,
 	if remoteType == "" {
 		return fmt.Errorf("remote type cannot be empty")
 	}
+	if err := validateRemoteOptions(remoteType, config); err != nil {
+		return err
+	}
 
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
@@ -183,6 +186,9 @@ func (r *RemoteService) UpdateRemote(ctx context.Context, name string, config ma
 	if !found {
 		return fmt.Errorf("remote '%s' not found", name)
 	}
+	if err := validateRemoteOptions(existingType, config); err != nil {
+		return err
+	}
 
 	// Convert config to rc.Params
 	rcParams := rc.Params{}
@@ -249,6 +255,11 @@ func (r *RemoteService) DeleteRemote(ctx context.Context, name string) error {
 		}
 	}
 
+	// Forget its pin
+	if err := deleteRemotePin(name); err != nil {
+		log.Printf("Warning: failed to delete the pin of remote '%s': %v", name, err)
+	}
+
 	// Create remote info for event
 	remoteInfo := RemoteInfo{
 		Name:        name,
@@ -297,22 +308,23 @@ func (r *RemoteService) TestRemote(ctx context.Context, name string) error {
 // getRemoteDescription returns a description for a remote type
 func (r *RemoteService) getRemoteDescription(remoteType string) string {
 	descriptions := map[string]string{
-		"s3":       "Amazon S3 Compatible Storage",
-		"drive":    "Google Drive",
-		"dropbox":  "Dropbox",
-		"onedrive": "Microsoft OneDrive",
-		"gdrive":   "Google Drive",
-		"box":      "Box",
-		"mega":     "Mega",
-		"pcloud":   "pCloud",
-		"webdav":   "WebDAV",
-		"ftp":      "FTP",
-		"sftp":     "SFTP",
-		"local":    "Local Filesystem",
-		"memory":   "In Memory",
-		"crypt":    "Encrypted Remote",
-		"compress": "Compressed Remote",
-		"cache":    "Cached Remote",
+		"s3":        "Amazon S3 Compatible Storage",
+		"drive":     "Google Drive",
+		"dropbox":   "Dropbox",
+		"onedrive":  "Microsoft OneDrive",
+		"gdrive":    "Google Drive",
+		"box":       "Box",
+		"mega":      "Mega",
+		"pcloud":    "pCloud",
+		"webdav":    "WebDAV",
+		"ftp":       "FTP",
+		"sftp":      "SFTP",
+		"local":     "Local Filesystem",
+		"memory":    "In Memory",
+		"simulated": "Simulated Remote",
+		"crypt":     "Encrypted Remote",
+		"compress":  "Compressed Remote",
+		"cache":     "Cached Remote",
 	}
 
 	if desc, exists := descriptions[remoteType]; exists {
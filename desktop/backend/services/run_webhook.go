@@ -0,0 +1,213 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"desktop/backend/models"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rclone/rclone/fs/fshttp"
+)
+
+// runWebhookTimeout bounds each run webhook request
+const runWebhookTimeout = 30 * time.Second
+
+// sendRunWebhooks POSTs payload to each webhook that wants its event, in the
+// background. A webhook that fails is logged; it doesn't affect the run.
+func sendRunWebhooks(hooks []models.RunWebhook, payload models.RunWebhookPayload) {
+	for _, hook := range hooks {
+		if !hook.Wants(payload.Event) {
+			continue
+		}
+		go func(hook models.RunWebhook) {
+			if err := postRunWebhook(context.Background(), hook, payload); err != nil {
+				log.Printf("[Webhook] %s %s %q: %v", payload.Kind, payload.Event, payload.Name, err)
+			}
+		}(hook)
+	}
+}
+
+// postRunWebhook POSTs payload to a webhook as JSON. Like HTTP flow steps, it
+// goes through rclone's HTTP transport and reads secret header values from
+// the vault. Any non-2xx response is an error. Besides run payloads, it
+// carries the freshness alerts of profiles.
+func postRunWebhook(ctx context.Context, hook models.RunWebhook, payload interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, runWebhookTimeout)
+	defer cancel()
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	// Run variables don't apply to webhooks; the payload carries the run
+	req, err := buildHTTPStepRequest(ctx, &models.HTTPStep{URL: hook.URL, Headers: hook.Headers}, nil, lookupSecret)
+	if err != nil {
+		return err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	req.ContentLength = int64(len(body))
+	if req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := fshttp.NewClient(ctx).Do(req)
+	if err != nil {
+		return fmt.Errorf("POST %s: %w", req.URL.Redacted(), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxHTTPStepErrorBody))
+		return fmt.Errorf("POST %s returned %s: %s", req.URL.Redacted(), resp.Status, strings.TrimSpace(string(snippet)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// validateRunWebhooks checks that webhooks have absolute http(s) URLs, known
+// events and named headers
+func validateRunWebhooks(hooks []models.RunWebhook) error {
+	for i, hook := range hooks {
+		u, err := url.Parse(strings.TrimSpace(hook.URL))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook %d: invalid url '%s': must be an absolute http(s) URL", i+1, hook.URL)
+		}
+		for _, event := range hook.Events {
+			if event != models.RunWebhookStarted && event != models.RunWebhookFinished {
+				return fmt.Errorf("webhook %d: unknown event '%s'", i+1, event)
+			}
+		}
+		for _, h := range hook.Headers {
+			if strings.TrimSpace(h.Name) == "" {
+				return fmt.Errorf("webhook %d: header name is required", i+1)
+			}
+		}
+	}
+	return nil
+}
+
+// webhookPayload describes a board run for its webhooks
+func (flow *FlowExecution) webhookPayload(board *models.Board, event string) models.RunWebhookPayload {
+	progress := flow.progress()
+
+	labels := make(map[string]string, len(board.Nodes))
+	for _, node := range board.Nodes {
+		labels[node.Id] = node.Label
+	}
+	names := make(map[string]string, len(board.Edges))
+	for _, edge := range board.Edges {
+		names[edge.Id] = fmt.Sprintf("%s -> %s", labels[edge.SourceId], labels[edge.TargetId])
+	}
+
+	flow.StatusMu.Lock()
+	defer flow.StatusMu.Unlock()
+	payload := models.RunWebhookPayload{
+		Event:            event,
+		Kind:             "board",
+		Id:               board.Id,
+		Name:             board.Name,
+		Status:           flow.Status.Status,
+		StartTime:        flow.Status.StartTime,
+		EndTime:          flow.Status.EndTime,
+		Cards:            make([]models.RunWebhookCard, 0, len(flow.Status.EdgeStatuses)),
+		FilesTransferred: progress.FilesTransferred,
+		BytesTransferred: progress.BytesTransferred,
+		Errors:           progress.Errors,
+	}
+	for _, es := range flow.Status.EdgeStatuses {
+		card := models.RunWebhookCard{Id: es.EdgeId, Name: names[es.EdgeId], Status: es.Status, Message: es.Message, TaskId: es.TaskId}
+		if task := flow.tasks[es.EdgeId]; task != nil {
+			if status := task.latestStatus(); status != nil {
+				card.FilesTransferred, card.BytesTransferred = status.FilesTransferred, status.BytesTransferred
+			}
+		}
+		if es.Status == "failed" {
+			payload.Failures = append(payload.Failures, fmt.Sprintf("%s: %s", card.Name, es.Message))
+		}
+		payload.Cards = append(payload.Cards, card)
+	}
+	return payload
+}
+
+// flowRunPayload tracks a backend run of a flow for its webhooks
+type flowRunPayload struct {
+	models.RunWebhookPayload
+}
+
+// newFlowRunPayload starts tracking a run of flow, with all steps pending
+func newFlowRunPayload(flow *models.Flow) *flowRunPayload {
+	p := &flowRunPayload{models.RunWebhookPayload{
+		Kind:      "flow",
+		Id:        flow.Id,
+		Name:      flow.Name,
+		Status:    "running",
+		StartTime: time.Now(),
+		Cards:     make([]models.RunWebhookCard, len(flow.Operations)),
+	}}
+	for i, op := range flow.Operations {
+		stepType := op.Type
+		if stepType == "" {
+			stepType = models.OperationTypeSync
+		}
+		p.Cards[i] = models.RunWebhookCard{Id: op.Id, Name: stepType, Status: "pending"}
+	}
+	return p
+}
+
+// event returns the payload of a run webhook event
+func (p *flowRunPayload) event(event string) models.RunWebhookPayload {
+	payload := p.RunWebhookPayload
+	payload.Event = event
+	payload.Cards = append([]models.RunWebhookCard(nil), p.Cards...)
+	payload.Failures = append([]string(nil), p.Failures...)
+	return payload
+}
+
+// endStep records how step i ended, with the final status of its sync task
+// if it ran one
+func (p *flowRunPayload) endStep(i int, err error, task *SyncTask) {
+	card := &p.Cards[i]
+	card.Status = "completed"
+	if task != nil {
+		card.TaskId = task.Id
+		if status := task.latestStatus(); status != nil {
+			card.FilesTransferred, card.BytesTransferred = status.FilesTransferred, status.BytesTransferred
+			p.FilesTransferred += status.FilesTransferred
+			p.BytesTransferred += status.BytesTransferred
+			p.Errors += status.Errors
+		}
+	}
+	if err != nil {
+		card.Status = "failed"
+		card.Message = err.Error()
+		p.Failures = append(p.Failures, fmt.Sprintf("%s: %s", card.Id, err))
+	}
+}
+
+// finish records how the run ended; steps that never ran are skipped
+func (p *flowRunPayload) finish(ctx context.Context, err error) {
+	end := time.Now()
+	p.EndTime = &end
+	switch {
+	case err == nil:
+		p.Status = "completed"
+	case ctx.Err() != nil:
+		p.Status = "cancelled"
+	default:
+		p.Status = "failed"
+	}
+	for i := range p.Cards {
+		if p.Cards[i].Status == "pending" {
+			p.Cards[i].Status = "skipped"
+		}
+	}
+	if err != nil && len(p.Failures) == 0 {
+		p.Failures = append(p.Failures, err.Error())
+	}
+}
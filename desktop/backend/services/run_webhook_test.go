@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPostRunWebhook(t *testing.T) {
+	var got models.RunWebhookPayload
+	var gotType, gotToken string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotType, gotToken = r.Header.Get("Content-Type"), r.Header.Get("X-Token")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("bad payload: %v", err)
+		}
+		if r.URL.Path == "/fail" {
+			http.Error(w, "dashboard down", http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	hook := models.RunWebhook{URL: server.URL + "/runs", Headers: []models.HTTPHeader{{Name: "X-Token", Value: "abc"}}}
+	payload := models.RunWebhookPayload{Event: models.RunWebhookFinished, Kind: "board", Id: "board-1", Name: "Nightly ${X}",
+		Status: "failed", BytesTransferred: 2048, Failures: []string{"NAS -> Cloud: quota exceeded"}}
+	if err := postRunWebhook(context.Background(), hook, payload); err != nil {
+		t.Fatal(err)
+	}
+	if gotType != "application/json" || gotToken != "abc" || got.Name != "Nightly ${X}" || got.BytesTransferred != 2048 || len(got.Failures) != 1 {
+		t.Errorf("server got %s %s %+v", gotType, gotToken, got)
+	}
+
+	hook.URL = server.URL + "/fail"
+	if err := postRunWebhook(context.Background(), hook, payload); err == nil {
+		t.Error("expected a non-2xx response to fail")
+	}
+}
+
+func TestFlowRunPayload(t *testing.T) {
+	flow := &models.Flow{Id: "flow-1", Name: "Nightly", Operations: []models.Operation{
+		{Id: "op-1", Type: models.OperationTypeHTTP}, {Id: "op-2"}, {Id: "op-3"},
+	}}
+	p := newFlowRunPayload(flow)
+	started := p.event(models.RunWebhookStarted)
+	if started.Status != "running" || started.Cards[1].Name != models.OperationTypeSync || started.Cards[1].Status != "pending" {
+		t.Errorf("unexpected start payload: %+v", started)
+	}
+
+	p.endStep(0, nil, nil)
+	err := errors.New("operation 'op-2' failed: boom")
+	p.endStep(1, err, nil)
+	p.finish(context.Background(), err)
+	finished := p.event(models.RunWebhookFinished)
+	if finished.Status != "failed" || finished.EndTime == nil || len(finished.Failures) != 1 {
+		t.Errorf("unexpected finish payload: %+v", finished)
+	}
+	for i, want := range []string{"completed", "failed", "skipped"} {
+		if finished.Cards[i].Status != want {
+			t.Errorf("card %d status = %s, want %s", i, finished.Cards[i].Status, want)
+		}
+	}
+	if started.Cards[0].Status != "pending" {
+		t.Error("a sent payload should not change as the run goes on")
+	}
+}
+
+func TestValidateRunWebhooks(t *testing.T) {
+	valid := []models.RunWebhook{{URL: "https://dash.example.com/hook", Events: []string{models.RunWebhookFinished}}}
+	if err := validateRunWebhooks(valid); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, hooks := range [][]models.RunWebhook{
+		{{URL: "dash.example.com/hook"}},
+		{{URL: "https://dash.example.com", Events: []string{"progress"}}},
+		{{URL: "https://dash.example.com", Headers: []models.HTTPHeader{{Value: "x"}}}},
+	} {
+		if err := validateRunWebhooks(hooks); err == nil {
+			t.Errorf("expected %+v to be refused", hooks)
+		}
+	}
+	if (models.RunWebhook{Events: []string{models.RunWebhookFinished}}).Wants(models.RunWebhookStarted) {
+		t.Error("a finish-only webhook should not want start events")
+	}
+}
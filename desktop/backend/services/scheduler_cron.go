@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // schedule time zones on systems without a zoneinfo database, e.g. Windows
+
+	"github.com/robfig/cron/v3"
+)
+
+// maxUpcomingRuns caps how many fire times GetUpcomingRuns returns
+const maxUpcomingRuns = 500
+
+// cronParser parses schedule cron expressions: the standard 5 fields,
+// optionally preceded by a seconds field, or a descriptor such as "@daily"
+// or "@every 90m"
+var cronParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// parseScheduleCron parses the cron expression of a schedule in its time
+// zone, the local one when unset
+func parseScheduleCron(entry models.ScheduleEntry) (cron.Schedule, error) {
+	spec := strings.TrimSpace(entry.CronExpr)
+	if entry.Timezone != "" {
+		if strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=") {
+			return nil, fmt.Errorf("the time zone is set both in the expression and on the schedule")
+		}
+		if _, err := time.LoadLocation(entry.Timezone); err != nil {
+			return nil, fmt.Errorf("unknown time zone %q", entry.Timezone)
+		}
+		spec = "CRON_TZ=" + entry.Timezone + " " + spec
+	}
+	return cronParser.Parse(spec)
+}
+
+// GetUpcomingRuns returns the next n fire times of a schedule, in its time
+// zone, so they can be shown on a calendar. Disabled and suspended
+// schedules are previewed as if they were active.
+func (s *SchedulerService) GetUpcomingRuns(ctx context.Context, scheduleId string, n int) ([]time.Time, error) {
+	if n < 1 || n > maxUpcomingRuns {
+		return nil, fmt.Errorf("number of runs must be between 1 and %d", maxUpcomingRuns)
+	}
+	s.mutex.RLock()
+	i := s.findSchedule(scheduleId)
+	var entry models.ScheduleEntry
+	if i >= 0 {
+		entry = s.schedules[i]
+	}
+	s.mutex.RUnlock()
+	if i < 0 {
+		return nil, fmt.Errorf("schedule '%s' not found", scheduleId)
+	}
+
+	sched, err := parseScheduleCron(entry)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", entry.CronExpr, err)
+	}
+	// Fire times come back in the location of the time they follow
+	from := time.Now()
+	if entry.Timezone != "" {
+		loc, _ := time.LoadLocation(entry.Timezone) // checked by parseScheduleCron
+		from = from.In(loc)
+	}
+	return upcomingRuns(sched, from, n), nil
+}
+
+// upcomingRuns returns up to n fire times of sched after from. Fewer are
+// returned for expressions that stop firing, e.g. on February 30th.
+func upcomingRuns(sched cron.Schedule, from time.Time, n int) []time.Time {
+	runs := make([]time.Time, 0, n)
+	for t := from; len(runs) < n; {
+		t = sched.Next(t)
+		if t.IsZero() {
+			break
+		}
+		runs = append(runs, t)
+	}
+	return runs
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"testing"
+	"time"
+)
+
+func TestParseScheduleCron(t *testing.T) {
+	tests := []struct {
+		expr, timezone string
+		wantErr        bool
+	}{
+		{"0 */6 * * *", "", false},
+		{"30 0 */6 * * *", "", false}, // with seconds
+		{"@daily", "Asia/Tokyo", false},
+		{"0 2 * * *", "Not/AZone", true},
+		{"CRON_TZ=UTC 0 2 * * *", "Asia/Tokyo", true}, // zone set twice
+		{"0 2 * *", "", true},
+	}
+	for _, tt := range tests {
+		_, err := parseScheduleCron(models.ScheduleEntry{CronExpr: tt.expr, Timezone: tt.timezone})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseScheduleCron(%q, %q) error = %v, wantErr %v", tt.expr, tt.timezone, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSchedulerService_GetUpcomingRuns(t *testing.T) {
+	s := newTestSchedulerService(t)
+	ctx := context.Background()
+
+	entry := models.ScheduleEntry{
+		Id:          "sched-tz",
+		ProfileName: "test-profile",
+		Action:      "push",
+		CronExpr:    "15 0 9 * * *",
+		Timezone:    "America/New_York",
+		CreatedAt:   time.Now(),
+	}
+	if err := s.AddSchedule(ctx, entry); err != nil {
+		t.Fatalf("AddSchedule failed: %v", err)
+	}
+
+	runs, err := s.GetUpcomingRuns(ctx, "sched-tz", 3)
+	if err != nil {
+		t.Fatalf("GetUpcomingRuns failed: %v", err)
+	}
+	if len(runs) != 3 {
+		t.Fatalf("expected 3 runs, got %d", len(runs))
+	}
+	for i, run := range runs {
+		if run.Location().String() != "America/New_York" || run.Hour() != 9 || run.Minute() != 0 || run.Second() != 15 {
+			t.Errorf("run %d = %v, want 09:00:15 New York time", i, run)
+		}
+		if i > 0 && !run.After(runs[i-1]) {
+			t.Errorf("runs not in order: %v", runs)
+		}
+	}
+
+	if _, err := s.GetUpcomingRuns(ctx, "sched-tz", 0); err == nil {
+		t.Error("expected error for zero runs")
+	}
+	if _, err := s.GetUpcomingRuns(ctx, "missing", 3); err == nil {
+		t.Error("expected error for an unknown schedule")
+	}
+}
+
+func TestUpcomingRunsNeverFiring(t *testing.T) {
+	sched, err := cronParser.Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runs := upcomingRuns(sched, time.Now(), 3); len(runs) != 0 {
+		t.Errorf("expected no runs on February 30th, got %v", runs)
+	}
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/events"
+	"desktop/backend/models"
+	"fmt"
+	"log"
+	"time"
+)
+
+// runScheduledDrift checks whether the schedule's profile still mirrors
+// its source without fixing anything, and alerts when it starts drifting.
+// The run fails while the destination has drifted or when the check can't
+// run.
+func (s *SchedulerService) runScheduledDrift(ctx context.Context, entry models.ScheduleEntry) error {
+	if s.operationService == nil || s.configService == nil {
+		return fmt.Errorf("operation service not available")
+	}
+	profile, err := s.findProfile(ctx, entry.ProfileName)
+	if err != nil {
+		return err
+	}
+
+	report, err := s.operationService.detectDrift(ctx, profile, entry.Id)
+	if report != nil {
+		s.emitScheduleEvent(events.ScheduleDrift, entry.Id, report)
+		if startedDrifting(report) {
+			s.sendDriftNotification(report)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if report.Differences() > 0 {
+		return fmt.Errorf("%s has drifted from its source: %d new, %d changed and %d extra files",
+			profile.Name, report.NewCount, report.ChangedCount, report.ExtraCount)
+	}
+	return nil
+}
+
+// startedDrifting reports whether every difference a drift check found was
+// new to it, i.e. the mirror was in sync at the previous check
+func startedDrifting(report *models.DriftReport) bool {
+	return report.DriftingSince != nil && !report.DriftingSince.Before(report.EndTime.UTC().Truncate(time.Second))
+}
+
+// sendDriftNotification alerts that a profile's destination stopped
+// mirroring its source
+func (s *SchedulerService) sendDriftNotification(report *models.DriftReport) {
+	if s.notificationService == nil {
+		return
+	}
+	body := fmt.Sprintf("Profile \"%s\": the destination no longer mirrors the source. %d files are new, %d changed and %d extra.",
+		report.ProfileName, report.NewCount, report.ChangedCount, report.ExtraCount)
+	if err := s.notificationService.SendCategoryNotification(context.Background(), NotifyCategoryMirrorDrift, NotifySeverityWarning, "Mirror Drift Detected", body); err != nil {
+		log.Printf("Failed to send drift notification: %v", err)
+	}
+}
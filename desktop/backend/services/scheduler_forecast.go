@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/events"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	fssync "github.com/rclone/rclone/fs/sync"
+)
+
+// throughputHistoryRuns is how many recent completed runs of a profile its
+// measured throughput is averaged over
+const throughputHistoryRuns = 10
+
+// errWindowEnded is returned by a run that was stopped at the end of its
+// sync window; it carries on at the schedule's next trigger
+var errWindowEnded = errors.New("sync window ended before the run finished")
+
+// ForecastSchedule estimates whether the next run of a profile schedule fits
+// its sync window. A dry run works out what would be transferred, and the
+// time it takes is estimated from the throughput of recent runs of the
+// profile, capped by its bandwidth limit.
+func (s *SchedulerService) ForecastSchedule(ctx context.Context, scheduleId string) (*models.WindowForecast, error) {
+	s.mutex.RLock()
+	i := s.findSchedule(scheduleId)
+	var entry models.ScheduleEntry
+	if i >= 0 {
+		entry = s.schedules[i]
+	}
+	s.mutex.RUnlock()
+	if i < 0 {
+		return nil, fmt.Errorf("schedule '%s' not found", scheduleId)
+	}
+	if scheduleTargetType(entry) != "profile" {
+		return nil, fmt.Errorf("only profile schedules can be forecast")
+	}
+	if s.configService == nil {
+		return nil, fmt.Errorf("config service not available")
+	}
+	profile, err := s.findProfile(ctx, entry.ProfileName)
+	if err != nil {
+		return nil, err
+	}
+	return forecastScheduleRun(ctx, entry, profile)
+}
+
+// forecastScheduleRun plans the schedule's run of profile and estimates
+// how long it takes. Two-way syncs are planned in both directions.
+func forecastScheduleRun(ctx context.Context, entry models.ScheduleEntry, profile models.Profile) (*models.WindowForecast, error) {
+	var files, bytes int64
+	for _, pull := range planDirections(entry.Action) {
+		f, b, err := rclone.PlanTransfer(ctx, profile, pull)
+		if err != nil {
+			return nil, err
+		}
+		files += f
+		bytes += b
+	}
+	bps, source := profileThroughput(profile)
+	forecast := newWindowForecast(entry.WindowMinutes, files, bytes, bps, source)
+	forecast.ScheduleId = entry.Id
+	forecast.ProfileName = profile.Name
+	return forecast, nil
+}
+
+// planDirections returns which directions an action transfers in, as
+// PlanTransfer's pull argument
+func planDirections(action string) []bool {
+	switch action {
+	case "pull":
+		return []bool{true}
+	case "bi", "bi-resync":
+		return []bool{false, true}
+	default:
+		return []bool{false}
+	}
+}
+
+// profileThroughput returns the average throughput, in bytes per second, of
+// the profile's recent completed runs that transferred data, capped by its
+// bandwidth limit. Without such runs the bandwidth limit is used, and 0 is
+// returned when there is neither.
+func profileThroughput(profile models.Profile) (float64, string) {
+	limit := float64(profile.Bandwidth) * float64(fs.Mebi)
+
+	var measured float64
+	if db, err := GetSharedDB(); err == nil {
+		rows, err := db.Query(`SELECT duration, bytes_transferred FROM history
+			WHERE profile_name = ? AND status = 'completed' AND bytes_transferred > 0 AND duration != ''
+			ORDER BY start_time DESC LIMIT ?`, profile.Name, throughputHistoryRuns)
+		if err == nil {
+			defer rows.Close()
+			var total time.Duration
+			var bytes int64
+			for rows.Next() {
+				var dur string
+				var b int64
+				if rows.Scan(&dur, &b) != nil {
+					continue
+				}
+				if d, err := time.ParseDuration(dur); err == nil && d > 0 {
+					total += d
+					bytes += b
+				}
+			}
+			if total > 0 {
+				measured = float64(bytes) / total.Seconds()
+			}
+		}
+	}
+
+	switch {
+	case measured > 0 && (limit == 0 || measured <= limit):
+		return measured, "history"
+	case limit > 0:
+		return limit, "bandwidth_limit"
+	default:
+		return 0, ""
+	}
+}
+
+// newWindowForecast estimates how long transferring bytes at bps takes and
+// how many windows of windowMinutes it needs
+func newWindowForecast(windowMinutes int, files, bytes int64, bps float64, source string) *models.WindowForecast {
+	f := &models.WindowForecast{
+		WindowMinutes:    windowMinutes,
+		PlannedFiles:     files,
+		PlannedBytes:     bytes,
+		BytesPerSecond:   bps,
+		ThroughputSource: source,
+		Verdict:          "unknown",
+	}
+	switch {
+	case bytes == 0:
+		f.WindowsNeeded = 1
+		f.Verdict = "fits"
+		f.Message = "Nothing to transfer"
+		return f
+	case bps <= 0:
+		f.Message = fmt.Sprintf("%d files (%s) to transfer; no throughput measured yet", files, fs.SizeSuffix(bytes))
+		return f
+	}
+
+	estimate := time.Duration(float64(bytes) / bps * float64(time.Second))
+	f.EstimatedSeconds = int64(math.Ceil(estimate.Seconds()))
+	if windowMinutes <= 0 {
+		f.Message = fmt.Sprintf("%d files (%s) to transfer in about %s", files, fs.SizeSuffix(bytes), estimate.Round(time.Minute))
+		return f
+	}
+
+	window := time.Duration(windowMinutes) * time.Minute
+	f.WindowsNeeded = int(math.Ceil(float64(estimate) / float64(window)))
+	if f.WindowsNeeded < 1 {
+		f.WindowsNeeded = 1
+	}
+	if estimate <= window {
+		f.Verdict = "fits"
+		f.Message = fmt.Sprintf("%d files (%s) to transfer in about %s, within the %s window",
+			files, fs.SizeSuffix(bytes), estimate.Round(time.Minute), window)
+	} else {
+		f.Verdict = "exceeds"
+		f.Message = fmt.Sprintf("%d files (%s) to transfer in about %s, which needs %d windows of %s",
+			files, fs.SizeSuffix(bytes), estimate.Round(time.Minute), f.WindowsNeeded, window)
+	}
+	return f
+}
+
+// applySyncWindow forecasts a windowed run before it starts and warns when
+// it won't fit. When the schedule splits runs across windows, the run is
+// stopped at the end of its window; since syncs only transfer what changed,
+// the next run picks up where it left off. A forecast that fails is
+// logged, and the run goes ahead.
+func (s *SchedulerService) applySyncWindow(ctx context.Context, entry models.ScheduleEntry, profile models.Profile) models.Profile {
+	if entry.WindowMinutes <= 0 {
+		return profile
+	}
+	window := time.Duration(entry.WindowMinutes) * time.Minute
+
+	forecast, err := forecastScheduleRun(ctx, entry, profile)
+	if err != nil {
+		log.Printf("Schedule '%s': could not forecast the run: %v", entry.Id, err)
+	} else if forecast.Verdict == "exceeds" {
+		log.Printf("Schedule '%s': %s", entry.Id, forecast.Message)
+		s.emitScheduleEvent(events.ScheduleForecast, entry.Id, forecast)
+		s.sendWindowNotification(entry, forecast)
+	}
+
+	if entry.SplitAcrossWindow {
+		var current fs.Duration
+		if profile.MaxDuration == "" || current.Set(profile.MaxDuration) != nil || time.Duration(current) <= 0 || time.Duration(current) > window {
+			profile.MaxDuration = window.String()
+		}
+	}
+	return profile
+}
+
+// sendWindowNotification warns that a run is forecast to overrun its window
+func (s *SchedulerService) sendWindowNotification(entry models.ScheduleEntry, forecast *models.WindowForecast) {
+	if s.notificationService == nil {
+		return
+	}
+	body := fmt.Sprintf("Profile \"%s\": %s.", forecast.ProfileName, forecast.Message)
+	if entry.SplitAcrossWindow {
+		body += " The run will stop at the end of the window and carry on at the next one."
+	}
+	if err := s.notificationService.SendCategoryNotification(context.Background(), NotifyCategoryScheduleWindow,
+		NotifySeverityWarning, "Sync Won't Fit Its Window", body); err != nil {
+		log.Printf("Failed to send window forecast notification: %v", err)
+	}
+}
+
+// windowEnded reports whether a run failed only because it reached the end
+// of its sync window
+func windowEnded(entry models.ScheduleEntry, err error) bool {
+	return entry.SplitAcrossWindow && errors.Is(err, fssync.ErrorMaxDurationReached)
+}
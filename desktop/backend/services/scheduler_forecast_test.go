@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"desktop/backend/models"
+)
+
+func TestNewWindowForecast(t *testing.T) {
+	const mib = 1 << 20
+	tests := []struct {
+		name          string
+		window        int
+		bytes         int64
+		bps           float64
+		verdict       string
+		windowsNeeded int
+	}{
+		{"nothing to transfer", 60, 0, 0, "fits", 1},
+		{"no throughput", 60, mib, 0, "unknown", 0},
+		{"no window", 0, mib, mib, "unknown", 0},
+		{"fits", 60, 1800 * mib, mib, "fits", 1},
+		{"exceeds", 60, 9000 * mib, mib, "exceeds", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newWindowForecast(tt.window, 10, tt.bytes, tt.bps, "history")
+			if f.Verdict != tt.verdict || f.WindowsNeeded != tt.windowsNeeded {
+				t.Errorf("verdict = %s, windows = %d; want %s, %d (%s)", f.Verdict, f.WindowsNeeded, tt.verdict, tt.windowsNeeded, f.Message)
+			}
+		})
+	}
+
+	if f := newWindowForecast(60, 10, 1800*mib, mib, "history"); f.EstimatedSeconds != 1800 {
+		t.Errorf("EstimatedSeconds = %d, want 1800", f.EstimatedSeconds)
+	}
+}
+
+func TestSchedulerService_SyncWindow(t *testing.T) {
+	s := newTestSchedulerService(t)
+	ctx := context.Background()
+
+	entry := models.ScheduleEntry{
+		Id: "nightly", ProfileName: "p", Action: "push", CronExpr: "0 1 * * *",
+		WindowMinutes: 300, SplitAcrossWindow: true, CreatedAt: time.Now(),
+	}
+	if err := s.AddSchedule(ctx, entry); err != nil {
+		t.Fatalf("AddSchedule failed: %v", err)
+	}
+	loaded, err := s.loadSchedulesFromDB()
+	if err != nil || len(loaded) != 1 || loaded[0].WindowMinutes != 300 || !loaded[0].SplitAcrossWindow {
+		t.Errorf("sync window not persisted: %+v, %v", loaded, err)
+	}
+
+	invalid := entry
+	invalid.Id = "invalid"
+	invalid.WindowMinutes = 0
+	if err := s.AddSchedule(ctx, invalid); err == nil {
+		t.Error("expected splitting without a window to be rejected")
+	}
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"desktop/backend/models"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// freshnessCheckInterval is how often profiles are checked against their
+// freshness targets
+const freshnessCheckInterval = 5 * time.Minute
+
+// freshnessEscalation is how old, as a multiple of its freshness target, a
+// profile's last completed run must be to reach each alert level
+var freshnessEscalation = []struct {
+	level  string
+	factor float64
+}{
+	{models.FreshnessLevelInApp, 1},
+	{models.FreshnessLevelOS, 1.25},
+	{models.FreshnessLevelWebhook, 1.5},
+}
+
+// freshnessLevel returns the alert level a profile reached when its last
+// completed run is age old, or "" if it meets its target, and the age at
+// which it escalates next (0 when there is no further level)
+func freshnessLevel(target, age time.Duration) (string, time.Duration) {
+	level := ""
+	for _, step := range freshnessEscalation {
+		at := time.Duration(float64(target) * step.factor)
+		if age <= at {
+			return level, at
+		}
+		level = step.level
+	}
+	return level, 0
+}
+
+// freshnessRank orders alert levels; "" ranks lowest
+func freshnessRank(level string) int {
+	for i, step := range freshnessEscalation {
+		if step.level == level {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// GetFreshnessStatuses returns how recently each profile with a freshness
+// target completed a run, and how far its alert escalated
+func (s *SchedulerService) GetFreshnessStatuses(ctx context.Context) ([]models.FreshnessStatus, error) {
+	if s.configService == nil {
+		return []models.FreshnessStatus{}, nil
+	}
+	profiles, err := s.configService.GetProfiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := []models.FreshnessStatus{}
+	now := time.Now()
+	for _, p := range profiles {
+		if p.FreshnessTarget == "" {
+			continue
+		}
+		status, _, err := loadFreshnessStatus(p, now)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// watchFreshness checks profiles against their freshness targets every
+// freshnessCheckInterval until the scheduler stops. It doesn't depend on
+// schedules: a profile nothing tried to run is overdue all the same.
+func (s *SchedulerService) watchFreshness() {
+	ticker := time.NewTicker(freshnessCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.mutex.RLock()
+		stopped := s.stopped
+		s.mutex.RUnlock()
+		if stopped {
+			return
+		}
+		s.checkFreshness(context.Background())
+	}
+}
+
+// checkFreshness escalates the alerts of profiles overdue for a completed
+// run, one level at a time as they get older, and reports profiles that
+// meet their target again
+func (s *SchedulerService) checkFreshness(ctx context.Context) {
+	if s.configService == nil {
+		return
+	}
+	profiles, err := s.configService.GetProfiles(ctx)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	monitored := make(map[string]bool)
+	for _, p := range profiles {
+		if p.FreshnessTarget == "" {
+			continue
+		}
+		monitored[p.Name] = true
+		status, previous, err := loadFreshnessStatus(p, now)
+		if err != nil {
+			log.Printf("Warning: Could not check the freshness of '%s': %v", p.Name, err)
+			continue
+		}
+
+		if !status.Breached && previous != "" {
+			s.sendFreshnessRecovered(p, status, previous)
+		}
+		for _, step := range freshnessEscalation {
+			if freshnessRank(step.level) > freshnessRank(previous) && freshnessRank(step.level) <= freshnessRank(status.Level) {
+				s.sendFreshnessAlert(p, status, step.level)
+			}
+		}
+		if status.Level != previous {
+			if err := saveFreshnessLevel(p.Name, status.Level); err != nil {
+				log.Printf("Warning: Could not record the freshness alert of '%s': %v", p.Name, err)
+			}
+		}
+	}
+	if err := pruneFreshnessState(monitored); err != nil {
+		log.Printf("Warning: Could not prune freshness state: %v", err)
+	}
+}
+
+// sendFreshnessAlert alerts that a profile is overdue, through the channel
+// of the given level
+func (s *SchedulerService) sendFreshnessAlert(profile models.Profile, status models.FreshnessStatus, level string) {
+	title := "Backup Overdue"
+	body := freshnessMessage(status)
+	log.Printf("[Freshness] '%s' is overdue (%s): %s", profile.Name, level, body)
+
+	switch level {
+	case models.FreshnessLevelInApp:
+		if s.notificationService != nil {
+			s.notificationService.SendInAppNotification(context.Background(), NotifyCategoryFreshness, NotifySeverityWarning, title, body)
+		}
+	case models.FreshnessLevelOS:
+		if s.notificationService != nil {
+			if err := s.notificationService.SendCategoryNotification(context.Background(), NotifyCategoryFreshness,
+				NotifySeverityError, title, body); err != nil {
+				log.Printf("Failed to send freshness notification: %v", err)
+			}
+		}
+	case models.FreshnessLevelWebhook:
+		sendFreshnessWebhooks(profile, status)
+	}
+}
+
+// sendFreshnessRecovered reports that an overdue profile completed a run
+// again: in the app, and to its webhooks if the alert had reached them
+func (s *SchedulerService) sendFreshnessRecovered(profile models.Profile, status models.FreshnessStatus, previous string) {
+	log.Printf("[Freshness] '%s' meets its freshness target again", profile.Name)
+	if s.notificationService != nil {
+		s.notificationService.SendInAppNotification(context.Background(), NotifyCategoryFreshness, NotifySeverityInfo,
+			"Backup Up to Date", fmt.Sprintf("Profile \"%s\" completed a run and meets its %s freshness target again.", profile.Name, profile.FreshnessTarget))
+	}
+	if previous == models.FreshnessLevelWebhook {
+		sendFreshnessWebhooks(profile, status)
+	}
+}
+
+// sendFreshnessWebhooks POSTs a profile's freshness status to its freshness
+// webhooks in the background
+func sendFreshnessWebhooks(profile models.Profile, status models.FreshnessStatus) {
+	for _, hook := range profile.FreshnessWebhooks {
+		go func(hook models.RunWebhook) {
+			if err := postRunWebhook(context.Background(), hook, status); err != nil {
+				log.Printf("[Webhook] freshness %q: %v", profile.Name, err)
+			}
+		}(hook)
+	}
+}
+
+// freshnessMessage describes how overdue a profile is
+func freshnessMessage(status models.FreshnessStatus) string {
+	age := (time.Duration(status.AgeSeconds) * time.Second).Round(time.Minute)
+	if status.LastSuccess == nil {
+		return fmt.Sprintf("Profile \"%s\" hasn't completed a run in the %s since its freshness was first checked; its target is every %s.",
+			status.ProfileName, age, status.Target)
+	}
+	return fmt.Sprintf("Profile \"%s\" last completed a run %s ago; its target is every %s.", status.ProfileName, age, status.Target)
+}
+
+// loadFreshnessStatus works out a profile's freshness at now from its last
+// completed run, and returns the alert level recorded at the last check.
+// A profile checked for the first time starts being watched.
+func loadFreshnessStatus(profile models.Profile, now time.Time) (models.FreshnessStatus, string, error) {
+	status := models.FreshnessStatus{ProfileName: profile.Name, Target: profile.FreshnessTarget}
+	target, err := time.ParseDuration(profile.FreshnessTarget)
+	if err != nil || target <= 0 {
+		return status, "", fmt.Errorf("invalid freshness target '%s'", profile.FreshnessTarget)
+	}
+	db, err := GetSharedDB()
+	if err != nil {
+		return status, "", err
+	}
+
+	var previous, watchedSince string
+	err = db.QueryRow("SELECT level, watched_since FROM freshness_alerts WHERE profile_name = ?", profile.Name).Scan(&previous, &watchedSince)
+	if errors.Is(err, sql.ErrNoRows) {
+		watchedSince = now.UTC().Format(time.RFC3339)
+		_, err = db.Exec("INSERT INTO freshness_alerts (profile_name, level, watched_since) VALUES (?, '', ?)", profile.Name, watchedSince)
+	}
+	if err != nil {
+		return status, "", err
+	}
+	status.WatchedSince, _ = time.Parse(time.RFC3339, watchedSince)
+
+	var endTime string
+	err = db.QueryRow(`SELECT end_time FROM history
+		WHERE profile_name = ? AND status = 'completed' AND action IN ('pull', 'push', 'bi', 'bi-resync')
+		ORDER BY end_time DESC LIMIT 1`, profile.Name).Scan(&endTime)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return status, "", err
+	}
+	since := status.WatchedSince
+	if t, err := time.Parse(time.RFC3339, endTime); err == nil {
+		status.LastSuccess = &t
+		since = t
+	}
+
+	age := now.Sub(since)
+	status.AgeSeconds = int64(age / time.Second)
+	level, next := freshnessLevel(target, age)
+	status.Level = level
+	status.Breached = level != ""
+	if status.Breached {
+		breachedAt := since.Add(target)
+		status.BreachedAt = &breachedAt
+	}
+	if status.Breached && next > 0 {
+		nextAt := since.Add(next)
+		status.NextLevelAt = &nextAt
+	}
+	return status, previous, nil
+}
+
+// saveFreshnessLevel records the alert level a profile reached
+func saveFreshnessLevel(profileName, level string) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("UPDATE freshness_alerts SET level = ? WHERE profile_name = ?", level, profileName)
+	return err
+}
+
+// pruneFreshnessState forgets profiles that are no longer monitored, so
+// setting a target again starts watching afresh
+func pruneFreshnessState(monitored map[string]bool) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	rows, err := db.Query("SELECT profile_name FROM freshness_alerts")
+	if err != nil {
+		return err
+	}
+	var stale []string
+	for rows.Next() {
+		var name string
+		if rows.Scan(&name) == nil && !monitored[name] {
+			stale = append(stale, name)
+		}
+	}
+	rows.Close()
+	for _, name := range stale {
+		if _, err := db.Exec("DELETE FROM freshness_alerts WHERE profile_name = ?", name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"desktop/backend/models"
+	"testing"
+	"time"
+)
+
+func TestFreshnessLevel(t *testing.T) {
+	target := 24 * time.Hour
+	tests := []struct {
+		age       time.Duration
+		wantLevel string
+		wantNext  time.Duration
+	}{
+		{time.Hour, "", 24 * time.Hour},
+		{24 * time.Hour, "", 24 * time.Hour},
+		{25 * time.Hour, models.FreshnessLevelInApp, 30 * time.Hour},
+		{31 * time.Hour, models.FreshnessLevelOS, 36 * time.Hour},
+		{48 * time.Hour, models.FreshnessLevelWebhook, 0},
+	}
+	for _, tt := range tests {
+		level, next := freshnessLevel(target, tt.age)
+		if level != tt.wantLevel || next != tt.wantNext {
+			t.Errorf("freshnessLevel(%s) = %q, %s; want %q, %s", tt.age, level, next, tt.wantLevel, tt.wantNext)
+		}
+	}
+
+	if freshnessRank("") >= freshnessRank(models.FreshnessLevelInApp) ||
+		freshnessRank(models.FreshnessLevelOS) >= freshnessRank(models.FreshnessLevelWebhook) {
+		t.Error("freshnessRank() doesn't follow the escalation order")
+	}
+}
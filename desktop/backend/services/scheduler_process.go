@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// processWatchInterval is how often a run that pauses for blocking
+// applications checks whether one of them is running
+const processWatchInterval = 15 * time.Second
+
+type scheduleRunKey struct{}
+
+// withScheduleRun marks the syncs started under ctx as part of a schedule's
+// run, so the schedule can pause them (see holdScheduleTasks)
+func withScheduleRun(ctx context.Context, scheduleId string) context.Context {
+	if scheduleId == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, scheduleRunKey{}, scheduleId)
+}
+
+// scheduleRunFromContext returns the schedule whose run ctx belongs to, or ""
+func scheduleRunFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(scheduleRunKey{}).(string)
+	return id
+}
+
+// runningProcessNames returns the names of the running processes, keyed by
+// processKey. Tests replace it to fake the process list.
+var runningProcessNames = func() (map[string]bool, error) {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(procs))
+	for _, p := range procs {
+		// Processes of other users may not be readable; skip them
+		if name, err := p.Name(); err == nil && name != "" {
+			names[processKey(name)] = true
+		}
+	}
+	return names, nil
+}
+
+// processKey normalises a process name for matching: case-insensitive and
+// without an ".exe" or ".app" suffix, so "steam" matches "Steam.exe"
+func processKey(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.TrimSuffix(name, ".exe")
+	return strings.TrimSuffix(name, ".app")
+}
+
+// runningBlockingProcess returns the first of the blocking applications that
+// is running, or "" when none is (or the process list can't be read)
+func runningBlockingProcess(blocking []string) string {
+	if len(blocking) == 0 {
+		return ""
+	}
+	names, err := runningProcessNames()
+	if err != nil {
+		log.Printf("SchedulerService: could not list running processes: %v", err)
+		return ""
+	}
+	for _, name := range blocking {
+		if names[processKey(name)] {
+			return name
+		}
+	}
+	return ""
+}
+
+// blockingProcessFor returns the running blocking application of a schedule, or ""
+func (s *SchedulerService) blockingProcessFor(scheduleId string) string {
+	s.mutex.RLock()
+	var blocking []string
+	if i := s.findSchedule(scheduleId); i >= 0 {
+		blocking = s.schedules[i].BlockingProcesses
+	}
+	s.mutex.RUnlock()
+	return runningBlockingProcess(blocking)
+}
+
+// watchBlockingProcesses pauses the syncs of a schedule's run while one of
+// its blocking applications runs, and resumes them once all have exited.
+// It returns when the run is done, which cancels ctx.
+func (s *SchedulerService) watchBlockingProcesses(ctx context.Context, entry models.ScheduleEntry) {
+	if s.syncService == nil {
+		return
+	}
+	ticker := time.NewTicker(processWatchInterval)
+	defer ticker.Stop()
+
+	held := false
+	defer func() {
+		// Let held syncs finish, as cancelled if the run was
+		if held {
+			s.syncService.holdScheduleTasks(entry.Id, false)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		proc := runningBlockingProcess(entry.BlockingProcesses)
+		switch {
+		case proc != "":
+			// Hold on every tick: a board run starts new syncs as it goes
+			s.syncService.holdScheduleTasks(entry.Id, true)
+			if !held {
+				log.Printf("Schedule '%s' paused: %s is running", entry.Id, proc)
+				held = true
+			}
+		case held:
+			log.Printf("Schedule '%s' resuming: blocking applications have exited", entry.Id)
+			s.syncService.holdScheduleTasks(entry.Id, false)
+			held = false
+		}
+	}
+}
@@ -4,8 +4,12 @@ import (
 	"context"
 	"desktop/backend/events"
 	"desktop/backend/models"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -15,16 +19,24 @@ import (
 
 // SchedulerService manages cron-based scheduled sync operations
 type SchedulerService struct {
-	app         *application.App
-	eventBus    *events.WailsEventBus
-	cron        *cron.Cron
-	schedules   []models.ScheduleEntry
-	cronEntries map[string]cron.EntryID // scheduleId -> cron entry ID
-	mutex       sync.RWMutex
-	initialized bool
+	app          *application.App
+	eventBus     *events.WailsEventBus
+	cron         *cron.Cron
+	schedules    []models.ScheduleEntry
+	cronEntries  map[string]cron.EntryID // scheduleId -> cron entry ID
+	queued       map[string]bool         // scheduleId -> a trigger waits for the run in flight ("queue" policy)
+	deferred     map[string]bool         // scheduleId -> its last trigger was deferred for the API budget
+	pausedUntil  time.Time               // end of the pause set by PauseSchedules, kept with each schedule
+	pendingSince time.Time               // when the scheduler started waiting for unlock; zero once it runs
+	mutex        sync.RWMutex
+	initialized  bool
+	stopped      bool // the app is exiting; nothing triggers any more
 
 	// Dependencies injected after creation
-	syncService *SyncService
+	syncService         *SyncService
+	operationService    *OperationService
+	configService       *ConfigService
+	notificationService *NotificationService
 }
 
 // NewSchedulerService creates a new scheduler service
@@ -33,6 +45,8 @@ func NewSchedulerService(app *application.App) *SchedulerService {
 		app:         app,
 		schedules:   []models.ScheduleEntry{},
 		cronEntries: make(map[string]cron.EntryID),
+		queued:      make(map[string]bool),
+		deferred:    make(map[string]bool),
 		cron:        cron.New(),
 	}
 }
@@ -52,6 +66,52 @@ func (s *SchedulerService) SetSyncService(syncService *SyncService) {
 	s.syncService = syncService
 }
 
+// SetOperationService sets the operation service that runs verifications
+func (s *SchedulerService) SetOperationService(operationService *OperationService) {
+	s.operationService = operationService
+}
+
+// SetConfigService sets the config service verifications look profiles up in
+func (s *SchedulerService) SetConfigService(configService *ConfigService) {
+	s.configService = configService
+}
+
+// SetNotificationService sets the notification service verification alerts go through
+func (s *SchedulerService) SetNotificationService(notificationService *NotificationService) {
+	s.notificationService = notificationService
+}
+
+// SetSettingsService sets the settings service and follows changes to the
+// API budget, which decides whether triggers are deferred
+func (s *SchedulerService) SetSettingsService(settingsService *SettingsService) {
+	settingsService.Subscribe(s.settingsChanged)
+}
+
+// settingsChanged applies changed settings to the schedules
+func (s *SchedulerService) settingsChanged(change SettingsChange) {
+	// A higher budget may let deferred schedules run before their next trigger
+	if change.Changed("api_budget_threshold", "api_daily_quotas") {
+		go s.retryDeferred()
+	}
+}
+
+// retryDeferred triggers again the schedules whose last trigger was deferred
+// for the API budget; those still over it are deferred again
+func (s *SchedulerService) retryDeferred() {
+	s.mutex.Lock()
+	ids := make([]string, 0, len(s.deferred))
+	for id := range s.deferred {
+		ids = append(ids, id)
+	}
+	clear(s.deferred)
+	s.mutex.Unlock()
+
+	for _, id := range ids {
+		log.Printf("Schedule '%s' retried: API budget changed", id)
+		s.triggerSchedule(id)
+	}
+}
+
 // ServiceName returns the name of the service
 func (s *SchedulerService) ServiceName() string {
 	return "SchedulerService"
@@ -63,9 +123,15 @@ func (s *SchedulerService) ServiceName() string {
 // and ensureInitialized() retries on first access after unlock.
 func (s *SchedulerService) ServiceStartup(ctx context.Context, options application.ServiceOptions) error {
 	log.Printf("SchedulerService starting up (async)...")
+	startedAt := time.Now()
 	go func() {
 		if err := s.initialize(); err != nil {
 			log.Printf("SchedulerService init deferred (DB not ready): %v", err)
+			s.mutex.Lock()
+			if !s.initialized {
+				s.pendingSince = startedAt
+			}
+			s.mutex.Unlock()
 		}
 	}()
 	return nil
@@ -85,10 +151,55 @@ func (s *SchedulerService) ensureInitialized() error {
 // ServiceShutdown is called when the service shuts down
 func (s *SchedulerService) ServiceShutdown(ctx context.Context) error {
 	log.Printf("SchedulerService shutting down...")
-	s.cron.Stop()
+	s.stopTriggers()
+	s.cancelRuns(ctx)
 	return nil
 }
 
+// stopTriggers stops firing schedules for app exit and drops re-runs queued
+// behind active runs. Runs in flight keep going; see cancelRuns.
+func (s *SchedulerService) stopTriggers() {
+	s.cron.Stop()
+	s.mutex.RLock()
+	if s.initialized && !s.stopped {
+		saveSchedulerHeartbeat(time.Now())
+	}
+	s.mutex.RUnlock()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.stopped = true
+	clear(s.queued)
+	clear(s.deferred)
+}
+
+// cancelRuns cancels runs still in flight and waits until they have recorded
+// their results, or ctx is done
+func (s *SchedulerService) cancelRuns(ctx context.Context) {
+	if s.operationService == nil {
+		return
+	}
+	tasks := s.operationService.scheduleRunTasks()
+	for _, task := range tasks {
+		task.Cancel()
+	}
+	for _, task := range tasks {
+		select {
+		case <-task.Done:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// activeRun returns the task of the schedule's run in flight, or nil
+func (s *SchedulerService) activeRun(scheduleId string) *OperationTask {
+	if s.operationService == nil {
+		return nil
+	}
+	return s.operationService.scheduleRunTask(scheduleId)
+}
+
 // initialize loads existing schedules from SQLite and registers cron jobs.
 // Returns error if DB is not available (e.g. auth enabled, files encrypted).
 // In that case, initialized stays false so ensureInitialized() retries later.
@@ -107,9 +218,16 @@ func (s *SchedulerService) initialize() error {
 	}
 	s.schedules = schedules
 
+	// A pause outlives restarts
+	for _, e := range s.schedules {
+		if e.PausedUntil != nil && e.PausedUntil.After(s.pausedUntil) {
+			s.pausedUntil = *e.PausedUntil
+		}
+	}
+
 	// Register enabled schedules with cron
 	for i := range s.schedules {
-		if s.schedules[i].Enabled {
+		if isScheduleActive(s.schedules[i]) {
 			if err := s.registerCronJob(&s.schedules[i]); err != nil {
 				log.Printf("Warning: Failed to register schedule %s: %v", s.schedules[i].Id, err)
 			}
@@ -119,6 +237,17 @@ func (s *SchedulerService) initialize() error {
 	// Start cron scheduler (safe to call multiple times)
 	s.cron.Start()
 
+	// Catch up on schedules missed since the scheduler last ran
+	startedAt := time.Now().Round(0)
+	if aliveAt := loadSchedulerHeartbeat(); !aliveAt.IsZero() {
+		go s.catchUpMissed(aliveAt, startedAt, "while the app wasn't running")
+	}
+	saveSchedulerHeartbeat(startedAt)
+	go s.watchHeartbeat(startedAt)
+
+	go s.watchFreshness()
+	go s.watchUsageDrift()
+
 	s.initialized = true
 	log.Printf("SchedulerService initialized with %d schedules", len(s.schedules))
 	return nil
@@ -132,18 +261,22 @@ func (s *SchedulerService) AddSchedule(ctx context.Context, entry models.Schedul
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	// Validate cron expression
-	if _, err := cron.ParseStandard(entry.CronExpr); err != nil {
-		return fmt.Errorf("invalid cron expression %q: %w", entry.CronExpr, err)
+	if err := validateScheduleEntry(entry); err != nil {
+		return err
 	}
 
 	if entry.CreatedAt.IsZero() {
 		entry.CreatedAt = time.Now()
 	}
+	entry.PausedUntil = nil
+	if time.Now().Before(s.pausedUntil) {
+		until := s.pausedUntil
+		entry.PausedUntil = &until
+	}
 
 	s.schedules = append(s.schedules, entry)
 
-	if entry.Enabled {
+	if isScheduleActive(entry) {
 		if err := s.registerCronJob(&s.schedules[len(s.schedules)-1]); err != nil {
 			s.schedules = s.schedules[:len(s.schedules)-1]
 			return fmt.Errorf("failed to register cron job: %w", err)
@@ -169,9 +302,8 @@ func (s *SchedulerService) UpdateSchedule(ctx context.Context, entry models.Sche
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	// Validate cron expression
-	if _, err := cron.ParseStandard(entry.CronExpr); err != nil {
-		return fmt.Errorf("invalid cron expression %q: %w", entry.CronExpr, err)
+	if err := validateScheduleEntry(entry); err != nil {
+		return err
 	}
 
 	found := false
@@ -179,12 +311,13 @@ func (s *SchedulerService) UpdateSchedule(ctx context.Context, entry models.Sche
 	for i, existing := range s.schedules {
 		if existing.Id == entry.Id {
 			oldEntry = existing
+			entry.PausedUntil = existing.PausedUntil
 			s.schedules[i] = entry
 			found = true
 
 			// Re-register cron job
 			s.unregisterCronJob(entry.Id)
-			if entry.Enabled {
+			if isScheduleActive(entry) {
 				if err := s.registerCronJob(&s.schedules[i]); err != nil {
 					s.schedules[i] = oldEntry
 					return fmt.Errorf("failed to register cron job: %w", err)
@@ -204,7 +337,7 @@ func (s *SchedulerService) UpdateSchedule(ctx context.Context, entry models.Sche
 			if existing.Id == entry.Id {
 				s.unregisterCronJob(entry.Id)
 				s.schedules[i] = oldEntry
-				if oldEntry.Enabled {
+				if isScheduleActive(oldEntry) {
 					_ = s.registerCronJob(&s.schedules[i])
 				}
 				break
@@ -241,7 +374,7 @@ func (s *SchedulerService) DeleteSchedule(ctx context.Context, scheduleId string
 		return fmt.Errorf("schedule '%s' not found", scheduleId)
 	}
 
-	if err := s.deleteScheduleFromDB(scheduleId); err != nil {
+	if err := s.deleteScheduleFromDB(deletedEntry); err != nil {
 		s.schedules = append(s.schedules, deletedEntry)
 		return fmt.Errorf("failed to delete schedule: %w", err)
 	}
@@ -274,8 +407,12 @@ func (s *SchedulerService) EnableSchedule(ctx context.Context, scheduleId string
 	for i, entry := range s.schedules {
 		if entry.Id == scheduleId {
 			s.schedules[i].Enabled = true
-			if err := s.registerCronJob(&s.schedules[i]); err != nil {
-				return fmt.Errorf("failed to register cron job: %w", err)
+			s.unregisterCronJob(scheduleId)
+			if isScheduleActive(s.schedules[i]) {
+				if err := s.registerCronJob(&s.schedules[i]); err != nil {
+					s.schedules[i].Enabled = false
+					return fmt.Errorf("failed to register cron job: %w", err)
+				}
 			}
 			if err := s.saveScheduleToDB(s.schedules[i]); err != nil {
 				s.unregisterCronJob(scheduleId)
@@ -312,18 +449,325 @@ func (s *SchedulerService) DisableSchedule(ctx context.Context, scheduleId strin
 	return fmt.Errorf("schedule '%s' not found", scheduleId)
 }
 
+// PauseSchedules skips all schedule triggers for the given duration, also
+// across restarts. Returns the time at which schedules resume.
+func (s *SchedulerService) PauseSchedules(ctx context.Context, duration time.Duration) (time.Time, error) {
+	if duration <= 0 {
+		return time.Time{}, fmt.Errorf("pause duration must be positive")
+	}
+	if err := s.ensureInitialized(); err != nil {
+		return time.Time{}, err
+	}
+	s.mutex.Lock()
+	until := time.Now().Add(duration)
+	err := s.setPausedUntilLocked(until)
+	s.mutex.Unlock()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	log.Printf("Schedules paused until %s", until.Format(time.RFC3339))
+	s.emitScheduleEvent(events.ScheduleUpdated, "", map[string]string{
+		"paused_until": until.UTC().Format(time.RFC3339),
+	})
+	return until, nil
+}
+
+// ResumeSchedules lifts a pause set by PauseSchedules
+func (s *SchedulerService) ResumeSchedules(ctx context.Context) {
+	s.mutex.Lock()
+	if err := s.setPausedUntilLocked(time.Time{}); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	s.mutex.Unlock()
+
+	log.Printf("Schedules resumed")
+	s.emitScheduleEvent(events.ScheduleUpdated, "", map[string]string{
+		"paused_until": "",
+	})
+}
+
+// setPausedUntilLocked sets the end of the pause, zero for none, on the
+// scheduler and every schedule. Caller must hold s.mutex.
+func (s *SchedulerService) setPausedUntilLocked(until time.Time) error {
+	s.pausedUntil = until
+	var saveErr error
+	for i := range s.schedules {
+		s.schedules[i].PausedUntil = nil
+		if !until.IsZero() {
+			t := until
+			s.schedules[i].PausedUntil = &t
+		}
+		if err := s.saveScheduleToDB(s.schedules[i]); err != nil && saveErr == nil {
+			saveErr = fmt.Errorf("failed to save schedule '%s': %w", s.schedules[i].Id, err)
+		}
+	}
+	return saveErr
+}
+
+// GetSchedulesPausedUntil returns the end of the current pause, or nil if schedules are not paused
+func (s *SchedulerService) GetSchedulesPausedUntil(ctx context.Context) *time.Time {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if !time.Now().Before(s.pausedUntil) {
+		return nil
+	}
+	until := s.pausedUntil
+	return &until
+}
+
+// ============ Bulk Operations ============
+
+// EnableSchedulesByTag enables every schedule carrying the given tag.
+// Returns the number of schedules changed.
+func (s *SchedulerService) EnableSchedulesByTag(ctx context.Context, tag string) (int, error) {
+	return s.setEnabledByTag(tag, true)
+}
+
+// DisableSchedulesByTag disables every schedule carrying the given tag.
+// Returns the number of schedules changed.
+func (s *SchedulerService) DisableSchedulesByTag(ctx context.Context, tag string) (int, error) {
+	return s.setEnabledByTag(tag, false)
+}
+
+// ShiftSchedules moves the fire time of the selected schedules by the given
+// number of minutes (negative values move them earlier). All cron expressions
+// are shifted before any schedule is changed, so an unshiftable expression
+// leaves every schedule untouched.
+func (s *SchedulerService) ShiftSchedules(ctx context.Context, scheduleIds []string, minutes int) (int, error) {
+	if err := s.ensureInitialized(); err != nil {
+		return 0, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	shifted := make(map[string]string, len(scheduleIds))
+	for _, id := range scheduleIds {
+		i := s.findSchedule(id)
+		if i < 0 {
+			return 0, fmt.Errorf("schedule '%s' not found", id)
+		}
+		expr, err := shiftCronExpr(s.schedules[i].CronExpr, minutes)
+		if err != nil {
+			return 0, fmt.Errorf("cannot shift schedule '%s': %w", id, err)
+		}
+		shifted[id] = expr
+	}
+
+	return s.updateSchedules(func(e models.ScheduleEntry) bool {
+		_, ok := shifted[e.Id]
+		return ok
+	}, func(e *models.ScheduleEntry) {
+		e.CronExpr = shifted[e.Id]
+	})
+}
+
+// SuspendSchedulesForRemote pauses every schedule whose target reads from or
+// writes to the given remote, without changing the schedules' enabled state.
+// Returns the number of schedules suspended.
+func (s *SchedulerService) SuspendSchedulesForRemote(ctx context.Context, remoteName string) (int, error) {
+	if err := s.ensureInitialized(); err != nil {
+		return 0, err
+	}
+	if remoteName == "" {
+		return 0, fmt.Errorf("remote name is required")
+	}
+
+	// Resolve targets outside the lock since it queries other services
+	s.mutex.RLock()
+	snapshot := make([]models.ScheduleEntry, len(s.schedules))
+	copy(snapshot, s.schedules)
+	s.mutex.RUnlock()
+
+	matching := make(map[string]bool)
+	for _, e := range snapshot {
+		if e.SuspendedRemote != "" {
+			continue
+		}
+		for _, r := range s.scheduleRemotes(ctx, e) {
+			if r == remoteName {
+				matching[e.Id] = true
+				break
+			}
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.updateSchedules(func(e models.ScheduleEntry) bool {
+		return matching[e.Id] && e.SuspendedRemote == ""
+	}, func(e *models.ScheduleEntry) {
+		e.SuspendedRemote = remoteName
+	})
+}
+
+// ResumeSchedulesForRemote lifts a suspension previously applied with
+// SuspendSchedulesForRemote. Returns the number of schedules resumed.
+func (s *SchedulerService) ResumeSchedulesForRemote(ctx context.Context, remoteName string) (int, error) {
+	if err := s.ensureInitialized(); err != nil {
+		return 0, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.updateSchedules(func(e models.ScheduleEntry) bool {
+		return e.SuspendedRemote == remoteName && remoteName != ""
+	}, func(e *models.ScheduleEntry) {
+		e.SuspendedRemote = ""
+	})
+}
+
+// reassignProfileSchedules points the profile schedules of the given profiles
+// at another profile, e.g. after they were merged into it. Returns the number
+// of schedules updated.
+func (s *SchedulerService) reassignProfileSchedules(fromNames []string, toName string) (int, error) {
+	if err := s.ensureInitialized(); err != nil {
+		return 0, err
+	}
+	from := make(map[string]bool, len(fromNames))
+	for _, name := range fromNames {
+		from[name] = true
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.updateSchedules(func(e models.ScheduleEntry) bool {
+		return scheduleTargetType(e) == "profile" && from[e.ProfileName]
+	}, func(e *models.ScheduleEntry) {
+		e.ProfileName = toName
+	})
+}
+
+// setEnabledByTag enables or disables all schedules carrying the tag
+func (s *SchedulerService) setEnabledByTag(tag string, enabled bool) (int, error) {
+	if err := s.ensureInitialized(); err != nil {
+		return 0, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.updateSchedules(func(e models.ScheduleEntry) bool {
+		return e.Enabled != enabled && hasTag(e.Tags, tag)
+	}, func(e *models.ScheduleEntry) {
+		e.Enabled = enabled
+	})
+}
+
+// updateSchedules applies fn to every schedule matching the predicate,
+// re-registers its cron job and persists it. Stops at the first failure and
+// returns the number of schedules updated so far. Caller must hold s.mutex.
+func (s *SchedulerService) updateSchedules(match func(models.ScheduleEntry) bool, fn func(*models.ScheduleEntry)) (int, error) {
+	count := 0
+	for i := range s.schedules {
+		if !match(s.schedules[i]) {
+			continue
+		}
+		oldEntry := s.schedules[i]
+		fn(&s.schedules[i])
+
+		s.unregisterCronJob(oldEntry.Id)
+		if isScheduleActive(s.schedules[i]) {
+			if err := s.registerCronJob(&s.schedules[i]); err != nil {
+				s.schedules[i] = oldEntry
+				if isScheduleActive(oldEntry) {
+					_ = s.registerCronJob(&s.schedules[i])
+				}
+				return count, fmt.Errorf("failed to register cron job for '%s': %w", oldEntry.Id, err)
+			}
+		}
+
+		if err := s.saveScheduleToDB(s.schedules[i]); err != nil {
+			s.unregisterCronJob(oldEntry.Id)
+			s.schedules[i] = oldEntry
+			if isScheduleActive(oldEntry) {
+				_ = s.registerCronJob(&s.schedules[i])
+			}
+			return count, fmt.Errorf("failed to save schedule '%s': %w", oldEntry.Id, err)
+		}
+
+		s.emitScheduleEvent(events.ScheduleUpdated, s.schedules[i].Id, s.schedules[i])
+		count++
+	}
+	return count, nil
+}
+
+// scheduleRemotes returns the remote names a schedule's target touches
+func (s *SchedulerService) scheduleRemotes(ctx context.Context, entry models.ScheduleEntry) []string {
+	var remotes []string
+	switch scheduleTargetType(entry) {
+	case "board":
+		if bs := GetBoardService(); bs != nil {
+			if board, err := bs.GetBoard(ctx, entry.TargetId); err == nil {
+				for _, node := range board.Nodes {
+					remotes = append(remotes, node.RemoteName)
+				}
+			}
+		}
+	case "flow":
+		if fs := GetFlowService(); fs != nil {
+			if flows, err := fs.GetFlows(ctx); err == nil {
+				for _, f := range flows {
+					if f.Id != entry.TargetId {
+						continue
+					}
+					for _, op := range f.Operations {
+						remotes = append(remotes, op.SourceRemote, op.TargetRemote)
+						if op.Wait != nil {
+							remotes = append(remotes, op.Wait.Remote)
+						}
+					}
+				}
+			}
+		}
+	case "lifecycle":
+		if ls := GetLifecycleService(); ls != nil {
+			if rule, err := ls.GetLifecycleRule(ctx, entry.TargetId); err == nil {
+				remotes = append(remotes, parseRemoteName(rule.SourcePath), parseRemoteName(rule.TargetPath))
+			}
+		}
+	default:
+		db, err := GetSharedDB()
+		if err != nil {
+			return nil
+		}
+		var from, to string
+		if err := db.QueryRow("SELECT from_path, to_path FROM profiles WHERE name = ?", entry.ProfileName).Scan(&from, &to); err == nil {
+			remotes = append(remotes, parseRemoteName(from), parseRemoteName(to))
+		}
+	}
+	return remotes
+}
+
+// apiBudgetExhaustedFor returns the provider of a non-urgent schedule whose
+// daily API budget is nearly used up, with the percent used, or "" if none is
+func (s *SchedulerService) apiBudgetExhaustedFor(scheduleId string) (string, float64) {
+	if s.syncService == nil {
+		return "", 0
+	}
+	s.mutex.RLock()
+	i := s.findSchedule(scheduleId)
+	var entry models.ScheduleEntry
+	if i >= 0 {
+		entry = s.schedules[i]
+	}
+	s.mutex.RUnlock()
+	if i < 0 || entry.Urgent {
+		return "", 0
+	}
+	return s.syncService.apiBudgetExhausted(s.scheduleRemotes(context.Background(), entry))
+}
+
 // registerCronJob registers a cron job for a schedule entry
 func (s *SchedulerService) registerCronJob(entry *models.ScheduleEntry) error {
 	scheduleId := entry.Id
-	profileName := entry.ProfileName
-	action := entry.Action
 
-	entryId, err := s.cron.AddFunc(entry.CronExpr, func() {
-		s.triggerSchedule(scheduleId, profileName, action)
-	})
+	sched, err := parseScheduleCron(*entry)
 	if err != nil {
 		return err
 	}
+	entryId := s.cron.Schedule(sched, cron.FuncJob(func() {
+		s.triggerSchedule(scheduleId)
+	}))
 
 	s.cronEntries[scheduleId] = entryId
 
@@ -343,80 +787,521 @@ func (s *SchedulerService) unregisterCronJob(scheduleId string) {
 	}
 }
 
-// triggerSchedule is called by cron to execute a scheduled sync
-func (s *SchedulerService) triggerSchedule(scheduleId, profileName, action string) {
-	log.Printf("Schedule '%s' triggered: profile=%s action=%s", scheduleId, profileName, action)
+// triggerSchedule is called by cron to execute a scheduled run. It is
+// skipped while schedules are paused or one of its blocking applications
+// runs, and deferred to its next trigger, or until the API budget settings
+// change, when it isn't urgent and a provider it uses has nearly used up its
+// daily API budget. If the previous run of the same schedule is still
+// active, the schedule's overlap policy decides whether this trigger is
+// skipped, queued behind it, or cancels it and starts over.
+func (s *SchedulerService) triggerSchedule(scheduleId string) {
+	// Listing processes and checking API usage are slow; do them before taking the lock
+	blocking := s.blockingProcessFor(scheduleId)
+	budgetProvider, budgetUsed := s.apiBudgetExhaustedFor(scheduleId)
+
+	s.mutex.Lock()
+	i := s.findSchedule(scheduleId)
+	if i < 0 || s.stopped {
+		s.mutex.Unlock()
+		return
+	}
+
+	entry := s.schedules[i]
+	log.Printf("Schedule '%s' triggered: target=%s/%s action=%s", scheduleId, scheduleTargetType(entry), scheduleTargetLabel(entry), entry.Action)
+
+	// Update last/next run time
+	now := time.Now()
+	s.schedules[i].LastRun = &now
+	if entryId, exists := s.cronEntries[scheduleId]; exists {
+		nextRun := s.cron.Entry(entryId).Next
+		s.schedules[i].NextRun = &nextRun
+	}
+
+	if entry.PausedUntil != nil && now.Before(*entry.PausedUntil) {
+		s.schedules[i].LastResult = "skipped"
+		_ = s.saveScheduleToDB(s.schedules[i])
+		s.mutex.Unlock()
+		log.Printf("Schedule '%s' skipped: schedules paused", scheduleId)
+		s.emitScheduleEvent(events.ScheduleSkipped, scheduleId, map[string]string{
+			"reason": "schedules paused",
+		})
+		return
+	}
+
+	if blocking != "" {
+		s.schedules[i].LastResult = "skipped"
+		_ = s.saveScheduleToDB(s.schedules[i])
+		s.mutex.Unlock()
+		log.Printf("Schedule '%s' skipped: %s is running", scheduleId, blocking)
+		s.emitScheduleEvent(events.ScheduleSkipped, scheduleId, map[string]string{
+			"reason": blocking + " is running",
+		})
+		return
+	}
+
+	delete(s.deferred, scheduleId)
+	if budgetProvider != "" {
+		if s.deferred == nil {
+			s.deferred = make(map[string]bool)
+		}
+		s.deferred[scheduleId] = true
+		s.schedules[i].LastResult = "skipped"
+		_ = s.saveScheduleToDB(s.schedules[i])
+		s.mutex.Unlock()
+		reason := fmt.Sprintf("%.0f%% of the daily %s API budget used", budgetUsed, budgetProvider)
+		log.Printf("Schedule '%s' deferred: %s", scheduleId, reason)
+		s.emitScheduleEvent(events.ScheduleSkipped, scheduleId, map[string]string{
+			"reason": reason,
+		})
+		return
+	}
+
+	if task := s.activeRun(scheduleId); task != nil {
+		switch scheduleOverlapPolicy(entry) {
+		case "queue":
+			if s.queued == nil {
+				s.queued = make(map[string]bool)
+			}
+			s.queued[scheduleId] = true
+			_ = s.saveScheduleToDB(s.schedules[i])
+			s.mutex.Unlock()
+			log.Printf("Schedule '%s' queued: previous run still active", scheduleId)
+			s.emitScheduleEvent(events.ScheduleQueued, scheduleId, map[string]string{
+				"reason": "previous run still active",
+			})
+			return
+		case "cancel":
+			s.mutex.Unlock()
+			log.Printf("Schedule '%s' cancelling previous run", scheduleId)
+			task.Cancel()
+			<-task.Done
+			s.mutex.Lock()
+			if i = s.findSchedule(scheduleId); i < 0 {
+				s.mutex.Unlock()
+				return
+			}
+		default:
+			s.schedules[i].LastResult = "skipped"
+			_ = s.saveScheduleToDB(s.schedules[i])
+			s.mutex.Unlock()
+			log.Printf("Schedule '%s' skipped: previous run still active", scheduleId)
+			s.emitScheduleEvent(events.ScheduleSkipped, scheduleId, map[string]string{
+				"reason": "previous run still active",
+			})
+			return
+		}
+	}
+
+	if err := s.startRun(s.schedules[i]); err != nil {
+		s.schedules[i].LastResult = "failed"
+		_ = s.saveScheduleToDB(s.schedules[i])
+		s.mutex.Unlock()
+		log.Printf("Schedule '%s' run failed: %v", scheduleId, err)
+		return
+	}
+	_ = s.saveScheduleToDB(s.schedules[i])
+	s.mutex.Unlock()
 
 	s.emitScheduleEvent(events.ScheduleTriggered, scheduleId, map[string]string{
-		"profile_name": profileName,
-		"action":       action,
+		"profile_name": entry.ProfileName,
+		"action":       entry.Action,
+		"target_type":  scheduleTargetType(entry),
+		"target_id":    entry.TargetId,
 	})
+}
 
-	// Update last run time
-	s.mutex.Lock()
-	now := time.Now()
-	for i, entry := range s.schedules {
-		if entry.Id == scheduleId {
-			s.schedules[i].LastRun = &now
+// startRun registers the schedule's run in the operation task registry and
+// executes it in the background. Caller must hold s.mutex.
+func (s *SchedulerService) startRun(entry models.ScheduleEntry) error {
+	if s.operationService == nil {
+		return fmt.Errorf("operation service not available")
+	}
+	// Scheduled runs yield to manual ones (see StartSyncNow)
+	ctx, finish := s.operationService.trackScheduleRun(WithTaskPriority(withScheduleRun(context.Background(), entry.Id), PriorityScheduled), entry.Id)
 
-			// Update next run from cron
-			if entryId, exists := s.cronEntries[scheduleId]; exists {
-				cronEntry := s.cron.Entry(entryId)
-				nextRun := cronEntry.Next
-				s.schedules[i].NextRun = &nextRun
+	if entry.PauseForProcesses && len(entry.BlockingProcesses) > 0 {
+		go s.watchBlockingProcesses(ctx, entry)
+	}
+
+	go func() {
+		for {
+			err := s.executeScheduleTarget(ctx, entry)
+
+			result := "success"
+			if ctx.Err() != nil {
+				result = "cancelled"
+			} else if errors.Is(err, errWindowEnded) {
+				result = "partial"
+				log.Printf("Schedule '%s' run stopped at the end of its window; it continues at the next trigger", entry.Id)
+			} else if err != nil {
+				result = "failed"
+				log.Printf("Schedule '%s' run failed: %v", entry.Id, err)
 			}
 
-			// Trigger sync via SyncService if available
-			if s.syncService != nil {
-				var syncAction SyncAction
-				switch action {
-				case "pull":
-					syncAction = ActionPull
-				case "push":
-					syncAction = ActionPush
-				case "bi":
-					syncAction = ActionBi
-				case "bi-resync":
-					syncAction = ActionBiResync
-				default:
-					s.schedules[i].LastResult = "failed"
-					log.Printf("Unknown action '%s' for schedule '%s'", action, scheduleId)
-					_ = s.saveScheduleToDB(s.schedules[i])
+			s.mutex.Lock()
+			if i := s.findSchedule(entry.Id); i >= 0 {
+				s.schedules[i].LastResult = result
+				_ = s.saveScheduleToDB(s.schedules[i])
+
+				// Run once more if a trigger was queued behind this run
+				if s.queued[entry.Id] && ctx.Err() == nil {
+					delete(s.queued, entry.Id)
+					entry = s.schedules[i]
 					s.mutex.Unlock()
-					return
+					s.emitScheduleEvent(events.ScheduleCompleted, entry.Id, map[string]string{"result": result})
+					continue
 				}
+			}
+			delete(s.queued, entry.Id)
+			// Still under the lock, so a trigger either queues behind this run or starts the next
+			finish(err)
+			s.mutex.Unlock()
 
-				// We need the profile from ConfigService, but for now we use profile name
-				// The caller should have the profile data when creating the schedule
-				s.mutex.Unlock()
+			s.emitScheduleEvent(events.ScheduleCompleted, entry.Id, map[string]string{"result": result})
+			return
+		}
+	}()
+	return nil
+}
+
+// executeScheduleTarget runs the schedule's target and blocks until it finishes
+func (s *SchedulerService) executeScheduleTarget(ctx context.Context, entry models.ScheduleEntry) error {
+	switch scheduleTargetType(entry) {
+	case "board":
+		return s.runScheduledBoard(ctx, entry.TargetId)
+	case "flow":
+		return s.runScheduledFlow(ctx, entry.TargetId)
+	case "lifecycle":
+		return s.runScheduledLifecycle(ctx, entry.TargetId)
+	case "verify":
+		return s.runScheduledVerify(ctx, entry)
+	case "drift":
+		return s.runScheduledDrift(ctx, entry)
+	default:
+		return s.runScheduledProfile(ctx, entry)
+	}
+}
+
+// runScheduledProfile starts a sync for the schedule's profile and waits for
+// it. Runs of schedules with a sync window are forecast first (see
+// applySyncWindow).
+func (s *SchedulerService) runScheduledProfile(ctx context.Context, entry models.ScheduleEntry) error {
+	if s.syncService == nil {
+		return fmt.Errorf("sync service not available")
+	}
+
+	var syncAction SyncAction
+	switch entry.Action {
+	case "pull":
+		syncAction = ActionPull
+	case "push":
+		syncAction = ActionPush
+	case "bi":
+		syncAction = ActionBi
+	case "bi-resync":
+		syncAction = ActionBiResync
+	default:
+		return fmt.Errorf("unknown action '%s'", entry.Action)
+	}
+
+	profile := models.Profile{Name: entry.ProfileName}
+	if s.configService != nil {
+		p, err := s.findProfile(ctx, entry.ProfileName)
+		if err != nil {
+			return err
+		}
+		profile = s.applySyncWindow(ctx, entry, p)
+	}
+
+	result, err := s.syncService.StartSync(ctx, string(syncAction), profile, "")
+	if err != nil {
+		return fmt.Errorf("failed to start sync: %w", err)
+	}
+	err = s.syncService.WaitForTask(ctx, result.TaskId)
+	if windowEnded(entry, err) {
+		return errWindowEnded
+	}
+	return err
+}
+
+// runScheduledBoard executes a board and waits for it to reach a terminal state
+func (s *SchedulerService) runScheduledBoard(ctx context.Context, boardId string) error {
+	boardService := GetBoardService()
+	if boardService == nil {
+		return fmt.Errorf("board service not available")
+	}
+
+	if _, err := boardService.ExecuteBoard(ctx, boardId); err != nil {
+		return fmt.Errorf("failed to execute board: %w", err)
+	}
+
+	status, err := boardService.WaitForBoardExecution(ctx, boardId)
+	if err != nil {
+		if ctx.Err() != nil {
+			_ = boardService.StopBoardExecution(context.Background(), boardId)
+		}
+		return err
+	}
+	if status.Status != "completed" {
+		return fmt.Errorf("board execution %s", status.Status)
+	}
+	return nil
+}
+
+// runScheduledFlow executes a flow's operations and waits for them to finish
+func (s *SchedulerService) runScheduledFlow(ctx context.Context, flowId string) error {
+	flowService := GetFlowService()
+	if flowService == nil {
+		return fmt.Errorf("flow service not available")
+	}
+	return flowService.RunFlow(ctx, flowId)
+}
+
+// runScheduledLifecycle runs a lifecycle rule's move and waits for it to finish
+func (s *SchedulerService) runScheduledLifecycle(ctx context.Context, ruleId string) error {
+	lifecycleService := GetLifecycleService()
+	if lifecycleService == nil {
+		return fmt.Errorf("lifecycle service not available")
+	}
+	_, err := lifecycleService.RunLifecycleRule(ctx, ruleId)
+	return err
+}
+
+// findSchedule returns the index of the schedule with the given ID, or -1.
+// Caller must hold s.mutex.
+func (s *SchedulerService) findSchedule(scheduleId string) int {
+	for i := range s.schedules {
+		if s.schedules[i].Id == scheduleId {
+			return i
+		}
+	}
+	return -1
+}
+
+// validateScheduleEntry checks the cron expression, target, overlap and catch-up policies and blocking processes of a schedule
+func validateScheduleEntry(entry models.ScheduleEntry) error {
+	if _, err := parseScheduleCron(entry); err != nil {
+		return fmt.Errorf("invalid cron expression %q: %w", entry.CronExpr, err)
+	}
+
+	switch entry.TargetType {
+	case "", "profile":
+	case "verify":
+		if entry.ProfileName == "" {
+			return fmt.Errorf("schedule target verify requires a profile")
+		}
+		switch entry.Action {
+		case "", "check", "download":
+		default:
+			return fmt.Errorf("invalid verify action %q: expected check or download", entry.Action)
+		}
+		if entry.SamplePercent < 0 || entry.SamplePercent > 100 {
+			return fmt.Errorf("sample percent must be between 0 and 100")
+		}
+	case "drift":
+		if entry.ProfileName == "" {
+			return fmt.Errorf("schedule target drift requires a profile")
+		}
+	case "board", "flow", "lifecycle":
+		if entry.TargetId == "" {
+			return fmt.Errorf("schedule target %s requires a target id", entry.TargetType)
+		}
+	default:
+		return fmt.Errorf("invalid schedule target type %q", entry.TargetType)
+	}
+
+	switch entry.OverlapPolicy {
+	case "", "skip", "queue", "cancel":
+	default:
+		return fmt.Errorf("invalid overlap policy %q", entry.OverlapPolicy)
+	}
+
+	switch entry.CatchUp {
+	case "", "skip", "once", "all":
+	default:
+		return fmt.Errorf("invalid catch-up policy %q", entry.CatchUp)
+	}
 
-				// Start sync (will run asynchronously)
-				_, err := s.syncService.StartSync(context.Background(), string(syncAction), models.Profile{Name: profileName}, "")
-				s.mutex.Lock()
+	for _, name := range entry.BlockingProcesses {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("blocking process names must not be empty")
+		}
+	}
+	if entry.PauseForProcesses && len(entry.BlockingProcesses) == 0 {
+		return fmt.Errorf("pausing for processes requires blocking processes")
+	}
+	if entry.WindowMinutes < 0 {
+		return fmt.Errorf("window minutes cannot be negative")
+	}
+	if entry.SplitAcrossWindow && entry.WindowMinutes == 0 {
+		return fmt.Errorf("splitting runs across windows requires a window")
+	}
+	return nil
+}
+
+// isScheduleActive reports whether a schedule should have a cron job registered
+func isScheduleActive(entry models.ScheduleEntry) bool {
+	return entry.Enabled && entry.SuspendedRemote == ""
+}
+
+// hasTag reports whether tags contains tag
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// shiftCronExpr moves a standard 5-field cron expression, optionally preceded
+// by a seconds field, by the given number of minutes. The seconds are kept.
+// The minute field must list fixed minutes; if the shift carries
+// into another hour, the hour field must list fixed hours too. A carry
+// across midnight moves fixed days of the week along with it, and is
+// rejected when the expression pins days of the month or months, which
+// can't be moved without changing which dates it fires on.
+func shiftCronExpr(expr string, minutes int) (string, error) {
+	fields := strings.Fields(expr)
+	var seconds []string
+	if len(fields) == 6 {
+		seconds, fields = fields[:1], fields[1:]
+	}
+	if len(fields) != 5 {
+		return "", fmt.Errorf("expected 5 or 6 cron fields, got %d", len(fields))
+	}
+
+	minuteVals, err := parseCronList(fields[0], 59)
+	if err != nil {
+		return "", fmt.Errorf("minute field: %w", err)
+	}
+
+	// All minutes must carry by the same number of hours, otherwise a single
+	// expression can't represent the shifted times
+	carry := 0
+	for i, m := range minuteVals {
+		total := m + minutes
+		c := floorDiv(total, 60)
+		if i > 0 && c != carry {
+			return "", fmt.Errorf("minutes %s would shift into different hours", fields[0])
+		}
+		carry = c
+		minuteVals[i] = total - c*60
+	}
+	fields[0] = joinCronList(minuteVals)
 
+	if carry != 0 {
+		hourVals, err := parseCronList(fields[1], 23)
+		if err != nil {
+			return "", fmt.Errorf("hour field: %w", err)
+		}
+		hours := fields[1]
+		dayCarries := make(map[int]bool)
+		for i, h := range hourVals {
+			total := h + carry
+			c := floorDiv(total, 24)
+			dayCarries[c] = true
+			hourVals[i] = total - c*24
+		}
+		fields[1] = joinCronList(hourVals)
+
+		if len(dayCarries) > 1 || !dayCarries[0] {
+			if fields[2] != "*" || fields[3] != "*" {
+				return "", fmt.Errorf("shift crosses midnight on a schedule restricted to days of the month or months")
+			}
+			if fields[4] != "*" {
+				if len(dayCarries) > 1 {
+					return "", fmt.Errorf("hours %s would shift into different days", hours)
+				}
+				dayVals, err := parseCronList(fields[4], 7)
 				if err != nil {
-					for j, e := range s.schedules {
-						if e.Id == scheduleId {
-							s.schedules[j].LastResult = "failed"
-							break
-						}
-					}
-					log.Printf("Failed to trigger sync for schedule '%s': %v", scheduleId, err)
-				} else {
-					for j, e := range s.schedules {
-						if e.Id == scheduleId {
-							s.schedules[j].LastResult = "success"
-							break
-						}
+					return "", fmt.Errorf("day of week field: %w", err)
+				}
+				for dayCarry := range dayCarries {
+					for i, d := range dayVals {
+						// 7 is Sunday like 0
+						total := d + dayCarry
+						dayVals[i] = total - floorDiv(total, 7)*7
 					}
 				}
+				fields[4] = joinCronList(dayVals)
 			}
+		}
+	}
 
-			_ = s.saveScheduleToDB(s.schedules[i])
-			break
+	shifted := strings.Join(append(seconds, fields...), " ")
+	if _, err := cronParser.Parse(shifted); err != nil {
+		return "", fmt.Errorf("shifted expression %q is invalid: %w", shifted, err)
+	}
+	return shifted, nil
+}
+
+// parseCronList parses a comma-separated list of fixed values in [0, max]
+func parseCronList(field string, max int) ([]int, error) {
+	parts := strings.Split(field, ",")
+	vals := make([]int, 0, len(parts))
+	for _, p := range parts {
+		v, err := strconv.Atoi(p)
+		if err != nil || v < 0 || v > max {
+			return nil, fmt.Errorf("%q is not a fixed value list", field)
 		}
+		vals = append(vals, v)
 	}
-	s.mutex.Unlock()
+	return vals, nil
+}
+
+// joinCronList formats values as a sorted, de-duplicated cron list
+func joinCronList(vals []int) string {
+	sort.Ints(vals)
+	parts := make([]string, 0, len(vals))
+	for i, v := range vals {
+		if i > 0 && v == vals[i-1] {
+			continue
+		}
+		parts = append(parts, strconv.Itoa(v))
+	}
+	return strings.Join(parts, ",")
+}
+
+// floorDiv divides rounding towards negative infinity
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+// scheduleTargetType returns the schedule's target type, defaulting to "profile"
+func scheduleTargetType(entry models.ScheduleEntry) string {
+	if entry.TargetType == "" {
+		return "profile"
+	}
+	return entry.TargetType
+}
+
+// scheduleTargetLabel returns a human-readable identifier of the schedule's target
+func scheduleTargetLabel(entry models.ScheduleEntry) string {
+	if t := scheduleTargetType(entry); t == "profile" || t == "verify" || t == "drift" {
+		return entry.ProfileName
+	}
+	return entry.TargetId
+}
+
+// scheduleOverlapPolicy returns the schedule's overlap policy, defaulting to "skip"
+func scheduleOverlapPolicy(entry models.ScheduleEntry) string {
+	if entry.OverlapPolicy == "" {
+		return "skip"
+	}
+	return entry.OverlapPolicy
+}
+
+// scheduleCatchUp returns the schedule's catch-up policy, defaulting to "skip"
+func scheduleCatchUp(entry models.ScheduleEntry) string {
+	if entry.CatchUp == "" {
+		return "skip"
+	}
+	return entry.CatchUp
 }
 
 // loadSchedulesFromDB loads all schedules from SQLite
@@ -426,7 +1311,7 @@ func (s *SchedulerService) loadSchedulesFromDB() ([]models.ScheduleEntry, error)
 		return nil, err
 	}
 
-	rows, err := db.Query("SELECT id, profile_name, action, cron_expr, enabled, last_run, next_run, last_result, created_at FROM schedules")
+	rows, err := db.Query("SELECT id, profile_name, action, cron_expr, timezone, target_type, target_id, overlap_policy, catch_up, tags, enabled, suspended_remote, blocking_processes, pause_for_processes, urgent, sample_percent, window_minutes, split_across_windows, last_run, next_run, last_result, paused_until, created_at FROM schedules")
 	if err != nil {
 		return nil, err
 	}
@@ -435,13 +1320,19 @@ func (s *SchedulerService) loadSchedulesFromDB() ([]models.ScheduleEntry, error)
 	var schedules []models.ScheduleEntry
 	for rows.Next() {
 		var e models.ScheduleEntry
-		var enabled int
-		var lastRun, nextRun *string
+		var enabled, pauseForProcesses, urgent, splitAcrossWindows int
+		var tags, blockingProcesses string
+		var lastRun, nextRun, pausedUntil *string
 		var createdAt string
-		if err := rows.Scan(&e.Id, &e.ProfileName, &e.Action, &e.CronExpr, &enabled, &lastRun, &nextRun, &e.LastResult, &createdAt); err != nil {
+		if err := rows.Scan(&e.Id, &e.ProfileName, &e.Action, &e.CronExpr, &e.Timezone, &e.TargetType, &e.TargetId, &e.OverlapPolicy, &e.CatchUp, &tags, &enabled, &e.SuspendedRemote, &blockingProcesses, &pauseForProcesses, &urgent, &e.SamplePercent, &e.WindowMinutes, &splitAcrossWindows, &lastRun, &nextRun, &e.LastResult, &pausedUntil, &createdAt); err != nil {
 			return nil, fmt.Errorf("failed to scan schedule: %w", err)
 		}
 		e.Enabled = enabled != 0
+		e.Tags = unmarshalStringSlice(tags)
+		e.BlockingProcesses = unmarshalStringSlice(blockingProcesses)
+		e.PauseForProcesses = pauseForProcesses != 0
+		e.Urgent = urgent != 0
+		e.SplitAcrossWindow = splitAcrossWindows != 0
 		if lastRun != nil {
 			if t, err := time.Parse(time.RFC3339, *lastRun); err == nil {
 				e.LastRun = &t
@@ -452,6 +1343,11 @@ func (s *SchedulerService) loadSchedulesFromDB() ([]models.ScheduleEntry, error)
 				e.NextRun = &t
 			}
 		}
+		if pausedUntil != nil {
+			if t, err := time.Parse(time.RFC3339, *pausedUntil); err == nil {
+				e.PausedUntil = &t
+			}
+		}
 		if t, err := time.Parse(time.RFC3339, createdAt); err == nil {
 			e.CreatedAt = t
 		}
@@ -469,22 +1365,21 @@ func (s *SchedulerService) saveScheduleToDB(e models.ScheduleEntry) error {
 	if err != nil {
 		return err
 	}
-	_, err = db.Exec(`INSERT OR REPLACE INTO schedules (id, profile_name, action, cron_expr, enabled, last_run, next_run, last_result, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		e.Id, e.ProfileName, e.Action, e.CronExpr, boolToInt(e.Enabled),
+	_, err = db.Exec(`INSERT OR REPLACE INTO schedules (id, profile_name, action, cron_expr, timezone, target_type, target_id, overlap_policy, catch_up, tags, enabled, suspended_remote, blocking_processes, pause_for_processes, urgent, sample_percent, window_minutes, split_across_windows, last_run, next_run, last_result, paused_until, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.Id, e.ProfileName, e.Action, e.CronExpr, e.Timezone, scheduleTargetType(e), e.TargetId, scheduleOverlapPolicy(e), scheduleCatchUp(e),
+		marshalStringSlice(e.Tags), boolToInt(e.Enabled), e.SuspendedRemote,
+		marshalStringSlice(e.BlockingProcesses), boolToInt(e.PauseForProcesses), boolToInt(e.Urgent), e.SamplePercent,
+		e.WindowMinutes, boolToInt(e.SplitAcrossWindow),
 		timePtrToNullable(e.LastRun), timePtrToNullable(e.NextRun),
-		e.LastResult, e.CreatedAt.UTC().Format(time.RFC3339))
+		e.LastResult, timePtrToNullable(e.PausedUntil), e.CreatedAt.UTC().Format(time.RFC3339))
 	return err
 }
 
-// deleteScheduleFromDB removes a schedule from the database
-func (s *SchedulerService) deleteScheduleFromDB(id string) error {
-	db, err := GetSharedDB()
-	if err != nil {
-		return err
-	}
-	_, err = db.Exec("DELETE FROM schedules WHERE id = ?", id)
-	return err
+// deleteScheduleFromDB removes a schedule from the database and moves it to
+// the trash
+func (s *SchedulerService) deleteScheduleFromDB(entry models.ScheduleEntry) error {
+	return deleteWithTrash(models.TrashSchedule, entry.Id, entry.ProfileName, entry, "DELETE FROM schedules WHERE id = ?", entry.Id)
 }
 
 // emitScheduleEvent emits a schedule event
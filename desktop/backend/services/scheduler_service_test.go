@@ -15,10 +15,12 @@ func newTestSchedulerService(t *testing.T) *SchedulerService {
 	db, _ := GetSharedDB()
 	db.Exec("DELETE FROM schedules")
 	return &SchedulerService{
-		schedules:   []models.ScheduleEntry{},
-		cronEntries: make(map[string]cron.EntryID),
-		cron:        cron.New(),
-		initialized: true,
+		schedules:        []models.ScheduleEntry{},
+		cronEntries:      make(map[string]cron.EntryID),
+		queued:           make(map[string]bool),
+		cron:             cron.New(),
+		initialized:      true,
+		operationService: NewOperationService(nil),
 	}
 }
 
@@ -232,3 +234,411 @@ func TestSchedulerService_Persistence(t *testing.T) {
 		t.Error("expected to find 'persist-sched' after loading from DB")
 	}
 }
+
+func TestSchedulerService_AddSchedule_InvalidTarget(t *testing.T) {
+	s := newTestSchedulerService(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		entry models.ScheduleEntry
+	}{
+		{"unknown target type", models.ScheduleEntry{Id: "t1", CronExpr: "0 0 * * *", TargetType: "folder"}},
+		{"board without id", models.ScheduleEntry{Id: "t2", CronExpr: "0 0 * * *", TargetType: "board"}},
+		{"unknown overlap policy", models.ScheduleEntry{Id: "t3", CronExpr: "0 0 * * *", OverlapPolicy: "parallel"}},
+		{"verify without profile", models.ScheduleEntry{Id: "t4", CronExpr: "0 0 * * *", TargetType: "verify"}},
+		{"verify with sync action", models.ScheduleEntry{Id: "t5", CronExpr: "0 0 * * *", TargetType: "verify", ProfileName: "docs", Action: "push"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := s.AddSchedule(ctx, tt.entry); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestSchedulerService_TargetPersistence(t *testing.T) {
+	s := newTestSchedulerService(t)
+	s.cron.Start()
+	defer s.cron.Stop()
+	ctx := context.Background()
+
+	entry := models.ScheduleEntry{
+		Id:            "sched-board",
+		Action:        "push",
+		CronExpr:      "0 2 * * *",
+		TargetType:    "board",
+		TargetId:      "board-nightly",
+		OverlapPolicy: "queue",
+		Enabled:       true,
+		CreatedAt:     time.Now(),
+	}
+	if err := s.AddSchedule(ctx, entry); err != nil {
+		t.Fatalf("AddSchedule failed: %v", err)
+	}
+
+	loaded, err := s.loadSchedulesFromDB()
+	if err != nil {
+		t.Fatalf("loadSchedulesFromDB failed: %v", err)
+	}
+	if len(loaded) != 1 {
+		t.Fatalf("expected 1 schedule, got %d", len(loaded))
+	}
+	if loaded[0].TargetType != "board" || loaded[0].TargetId != "board-nightly" {
+		t.Errorf("expected board target 'board-nightly', got %s/%s", loaded[0].TargetType, loaded[0].TargetId)
+	}
+	if loaded[0].OverlapPolicy != "queue" {
+		t.Errorf("expected overlap policy 'queue', got %q", loaded[0].OverlapPolicy)
+	}
+}
+
+func TestSchedulerService_OverlapPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("skip", func(t *testing.T) {
+		s := newTestSchedulerService(t)
+		entry := models.ScheduleEntry{Id: "overlap-skip", ProfileName: "p", Action: "push", CronExpr: "0 0 * * *", CreatedAt: time.Now()}
+		if err := s.AddSchedule(ctx, entry); err != nil {
+			t.Fatalf("AddSchedule failed: %v", err)
+		}
+		_, finish := s.operationService.trackScheduleRun(ctx, "overlap-skip")
+		defer finish(nil)
+
+		s.triggerSchedule("overlap-skip")
+
+		schedules, _ := s.GetSchedules(ctx)
+		if schedules[0].LastResult != "skipped" {
+			t.Errorf("expected last result 'skipped', got %q", schedules[0].LastResult)
+		}
+	})
+
+	t.Run("queue", func(t *testing.T) {
+		s := newTestSchedulerService(t)
+		entry := models.ScheduleEntry{Id: "overlap-queue", ProfileName: "p", Action: "push", CronExpr: "0 0 * * *", OverlapPolicy: "queue", CreatedAt: time.Now()}
+		if err := s.AddSchedule(ctx, entry); err != nil {
+			t.Fatalf("AddSchedule failed: %v", err)
+		}
+		_, finish := s.operationService.trackScheduleRun(ctx, "overlap-queue")
+		defer finish(nil)
+		task := s.operationService.scheduleRunTask("overlap-queue")
+
+		s.triggerSchedule("overlap-queue")
+
+		if !s.queued["overlap-queue"] {
+			t.Error("expected trigger to be queued behind the active run")
+		}
+		if s.operationService.scheduleRunTask("overlap-queue") != task {
+			t.Error("expected active run to be left in place")
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		s := newTestSchedulerService(t)
+		entry := models.ScheduleEntry{Id: "overlap-cancel", ProfileName: "p", Action: "push", CronExpr: "0 0 * * *", OverlapPolicy: "cancel", CreatedAt: time.Now()}
+		if err := s.AddSchedule(ctx, entry); err != nil {
+			t.Fatalf("AddSchedule failed: %v", err)
+		}
+		runCtx, finish := s.operationService.trackScheduleRun(ctx, "overlap-cancel")
+		previous := s.operationService.scheduleRunTask("overlap-cancel")
+		go func() {
+			<-runCtx.Done()
+			finish(runCtx.Err())
+		}()
+
+		s.triggerSchedule("overlap-cancel")
+
+		if runCtx.Err() == nil {
+			t.Error("expected the previous run to be cancelled")
+		}
+		if task := s.operationService.scheduleRunTask("overlap-cancel"); task == nil || task == previous {
+			t.Error("expected a new run to start in place of the cancelled one")
+		}
+		s.cancelRuns(ctx)
+	})
+}
+
+func TestShiftCronExpr(t *testing.T) {
+	tests := []struct {
+		expr    string
+		minutes int
+		want    string
+		wantErr bool
+	}{
+		{"0 2 * * *", 30, "30 2 * * *", false},
+		{"45 2 * * *", 30, "15 3 * * *", false},
+		{"15 0 * * *", -30, "45 23 * * *", false},
+		{"0 1,13 * * *", 90, "30 2,14 * * *", false},
+		{"30 * * * *", 15, "45 * * * *", false},
+		{"10 0 2 * * *", 30, "10 30 2 * * *", false},  // seconds are kept
+		{"0 1,23 * * *", 120, "0 1,3 * * *", false},   // only some hours cross midnight, every day
+		{"30 23 * * 1", 60, "30 0 * * 2", false},      // crosses midnight into the next weekday
+		{"30 23 * * 6,7", 60, "30 0 * * 0,1", false},  // Saturday and Sunday move to Sunday and Monday
+		{"15 0 * * 0,6", -30, "45 23 * * 5,6", false}, // crosses midnight into the previous weekday
+		{"30 * * * *", 45, "", true},                  // carry into a wildcard hour
+		{"0,30 2 * * *", 45, "", true},                // minutes split across hours
+		{"0 1,23 * * 1", 120, "", true},               // hours split across days on a weekday schedule
+		{"30 23 1 * *", 60, "", true},                 // crosses midnight on a day of the month
+		{"15 0 * 1 *", -30, "", true},                 // crosses midnight out of a month
+		{"30 23 * * MON", 60, "", true},               // named weekday
+		{"*/5 * * * *", 10, "", true},                 // step minutes
+		{"0 2 * *", 10, "", true},                     // wrong field count
+	}
+	for _, tt := range tests {
+		got, err := shiftCronExpr(tt.expr, tt.minutes)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("shiftCronExpr(%q, %d) expected error, got %q", tt.expr, tt.minutes, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("shiftCronExpr(%q, %d) unexpected error: %v", tt.expr, tt.minutes, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("shiftCronExpr(%q, %d) = %q, want %q", tt.expr, tt.minutes, got, tt.want)
+		}
+	}
+}
+
+func TestSchedulerService_BulkByTag(t *testing.T) {
+	s := newTestSchedulerService(t)
+	s.cron.Start()
+	defer s.cron.Stop()
+	ctx := context.Background()
+
+	entries := []models.ScheduleEntry{
+		{Id: "tag-1", ProfileName: "a", Action: "push", CronExpr: "0 1 * * *", Tags: []string{"nightly"}},
+		{Id: "tag-2", ProfileName: "b", Action: "push", CronExpr: "0 2 * * *", Tags: []string{"nightly", "photos"}},
+		{Id: "tag-3", ProfileName: "c", Action: "push", CronExpr: "0 3 * * *", Tags: []string{"photos"}},
+	}
+	for _, e := range entries {
+		if err := s.AddSchedule(ctx, e); err != nil {
+			t.Fatalf("AddSchedule failed: %v", err)
+		}
+	}
+
+	n, err := s.EnableSchedulesByTag(ctx, "nightly")
+	if err != nil {
+		t.Fatalf("EnableSchedulesByTag failed: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 schedules enabled, got %d", n)
+	}
+	if len(s.cronEntries) != 2 {
+		t.Errorf("expected 2 cron entries, got %d", len(s.cronEntries))
+	}
+
+	n, err = s.DisableSchedulesByTag(ctx, "photos")
+	if err != nil {
+		t.Fatalf("DisableSchedulesByTag failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 schedule disabled, got %d", n)
+	}
+	if _, ok := s.cronEntries["tag-2"]; ok {
+		t.Error("expected tag-2 cron job to be removed")
+	}
+}
+
+func TestSchedulerService_ShiftSchedules(t *testing.T) {
+	s := newTestSchedulerService(t)
+	ctx := context.Background()
+
+	for _, e := range []models.ScheduleEntry{
+		{Id: "shift-1", ProfileName: "a", Action: "push", CronExpr: "0 2 * * *"},
+		{Id: "shift-2", ProfileName: "b", Action: "push", CronExpr: "*/5 * * * *"},
+	} {
+		if err := s.AddSchedule(ctx, e); err != nil {
+			t.Fatalf("AddSchedule failed: %v", err)
+		}
+	}
+
+	// One unshiftable schedule leaves all of them untouched
+	if _, err := s.ShiftSchedules(ctx, []string{"shift-1", "shift-2"}, 30); err == nil {
+		t.Error("expected error shifting a step expression")
+	}
+	schedules, _ := s.GetSchedules(ctx)
+	if schedules[0].CronExpr != "0 2 * * *" {
+		t.Errorf("expected schedule to be unchanged, got %q", schedules[0].CronExpr)
+	}
+
+	n, err := s.ShiftSchedules(ctx, []string{"shift-1"}, 30)
+	if err != nil {
+		t.Fatalf("ShiftSchedules failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 schedule shifted, got %d", n)
+	}
+	schedules, _ = s.GetSchedules(ctx)
+	if schedules[0].CronExpr != "30 2 * * *" {
+		t.Errorf("expected shifted cron '30 2 * * *', got %q", schedules[0].CronExpr)
+	}
+}
+
+func TestSchedulerService_PauseSchedules(t *testing.T) {
+	s := newTestSchedulerService(t)
+	ctx := context.Background()
+
+	entry := models.ScheduleEntry{Id: "paused", ProfileName: "p", Action: "push", CronExpr: "0 0 * * *", CreatedAt: time.Now()}
+	if err := s.AddSchedule(ctx, entry); err != nil {
+		t.Fatalf("AddSchedule failed: %v", err)
+	}
+
+	if _, err := s.PauseSchedules(ctx, time.Hour); err != nil {
+		t.Fatalf("PauseSchedules failed: %v", err)
+	}
+	if s.GetSchedulesPausedUntil(ctx) == nil {
+		t.Fatal("expected schedules to be paused")
+	}
+
+	s.triggerSchedule("paused")
+
+	schedules, _ := s.GetSchedules(ctx)
+	if schedules[0].LastResult != "skipped" {
+		t.Errorf("expected last result 'skipped' while paused, got %q", schedules[0].LastResult)
+	}
+	if task := s.activeRun("paused"); task != nil {
+		t.Error("expected no run to start while paused")
+	}
+
+	// The pause is kept with the schedules across restarts
+	restarted := &SchedulerService{
+		schedules:   []models.ScheduleEntry{},
+		cronEntries: make(map[string]cron.EntryID),
+		cron:        cron.New(),
+	}
+	if err := restarted.initialize(); err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+	restarted.cron.Stop()
+	if restarted.GetSchedulesPausedUntil(ctx) == nil {
+		t.Error("expected schedules to stay paused after a restart")
+	}
+
+	s.ResumeSchedules(ctx)
+	if s.GetSchedulesPausedUntil(ctx) != nil {
+		t.Error("expected schedules to be resumed")
+	}
+	loaded, err := s.loadSchedulesFromDB()
+	if err != nil || len(loaded) != 1 || loaded[0].PausedUntil != nil {
+		t.Errorf("resume not persisted: %+v, %v", loaded, err)
+	}
+}
+
+func TestSchedulerService_RetryDeferred(t *testing.T) {
+	s := newTestSchedulerService(t)
+	ctx := context.Background()
+
+	entry := models.ScheduleEntry{Id: "deferred", ProfileName: "p", Action: "push", CronExpr: "0 0 * * *", CreatedAt: time.Now()}
+	if err := s.AddSchedule(ctx, entry); err != nil {
+		t.Fatalf("AddSchedule failed: %v", err)
+	}
+	// Paused, so the retried trigger doesn't start a run
+	if _, err := s.PauseSchedules(ctx, time.Hour); err != nil {
+		t.Fatalf("PauseSchedules failed: %v", err)
+	}
+	defer s.ResumeSchedules(ctx)
+	s.deferred = map[string]bool{"deferred": true, "deleted": true}
+
+	s.retryDeferred()
+
+	if len(s.deferred) != 0 {
+		t.Errorf("expected the deferred schedules to be forgotten, got %v", s.deferred)
+	}
+	schedules, _ := s.GetSchedules(ctx)
+	if schedules[0].LastRun == nil || schedules[0].LastResult != "skipped" {
+		t.Errorf("expected the deferred schedule to be triggered again, got %+v", schedules[0])
+	}
+}
+
+func TestSchedulerService_MissedWhileLocked(t *testing.T) {
+	s := newTestSchedulerService(t)
+	s.schedules = []models.ScheduleEntry{
+		{Id: "hourly", CronExpr: "0 * * * *", Enabled: true},
+		{Id: "daily", CronExpr: "0 3 * * *", Enabled: true},
+		{Id: "disabled", CronExpr: "0 * * * *", Enabled: false},
+		{Id: "caught-up", CronExpr: "0 * * * *", Enabled: true, CatchUp: "once"},
+	}
+
+	since := time.Date(2024, 5, 1, 8, 30, 0, 0, time.Local)
+	now := since.Add(2 * time.Hour)
+	missed := s.missedScheduleIds(since, now)
+	if len(missed) != 1 || missed[0] != "hourly" {
+		t.Errorf("expected only the hourly schedule to be missed, got %v", missed)
+	}
+
+	if state := s.GetSchedulerState(context.Background()); state != SchedulerRunning {
+		t.Errorf("state = %s, want running", state)
+	}
+	s.initialized = false
+	if state := s.GetSchedulerState(context.Background()); state != SchedulerPending {
+		t.Errorf("state = %s, want pending before unlock", state)
+	}
+}
+
+func TestMissedFireTimes(t *testing.T) {
+	since := time.Date(2024, 5, 1, 8, 30, 0, 0, time.Local)
+	now := since.Add(5 * time.Hour)
+	hourly := models.ScheduleEntry{Id: "hourly", CronExpr: "0 * * * *", Enabled: true}
+
+	if n := missedFireTimes(hourly, since, now, maxCatchUpRuns); n != 5 {
+		t.Errorf("missed = %d, want 5", n)
+	}
+	if n := missedFireTimes(hourly, since, now, 1); n != 1 {
+		t.Errorf("missed with limit 1 = %d, want 1", n)
+	}
+
+	// Fire times before the last trigger were already run late
+	lastRun := since.Add(3*time.Hour + time.Minute)
+	hourly.LastRun = &lastRun
+	if n := missedFireTimes(hourly, since, now, maxCatchUpRuns); n != 1 {
+		t.Errorf("missed after a late run = %d, want 1", n)
+	}
+
+	s := newTestSchedulerService(t)
+	err := s.AddSchedule(context.Background(), models.ScheduleEntry{Id: "bad", ProfileName: "p", Action: "push", CronExpr: "0 * * * *", CatchUp: "twice"})
+	if err == nil {
+		t.Error("expected error for an invalid catch-up policy")
+	}
+}
+
+func TestSchedulerService_BlockingProcesses(t *testing.T) {
+	s := newTestSchedulerService(t)
+	ctx := context.Background()
+
+	running := map[string]bool{processKey("FinalCutPro.app"): true, processKey("Steam.exe"): true}
+	saved := runningProcessNames
+	runningProcessNames = func() (map[string]bool, error) { return running, nil }
+	defer func() { runningProcessNames = saved }()
+
+	entry := models.ScheduleEntry{
+		Id: "blocked", ProfileName: "p", Action: "push", CronExpr: "0 0 * * *",
+		BlockingProcesses: []string{"steam"}, PauseForProcesses: true, CreatedAt: time.Now(),
+	}
+	if err := s.AddSchedule(ctx, entry); err != nil {
+		t.Fatalf("AddSchedule failed: %v", err)
+	}
+
+	s.triggerSchedule("blocked")
+	schedules, _ := s.GetSchedules(ctx)
+	if schedules[0].LastResult != "skipped" {
+		t.Errorf("expected last result 'skipped' while steam runs, got %q", schedules[0].LastResult)
+	}
+	if task := s.activeRun("blocked"); task != nil {
+		t.Error("expected no run to start while steam runs")
+	}
+
+	loaded, err := s.loadSchedulesFromDB()
+	if err != nil || len(loaded) != 1 || len(loaded[0].BlockingProcesses) != 1 || !loaded[0].PauseForProcesses {
+		t.Errorf("blocking processes not persisted: %+v, %v", loaded, err)
+	}
+
+	invalid := entry
+	invalid.Id = "invalid"
+	invalid.BlockingProcesses = nil
+	if err := s.AddSchedule(ctx, invalid); err == nil {
+		t.Error("expected pausing without blocking processes to be rejected")
+	}
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/events"
+	"desktop/backend/models"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// runScheduledVerify verifies the schedule's profile without transferring
+// anything and alerts when the destination has corrupted or missing files.
+// The run fails when the verification finds problems or can't run.
+func (s *SchedulerService) runScheduledVerify(ctx context.Context, entry models.ScheduleEntry) error {
+	if s.operationService == nil || s.configService == nil {
+		return fmt.Errorf("operation service not available")
+	}
+	profile, err := s.findProfile(ctx, entry.ProfileName)
+	if err != nil {
+		return err
+	}
+
+	opts := models.VerifyOptions{Download: entry.Action == "download", SamplePercent: entry.SamplePercent}
+	if len(profile.FanOutTo) > 0 && opts.SamplePercent == 0 {
+		return s.runScheduledVerifyDestinations(ctx, entry, profile, opts)
+	}
+	report, err := s.operationService.verifyProfile(ctx, profile, opts, entry.Id)
+	if report != nil {
+		s.emitScheduleEvent(events.ScheduleVerified, entry.Id, report)
+		s.sendVerifyNotification(report)
+	}
+	if err != nil {
+		return err
+	}
+	if n := report.Problems(); n > 0 {
+		return fmt.Errorf("verification of %s found %d problems", profile.Name, n)
+	}
+	return nil
+}
+
+// runScheduledVerifyDestinations verifies all destinations of a fan-out profile in one
+// pass, emitting and alerting about each destination's report. The run
+// fails when some copy is out of date or the source can't be read.
+func (s *SchedulerService) runScheduledVerifyDestinations(ctx context.Context, entry models.ScheduleEntry, profile models.Profile, opts models.VerifyOptions) error {
+	result, err := s.operationService.verifyDestinations(ctx, profile, opts, entry.Id)
+	if result != nil {
+		for i := range result.Reports {
+			s.emitScheduleEvent(events.ScheduleVerified, entry.Id, &result.Reports[i])
+			s.sendVerifyNotification(&result.Reports[i])
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if len(result.OutOfDate) > 0 {
+		return fmt.Errorf("verification of %s found out of date copies: %s", profile.Name, strings.Join(result.OutOfDate, ", "))
+	}
+	return nil
+}
+
+// findProfile returns the profile with the given name
+func (s *SchedulerService) findProfile(ctx context.Context, name string) (models.Profile, error) {
+	profiles, err := s.configService.GetProfiles(ctx)
+	if err != nil {
+		return models.Profile{}, err
+	}
+	for _, p := range profiles {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return models.Profile{}, fmt.Errorf("profile '%s' not found", name)
+}
+
+// sendVerifyNotification alerts about a verification that found problems
+// or failed. Clean verifications stay quiet.
+func (s *SchedulerService) sendVerifyNotification(report *models.VerifyReport) {
+	if s.notificationService == nil || report.Status == "ok" {
+		return
+	}
+
+	var title, body string
+	severity := NotifySeverityError
+	switch {
+	case report.Status == "failed":
+		title = "Backup Verification Failed"
+		body = fmt.Sprintf("Profile \"%s\" could not be verified: %s", report.ProfileName, report.ErrorMessage)
+		severity = NotifySeverityWarning
+	case report.DifferCount > 0:
+		title = "Backup Corruption Detected"
+		body = fmt.Sprintf("Profile \"%s\": %d files differ from the source, %d are missing and %d could not be read.",
+			report.ProfileName, report.DifferCount, report.MissingCount, report.ErrorCount)
+	default:
+		title = "Backup Files Missing"
+		body = fmt.Sprintf("Profile \"%s\": %d files are missing from the destination and %d could not be read.",
+			report.ProfileName, report.MissingCount, report.ErrorCount)
+	}
+
+	if report.Sample != nil && report.Status != "failed" {
+		body += fmt.Sprintf(" Only %g%% of the files were checked.", report.Sample.Percent)
+	}
+
+	if err := s.notificationService.SendCategoryNotification(context.Background(), NotifyCategoryVerify, severity, title, body); err != nil {
+		log.Printf("Failed to send verify notification: %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"log"
+	"time"
+)
+
+// schedulerHeartbeatInterval is how often the scheduler records that it is
+// running, and looks for a gap showing the machine slept
+const schedulerHeartbeatInterval = time.Minute
+
+// schedulerSleepGap is how late a heartbeat must come for the machine to be
+// taken to have slept in between
+const schedulerSleepGap = 3 * time.Minute
+
+// maxCatchUpRuns caps the missed runs a schedule with the "all" catch-up
+// policy makes up for at once
+const maxCatchUpRuns = 24
+
+// Scheduler states reported by GetSchedulerState
+const (
+	SchedulerPending = "pending" // waiting for unlock; nothing runs until then
+	SchedulerRunning = "running"
+	SchedulerPaused  = "paused" // see PauseSchedules
+)
+
+// GetSchedulerState reports whether schedules are waiting for unlock, paused or running
+func (s *SchedulerService) GetSchedulerState(ctx context.Context) string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	switch {
+	case !s.initialized:
+		return SchedulerPending
+	case time.Now().Before(s.pausedUntil):
+		return SchedulerPaused
+	default:
+		return SchedulerRunning
+	}
+}
+
+// wakeAfterUnlock starts the schedules once the database is unlocked. With
+// runMissed, schedules with the "skip" catch-up policy that would have fired
+// while the app waited for unlock run once right away; the others catch up
+// by their policy as the scheduler starts. Returns how many were caught up.
+func (s *SchedulerService) wakeAfterUnlock(runMissed bool) int {
+	if err := s.initialize(); err != nil {
+		log.Printf("SchedulerService: could not start after unlock: %v", err)
+		return 0
+	}
+
+	s.mutex.Lock()
+	since := s.pendingSince
+	s.pendingSince = time.Time{}
+	s.mutex.Unlock()
+
+	if !runMissed || since.IsZero() {
+		return 0
+	}
+	missed := s.missedScheduleIds(since, time.Now())
+	for _, id := range missed {
+		log.Printf("Schedule '%s' missed while locked, running now", id)
+		s.triggerSchedule(id)
+	}
+	return len(missed)
+}
+
+// missedScheduleIds returns the active schedules with the "skip" catch-up
+// policy and a fire time in [since, now)
+func (s *SchedulerService) missedScheduleIds(since, now time.Time) []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var missed []string
+	for _, entry := range s.schedules {
+		if !isScheduleActive(entry) || scheduleCatchUp(entry) != "skip" {
+			continue
+		}
+		sched, err := parseScheduleCron(entry)
+		if err != nil {
+			continue
+		}
+		// Next returns the first fire time strictly after its argument
+		if next := sched.Next(since.Add(-time.Second)); next.Before(now) {
+			missed = append(missed, entry.Id)
+		}
+	}
+	return missed
+}
+
+// missedFireTimes counts the fire times of a schedule in (since, now), up to
+// limit. Fire times before its last trigger don't count, so a run the cron
+// timer made up for late isn't repeated.
+func missedFireTimes(entry models.ScheduleEntry, since, now time.Time, limit int) int {
+	sched, err := parseScheduleCron(entry)
+	if err != nil {
+		return 0
+	}
+	if entry.LastRun != nil && entry.LastRun.After(since) {
+		since = *entry.LastRun
+	}
+	n := 0
+	for t := sched.Next(since); !t.IsZero() && t.Before(now) && n < limit; t = sched.Next(t) {
+		n++
+	}
+	return n
+}
+
+// catchUpMissed runs the active schedules that should have fired in
+// (since, now) by their catch-up policy: "once" runs once, "all" once per
+// missed fire time (at most maxCatchUpRuns), one after another, and "skip"
+// waits for the next fire time. Returns how many schedules catch up.
+func (s *SchedulerService) catchUpMissed(since, now time.Time, reason string) int {
+	type catchUp struct {
+		id   string
+		runs int
+	}
+	var due []catchUp
+	s.mutex.RLock()
+	for _, entry := range s.schedules {
+		if !isScheduleActive(entry) {
+			continue
+		}
+		policy := scheduleCatchUp(entry)
+		limit := 1
+		if policy == "all" {
+			limit = maxCatchUpRuns
+		}
+		n := missedFireTimes(entry, since, now, limit)
+		if n == 0 {
+			continue
+		}
+		if policy == "skip" {
+			log.Printf("Schedule '%s' missed its run %s, waiting for the next one", entry.Id, reason)
+			continue
+		}
+		due = append(due, catchUp{entry.Id, n})
+	}
+	s.mutex.RUnlock()
+
+	for _, c := range due {
+		log.Printf("Schedule '%s' missed %d run(s) %s, catching up", c.id, c.runs, reason)
+		go s.runMissed(c.id, c.runs)
+	}
+	return len(due)
+}
+
+// runMissed triggers a schedule runs times, each once the previous run
+// finished
+func (s *SchedulerService) runMissed(scheduleId string, runs int) {
+	for range runs {
+		s.triggerSchedule(scheduleId)
+		s.mutex.RLock()
+		stopped := s.stopped
+		s.mutex.RUnlock()
+		if stopped {
+			return
+		}
+		if task := s.activeRun(scheduleId); task != nil {
+			<-task.Done
+		}
+	}
+}
+
+// watchHeartbeat records that the scheduler is running every
+// schedulerHeartbeatInterval until it stops, so schedules missed while the
+// app was closed catch up at the next start. A heartbeat much later than due
+// means the machine slept in between.
+func (s *SchedulerService) watchHeartbeat(last time.Time) {
+	ticker := time.NewTicker(schedulerHeartbeatInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.mutex.RLock()
+		stopped := s.stopped
+		s.mutex.RUnlock()
+		if stopped {
+			return
+		}
+		// Wall clock: the monotonic clock stops while some systems sleep
+		now := time.Now().Round(0)
+		if now.Sub(last) > schedulerSleepGap {
+			s.wakeFromSleep(last, now)
+		}
+		saveSchedulerHeartbeat(now)
+		last = now
+	}
+}
+
+// wakeFromSleep restarts the cron timers, which don't count the time the
+// machine slept, and catches up schedules missed in between
+func (s *SchedulerService) wakeFromSleep(since, now time.Time) {
+	log.Printf("SchedulerService: system woke after %s", now.Sub(since).Round(time.Second))
+	s.mutex.Lock()
+	if s.stopped {
+		s.mutex.Unlock()
+		return
+	}
+	s.cron.Stop()
+	s.cron.Start()
+	s.mutex.Unlock()
+	s.catchUpMissed(since, now, "while the system slept")
+}
+
+// loadSchedulerHeartbeat returns when the scheduler last recorded it was
+// running, zero if never
+func loadSchedulerHeartbeat() time.Time {
+	db, err := GetSharedDB()
+	if err != nil {
+		return time.Time{}
+	}
+	var aliveAt string
+	if err := db.QueryRow("SELECT alive_at FROM scheduler_heartbeat WHERE id = 1").Scan(&aliveAt); err != nil {
+		return time.Time{}
+	}
+	t, _ := time.Parse(time.RFC3339, aliveAt)
+	return t
+}
+
+// saveSchedulerHeartbeat records that the scheduler was running at t
+func saveSchedulerHeartbeat(t time.Time) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return
+	}
+	if _, err := db.Exec("INSERT OR REPLACE INTO scheduler_heartbeat (id, alive_at) VALUES (1, ?)", t.UTC().Format(time.RFC3339)); err != nil {
+		log.Printf("Warning: Could not record the scheduler heartbeat: %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"desktop/backend/models"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// SecretService manages the secrets vault: named values such as API tokens
+// that flow steps use without storing them in the flow. Secrets live in the
+// shared database, which is encrypted at rest when a master password is set.
+type SecretService struct {
+	app *application.App
+}
+
+// NewSecretService creates a new secret service
+func NewSecretService(app *application.App) *SecretService {
+	return &SecretService{
+		app: app,
+	}
+}
+
+// SetApp sets the application reference
+func (s *SecretService) SetApp(app *application.App) {
+	s.app = app
+}
+
+// ServiceName returns the name of the service
+func (s *SecretService) ServiceName() string {
+	return "SecretService"
+}
+
+// GetSecrets returns the names of all secrets in the vault, ordered by name
+func (s *SecretService) GetSecrets(ctx context.Context) ([]models.SecretInfo, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query("SELECT name, updated_at FROM secrets ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query secrets: %w", err)
+	}
+	defer rows.Close()
+
+	secrets := []models.SecretInfo{}
+	for rows.Next() {
+		var info models.SecretInfo
+		var updatedAt string
+		if err := rows.Scan(&info.Name, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan secret: %w", err)
+		}
+		if t, err := time.Parse(time.RFC3339, updatedAt); err == nil {
+			info.UpdatedAt = t
+		}
+		secrets = append(secrets, info)
+	}
+	return secrets, rows.Err()
+}
+
+// SetSecret creates or replaces a secret
+func (s *SecretService) SetSecret(ctx context.Context, name, value string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("secret name is required")
+	}
+	if value == "" {
+		return fmt.Errorf("secret '%s' needs a value", name)
+	}
+
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec("INSERT OR REPLACE INTO secrets (name, value, updated_at) VALUES (?, ?, ?)",
+		name, value, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("failed to save secret: %w", err)
+	}
+	log.Printf("[SecretService] Secret '%s' saved", name)
+	return nil
+}
+
+// DeleteSecret removes a secret from the vault
+func (s *SecretService) DeleteSecret(ctx context.Context, name string) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	result, err := db.Exec("DELETE FROM secrets WHERE name = ?", name)
+	if err != nil {
+		return fmt.Errorf("failed to delete secret: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("secret '%s' not found", name)
+	}
+	return nil
+}
+
+// lookupSecret returns the value of a vault secret
+func lookupSecret(name string) (string, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return "", err
+	}
+	var value string
+	err = db.QueryRow("SELECT value FROM secrets WHERE name = ?", name).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("secret '%s' not found", name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret '%s': %w", name, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,859 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/events"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"desktop/backend/utils"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/emersion/go-autostart"
+	"github.com/rclone/rclone/fs"
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// AppSettings holds persisted application settings
+type AppSettings struct {
+	NotificationsEnabled    bool `json:"notifications_enabled"`
+	DebugMode               bool `json:"debug_mode"`
+	MinimizeToTray          bool `json:"minimize_to_tray"`
+	StartAtLogin            bool `json:"start_at_login"`
+	MinimizeToTrayOnStartup bool `json:"minimize_to_tray_on_startup"`
+	TrayOnly                bool `json:"tray_only"`             // start without creating the main window until the tray opens it
+	StartLockedHidden       bool `json:"start_locked_hidden"`   // start at login in the tray, locked; schedules wait for unlock, then catch up
+	MaxConcurrentTasks      int  `json:"max_concurrent_tasks"`  // sync tasks allowed to run at once; 0 = unlimited
+	ShutdownGracePeriod     int  `json:"shutdown_grace_period"` // seconds running syncs may finish in when the app exits before they are cancelled
+	APIBudgetThreshold      int  `json:"api_budget_threshold"`  // percent of a provider's daily API quota from which scheduled runs using it are deferred; 0 = never
+
+	MinFreeDiskSpace string `json:"min_free_disk_space"` // syncs writing to this computer stop below this much free space, e.g. "1G"; "0" = off
+
+	PresentationMode    string `json:"presentation_mode"`     // what scheduled syncs do while presenting or in Do Not Disturb: "off", "defer" or "throttle"
+	PresentationBwLimit string `json:"presentation_bw_limit"` // bandwidth cap of the "throttle" presentation mode, e.g. "512k"
+
+	APIDailyQuotas string `json:"api_daily_quotas"` // daily API call quotas replacing or adding to the known ones, e.g. "drive=20000,dropbox=5000"
+
+	CacheLocations map[string]models.CacheLocationSetting `json:"cache_locations,omitempty"` // location id -> directory and size cap of temp, cache and staging files
+
+	Network models.NetworkSettings `json:"network"` // app-wide proxy, CA bundle, TLS verification, bind address and DNS
+
+	ChangeFeedPath string `json:"change_feed_path"` // JSON Lines file the changes delta watchers detect are appended to; "" = off
+}
+
+// preUnlock returns the settings that may be stored unencrypted in auth.json
+// for use before unlock. Network settings may hold proxy credentials, so they
+// stay in the encrypted database.
+func (s AppSettings) preUnlock() AppSettings {
+	s.Network = models.NetworkSettings{}
+	return s
+}
+
+// clone returns a copy that shares no maps with s
+func (s AppSettings) clone() AppSettings {
+	if s.CacheLocations != nil {
+		locations := make(map[string]models.CacheLocationSetting, len(s.CacheLocations))
+		for id, setting := range s.CacheLocations {
+			locations[id] = setting
+		}
+		s.CacheLocations = locations
+	}
+	return s
+}
+
+// defaultAppSettings returns the settings used before any are saved
+func defaultAppSettings() AppSettings {
+	return AppSettings{
+		NotificationsEnabled: true,
+		DebugMode:            false,
+		MinFreeDiskSpace:     defaultMinFreeDiskSpace,
+		PresentationMode:     PresentationOff,
+		PresentationBwLimit:  defaultPresentationBwLimit,
+		ShutdownGracePeriod:  defaultShutdownGracePeriod,
+		APIBudgetThreshold:   defaultAPIBudgetThreshold,
+	}
+}
+
+// defaultMinFreeDiskSpace is the free space threshold of the local disk guard
+const defaultMinFreeDiskSpace = "1G"
+
+// defaultShutdownGracePeriod is how many seconds running syncs get to finish when the app exits
+const defaultShutdownGracePeriod = 30
+
+// defaultAPIBudgetThreshold is the percent of a daily API quota from which scheduled runs are deferred
+const defaultAPIBudgetThreshold = 90
+
+// Presentation modes: what syncs do while the user presents, runs a
+// full-screen app or has Do Not Disturb on
+const (
+	PresentationOff      = "off"
+	PresentationDefer    = "defer"    // scheduled and background syncs wait until the presentation ends
+	PresentationThrottle = "throttle" // all transfers are capped at PresentationBwLimit
+)
+
+// defaultPresentationBwLimit is the bandwidth cap of the throttle presentation mode
+const defaultPresentationBwLimit = "512k"
+
+// ErrSettingsConflict is returned by UpdateSettings when the settings were
+// changed after the caller read them
+var ErrSettingsConflict = errors.New("settings were changed elsewhere")
+
+// SettingsSnapshot is the settings at a revision. The revision goes up with
+// every change; UpdateSettings takes it back to detect lost updates.
+type SettingsSnapshot struct {
+	Settings AppSettings `json:"settings"`
+	Revision int64       `json:"revision"`
+}
+
+// SettingsChange describes a settings change delivered to subscribers
+type SettingsChange struct {
+	Old      AppSettings
+	New      AppSettings
+	Keys     []string // settings table keys that changed, e.g. "max_concurrent_tasks"
+	Revision int64
+	Loaded   bool // the settings were loaded from the database rather than set
+}
+
+// Changed reports whether any of the given settings keys changed
+func (c SettingsChange) Changed(keys ...string) bool {
+	for _, key := range keys {
+		if slices.Contains(c.Keys, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// SettingsService owns the app settings: it loads and saves them, and tells
+// subscribers (the sync service, the scheduler, the tray, auth.json, the
+// frontend) when they change
+type SettingsService struct {
+	app      *application.App
+	eventBus *events.WailsEventBus
+	mutex    sync.RWMutex
+	settings AppSettings
+	revision int64
+
+	subscribers    map[int]func(SettingsChange)
+	nextSubscriber int
+
+	// Tells whether syncs are running, which cache clearing and trimming wait for
+	syncService *SyncService
+
+	// Held while subscribers run, so they see changes one at a time, in order
+	notifyMutex sync.Mutex
+}
+
+// NewSettingsService creates a new settings service with default settings
+func NewSettingsService(app *application.App) *SettingsService {
+	return &SettingsService{
+		app:         app,
+		settings:    defaultAppSettings(),
+		subscribers: make(map[int]func(SettingsChange)),
+	}
+}
+
+// SetApp sets the application reference
+func (s *SettingsService) SetApp(app *application.App) {
+	s.app = app
+	if bus := GetSharedEventBus(); bus != nil {
+		s.eventBus = bus
+	} else {
+		s.eventBus = events.NewEventBus(app)
+	}
+}
+
+// SetSyncService sets the sync service whose runs use the cache locations
+func (s *SettingsService) SetSyncService(syncService *SyncService) {
+	s.syncService = syncService
+}
+
+// ServiceName returns the name of the service
+func (s *SettingsService) ServiceName() string {
+	return "SettingsService"
+}
+
+// ServiceStartup is called when the service starts.
+// Note: LoadSettings() is NOT called here because when auth is enabled,
+// the DB is encrypted and not available yet. AuthService.initializeApp()
+// will call LoadSettings() after decryption.
+func (s *SettingsService) ServiceStartup(ctx context.Context, options application.ServiceOptions) error {
+	log.Printf("SettingsService starting up...")
+	return nil
+}
+
+// ServiceShutdown is called when the service shuts down
+func (s *SettingsService) ServiceShutdown(ctx context.Context) error {
+	log.Printf("SettingsService shutting down...")
+	return nil
+}
+
+// Subscribe calls fn after every settings change until the returned func is
+// called. Subscribers run one at a time, in revision order, on the goroutine
+// that made the change; they must not change settings themselves except from
+// another goroutine.
+func (s *SettingsService) Subscribe(fn func(SettingsChange)) (unsubscribe func()) {
+	s.mutex.Lock()
+	id := s.nextSubscriber
+	s.nextSubscriber++
+	s.subscribers[id] = fn
+	s.mutex.Unlock()
+
+	return func() {
+		s.mutex.Lock()
+		delete(s.subscribers, id)
+		s.mutex.Unlock()
+	}
+}
+
+// GetSettings returns all current app settings
+func (s *SettingsService) GetSettings(ctx context.Context) AppSettings {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.settings.clone()
+}
+
+// GetSettingsSnapshot returns the current settings with their revision, to
+// edit and pass back to UpdateSettings
+func (s *SettingsService) GetSettingsSnapshot(ctx context.Context) SettingsSnapshot {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return SettingsSnapshot{Settings: s.settings.clone(), Revision: s.revision}
+}
+
+// UpdateSettings replaces all settings, if they are still at revision.
+// Otherwise it returns ErrSettingsConflict and changes nothing, so an editor
+// working on stale settings can't undo a change made meanwhile.
+func (s *SettingsService) UpdateSettings(ctx context.Context, settings AppSettings, revision int64) (SettingsSnapshot, error) {
+	change, err := s.update(func(current int64, next *AppSettings) error {
+		if revision != current {
+			return fmt.Errorf("%w: revision %d is not the current revision %d", ErrSettingsConflict, revision, current)
+		}
+		*next = settings.clone()
+		return nil
+	})
+	if err != nil {
+		return SettingsSnapshot{}, err
+	}
+	return SettingsSnapshot{Settings: change.New, Revision: change.Revision}, nil
+}
+
+// set changes settings with apply, whatever their revision
+func (s *SettingsService) set(apply func(next *AppSettings)) error {
+	_, err := s.update(func(_ int64, next *AppSettings) error {
+		apply(next)
+		return nil
+	})
+	return err
+}
+
+// update applies a change to a copy of the settings, validates it, makes the
+// OS and rclone follow it, saves the changed keys and notifies subscribers.
+// The returned change has no keys when nothing changed.
+func (s *SettingsService) update(apply func(revision int64, next *AppSettings) error) (SettingsChange, error) {
+	s.mutex.Lock()
+	old := s.settings
+	next := old.clone()
+	if err := apply(s.revision, &next); err != nil {
+		s.mutex.Unlock()
+		return SettingsChange{}, err
+	}
+
+	keys := changedSettingKeys(old, next)
+	if len(keys) == 0 {
+		defer s.mutex.Unlock()
+		return SettingsChange{Old: old, New: next, Revision: s.revision}, nil
+	}
+	if err := validateAppSettings(next, keys); err != nil {
+		s.mutex.Unlock()
+		return SettingsChange{}, err
+	}
+	if err := applyExternalSettings(old, next); err != nil {
+		s.mutex.Unlock()
+		return SettingsChange{}, err
+	}
+
+	s.settings = next
+	s.revision++
+	saveSettings(next, keys)
+	change := SettingsChange{Old: old.clone(), New: next.clone(), Keys: keys, Revision: s.revision}
+	s.notifyAndUnlock(change)
+	return change, nil
+}
+
+// notifyAndUnlock releases s.mutex and delivers change to the subscribers.
+// Taking notifyMutex first keeps deliveries in revision order.
+func (s *SettingsService) notifyAndUnlock(change SettingsChange) {
+	s.notifyMutex.Lock()
+	defer s.notifyMutex.Unlock()
+	subscribers := make([]func(SettingsChange), 0, len(s.subscribers))
+	for id := 0; id < s.nextSubscriber; id++ {
+		if fn, ok := s.subscribers[id]; ok {
+			subscribers = append(subscribers, fn)
+		}
+	}
+	s.mutex.Unlock()
+
+	for _, fn := range subscribers {
+		fn(change)
+	}
+	s.emitSettingsEvent(change)
+}
+
+// validateAppSettings checks the changed settings that have a fixed set of
+// values or a syntax. Unchanged ones are left alone, so a bad stored value
+// doesn't block changing other settings.
+func validateAppSettings(settings AppSettings, keys []string) error {
+	changed := SettingsChange{Keys: keys}.Changed
+	if changed("max_concurrent_tasks") && settings.MaxConcurrentTasks < 0 {
+		return fmt.Errorf("max concurrent tasks cannot be negative")
+	}
+	if changed("shutdown_grace_period") && settings.ShutdownGracePeriod < 0 {
+		return fmt.Errorf("shutdown grace period cannot be negative")
+	}
+	if changed("api_budget_threshold") && (settings.APIBudgetThreshold < 0 || settings.APIBudgetThreshold > 100) {
+		return fmt.Errorf("API budget threshold must be between 0 and 100")
+	}
+	if changed("api_daily_quotas") {
+		if _, err := parseAPIQuotas(settings.APIDailyQuotas); err != nil {
+			return err
+		}
+	}
+	if changed("min_free_disk_space") {
+		var size fs.SizeSuffix
+		if err := size.Set(settings.MinFreeDiskSpace); err != nil || size < 0 {
+			return fmt.Errorf("invalid free disk space threshold %q", settings.MinFreeDiskSpace)
+		}
+	}
+	if changed("presentation_mode") {
+		switch settings.PresentationMode {
+		case PresentationOff, PresentationDefer, PresentationThrottle:
+		default:
+			return fmt.Errorf("invalid presentation mode %q", settings.PresentationMode)
+		}
+	}
+	if changed("presentation_bw_limit") {
+		var bw fs.BwPair
+		if err := bw.Set(settings.PresentationBwLimit); err != nil || !bw.IsSet() {
+			return fmt.Errorf("invalid presentation bandwidth limit %q", settings.PresentationBwLimit)
+		}
+	}
+	if changed("change_feed_path") && settings.ChangeFeedPath != "" {
+		if err := validateChangeFeedPath(settings.ChangeFeedPath); err != nil {
+			return err
+		}
+	}
+	for id, setting := range settings.CacheLocations {
+		if !isCacheDirLocation(id) {
+			return fmt.Errorf("cache location '%s' cannot be configured", id)
+		}
+		if setting.MaxSize != "" {
+			if _, err := parseCacheSize(setting.MaxSize); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// applyExternalSettings makes rclone and the OS follow the settings that take
+// effect outside the app: network settings, cache directories and start at
+// login. On failure the ones already applied are put back.
+func applyExternalSettings(old, next AppSettings) error {
+	networkChanged := next.Network != old.Network
+	if networkChanged {
+		if err := rclone.ApplyNetworkSettings(next.Network); err != nil {
+			return err
+		}
+	}
+	cacheChanged := !reflect.DeepEqual(next.CacheLocations, old.CacheLocations)
+	if cacheChanged {
+		if err := applyCacheLocations(next.CacheLocations); err != nil {
+			if networkChanged {
+				rclone.ApplyNetworkSettings(old.Network)
+			}
+			return err
+		}
+	}
+	if next.StartAtLogin != old.StartAtLogin {
+		if err := setStartAtLogin(next.StartAtLogin); err != nil {
+			if networkChanged {
+				rclone.ApplyNetworkSettings(old.Network)
+			}
+			if cacheChanged {
+				applyCacheLocations(old.CacheLocations)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// setStartAtLogin adds or removes the app's login item
+func setStartAtLogin(enabled bool) error {
+	// Get executable path
+	execPath, err := os.Executable()
+	if err != nil {
+		log.Printf("Failed to get executable path: %v", err)
+		return err
+	}
+
+	app := &autostart.App{
+		Name:        "GN Drive",
+		DisplayName: "GN Drive",
+		Exec:        append([]string{execPath}, utils.ConfigDirArgs()...),
+	}
+
+	if enabled {
+		if err := app.Enable(); err != nil {
+			log.Printf("Failed to enable start at login: %v", err)
+			return err
+		}
+	} else {
+		if err := app.Disable(); err != nil {
+			log.Printf("Failed to disable start at login: %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// settingField maps a settings table key to an AppSettings field
+type settingField struct {
+	key string
+	get func(AppSettings) string
+	set func(*AppSettings, string)
+}
+
+func boolSetting(key string, field func(*AppSettings) *bool) settingField {
+	return settingField{key,
+		func(s AppSettings) string { return boolToStr(*field(&s)) },
+		func(s *AppSettings, value string) { *field(s) = value == "true" }}
+}
+
+func intSetting(key string, field func(*AppSettings) *int) settingField {
+	return settingField{key,
+		func(s AppSettings) string { return strconv.Itoa(*field(&s)) },
+		func(s *AppSettings, value string) { *field(s), _ = strconv.Atoi(value) }}
+}
+
+func stringSetting(key string, field func(*AppSettings) *string) settingField {
+	return settingField{key,
+		func(s AppSettings) string { return *field(&s) },
+		func(s *AppSettings, value string) { *field(s) = value }}
+}
+
+// settingFields are the settings table keys of the AppSettings fields. Cache
+// locations are stored under "cache_<id>_path" and "cache_<id>_max_size".
+var settingFields = []settingField{
+	boolSetting("notifications_enabled", func(s *AppSettings) *bool { return &s.NotificationsEnabled }),
+	boolSetting("debug_mode", func(s *AppSettings) *bool { return &s.DebugMode }),
+	boolSetting("minimize_to_tray", func(s *AppSettings) *bool { return &s.MinimizeToTray }),
+	boolSetting("start_at_login", func(s *AppSettings) *bool { return &s.StartAtLogin }),
+	boolSetting("minimize_to_tray_on_startup", func(s *AppSettings) *bool { return &s.MinimizeToTrayOnStartup }),
+	boolSetting("tray_only", func(s *AppSettings) *bool { return &s.TrayOnly }),
+	boolSetting("start_locked_hidden", func(s *AppSettings) *bool { return &s.StartLockedHidden }),
+	intSetting("max_concurrent_tasks", func(s *AppSettings) *int { return &s.MaxConcurrentTasks }),
+	intSetting("shutdown_grace_period", func(s *AppSettings) *int { return &s.ShutdownGracePeriod }),
+	intSetting("api_budget_threshold", func(s *AppSettings) *int { return &s.APIBudgetThreshold }),
+	stringSetting("api_daily_quotas", func(s *AppSettings) *string { return &s.APIDailyQuotas }),
+	stringSetting("min_free_disk_space", func(s *AppSettings) *string { return &s.MinFreeDiskSpace }),
+	stringSetting("presentation_mode", func(s *AppSettings) *string { return &s.PresentationMode }),
+	stringSetting("presentation_bw_limit", func(s *AppSettings) *string { return &s.PresentationBwLimit }),
+	stringSetting("change_feed_path", func(s *AppSettings) *string { return &s.ChangeFeedPath }),
+	stringSetting("network_proxy", func(s *AppSettings) *string { return &s.Network.Proxy }),
+	stringSetting("network_ca_cert_file", func(s *AppSettings) *string { return &s.Network.CACertFile }),
+	boolSetting("network_insecure_skip_verify", func(s *AppSettings) *bool { return &s.Network.InsecureSkipVerify }),
+	stringSetting("network_bind_address", func(s *AppSettings) *string { return &s.Network.BindAddress }),
+	stringSetting("network_ip_family", func(s *AppSettings) *string { return &s.Network.IPFamily }),
+	stringSetting("network_dns_server", func(s *AppSettings) *string { return &s.Network.DNSServer }),
+}
+
+// settingValues returns the settings as settings table rows
+func settingValues(settings AppSettings) map[string]string {
+	values := make(map[string]string, len(settingFields)+2*len(cacheDirLocations))
+	for _, field := range settingFields {
+		values[field.key] = field.get(settings)
+	}
+	for _, id := range cacheDirLocations {
+		values["cache_"+id+"_path"] = settings.CacheLocations[id].Path
+		values["cache_"+id+"_max_size"] = settings.CacheLocations[id].MaxSize
+	}
+	return values
+}
+
+// changedSettingKeys returns the settings table keys whose values differ, sorted
+func changedSettingKeys(old, next AppSettings) []string {
+	oldValues := settingValues(old)
+	var keys []string
+	for key, value := range settingValues(next) {
+		if oldValues[key] != value {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// setSettingValue sets the field stored under a settings table key. Unknown keys are ignored.
+func setSettingValue(settings *AppSettings, key, value string) {
+	for _, field := range settingFields {
+		if field.key == key {
+			field.set(settings, value)
+			return
+		}
+	}
+	if id, field, ok := cacheSettingKey(key); ok {
+		if settings.CacheLocations == nil {
+			settings.CacheLocations = make(map[string]models.CacheLocationSetting)
+		}
+		setting := settings.CacheLocations[id]
+		switch field {
+		case "path":
+			setting.Path = value
+		case "max_size":
+			setting.MaxSize = value
+		}
+		settings.CacheLocations[id] = setting
+	}
+}
+
+// saveSettings writes the given keys of settings to the database
+func saveSettings(settings AppSettings, keys []string) {
+	db, err := GetSharedDB()
+	if err != nil {
+		log.Printf("Warning: Could not get database for saving settings: %v", err)
+		return
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("Warning: Could not save settings: %v", err)
+		return
+	}
+	defer tx.Rollback()
+
+	values := settingValues(settings)
+	for _, key := range keys {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO settings (key, value) VALUES (?, ?)", key, values[key]); err != nil {
+			log.Printf("Warning: Could not save setting %s: %v", key, err)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Warning: Could not save settings: %v", err)
+	}
+}
+
+// LoadSettings loads settings from the database and applies the network and
+// cache settings to rclone. Called by AuthService once the database is open.
+func (s *SettingsService) LoadSettings() {
+	db, err := GetSharedDB()
+	if err != nil {
+		log.Printf("Warning: Could not get database for settings: %v", err)
+		return
+	}
+
+	rows, err := db.Query("SELECT key, value FROM settings")
+	if err != nil {
+		log.Printf("Warning: Could not load settings: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	s.mutex.Lock()
+	old := s.settings
+	next := old.clone()
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			continue
+		}
+		setSettingValue(&next, key, value)
+	}
+
+	// Settings that no longer validate (e.g. a CA bundle that went missing)
+	// are logged and skipped, since rclone would exit on them
+	if next.Network != (models.NetworkSettings{}) {
+		if err := rclone.ApplyNetworkSettings(next.Network); err != nil {
+			log.Printf("Warning: Could not apply network settings: %v", err)
+		}
+	}
+	// Fall back to the default cache directories if the loaded ones can't be used
+	if err := applyCacheLocations(next.CacheLocations); err != nil {
+		log.Printf("Warning: Could not apply cache locations, using defaults: %v", err)
+		if err := applyCacheLocations(nil); err != nil {
+			log.Printf("Warning: Could not create default cache locations: %v", err)
+		}
+	}
+
+	s.settings = next
+	keys := changedSettingKeys(old, next)
+	if len(keys) == 0 {
+		s.mutex.Unlock()
+		return
+	}
+	s.revision++
+	s.notifyAndUnlock(SettingsChange{Old: old.clone(), New: next.clone(), Keys: keys, Revision: s.revision, Loaded: true})
+}
+
+// emitSettingsEvent tells the frontend which settings changed
+func (s *SettingsService) emitSettingsEvent(change SettingsChange) {
+	event := events.NewSettingsEvent(events.SettingsChanged, change.Keys, change.Revision, change.New)
+	if s.eventBus != nil {
+		if err := s.eventBus.EmitSettingsEvent(event); err != nil {
+			log.Printf("Failed to emit settings event: %v", err)
+		}
+	} else if s.app != nil {
+		s.app.Event.Emit("tofe", event)
+	}
+}
+
+// SetNotificationsEnabled enables or disables notifications
+func (s *SettingsService) SetNotificationsEnabled(ctx context.Context, enabled bool) error {
+	return s.set(func(next *AppSettings) { next.NotificationsEnabled = enabled })
+}
+
+// IsNotificationsEnabled returns whether notifications are enabled
+func (s *SettingsService) IsNotificationsEnabled(ctx context.Context) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.settings.NotificationsEnabled
+}
+
+// SetDebugMode enables or disables debug mode
+func (s *SettingsService) SetDebugMode(ctx context.Context, enabled bool) error {
+	return s.set(func(next *AppSettings) { next.DebugMode = enabled })
+}
+
+// IsDebugMode returns whether debug mode is enabled
+func (s *SettingsService) IsDebugMode(ctx context.Context) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.settings.DebugMode
+}
+
+// SetMinimizeToTray enables or disables minimize to tray on close
+func (s *SettingsService) SetMinimizeToTray(ctx context.Context, enabled bool) error {
+	return s.set(func(next *AppSettings) { next.MinimizeToTray = enabled })
+}
+
+// IsMinimizeToTray returns whether minimize to tray is enabled
+func (s *SettingsService) IsMinimizeToTray(ctx context.Context) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.settings.MinimizeToTray
+}
+
+// SetStartAtLogin enables or disables starting the app at login
+func (s *SettingsService) SetStartAtLogin(ctx context.Context, enabled bool) error {
+	return s.set(func(next *AppSettings) { next.StartAtLogin = enabled })
+}
+
+// IsStartAtLogin returns whether start at login is enabled
+func (s *SettingsService) IsStartAtLogin(ctx context.Context) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.settings.StartAtLogin
+}
+
+// SetMinimizeToTrayOnStartup enables or disables minimizing to tray on startup
+func (s *SettingsService) SetMinimizeToTrayOnStartup(ctx context.Context, enabled bool) error {
+	return s.set(func(next *AppSettings) { next.MinimizeToTrayOnStartup = enabled })
+}
+
+// IsMinimizeToTrayOnStartup returns whether minimize to tray on startup is enabled
+func (s *SettingsService) IsMinimizeToTrayOnStartup(ctx context.Context) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.settings.MinimizeToTrayOnStartup
+}
+
+// SetTrayOnly enables or disables tray-only mode (takes effect on next start)
+func (s *SettingsService) SetTrayOnly(ctx context.Context, enabled bool) error {
+	return s.set(func(next *AppSettings) { next.TrayOnly = enabled })
+}
+
+// IsTrayOnly returns whether tray-only mode is enabled
+func (s *SettingsService) IsTrayOnly(ctx context.Context) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.settings.TrayOnly
+}
+
+// SetStartLockedHidden enables or disables starting at login hidden in the
+// tray. With a password set the app stays locked and schedules wait until
+// the user unlocks it from the tray; runs missed meanwhile then start right
+// away. Enabling it also enables start at login.
+func (s *SettingsService) SetStartLockedHidden(ctx context.Context, enabled bool) error {
+	return s.set(func(next *AppSettings) {
+		next.StartLockedHidden = enabled
+		if enabled {
+			next.StartAtLogin = true
+		}
+	})
+}
+
+// IsStartLockedHidden returns whether the app starts hidden and locked at login
+func (s *SettingsService) IsStartLockedHidden(ctx context.Context) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.settings.StartLockedHidden
+}
+
+// SetMaxConcurrentTasks sets how many sync tasks may run at once (0 = unlimited).
+// Tasks beyond the limit wait in the priority queue.
+func (s *SettingsService) SetMaxConcurrentTasks(ctx context.Context, max int) error {
+	return s.set(func(next *AppSettings) { next.MaxConcurrentTasks = max })
+}
+
+// GetMaxConcurrentTasks returns how many sync tasks may run at once (0 = unlimited)
+func (s *SettingsService) GetMaxConcurrentTasks(ctx context.Context) int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.settings.MaxConcurrentTasks
+}
+
+// SetShutdownGracePeriod sets how many seconds running syncs may take to
+// finish when the app exits; queued syncs are cancelled right away and
+// running ones once it ends (0 = cancel at once)
+func (s *SettingsService) SetShutdownGracePeriod(ctx context.Context, seconds int) error {
+	return s.set(func(next *AppSettings) { next.ShutdownGracePeriod = seconds })
+}
+
+// GetShutdownGracePeriod returns how many seconds running syncs may take to finish when the app exits
+func (s *SettingsService) GetShutdownGracePeriod(ctx context.Context) int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.settings.ShutdownGracePeriod
+}
+
+// SetAPIBudget sets the percent of a provider's daily API quota from which
+// scheduled runs using it are deferred (0 = never), and the daily quotas
+// ("provider=calls", comma separated) replacing or adding to the known ones
+func (s *SettingsService) SetAPIBudget(ctx context.Context, thresholdPercent int, dailyQuotas string) error {
+	return s.set(func(next *AppSettings) {
+		next.APIBudgetThreshold = thresholdPercent
+		next.APIDailyQuotas = dailyQuotas
+	})
+}
+
+// getAPIBudget returns the API budget threshold and the daily quotas in effect
+func (s *SettingsService) getAPIBudget() (int, map[string]int64) {
+	s.mutex.RLock()
+	threshold, quotas := s.settings.APIBudgetThreshold, s.settings.APIDailyQuotas
+	s.mutex.RUnlock()
+
+	effective := make(map[string]int64, len(rclone.KnownDailyQuotas))
+	for provider, quota := range rclone.KnownDailyQuotas {
+		effective[provider] = quota
+	}
+	overrides, _ := parseAPIQuotas(quotas)
+	for provider, quota := range overrides {
+		effective[provider] = quota
+	}
+	return threshold, effective
+}
+
+// SetMinFreeDiskSpace sets how much free space must remain on local disks a
+// sync writes to (e.g. "1G", "0" = off). Syncs stop with a low disk space
+// error instead of filling the disk.
+func (s *SettingsService) SetMinFreeDiskSpace(ctx context.Context, size string) error {
+	return s.set(func(next *AppSettings) { next.MinFreeDiskSpace = size })
+}
+
+// GetMinFreeDiskSpace returns the free space threshold of the local disk guard
+func (s *SettingsService) GetMinFreeDiskSpace(ctx context.Context) string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.settings.MinFreeDiskSpace
+}
+
+// minFreeDiskSpaceBytes returns the free space threshold in bytes (0 = off)
+func (s *SettingsService) minFreeDiskSpaceBytes() int64 {
+	var parsed fs.SizeSuffix
+	if err := parsed.Set(s.GetMinFreeDiskSpace(context.Background())); err != nil {
+		return 0
+	}
+	return int64(parsed)
+}
+
+// SetPresentationMode sets what syncs do while the user presents, runs a
+// full-screen app or has Do Not Disturb on: nothing ("off"), wait
+// ("defer", for scheduled and background syncs) or slow down to bwLimit
+// ("throttle"). Non-error notifications are held back unless the mode is off.
+func (s *SettingsService) SetPresentationMode(ctx context.Context, mode, bwLimit string) error {
+	if bwLimit == "" {
+		bwLimit = defaultPresentationBwLimit
+	}
+	return s.set(func(next *AppSettings) {
+		next.PresentationMode = mode
+		next.PresentationBwLimit = bwLimit
+	})
+}
+
+// presentationSettings returns the presentation mode and its bandwidth cap
+func (s *SettingsService) presentationSettings() (string, string) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.settings.PresentationMode, s.settings.PresentationBwLimit
+}
+
+// SetChangeFeedPath sets the JSON Lines file the changes delta watchers
+// detect on remotes are appended to, for external tools such as indexers;
+// "" stops writing it
+func (s *SettingsService) SetChangeFeedPath(ctx context.Context, path string) error {
+	return s.set(func(next *AppSettings) { next.ChangeFeedPath = path })
+}
+
+// GetChangeFeedPath returns the change feed file, or "" if none is written
+func (s *SettingsService) GetChangeFeedPath(ctx context.Context) string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.settings.ChangeFeedPath
+}
+
+// validateChangeFeedPath checks that the change feed can be written to path
+func validateChangeFeedPath(path string) error {
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("change feed path %q must be absolute", path)
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return fmt.Errorf("change feed path %q is a directory", path)
+	}
+	if info, err := os.Stat(filepath.Dir(path)); err != nil || !info.IsDir() {
+		return fmt.Errorf("directory of change feed path %q doesn't exist", path)
+	}
+	return nil
+}
+
+// SetNetworkSettings sets the app-wide proxy, CA bundle, TLS verification,
+// bind address and DNS server settings and applies them to rclone. Remotes can override them. Returns
+// warnings for risky settings such as disabled certificate verification.
+func (s *SettingsService) SetNetworkSettings(ctx context.Context, settings models.NetworkSettings) ([]string, error) {
+	if err := s.set(func(next *AppSettings) { next.Network = settings }); err != nil {
+		return nil, err
+	}
+	return rclone.NetworkWarnings(settings), nil
+}
+
+// GetNetworkSettings returns the app-wide network settings
+func (s *SettingsService) GetNetworkSettings(ctx context.Context) models.NetworkSettings {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.settings.Network
+}
+
+// cacheLocations returns the configured cache locations; callers must not modify the map
+func (s *SettingsService) cacheLocations() map[string]models.CacheLocationSetting {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.settings.CacheLocations
+}
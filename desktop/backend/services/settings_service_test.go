@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func newTestSettingsService(t *testing.T) *SettingsService {
+	t.Helper()
+	// Clean DB table for test isolation
+	db, _ := GetSharedDB()
+	db.Exec("DELETE FROM settings")
+	return NewSettingsService(nil)
+}
+
+func TestSettingsService_UpdateSettingsConflict(t *testing.T) {
+	s := newTestSettingsService(t)
+	ctx := context.Background()
+
+	snapshot := s.GetSettingsSnapshot(ctx)
+	edited := snapshot.Settings
+	edited.MaxConcurrentTasks = 3
+	updated, err := s.UpdateSettings(ctx, edited, snapshot.Revision)
+	if err != nil {
+		t.Fatalf("UpdateSettings: %v", err)
+	}
+	if updated.Revision != snapshot.Revision+1 || updated.Settings.MaxConcurrentTasks != 3 {
+		t.Errorf("updated = %+v", updated)
+	}
+
+	// A second editor still holding the first snapshot loses
+	stale := snapshot.Settings
+	stale.TrayOnly = true
+	if _, err := s.UpdateSettings(ctx, stale, snapshot.Revision); !errors.Is(err, ErrSettingsConflict) {
+		t.Fatalf("expected a conflict, got %v", err)
+	}
+	if got := s.GetSettings(ctx); got.TrayOnly || got.MaxConcurrentTasks != 3 {
+		t.Errorf("a conflicting update changed the settings: %+v", got)
+	}
+
+	// Setters don't check the revision, but move it on
+	if err := s.SetTrayOnly(ctx, true); err != nil {
+		t.Fatalf("SetTrayOnly: %v", err)
+	}
+	if got := s.GetSettingsSnapshot(ctx).Revision; got != updated.Revision+1 {
+		t.Errorf("revision = %d, want %d", got, updated.Revision+1)
+	}
+}
+
+func TestSettingsService_Subscribe(t *testing.T) {
+	s := newTestSettingsService(t)
+	ctx := context.Background()
+
+	var changes []SettingsChange
+	unsubscribe := s.Subscribe(func(change SettingsChange) { changes = append(changes, change) })
+
+	if _, err := s.SetNetworkSettings(ctx, models.NetworkSettings{}); err != nil {
+		t.Fatalf("SetNetworkSettings: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("an unchanged setting notified subscribers: %+v", changes)
+	}
+
+	if err := s.SetPresentationMode(ctx, PresentationDefer, "1M"); err != nil {
+		t.Fatalf("SetPresentationMode: %v", err)
+	}
+	if len(changes) != 1 || !slices.Equal(changes[0].Keys, []string{"presentation_bw_limit", "presentation_mode"}) {
+		t.Fatalf("changes = %+v", changes)
+	}
+	if changes[0].Old.PresentationMode != PresentationOff || changes[0].New.PresentationMode != PresentationDefer {
+		t.Errorf("change = %+v", changes[0])
+	}
+	if !changes[0].Changed("max_concurrent_tasks", "presentation_mode") || changes[0].Changed("debug_mode") {
+		t.Error("Changed should match the changed keys only")
+	}
+
+	unsubscribe()
+	if err := s.SetDebugMode(ctx, true); err != nil {
+		t.Fatalf("SetDebugMode: %v", err)
+	}
+	if len(changes) != 1 {
+		t.Error("an unsubscribed func was still called")
+	}
+}
+
+func TestSettingsService_LoadSettings(t *testing.T) {
+	s := newTestSettingsService(t)
+	ctx := context.Background()
+	if err := s.SetMaxConcurrentTasks(ctx, 2); err != nil {
+		t.Fatalf("SetMaxConcurrentTasks: %v", err)
+	}
+	if err := s.SetMinFreeDiskSpace(ctx, "5G"); err != nil {
+		t.Fatalf("SetMinFreeDiskSpace: %v", err)
+	}
+	if err := s.SetMaxConcurrentTasks(ctx, -1); err == nil {
+		t.Error("expected an error for a negative limit")
+	}
+
+	loaded := NewSettingsService(nil)
+	var change SettingsChange
+	loaded.Subscribe(func(c SettingsChange) { change = c })
+	loaded.LoadSettings()
+
+	got := loaded.GetSettings(ctx)
+	if got.MaxConcurrentTasks != 2 || got.MinFreeDiskSpace != "5G" || !got.NotificationsEnabled {
+		t.Errorf("loaded settings = %+v", got)
+	}
+	if !change.Loaded || !slices.Equal(change.Keys, []string{"max_concurrent_tasks", "min_free_disk_space"}) {
+		t.Errorf("load change = %+v", change)
+	}
+}
+
+func TestSyncService_FollowsSettings(t *testing.T) {
+	settings := newTestSettingsService(t)
+	ctx := context.Background()
+	if err := settings.SetDebugMode(ctx, true); err != nil {
+		t.Fatalf("SetDebugMode: %v", err)
+	}
+	s := NewSyncService(nil)
+	s.SetSettingsService(settings)
+	if err := s.SetChaosMode(ctx, models.ChaosConfig{NetworkErrorRate: 0.1}); err != nil {
+		t.Fatalf("SetChaosMode: %v", err)
+	}
+
+	if err := settings.SetDebugMode(ctx, false); err != nil {
+		t.Fatalf("SetDebugMode: %v", err)
+	}
+	if s.GetChaosMode(ctx) != nil {
+		t.Error("turning debug mode off should turn chaos mode off")
+	}
+}
@@ -6,7 +6,7 @@ import "sync"
 // to avoid duplicate file I/O across services.
 type SharedConfig struct {
 	HomeDir    string
-	ConfigDir  string // e.g. ~/.config/gn-drive/
+	ConfigDir  string // ~/.config/gn-drive/ unless moved, see utils.SetConfigDir
 	WorkingDir string
 }
 
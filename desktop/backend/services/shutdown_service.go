@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// ShutdownService stops the services that hold state in a safe order when
+// the app exits: schedules stop firing, the sync queue drains within the
+// shutdown grace period, scheduled runs record their results, the delta
+// watchers save their pending changes, mounts are unmounted, and only then does AuthService
+// encrypt the files. Wails shuts services down in the reverse order they
+// are registered in, so it is registered last to run before all others.
+type ShutdownService struct {
+	app              *application.App
+	schedulerService *SchedulerService
+	syncService      *SyncService
+	authService      *AuthService
+	settingsService  *SettingsService
+	mountService     *MountService
+	once             sync.Once
+}
+
+// NewShutdownService creates a new shutdown service
+func NewShutdownService(app *application.App) *ShutdownService {
+	return &ShutdownService{app: app}
+}
+
+// SetApp sets the application reference
+func (s *ShutdownService) SetApp(app *application.App) {
+	s.app = app
+}
+
+// SetSchedulerService sets the scheduler stopped first
+func (s *ShutdownService) SetSchedulerService(schedulerService *SchedulerService) {
+	s.schedulerService = schedulerService
+}
+
+// SetSyncService sets the sync service whose queue is drained
+func (s *ShutdownService) SetSyncService(syncService *SyncService) {
+	s.syncService = syncService
+}
+
+// SetAuthService sets the auth service that encrypts the files last
+func (s *ShutdownService) SetAuthService(authService *AuthService) {
+	s.authService = authService
+}
+
+// SetSettingsService sets the settings service holding the shutdown grace period
+func (s *ShutdownService) SetSettingsService(settingsService *SettingsService) {
+	s.settingsService = settingsService
+}
+
+// SetMountService sets the mount service whose mounts are unmounted
+func (s *ShutdownService) SetMountService(mountService *MountService) {
+	s.mountService = mountService
+}
+
+// ServiceName returns the name of the service
+func (s *ShutdownService) ServiceName() string {
+	return "ShutdownService"
+}
+
+// ServiceStartup is called when the service starts
+func (s *ShutdownService) ServiceStartup(ctx context.Context, options application.ServiceOptions) error {
+	log.Printf("ShutdownService starting up...")
+	return nil
+}
+
+// ServiceShutdown is called when the service shuts down. It runs before the
+// other services' ServiceShutdown, which then find nothing left to stop.
+func (s *ShutdownService) ServiceShutdown(ctx context.Context) error {
+	log.Printf("ShutdownService shutting down...")
+	s.shutdown(ctx)
+	return nil
+}
+
+// shutdown stops the services in order, once
+func (s *ShutdownService) shutdown(ctx context.Context) {
+	s.once.Do(func() {
+		// No schedule fires while the queue drains
+		if s.schedulerService != nil {
+			s.schedulerService.stopTriggers()
+		}
+
+		if s.syncService != nil {
+			s.syncService.drainTasks(ctx, s.gracePeriod())
+		}
+
+		// Runs whose syncs were cancelled save their results while the database is still open
+		if s.schedulerService != nil {
+			s.schedulerService.cancelRuns(ctx)
+		}
+
+		if s.syncService != nil {
+			s.syncService.stopWatchers()
+		}
+
+		// Files written to mounts are flushed to their caches before the config is encrypted
+		if s.mountService != nil {
+			s.mountService.unmountAll("the app is exiting")
+		}
+
+		// Encrypting closes the database, so it comes last
+		if s.authService != nil {
+			s.authService.lockOnExit()
+		}
+		log.Printf("[ShutdownService] Services stopped")
+	})
+}
+
+// gracePeriod returns how long running syncs may take to finish
+func (s *ShutdownService) gracePeriod() time.Duration {
+	seconds := defaultShutdownGracePeriod
+	if s.settingsService != nil {
+		seconds = s.settingsService.GetShutdownGracePeriod(context.Background())
+	}
+	return time.Duration(seconds) * time.Second
+}
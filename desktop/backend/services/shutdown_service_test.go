@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSyncService_DrainTasks(t *testing.T) {
+	s := NewSyncService(nil)
+	running := &SyncTask{Id: 1, Status: "running", running: true, Done: make(chan error, 1)}
+	queued := &SyncTask{Id: 2, Status: "queued", Done: make(chan error, 1)}
+	s.activeTasks[1] = running
+	s.activeTasks[2] = queued
+
+	// The running task finishes within the grace period
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		s.mutex.Lock()
+		s.releaseTaskLocked(running)
+		s.mutex.Unlock()
+	}()
+	s.drainTasks(context.Background(), time.Minute)
+
+	if err := <-queued.Done; !errors.Is(err, context.Canceled) || queued.Status != "cancelled" {
+		t.Errorf("queued task should be cancelled, got %v (%s)", err, queued.Status)
+	}
+	if s.hasActiveTasks() {
+		t.Error("tasks left after draining")
+	}
+	if _, err := s.StartSync(context.Background(), "pull", models.Profile{}, ""); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("expected new syncs to be refused, got %v", err)
+	}
+}
+
+func TestSyncService_DrainTasksCancelsAfterGrace(t *testing.T) {
+	s := NewSyncService(nil)
+	task := &SyncTask{Id: 1, Status: "running", running: true}
+	task.Cancel = func() {
+		go func() {
+			s.mutex.Lock()
+			s.releaseTaskLocked(task)
+			s.mutex.Unlock()
+		}()
+	}
+	s.activeTasks[1] = task
+
+	start := time.Now()
+	s.drainTasks(context.Background(), 100*time.Millisecond)
+	if s.hasActiveTasks() {
+		t.Error("running task should be cancelled once the grace period is over")
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond || elapsed > shutdownCancelWait {
+		t.Errorf("drain took %v", elapsed)
+	}
+}
+
+func TestShutdownService_StopsServices(t *testing.T) {
+	settings := NewSettingsService(nil)
+	settings.settings.ShutdownGracePeriod = 0
+	scheduler := NewSchedulerService(nil)
+	scheduler.SetOperationService(NewOperationService(nil))
+	syncService := NewSyncService(nil)
+
+	runCtx, finish := scheduler.operationService.trackScheduleRun(context.Background(), "nightly")
+	go func() {
+		<-runCtx.Done()
+		finish(runCtx.Err())
+	}()
+	scheduler.queued["nightly"] = true
+
+	s := NewShutdownService(nil)
+	s.SetSchedulerService(scheduler)
+	s.SetSyncService(syncService)
+	s.SetSettingsService(settings)
+	if err := s.ServiceShutdown(context.Background()); err != nil {
+		t.Fatalf("ServiceShutdown: %v", err)
+	}
+
+	if !scheduler.stopped || scheduler.queued["nightly"] || runCtx.Err() == nil || scheduler.activeRun("nightly") != nil {
+		t.Error("the scheduler should stop firing and cancel its runs")
+	}
+	if !syncService.shuttingDown {
+		t.Error("the sync queue should be drained")
+	}
+
+	// The services' own ServiceShutdown still works afterwards
+	if err := scheduler.ServiceShutdown(context.Background()); err != nil {
+		t.Errorf("SchedulerService.ServiceShutdown: %v", err)
+	}
+	if err := syncService.ServiceShutdown(context.Background()); err != nil {
+		t.Errorf("SyncService.ServiceShutdown: %v", err)
+	}
+}
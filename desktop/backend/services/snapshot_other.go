@@ -0,0 +1,13 @@
+//go:build !windows
+
+package services
+
+import (
+	"context"
+	"fmt"
+)
+
+// createVolumeSnapshot is only available on Windows, through VSS
+func createVolumeSnapshot(ctx context.Context, path string) (string, func(), error) {
+	return "", nil, fmt.Errorf("snapshots are only available on Windows")
+}
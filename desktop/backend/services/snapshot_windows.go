@@ -0,0 +1,52 @@
+//go:build windows
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// createVolumeSnapshot takes a VSS shadow copy of the drive path is on and
+// returns path inside it, where files other applications hold open can be
+// read. release deletes the shadow copy. Needs administrator rights.
+func createVolumeSnapshot(ctx context.Context, path string) (string, func(), error) {
+	volume := filepath.VolumeName(path)
+	if len(volume) != 2 || volume[1] != ':' {
+		return "", nil, fmt.Errorf("%s is not on a local drive", path)
+	}
+	out, err := runPowerShell(ctx, fmt.Sprintf(`$r = (Get-WmiObject -List Win32_ShadowCopy).Create('%s\', 'ClientAccessible')
+if ($r.ReturnValue -ne 0) { throw "Win32_ShadowCopy.Create returned $($r.ReturnValue)" }
+$s = Get-WmiObject Win32_ShadowCopy | Where-Object { $_.ID -eq $r.ShadowID }
+$s.ID; $s.DeviceObject`, volume))
+	if err != nil {
+		return "", nil, err
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return "", nil, fmt.Errorf("unexpected shadow copy output: %q", out)
+	}
+	id, device := fields[0], fields[1]
+	release := func() {
+		if _, err := runPowerShell(context.Background(), fmt.Sprintf(
+			`Get-WmiObject Win32_ShadowCopy | Where-Object { $_.ID -eq '%s' } | ForEach-Object { $_.Delete() }`, id)); err != nil {
+			log.Printf("[SyncService] Could not delete shadow copy %s: %v", id, err)
+		}
+	}
+	return device + path[len(volume):], release, nil
+}
+
+func runPowerShell(ctx context.Context, script string) (string, error) {
+	cmd := exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("powershell: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"fmt"
+	"log"
+	"time"
+)
+
+// maxResilienceReports caps how many chaos mode run reports are kept
+const maxResilienceReports = 20
+
+// SetChaosMode turns on fault injection for managed runs. It is a debugging
+// aid: it requires debug mode, lasts until disabled or the app restarts, and
+// turns itself off when debug mode is disabled.
+func (s *SyncService) SetChaosMode(ctx context.Context, config models.ChaosConfig) error {
+	if s.settingsService == nil || !s.settingsService.IsDebugMode(ctx) {
+		return fmt.Errorf("chaos mode requires debug mode")
+	}
+	if err := rclone.ValidateChaosConfig(config); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	s.chaosConfig = &config
+	s.mutex.Unlock()
+	log.Printf("[SyncService] Chaos mode on: %+v", config)
+	return nil
+}
+
+// DisableChaosMode stops injecting faults into new runs
+func (s *SyncService) DisableChaosMode(ctx context.Context) {
+	s.mutex.Lock()
+	wasOn := s.chaosConfig != nil
+	s.chaosConfig = nil
+	s.mutex.Unlock()
+	if wasOn {
+		log.Printf("[SyncService] Chaos mode off")
+	}
+}
+
+// GetChaosMode returns the faults injected into managed runs, or nil when chaos mode is off
+func (s *SyncService) GetChaosMode(ctx context.Context) *models.ChaosConfig {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if s.chaosConfig == nil {
+		return nil
+	}
+	config := *s.chaosConfig
+	return &config
+}
+
+// GetResilienceReports returns how runs with chaos mode on coped, newest first
+func (s *SyncService) GetResilienceReports(ctx context.Context) []models.ResilienceReport {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	reports := make([]models.ResilienceReport, len(s.resilienceReports))
+	for i, r := range s.resilienceReports {
+		reports[len(reports)-1-i] = r
+	}
+	return reports
+}
+
+// injectChaos makes the task's remotes inject faults when chaos mode is on.
+// The returned injector is nil otherwise; the returned func releases the
+// run's cancel context.
+func (s *SyncService) injectChaos(ctx context.Context, task *SyncTask) (context.Context, *rclone.ChaosInjector, func()) {
+	config := s.GetChaosMode(ctx)
+	if config == nil {
+		return ctx, nil, func() {}
+	}
+	if s.settingsService == nil || !s.settingsService.IsDebugMode(ctx) {
+		s.DisableChaosMode(ctx)
+		return ctx, nil, func() {}
+	}
+
+	chaosCtx, cancel := context.WithCancelCause(ctx)
+	chaos := rclone.NewChaosInjector(*config, func(err error) {
+		log.Printf("[SyncService] Chaos mode cancelling task %d", task.Id)
+		cancel(err)
+	})
+	log.Printf("[SyncService] Chaos mode injecting faults into task %d", task.Id)
+	return rclone.WithChaos(chaosCtx, chaos), chaos, func() { cancel(nil) }
+}
+
+// recordResilienceReport records how a run coped with the faults chaos mode injected
+func (s *SyncService) recordResilienceReport(task *SyncTask, chaos *rclone.ChaosInjector, startTime time.Time, taskErr error) {
+	report := models.ResilienceReport{
+		TaskId:      task.Id,
+		ProfileName: task.Profile.Name,
+		Action:      string(task.Action),
+		Config:      chaos.Config(),
+		Injected:    chaos.Counts(),
+		Status:      task.Status,
+		StartTime:   startTime,
+		EndTime:     time.Now(),
+	}
+	if taskErr != nil {
+		report.Error = taskErr.Error()
+	}
+	faults := report.Injected.NetworkErrors + report.Injected.RateLimits + report.Injected.TransferCuts
+	report.Recovered = task.Status == "completed" && faults > 0
+	if status := task.latestStatus(); status != nil {
+		report.FilesTransferred = status.FilesTransferred
+		report.Errors = status.Errors
+	}
+	task.failedMu.Lock()
+	report.FailedFiles = len(task.failedFiles)
+	task.failedMu.Unlock()
+	if task.report != nil {
+		for _, f := range task.report.MostRetried {
+			if f.Attempts > 1 {
+				report.RetriedFiles++
+			}
+		}
+	}
+	log.Printf("[SyncService] Resilience report for task %d: status=%s injected=%+v", task.Id, report.Status, report.Injected)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.resilienceReports = append(s.resilienceReports, report)
+	if len(s.resilienceReports) > maxResilienceReports {
+		s.resilienceReports = s.resilienceReports[len(s.resilienceReports)-maxResilienceReports:]
+	}
+}
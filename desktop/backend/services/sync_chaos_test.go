@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"testing"
+	"time"
+)
+
+func TestSyncService_ChaosModeRequiresDebugMode(t *testing.T) {
+	ctx := context.Background()
+	settings := NewSettingsService(nil)
+	s := NewSyncService(nil)
+	s.settingsService = settings
+	config := models.ChaosConfig{NetworkErrorRate: 0.1}
+
+	if err := s.SetChaosMode(ctx, config); err == nil {
+		t.Fatal("chaos mode should be refused without debug mode")
+	}
+
+	settings.settings.DebugMode = true
+	if err := s.SetChaosMode(ctx, models.ChaosConfig{NetworkErrorRate: 2}); err == nil {
+		t.Error("an invalid rate should be rejected")
+	}
+	if err := s.SetChaosMode(ctx, config); err != nil {
+		t.Fatalf("SetChaosMode: %v", err)
+	}
+
+	task := &SyncTask{Id: 7, Action: ActionPush}
+	_, chaos, stop := s.injectChaos(ctx, task)
+	stop()
+	if chaos == nil || chaos.Config() != config {
+		t.Fatalf("injectChaos should inject the configured faults, got %v", chaos)
+	}
+
+	// Turning debug mode off turns chaos mode off for the next run
+	settings.settings.DebugMode = false
+	if _, chaos, _ := s.injectChaos(ctx, task); chaos != nil {
+		t.Error("chaos mode should not outlive debug mode")
+	}
+	if s.GetChaosMode(ctx) != nil {
+		t.Error("chaos mode should be disabled once debug mode is off")
+	}
+}
+
+func TestSyncService_RecordResilienceReport(t *testing.T) {
+	s := NewSyncService(nil)
+	ctx := context.Background()
+	for i := 1; i <= maxResilienceReports+2; i++ {
+		task := &SyncTask{Id: i, Action: ActionPush, Status: "completed", Profile: models.Profile{Name: "p"}}
+		s.recordResilienceReport(task, rclone.NewChaosInjector(models.ChaosConfig{}, nil), time.Now(), nil)
+	}
+	reports := s.GetResilienceReports(ctx)
+	if len(reports) != maxResilienceReports {
+		t.Fatalf("kept %d reports, want %d", len(reports), maxResilienceReports)
+	}
+	if reports[0].TaskId != maxResilienceReports+2 {
+		t.Errorf("newest report first: got task %d", reports[0].TaskId)
+	}
+	if reports[0].Recovered {
+		t.Error("a run without injected faults did not recover from anything")
+	}
+}
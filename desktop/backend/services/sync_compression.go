@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"desktop/backend/utils"
+	"log"
+)
+
+// PreviewCompression samples how well the profile's source files would
+// compress on a destination with compression wrapping, and which types
+// would be stored uncompressed (see Profile.CompressDest)
+func (s *SyncService) PreviewCompression(ctx context.Context, profile models.Profile) (*models.CompressionEstimate, error) {
+	profile, err := resolvePathVariables(profile)
+	if err != nil {
+		return nil, err
+	}
+	return rclone.EstimateCompression(ctx, profile)
+}
+
+// measuresCompression reports whether the task's run writes compressed
+// files whose compression can be measured: a push with compression wrapping
+func (t *SyncTask) measuresCompression() bool {
+	return t.Profile.CompressDest && t.Action == ActionPush && len(t.Profile.FanOutTo) == 0
+}
+
+// recordCompressedFile remembers a file a push wrote through compression wrapping
+func (t *SyncTask) recordCompressedFile(tr utils.TransferTransition) {
+	if tr.Phase != utils.TransferCompleted || tr.Transfer.Name == "" || !t.measuresCompression() {
+		return
+	}
+	t.failedMu.Lock()
+	defer t.failedMu.Unlock()
+	if t.compressedFiles == nil {
+		t.compressedFiles = make(map[string]struct{})
+	}
+	t.compressedFiles[tr.Transfer.Name] = struct{}{}
+}
+
+// rememberCompression measures the compression the task's push achieved on
+// the files it wrote, so the profile's next history entry can include it
+func (s *SyncService) rememberCompression(ctx context.Context, task *SyncTask) {
+	task.failedMu.Lock()
+	files := task.compressedFiles
+	task.failedMu.Unlock()
+	if len(files) == 0 || ctx.Err() != nil {
+		return
+	}
+	// task.Profile.To is the compress remote since ApplyCompressWrapping
+	run, err := rclone.MeasureCompression(ctx, task.Profile.To, files)
+	if err != nil {
+		log.Printf("[SyncService] Could not measure the compression of task %d: %v", task.Id, err)
+		return
+	}
+	log.Printf("[SyncService] Task %d stored %d files at %.2fx compression (%d uncompressed)", task.Id, run.Files, run.Ratio, run.Uncompressed)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.compressionRuns == nil {
+		s.compressionRuns = make(map[string]*models.CompressionRun)
+	}
+	s.compressionRuns[task.Profile.Name] = run
+}
+
+// takeCompression returns and forgets the compression of a profile's last run
+func (s *SyncService) takeCompression(profileName string) *models.CompressionRun {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	run := s.compressionRuns[profileName]
+	delete(s.compressionRuns, profileName)
+	return run
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"desktop/backend/events"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// GetConflictCopies lists the conflict copies bisyncs of the profile kept on
+// either side, newest first: files named with the profile's conflict suffix
+// and everything in its conflict folder (see Profile.ConflictNaming)
+func (s *SyncService) GetConflictCopies(ctx context.Context, profile models.Profile) ([]models.ConflictCopy, error) {
+	profile, err := resolvePathVariables(profile)
+	if err != nil {
+		return nil, err
+	}
+	return rclone.ListConflictCopies(ctx, profile)
+}
+
+// GetSyncConflicts lists the files pushes and pulls of profiles with
+// detect_conflicts left out because they changed on both sides, oldest
+// first, until they are resolved with ResolveConflict
+func (s *SyncService) GetSyncConflicts(ctx context.Context) ([]models.SyncConflict, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`SELECT id, profile_name, action, source, dest, path, source_size, source_mod_time,
+		dest_size, dest_mod_time, detected_at FROM sync_conflicts ORDER BY detected_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query conflicts: %w", err)
+	}
+	defer rows.Close()
+
+	conflicts := []models.SyncConflict{}
+	for rows.Next() {
+		var c models.SyncConflict
+		var sourceModTime, destModTime, detectedAt string
+		if err := rows.Scan(&c.Id, &c.ProfileName, &c.Action, &c.Source, &c.Dest, &c.Path, &c.SourceSize, &sourceModTime,
+			&c.DestSize, &destModTime, &detectedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan conflict: %w", err)
+		}
+		c.SourceModTime, _ = time.Parse(time.RFC3339Nano, sourceModTime)
+		c.DestModTime, _ = time.Parse(time.RFC3339Nano, destModTime)
+		c.DetectedAt, _ = time.Parse(time.RFC3339, detectedAt)
+		conflicts = append(conflicts, c)
+	}
+	return conflicts, rows.Err()
+}
+
+// ResolveConflict resolves a conflict: "keep_source" copies the source's
+// version over the destination's, "keep_dest" the destination's over the
+// source's, and "keep_both" renames the destination's version with the
+// profile's conflict suffix on both sides before copying the source's.
+// Later runs then sync the file again.
+func (s *SyncService) ResolveConflict(ctx context.Context, conflictId int64, resolution string) error {
+	switch resolution {
+	case models.ConflictKeepSource, models.ConflictKeepDest, models.ConflictKeepBoth:
+	default:
+		return fmt.Errorf("resolution must be %s, %s or %s", models.ConflictKeepSource, models.ConflictKeepDest, models.ConflictKeepBoth)
+	}
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	var profileName, action, path, data string
+	err = db.QueryRow("SELECT profile_name, action, path, profile FROM sync_conflicts WHERE id = ?", conflictId).
+		Scan(&profileName, &action, &path, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("conflict %d not found", conflictId)
+	}
+	if err != nil {
+		return err
+	}
+	var profile models.Profile
+	if err := json.Unmarshal([]byte(data), &profile); err != nil {
+		return fmt.Errorf("failed to read the profile of conflict %d: %w", conflictId, err)
+	}
+
+	s.mutex.RLock()
+	busy := false
+	for _, task := range s.activeTasks {
+		if task.Profile.Name == profileName && (task.Action == ActionPush || task.Action == ActionPull) {
+			busy = true
+		}
+	}
+	s.mutex.RUnlock()
+	if busy {
+		return fmt.Errorf("a run of profile '%s' is active; resolve the conflict once it ends", profileName)
+	}
+
+	if err := rclone.ResolveConflict(ctx, profile, action == string(ActionPull), path, resolution); err != nil {
+		return fmt.Errorf("failed to resolve the conflict of %s: %w", path, err)
+	}
+	if _, err := db.Exec("DELETE FROM sync_conflicts WHERE id = ?", conflictId); err != nil {
+		return err
+	}
+	log.Printf("[SyncService] Conflict of %s in profile '%s' resolved: %s", path, profileName, resolution)
+	return nil
+}
+
+// detectConflicts leaves out of a push or pull of a profile with
+// detect_conflicts the files changed on both sides since its last
+// successful run, and the ones still unresolved from earlier runs. New
+// conflicts are recorded and announced. Returns when the check started,
+// the baseline of the next check once the run succeeds, or the zero time
+// if nothing was checked.
+func (s *SyncService) detectConflicts(ctx context.Context, task *SyncTask) time.Time {
+	profile := task.Profile
+	if !profile.DetectConflicts || profile.Name == "" || (task.Action != ActionPush && task.Action != ActionPull) {
+		return time.Time{}
+	}
+	// Wrapped remotes only exist during the run, so their conflicts couldn't be resolved
+	if profile.EncryptSource || profile.EncryptDest || profile.CompressDest || task.failover != nil {
+		log.Printf("[SyncService] Not checking profile '%s' for conflicts: the run is encrypted, compressed or failed over", profile.Name)
+		return time.Time{}
+	}
+
+	started := time.Now()
+	pending, err := loadPendingConflicts(profile.Name, task.Action)
+	if err != nil {
+		log.Printf("[SyncService] Could not load the conflicts of profile '%s': %v", profile.Name, err)
+		return time.Time{}
+	}
+	since, err := loadConflictBaseline(profile.Name, task.Action)
+	if err != nil {
+		log.Printf("[SyncService] Could not load the conflict baseline of profile '%s': %v", profile.Name, err)
+		return time.Time{}
+	}
+
+	// The first run only sets the baseline
+	checked := since.IsZero()
+	if !since.IsZero() {
+		conflicts, settled, err := rclone.DetectConflicts(ctx, profile, task.Action == ActionPull, since, pending)
+		if err != nil {
+			log.Printf("[SyncService] Could not check profile '%s' for conflicts: %v", profile.Name, err)
+		} else {
+			checked = true
+			for _, path := range settled {
+				delete(pending, path)
+			}
+			added := 0
+			for _, c := range conflicts {
+				if !pending[c.Path] {
+					added++
+				}
+				pending[c.Path] = true
+			}
+			if err := saveConflicts(task, conflicts, settled); err != nil {
+				log.Printf("[SyncService] Could not record the conflicts of profile '%s': %v", profile.Name, err)
+			}
+			if added > 0 {
+				s.emitSyncEvent(events.SyncConflict, task.TabId, string(task.Action), "running",
+					fmt.Sprintf("%d files changed on both sides since the last run were left out", added))
+			}
+		}
+	}
+
+	task.Profile.SkipFiles = nil
+	for path := range pending {
+		task.Profile.SkipFiles = append(task.Profile.SkipFiles, path)
+	}
+	sort.Strings(task.Profile.SkipFiles)
+	if !checked {
+		return time.Time{}
+	}
+	return started
+}
+
+// loadPendingConflicts returns the unresolved conflicts of a profile's
+// pushes or pulls, by path
+func loadPendingConflicts(profileName string, action SyncAction) (map[string]bool, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query("SELECT path FROM sync_conflicts WHERE profile_name = ? AND action = ?", profileName, string(action))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	pending := make(map[string]bool)
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		pending[path] = true
+	}
+	return pending, rows.Err()
+}
+
+// saveConflicts records the conflicts a run found, updating those found
+// before, and forgets the ones settled since
+func saveConflicts(task *SyncTask, conflicts []models.SyncConflict, settled []string) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	for _, path := range settled {
+		if _, err := db.Exec("DELETE FROM sync_conflicts WHERE profile_name = ? AND action = ? AND path = ?",
+			task.Profile.Name, string(task.Action), path); err != nil {
+			return err
+		}
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	profile := task.Profile
+	profile.SkipFiles = nil
+	profile.StripEncryptPasswords()
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return err
+	}
+	source, dest := profile.From, profile.To
+	if task.Action == ActionPull {
+		source, dest = dest, source
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, c := range conflicts {
+		if _, err := db.Exec(`INSERT INTO sync_conflicts (profile_name, action, profile, source, dest, path,
+			source_size, source_mod_time, dest_size, dest_mod_time, detected_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(profile_name, action, path) DO UPDATE SET profile = excluded.profile, source = excluded.source,
+				dest = excluded.dest, source_size = excluded.source_size, source_mod_time = excluded.source_mod_time,
+				dest_size = excluded.dest_size, dest_mod_time = excluded.dest_mod_time`,
+			profile.Name, string(task.Action), string(data), source, dest, c.Path,
+			c.SourceSize, c.SourceModTime.Format(time.RFC3339Nano), c.DestSize, c.DestModTime.Format(time.RFC3339Nano), now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadConflictBaseline returns when the conflict check of the last
+// successful push or pull of a profile started, or the zero time
+func loadConflictBaseline(profileName string, action SyncAction) (time.Time, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return time.Time{}, err
+	}
+	var since string
+	err = db.QueryRow("SELECT since FROM conflict_baselines WHERE profile_name = ? AND action = ?", profileName, string(action)).Scan(&since)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339Nano, since)
+}
+
+// saveConflictBaseline records when the conflict check of a successful run
+// started; changes after it are checked by the next run
+func saveConflictBaseline(task *SyncTask, since time.Time) {
+	if since.IsZero() {
+		return
+	}
+	db, err := GetSharedDB()
+	if err != nil {
+		return
+	}
+	if _, err := db.Exec(`INSERT INTO conflict_baselines (profile_name, action, since) VALUES (?, ?, ?)
+		ON CONFLICT(profile_name, action) DO UPDATE SET since = excluded.since`,
+		task.Profile.Name, string(task.Action), since.UTC().Format(time.RFC3339Nano)); err != nil {
+		log.Printf("[SyncService] Could not save the conflict baseline of profile '%s': %v", task.Profile.Name, err)
+	}
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSyncService_DetectAndResolveConflicts(t *testing.T) {
+	db, _ := GetSharedDB()
+	db.Exec("DELETE FROM sync_conflicts")
+	db.Exec("DELETE FROM conflict_baselines")
+	ctx := context.Background()
+	src, dst := t.TempDir(), t.TempDir()
+	for dir, content := range map[string]string{src: "mine", dst: "theirs"} {
+		if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := NewSyncService(nil)
+	task := &SyncTask{Action: ActionPush, Profile: models.Profile{Name: "notes", From: src, To: dst, DetectConflicts: true}}
+
+	// The first run only sets the baseline
+	if since := s.detectConflicts(ctx, task); since.IsZero() || len(task.Profile.SkipFiles) != 0 {
+		t.Fatalf("first check = %v, skipping %v; want a baseline and nothing skipped", since, task.Profile.SkipFiles)
+	}
+	saveConflictBaseline(task, time.Now().Add(-time.Hour))
+
+	if since := s.detectConflicts(ctx, task); since.IsZero() {
+		t.Fatal("expected the check to run")
+	}
+	if len(task.Profile.SkipFiles) != 1 || task.Profile.SkipFiles[0] != "notes.txt" {
+		t.Fatalf("skipped files = %v, want notes.txt", task.Profile.SkipFiles)
+	}
+	conflicts, err := s.GetSyncConflicts(ctx)
+	if err != nil || len(conflicts) != 1 {
+		t.Fatalf("GetSyncConflicts() = %v, %v, want one conflict", conflicts, err)
+	}
+	if c := conflicts[0]; c.ProfileName != "notes" || c.Source != src || c.Dest != dst || c.DestSize != 6 {
+		t.Errorf("conflict = %+v", c)
+	}
+
+	if err := s.ResolveConflict(ctx, conflicts[0].Id, "overwrite"); err == nil {
+		t.Error("expected an error for an unknown resolution")
+	}
+	if err := s.ResolveConflict(ctx, conflicts[0].Id, models.ConflictKeepSource); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "notes.txt")); string(data) != "mine" {
+		t.Errorf("destination = %q after keeping the source's version", data)
+	}
+	if conflicts, _ := s.GetSyncConflicts(ctx); len(conflicts) != 0 {
+		t.Errorf("resolved conflict still listed: %v", conflicts)
+	}
+}
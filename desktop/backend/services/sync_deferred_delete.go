@@ -0,0 +1,18 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+)
+
+// GetPendingDeletions lists the files runs of the profile moved to the
+// pending-delete folder of their destination, oldest deletion first, with
+// how many runs each waited (see Profile.DeferDeletes)
+func (s *SyncService) GetPendingDeletions(ctx context.Context, profile models.Profile) ([]models.PendingDeletion, error) {
+	profile, err := resolvePathVariables(profile)
+	if err != nil {
+		return nil, err
+	}
+	return rclone.ListPendingDeletions(ctx, profile)
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/delta"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"log"
+	"path"
+	"strings"
+)
+
+// GetDirectoryStatus returns the sync state of dir, a directory of the
+// profile's source given relative to it ("" for the source itself),
+// followed by the states of the directories directly inside it. States are
+// computed from the profile's last sync run and from the changes the
+// source's delta watcher collected that no run picked up yet.
+func (s *SyncService) GetDirectoryStatus(ctx context.Context, profile models.Profile, dir string) ([]models.DirectoryStatus, error) {
+	dir = strings.Trim(path.Clean("/"+dir), "/")
+	excluded, err := rclone.ExcludedDirFunc(profile)
+	if err != nil {
+		return nil, err
+	}
+	subdirs, err := rclone.ListSubdirs(ctx, profile.From, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []delta.FileChange
+	if s.deltaSvc != nil {
+		pending = s.deltaSvc.PendingChanges(rclone.SourceRemoteKey(string(ActionPush), profile))
+	}
+	lastStatus := s.lastSyncStatus(ctx, profile.Name)
+
+	s.mutex.RLock()
+	failed := s.lastFailures[profile.Name]
+	s.mutex.RUnlock()
+
+	return directoryStatuses(append([]string{dir}, subdirs...), excluded, lastStatus, pending, failed), nil
+}
+
+// directoryStatuses works out the state of each of dirs. lastStatus is the
+// history status of the profile's last sync run, "" if it never ran. A
+// failed run without any failed files known puts every directory in error.
+func directoryStatuses(dirs []string, excluded func(string) bool, lastStatus string, pending []delta.FileChange, failed []string) []models.DirectoryStatus {
+	statuses := make([]models.DirectoryStatus, 0, len(dirs))
+	for _, dir := range dirs {
+		st := models.DirectoryStatus{Path: dir}
+		for _, c := range pending {
+			if pathWithin(c.Path, dir) || (c.OldPath != "" && pathWithin(c.OldPath, dir)) {
+				st.Pending++
+			}
+		}
+		for _, f := range failed {
+			if pathWithin(f, dir) {
+				st.Errors++
+			}
+		}
+
+		switch {
+		case excluded(dir):
+			st.State = models.DirExcluded
+		case st.Errors > 0 || (lastStatus == "failed" && len(failed) == 0):
+			st.State = models.DirError
+		case st.Pending > 0 || lastStatus != "completed":
+			st.State = models.DirPending
+		default:
+			st.State = models.DirInSync
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses
+}
+
+// pathWithin reports whether p is dir or lies below it; every path lies
+// within the root ""
+func pathWithin(p, dir string) bool {
+	p = strings.Trim(p, "/")
+	return dir == "" || p == dir || strings.HasPrefix(p, dir+"/")
+}
+
+// lastSyncStatus returns the history status of the profile's last pull,
+// push or bisync run, or "" if it has none
+func (s *SyncService) lastSyncStatus(ctx context.Context, profileName string) string {
+	if s.historyService == nil {
+		return ""
+	}
+	entries, err := s.historyService.GetHistoryForProfile(ctx, profileName)
+	if err != nil {
+		log.Printf("warning: failed to load the history of %s: %v", profileName, err)
+		return ""
+	}
+	for _, e := range entries {
+		switch SyncAction(e.Action) {
+		case ActionPull, ActionPush, ActionBi, ActionBiResync:
+			return e.Status
+		}
+	}
+	return ""
+}
+
+// rememberLastFailures keeps the files that failed in a profile's sync run,
+// replacing those of its previous run
+func (s *SyncService) rememberLastFailures(task *SyncTask) {
+	if task.Action == ActionRestore {
+		return
+	}
+	files := task.failedFileList()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.lastFailures == nil {
+		s.lastFailures = make(map[string][]string)
+	}
+	if len(files) == 0 {
+		delete(s.lastFailures, task.Profile.Name)
+		return
+	}
+	s.lastFailures[task.Profile.Name] = files
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"desktop/backend/delta"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"testing"
+)
+
+func TestDirectoryStatuses(t *testing.T) {
+	excluded, err := rclone.ExcludedDirFunc(models.Profile{ExcludedPaths: []string{"/cache/**"}})
+	if err != nil {
+		t.Fatalf("ExcludedDirFunc() error = %v", err)
+	}
+	dirs := []string{"", "docs", "photos", "cache", "music"}
+	pending := []delta.FileChange{
+		{Path: "docs/a.txt"},
+		{Path: "docs/sub/b.txt"},
+		{Path: "music/new.mp3", OldPath: "photos/old.mp3", Type: delta.ChangeRenamed},
+	}
+
+	tests := []struct {
+		name       string
+		lastStatus string
+		pending    []delta.FileChange
+		failed     []string
+		want       map[string]string
+	}{
+		{"never ran", "", nil, nil, map[string]string{
+			"": models.DirPending, "docs": models.DirPending, "photos": models.DirPending, "cache": models.DirExcluded, "music": models.DirPending,
+		}},
+		{"completed with changes", "completed", pending, nil, map[string]string{
+			"": models.DirPending, "docs": models.DirPending, "photos": models.DirPending, "cache": models.DirExcluded, "music": models.DirPending,
+		}},
+		{"completed in sync", "completed", pending[:1], nil, map[string]string{
+			"": models.DirPending, "docs": models.DirPending, "photos": models.DirInSync, "cache": models.DirExcluded, "music": models.DirInSync,
+		}},
+		{"failed files", "failed", nil, []string{"photos/x.jpg"}, map[string]string{
+			"": models.DirError, "docs": models.DirPending, "photos": models.DirError, "cache": models.DirExcluded, "music": models.DirPending,
+		}},
+		{"failed without files", "failed", nil, nil, map[string]string{
+			"": models.DirError, "docs": models.DirError, "photos": models.DirError, "cache": models.DirExcluded, "music": models.DirError,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := directoryStatuses(dirs, excluded, tt.lastStatus, tt.pending, tt.failed)
+			if len(got) != len(dirs) {
+				t.Fatalf("got %d statuses, want %d", len(got), len(dirs))
+			}
+			for _, st := range got {
+				if st.State != tt.want[st.Path] {
+					t.Errorf("%q: state = %s, want %s", st.Path, st.State, tt.want[st.Path])
+				}
+			}
+		})
+	}
+
+	got := directoryStatuses(dirs, excluded, "completed", pending, nil)
+	if got[0].Pending != 3 || got[1].Pending != 2 || got[2].Pending != 1 || got[4].Pending != 1 {
+		t.Errorf("pending counts = %d, %d, %d, %d; want 3, 2, 1, 1", got[0].Pending, got[1].Pending, got[2].Pending, got[4].Pending)
+	}
+}
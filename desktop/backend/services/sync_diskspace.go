@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/rclone"
+	"errors"
+	"log"
+)
+
+// guardDiskSpace checks the local directories a task writes to before it
+// starts, and watches them while it runs. If free space drops below the
+// threshold, the returned context is cancelled with a *rclone.LowDiskSpaceError
+// so the run stops cleanly instead of failing mid-file with ENOSPC. The
+// returned func stops the watch.
+func (s *SyncService) guardDiskSpace(ctx context.Context, task *SyncTask) (context.Context, func(), error) {
+	if s.settingsService == nil {
+		return ctx, func() {}, nil
+	}
+	minFree := s.settingsService.minFreeDiskSpaceBytes()
+	if minFree <= 0 {
+		return ctx, func() {}, nil
+	}
+
+	dirs := rclone.LocalWriteDirs(string(task.Action), task.Profile)
+	if err := rclone.CheckFreeSpace(dirs, minFree); err != nil {
+		return ctx, func() {}, err
+	}
+
+	guarded, cancel := context.WithCancelCause(ctx)
+	go rclone.WatchFreeSpace(guarded, dirs, minFree, rclone.DiskSpaceCheckInterval, func(err error) {
+		log.Printf("[SyncService] Stopping task %d: %v", task.Id, err)
+		cancel(err)
+	})
+	return guarded, func() { cancel(nil) }, nil
+}
+
+// lowDiskSpaceCause returns the low disk space error a guarded context was
+// cancelled with, or nil if it was cancelled for another reason
+func lowDiskSpaceCause(ctx context.Context) error {
+	var lowSpace *rclone.LowDiskSpaceError
+	if cause := context.Cause(ctx); errors.As(cause, &lowSpace) {
+		return lowSpace
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"errors"
+	"testing"
+)
+
+func TestSyncService_GuardDiskSpace(t *testing.T) {
+	settings := NewSettingsService(nil)
+	s := NewSyncService(nil)
+	s.settingsService = settings
+	task := &SyncTask{Id: 1, Action: ActionPull, Profile: models.Profile{From: t.TempDir(), To: "gdrive:docs"}}
+
+	// The default threshold leaves room on the test machine
+	ctx, stop, err := s.guardDiskSpace(context.Background(), task)
+	if err != nil {
+		t.Fatalf("expected the default threshold to pass, got %v", err)
+	}
+	stop()
+	if lowDiskSpaceCause(ctx) != nil {
+		t.Error("stopping the guard should not report low disk space")
+	}
+
+	settings.settings.MinFreeDiskSpace = "1E"
+	_, _, err = s.guardDiskSpace(context.Background(), task)
+	var lowSpace *rclone.LowDiskSpaceError
+	if !errors.As(err, &lowSpace) {
+		t.Fatalf("expected a low disk space error, got %v", err)
+	}
+
+	settings.settings.MinFreeDiskSpace = "0"
+	if _, _, err := s.guardDiskSpace(context.Background(), task); err != nil {
+		t.Errorf("expected a zero threshold to turn the guard off, got %v", err)
+	}
+}
+
+func TestLowDiskSpaceCause(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(&rclone.LowDiskSpaceError{Path: "/mnt", Free: 1, Required: 2})
+	if lowDiskSpaceCause(ctx) == nil {
+		t.Error("expected the low disk space cause")
+	}
+
+	ctx, cancel = context.WithCancelCause(context.Background())
+	cancel(nil)
+	if lowDiskSpaceCause(ctx) != nil {
+		t.Error("a plain cancel is not low disk space")
+	}
+}
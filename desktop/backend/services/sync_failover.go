@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"desktop/backend/events"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// failoverCheckInterval is how often primaries that were failed over from
+// are checked for being back
+const failoverCheckInterval = 5 * time.Minute
+
+// probeRemote checks whether a destination is reachable; replaced in tests
+var probeRemote = rclone.ProbeRemote
+
+// PendingReconciliation is a profile whose runs went to its failover
+// destination, waiting for its primary to be synced again
+type PendingReconciliation struct {
+	ProfileName string         `json:"profile_name"`
+	Profile     models.Profile `json:"profile"` // encryption passwords are not kept
+	Primary     string         `json:"primary"`
+	Fallback    string         `json:"fallback"`
+	Reason      string         `json:"reason"`
+	Since       time.Time      `json:"since"` // first run that failed over
+}
+
+// applyFailover points a push at the profile's failover destination when
+// its primary destination is unreachable, and records that the primary
+// needs reconciling once it is back. Called before encryption wraps the paths.
+func (s *SyncService) applyFailover(ctx context.Context, task *SyncTask) {
+	// A resumed task that already failed over stays on the fallback
+	if task.Profile.FailoverTo == "" || task.Action != ActionPush || task.failover != nil {
+		return
+	}
+	err := probeRemote(ctx, task.Profile.To)
+	if err == nil || ctx.Err() != nil {
+		return
+	}
+
+	primary, fallback := task.Profile.To, task.Profile.FailoverTo
+	log.Printf("[SyncService] Task %d: %s is unreachable, syncing to %s instead: %v", task.Id, primary, fallback, err)
+	task.failover = &models.FailoverRun{Primary: primary, Fallback: fallback, Reason: err.Error()}
+	if err := savePendingReconciliation(task.Profile, task.failover); err != nil {
+		log.Printf("[SyncService] Could not record the failover of %q: %v", task.Profile.Name, err)
+	}
+	task.Profile.To = fallback
+	s.emitSyncEvent(events.SyncFailover, task.TabId, string(task.Action), "running",
+		fmt.Sprintf("%s is unreachable, syncing to %s instead", primary, fallback))
+}
+
+// finishFailover is called when a push completed. A run that reached the
+// primary of a profile that had failed over has reconciled it, so it is
+// marked as such for history and the profile no longer waits for
+// reconciliation.
+func (s *SyncService) finishFailover(task *SyncTask) {
+	if task.failover != nil || task.Profile.FailoverTo == "" || task.Action != ActionPush {
+		return
+	}
+	pending, err := getPendingReconciliation(task.Profile.Name)
+	if err != nil || pending == nil {
+		return
+	}
+	if err := deletePendingReconciliation(task.Profile.Name); err != nil {
+		log.Printf("[SyncService] Could not clear the failover of %q: %v", task.Profile.Name, err)
+	}
+	task.failover = &models.FailoverRun{Primary: pending.Primary, Fallback: pending.Fallback, Reconciliation: true}
+	log.Printf("[SyncService] %s is up to date again after failing over to %s", pending.Primary, pending.Fallback)
+	s.rememberFailover(task)
+	s.emitSyncEvent(events.SyncReconciled, task.TabId, string(task.Action), "completed",
+		fmt.Sprintf("%s is up to date again", pending.Primary))
+}
+
+// rememberFailover keeps the task's failover so the profile's next history
+// entry can include it
+func (s *SyncService) rememberFailover(task *SyncTask) {
+	if task.failover == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.failovers == nil {
+		s.failovers = make(map[string]*models.FailoverRun)
+	}
+	s.failovers[task.Profile.Name] = task.failover
+}
+
+// takeFailover returns and forgets the failover of a profile's last run
+func (s *SyncService) takeFailover(profileName string) *models.FailoverRun {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	run := s.failovers[profileName]
+	delete(s.failovers, profileName)
+	return run
+}
+
+// watchFailovers checks the primaries of profiles that failed over every
+// failoverCheckInterval until ctx ends
+func (s *SyncService) watchFailovers(ctx context.Context) {
+	ticker := time.NewTicker(failoverCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.reconcileFailovers(ctx)
+		}
+	}
+}
+
+// reconcileFailovers starts a background push to the primary of each
+// profile that failed over, once the primary is reachable again. Profiles
+// with a run going, or with encryption whose passwords aren't stored, are
+// left for their next run to reconcile.
+func (s *SyncService) reconcileFailovers(ctx context.Context) {
+	pending, err := s.GetPendingReconciliations(ctx)
+	if err != nil {
+		return
+	}
+	for _, p := range pending {
+		if p.Profile.EncryptSource || p.Profile.EncryptDest || s.profileRunning(p.ProfileName) {
+			continue
+		}
+		if err := probeRemote(ctx, p.Primary); err != nil {
+			continue
+		}
+		log.Printf("[SyncService] %s is reachable again, reconciling %q", p.Primary, p.ProfileName)
+		profile := p.Profile
+		profile.To = p.Primary
+		if _, err := s.StartSync(WithTaskPriority(ctx, PriorityBackground), string(ActionPush), profile, ""); err != nil {
+			log.Printf("[SyncService] Could not reconcile %q: %v", p.ProfileName, err)
+		}
+	}
+}
+
+// profileRunning reports whether a task of the profile is active
+func (s *SyncService) profileRunning(profileName string) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for _, task := range s.activeTasks {
+		if task.Profile.Name == profileName {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPendingReconciliations returns the profiles that failed over and whose
+// primary destination hasn't been synced since
+func (s *SyncService) GetPendingReconciliations(ctx context.Context) ([]PendingReconciliation, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`SELECT profile_name, profile, primary_path, fallback_path, reason, since
+		FROM failover_reconciliations ORDER BY since`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pending := []PendingReconciliation{}
+	for rows.Next() {
+		var p PendingReconciliation
+		var profile, since string
+		if err := rows.Scan(&p.ProfileName, &profile, &p.Primary, &p.Fallback, &p.Reason, &since); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(profile), &p.Profile); err != nil {
+			log.Printf("[SyncService] Skipping unreadable failover of %q: %v", p.ProfileName, err)
+			continue
+		}
+		p.Since, _ = time.Parse(time.RFC3339, since)
+		pending = append(pending, p)
+	}
+	return pending, rows.Err()
+}
+
+// getPendingReconciliation returns the failover a profile waits to
+// reconcile, or nil if there is none
+func getPendingReconciliation(profileName string) (*PendingReconciliation, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	var p PendingReconciliation
+	err = db.QueryRow("SELECT primary_path, fallback_path FROM failover_reconciliations WHERE profile_name = ?", profileName).
+		Scan(&p.Primary, &p.Fallback)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.ProfileName = profileName
+	return &p, nil
+}
+
+// savePendingReconciliation records that a profile failed over. A profile
+// that already waits keeps the time it first failed over.
+func savePendingReconciliation(profile models.Profile, run *models.FailoverRun) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	profile.StripEncryptPasswords()
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`INSERT INTO failover_reconciliations (profile_name, profile, primary_path, fallback_path, reason, since)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(profile_name) DO UPDATE SET profile = excluded.profile, primary_path = excluded.primary_path,
+			fallback_path = excluded.fallback_path, reason = excluded.reason`,
+		profile.Name, string(data), run.Primary, run.Fallback, run.Reason, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// deletePendingReconciliation forgets a profile's failover
+func deletePendingReconciliation(profileName string) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("DELETE FROM failover_reconciliations WHERE profile_name = ?", profileName)
+	return err
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSyncService_Failover(t *testing.T) {
+	db, _ := GetSharedDB()
+	db.Exec("DELETE FROM failover_reconciliations")
+	ctx := context.Background()
+	s := NewSyncService(nil)
+
+	reachable := false
+	defer func(p func(context.Context, string) error) { probeRemote = p }(probeRemote)
+	probeRemote = func(ctx context.Context, path string) error {
+		if path == "nas:backup" && !reachable {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+
+	// The NAS is down: the run goes to the cloud and the NAS waits for reconciliation
+	task := &SyncTask{Id: 1, Action: ActionPush}
+	task.Profile.Name, task.Profile.From, task.Profile.To, task.Profile.FailoverTo = "docs", "/home/docs", "nas:backup", "s3:backup"
+	s.applyFailover(ctx, task)
+	if task.Profile.To != "s3:backup" || task.failover == nil || task.failover.Primary != "nas:backup" || task.failover.Reconciliation {
+		t.Fatalf("expected the run to fail over, got to=%s failover=%+v", task.Profile.To, task.failover)
+	}
+	s.rememberFailover(task)
+	if run := s.takeFailover("docs"); run == nil || run.Fallback != "s3:backup" {
+		t.Errorf("expected the failover to be kept for history, got %+v", run)
+	}
+	pending, err := s.GetPendingReconciliations(ctx)
+	if err != nil || len(pending) != 1 || pending[0].Profile.To != "nas:backup" || pending[0].Reason != "connection refused" {
+		t.Fatalf("unexpected pending reconciliations: %+v, %v", pending, err)
+	}
+
+	// The NAS is back: the next run reaches it and reconciles it
+	reachable = true
+	next := &SyncTask{Id: 2, Action: ActionPush, Profile: pending[0].Profile}
+	s.applyFailover(ctx, next)
+	if next.Profile.To != "nas:backup" || next.failover != nil {
+		t.Fatalf("expected the run to use the primary, got to=%s failover=%+v", next.Profile.To, next.failover)
+	}
+	s.finishFailover(next)
+	if run := s.takeFailover("docs"); run == nil || !run.Reconciliation {
+		t.Errorf("expected the run to be marked as a reconciliation, got %+v", run)
+	}
+	if pending, _ := s.GetPendingReconciliations(ctx); len(pending) != 0 {
+		t.Errorf("expected no pending reconciliations, got %+v", pending)
+	}
+}
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/events"
+	"desktop/backend/models"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InterruptedRun is a sync that was running when the app last exited
+type InterruptedRun struct {
+	Id          int64          `json:"id"`
+	Action      string         `json:"action"`
+	Profile     models.Profile `json:"profile"` // encryption passwords are not kept
+	TabId       string         `json:"tab_id,omitempty"`
+	StartTime   time.Time      `json:"start_time"`
+	LastSeen    time.Time      `json:"last_seen"`              // last time the run was known to be going
+	FailedFiles []string       `json:"failed_files,omitempty"` // files that had failed before it was interrupted
+}
+
+// runSession identifies this app process in run markers, so runs of this
+// session aren't taken for interrupted ones after a lock and unlock
+var runSession = strconv.FormatInt(time.Now().UnixNano(), 36)
+
+// saveRunMarker records that a task started running, so a crash leaves a
+// trace of it. Nothing is written when it resumes after a pause.
+func saveRunMarker(task *SyncTask) {
+	if task.markerId != 0 {
+		return
+	}
+	db, err := GetSharedDB()
+	if err != nil {
+		log.Printf("[SyncService] Could not record task %d as running: %v", task.Id, err)
+		return
+	}
+
+	profile := task.Profile
+	profile.StripEncryptPasswords()
+	data, err := json.Marshal(profile)
+	if err != nil {
+		log.Printf("[SyncService] Could not record task %d as running: %v", task.Id, err)
+		return
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	result, err := db.Exec(`INSERT INTO running_tasks (session, task_id, action, tab_id, profile, started_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		runSession, task.Id, string(task.Action), task.TabId, string(data), task.StartTime.UTC().Format(time.RFC3339), now)
+	if err != nil {
+		log.Printf("[SyncService] Could not record task %d as running: %v", task.Id, err)
+		return
+	}
+	task.markerId, _ = result.LastInsertId()
+}
+
+// saveRunMarkerFailures updates a running task's marker with its failed
+// files, for the retry_failed resume mode
+func saveRunMarkerFailures(task *SyncTask) {
+	if task.markerId == 0 {
+		return
+	}
+	db, err := GetSharedDB()
+	if err != nil {
+		return
+	}
+	data, _ := json.Marshal(task.failedFileList())
+	now := time.Now().UTC().Format(time.RFC3339)
+	if _, err := db.Exec("UPDATE running_tasks SET failed_files = ?, updated_at = ? WHERE id = ?", string(data), now, task.markerId); err != nil {
+		log.Printf("[SyncService] Could not record failed files of task %d: %v", task.Id, err)
+	}
+}
+
+// clearRunMarker removes a finished task's marker
+func clearRunMarker(task *SyncTask) {
+	if task.markerId == 0 {
+		return
+	}
+	db, err := GetSharedDB()
+	if err != nil {
+		return
+	}
+	if _, err := db.Exec("DELETE FROM running_tasks WHERE id = ?", task.markerId); err != nil {
+		log.Printf("[SyncService] Could not clear the running marker of task %d: %v", task.Id, err)
+		return
+	}
+	task.markerId = 0
+}
+
+// recoverInterruptedRuns looks for runs the app exited during, records
+// them as interrupted in the history and re-runs them, or retries their
+// failed files, if their profile asks to. The others are offered with a
+// sync:interrupted event and GetInterruptedRuns. Called once the database is open.
+func (s *SyncService) recoverInterruptedRuns() {
+	runs, err := s.takeInterruptedRuns()
+	if err != nil {
+		log.Printf("[SyncService] Could not check for interrupted runs: %v", err)
+		return
+	}
+	if len(runs) == 0 {
+		return
+	}
+	log.Printf("[SyncService] Found %d runs interrupted when the app last exited", len(runs))
+
+	if s.historyService != nil {
+		entries := make([]models.HistoryEntry, len(runs))
+		for i, run := range runs {
+			entries[i] = run.historyEntry()
+		}
+		if _, err := s.historyService.addRecordedEntries(entries); err != nil {
+			log.Printf("[SyncService] Could not record interrupted runs in history: %v", err)
+		}
+	}
+
+	for _, run := range runs {
+		if s.autoResume(run) {
+			continue
+		}
+		s.mutex.Lock()
+		if s.interruptedRuns == nil {
+			s.interruptedRuns = make(map[int64]InterruptedRun)
+		}
+		s.interruptedRuns[run.Id] = run
+		s.mutex.Unlock()
+		s.emitSyncEvent(events.SyncInterrupted, run.TabId, run.Action, "interrupted",
+			fmt.Sprintf("Sync of %s was interrupted when the app exited", run.Profile.Name))
+	}
+}
+
+// autoResume starts an interrupted run again if its profile asks to.
+// Returns true if it was started.
+func (s *SyncService) autoResume(run InterruptedRun) bool {
+	mode := run.Profile.ResumeInterrupted
+	if mode == models.ResumeInterruptedOffer {
+		return false
+	}
+	if run.Profile.EncryptSource || run.Profile.EncryptDest {
+		log.Printf("[SyncService] Not resuming interrupted run %d of %q: its encryption passwords aren't stored", run.Id, run.Profile.Name)
+		return false
+	}
+
+	ctx := WithTaskPriority(context.Background(), PriorityBackground)
+	if _, err := s.startInterruptedRun(ctx, run, mode == models.ResumeInterruptedRetryFailed); err != nil {
+		log.Printf("[SyncService] Could not resume interrupted run %d of %q: %v", run.Id, run.Profile.Name, err)
+		return false
+	}
+	return true
+}
+
+// takeInterruptedRuns removes and returns the run markers of earlier
+// sessions. Markers of this session whose task is gone (it ended while the
+// database was closed) are removed too.
+func (s *SyncService) takeInterruptedRuns() ([]InterruptedRun, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`SELECT id, session, task_id, action, tab_id, profile, failed_files, started_at, updated_at
+		FROM running_tasks ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	var runs []InterruptedRun
+	var stale []int64
+	for rows.Next() {
+		var run InterruptedRun
+		var session, profile, failedFiles, startedAt, updatedAt string
+		var taskId int
+		if err := rows.Scan(&run.Id, &session, &taskId, &run.Action, &run.TabId, &profile, &failedFiles, &startedAt, &updatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if session == runSession {
+			if s.getTask(taskId) == nil {
+				stale = append(stale, run.Id)
+			}
+			continue
+		}
+		if err := json.Unmarshal([]byte(profile), &run.Profile); err != nil {
+			log.Printf("[SyncService] Skipping unreadable interrupted run %d: %v", run.Id, err)
+			stale = append(stale, run.Id)
+			continue
+		}
+		json.Unmarshal([]byte(failedFiles), &run.FailedFiles)
+		run.StartTime, _ = time.Parse(time.RFC3339, startedAt)
+		run.LastSeen, _ = time.Parse(time.RFC3339, updatedAt)
+		runs = append(runs, run)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, run := range runs {
+		stale = append(stale, run.Id)
+	}
+	for _, id := range stale {
+		if _, err := db.Exec("DELETE FROM running_tasks WHERE id = ?", id); err != nil {
+			return nil, err
+		}
+	}
+	return runs, nil
+}
+
+// historyEntry returns the history entry recording the interrupted run
+func (r InterruptedRun) historyEntry() models.HistoryEntry {
+	message := "The app exited before the run finished"
+	if len(r.FailedFiles) > 0 {
+		message += fmt.Sprintf("; %d files had failed", len(r.FailedFiles))
+	}
+	return models.HistoryEntry{
+		Id:           fmt.Sprintf("interrupted-%d-%d", r.StartTime.Unix(), r.Id),
+		ProfileName:  r.Profile.Name,
+		Action:       r.Action,
+		Status:       "interrupted",
+		StartTime:    r.StartTime,
+		EndTime:      r.LastSeen,
+		Duration:     r.LastSeen.Sub(r.StartTime).String(),
+		Errors:       len(r.FailedFiles),
+		ErrorMessage: message,
+	}
+}
+
+// GetInterruptedRuns returns the runs the app last exited during that
+// weren't resumed automatically, oldest first
+func (s *SyncService) GetInterruptedRuns(ctx context.Context) []InterruptedRun {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	runs := make([]InterruptedRun, 0, len(s.interruptedRuns))
+	for _, run := range s.interruptedRuns {
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Id < runs[j].Id })
+	return runs
+}
+
+// ResumeInterruptedRun starts an interrupted run again: all of it, or only
+// the files that had failed before it was interrupted (retryFailed)
+func (s *SyncService) ResumeInterruptedRun(ctx context.Context, runId int64, retryFailed bool) (*SyncResult, error) {
+	s.mutex.RLock()
+	run, exists := s.interruptedRuns[runId]
+	s.mutex.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("interrupted run %d not found", runId)
+	}
+
+	result, err := s.startInterruptedRun(ctx, run, retryFailed)
+	if err != nil {
+		return nil, err
+	}
+	s.DismissInterruptedRun(ctx, runId)
+	return result, nil
+}
+
+// DismissInterruptedRun stops offering an interrupted run
+func (s *SyncService) DismissInterruptedRun(ctx context.Context, runId int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.interruptedRuns, runId)
+}
+
+// startInterruptedRun starts an interrupted run again, like RetryFailedFiles
+// when only its failed files are retried
+func (s *SyncService) startInterruptedRun(ctx context.Context, run InterruptedRun, retryFailed bool) (*SyncResult, error) {
+	profile := run.Profile
+	if retryFailed {
+		if len(run.FailedFiles) == 0 {
+			return nil, fmt.Errorf("no failed files recorded for interrupted run %d", run.Id)
+		}
+		profile.UseRegex = false
+		profile.IncludedPaths = make([]string, len(run.FailedFiles))
+		for i, f := range run.FailedFiles {
+			profile.IncludedPaths[i] = "/" + escapeFilterGlob(f)
+		}
+	}
+
+	// Board tabs belong to the board run that was interrupted
+	tabId := run.TabId
+	if strings.HasPrefix(tabId, "board-") {
+		tabId = ""
+	}
+	log.Printf("[SyncService] Resuming interrupted run %d of %q (retry failed: %v)", run.Id, run.Profile.Name, retryFailed)
+	ctx = withRunLabels(ctx, models.LabelResumeOfPrefix+run.historyEntry().Id)
+	return s.StartSync(ctx, run.Action, profile, tabId)
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/dto"
+	"desktop/backend/models"
+	"desktop/backend/utils"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSyncService_RecoverInterruptedRuns(t *testing.T) {
+	db, _ := GetSharedDB()
+	db.Exec("DELETE FROM running_tasks")
+	h := newTestHistoryService(t)
+	ctx := context.Background()
+
+	// Runs of an earlier session that never finished
+	offered := &SyncTask{Id: 1, Action: ActionPush, StartTime: time.Now().Add(-time.Hour),
+		Profile: models.Profile{Name: "docs", EncryptDest: true, EncryptPassword: "secret"}}
+	saveRunMarker(offered)
+	retried := &SyncTask{Id: 2, Action: ActionPull, StartTime: time.Now(),
+		Profile: models.Profile{Name: "photos", ResumeInterrupted: models.ResumeInterruptedRetryFailed}}
+	saveRunMarker(retried)
+	db.Exec("UPDATE running_tasks SET session = 'earlier'")
+
+	// A run of this session that is still going, and one that ended while locked
+	running := &SyncTask{Id: 3, Action: ActionPush, StartTime: time.Now(), Profile: models.Profile{Name: "music"}}
+	saveRunMarker(running)
+	saveRunMarker(&SyncTask{Id: 4, Action: ActionPush, StartTime: time.Now(), Profile: models.Profile{Name: "video"}})
+
+	s := NewSyncService(nil)
+	s.SetHistoryService(h)
+	s.activeTasks[3] = running
+	s.recoverInterruptedRuns()
+
+	entries, err := h.GetHistory(ctx, 10, 0)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("expected 2 interrupted runs in history, got %+v, %v", entries, err)
+	}
+	for _, e := range entries {
+		if e.Status != "interrupted" || !slices.Contains([]string{"docs", "photos"}, e.ProfileName) {
+			t.Errorf("unexpected history entry: %+v", e)
+		}
+	}
+
+	// Neither could resume: one has encryption passwords, the other no failed files
+	runs := s.GetInterruptedRuns(ctx)
+	if len(runs) != 2 || runs[0].Profile.Name != "docs" || runs[1].Profile.Name != "photos" {
+		t.Fatalf("unexpected offered runs: %+v", runs)
+	}
+	if runs[0].Profile.EncryptPassword != "" {
+		t.Error("encryption passwords should not be stored")
+	}
+	if _, err := s.ResumeInterruptedRun(ctx, runs[1].Id, true); err == nil {
+		t.Error("expected retrying a run without failed files to fail")
+	}
+	s.DismissInterruptedRun(ctx, runs[0].Id)
+	if runs := s.GetInterruptedRuns(ctx); len(runs) != 1 {
+		t.Errorf("expected a dismissed run to be gone, got %+v", runs)
+	}
+
+	// Only the marker of the run still going is left
+	var left []int
+	rows, _ := db.Query("SELECT task_id FROM running_tasks")
+	for rows.Next() {
+		var id int
+		rows.Scan(&id)
+		left = append(left, id)
+	}
+	rows.Close()
+	if !slices.Equal(left, []int{3}) {
+		t.Errorf("markers left = %v, want the running task's", left)
+	}
+
+	clearRunMarker(running)
+	if running.markerId != 0 {
+		t.Error("marker should be cleared")
+	}
+}
+
+func TestSyncTask_RecordTransferOutcome(t *testing.T) {
+	task := &SyncTask{}
+	file := dto.FileTransferInfo{Name: "a.txt"}
+	failed := utils.TransferTransition{Phase: utils.TransferFailed, Transfer: file}
+	if !task.recordTransferOutcome(failed) || task.recordTransferOutcome(failed) {
+		t.Error("only the first failure should change the failed files")
+	}
+	if !task.recordTransferOutcome(utils.TransferTransition{Phase: utils.TransferCompleted, Transfer: file}) {
+		t.Error("a completed retry should change the failed files")
+	}
+	if files := task.failedFileList(); len(files) != 0 {
+		t.Errorf("failed files = %v", files)
+	}
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	beConfig "desktop/backend/config"
+	"desktop/backend/dto"
+	apperrors "desktop/backend/errors"
+	"desktop/backend/events"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"desktop/backend/utils"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// lockedFilesRetryDelay is how long a run waits before copying its locked
+// files again, so the applications holding them get a chance to let go
+var lockedFilesRetryDelay = 30 * time.Second
+
+// recordLockedFile marks a failed transfer as locked when its error says
+// another application holds the source file open
+func (t *SyncTask) recordLockedFile(tr *utils.TransferTransition) {
+	if tr.Phase != utils.TransferFailed || tr.Transfer.Name == "" || !apperrors.IsFileLocked(tr.Transfer.Error) {
+		return
+	}
+	tr.Transfer.Locked = true
+	t.failedMu.Lock()
+	defer t.failedMu.Unlock()
+	if t.lockedFiles == nil {
+		t.lockedFiles = make(map[string]struct{})
+	}
+	t.lockedFiles[tr.Transfer.Name] = struct{}{}
+}
+
+// lockedFilesRun splits the files that failed as locked into those still
+// failed and those a later attempt copied. Returns nil if none were locked.
+func (t *SyncTask) lockedFilesRun() *models.LockedFilesRun {
+	t.failedMu.Lock()
+	defer t.failedMu.Unlock()
+	if len(t.lockedFiles) == 0 {
+		return nil
+	}
+	run := &models.LockedFilesRun{
+		Files:         []string{},
+		Handling:      t.Profile.LockedFiles,
+		Snapshot:      t.lockedSnapshot,
+		SnapshotError: t.snapshotError,
+	}
+	for name := range t.lockedFiles {
+		if _, failed := t.failedFiles[name]; failed {
+			run.Files = append(run.Files, name)
+		} else {
+			run.Recovered = append(run.Recovered, name)
+		}
+	}
+	sort.Strings(run.Files)
+	sort.Strings(run.Recovered)
+	return run
+}
+
+// onlyLockedFilesFailed reports whether every failed file of the run failed
+// because it was locked
+func (t *SyncTask) onlyLockedFilesFailed() bool {
+	t.failedMu.Lock()
+	defer t.failedMu.Unlock()
+	for name := range t.failedFiles {
+		if _, locked := t.lockedFiles[name]; !locked {
+			return false
+		}
+	}
+	return true
+}
+
+// retryLockedFiles copies a push or pull run's locked files again once the
+// rest of the run is done, when the profile asks for it. With "snapshot"
+// the retry reads them from a VSS snapshot of the source drive, falling back
+// to the live files if none can be taken. Returns nil instead of runErr if
+// the locked files were all that failed and the retry copied them.
+func (s *SyncService) retryLockedFiles(ctx context.Context, task *SyncTask, config beConfig.Config, outStatus chan *dto.SyncStatusDTO, runErr error) error {
+	if runErr == nil || task.Profile.LockedFiles == "" || len(task.Profile.FanOutTo) > 0 || ctx.Err() != nil {
+		return runErr
+	}
+	if task.Action != ActionPush && task.Action != ActionPull {
+		return runErr
+	}
+	run := task.lockedFilesRun()
+	if run == nil || len(run.Files) == 0 {
+		return runErr
+	}
+	onlyLocked := task.onlyLockedFilesFailed()
+
+	s.emitSyncEvent(events.SyncProgress, task.TabId, string(task.Action), "running",
+		fmt.Sprintf("Retrying %d files in use by other applications", len(run.Files)))
+	select {
+	case <-ctx.Done():
+		return runErr
+	case <-time.After(lockedFilesRetryDelay):
+	}
+
+	profile := task.Profile
+	profile.UseRegex = false
+	profile.SessionTransfer = ""
+	profile.IncludedPaths = make([]string, len(run.Files))
+	for i, f := range run.Files {
+		profile.IncludedPaths[i] = "/" + escapeFilterGlob(f)
+	}
+
+	if profile.LockedFiles == models.LockedFilesSnapshot {
+		// A pull reads from To
+		source := &profile.From
+		if task.Action == ActionPull {
+			source = &profile.To
+		}
+		snapshotPath, release, err := createVolumeSnapshot(ctx, *source)
+		task.failedMu.Lock()
+		if err != nil {
+			task.snapshotError = err.Error()
+		} else {
+			task.lockedSnapshot = true
+		}
+		task.failedMu.Unlock()
+		if err != nil {
+			log.Printf("[SyncService] No snapshot of %s, retrying the live files: %v", *source, err)
+		} else {
+			defer release()
+			*source = snapshotPath
+		}
+	}
+
+	log.Printf("[SyncService] Retrying %d locked files of task %d", len(run.Files), task.Id)
+	if err := rclone.Sync(ctx, config, string(task.Action), profile, outStatus, nil); err != nil {
+		log.Printf("[SyncService] Locked file retry of task %d failed: %v", task.Id, err)
+	}
+	if onlyLocked && len(task.failedFileList()) == 0 {
+		return nil
+	}
+	return runErr
+}
+
+// classifyTaskError classifies a failed run's error, or reports it as
+// FILE_LOCKED when it isn't recognised and locked files were left
+func classifyTaskError(task *SyncTask, errorMsg string) *models.ErrorInfo {
+	if info := apperrors.ClassifyRcloneError(errorMsg); info != nil {
+		return info
+	}
+	if run := task.lockedFilesRun(); run != nil && len(run.Files) > 0 {
+		return apperrors.LookupErrorInfo(string(apperrors.FileLocked))
+	}
+	return nil
+}
+
+// rememberLockedFiles keeps the task's locked files so the profile's next
+// history entry can include them
+func (s *SyncService) rememberLockedFiles(task *SyncTask) {
+	run := task.lockedFilesRun()
+	if run == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.lockedFileRuns == nil {
+		s.lockedFileRuns = make(map[string]*models.LockedFilesRun)
+	}
+	s.lockedFileRuns[task.Profile.Name] = run
+}
+
+// takeLockedFiles returns and forgets the locked files of a profile's last run
+func (s *SyncService) takeLockedFiles(profileName string) *models.LockedFilesRun {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	run := s.lockedFileRuns[profileName]
+	delete(s.lockedFileRuns, profileName)
+	return run
+}
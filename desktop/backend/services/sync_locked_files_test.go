@@ -0,0 +1,53 @@
+package services
+
+import (
+	"desktop/backend/dto"
+	"desktop/backend/models"
+	"desktop/backend/utils"
+	"testing"
+)
+
+func TestLockedFilesRun(t *testing.T) {
+	task := &SyncTask{Profile: models.Profile{Name: "docs", LockedFiles: models.LockedFilesRetry}}
+	record := func(phase utils.TransferPhase, name, errMsg string) bool {
+		tr := utils.TransferTransition{Phase: phase, Transfer: dto.FileTransferInfo{Name: name, Error: errMsg}}
+		task.recordLockedFile(&tr)
+		task.recordTransferOutcome(tr)
+		return tr.Transfer.Locked
+	}
+
+	if !record(utils.TransferFailed, "mail/outlook.pst", "failed to open source object: The process cannot access the file because it is being used by another process.") {
+		t.Error("expected the sharing violation to mark the transfer locked")
+	}
+	record(utils.TransferFailed, "vm/disk.vmdk", "can't copy - source file is being updated (size changed from 1 to 2)")
+	if task.onlyLockedFilesFailed() != true {
+		t.Error("expected only locked files to have failed")
+	}
+	if record(utils.TransferFailed, "notes.txt", "permission denied") {
+		t.Error("expected a permission error not to mark the transfer locked")
+	}
+	if task.onlyLockedFilesFailed() {
+		t.Error("expected a failure that isn't a lock to count")
+	}
+
+	// The end-of-run retry copies one of them
+	record(utils.TransferCompleted, "vm/disk.vmdk", "")
+
+	run := task.lockedFilesRun()
+	if run == nil || run.Handling != models.LockedFilesRetry {
+		t.Fatalf("lockedFilesRun() = %+v", run)
+	}
+	if len(run.Files) != 1 || run.Files[0] != "mail/outlook.pst" {
+		t.Errorf("Files = %v, want [mail/outlook.pst]", run.Files)
+	}
+	if len(run.Recovered) != 1 || run.Recovered[0] != "vm/disk.vmdk" {
+		t.Errorf("Recovered = %v, want [vm/disk.vmdk]", run.Recovered)
+	}
+	if info := classifyTaskError(task, "failed to copy 2 files"); info == nil || info.Code != "FILE_LOCKED" {
+		t.Errorf("classifyTaskError() = %+v, want FILE_LOCKED", info)
+	}
+
+	if (&SyncTask{}).lockedFilesRun() != nil {
+		t.Error("expected no locked files run for a task without locked files")
+	}
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/events"
+	"desktop/backend/rclone"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// writeManifest writes the manifest of a completed push whose profile asks
+// for one. A manifest that can't be written is logged and reported, but
+// doesn't fail the run.
+func (s *SyncService) writeManifest(ctx context.Context, task *SyncTask) {
+	if !task.Profile.WriteManifest || task.Action != ActionPush {
+		return
+	}
+	runId := uuid.New().String()
+	manifest, err := rclone.WriteManifest(ctx, task.Profile, runId, checksumCache{})
+	if err != nil {
+		log.Printf("[SyncService] Task %d: could not write the manifest of %s: %v", task.Id, task.Profile.To, err)
+		s.emitSyncEvent(events.SyncProgress, task.TabId, string(task.Action), "running",
+			fmt.Sprintf("Could not write the manifest: %v", err))
+		return
+	}
+	log.Printf("[SyncService] Task %d: wrote manifest %s of %d files to %s", task.Id, runId, len(manifest.Files), task.Profile.To)
+}
+
+// checksumCache is the rclone.ChecksumCache kept in the database
+type checksumCache struct{}
+
+// GetChecksum returns the cached hash of a file, if it hasn't changed since
+func (checksumCache) GetChecksum(path string, size int64, modTime time.Time, hashType string) (string, bool) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return "", false
+	}
+	var sum string
+	err = db.QueryRow("SELECT hash FROM checksum_cache WHERE path = ? AND hash_type = ? AND size = ? AND mod_time = ?",
+		path, hashType, size, modTime.UTC().Format(time.RFC3339Nano)).Scan(&sum)
+	return sum, err == nil
+}
+
+// PutChecksum caches the hash of a file
+func (checksumCache) PutChecksum(path string, size int64, modTime time.Time, hashType, sum string) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return
+	}
+	if _, err := db.Exec("INSERT OR REPLACE INTO checksum_cache (path, hash_type, size, mod_time, hash) VALUES (?, ?, ?, ?, ?)",
+		path, hashType, size, modTime.UTC().Format(time.RFC3339Nano), sum); err != nil {
+		log.Printf("warning: failed to cache the checksum of %s: %v", path, err)
+	}
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"fmt"
+)
+
+// PreviewTransferPlan lists the files a push or pull of the profile would
+// transfer, in the order the run starts them (see Profile.TransferOrder).
+// Only the first files are listed, the plan counts all of them.
+func (s *SyncService) PreviewTransferPlan(ctx context.Context, action string, profile models.Profile) (*models.TransferPlan, error) {
+	if action != string(ActionPush) && action != string(ActionPull) {
+		return nil, fmt.Errorf("transfer plans are only available for push and pull, not %q", action)
+	}
+	profile, err := resolvePathVariables(profile)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := rclone.PlanTransfers(ctx, profile, action == string(ActionPull), maxQueuedFiles)
+	if err != nil {
+		return nil, err
+	}
+	plan.ProfileName = profile.Name
+	plan.Action = action
+	return plan, nil
+}
+
+// PreviewSync works out with a dry run which files a push or pull of the
+// profile would create, update and delete on its destination, so they can
+// be confirmed before the run. Nothing is transferred or deleted; the
+// preview counts every change and lists the first ones.
+func (s *SyncService) PreviewSync(ctx context.Context, action string, profile models.Profile) (*models.SyncPreview, error) {
+	if action != string(ActionPush) && action != string(ActionPull) {
+		return nil, fmt.Errorf("sync previews are only available for push and pull, not %q", action)
+	}
+	profile, err := resolvePathVariables(profile)
+	if err != nil {
+		return nil, err
+	}
+	preview, err := rclone.PreviewSync(ctx, profile, action == string(ActionPull), maxQueuedFiles)
+	if err != nil {
+		return nil, err
+	}
+	preview.ProfileName = profile.Name
+	preview.Action = action
+	return preview, nil
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/rclone"
+)
+
+// checkDestination checks a destination before a run; replaced in tests
+var checkDestination = rclone.CheckDestination
+
+// checkDestinations checks that the directories a task writes into exist
+// and, unless it is a dry run, that it can write there, so a run into a
+// missing folder or a read-only share fails at once with an error saying
+// what to fix instead of rclone failing on every file. Called after
+// failover, so a push checks the destination it actually goes to.
+func (s *SyncService) checkDestinations(ctx context.Context, task *SyncTask) error {
+	for _, dest := range rclone.WriteDestinations(string(task.Action), task.Profile) {
+		if err := checkDestination(ctx, dest, !task.Profile.DryRun); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/rclone"
+	"errors"
+	"testing"
+)
+
+func TestSyncService_CheckDestinations(t *testing.T) {
+	s := NewSyncService(nil)
+
+	checked := map[string]bool{}
+	defer func(c func(context.Context, string, bool) error) { checkDestination = c }(checkDestination)
+	checkDestination = func(ctx context.Context, path string, write bool) error {
+		checked[path] = write
+		if path == "nas:readonly" {
+			return &rclone.DestinationNotWritableError{Path: path, Err: errors.New("permission denied")}
+		}
+		return nil
+	}
+
+	task := &SyncTask{Id: 1, Action: ActionPush}
+	task.Profile.From, task.Profile.To, task.Profile.FanOutTo = "/home/docs", "gdrive:docs", []string{"nas:readonly"}
+	var notWritable *rclone.DestinationNotWritableError
+	if err := s.checkDestinations(context.Background(), task); !errors.As(err, &notWritable) {
+		t.Errorf("expected the read-only fan-out destination to fail the run, got %v", err)
+	}
+	if checked["/home/docs"] || !checked["gdrive:docs"] {
+		t.Errorf("a push should write test its destinations only, checked %v", checked)
+	}
+
+	// Pulls write into the source; dry runs only check it exists
+	checked = map[string]bool{}
+	task = &SyncTask{Id: 2, Action: ActionPull}
+	task.Profile.From, task.Profile.To, task.Profile.DryRun = "/home/docs", "gdrive:docs", true
+	if err := s.checkDestinations(context.Background(), task); err != nil {
+		t.Fatal(err)
+	}
+	if write, ok := checked["/home/docs"]; !ok || write || len(checked) != 1 {
+		t.Errorf("a dry-run pull should only check that its source exists, checked %v", checked)
+	}
+}
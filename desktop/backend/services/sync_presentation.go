@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// presentationCheckInterval is how often the sync service checks whether the user is presenting
+const presentationCheckInterval = 30 * time.Second
+
+// detectPresentation reports whether the user is presenting, runs a
+// full-screen app or has Do Not Disturb on. Tests replace it.
+var detectPresentation = presentationActive
+
+// IsPresenting reports whether syncs are deferred or throttled, and
+// notifications held back, because the user is presenting
+func (s *SyncService) IsPresenting(ctx context.Context) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.presentation != ""
+}
+
+// watchPresentation checks whether the user is presenting until ctx is done
+func (s *SyncService) watchPresentation(ctx context.Context) {
+	ticker := time.NewTicker(presentationCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.applyPresentation("", "")
+			return
+		case <-ticker.C:
+			s.checkPresentation()
+		}
+	}
+}
+
+// checkPresentation defers or throttles syncs, as the presentation mode
+// setting says, while the user presents, and lifts it once they stop
+func (s *SyncService) checkPresentation() {
+	mode, limit := PresentationOff, ""
+	if s.settingsService != nil {
+		mode, limit = s.settingsService.presentationSettings()
+	}
+	if mode == "" || mode == PresentationOff {
+		s.applyPresentation("", "")
+		return
+	}
+
+	presenting, err := detectPresentation()
+	s.mutex.Lock()
+	if err != nil && !s.presentationErr {
+		log.Printf("[SyncService] Could not tell whether the user is presenting: %v", err)
+	}
+	s.presentationErr = err != nil
+	s.mutex.Unlock()
+
+	if presenting {
+		s.applyPresentation(mode, limit)
+	} else {
+		s.applyPresentation("", "")
+	}
+}
+
+// applyPresentation switches syncs to a presentation mode ("" when not
+// presenting), undoing the mode applied before
+func (s *SyncService) applyPresentation(mode, limit string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if mode != PresentationThrottle {
+		limit = ""
+	}
+	if s.presentation == mode && s.presentationLimit == limit {
+		return
+	}
+	background := func(task *SyncTask) bool { return task.Priority < PriorityManual }
+
+	switch s.presentation {
+	case PresentationDefer:
+		s.holdTasksLocked(background, holdPresentation, false)
+	}
+
+	s.presentation, s.presentationLimit = mode, limit
+	if err := s.applyBandwidthLimitsLocked(); err != nil {
+		log.Printf("[SyncService] Could not apply the presentation bandwidth limit: %v", err)
+	}
+	switch mode {
+	case PresentationDefer:
+		log.Printf("[SyncService] Presenting: deferring scheduled and background syncs")
+		s.holdTasksLocked(background, holdPresentation, true)
+	case PresentationThrottle:
+		log.Printf("[SyncService] Presenting: limiting transfers to %s/s", limit)
+	default:
+		log.Printf("[SyncService] No longer presenting: syncs run normally")
+	}
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSyncService_PresentationDefer(t *testing.T) {
+	presenting := true
+	saved := detectPresentation
+	detectPresentation = func() (bool, error) { return presenting, nil }
+	defer func() { detectPresentation = saved }()
+
+	settings := NewSettingsService(nil)
+	settings.settings.PresentationMode = PresentationDefer
+	settings.settings.MaxConcurrentTasks = 1 // the manual task keeps the slot, so nothing launches
+	notifications := NewNotificationService(nil)
+	s := NewSyncService(nil)
+	s.settingsService = settings
+	s.notificationService = notifications
+	notifications.syncService = s
+
+	cancelled := false
+	s.activeTasks[1] = &SyncTask{Id: 1, Status: "running", running: true, Priority: PriorityScheduled, Cancel: func() { cancelled = true }}
+	s.activeTasks[2] = &SyncTask{Id: 2, Status: "running", running: true, Priority: PriorityManual}
+
+	s.checkPresentation()
+	if !s.IsPresenting(context.Background()) {
+		t.Fatal("expected presentation mode to be on")
+	}
+	if !cancelled || s.activeTasks[1].holds&holdPresentation == 0 {
+		t.Error("scheduled task should be paused while presenting")
+	}
+	if s.activeTasks[2].holds != 0 {
+		t.Error("manual task should keep running while presenting")
+	}
+	if !notifications.notificationSnoozed(NotifyCategorySyncCompleted, NotifySeverityInfo) {
+		t.Error("completion notifications should be held back while presenting")
+	}
+
+	presenting = false
+	s.activeTasks[1].running = false
+	s.activeTasks[1].Status = "paused"
+	s.activeTasks[1].preempted = false
+	s.checkPresentation()
+	if s.IsPresenting(context.Background()) || s.activeTasks[1].holds != 0 || s.activeTasks[1].Status != "queued" {
+		t.Error("expected syncs to resume after the presentation")
+	}
+}
+
+func TestSettingsService_SetPresentationMode(t *testing.T) {
+	s := NewSettingsService(nil)
+	ctx := context.Background()
+	if err := s.SetPresentationMode(ctx, "loud", ""); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+	if err := s.SetPresentationMode(ctx, PresentationThrottle, "fast"); err == nil {
+		t.Error("expected an error for an invalid bandwidth limit")
+	}
+	if err := s.SetPresentationMode(ctx, PresentationThrottle, ""); err != nil {
+		t.Fatalf("SetPresentationMode: %v", err)
+	}
+	if mode, limit := s.presentationSettings(); mode != PresentationThrottle || limit != defaultPresentationBwLimit {
+		t.Errorf("settings = %s, %s", mode, limit)
+	}
+}
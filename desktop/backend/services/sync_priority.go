@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/events"
+	"log"
+	"sort"
+)
+
+// TaskPriority orders sync tasks competing for a run slot; higher runs first
+type TaskPriority int
+
+const (
+	PriorityBackground TaskPriority = iota // background catch-up work (e.g. delta catch-ups)
+	PriorityScheduled                      // runs started by the scheduler
+	PriorityManual                         // runs started by the user
+)
+
+// String returns the priority name used in logs and events
+func (p TaskPriority) String() string {
+	switch p {
+	case PriorityBackground:
+		return "background"
+	case PriorityScheduled:
+		return "scheduled"
+	default:
+		return "manual"
+	}
+}
+
+type taskPriorityKey struct{}
+
+// WithTaskPriority returns a context whose sync tasks start with the given
+// priority. Callers that start syncs on behalf of someone else (scheduler,
+// board runs, background catch-ups) use this; StartSync defaults to manual.
+func WithTaskPriority(ctx context.Context, priority TaskPriority) context.Context {
+	return context.WithValue(ctx, taskPriorityKey{}, priority)
+}
+
+// TaskPriorityFromContext returns the priority set by WithTaskPriority,
+// or PriorityManual if none was set
+func TaskPriorityFromContext(ctx context.Context) TaskPriority {
+	if ctx != nil {
+		if p, ok := ctx.Value(taskPriorityKey{}).(TaskPriority); ok {
+			return p
+		}
+	}
+	return PriorityManual
+}
+
+// maxConcurrentTasks returns the configured run slot count (0 = unlimited)
+func (s *SyncService) maxConcurrentTasks() int {
+	if s.settingsService == nil {
+		return 0
+	}
+	return s.settingsService.GetMaxConcurrentTasks(context.Background())
+}
+
+// dispatchQueued starts queued tasks while run slots are free
+func (s *SyncService) dispatchQueued() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.dispatchQueuedLocked()
+}
+
+// dispatchQueuedLocked starts queued tasks, highest priority first and in
+// submission order within a priority. Caller must hold s.mutex.
+func (s *SyncService) dispatchQueuedLocked() {
+	if s.shuttingDown {
+		return
+	}
+	max := s.maxConcurrentTasks()
+
+	running := 0
+	var queued []*SyncTask
+	for _, task := range s.activeTasks {
+		if task.running {
+			running++
+		} else if task.Status == "queued" {
+			queued = append(queued, task)
+		}
+	}
+
+	sort.Slice(queued, func(i, j int) bool {
+		if queued[i].Priority != queued[j].Priority {
+			return queued[i].Priority > queued[j].Priority
+		}
+		return queued[i].Id < queued[j].Id
+	})
+
+	for _, task := range queued {
+		if max > 0 && running >= max {
+			return
+		}
+		s.launchTaskLocked(task)
+		running++
+	}
+}
+
+// launchTaskLocked starts (or resumes) a task in its own goroutine.
+// Caller must hold s.mutex.
+func (s *SyncService) launchTaskLocked(task *SyncTask) {
+	taskCtx, cancel := context.WithCancel(task.parentCtx)
+	task.Cancel = cancel
+	task.running = true
+	if task.transfers > 0 {
+		task.Profile.Parallel = task.transfers
+	}
+	saveRunMarker(task)
+
+	if task.resumed {
+		task.Status = "running"
+		s.emitSyncEvent(events.SyncResumed, task.TabId, string(task.Action), "running", "Sync operation resumed")
+	} else {
+		task.Status = "starting"
+		s.emitSyncEvent(events.SyncStarted, task.TabId, string(task.Action), "starting", "Sync operation started")
+	}
+
+	go s.executeSyncTask(taskCtx, task)
+}
+
+// preemptLowerPriorityLocked pauses running tasks with a lower priority than
+// the given task. Bisync runs are never preempted, since interrupting them can
+// force a resync. Caller must hold s.mutex.
+func (s *SyncService) preemptLowerPriorityLocked(by *SyncTask) {
+	for _, task := range s.activeTasks {
+		if !task.running || task.Priority >= by.Priority || task.preempted {
+			continue
+		}
+		if task.Action == ActionBi || task.Action == ActionBiResync {
+			continue
+		}
+
+		log.Printf("[SyncService] Task %d (%s) preempted by task %d (%s)", task.Id, task.Priority, by.Id, by.Priority)
+		task.preempted = true
+		task.preemptedBy = by.Id
+		if task.Cancel != nil {
+			task.Cancel()
+		}
+	}
+}
+
+// pauseIfPreempted parks a task whose run was cancelled by preemption.
+// Returns true if the task was paused rather than cancelled. While the app
+// exits, preempted tasks end as cancelled, since nothing would resume them.
+func (s *SyncService) pauseIfPreempted(task *SyncTask) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !task.preempted || s.shuttingDown {
+		task.preempted = false
+		return false
+	}
+
+	task.preempted = false
+	task.running = false
+	task.resumed = true
+	// The preempting task may already be gone; resume right away in that case
+	if _, exists := s.activeTasks[task.preemptedBy]; exists {
+		task.Status = "paused"
+		s.emitSyncEvent(events.SyncPaused, task.TabId, string(task.Action), "paused", "Sync operation paused for a higher-priority run")
+	} else if task.holds != 0 {
+		task.preemptedBy = 0
+		task.Status = "paused"
+		s.emitSyncEvent(events.SyncPaused, task.TabId, string(task.Action), "paused", task.holds.message())
+	} else {
+		task.preemptedBy = 0
+		task.Status = "queued"
+	}
+	s.dispatchQueuedLocked()
+	return true
+}
+
+// releaseTaskLocked removes a finished task, requeues tasks it preempted and
+// starts whatever can run next. Caller must hold s.mutex.
+func (s *SyncService) releaseTaskLocked(task *SyncTask) {
+	task.running = false
+	delete(s.activeTasks, task.Id)
+	clearRunMarker(task)
+	if task.bwLimit != "" {
+		if err := s.applyBandwidthLimitsLocked(); err != nil {
+			log.Printf("[SyncService] Could not lift the bandwidth limit of task %d: %v", task.Id, err)
+		}
+	}
+
+	for _, other := range s.activeTasks {
+		if other.preemptedBy == task.Id {
+			other.preemptedBy = 0
+			if other.Status == "paused" && other.holds == 0 {
+				other.Status = "queued"
+			}
+		}
+	}
+	s.dispatchQueuedLocked()
+}
+
+// taskHold is a reason a task is paused until released; see holdTasksLocked
+type taskHold uint8
+
+const (
+	holdBlockingProcess taskHold = 1 << iota // a blocking application of its schedule runs
+	holdPresentation                         // the user is presenting, see PresentationDefer
+)
+
+// message describes why a held task is paused, for the paused event
+func (h taskHold) message() string {
+	if h&holdPresentation != 0 {
+		return "Sync operation paused while presenting"
+	}
+	return "Sync operation paused while a blocking application runs"
+}
+
+// holdScheduleTasks pauses (hold) or resumes the tasks started by a schedule's
+// run while one of its blocking applications runs. Returns how many tasks changed.
+func (s *SyncService) holdScheduleTasks(scheduleId string, hold bool) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.holdTasksLocked(func(task *SyncTask) bool {
+		return scheduleRunFromContext(task.parentCtx) == scheduleId
+	}, holdBlockingProcess, hold)
+}
+
+// holdTasksLocked adds (hold) or removes a hold on the matching tasks. Held
+// tasks pause like preempted ones and resume once their last hold is
+// removed; like preemption, bisync runs are never interrupted. Returns how
+// many tasks changed. Caller must hold s.mutex.
+func (s *SyncService) holdTasksLocked(match func(*SyncTask) bool, reason taskHold, hold bool) int {
+	changed := 0
+	for _, task := range s.activeTasks {
+		if (task.holds&reason != 0) == hold || !match(task) {
+			continue
+		}
+		if hold && task.running && (task.Action == ActionBi || task.Action == ActionBiResync) {
+			continue
+		}
+
+		wasHeld := task.holds != 0
+		if hold {
+			task.holds |= reason
+		} else {
+			task.holds &^= reason
+		}
+		changed++
+		switch {
+		case hold && wasHeld:
+			// already paused for another reason
+		case hold && task.running:
+			log.Printf("[SyncService] Task %d pausing: %s", task.Id, task.holds.message())
+			task.preempted = true
+			if task.Cancel != nil {
+				task.Cancel()
+			}
+		case hold && task.Status == "queued":
+			task.Status = "paused"
+			s.emitSyncEvent(events.SyncPaused, task.TabId, string(task.Action), "paused", task.holds.message())
+		case !hold && task.holds == 0 && task.Status == "paused" && task.preemptedBy == 0:
+			log.Printf("[SyncService] Task %d resuming", task.Id)
+			task.Status = "queued"
+		}
+	}
+	if !hold {
+		s.dispatchQueuedLocked()
+	}
+	return changed
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTaskPriorityFromContext(t *testing.T) {
+	ctx := context.Background()
+	if got := TaskPriorityFromContext(ctx); got != PriorityManual {
+		t.Errorf("default priority = %s, want manual", got)
+	}
+
+	scheduled := WithTaskPriority(ctx, PriorityScheduled)
+	if got := TaskPriorityFromContext(scheduled); got != PriorityScheduled {
+		t.Errorf("priority = %s, want scheduled", got)
+	}
+
+	// Derived contexts keep the priority
+	derived, cancel := context.WithCancel(scheduled)
+	defer cancel()
+	if got := TaskPriorityFromContext(derived); got != PriorityScheduled {
+		t.Errorf("derived priority = %s, want scheduled", got)
+	}
+}
+
+func TestSyncService_QueueRespectsLimit(t *testing.T) {
+	settings := NewSettingsService(nil)
+	settings.settings.MaxConcurrentTasks = 1
+
+	s := NewSyncService(nil)
+	s.settingsService = settings
+
+	// One task already holds the only run slot
+	s.activeTasks[1] = &SyncTask{Id: 1, Status: "running", Priority: PriorityScheduled, running: true}
+	s.activeTasks[2] = &SyncTask{Id: 2, Status: "queued", Priority: PriorityManual, Done: make(chan error, 1)}
+
+	s.dispatchQueued()
+	if s.activeTasks[2].running {
+		t.Fatal("queued task started while the run slot was taken")
+	}
+
+	// Preempting marks the lower-priority task without touching equal/higher ones
+	s.mutex.Lock()
+	s.preemptLowerPriorityLocked(&SyncTask{Id: 3, Priority: PriorityScheduled})
+	s.mutex.Unlock()
+	if s.activeTasks[1].preempted {
+		t.Error("task with equal priority should not be preempted")
+	}
+
+	s.mutex.Lock()
+	s.preemptLowerPriorityLocked(s.activeTasks[2])
+	s.mutex.Unlock()
+	if !s.activeTasks[1].preempted || s.activeTasks[1].preemptedBy != 2 {
+		t.Error("lower-priority running task should be preempted by the manual task")
+	}
+}
+
+func TestSyncService_HoldScheduleTasks(t *testing.T) {
+	s := NewSyncService(nil)
+	scheduled := withScheduleRun(context.Background(), "nightly")
+	cancelled := false
+	s.activeTasks[1] = &SyncTask{Id: 1, Status: "running", running: true, parentCtx: scheduled, Cancel: func() { cancelled = true }}
+	s.activeTasks[2] = &SyncTask{Id: 2, Status: "queued", parentCtx: scheduled, Done: make(chan error, 1)}
+	s.activeTasks[3] = &SyncTask{Id: 3, Status: "running", running: true, parentCtx: context.Background()}
+	s.activeTasks[4] = &SyncTask{Id: 4, Status: "running", running: true, Action: ActionBi, parentCtx: scheduled}
+
+	if n := s.holdScheduleTasks("nightly", true); n != 2 {
+		t.Errorf("held %d tasks, want 2", n)
+	}
+	if !cancelled || !s.activeTasks[1].preempted {
+		t.Error("running task of the schedule should be interrupted")
+	}
+	if s.activeTasks[2].Status != "paused" {
+		t.Errorf("queued task status = %s, want paused", s.activeTasks[2].Status)
+	}
+	if s.activeTasks[3].holds != 0 || s.activeTasks[4].holds != 0 {
+		t.Error("tasks of other runs and bisync runs should not be held")
+	}
+
+	// The interrupted task parks instead of requeueing while held
+	if !s.pauseIfPreempted(s.activeTasks[1]) || s.activeTasks[1].Status != "paused" {
+		t.Errorf("held task status = %s, want paused", s.activeTasks[1].Status)
+	}
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"log"
+)
+
+// rememberRawFlags keeps the raw rclone flags the task's run used, with
+// their risk, until the run is added to history
+func (s *SyncService) rememberRawFlags(task *SyncTask) {
+	flags, err := rclone.CheckRawFlags(task.Profile.RawFlags)
+	if err != nil {
+		// The run couldn't apply them either
+		log.Printf("[SyncService] Task %d has invalid raw flags: %v", task.Id, err)
+		flags = nil
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(flags) == 0 {
+		return
+	}
+	if s.rawFlagRuns == nil {
+		s.rawFlagRuns = make(map[int][]models.RawFlag)
+	}
+	s.rawFlagRuns[task.Id] = flags
+}
+
+// takeRawFlags returns and forgets the raw rclone flags of a task's run
+func (s *SyncService) takeRawFlags(taskId int) []models.RawFlag {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	flags := s.rawFlagRuns[taskId]
+	delete(s.rawFlagRuns, taskId)
+	return flags
+}
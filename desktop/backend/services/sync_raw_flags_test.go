@@ -0,0 +1,29 @@
+package services
+
+import (
+	"desktop/backend/models"
+	"testing"
+)
+
+func TestRawFlagRuns(t *testing.T) {
+	s := &SyncService{}
+	s.rememberRawFlags(&SyncTask{Id: 1, Profile: models.Profile{Name: "docs", RawFlags: []string{"--fast-list", "--ignore-errors"}}})
+
+	flags := s.takeRawFlags(1)
+	if len(flags) != 2 || flags[0].Risk != models.RawFlagPerformance || flags[1].Risk != models.RawFlagDestructive {
+		t.Fatalf("takeRawFlags() = %+v", flags)
+	}
+	if s.takeRawFlags(1) != nil {
+		t.Error("expected the raw flags to be forgotten once taken")
+	}
+
+	// Two runs of the same profile at once keep their own flags
+	s.rememberRawFlags(&SyncTask{Id: 2, Profile: models.Profile{Name: "docs", RawFlags: []string{"--fast-list"}}})
+	s.rememberRawFlags(&SyncTask{Id: 3, Profile: models.Profile{Name: "docs"}})
+	if flags := s.takeRawFlags(3); flags != nil {
+		t.Errorf("takeRawFlags(3) = %+v, want none", flags)
+	}
+	if flags := s.takeRawFlags(2); len(flags) != 1 || flags[0].Name != "fast_list" {
+		t.Errorf("takeRawFlags(2) = %+v, want --fast-list", flags)
+	}
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/events"
+	"desktop/backend/rclone"
+	"fmt"
+	"log"
+)
+
+// maxTaskTransfers caps the transfers UpdateRunningTask sets, like the
+// profile's parallel setting
+const maxTaskTransfers = 256
+
+// maxTaskBandwidth caps the bandwidth limit UpdateRunningTask sets, in MB/s,
+// like the profile's bandwidth setting
+const maxTaskBandwidth = 10000
+
+// TaskUpdate holds the settings UpdateRunningTask changes on a task. Unset
+// fields are left as they are.
+type TaskUpdate struct {
+	Bandwidth *int `json:"bandwidth,omitempty"` // limit in MB/s; 0 removes it
+	Transfers int  `json:"transfers,omitempty"` // parallel file transfers
+}
+
+// UpdateRunningTask changes the bandwidth limit and transfers of an active
+// task without cancelling it, e.g. to throttle a sync during a video call.
+// The bandwidth limit applies right away. rclone shares its limiter between
+// all transfers, so while the task is active the lowest limit of all tasks
+// applies to every run. Transfers can't change mid-run: a running task is
+// restarted with the new count, skipping the files it already copied.
+// Running two-way syncs only take bandwidth changes, since interrupting them
+// can force a resync.
+func (s *SyncService) UpdateRunningTask(ctx context.Context, taskId int, update TaskUpdate) error {
+	if update.Bandwidth == nil && update.Transfers == 0 {
+		return fmt.Errorf("nothing to update")
+	}
+	if update.Bandwidth != nil && (*update.Bandwidth < 0 || *update.Bandwidth > maxTaskBandwidth) {
+		return fmt.Errorf("bandwidth must be between 0 and %d MB/s", maxTaskBandwidth)
+	}
+	if update.Transfers < 0 || update.Transfers > maxTaskTransfers {
+		return fmt.Errorf("transfers must be between 1 and %d", maxTaskTransfers)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	task, exists := s.activeTasks[taskId]
+	if !exists {
+		return fmt.Errorf("task %d not found", taskId)
+	}
+	bisync := task.Action == ActionBi || task.Action == ActionBiResync
+	if update.Transfers > 0 && task.running && bisync {
+		return fmt.Errorf("transfers of a running two-way sync can't be changed")
+	}
+
+	if update.Bandwidth != nil {
+		previous := task.bwLimit
+		task.bwLimit = ""
+		if *update.Bandwidth > 0 {
+			task.bwLimit = fmt.Sprint(*update.Bandwidth) + "M"
+		}
+		if err := s.applyBandwidthLimitsLocked(); err != nil {
+			task.bwLimit = previous
+			return err
+		}
+		task.Profile.Bandwidth = *update.Bandwidth
+		log.Printf("[SyncService] Task %d bandwidth limit set to %d MB/s", task.Id, *update.Bandwidth)
+	}
+
+	if update.Transfers > 0 && update.Transfers != task.transfers {
+		task.transfers = update.Transfers
+		log.Printf("[SyncService] Task %d transfers set to %d", task.Id, update.Transfers)
+		// Restarted through the preemption path, which resumes it right away
+		if task.running && !task.preempted {
+			task.preempted = true
+			if task.Cancel != nil {
+				task.Cancel()
+			}
+		}
+	}
+
+	s.emitSyncEvent(events.SyncProgress, task.TabId, string(task.Action), task.Status, "Sync settings updated")
+	return nil
+}
+
+// applyBandwidthLimitsLocked sets rclone's shared bandwidth limiter to the
+// lowest of the presentation throttle limit and the limits set on active
+// tasks, or lifts it when there are none. Caller must hold s.mutex.
+func (s *SyncService) applyBandwidthLimitsLocked() error {
+	var limits []string
+	if s.presentation == PresentationThrottle {
+		limits = append(limits, s.presentationLimit)
+	}
+	for _, task := range s.activeTasks {
+		limits = append(limits, task.bwLimit)
+	}
+	if err := rclone.SetGlobalBandwidthLimit(limits...); err != nil {
+		return fmt.Errorf("failed to apply the bandwidth limit: %w", err)
+	}
+	return nil
+}
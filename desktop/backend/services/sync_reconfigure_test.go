@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/rclone"
+	"testing"
+)
+
+func TestSyncService_UpdateRunningTask(t *testing.T) {
+	defer rclone.SetGlobalBandwidthLimit("")
+	ctx := context.Background()
+	s := NewSyncService(nil)
+	cancelled := false
+	s.activeTasks[1] = &SyncTask{Id: 1, Status: "running", running: true, Action: ActionPush, Cancel: func() { cancelled = true }}
+	s.activeTasks[2] = &SyncTask{Id: 2, Status: "running", running: true, Action: ActionBi}
+
+	limit := 2
+	if err := s.UpdateRunningTask(ctx, 1, TaskUpdate{Bandwidth: &limit}); err != nil {
+		t.Fatalf("UpdateRunningTask(bandwidth): %v", err)
+	}
+	if s.activeTasks[1].bwLimit != "2M" || cancelled {
+		t.Errorf("bandwidth limit = %q, cancelled = %v; want 2M without a restart", s.activeTasks[1].bwLimit, cancelled)
+	}
+
+	if err := s.UpdateRunningTask(ctx, 1, TaskUpdate{Transfers: 8}); err != nil {
+		t.Fatalf("UpdateRunningTask(transfers): %v", err)
+	}
+	if !cancelled || !s.activeTasks[1].preempted || s.activeTasks[1].transfers != 8 {
+		t.Error("changing transfers should restart the run with the new count")
+	}
+
+	if err := s.UpdateRunningTask(ctx, 2, TaskUpdate{Transfers: 8}); err == nil {
+		t.Error("expected an error changing the transfers of a running bisync")
+	}
+	if err := s.UpdateRunningTask(ctx, 2, TaskUpdate{Bandwidth: &limit}); err != nil {
+		t.Errorf("bisync bandwidth change: %v", err)
+	}
+
+	negative := -1
+	for _, update := range []TaskUpdate{{}, {Transfers: 1000}, {Bandwidth: &negative}} {
+		if err := s.UpdateRunningTask(ctx, 1, update); err == nil {
+			t.Errorf("expected an error for %+v", update)
+		}
+	}
+	if err := s.UpdateRunningTask(ctx, 9, TaskUpdate{Transfers: 4}); err == nil {
+		t.Error("expected an error for an unknown task")
+	}
+}
@@ -0,0 +1,337 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"desktop/backend/events"
+	"desktop/backend/models"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	fsConfig "github.com/rclone/rclone/fs/config"
+	"github.com/rclone/rclone/fs/config/obscure"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// defaultSSHHookTimeout applies to SSH hooks that don't set a timeout
+const defaultSSHHookTimeout = 5 * time.Minute
+
+// maxRemoteHookOutput caps how much of an SSH command's output is kept
+const maxRemoteHookOutput = 1024
+
+// remoteHookVariables are the run variables hooks can use
+var remoteHookVariables = []string{"PROFILE_NAME", "ACTION", "STATUS", "FILES_TRANSFERRED", "BYTES_TRANSFERRED"}
+
+// shellQuote quotes a string for safe use in a POSIX shell script
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// runsRemoteHooks reports whether the task's run uploads to the destination,
+// so its remote hooks apply
+func (t *SyncTask) runsRemoteHooks() bool {
+	return len(t.Profile.RemoteHooks) > 0 &&
+		(t.Action == ActionPush || t.Action == ActionBi || t.Action == ActionBiResync)
+}
+
+// runRemoteHooks runs the profile's remote hooks once the run has ended with
+// status, in order. Only hooks set to run always follow a failed run. A hook
+// that fails is reported but doesn't fail the run, and the results are kept
+// for the task's history entry.
+func (s *SyncService) runRemoteHooks(ctx context.Context, task *SyncTask, status string) {
+	if !task.runsRemoteHooks() {
+		return
+	}
+	// Hooks run after the stopped run, e.g. one stopped for low disk space
+	ctx = context.WithoutCancel(ctx)
+
+	variables := map[string]string{
+		"PROFILE_NAME":      task.Profile.Name,
+		"ACTION":            string(task.Action),
+		"STATUS":            status,
+		"FILES_TRANSFERRED": "0",
+		"BYTES_TRANSFERRED": "0",
+	}
+	if st := task.latestStatus(); st != nil {
+		variables["FILES_TRANSFERRED"] = strconv.FormatInt(st.FilesTransferred, 10)
+		variables["BYTES_TRANSFERRED"] = strconv.FormatInt(st.BytesTransferred, 10)
+	}
+
+	var results []models.RemoteHookResult
+	for i, hook := range task.Profile.RemoteHooks {
+		if status != "completed" && hook.On != models.RemoteHookAlways {
+			continue
+		}
+		result := models.RemoteHookResult{Name: remoteHookName(hook, i), Kind: "http", StartedAt: time.Now()}
+		if hook.SSH != nil {
+			result.Kind = "ssh"
+		}
+		s.emitSyncEvent(events.SyncProgress, task.TabId, string(task.Action), "running",
+			fmt.Sprintf("Running remote hook '%s'", result.Name))
+
+		var err error
+		if hook.SSH != nil {
+			result.Output, err = runSSHHook(ctx, hook.SSH, variables)
+		} else {
+			err = runHTTPStep(ctx, models.Operation{HTTP: hook.HTTP}, variables, lookupSecret)
+		}
+		result.DurationMs = time.Since(result.StartedAt).Milliseconds()
+		result.Status = "completed"
+		if err != nil {
+			result.Status = "failed"
+			result.Error = err.Error()
+			log.Printf("[SyncService] Remote hook '%s' of task %d failed: %v", result.Name, task.Id, err)
+			s.emitSyncEvent(events.SyncProgress, task.TabId, string(task.Action), "running",
+				fmt.Sprintf("Remote hook '%s' failed: %v", result.Name, err))
+		}
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.remoteHookRuns == nil {
+		s.remoteHookRuns = make(map[int][]models.RemoteHookResult)
+	}
+	s.remoteHookRuns[task.Id] = results
+}
+
+// takeRemoteHookResults returns and forgets the remote hook results of a task's run
+func (s *SyncService) takeRemoteHookResults(taskId int) []models.RemoteHookResult {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	results := s.remoteHookRuns[taskId]
+	delete(s.remoteHookRuns, taskId)
+	return results
+}
+
+// remoteHookName names a hook in events and history
+func remoteHookName(hook models.RemoteHook, i int) string {
+	if name := strings.TrimSpace(hook.Name); name != "" {
+		return name
+	}
+	return fmt.Sprintf("hook %d", i+1)
+}
+
+// runSSHHook runs a hook's command on the server of its SFTP remote and
+// returns the start of its output. Run variables are quoted for the shell.
+func runSSHHook(ctx context.Context, hook *models.SSHHook, variables map[string]string) (string, error) {
+	quoted := make(map[string]string, len(variables))
+	for name, value := range variables {
+		quoted[name] = shellQuote(value)
+	}
+	command, err := expandRunVariables(hook.Command, quoted)
+	if err != nil {
+		return "", fmt.Errorf("command: %w", err)
+	}
+
+	timeout := defaultSSHHookTimeout
+	if hook.TimeoutSeconds > 0 {
+		timeout = time.Duration(hook.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client, err := dialSSHRemote(ctx, hook.Remote)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+	// Closing the connection stops a command that runs past the timeout
+	stop := context.AfterFunc(ctx, func() { client.Close() })
+	defer stop()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return "", fmt.Errorf("failed to open an ssh session: %w", err)
+	}
+	defer session.Close()
+	var output bytes.Buffer
+	session.Stdout = &output
+	session.Stderr = &output
+	err = session.Run(command)
+
+	out := strings.TrimSpace(output.String())
+	if len(out) > maxRemoteHookOutput {
+		out = out[:maxRemoteHookOutput]
+	}
+	if ctx.Err() != nil {
+		return out, fmt.Errorf("command timed out after %s", timeout)
+	}
+	if err != nil {
+		return out, fmt.Errorf("command failed: %w", err)
+	}
+	return out, nil
+}
+
+// dialSSHRemote connects to the server of an SFTP remote with its host,
+// port, user and key or password. Like the SFTP backend, the host key is
+// checked only when the remote has a known_hosts_file.
+func dialSSHRemote(ctx context.Context, remote string) (*ssh.Client, error) {
+	get := func(key string) string {
+		value, _ := fsConfig.FileGetValue(remote, key)
+		return value
+	}
+	if remoteType := get("type"); remoteType != "sftp" {
+		return nil, fmt.Errorf("remote '%s' is not an sftp remote", remote)
+	}
+	host := get("host")
+	if host == "" {
+		return nil, fmt.Errorf("remote '%s' has no host", remote)
+	}
+	port := get("port")
+	if port == "" {
+		port = "22"
+	}
+	user := get("user")
+	if user == "" {
+		user = os.Getenv("USER")
+		if user == "" {
+			user = os.Getenv("USERNAME")
+		}
+	}
+
+	auth, err := sshRemoteAuth(get)
+	if err != nil {
+		return nil, fmt.Errorf("remote '%s': %w", remote, err)
+	}
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if file := get("known_hosts_file"); file != "" {
+		if hostKeyCallback, err = knownhosts.New(expandHome(file)); err != nil {
+			return nil, fmt.Errorf("remote '%s': failed to read known hosts: %w", remote, err)
+		}
+	}
+
+	addr := net.JoinHostPort(host, port)
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, &ssh.ClientConfig{
+		User:            user,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("ssh handshake with %s failed: %w", addr, err)
+	}
+	// The command's own timeout applies from here
+	conn.SetDeadline(time.Time{})
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// sshRemoteAuth returns the auth methods of an SFTP remote: its key, inline
+// or from a file, then its password
+func sshRemoteAuth(get func(key string) string) ([]ssh.AuthMethod, error) {
+	var methods []ssh.AuthMethod
+
+	var key []byte
+	if pem := get("key_pem"); pem != "" {
+		// The config stores the key on one line
+		key = []byte(strings.ReplaceAll(pem, `\n`, "\n"))
+	} else if file := get("key_file"); file != "" {
+		data, err := os.ReadFile(expandHome(file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+		key = data
+	}
+	if key != nil {
+		var signer ssh.Signer
+		var err error
+		if pass := get("key_file_pass"); pass != "" {
+			passphrase, revealErr := obscure.Reveal(pass)
+			if revealErr != nil {
+				return nil, fmt.Errorf("failed to decode key passphrase: %w", revealErr)
+			}
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+
+	if pass := get("pass"); pass != "" {
+		password, err := obscure.Reveal(pass)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode password: %w", err)
+		}
+		methods = append(methods, ssh.Password(password))
+	}
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("no key or password configured")
+	}
+	return methods, nil
+}
+
+// expandHome expands a leading ~ in a path from the rclone config
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// validateRemoteHooks checks that each hook is an HTTP request or an SSH
+// command, runs at a known time and uses only known run variables
+func validateRemoteHooks(hooks []models.RemoteHook) error {
+	known := make(map[string]string, len(remoteHookVariables))
+	for _, name := range remoteHookVariables {
+		known[name] = ""
+	}
+	for i, hook := range hooks {
+		name := remoteHookName(hook, i)
+		if hook.On != models.RemoteHookOnSuccess && hook.On != models.RemoteHookAlways {
+			return fmt.Errorf("%s: unknown 'on' value '%s'", name, hook.On)
+		}
+		if (hook.HTTP == nil) == (hook.SSH == nil) {
+			return fmt.Errorf("%s: set either an http request or an ssh command", name)
+		}
+		if hook.HTTP != nil {
+			if strings.TrimSpace(hook.HTTP.URL) == "" {
+				return fmt.Errorf("%s: url is required", name)
+			}
+			for _, field := range []string{hook.HTTP.URL, hook.HTTP.Body} {
+				if _, err := expandRunVariables(field, known); err != nil {
+					return fmt.Errorf("%s: %w", name, err)
+				}
+			}
+			for _, h := range hook.HTTP.Headers {
+				if strings.TrimSpace(h.Name) == "" {
+					return fmt.Errorf("%s: header name is required", name)
+				}
+			}
+			continue
+		}
+		if strings.TrimSpace(hook.SSH.Remote) == "" {
+			return fmt.Errorf("%s: remote is required", name)
+		}
+		if strings.TrimSpace(hook.SSH.Command) == "" {
+			return fmt.Errorf("%s: command is required", name)
+		}
+		if _, err := expandRunVariables(hook.SSH.Command, known); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
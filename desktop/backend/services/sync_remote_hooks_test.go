@@ -0,0 +1,85 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"desktop/backend/models"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/rclone/rclone/fs/config/obscure"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestValidateRemoteHooks(t *testing.T) {
+	tests := []struct {
+		name    string
+		hook    models.RemoteHook
+		wantErr string
+	}{
+		{"http", models.RemoteHook{HTTP: &models.HTTPStep{URL: "http://nas.local:5572/core/command", Body: `{"profile":"${PROFILE_NAME}"}`}}, ""},
+		{"ssh always", models.RemoteHook{On: models.RemoteHookAlways, SSH: &models.SSHHook{Remote: "nas", Command: "snapshot-create ${STATUS}"}}, ""},
+		{"neither", models.RemoteHook{Name: "index"}, "index: set either an http request or an ssh command"},
+		{"both", models.RemoteHook{HTTP: &models.HTTPStep{URL: "http://nas"}, SSH: &models.SSHHook{Remote: "nas", Command: "ls"}}, "either"},
+		{"unknown on", models.RemoteHook{On: "sometimes", SSH: &models.SSHHook{Remote: "nas", Command: "ls"}}, "unknown 'on' value"},
+		{"no remote", models.RemoteHook{SSH: &models.SSHHook{Command: "ls"}}, "remote is required"},
+		{"unknown variable", models.RemoteHook{SSH: &models.SSHHook{Remote: "nas", Command: "index ${FLOW_NAME}"}}, "unknown run variables: FLOW_NAME"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRemoteHooks([]models.RemoteHook{tt.hook})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSSHHookCommandQuoting(t *testing.T) {
+	quoted := map[string]string{"PROFILE_NAME": shellQuote("it's mine; rm -rf /")}
+	command, err := expandRunVariables("synoindex -R ${PROFILE_NAME}", quoted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `synoindex -R 'it'\''s mine; rm -rf /'`; command != want {
+		t.Errorf("command = %s, want %s", command, want)
+	}
+}
+
+func TestSSHRemoteAuth(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pass, err := obscure.Obscure("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := map[string]string{
+		// rclone stores an inline key on one line
+		"key_pem": strings.ReplaceAll(string(pem.EncodeToMemory(block)), "\n", `\n`),
+		"pass":    pass,
+	}
+	methods, err := sshRemoteAuth(func(key string) string { return config[key] })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(methods) != 2 {
+		t.Errorf("got %d auth methods, want the key and the password", len(methods))
+	}
+
+	if _, err := sshRemoteAuth(func(string) string { return "" }); err == nil {
+		t.Error("expected an error for a remote without a key or password")
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/utils"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// restartMultiThreadStreams is the least number of streams a restarted file
+// is transferred with
+const restartMultiThreadStreams = 8
+
+// restartExitWait is how long RestartFileTransfer waits for the stopped
+// run to let go of the file
+const restartExitWait = 10 * time.Second
+
+// recordStall keeps track of the task's files whose transfer is stalled
+func (t *SyncTask) recordStall(tr utils.TransferTransition) {
+	name := tr.Transfer.Name
+	if name == "" {
+		return
+	}
+	t.failedMu.Lock()
+	defer t.failedMu.Unlock()
+	if tr.Phase == utils.TransferStalled {
+		if t.stalledFiles == nil {
+			t.stalledFiles = make(map[string]struct{})
+		}
+		t.stalledFiles[name] = struct{}{}
+		return
+	}
+	delete(t.stalledFiles, name)
+}
+
+// canRestartFile reports whether a file's transfer is stalled, or failed, in the task
+func (t *SyncTask) canRestartFile(name string) bool {
+	t.failedMu.Lock()
+	defer t.failedMu.Unlock()
+	_, stalled := t.stalledFiles[name]
+	_, failed := t.failedFiles[name]
+	return stalled || failed
+}
+
+// RestartFileTransfer restarts a single stalled or failed file of a running
+// sync with multi-thread transfers. The run is stopped, the file is
+// transferred on its own with at least restartMultiThreadStreams streams,
+// and then the run starts again for the rest of its files; files it had
+// already transferred are only checked. Returns the single-file run.
+func (s *SyncService) RestartFileTransfer(ctx context.Context, taskId int, fileName string) (*SyncResult, error) {
+	s.mutex.Lock()
+	task, exists := s.activeTasks[taskId]
+	if !exists {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("task %d not found", taskId)
+	}
+	if strings.HasPrefix(task.TabId, "board-") {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("task %d is part of a board run; restart the board instead", taskId)
+	}
+	if !task.canRestartFile(fileName) {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("'%s' is not stalled or failed in task %d", fileName, taskId)
+	}
+	running := task.running
+	s.cancelTaskLocked(task)
+	s.mutex.Unlock()
+
+	// The stopped run must not be writing the file any more
+	if running && task.exited != nil {
+		select {
+		case <-task.exited:
+		case <-time.After(restartExitWait):
+			return nil, fmt.Errorf("task %d did not stop in time", taskId)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	profile := task.Profile
+	profile.UseRegex = false
+	profile.IncludedPaths = []string{"/" + escapeFilterGlob(fileName)}
+	if profile.MultiThreadStreams == nil || *profile.MultiThreadStreams < restartMultiThreadStreams {
+		streams := restartMultiThreadStreams
+		profile.MultiThreadStreams = &streams
+	}
+
+	log.Printf("[SyncService] Restarting %q of task %d with %d streams", fileName, taskId, *profile.MultiThreadStreams)
+	runCtx := WithTaskPriority(context.Background(), task.Priority)
+	result, err := s.StartSync(runCtx, string(task.Action), profile, task.TabId)
+	if err != nil {
+		return nil, err
+	}
+
+	// The rest of the run follows once the file is done
+	fileTask := s.getTask(result.TaskId)
+	go func() {
+		if fileTask != nil {
+			if err := <-fileTask.Done; err != nil {
+				log.Printf("[SyncService] Restarted file %q of task %d failed: %v", fileName, taskId, err)
+			}
+		}
+		if _, err := s.StartSync(runCtx, string(task.Action), task.Profile, task.TabId); err != nil {
+			log.Printf("[SyncService] Could not resume task %d after restarting %q: %v", taskId, fileName, err)
+		}
+	}()
+	return result, nil
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/dto"
+	"desktop/backend/utils"
+	"testing"
+)
+
+func TestSyncService_RestartFileTransferChecksFile(t *testing.T) {
+	s := NewSyncService(nil)
+	task := &SyncTask{Id: 1, Status: "running", TabId: "tab-1", Done: make(chan error, 1)}
+	s.activeTasks[1] = task
+
+	file := dto.FileTransferInfo{Name: "disk.img"}
+	task.recordStall(utils.TransferTransition{Phase: utils.TransferStalled, Transfer: file})
+	if !task.canRestartFile("disk.img") {
+		t.Error("a stalled file should be restartable")
+	}
+	task.recordStall(utils.TransferTransition{Phase: utils.TransferProgressed, Transfer: file})
+	if task.canRestartFile("disk.img") {
+		t.Error("a file that moves again should not be restartable")
+	}
+
+	if _, err := s.RestartFileTransfer(context.Background(), 1, "disk.img"); err == nil {
+		t.Error("expected restarting a file that is neither stalled nor failed to fail")
+	}
+	if s.getTask(1) == nil {
+		t.Error("the run should keep going when the restart is refused")
+	}
+	if _, err := s.RestartFileTransfer(context.Background(), 2, "disk.img"); err == nil {
+		t.Error("expected an unknown task to fail")
+	}
+}
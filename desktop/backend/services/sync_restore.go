@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/dto"
+	"desktop/backend/events"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"desktop/backend/validation"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"time"
+)
+
+// maxRestoreReports caps how many restore reports are kept
+const maxRestoreReports = 100
+
+// StartRestore rebuilds a local folder from a backup: it copies
+// opts.Source, a profile's destination or a snapshot dir of it, into the
+// local folder opts.Target. Nothing in the target is deleted and files
+// already there are kept as they are. When the backup has a manifest (see
+// Profile.WriteManifest), the restored files are checked against it. The
+// restore runs as a task like a sync; its report is recorded and emitted
+// with sync:restored once it ends. A restore whose files don't match the
+// manifest fails.
+//
+// opts.Files restores only the given files, such as ones picked in the file
+// browser; files the manifest doesn't list are checked against the backup.
+func (s *SyncService) StartRestore(ctx context.Context, opts models.RestoreOptions, tabId string) (*SyncResult, error) {
+	if err := validateRestoreOptions(opts); err != nil {
+		return nil, err
+	}
+	name := opts.ProfileName
+	if name == "" {
+		name = "restore"
+	}
+	profile := models.Profile{Name: name, From: opts.Source, To: opts.Target, Parallel: opts.Parallel}
+	if len(opts.Files) > 0 {
+		ctx = context.WithValue(ctx, restoreSelectionKey{}, restoreSelection{files: opts.Files, flatten: opts.Flatten})
+	}
+	return s.startSync(ctx, string(ActionRestore), profile, tabId, TaskPriorityFromContext(ctx), false)
+}
+
+type restoreSelectionKey struct{}
+
+// restoreSelection is the files a restore task is limited to
+type restoreSelection struct {
+	files   []string
+	flatten bool
+}
+
+// runRestore runs a restore task and records its report
+func (s *SyncService) runRestore(ctx context.Context, task *SyncTask, outStatus chan *dto.SyncStatusDTO) error {
+	start := time.Now()
+	sel, _ := task.parentCtx.Value(restoreSelectionKey{}).(restoreSelection)
+	report, err := rclone.Restore(ctx, s.envConfig, task.Profile, sel.files, sel.flatten, outStatus)
+	if ctx.Err() != nil {
+		return err
+	}
+	if report == nil {
+		report = &models.RestoreReport{ProfileName: task.Profile.Name, Source: task.Profile.From, Target: task.Profile.To}
+	}
+	report.StartTime = start
+	report.EndTime = time.Now()
+	switch {
+	case err != nil:
+		report.Status = "failed"
+		report.ErrorMessage = err.Error()
+	case report.Problems() > 0:
+		report.Status = "problems"
+		err = fmt.Errorf("%d restored files don't match the manifest and %d are missing", report.MismatchCount, report.MissingCount)
+	default:
+		report.Status = "ok"
+	}
+
+	if saveErr := saveRestoreReport(report); saveErr != nil {
+		log.Printf("warning: failed to record restore report of %s: %v", task.Profile.Name, saveErr)
+	}
+	log.Printf("Restored %s to %s: %s (%d files, %d verified, %d mismatched, %d missing)",
+		report.Source, report.Target, report.Status, report.FilesRestored, report.Verified, report.MismatchCount, report.MissingCount)
+	s.emitSyncEvent(events.SyncRestored, task.TabId, string(task.Action), report.Status,
+		fmt.Sprintf("Restored %d files from %s to %s", report.FilesRestored, report.Source, report.Target))
+	return err
+}
+
+// GetRestoreReports returns the recorded restore reports, most recent first
+func (s *SyncService) GetRestoreReports(ctx context.Context, limit int) ([]models.RestoreReport, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = maxRestoreReports
+	}
+
+	rows, err := db.Query(`SELECT id, profile_name, source, target, status, files_restored, bytes_restored,
+		manifest_run_id, hash_type, verified, mismatched, mismatch_count, missing, missing_count,
+		error_message, start_time, end_time
+		FROM restore_reports ORDER BY start_time DESC, id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query restore reports: %w", err)
+	}
+	defer rows.Close()
+
+	reports := []models.RestoreReport{}
+	for rows.Next() {
+		var r models.RestoreReport
+		var mismatched, missing, startTime, endTime string
+		if err := rows.Scan(&r.Id, &r.ProfileName, &r.Source, &r.Target, &r.Status, &r.FilesRestored, &r.BytesRestored,
+			&r.ManifestRunId, &r.HashType, &r.Verified, &mismatched, &r.MismatchCount, &missing, &r.MissingCount,
+			&r.ErrorMessage, &startTime, &endTime); err != nil {
+			return nil, fmt.Errorf("failed to scan restore report: %w", err)
+		}
+		r.Mismatched = unmarshalStringSlice(mismatched)
+		r.Missing = unmarshalStringSlice(missing)
+		r.StartTime, _ = time.Parse(time.RFC3339, startTime)
+		r.EndTime, _ = time.Parse(time.RFC3339, endTime)
+		reports = append(reports, r)
+	}
+	return reports, rows.Err()
+}
+
+// saveRestoreReport records a restore report, dropping the oldest reports
+// beyond maxRestoreReports
+func saveRestoreReport(r *models.RestoreReport) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	result, err := db.Exec(`INSERT INTO restore_reports (profile_name, source, target, status, files_restored, bytes_restored,
+		manifest_run_id, hash_type, verified, mismatched, mismatch_count, missing, missing_count,
+		error_message, start_time, end_time)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.ProfileName, r.Source, r.Target, r.Status, r.FilesRestored, r.BytesRestored,
+		r.ManifestRunId, r.HashType, r.Verified, marshalStringSlice(r.Mismatched), r.MismatchCount,
+		marshalStringSlice(r.Missing), r.MissingCount, r.ErrorMessage,
+		r.StartTime.UTC().Format(time.RFC3339), r.EndTime.UTC().Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+	r.Id, _ = result.LastInsertId()
+
+	_, _ = db.Exec(`DELETE FROM restore_reports WHERE id NOT IN (
+		SELECT id FROM restore_reports ORDER BY id DESC LIMIT ?
+	)`, maxRestoreReports)
+	return nil
+}
+
+// validateRestoreOptions checks that a restore reads a valid path and
+// writes to a local folder outside of it
+func validateRestoreOptions(opts models.RestoreOptions) error {
+	v := validation.NewProfileValidator()
+	if err := v.ValidateRclonePath(opts.Source, "source"); err != nil {
+		return err
+	}
+	if err := v.ValidateRclonePath(opts.Target, "target"); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(opts.Target, "/") && !(len(opts.Target) >= 2 && opts.Target[1] == ':') {
+		return &validation.ValidationError{Field: "target", Message: "must be a local folder"}
+	}
+	if pathsOverlap(opts.Source, opts.Target) {
+		return &validation.ValidationError{Field: "target", Message: "cannot be the source path or overlap with it"}
+	}
+	if opts.Parallel < 0 {
+		return &validation.ValidationError{Field: "parallel", Message: "cannot be negative"}
+	}
+
+	names := make(map[string]string, len(opts.Files))
+	for _, file := range opts.Files {
+		clean := path.Clean(strings.TrimPrefix(file, "/"))
+		if clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+			return &validation.ValidationError{Field: "files", Message: fmt.Sprintf("%q is not a file in the source", file)}
+		}
+		name := clean
+		if opts.Flatten {
+			name = path.Base(clean)
+		}
+		if other, ok := names[name]; ok && other != clean {
+			return &validation.ValidationError{Field: "files", Message: fmt.Sprintf("%s and %s would both be restored as %s", other, clean, name)}
+		}
+		names[name] = clean
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"desktop/backend/models"
+	"testing"
+)
+
+func TestValidateRestoreOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    models.RestoreOptions
+		wantErr bool
+	}{
+		{"whole backup", models.RestoreOptions{Source: "s3:backup", Target: "/home/me/restored"}, false},
+		{"remote target", models.RestoreOptions{Source: "s3:backup", Target: "nas:restored"}, true},
+		{"target inside source", models.RestoreOptions{Source: "/mnt/backup", Target: "/mnt/backup/restored"}, true},
+		{"selected files", models.RestoreOptions{Source: "s3:backup", Target: "/tmp/r", Files: []string{"/a/x.txt", "b/x.txt"}}, false},
+		{"flattened name clash", models.RestoreOptions{Source: "s3:backup", Target: "/tmp/r", Files: []string{"a/x.txt", "b/x.txt"}, Flatten: true}, true},
+		{"file outside source", models.RestoreOptions{Source: "s3:backup", Target: "/tmp/r", Files: []string{"../secret"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateRestoreOptions(tt.opts); (err != nil) != tt.wantErr {
+				t.Errorf("validateRestoreOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
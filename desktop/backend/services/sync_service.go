@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -86,6 +87,7 @@ const (
 	ActionPush     SyncAction = "push"
 	ActionBi       SyncAction = "bi"
 	ActionBiResync SyncAction = "bi-resync"
+	ActionRestore  SyncAction = "restore" // copy a backup into a local folder; see StartRestore
 )
 
 // SyncResult represents the result of a sync operation
@@ -103,10 +105,35 @@ type SyncService struct {
 	app                 *application.App
 	eventBus            *events.WailsEventBus
 	logService          *LogService
+	historyService      *HistoryService
 	notificationService *NotificationService
+	settingsService     *SettingsService
+	operationService    *OperationService
 	activeTasks         map[int]*SyncTask
+	failedRuns          map[int]*failedRun                    // taskId -> files that failed, kept for retry
+	deltaRuns           map[string]*models.DeltaRun           // profile name -> delta info of its last run, until added to history
+	transferReports     map[string]*dto.TransferReport        // profile name -> transfer report of its last run, until added to history
+	destinationResults  map[string][]models.DestinationResult // profile name -> fan-out outcomes of its last run, until added to history
+	apiCallRuns         map[string]map[string]int64           // profile name -> API calls per provider of its last run, until added to history
+	failovers           map[string]*models.FailoverRun        // profile name -> failover or reconciliation of its last run, until added to history
+	lockedFileRuns      map[string]*models.LockedFilesRun     // profile name -> locked source files of its last run, until added to history
+	compressionRuns     map[string]*models.CompressionRun     // profile name -> compression its last push achieved, until added to history
+	remoteHookRuns      map[int][]models.RemoteHookResult     // taskId -> remote hooks run after the run, until added to history
+	rawFlagRuns         map[int][]models.RawFlag              // taskId -> raw rclone flags the run used, until added to history
+	runLabels           map[string]*taskLabels                // profile name -> task and automatic labels of its last run, until added to history
+	lastFailures        map[string][]string                   // profile name -> files that failed in its last sync run; see GetDirectoryStatus
+	interruptedRuns     map[int64]InterruptedRun              // runs the app last exited during, offered to resume
+	chaosConfig         *models.ChaosConfig                   // faults injected into managed runs; nil = chaos mode off
+	presentation        string                                // presentation mode applied while the user presents; "" when not presenting
+	presentationLimit   string                                // bandwidth cap applied by the throttle presentation mode
+	presentationErr     bool                                  // presentation detection failed; logged once until it works again
+	shuttingDown        bool                                  // the app is exiting: no new tasks start; see drainTasks
+	resilienceReports   []models.ResilienceReport             // reports of runs with chaos mode on, oldest first
+	changeFeed          changeFeed                            // changes the delta watchers detected, for external tools
 	taskCounter         int
 	mutex               sync.RWMutex
+	apiCallsMu          sync.Mutex       // serializes recordAPICalls
+	apiCallsRecorded    map[string]int64 // API call counts already added to the api_usage table
 	envConfig           beConfig.Config
 	deltaSvc            *delta.DeltaService
 }
@@ -120,16 +147,67 @@ type SyncTask struct {
 	Cancel    context.CancelFunc
 	StartTime time.Time
 	EndTime   *time.Time
-	Status    string
-	Done      chan error // closed with result when task completes
+	Status    string       // "queued", "starting", "running", "paused", "completed", "failed", "cancelled"
+	Priority  TaskPriority // higher-priority tasks get run slots first and may preempt lower ones
+	Done      chan error   // closed with result when task completes
+
+	parentCtx   context.Context // context the task was started with; each (re)launch derives from it
+	running     bool            // holds a run slot
+	resumed     bool            // has been preempted at least once
+	preempted   bool            // cancelled by preemption; parks instead of finishing
+	preemptedBy int             // task that preempted this one; it stays paused until that task ends
+	holds       taskHold        // reasons the task is paused until released; see holdTasksLocked
+	bwLimit     string          // bandwidth limit set by UpdateRunningTask; "" for none
+	transfers   int             // transfers set by UpdateRunningTask, applied on (re)launch; 0 for the profile's
+
+	failedMu     sync.Mutex
+	failedFiles  map[string]struct{} // files reported as failed in transfer stats
+	stalledFiles map[string]struct{} // files whose transfer is stalled right now
+
+	lockedFiles    map[string]struct{} // files that failed because another application held them open; under failedMu
+	lockedSnapshot bool                // the locked file retry read from a VSS snapshot; under failedMu
+	snapshotError  string              // why the locked file retry took no snapshot; under failedMu
+
+	compressedFiles map[string]struct{} // files a push wrote through compression wrapping; under failedMu
+
+	report *dto.TransferReport // top-N file report from the run's final status
+
+	destinations []models.DestinationResult // per-destination outcomes of a fan-out run
+
+	failover *models.FailoverRun // set when the run went to the failover destination, or reconciled the primary
+
+	statusMu   sync.Mutex
+	lastStatus *dto.SyncStatusDTO // latest counters of the run, for combined board progress
+
+	apiCalls map[string]int64 // estimated API calls per provider made while the task ran
+
+	markerId int64 // running_tasks row kept while the task runs; 0 until it first starts
+
+	exited chan struct{} // closed once the run's rclone operation has returned for good
 }
 
+// failedRun records a finished task's failed files so they can be retried
+type failedRun struct {
+	Action  SyncAction
+	Profile models.Profile
+	TabId   string
+	Files   []string
+
+	HistoryId string // history entry of the run, once added; see noteRunHistoryEntry
+}
+
+// maxFailedRuns caps how many finished tasks keep their failed-file lists
+const maxFailedRuns = 20
+
 // NewSyncService creates a new sync service
 func NewSyncService(app *application.App) *SyncService {
 	return &SyncService{
-		app:         app,
-		activeTasks: make(map[int]*SyncTask),
-		taskCounter: 0,
+		app:             app,
+		activeTasks:     make(map[int]*SyncTask),
+		failedRuns:      make(map[int]*failedRun),
+		deltaRuns:       make(map[string]*models.DeltaRun),
+		transferReports: make(map[string]*dto.TransferReport),
+		taskCounter:     0,
 	}
 }
 
@@ -144,6 +222,7 @@ func (s *SyncService) SetApp(app *application.App) {
 // SetEnvConfig sets the environment configuration (needed for rclone init)
 func (s *SyncService) SetEnvConfig(config beConfig.Config) {
 	s.envConfig = config
+	utils.SetLogLimits(config.MaxLogMessagesPerStatus, config.LogStreamBufferSize)
 	// Initialize rclone global state once
 	if err := rclone.InitGlobal(config.DebugMode); err != nil {
 		log.Printf("WARNING: Failed to initialize rclone: %v", err)
@@ -151,6 +230,13 @@ func (s *SyncService) SetEnvConfig(config beConfig.Config) {
 	// Initialize delta service for change-notification-based sync optimization
 	store := delta.NewDeltaStore(GetSharedDB)
 	s.deltaSvc = delta.NewDeltaService(store)
+	s.deltaSvc.SetWatcherDownHandler(func(status delta.WatcherStatus) {
+		s.emitDeltaEvent(events.DeltaWatcherDown, status.RemoteKey, status)
+	})
+	s.deltaSvc.SetChangeHandler(func(remoteKey string, change delta.FileChange) {
+		invalidateListingsForChange(remoteKey, change)
+		s.publishChange(remoteKey, change)
+	})
 }
 
 // SetLogService sets the log service for reliable log delivery
@@ -158,11 +244,43 @@ func (s *SyncService) SetLogService(logService *LogService) {
 	s.logService = logService
 }
 
+// SetHistoryService sets the history service that records runs interrupted by the app exiting
+func (s *SyncService) SetHistoryService(historyService *HistoryService) {
+	s.historyService = historyService
+}
+
+// SetOperationService sets the operation service that runs verifications
+func (s *SyncService) SetOperationService(operationService *OperationService) {
+	s.operationService = operationService
+}
+
 // SetNotificationService sets the notification service for desktop notifications
 func (s *SyncService) SetNotificationService(notificationService *NotificationService) {
 	s.notificationService = notificationService
 }
 
+// SetSettingsService sets the settings service and follows changes to the
+// settings that affect running and queued syncs
+func (s *SyncService) SetSettingsService(settingsService *SettingsService) {
+	s.settingsService = settingsService
+	settingsService.Subscribe(s.settingsChanged)
+}
+
+// settingsChanged applies changed settings to running and queued syncs
+func (s *SyncService) settingsChanged(change SettingsChange) {
+	// Chaos mode is a debugging aid and never outlives debug mode
+	if change.Changed("debug_mode") && !change.New.DebugMode {
+		s.DisableChaosMode(context.Background())
+	}
+	// A higher limit may let queued tasks start
+	if change.Changed("max_concurrent_tasks") {
+		s.dispatchQueued()
+	}
+	if change.Changed("presentation_mode", "presentation_bw_limit") {
+		s.checkPresentation()
+	}
+}
+
 // ServiceName returns the name of the service
 func (s *SyncService) ServiceName() string {
 	return "SyncService"
@@ -171,6 +289,8 @@ func (s *SyncService) ServiceName() string {
 // ServiceStartup is called when the service starts
 func (s *SyncService) ServiceStartup(ctx context.Context, options application.ServiceOptions) error {
 	log.Printf("SyncService starting up...")
+	go s.watchPresentation(ctx)
+	go s.watchFailovers(ctx)
 	return nil
 }
 
@@ -180,25 +300,38 @@ func (s *SyncService) ServiceShutdown(ctx context.Context) error {
 
 	// Cancel all active tasks
 	s.mutex.Lock()
-	defer s.mutex.Unlock()
-
+	s.shuttingDown = true
 	for _, task := range s.activeTasks {
 		if task.Cancel != nil {
 			task.Cancel()
 		}
 	}
+	s.mutex.Unlock()
 
-	// Stop all delta watchers
-	if s.deltaSvc != nil {
-		s.deltaSvc.StopAll()
-	}
-
+	s.stopWatchers()
 	return nil
 }
 
-// StartSync starts a sync operation with context support
+// StartSync starts a sync operation with context support.
+// The task's priority comes from the context (see WithTaskPriority) and
+// defaults to manual. If all run slots are taken the task is queued.
 func (s *SyncService) StartSync(ctx context.Context, action string, profile models.Profile, tabId string) (*SyncResult, error) {
-	log.Printf("[SyncService] StartSync called: action=%s tabId=%s from=%s to=%s", action, tabId, profile.From, profile.To)
+	return s.startSync(ctx, action, profile, tabId, TaskPriorityFromContext(ctx), false)
+}
+
+// StartSyncNow starts a manual sync that preempts lower-priority runs:
+// scheduled and background tasks in progress are paused and resume
+// automatically once this task finishes.
+func (s *SyncService) StartSyncNow(ctx context.Context, action string, profile models.Profile, tabId string) (*SyncResult, error) {
+	return s.startSync(ctx, action, profile, tabId, PriorityManual, true)
+}
+
+func (s *SyncService) startSync(ctx context.Context, action string, profile models.Profile, tabId string, priority TaskPriority, preempt bool) (*SyncResult, error) {
+	profile, err := resolvePathVariables(profile)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[SyncService] StartSync called: action=%s tabId=%s from=%s to=%s priority=%s", action, tabId, profile.From, profile.To, priority)
 
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -211,32 +344,42 @@ func (s *SyncService) StartSync(ctx context.Context, action string, profile mode
 	default:
 	}
 
+	if s.shuttingDown {
+		return nil, ErrShuttingDown
+	}
+
 	// Create new task
 	s.taskCounter++
 	taskId := s.taskCounter
 	log.Printf("[SyncService] StartSync: created taskId=%d", taskId)
 
-	// Create cancellable context for the task
-	taskCtx, cancel := context.WithCancel(ctx)
-
 	task := &SyncTask{
 		Id:        taskId,
 		Action:    SyncAction(action),
 		Profile:   profile,
 		TabId:     tabId,
-		Cancel:    cancel,
 		StartTime: time.Now(),
-		Status:    "starting",
+		Status:    "queued",
+		Priority:  priority,
 		Done:      make(chan error, 1),
+		parentCtx: ctx,
+		exited:    make(chan struct{}),
 	}
 
 	s.activeTasks[taskId] = task
 
-	// Emit sync started event
-	s.emitSyncEvent(events.SyncStarted, tabId, action, "starting", "Sync operation started")
+	// Scheduled and background syncs wait while the user presents
+	if s.presentation == PresentationDefer && priority < PriorityManual {
+		task.holds |= holdPresentation
+		task.Status = "paused"
+	}
+
+	if preempt {
+		s.preemptLowerPriorityLocked(task)
+	}
 
-	// Start sync operation in goroutine
-	go s.executeSyncTask(taskCtx, task)
+	// Start the task now if a run slot is free; otherwise it waits in the queue
+	s.dispatchQueuedLocked()
 
 	result := &SyncResult{
 		TaskId:    taskId,
@@ -245,6 +388,11 @@ func (s *SyncService) StartSync(ctx context.Context, action string, profile mode
 		Message:   "Sync operation initiated",
 		StartTime: task.StartTime,
 	}
+	if !task.running {
+		result.Status = "queued"
+		result.Message = "Sync operation queued"
+		s.emitSyncEvent(events.SyncQueued, tabId, action, "queued", "Sync operation queued")
+	}
 
 	return result, nil
 }
@@ -259,21 +407,32 @@ func (s *SyncService) StopSync(ctx context.Context, taskId int) error {
 		return fmt.Errorf("task %d not found", taskId)
 	}
 
+	s.cancelTaskLocked(task)
+	return nil
+}
+
+// cancelTaskLocked cancels a task and releases it. Caller must hold s.mutex.
+func (s *SyncService) cancelTaskLocked(task *SyncTask) {
 	// Cancel the task context (this cancels the rclone Go library operation)
 	if task.Cancel != nil {
 		task.Cancel()
 	}
 
+	// A queued or paused task has no goroutine to report completion
+	if !task.running {
+		task.Done <- context.Canceled
+		close(task.Done)
+	}
+
 	// Update task status
 	task.Status = "cancelled"
+	task.preempted = false
 
 	// Emit cancelled event
 	s.emitSyncEvent(events.SyncCancelled, task.TabId, string(task.Action), "cancelled", "Sync operation cancelled")
 
-	// Remove from active tasks
-	delete(s.activeTasks, taskId)
-
-	return nil
+	// Remove from active tasks and free its run slot
+	s.releaseTaskLocked(task)
 }
 
 // GetActiveTasks returns all currently active sync tasks
@@ -308,17 +467,181 @@ func (s *SyncService) WaitForTask(ctx context.Context, taskId int) error {
 	}
 }
 
+// hasActiveTasks reports whether any sync task is running, queued or paused
+func (s *SyncService) hasActiveTasks() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.activeTasks) > 0
+}
+
+// getTask returns an active task, or nil if it has finished
+func (s *SyncService) getTask(taskId int) *SyncTask {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.activeTasks[taskId]
+}
+
+// RetryFailedFiles re-runs a failed task restricted to the files that failed in it
+func (s *SyncService) RetryFailedFiles(ctx context.Context, taskId int) (*SyncResult, error) {
+	s.mutex.RLock()
+	run, exists := s.failedRuns[taskId]
+	s.mutex.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("no failed files recorded for task %d", taskId)
+	}
+
+	profile := run.Profile
+	profile.UseRegex = false
+	profile.IncludedPaths = make([]string, len(run.Files))
+	for i, f := range run.Files {
+		profile.IncludedPaths[i] = "/" + escapeFilterGlob(f)
+	}
+
+	if run.HistoryId != "" {
+		ctx = withRunLabels(ctx, models.LabelRetryOfPrefix+run.HistoryId)
+	}
+	result, err := s.StartSync(ctx, string(run.Action), profile, run.TabId)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	delete(s.failedRuns, taskId)
+	s.mutex.Unlock()
+	return result, nil
+}
+
+// recordTransferOutcome remembers files whose transfer failed, and forgets
+// them again if a retry completes. Returns true if the failed files changed.
+func (t *SyncTask) recordTransferOutcome(tr utils.TransferTransition) bool {
+	name := tr.Transfer.Name
+	if name == "" {
+		return false
+	}
+	t.failedMu.Lock()
+	defer t.failedMu.Unlock()
+	_, failed := t.failedFiles[name]
+	switch tr.Phase {
+	case utils.TransferFailed:
+		if t.failedFiles == nil {
+			t.failedFiles = make(map[string]struct{})
+		}
+		t.failedFiles[name] = struct{}{}
+		return !failed
+	case utils.TransferCompleted:
+		delete(t.failedFiles, name)
+		return failed
+	}
+	return false
+}
+
+// failedFileList returns the files whose transfer failed, sorted
+func (t *SyncTask) failedFileList() []string {
+	t.failedMu.Lock()
+	files := make([]string, 0, len(t.failedFiles))
+	for f := range t.failedFiles {
+		files = append(files, f)
+	}
+	t.failedMu.Unlock()
+	sort.Strings(files)
+	return files
+}
+
+// setLastStatus keeps a run's latest counters; per-file details are dropped
+func (t *SyncTask) setLastStatus(status *dto.SyncStatusDTO) {
+	snapshot := *status
+	snapshot.LogMessages = nil
+	snapshot.Transfers = nil
+	snapshot.Report = nil
+	t.statusMu.Lock()
+	t.lastStatus = &snapshot
+	t.statusMu.Unlock()
+}
+
+// latestStatus returns the run's latest counters, or nil before the first status
+func (t *SyncTask) latestStatus() *dto.SyncStatusDTO {
+	t.statusMu.Lock()
+	defer t.statusMu.Unlock()
+	if t.lastStatus == nil {
+		return nil
+	}
+	snapshot := *t.lastStatus
+	return &snapshot
+}
+
+// rememberFailedRun stores a failed task's failed files for RetryFailedFiles
+func (s *SyncService) rememberFailedRun(task *SyncTask) {
+	files := task.failedFileList()
+	if len(files) == 0 {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.failedRuns == nil {
+		s.failedRuns = make(map[int]*failedRun)
+	}
+	s.failedRuns[task.Id] = &failedRun{
+		Action:  task.Action,
+		Profile: task.Profile,
+		TabId:   task.TabId,
+		Files:   files,
+	}
+
+	// Drop the oldest entries (lowest task IDs) beyond the cap
+	for len(s.failedRuns) > maxFailedRuns {
+		oldest := -1
+		for id := range s.failedRuns {
+			if oldest < 0 || id < oldest {
+				oldest = id
+			}
+		}
+		delete(s.failedRuns, oldest)
+	}
+}
+
+// escapeFilterGlob escapes rclone filter glob metacharacters so a file name matches literally
+func escapeFilterGlob(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch r {
+		case '*', '?', '[', ']', '{', '}', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
 // executeSyncTask executes the sync operation using the rclone Go library
 func (s *SyncService) executeSyncTask(ctx context.Context, task *SyncTask) {
 	log.Printf("[SyncService] executeSyncTask started: taskId=%d action=%s tabId=%s from=%s to=%s", task.Id, task.Action, task.TabId, task.Profile.From, task.Profile.To)
 	var taskErr error
+	paused := false
 	defer func() {
+		if paused {
+			log.Printf("[SyncService] executeSyncTask paused: taskId=%d", task.Id)
+			return
+		}
 		log.Printf("[SyncService] executeSyncTask finished: taskId=%d err=%v", task.Id, taskErr)
 		task.Done <- taskErr
 		close(task.Done)
+		if task.exited != nil {
+			close(task.exited)
+		}
 		s.mutex.Lock()
-		delete(s.activeTasks, task.Id)
+		// StopSync already released a task it cancelled
+		if s.activeTasks[task.Id] == task {
+			s.releaseTaskLocked(task)
+		}
+		idle := len(s.activeTasks) == 0
 		s.mutex.Unlock()
+
+		// Trim caches over their size cap once nothing is using them
+		if idle && s.settingsService != nil {
+			go s.settingsService.enforceCacheCaps()
+		}
 	}()
 
 	// Create isolated rclone context for this task
@@ -329,6 +652,55 @@ func (s *SyncService) executeSyncTask(ctx context.Context, task *SyncTask) {
 		return
 	}
 
+	// Go to the failover destination if the primary is unreachable; before
+	// the disk space guard so it checks the destination actually used
+	s.applyFailover(ctx, task)
+
+	// Fail at once on a pinned server presenting a different identity, or
+	// on a missing or read-only destination
+	err = s.checkRemotePins(ctx, task)
+	if err == nil {
+		err = s.checkDestinations(ctx, task)
+	}
+	if err != nil {
+		task.Status = "failed"
+		taskErr = fmt.Errorf("sync failed: %w", err)
+		s.runRemoteHooks(ctx, task, "failed")
+		s.handleSyncError(task, taskErr.Error())
+		if !strings.HasPrefix(task.TabId, "board-") {
+			s.sendSyncNotification(task, false, err.Error())
+		}
+		return
+	}
+
+	// Stop before local disks fill up; checked before crypt wrapping rewrites the paths
+	ctx, stopDiskGuard, err := s.guardDiskSpace(ctx, task)
+	if err != nil {
+		task.Status = "failed"
+		taskErr = fmt.Errorf("sync failed: %w", err)
+		s.handleSyncError(task, taskErr.Error())
+		if !strings.HasPrefix(task.TabId, "board-") {
+			s.sendSyncNotification(task, false, err.Error())
+		}
+		return
+	}
+	defer stopDiskGuard()
+
+	// Inject faults into the run when chaos mode is on
+	ctx, chaos, stopChaos := s.injectChaos(ctx, task)
+	defer stopChaos()
+	if chaos != nil {
+		startTime := time.Now()
+		defer func() { s.recordResilienceReport(task, chaos, startTime, taskErr) }()
+	}
+
+	// Leave out files changed on both sides since the last run; before
+	// crypt wrapping, as conflicts are resolved on the real paths
+	conflictsSince := s.detectConflicts(ctx, task)
+
+	// Credited with the bytes transferred once the run ends
+	usageRemotes := writtenRemotes(task.Action, task.Profile)
+
 	// Apply on-the-fly crypt wrapping if configured
 	cryptCleanup, err := rclone.ApplyCryptWrapping(ctx, &task.Profile)
 	if err != nil {
@@ -337,6 +709,7 @@ func (s *SyncService) executeSyncTask(ctx context.Context, task *SyncTask) {
 		return
 	}
 	defer cryptCleanup()
+	rclone.ApplyCompressWrapping(&task.Profile)
 
 	// Create structured status channel
 	outStatus := make(chan *dto.SyncStatusDTO, 100)
@@ -361,29 +734,46 @@ func (s *SyncService) executeSyncTask(ctx context.Context, task *SyncTask) {
 		utils.AddTabMapping(task.Id, task.TabId)
 	}
 
-	// Goroutine to consume structured SyncStatusDTO and dispatch events + logs
+	// Goroutine to consume the task's full log stream and dispatch it to the
+	// board log buffer and log service. The status DTO only carries the most
+	// recent messages, so it is not used for log delivery.
+	// NOTE: Do NOT re-log to stderr here — rclone's slog handler already
+	// writes these messages to stderr. Re-logging via log.Printf would
+	// create a feedback loop (Go 1.22+ redirects log.Printf through slog,
+	// which rclone captures via AddOutput). Even fmt.Fprintf would cause
+	// duplicate output since rclone already wrote the original message.
+	logStream := utils.OpenLogStream(task.Id)
+	logsDone := make(chan struct{})
 	go func() {
+		defer close(logsDone)
 		isBoardTask := strings.HasPrefix(task.TabId, "board-")
+		for line := range logStream.Lines() {
+			msg := line.Message
+			if line.Dropped > 0 {
+				msg = fmt.Sprintf("(%d log lines dropped) %s", line.Dropped, msg)
+			}
+			if isBoardTask {
+				AppendBoardLog(msg)
+			}
+			if s.logService != nil {
+				s.logService.LogSync(task.TabId, string(task.Action), "running", msg)
+			}
+		}
+	}()
+
+	// Goroutine to consume structured SyncStatusDTO and dispatch events
+	consumerDone := make(chan struct{})
+	go func() {
+		defer close(consumerDone)
 		for status := range outStatus {
 			// Enrich DTO with task identity (library layer doesn't set these)
 			status.Id = &task.Id
 			status.TabId = &task.TabId
 			status.Action = string(task.Action)
-
-			// Dispatch LogMessages to board log buffer and log service.
-			// NOTE: Do NOT re-log to stderr here — rclone's slog handler already
-			// writes these messages to stderr. Re-logging via log.Printf would
-			// create a feedback loop (Go 1.22+ redirects log.Printf through slog,
-			// which rclone captures via AddOutput). Even fmt.Fprintf would cause
-			// duplicate output since rclone already wrote the original message.
-			for _, logMsg := range status.LogMessages {
-				if isBoardTask {
-					AppendBoardLog(logMsg)
-				}
-				if s.logService != nil {
-					s.logService.LogSync(task.TabId, string(task.Action), status.Status, logMsg)
-				}
+			if status.Report != nil {
+				task.report = status.Report
 			}
+			task.setLastStatus(status)
 
 			// Emit structured SyncStatusDTO for frontend
 			if s.eventBus != nil {
@@ -394,27 +784,75 @@ func (s *SyncService) executeSyncTask(ctx context.Context, task *SyncTask) {
 		}
 	}()
 
+	// Per-file transfer transitions: track failures and forward as events
+	utils.WatchTransfers(task.Id, func(tr utils.TransferTransition) {
+		task.recordLockedFile(&tr)
+		task.recordCompressedFile(tr)
+		if task.recordTransferOutcome(tr) {
+			saveRunMarkerFailures(task)
+		}
+		task.recordStall(tr)
+		s.emitTransferEvent(task, tr)
+	})
+
 	// Update task status
 	task.Status = "running"
 	s.emitSyncEvent(events.SyncProgress, task.TabId, string(task.Action), "running", "Sync operation in progress")
 
 	// Execute the sync operation using rclone Go library
 	config := s.envConfig
-	switch task.Action {
-	case ActionPull:
+	apiCallsBefore := rclone.APICallCounts()
+	switch {
+	case len(task.Profile.FanOutTo) > 0 && task.Action != ActionPush:
+		err = fmt.Errorf("fan-out profiles only support push, not %s", task.Action)
+	case task.Action == ActionPull:
 		err = rclone.Sync(ctx, config, "pull", task.Profile, outStatus, s.deltaSvc)
-	case ActionPush:
-		err = rclone.Sync(ctx, config, "push", task.Profile, outStatus, s.deltaSvc)
-	case ActionBi:
+	case task.Action == ActionPush:
+		if len(task.Profile.FanOutTo) > 0 {
+			err = rclone.FanOutSync(ctx, config, task.Profile, outStatus, func(r models.DestinationResult) {
+				task.destinations = append(task.destinations, r)
+				s.emitSyncEvent(events.SyncProgress, task.TabId, string(task.Action), "running",
+					fmt.Sprintf("Destination %s: %s", r.Destination, r.Status))
+			})
+		} else {
+			err = rclone.Sync(ctx, config, "push", task.Profile, outStatus, s.deltaSvc)
+		}
+	case task.Action == ActionBi:
 		err = rclone.BiSync(ctx, config, task.Profile, false, outStatus, s.deltaSvc)
-	case ActionBiResync:
+	case task.Action == ActionBiResync:
 		err = rclone.BiSync(ctx, config, task.Profile, true, outStatus, s.deltaSvc)
+	case task.Action == ActionRestore:
+		err = s.runRestore(ctx, task, outStatus)
 	default:
 		err = fmt.Errorf("unknown sync action: %s", task.Action)
 	}
 
-	// Close the outStatus channel to unblock the reader goroutine
+	// Copy files other applications held open again, once the rest is done
+	err = s.retryLockedFiles(ctx, task, config, outStatus, err)
+
+	// Close the outStatus channel to unblock the reader goroutine and let it
+	// drain, so failed transfers are fully recorded before notifying
 	closeOutStatus()
+	<-consumerDone
+	utils.UnwatchTransfers(task.Id)
+	utils.CloseLogStream(task.Id)
+	<-logsDone
+	task.addAPICalls(apiCallsBefore, rclone.APICallCounts())
+	s.recordAPICalls()
+	s.rememberDeltaRun(task)
+	s.rememberRunLabels(task)
+	s.rememberTransferReport(task)
+	s.rememberDestinationResults(task)
+	s.rememberAPICalls(task)
+	s.rememberFailover(task)
+	s.rememberLastFailures(task)
+	s.rememberLockedFiles(task)
+	s.rememberCompression(ctx, task)
+	s.rememberRawFlags(task)
+	s.recordRemoteWrites(task, usageRemotes)
+
+	// A session that transferred its share succeeded; what is left is queued
+	err = s.endTransferSession(ctx, task, err)
 
 	if task.TabId != "" {
 		utils.RemoveTabMapping(task.Id)
@@ -423,6 +861,16 @@ func (s *SyncService) executeSyncTask(ctx context.Context, task *SyncTask) {
 	// Check if context was cancelled
 	select {
 	case <-ctx.Done():
+		// Stopped by the disk space guard: report it as a failure below
+		if lowSpace := lowDiskSpaceCause(ctx); lowSpace != nil {
+			err = lowSpace
+			break
+		}
+		// Preempted by a higher-priority run: park and resume later
+		if s.pauseIfPreempted(task) {
+			paused = true
+			return
+		}
 		task.Status = "cancelled"
 		taskErr = ctx.Err()
 		s.emitSyncEvent(events.SyncCancelled, task.TabId, string(task.Action), "cancelled", "Sync operation was cancelled")
@@ -433,6 +881,7 @@ func (s *SyncService) executeSyncTask(ctx context.Context, task *SyncTask) {
 	// Handle result
 	if err != nil {
 		task.Status = "failed"
+		s.rememberFailedRun(task)
 		taskErr = fmt.Errorf("sync failed: %w", err)
 		s.handleSyncError(task, taskErr.Error())
 
@@ -444,9 +893,13 @@ func (s *SyncService) executeSyncTask(ctx context.Context, task *SyncTask) {
 	}
 
 	// Success
+	saveConflictBaseline(task, conflictsSince)
+	s.writeManifest(ctx, task)
+	s.runRemoteHooks(ctx, task, "completed")
 	task.Status = "completed"
 	endTime := time.Now()
 	task.EndTime = &endTime
+	s.finishFailover(task)
 
 	s.emitSyncEvent(events.SyncCompleted, task.TabId, string(task.Action), "completed", "Sync operation completed successfully")
 
@@ -470,6 +923,136 @@ func (s *SyncService) emitSyncEvent(eventType events.EventType, tabId, action, s
 	}
 }
 
+// emitDeltaEvent emits a delta watcher event via the unified EventBus
+func (s *SyncService) emitDeltaEvent(eventType events.EventType, remoteKey string, data interface{}) {
+	event := events.NewDeltaEvent(eventType, remoteKey, data)
+	if s.eventBus != nil {
+		if err := s.eventBus.EmitDeltaEvent(event); err != nil {
+			log.Printf("Failed to emit delta event: %v", err)
+		}
+	} else if s.app != nil {
+		s.app.Event.Emit("tofe", event)
+	}
+}
+
+// transferEventTypes maps per-file transfer phases to event types
+var transferEventTypes = map[utils.TransferPhase]events.EventType{
+	utils.TransferStarted:    events.TransferStarted,
+	utils.TransferProgressed: events.TransferProgressed,
+	utils.TransferStalled:    events.TransferStalled,
+	utils.TransferCompleted:  events.TransferCompleted,
+	utils.TransferFailed:     events.TransferFailed,
+}
+
+// emitTransferEvent emits a per-file transfer event via the unified EventBus
+func (s *SyncService) emitTransferEvent(task *SyncTask, tr utils.TransferTransition) {
+	event := events.NewTransferEvent(transferEventTypes[tr.Phase], task.Id, task.TabId, string(task.Action), tr.Transfer.Name, tr.Transfer)
+	if s.eventBus != nil {
+		if err := s.eventBus.EmitTransferEvent(event); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to emit transfer event: %v\n", err)
+		}
+	} else if s.app != nil {
+		s.app.Event.Emit("tofe", event)
+	}
+}
+
+// GetDeltaStats returns delta sync statistics for a remote endpoint
+// (e.g. "gdrive:/data" or "local:/home/me/docs"), or nil if it has none
+func (s *SyncService) GetDeltaStats(ctx context.Context, remoteKey string) (*delta.DeltaStats, error) {
+	if s.deltaSvc == nil {
+		return nil, nil
+	}
+	stats, err := s.deltaSvc.GetStats(remoteKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load delta stats: %w", err)
+	}
+	return stats, nil
+}
+
+// rememberDeltaRun keeps the delta info recorded by the task's run so the
+// profile's next history entry can include it
+func (s *SyncService) rememberDeltaRun(task *SyncTask) {
+	if s.deltaSvc == nil {
+		return
+	}
+	run := s.deltaSvc.LastRun(rclone.SourceRemoteKey(string(task.Action), task.Profile))
+	if run == nil || run.FinishedAt.Before(task.StartTime) {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.deltaRuns[task.Profile.Name] = &models.DeltaRun{
+		Mode:           run.Mode,
+		ChangesScoped:  run.ChangesScoped,
+		FallbackReason: run.FallbackReason,
+		TimeSavedMs:    run.TimeSaved.Milliseconds(),
+	}
+}
+
+// takeDeltaRun returns and forgets the delta info of a profile's last run
+func (s *SyncService) takeDeltaRun(profileName string) *models.DeltaRun {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	run := s.deltaRuns[profileName]
+	delete(s.deltaRuns, profileName)
+	return run
+}
+
+// rememberTransferReport keeps the task's transfer report so the profile's
+// next history entry can include it
+func (s *SyncService) rememberTransferReport(task *SyncTask) {
+	if task.report == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.transferReports == nil {
+		s.transferReports = make(map[string]*dto.TransferReport)
+	}
+	s.transferReports[task.Profile.Name] = task.report
+}
+
+// takeTransferReport returns and forgets the transfer report of a profile's last run
+func (s *SyncService) takeTransferReport(profileName string) *dto.TransferReport {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	report := s.transferReports[profileName]
+	delete(s.transferReports, profileName)
+	return report
+}
+
+// rememberDestinationResults keeps the per-destination outcomes of a fan-out
+// task so the profile's next history entry can include them
+func (s *SyncService) rememberDestinationResults(task *SyncTask) {
+	if len(task.destinations) == 0 {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.destinationResults == nil {
+		s.destinationResults = make(map[string][]models.DestinationResult)
+	}
+	s.destinationResults[task.Profile.Name] = task.destinations
+}
+
+// takeDestinationResults returns and forgets the fan-out outcomes of a profile's last run
+func (s *SyncService) takeDestinationResults(profileName string) []models.DestinationResult {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	results := s.destinationResults[profileName]
+	delete(s.destinationResults, profileName)
+	return results
+}
+
+// GetWatcherStatuses returns the health of the delta change watchers
+func (s *SyncService) GetWatcherStatuses(ctx context.Context) []delta.WatcherStatus {
+	if s.deltaSvc == nil {
+		return []delta.WatcherStatus{}
+	}
+	return s.deltaSvc.WatcherStatuses()
+}
+
 // sendSyncNotification sends a desktop notification for sync completion/failure
 func (s *SyncService) sendSyncNotification(task *SyncTask, success bool, errorMsg string) {
 	if s.notificationService == nil {
@@ -487,6 +1070,8 @@ func (s *SyncService) sendSyncNotification(task *SyncTask, success bool, errorMs
 		actionLabel = "Bi-directional Sync"
 	case ActionBiResync:
 		actionLabel = "Bi-directional Resync"
+	case ActionRestore:
+		actionLabel = "Restore"
 	}
 
 	profileName := task.Profile.Name
@@ -495,9 +1080,14 @@ func (s *SyncService) sendSyncNotification(task *SyncTask, success bool, errorMs
 	}
 
 	var title, body string
+	var errorInfo *models.ErrorInfo
 	if success {
 		title = fmt.Sprintf("%s Completed", actionLabel)
 		body = fmt.Sprintf("Profile \"%s\" synced successfully.", profileName)
+	} else if errorInfo = classifyTaskError(task, errorMsg); errorInfo != nil {
+		// Known failure: show what went wrong and how to fix it instead of the raw error
+		title = fmt.Sprintf("%s Failed: %s", actionLabel, errorInfo.Title)
+		body = fmt.Sprintf("Profile \"%s\": %s", profileName, errorInfo.Remediation)
 	} else {
 		title = fmt.Sprintf("%s Failed", actionLabel)
 		// Truncate error message if too long
@@ -509,7 +1099,35 @@ func (s *SyncService) sendSyncNotification(task *SyncTask, success bool, errorMs
 	}
 
 	// Send notification (context.Background() since task context may be cancelled)
-	if err := s.notificationService.SendNotification(context.Background(), title, body); err != nil {
+	if success {
+		if err := s.notificationService.SendProfileNotification(context.Background(), task.Profile.NotifyMode, true, title, body); err != nil {
+			log.Printf("Failed to send sync notification: %v", err)
+		}
+		return
+	}
+
+	// Failures carry follow-up actions
+	actions := []NotificationAction{}
+	s.mutex.RLock()
+	_, hasFailedFiles := s.failedRuns[task.Id]
+	s.mutex.RUnlock()
+	if hasFailedFiles {
+		actions = append(actions, NotificationAction{Id: NotifyActionRetryFailed, Label: "Retry failed files"})
+	}
+	actions = append(actions,
+		NotificationAction{Id: NotifyActionOpenLog, Label: "Open log"},
+		NotificationAction{Id: NotifyActionPauseSchedules, Label: "Pause schedules for 1h"},
+		NotificationAction{Id: NotifyActionSnooze, Label: "Snooze for a day"},
+	)
+	if _, err := s.notificationService.SendActionNotification(context.Background(), ActionNotification{
+		Title:      title,
+		Body:       body,
+		Actions:    actions,
+		TaskId:     task.Id,
+		TabId:      task.TabId,
+		NotifyMode: task.Profile.NotifyMode,
+		ErrorInfo:  errorInfo,
+	}); err != nil {
 		log.Printf("Failed to send sync notification: %v", err)
 	}
 }
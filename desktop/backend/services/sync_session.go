@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"desktop/backend/events"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+)
+
+// maxQueuedFiles caps how many of the files left after a session are kept
+const maxQueuedFiles = 1000
+
+// endTransferSession records the end of a capped session of a profile with
+// Profile.SessionTransfer. A session that stopped because it transferred
+// its share succeeded: the files left are planned, in the order the next
+// sessions transfer them, and kept until the next run, which carries on
+// with them. A run that transferred everything completes the session. err
+// is the run's error, returned unless the session's share stopped it.
+func (s *SyncService) endTransferSession(ctx context.Context, task *SyncTask, err error) error {
+	if task.Profile.SessionTransfer == "" || (task.Action != ActionPush && task.Action != ActionPull) || ctx.Err() != nil {
+		return err
+	}
+	limitReached := rclone.SessionLimitReached(err)
+	if err != nil && !limitReached {
+		return err
+	}
+
+	session, loadErr := loadTransferSession(task.Profile.Name, string(task.Action))
+	if loadErr != nil {
+		log.Printf("warning: failed to load the transfer session of %s: %v", task.Profile.Name, loadErr)
+	}
+	if session == nil || session.Status != "in_progress" {
+		session = &models.TransferSession{StartedAt: time.Now()}
+	}
+	session.ProfileName = task.Profile.Name
+	session.Action = string(task.Action)
+	session.Order = task.Profile.SessionOrder
+	if session.Order == "" {
+		session.Order = models.SessionOrderSmallest
+	}
+	session.Sessions++
+	session.UpdatedAt = time.Now()
+	if status := task.latestStatus(); status != nil {
+		session.LastSessionFiles, session.LastSessionBytes = status.FilesTransferred, status.BytesTransferred
+	}
+
+	message := "Transfer session complete: nothing left to transfer"
+	if limitReached {
+		left, planErr := rclone.PlanSessionQueue(context.WithoutCancel(ctx), task.Profile, task.Action == ActionPull, maxQueuedFiles)
+		if planErr != nil {
+			log.Printf("warning: failed to plan the next session of %s: %v", task.Profile.Name, planErr)
+			left = &models.TransferSession{}
+		}
+		session.Status = "in_progress"
+		session.RemainingFiles, session.RemainingBytes, session.Queue = left.RemainingFiles, left.RemainingBytes, left.Queue
+		message = fmt.Sprintf("Session %d transferred its share (%s); %d files (%s) are left for the next session",
+			session.Sessions, task.Profile.SessionTransfer, session.RemainingFiles, fs.SizeSuffix(session.RemainingBytes))
+	} else {
+		session.Status = "complete"
+		session.RemainingFiles, session.RemainingBytes, session.Queue = 0, 0, nil
+	}
+
+	if saveErr := saveTransferSession(session); saveErr != nil {
+		log.Printf("warning: failed to record the transfer session of %s: %v", task.Profile.Name, saveErr)
+	}
+	log.Printf("[SyncService] Task %d: %s", task.Id, message)
+	s.emitSyncEvent(events.SyncProgress, task.TabId, string(task.Action), "running", message)
+	return nil
+}
+
+// GetTransferSessions returns the profiles synced in capped sessions, with
+// what is left for their next session
+func (s *SyncService) GetTransferSessions(ctx context.Context) ([]models.TransferSession, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`SELECT profile_name, action, session_order, status, sessions, last_session_files, last_session_bytes,
+		remaining_files, remaining_bytes, queue, started_at, updated_at
+		FROM transfer_sessions ORDER BY updated_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transfer sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.TransferSession{}
+	for rows.Next() {
+		session, err := scanTransferSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+	return sessions, rows.Err()
+}
+
+// ClearTransferSession forgets a profile's transfer session, so its next
+// run starts a new one
+func (s *SyncService) ClearTransferSession(ctx context.Context, profileName, action string) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec("DELETE FROM transfer_sessions WHERE profile_name = ? AND action = ?", profileName, action)
+	return err
+}
+
+// loadTransferSession returns a profile's transfer session, or nil if it has none
+func loadTransferSession(profileName, action string) (*models.TransferSession, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	row := db.QueryRow(`SELECT profile_name, action, session_order, status, sessions, last_session_files, last_session_bytes,
+		remaining_files, remaining_bytes, queue, started_at, updated_at
+		FROM transfer_sessions WHERE profile_name = ? AND action = ?`, profileName, action)
+	session, err := scanTransferSession(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return session, err
+}
+
+// scanTransferSession reads a transfer session from a row
+func scanTransferSession(row interface{ Scan(dest ...any) error }) (*models.TransferSession, error) {
+	var session models.TransferSession
+	var queue, startedAt, updatedAt string
+	if err := row.Scan(&session.ProfileName, &session.Action, &session.Order, &session.Status, &session.Sessions,
+		&session.LastSessionFiles, &session.LastSessionBytes, &session.RemainingFiles, &session.RemainingBytes,
+		&queue, &startedAt, &updatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan transfer session: %w", err)
+	}
+	session.Queue = []models.QueuedFile{}
+	_ = json.Unmarshal([]byte(queue), &session.Queue)
+	session.StartedAt, _ = time.Parse(time.RFC3339, startedAt)
+	session.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+	return &session, nil
+}
+
+// saveTransferSession records a transfer session
+func saveTransferSession(session *models.TransferSession) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	queue, err := json.Marshal(session.Queue)
+	if err != nil || session.Queue == nil {
+		queue = []byte("[]")
+	}
+	_, err = db.Exec(`INSERT OR REPLACE INTO transfer_sessions (profile_name, action, session_order, status, sessions,
+		last_session_files, last_session_bytes, remaining_files, remaining_bytes, queue, started_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		session.ProfileName, session.Action, session.Order, session.Status, session.Sessions,
+		session.LastSessionFiles, session.LastSessionBytes, session.RemainingFiles, session.RemainingBytes, string(queue),
+		session.StartedAt.UTC().Format(time.RFC3339), session.UpdatedAt.UTC().Format(time.RFC3339))
+	return err
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// ErrShuttingDown is returned when a sync is started while the app exits
+var ErrShuttingDown = errors.New("the app is shutting down")
+
+// shutdownCancelWait is how long cancelled tasks get to finish, and record
+// their results, once the shutdown grace period is over
+const shutdownCancelWait = 5 * time.Second
+
+// drainPollInterval is how often drainTasks checks whether tasks are left
+const drainPollInterval = 100 * time.Millisecond
+
+// drainTasks empties the sync queue for app exit: new syncs are refused,
+// queued and paused ones are cancelled, and running ones get up to grace to
+// finish before they are cancelled too. Returns once no task is left, or
+// when ctx is done.
+func (s *SyncService) drainTasks(ctx context.Context, grace time.Duration) {
+	s.mutex.Lock()
+	s.shuttingDown = true
+	for _, task := range s.activeTasks {
+		if !task.running {
+			s.cancelTaskLocked(task)
+		}
+	}
+	running := len(s.activeTasks)
+	s.mutex.Unlock()
+
+	if running == 0 {
+		return
+	}
+	log.Printf("[SyncService] Waiting up to %s for %d running syncs to finish", grace, running)
+	if s.waitForTasks(ctx, grace) {
+		return
+	}
+
+	s.mutex.Lock()
+	log.Printf("[SyncService] Cancelling %d syncs still running after the shutdown grace period", len(s.activeTasks))
+	for _, task := range s.activeTasks {
+		if task.Cancel != nil {
+			task.Cancel()
+		}
+	}
+	s.mutex.Unlock()
+
+	if !s.waitForTasks(ctx, shutdownCancelWait) {
+		log.Printf("[SyncService] Syncs did not stop in time; exiting anyway")
+	}
+}
+
+// waitForTasks waits up to timeout for all tasks to finish. Returns true if they did.
+func (s *SyncService) waitForTasks(ctx context.Context, timeout time.Duration) bool {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for s.hasActiveTasks() {
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			return !s.hasActiveTasks()
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// stopWatchers stops the delta watchers, saving the changes they collected
+// that no sync picked up
+func (s *SyncService) stopWatchers() {
+	if s.deltaSvc != nil {
+		s.deltaSvc.StopAll()
+	}
+}
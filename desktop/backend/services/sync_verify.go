@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+// Verify checks a profile's destination against its source without
+// transferring anything, like rclone check, or rclone cryptcheck when the
+// profile encrypts its destination. The report lists the files missing on
+// either side, the files that differ and the files that couldn't be read.
+// It is recorded with the profile's verify reports, downloadable with
+// ExportService.ExportVerifyReport, and as a "verify" run in history,
+// labelled with the report's ID. Files that differ, are missing from the
+// destination or can't be read fail the run but aren't returned as an
+// error; a verification that couldn't run is.
+func (s *SyncService) Verify(ctx context.Context, profile models.Profile, opts models.VerifyOptions) (*models.VerifyReport, error) {
+	if s.operationService == nil {
+		return nil, fmt.Errorf("operation service not available")
+	}
+	report, err := s.operationService.VerifyProfile(ctx, profile, opts)
+	if report != nil && s.historyService != nil {
+		if _, histErr := s.historyService.addRecordedEntries([]models.HistoryEntry{verifyHistoryEntry(report)}); histErr != nil {
+			log.Printf("[SyncService] Could not record the verification of %s in history: %v", profile.Name, histErr)
+		}
+	}
+	return report, err
+}
+
+// verifyHistoryEntry returns the history entry recording a verification
+func verifyHistoryEntry(report *models.VerifyReport) models.HistoryEntry {
+	entry := models.HistoryEntry{
+		Id:          fmt.Sprintf("verify-%d", report.Id),
+		ProfileName: report.ProfileName,
+		Action:      "verify",
+		Status:      "completed",
+		StartTime:   report.StartTime,
+		EndTime:     report.EndTime,
+		Duration:    report.EndTime.Sub(report.StartTime).String(),
+		Errors:      report.Problems(),
+		Labels:      []string{models.LabelVerifyPrefix + strconv.FormatInt(report.Id, 10)},
+	}
+	if report.Id == 0 {
+		// The report couldn't be recorded
+		entry.Id = fmt.Sprintf("verify-%d", report.StartTime.UnixNano())
+		entry.Labels = nil
+	}
+	switch report.Status {
+	case "failed":
+		entry.Status = "failed"
+		entry.ErrorMessage = report.ErrorMessage
+	case "problems":
+		entry.Status = "failed"
+		entry.ErrorMessage = fmt.Sprintf("%d files differ, %d are missing from the destination and %d could not be read",
+			report.DifferCount, report.MissingCount, report.ErrorCount)
+	}
+	return entry
+}
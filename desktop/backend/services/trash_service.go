@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"desktop/backend/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/wailsapp/wails/v3/pkg/application"
+)
+
+// trashRetention is how long deleted items stay restorable
+const trashRetention = 30 * 24 * time.Hour
+
+// TrashService keeps deleted profiles, boards, flows and schedules for
+// trashRetention, so a deletion can be undone. Items are moved to the trash
+// by the services that delete them, in the same transaction.
+type TrashService struct {
+	app *application.App
+
+	// Services items are restored through
+	configService    *ConfigService
+	schedulerService *SchedulerService
+}
+
+// NewTrashService creates a new trash service
+func NewTrashService(app *application.App) *TrashService {
+	return &TrashService{
+		app: app,
+	}
+}
+
+// SetApp sets the application reference
+func (t *TrashService) SetApp(app *application.App) {
+	t.app = app
+}
+
+// SetConfigService sets the config service profiles are restored through
+func (t *TrashService) SetConfigService(configService *ConfigService) {
+	t.configService = configService
+}
+
+// SetSchedulerService sets the scheduler service schedules are restored through
+func (t *TrashService) SetSchedulerService(schedulerService *SchedulerService) {
+	t.schedulerService = schedulerService
+}
+
+// ServiceName returns the name of the service
+func (t *TrashService) ServiceName() string {
+	return "TrashService"
+}
+
+// ServiceStartup purges items that expired while the app wasn't running
+func (t *TrashService) ServiceStartup(ctx context.Context, options application.ServiceOptions) error {
+	if err := pruneTrash(time.Now()); err != nil {
+		log.Printf("Warning: Could not purge expired trash: %v", err)
+	}
+	return nil
+}
+
+// GetTrash returns the deleted items that can still be restored, most
+// recently deleted first
+func (t *TrashService) GetTrash(ctx context.Context) ([]models.TrashItem, error) {
+	now := time.Now()
+	if err := pruneTrash(now); err != nil {
+		return nil, err
+	}
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`SELECT t.kind, t.id, t.name, t.data, t.deleted_at,
+		CASE WHEN t.kind = ? THEN (SELECT COUNT(*) FROM history h WHERE h.profile_name = t.id) ELSE 0 END
+		FROM trash t ORDER BY t.deleted_at DESC`, models.TrashProfile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trash: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.TrashItem{}
+	for rows.Next() {
+		var item models.TrashItem
+		var data, deletedAt string
+		if err := rows.Scan(&item.Kind, &item.Id, &item.Name, &data, &deletedAt, &item.HistoryCount); err != nil {
+			return nil, fmt.Errorf("failed to scan trash item: %w", err)
+		}
+		item.Data = json.RawMessage(data)
+		item.DeletedAt, _ = time.Parse(time.RFC3339, deletedAt)
+		item.ExpiresAt = item.DeletedAt.Add(trashRetention)
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// RestoreFromTrash adds a deleted item back as it was when deleted. A
+// profile or board whose name was taken since is refused, as is a schedule
+// whose profile doesn't exist; restore or rename those first.
+func (t *TrashService) RestoreFromTrash(ctx context.Context, kind, id string) error {
+	data, err := loadTrashItem(kind, id)
+	if err != nil {
+		return err
+	}
+
+	switch kind {
+	case models.TrashProfile:
+		var profile models.Profile
+		if err := json.Unmarshal(data, &profile); err != nil {
+			return fmt.Errorf("failed to read trashed profile: %w", err)
+		}
+		if t.configService == nil {
+			return fmt.Errorf("config service not available")
+		}
+		err = t.configService.AddProfile(ctx, profile)
+	case models.TrashBoard:
+		var board models.Board
+		if err := json.Unmarshal(data, &board); err != nil {
+			return fmt.Errorf("failed to read trashed board: %w", err)
+		}
+		boardService := GetBoardService()
+		if boardService == nil {
+			return fmt.Errorf("board service not available")
+		}
+		err = boardService.AddBoard(ctx, board)
+	case models.TrashFlow:
+		var flow models.Flow
+		if err := json.Unmarshal(data, &flow); err != nil {
+			return fmt.Errorf("failed to read trashed flow: %w", err)
+		}
+		flowService := GetFlowService()
+		if flowService == nil {
+			return fmt.Errorf("flow service not available")
+		}
+		err = flowService.restoreFlow(ctx, flow)
+	case models.TrashSchedule:
+		var entry models.ScheduleEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return fmt.Errorf("failed to read trashed schedule: %w", err)
+		}
+		if t.schedulerService == nil {
+			return fmt.Errorf("scheduler service not available")
+		}
+		if entry.ProfileName != "" && t.configService != nil {
+			if _, err := t.configService.findProfile(ctx, entry.ProfileName); err != nil {
+				return fmt.Errorf("restore profile '%s' first: %w", entry.ProfileName, err)
+			}
+		}
+		err = t.schedulerService.AddSchedule(ctx, entry)
+	default:
+		return fmt.Errorf("unknown trash item kind '%s'", kind)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore %s: %w", kind, err)
+	}
+
+	if err := t.PurgeFromTrash(ctx, kind, id); err != nil {
+		log.Printf("Warning: Restored %s '%s' is still in the trash: %v", kind, id, err)
+	}
+	log.Printf("Restored %s '%s' from the trash", kind, id)
+	return nil
+}
+
+// PurgeFromTrash deletes a trashed item for good. Its history is kept.
+func (t *TrashService) PurgeFromTrash(ctx context.Context, kind, id string) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	res, err := db.Exec("DELETE FROM trash WHERE kind = ? AND id = ?", kind, id)
+	if err != nil {
+		return fmt.Errorf("failed to purge %s: %w", kind, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%s '%s' is not in the trash", kind, id)
+	}
+	return nil
+}
+
+// EmptyTrash deletes all trashed items for good
+func (t *TrashService) EmptyTrash(ctx context.Context) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec("DELETE FROM trash"); err != nil {
+		return fmt.Errorf("failed to empty trash: %w", err)
+	}
+	return nil
+}
+
+// trashItem moves a deleted item to the trash through the transaction that
+// deletes it. An item deleted again replaces the earlier one.
+func trashItem(tx *sql.Tx, kind, id, name string, item any) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to trash %s: %w", kind, err)
+	}
+	_, err = tx.Exec("INSERT OR REPLACE INTO trash (kind, id, name, data, deleted_at) VALUES (?, ?, ?, ?, ?)",
+		kind, id, name, string(data), time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("failed to trash %s: %w", kind, err)
+	}
+	return nil
+}
+
+// deleteWithTrash runs a delete statement and moves the deleted item to the
+// trash in one transaction
+func deleteWithTrash(kind, id, name string, item any, query string, args ...any) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := trashItem(tx, kind, id, name, item); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(query, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// loadTrashItem returns the data of a trashed item that hasn't expired
+func loadTrashItem(kind, id string) ([]byte, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	var data, deletedAt string
+	err = db.QueryRow("SELECT data, deleted_at FROM trash WHERE kind = ? AND id = ?", kind, id).Scan(&data, &deletedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%s '%s' is not in the trash", kind, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if t, err := time.Parse(time.RFC3339, deletedAt); err == nil && time.Since(t) > trashRetention {
+		return nil, fmt.Errorf("%s '%s' has expired from the trash", kind, id)
+	}
+	return []byte(data), nil
+}
+
+// pruneTrash purges items deleted more than trashRetention before now
+func pruneTrash(now time.Time) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	cutoff := now.Add(-trashRetention).UTC().Format(time.RFC3339)
+	res, err := db.Exec("DELETE FROM trash WHERE deleted_at < ?", cutoff)
+	if err != nil {
+		return fmt.Errorf("failed to purge expired trash: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		log.Printf("Purged %d expired item(s) from the trash", n)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeletedProfileCanBeRestored(t *testing.T) {
+	ctx := context.Background()
+	svc := NewConfigService(nil)
+	trash := NewTrashService(nil)
+	trash.SetConfigService(svc)
+
+	profile := models.Profile{Name: "trash-docs", From: "/home/user/docs", To: "gdrive:backup", EncryptPassword: "secret"}
+	if err := svc.AddProfile(ctx, profile); err != nil {
+		t.Fatalf("AddProfile: %v", err)
+	}
+	defer trash.PurgeFromTrash(ctx, models.TrashProfile, profile.Name)
+	if err := svc.DeleteProfile(ctx, profile.Name); err != nil {
+		t.Fatalf("DeleteProfile: %v", err)
+	}
+
+	items, err := trash.GetTrash(ctx)
+	if err != nil {
+		t.Fatalf("GetTrash: %v", err)
+	}
+	var found *models.TrashItem
+	for i := range items {
+		if items[i].Kind == models.TrashProfile && items[i].Id == profile.Name {
+			found = &items[i]
+		}
+	}
+	if found == nil {
+		t.Fatal("expected the deleted profile in the trash")
+	}
+	if got := found.ExpiresAt.Sub(found.DeletedAt); got != trashRetention {
+		t.Errorf("expected the profile to expire after %v, got %v", trashRetention, got)
+	}
+	if string(found.Data) == "" || strings.Contains(string(found.Data), "secret") {
+		t.Errorf("expected the trashed profile without its encryption password, got %s", found.Data)
+	}
+
+	if err := trash.RestoreFromTrash(ctx, models.TrashProfile, profile.Name); err != nil {
+		t.Fatalf("RestoreFromTrash: %v", err)
+	}
+	defer svc.DeleteProfile(ctx, profile.Name)
+	if _, err := svc.findProfile(ctx, profile.Name); err != nil {
+		t.Errorf("expected the profile to be restored: %v", err)
+	}
+	if err := trash.RestoreFromTrash(ctx, models.TrashProfile, profile.Name); err == nil {
+		t.Error("expected a restored profile to have left the trash")
+	}
+}
+
+func TestPruneTrash(t *testing.T) {
+	ctx := context.Background()
+	db, err := GetSharedDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-trashRetention - time.Hour).UTC().Format(time.RFC3339)
+	if _, err := db.Exec("INSERT INTO trash (kind, id, name, data, deleted_at) VALUES (?, ?, ?, ?, ?)",
+		models.TrashBoard, "trash-old-board", "Old", "{}", old); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pruneTrash(time.Now()); err != nil {
+		t.Fatalf("pruneTrash: %v", err)
+	}
+	if err := NewTrashService(nil).PurgeFromTrash(ctx, models.TrashBoard, "trash-old-board"); err == nil {
+		t.Error("expected the expired board to be purged")
+	}
+}
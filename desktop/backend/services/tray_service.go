@@ -11,16 +11,19 @@ import (
 
 // TrayService manages the system tray
 type TrayService struct {
-	app            *application.App
-	tray           *application.SystemTray
-	menu           *application.Menu
-	boardService   *BoardService
-	flowService    *FlowService
-	window         application.Window
-	mutex          sync.RWMutex
-	initialized    bool
-	iconData       []byte
-	onShowCallback func() // called when restoring from tray to show in Dock
+	app             *application.App
+	tray            *application.SystemTray
+	menu            *application.Menu
+	boardService    *BoardService
+	flowService     *FlowService
+	authService     *AuthService
+	settingsService *SettingsService
+	window          application.Window
+	windowFactory   func() application.Window // creates the main window on demand in tray-only mode
+	mutex           sync.RWMutex
+	initialized     bool
+	iconData        []byte
+	onShowCallback  func() // called when restoring from tray to show in Dock
 }
 
 // Singleton instance
@@ -61,11 +64,34 @@ func (t *TrayService) SetFlowService(flowService *FlowService) {
 	t.flowService = flowService
 }
 
+// SetAuthService sets the auth service asked to prompt for unlock when the
+// window is opened while the app is locked
+func (t *TrayService) SetAuthService(authService *AuthService) {
+	t.authService = authService
+}
+
+// SetSettingsService sets the settings service behind the tray's
+// notifications toggle, and rebuilds the menu when the setting changes
+func (t *TrayService) SetSettingsService(settingsService *SettingsService) {
+	t.settingsService = settingsService
+	settingsService.Subscribe(func(change SettingsChange) {
+		if change.Changed("notifications_enabled") {
+			go t.RefreshMenu()
+		}
+	})
+}
+
 // SetWindow sets the main window reference
 func (t *TrayService) SetWindow(window application.Window) {
 	t.window = window
 }
 
+// SetWindowFactory sets a function that creates the main window the first
+// time the tray needs it. Used in tray-only mode, where no window exists at startup.
+func (t *TrayService) SetWindowFactory(factory func() application.Window) {
+	t.windowFactory = factory
+}
+
 // SetOnShowCallback sets a callback invoked when the app is restored from tray
 func (t *TrayService) SetOnShowCallback(cb func()) {
 	t.onShowCallback = cb
@@ -164,6 +190,14 @@ func (t *TrayService) populateMenu(menu *application.Menu) {
 		}
 	}
 
+	if t.settingsService != nil {
+		enabled := t.settingsService.IsNotificationsEnabled(context.Background())
+		menu.AddCheckbox("Notifications", enabled).OnClick(func(ctx *application.Context) {
+			t.setNotificationsEnabled(ctx.ClickedMenuItem().Checked())
+		})
+		menu.AddSeparator()
+	}
+
 	// Add standard items
 	menu.Add("Open GN Drive").OnClick(func(ctx *application.Context) {
 		t.showWindow()
@@ -176,16 +210,40 @@ func (t *TrayService) populateMenu(menu *application.Menu) {
 	})
 }
 
-// executeFlow emits an event for the frontend to execute a flow
+// executeFlow emits an event for the frontend to execute a flow.
+// Without a window (tray-only mode) there is no frontend to drive the flow,
+// so it runs in the backend instead.
 func (t *TrayService) executeFlow(flowId string) {
 	log.Printf("TrayService: Requesting flow execution %s", flowId)
+	if t.window == nil && t.flowService != nil {
+		go func() {
+			if err := t.flowService.RunFlow(context.Background(), flowId); err != nil {
+				log.Printf("TrayService: Flow %s failed: %v", flowId, err)
+			}
+		}()
+		return
+	}
 	if t.app != nil {
 		t.app.Event.Emit("tray:execute_flow", flowId)
 	}
 }
 
-// showWindow shows and focuses the main window
+// setNotificationsEnabled turns notifications on or off from the tray. The
+// menu is rebuilt from the settings once they change.
+func (t *TrayService) setNotificationsEnabled(enabled bool) {
+	go func() {
+		if err := t.settingsService.SetNotificationsEnabled(context.Background(), enabled); err != nil {
+			log.Printf("TrayService: Failed to set notifications: %v", err)
+		}
+	}()
+}
+
+// showWindow shows and focuses the main window, creating it first if needed
 func (t *TrayService) showWindow() {
+	if t.window == nil && t.windowFactory != nil {
+		log.Printf("TrayService: Creating main window on demand")
+		t.window = t.windowFactory()
+	}
 	if t.window != nil {
 		if t.onShowCallback != nil {
 			t.onShowCallback()
@@ -193,6 +251,9 @@ func (t *TrayService) showWindow() {
 		t.window.Show()
 		t.window.Focus()
 	}
+	if t.authService != nil {
+		t.authService.RequestUnlock(context.Background())
+	}
 }
 
 // quit exits the application
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"desktop/backend/models"
+	"desktop/backend/rclone"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	fsConfig "github.com/rclone/rclone/fs/config"
+)
+
+// usageDriftPollInterval is how often remotes due for a usage check are
+// looked for
+const usageDriftPollInterval = 15 * time.Minute
+
+// usageDriftCheckInterval is how long apart a remote's provider-reported
+// usage is compared with what the app wrote to it. About calls count
+// against API quotas, so usage is checked sparingly.
+const usageDriftCheckInterval = 6 * time.Hour
+
+// usageDriftMinBytes is the least unexplained growth that is flagged, so
+// metadata and provider bookkeeping don't raise alerts
+const usageDriftMinBytes = 256 * int64(fs.Mebi)
+
+// usageDriftPercent is how much more than it wrote, in percent of the bytes
+// written, a remote's usage must grow by to be flagged when that exceeds
+// usageDriftMinBytes
+const usageDriftPercent = 10
+
+// usageDriftFlagged reports whether usage grew by significantly more than
+// the app wrote. Shrinking usage isn't flagged: deletions and overwrites
+// free space the app doesn't count.
+func usageDriftFlagged(usageDelta, written int64) bool {
+	return usageDelta-written > max(usageDriftMinBytes, written*usageDriftPercent/100)
+}
+
+// writtenRemotes returns the configured remotes a run of profile writes to,
+// before crypt and compress wrapping rewrite its paths. Two-way runs write
+// to both sides. Local disks are left out: their usage changes with
+// everything else on them.
+func writtenRemotes(action SyncAction, profile models.Profile) []string {
+	var paths []string
+	switch action {
+	case ActionPull:
+		paths = []string{profile.From}
+	case ActionPush:
+		paths = profile.Destinations()
+	case ActionBi, ActionBiResync:
+		paths = []string{profile.From, profile.To}
+	}
+	var remotes []string
+	for _, p := range paths {
+		if remote := parseRemoteName(p); usageTrackable(remote) {
+			remotes = append(remotes, remote)
+		}
+	}
+	return remotes
+}
+
+// usageTrackable reports whether remote is a configured, non-local remote
+func usageTrackable(remote string) bool {
+	if remote == "" {
+		return false
+	}
+	provider, _ := fsConfig.FileGetValue(remote, "type")
+	return provider != "" && provider != "local"
+}
+
+// recordRemoteWrites adds the bytes a run transferred to the remotes it
+// wrote to, for the next usage check of each. A run writing to several
+// remotes credits all of them with its total, which can hide drift but not
+// raise a false alert.
+func (s *SyncService) recordRemoteWrites(task *SyncTask, remotes []string) {
+	status := task.latestStatus()
+	if status == nil || status.BytesTransferred <= 0 || len(remotes) == 0 {
+		return
+	}
+	db, err := GetSharedDB()
+	if err != nil {
+		return
+	}
+	for _, remote := range remotes {
+		// Remotes not checked yet have no row: their first check sets the baseline
+		if _, err := db.Exec("UPDATE remote_usage SET written = written + ? WHERE remote = ?", status.BytesTransferred, remote); err != nil {
+			log.Printf("[SyncService] Could not record the bytes written to %s: %v", remote, err)
+		}
+	}
+}
+
+// GetUsageDrift returns, for each remote whose usage is checked, how its
+// provider-reported usage changed over the last period between checks
+// against the bytes the app wrote to it, flagging remotes that grew by
+// significantly more, e.g. because something else writes to them or two
+// profiles sync into the same place
+func (s *SyncService) GetUsageDrift(ctx context.Context) ([]models.UsageDrift, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(`SELECT remote, used, usage_delta, written_bytes, flagged, period_start, checked_at
+		FROM remote_usage ORDER BY flagged DESC, remote`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query usage drift: %w", err)
+	}
+	defer rows.Close()
+
+	drifts := []models.UsageDrift{}
+	for rows.Next() {
+		var d models.UsageDrift
+		var flagged int
+		var periodStart sql.NullString
+		var checkedAt string
+		if err := rows.Scan(&d.Remote, &d.Used, &d.UsageDelta, &d.WrittenBytes, &flagged, &periodStart, &checkedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan usage drift: %w", err)
+		}
+		d.DriftBytes = d.UsageDelta - d.WrittenBytes
+		d.Flagged = flagged != 0
+		if periodStart.Valid {
+			if t, err := time.Parse(time.RFC3339, periodStart.String); err == nil {
+				d.PeriodStart = &t
+			}
+		}
+		d.CheckedAt, _ = time.Parse(time.RFC3339, checkedAt)
+		drifts = append(drifts, d)
+	}
+	return drifts, rows.Err()
+}
+
+// watchUsageDrift checks the usage of remotes profiles write to every
+// usageDriftCheckInterval until the scheduler stops
+func (s *SchedulerService) watchUsageDrift() {
+	ticker := time.NewTicker(usageDriftPollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.mutex.RLock()
+		stopped := s.stopped
+		s.mutex.RUnlock()
+		if stopped {
+			return
+		}
+		s.checkUsageDrift(context.Background(), time.Now())
+	}
+}
+
+// checkUsageDrift compares the usage of remotes due for a check with the
+// bytes written to them since their previous check, and notifies about
+// remotes that start drifting. Nothing is checked while syncs run, as their
+// bytes are only counted once they finish.
+func (s *SchedulerService) checkUsageDrift(ctx context.Context, now time.Time) {
+	if s.configService == nil || (s.syncService != nil && s.syncService.hasActiveTasks()) {
+		return
+	}
+	profiles, err := s.configService.GetProfiles(ctx)
+	if err != nil {
+		return
+	}
+	remotes := make(map[string]bool)
+	for _, p := range profiles {
+		for _, action := range []SyncAction{ActionPull, ActionPush} {
+			for _, remote := range writtenRemotes(action, p) {
+				remotes[remote] = true
+			}
+		}
+	}
+
+	for remote := range remotes {
+		drift, wasFlagged, err := checkRemoteUsage(ctx, remote, now)
+		if err != nil {
+			log.Printf("Warning: Could not check the usage of %s: %v", remote, err)
+			continue
+		}
+		if drift != nil && drift.Flagged && !wasFlagged {
+			s.sendUsageDriftAlert(*drift)
+		}
+	}
+	if err := pruneRemoteUsage(remotes); err != nil {
+		log.Printf("Warning: Could not prune remote usage: %v", err)
+	}
+}
+
+// checkRemoteUsage compares a remote's usage with the bytes written to it
+// when its last check is usageDriftCheckInterval old, and starts a new
+// period. A remote checked for the first time only gets its baseline.
+// Returns nil when the remote wasn't due, and whether it was flagged before.
+func checkRemoteUsage(ctx context.Context, remote string, now time.Time) (*models.UsageDrift, bool, error) {
+	db, err := GetSharedDB()
+	if err != nil {
+		return nil, false, err
+	}
+	var used, written int64
+	var since string
+	var wasFlagged int
+	err = db.QueryRow("SELECT used, written, since, flagged FROM remote_usage WHERE remote = ?", remote).Scan(&used, &written, &since, &wasFlagged)
+	known := err == nil
+	if err != nil && err != sql.ErrNoRows {
+		return nil, false, err
+	}
+	sinceTime, _ := time.Parse(time.RFC3339, since)
+	if known && now.Sub(sinceTime) < usageDriftCheckInterval {
+		return nil, wasFlagged != 0, nil
+	}
+
+	quota, err := rclone.About(ctx, remote)
+	if err != nil {
+		return nil, wasFlagged != 0, err
+	}
+	checkedAt := now.UTC().Format(time.RFC3339)
+	if !known {
+		_, err := db.Exec("INSERT INTO remote_usage (remote, used, since, checked_at) VALUES (?, ?, ?, ?)", remote, quota.Used, checkedAt, checkedAt)
+		return nil, false, err
+	}
+
+	drift := &models.UsageDrift{
+		Remote:       remote,
+		Used:         quota.Used,
+		UsageDelta:   quota.Used - used,
+		WrittenBytes: written,
+		PeriodStart:  &sinceTime,
+		CheckedAt:    now,
+	}
+	drift.DriftBytes = drift.UsageDelta - drift.WrittenBytes
+	drift.Flagged = usageDriftFlagged(drift.UsageDelta, drift.WrittenBytes)
+
+	// Bytes recorded since the count was read belong to the new period
+	if _, err := db.Exec(`UPDATE remote_usage SET used = ?, written = written - ?, since = ?, usage_delta = ?,
+		written_bytes = ?, flagged = ?, period_start = ?, checked_at = ? WHERE remote = ?`,
+		quota.Used, written, checkedAt, drift.UsageDelta, written, boolToInt(drift.Flagged), since, checkedAt, remote); err != nil {
+		return nil, wasFlagged != 0, err
+	}
+	return drift, wasFlagged != 0, nil
+}
+
+// pruneRemoteUsage forgets remotes no profile writes to anymore
+func pruneRemoteUsage(remotes map[string]bool) error {
+	db, err := GetSharedDB()
+	if err != nil {
+		return err
+	}
+	rows, err := db.Query("SELECT remote FROM remote_usage")
+	if err != nil {
+		return err
+	}
+	var stale []string
+	for rows.Next() {
+		var remote string
+		if rows.Scan(&remote) == nil && !remotes[remote] {
+			stale = append(stale, remote)
+		}
+	}
+	rows.Close()
+	for _, remote := range stale {
+		if _, err := db.Exec("DELETE FROM remote_usage WHERE remote = ?", remote); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendUsageDriftAlert notifies that a remote's usage grew by more than the
+// app wrote to it
+func (s *SchedulerService) sendUsageDriftAlert(drift models.UsageDrift) {
+	body := fmt.Sprintf("Usage of %s grew by %s since %s, but ng-drive only wrote %s to it. Something else may be writing to it, or profiles may overlap.",
+		drift.Remote, fs.SizeSuffix(drift.UsageDelta), drift.PeriodStart.Local().Format("Jan 2 15:04"), fs.SizeSuffix(drift.WrittenBytes))
+	log.Printf("[UsageDrift] %s", body)
+	if s.notificationService != nil {
+		s.notificationService.SendInAppNotification(context.Background(), NotifyCategoryUsageDrift, NotifySeverityWarning, "Unexpected Storage Growth", body)
+	}
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"desktop/backend/dto"
+	"testing"
+	"time"
+)
+
+func TestUsageDriftFlagged(t *testing.T) {
+	gib := int64(1 << 30)
+	tests := []struct {
+		name              string
+		usageDelta, wrote int64
+		want              bool
+	}{
+		{"grew as written", 10 * gib, 10 * gib, false},
+		{"shrank from deletions", -5 * gib, gib, false},
+		{"small overhead", usageDriftMinBytes, 0, false},
+		{"grew with nothing written", gib, 0, true},
+		{"within percent of large writes", 105 * gib, 100 * gib, false},
+		{"beyond percent of large writes", 120 * gib, 100 * gib, true},
+	}
+	for _, tt := range tests {
+		if got := usageDriftFlagged(tt.usageDelta, tt.wrote); got != tt.want {
+			t.Errorf("%s: usageDriftFlagged(%d, %d) = %v, want %v", tt.name, tt.usageDelta, tt.wrote, got, tt.want)
+		}
+	}
+}
+
+func TestRecordRemoteWrites(t *testing.T) {
+	db, _ := GetSharedDB()
+	db.Exec("DELETE FROM remote_usage")
+	now := time.Now().UTC().Format(time.RFC3339)
+	if _, err := db.Exec("INSERT INTO remote_usage (remote, used, since, checked_at) VALUES ('gdrive', 1000, ?, ?)", now, now); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &SyncService{}
+	task := &SyncTask{}
+	task.setLastStatus(&dto.SyncStatusDTO{BytesTransferred: 300})
+	s.recordRemoteWrites(task, []string{"gdrive", "unchecked"})
+
+	var written int64
+	db.QueryRow("SELECT written FROM remote_usage WHERE remote = 'gdrive'").Scan(&written)
+	if written != 300 {
+		t.Errorf("written = %d, want 300", written)
+	}
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM remote_usage").Scan(&count)
+	if count != 1 {
+		t.Errorf("expected remotes not checked yet to be left out, got %d rows", count)
+	}
+
+	drifts, err := s.GetUsageDrift(context.Background())
+	if err != nil {
+		t.Fatalf("GetUsageDrift failed: %v", err)
+	}
+	if len(drifts) != 1 || drifts[0].Remote != "gdrive" || drifts[0].Used != 1000 || drifts[0].PeriodStart != nil {
+		t.Errorf("unexpected usage drift before a second check: %+v", drifts)
+	}
+}
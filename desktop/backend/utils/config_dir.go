@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ConfigDirFlag is the command-line flag that moves the config directory
+const ConfigDirFlag = "--config-dir"
+
+// ConfigDirEnv is the environment variable that moves the config directory
+// when ConfigDirFlag isn't given
+const ConfigDirEnv = "GN_DRIVE_CONFIG_DIR"
+
+var (
+	configDir           string // resolved by SetConfigDir; "" until then
+	configDirOverridden bool   // set by the flag or the environment variable
+	configDirMutex      sync.RWMutex
+)
+
+// SetConfigDir resolves the directory holding auth.json, the database,
+// rclone.conf and profiles: flagDir (the --config-dir flag, "" if not given),
+// else $GN_DRIVE_CONFIG_DIR, else ~/.config/gn-drive. Called once at startup,
+// before the working directory changes, so relative paths are taken from the
+// directory the app was started in.
+func SetConfigDir(flagDir string) string {
+	dir := flagDir
+	if dir == "" {
+		dir = os.Getenv(ConfigDirEnv)
+	}
+	overridden := dir != ""
+	if overridden {
+		if expanded, err := ExpandHomePath(dir); err == nil {
+			dir = expanded
+		} else {
+			log.Printf("Warning: Could not expand home path for config directory: %v", err)
+		}
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+	} else {
+		dir = defaultConfigDir()
+	}
+
+	configDirMutex.Lock()
+	defer configDirMutex.Unlock()
+	configDir, configDirOverridden = dir, overridden
+	return dir
+}
+
+// ConfigDir returns the config directory resolved by SetConfigDir, or the
+// default one if SetConfigDir wasn't called
+func ConfigDir() string {
+	configDirMutex.RLock()
+	defer configDirMutex.RUnlock()
+	if configDir == "" {
+		return defaultConfigDir()
+	}
+	return configDir
+}
+
+// ConfigDirArgs returns the arguments that start another instance of the app
+// with the same config directory, e.g. at login; none for the default one
+func ConfigDirArgs() []string {
+	configDirMutex.RLock()
+	defer configDirMutex.RUnlock()
+	if !configDirOverridden {
+		return nil
+	}
+	return []string{ConfigDirFlag, configDir}
+}
+
+// instanceID identifies the app to the OS for single-instance detection
+const instanceID = "com.gnasdev.gn-drive"
+
+// InstanceID returns the id under which only one instance of the app runs.
+// Instances with a moved config directory get their own, so they can run
+// next to the one using the default directory without sharing its files.
+func InstanceID() string {
+	configDirMutex.RLock()
+	dir, overridden := configDir, configDirOverridden
+	configDirMutex.RUnlock()
+
+	if !overridden {
+		return instanceID
+	}
+	sum := sha256.Sum256([]byte(dir))
+	return instanceID + "-" + hex.EncodeToString(sum[:6])
+}
+
+// LogFilePath returns where the app writes a log file: the "logs" folder of
+// a moved config directory, otherwise the working directory
+func LogFilePath(name string) string {
+	configDirMutex.RLock()
+	dir, overridden := configDir, configDirOverridden
+	configDirMutex.RUnlock()
+
+	if overridden {
+		logDir := filepath.Join(dir, "logs")
+		err := os.MkdirAll(logDir, 0755)
+		if err == nil {
+			return filepath.Join(logDir, name)
+		}
+		log.Printf("Failed to create log directory: %v", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		log.Printf("Failed to get working directory: %v", err)
+		return name
+	}
+	return filepath.Join(wd, name)
+}
+
+// defaultConfigDir returns ~/.config/gn-drive
+func defaultConfigDir() string {
+	homeDir, err := GetUserHomeDir()
+	if err != nil {
+		log.Printf("Warning: Could not get user home directory, using relative paths: %v", err)
+		homeDir = "."
+	}
+	return filepath.Join(homeDir, ".config", "gn-drive")
+}
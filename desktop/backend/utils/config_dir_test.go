@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSetConfigDir(t *testing.T) {
+	defer SetConfigDir("")
+	envDir := t.TempDir()
+	flagDir := t.TempDir()
+	t.Setenv(ConfigDirEnv, envDir)
+
+	if got := SetConfigDir(""); got != envDir || ConfigDir() != envDir {
+		t.Errorf("config dir = %q, want the environment's %q", got, envDir)
+	}
+	if got := SetConfigDir(flagDir); got != flagDir {
+		t.Errorf("config dir = %q, want the flag's %q", got, flagDir)
+	}
+	if args := ConfigDirArgs(); !slices.Equal(args, []string{ConfigDirFlag, flagDir}) {
+		t.Errorf("ConfigDirArgs = %v", args)
+	}
+	if got, want := LogFilePath("desktop.log"), filepath.Join(flagDir, "logs", "desktop.log"); got != want {
+		t.Errorf("LogFilePath = %q, want %q", got, want)
+	}
+
+	if id := InstanceID(); id == instanceID {
+		t.Errorf("InstanceID = %q, want one of its own for a moved config dir", id)
+	}
+
+	if got := SetConfigDir("relative"); !filepath.IsAbs(got) {
+		t.Errorf("relative config dir %q was not made absolute", got)
+	}
+
+	t.Setenv(ConfigDirEnv, "")
+	if got := SetConfigDir(""); got != defaultConfigDir() || ConfigDirArgs() != nil {
+		t.Errorf("config dir = %q, args %v; want the default and no args", got, ConfigDirArgs())
+	}
+	if id := InstanceID(); id != instanceID {
+		t.Errorf("InstanceID = %q, want %q for the default config dir", id, instanceID)
+	}
+}
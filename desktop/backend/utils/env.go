@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	beConfig "desktop/backend/config"
@@ -54,18 +55,13 @@ func LoadEnvFile(filename string) error {
 }
 
 func LoadEnvConfigFromEnvStr(envConfigStr string) beConfig.Config {
-	// Create a Config struct with default values (using home directory paths)
-	homeDir, err := GetUserHomeDir()
-	if err != nil {
-		log.Printf("Warning: Could not get user home directory, using relative paths: %v", err)
-		homeDir = "."
-	}
-
+	// Create a Config struct with default values (using config directory paths)
+	configDir := ConfigDir()
 	cfg := beConfig.Config{
 		DebugMode:       false,
-		ProfileFilePath: filepath.Join(homeDir, ".config", "gn-drive", "profiles.json"),
-		ResyncFilePath:  filepath.Join(homeDir, ".config", "gn-drive", "resync"),
-		RcloneFilePath:  filepath.Join(homeDir, ".config", "gn-drive", "rclone.conf"),
+		ProfileFilePath: filepath.Join(configDir, "profiles.json"),
+		ResyncFilePath:  filepath.Join(configDir, "resync"),
+		RcloneFilePath:  filepath.Join(configDir, "rclone.conf"),
 	}
 
 	// Parse the env string manually to avoid Viper concurrency issues
@@ -115,6 +111,18 @@ func LoadEnvConfigFromEnvStr(envConfigStr string) beConfig.Config {
 				log.Printf("Warning: Could not expand home path for RCLONE_FILE_PATH: %v", err)
 				cfg.RcloneFilePath = value
 			}
+		case "MAX_LOG_MESSAGES_PER_STATUS":
+			if n, err := strconv.Atoi(value); err == nil {
+				cfg.MaxLogMessagesPerStatus = n
+			} else {
+				log.Printf("Warning: Invalid MAX_LOG_MESSAGES_PER_STATUS %q: %v", value, err)
+			}
+		case "LOG_STREAM_BUFFER_SIZE":
+			if n, err := strconv.Atoi(value); err == nil {
+				cfg.LogStreamBufferSize = n
+			} else {
+				log.Printf("Warning: Invalid LOG_STREAM_BUFFER_SIZE %q: %v", value, err)
+			}
 		}
 	}
 
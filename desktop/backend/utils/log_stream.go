@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rclone/rclone/fs/accounting"
+)
+
+const (
+	// DefaultMaxLogMessagesPerStatus caps the log messages attached to a status DTO
+	DefaultMaxLogMessagesPerStatus = 50
+	// DefaultLogStreamBufferSize is the per-task log stream channel capacity
+	DefaultLogStreamBufferSize = 4096
+)
+
+var (
+	maxLogMessagesPerStatus atomic.Int64
+	logStreamBufferSize     atomic.Int64
+)
+
+func init() {
+	maxLogMessagesPerStatus.Store(DefaultMaxLogMessagesPerStatus)
+	logStreamBufferSize.Store(DefaultLogStreamBufferSize)
+}
+
+// SetLogLimits configures the per-status log cap and the log stream buffer
+// size. Values <= 0 restore the defaults. Streams opened earlier keep their size.
+func SetLogLimits(maxPerStatus, streamBufferSize int) {
+	if maxPerStatus <= 0 {
+		maxPerStatus = DefaultMaxLogMessagesPerStatus
+	}
+	if streamBufferSize <= 0 {
+		streamBufferSize = DefaultLogStreamBufferSize
+	}
+	maxLogMessagesPerStatus.Store(int64(maxPerStatus))
+	logStreamBufferSize.Store(int64(streamBufferSize))
+}
+
+// TaskStatsGroup returns the rclone stats group name used for a task's context
+func TaskStatsGroup(taskId int) string {
+	return fmt.Sprintf("task-%d", taskId)
+}
+
+// LogLine is a single captured log message on a task's log stream
+type LogLine struct {
+	Seq     uint64    // per-stream sequence number, gaps mean dropped lines
+	Message string    // cleaned message content
+	Time    time.Time // when the message was captured
+	Dropped uint64    // lines dropped right before this one because the reader fell behind
+}
+
+// LogStream carries every captured log line of a task, unlike the status DTO
+// which only holds the most recent ones. Publishing never blocks rclone: when
+// the reader falls behind the buffer fills up and lines are dropped, and the
+// next delivered line reports how many were lost.
+type LogStream struct {
+	lines   chan LogLine
+	seq     uint64
+	dropped uint64 // dropped since the last delivered line
+	total   atomic.Uint64
+	mu      sync.Mutex
+	closed  bool
+}
+
+var (
+	logStreams   = make(map[string]*LogStream) // stats group -> stream
+	logStreamsMu sync.Mutex
+)
+
+// OpenLogStream opens the log stream for a task. Log lines captured by any
+// operation running in the task's context are published to it until
+// CloseLogStream is called, which also closes the Lines channel.
+func OpenLogStream(taskId int) *LogStream {
+	stream := &LogStream{
+		lines: make(chan LogLine, logStreamBufferSize.Load()),
+	}
+
+	logStreamsMu.Lock()
+	defer logStreamsMu.Unlock()
+	if old, ok := logStreams[TaskStatsGroup(taskId)]; ok {
+		old.close()
+	}
+	logStreams[TaskStatsGroup(taskId)] = stream
+	return stream
+}
+
+// CloseLogStream closes a task's log stream
+func CloseLogStream(taskId int) {
+	logStreamsMu.Lock()
+	stream, ok := logStreams[TaskStatsGroup(taskId)]
+	delete(logStreams, TaskStatsGroup(taskId))
+	logStreamsMu.Unlock()
+
+	if ok {
+		stream.close()
+	}
+}
+
+// Lines returns the channel the stream's lines are delivered on
+func (s *LogStream) Lines() <-chan LogLine {
+	return s.lines
+}
+
+// Dropped returns the total number of lines dropped so far
+func (s *LogStream) Dropped() uint64 {
+	return s.total.Load()
+}
+
+// publish delivers a line without blocking; if the buffer is full the line
+// is counted as dropped
+func (s *LogStream) publish(message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+
+	s.seq++
+	line := LogLine{Seq: s.seq, Message: message, Time: time.Now(), Dropped: s.dropped}
+	select {
+	case s.lines <- line:
+		s.dropped = 0
+	default:
+		s.dropped++
+		s.total.Add(1)
+	}
+}
+
+func (s *LogStream) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.lines)
+	}
+}
+
+// logStreamFor returns the open log stream for the context's stats group, or nil
+func logStreamFor(ctx context.Context) *LogStream {
+	group, ok := accounting.StatsGroupFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	logStreamsMu.Lock()
+	defer logStreamsMu.Unlock()
+	return logStreams[group]
+}
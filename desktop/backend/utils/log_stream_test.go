@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rclone/rclone/fs/accounting"
+)
+
+func TestLogStreamDropsWhenFull(t *testing.T) {
+	SetLogLimits(0, 2)
+	defer SetLogLimits(0, 0)
+
+	stream := OpenLogStream(42)
+	ctx := accounting.WithStatsGroup(context.Background(), TaskStatsGroup(42))
+	if logStreamFor(ctx) != stream {
+		t.Fatal("expected the stream to be found by the task's stats group")
+	}
+
+	for _, msg := range []string{"a", "b", "c", "d"} {
+		stream.publish(msg)
+	}
+	if got := stream.Dropped(); got != 2 {
+		t.Errorf("expected 2 dropped lines, got %d", got)
+	}
+
+	// Free the buffer; the next delivered line reports the gap
+	<-stream.Lines()
+	<-stream.Lines()
+	stream.publish("e")
+	CloseLogStream(42)
+
+	var lines []LogLine
+	for line := range stream.Lines() {
+		lines = append(lines, line)
+	}
+	if len(lines) != 1 || lines[0].Message != "e" || lines[0].Dropped != 2 || lines[0].Seq != 5 {
+		t.Errorf("unexpected lines after drain: %+v", lines)
+	}
+
+	// Publishing after close is a no-op
+	stream.publish("f")
+	if logStreamFor(ctx) != nil {
+		t.Error("expected the stream to be unregistered after close")
+	}
+}
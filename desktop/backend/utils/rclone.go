@@ -52,8 +52,13 @@ func RunRcloneWithRetryAndStats(ctx context.Context, retry bool, showStats bool,
 
 	cmd.SigInfoHandler()
 
+	budget := RetryBudgetFromContext(ctx)
+	var retryStart time.Time
 	for try := 1; try <= fsConfig.Retries; try++ {
 		cmdErr = cb()
+		if try > 1 {
+			budget.Spend(time.Since(retryStart))
+		}
 		cmdErr = fs.CountError(ctx, cmdErr)
 		lastErr := stats.GetLastError()
 		if cmdErr == nil {
@@ -73,6 +78,11 @@ func RunRcloneWithRetryAndStats(ctx context.Context, retry bool, showStats bool,
 			fs.Errorf(nil, "Can't retry any of the errors - not attempting retries")
 			break
 		}
+		if try < fsConfig.Retries && !budget.Take() {
+			fs.Errorf(nil, "Attempt %d/%d failed with %d errors and the retry budget is exhausted - not attempting retries", try, fsConfig.Retries, stats.GetErrors())
+			break
+		}
+		retryStart = time.Now()
 		if retryAfter := stats.RetryAfter(); !retryAfter.IsZero() {
 			d := time.Until(retryAfter)
 			if d > 0 {
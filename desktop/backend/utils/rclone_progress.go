@@ -19,8 +19,8 @@ import (
 const (
 	// interval between progress status emissions
 	defaultProgressInterval = 500 * time.Millisecond
-	// maximum log messages per status DTO to prevent unbounded growth
-	maxLogMessagesPerStatus = 50
+	// how long the final status DTO waits for room on a full channel
+	finalStatusTimeout = 2 * time.Second
 )
 
 // extractLogContent strips rclone log prefixes (stats group + timestamp + level)
@@ -113,8 +113,9 @@ func formatDuration(d time.Duration) string {
 
 // createStatusFromStats builds a SyncStatusDTO from rclone accounting stats.
 // Identity fields (Id, TabId, Action) are NOT set — the service layer sets them.
-// Returns the status DTO and the set of currently-checking file names (for accumulation tracking).
-func createStatusFromStats(ctx context.Context, startTime time.Time, logMessages []string) (*dto.SyncStatusDTO, map[string]struct{}) {
+// The DTO only lists active transfers and checks; finished transfers are
+// reported once through the tracker's per-file transitions instead.
+func createStatusFromStats(ctx context.Context, startTime time.Time, logMessages []string, tracker *transferTracker) *dto.SyncStatusDTO {
 	stats := accounting.Stats(ctx)
 
 	syncStatus := &dto.SyncStatusDTO{
@@ -123,9 +124,6 @@ func createStatusFromStats(ctx context.Context, startTime time.Time, logMessages
 		Timestamp: time.Now(),
 	}
 
-	// Track currently-checking file names for the caller's accumulation logic
-	currentlyChecking := make(map[string]struct{})
-
 	// Use RemoteStats for accurate totals, speed, ETA, and per-file transfer info
 	remoteStats, err := stats.RemoteStats(false)
 	if err == nil {
@@ -192,7 +190,7 @@ func createStatusFromStats(ctx context.Context, startTime time.Time, logMessages
 			}
 		}
 
-		// Build per-file transfer list
+		// Build the active transfer list
 		var transfers []dto.FileTransferInfo
 
 		// In-progress transfers
@@ -212,19 +210,28 @@ func createStatusFromStats(ctx context.Context, startTime time.Time, logMessages
 					if pct, ok := tr["percentage"].(int); ok {
 						fi.Progress = float64(pct)
 					}
+					// Fractional progress, so chunks of multi-GB files show up
+					if fi.Size > 0 {
+						fi.Progress = float64(fi.Bytes) / float64(fi.Size) * 100
+					}
 					if speed, ok := tr["speed"].(float64); ok {
 						fi.Speed = speed
 					}
-					transfers = append(transfers, fi)
+					if speed, ok := tr["speedAvg"].(float64); ok {
+						fi.CurrentSpeed = speed
+					}
+					if eta, ok := tr["eta"].(float64); ok {
+						fi.ETA = int64(eta)
+					}
+					transfers = append(transfers, tracker.observeActive(fi))
 				}
 			}
 		}
 
-		// In-progress checks — also collect names for accumulation tracking
+		// In-progress checks
 		if v, ok := remoteStats["checking"]; ok && v != nil {
 			if checkList, ok := v.([]string); ok {
 				for _, name := range checkList {
-					currentlyChecking[name] = struct{}{}
 					transfers = append(transfers, dto.FileTransferInfo{
 						Name:   name,
 						Status: "checking",
@@ -233,8 +240,11 @@ func createStatusFromStats(ctx context.Context, startTime time.Time, logMessages
 			}
 		}
 
-		// Completed/failed transfers
+		// Completed/failed transfers only produce transitions
 		for _, tr := range stats.Transferred() {
+			if tr.Checked {
+				continue
+			}
 			fi := dto.FileTransferInfo{
 				Name:  tr.Name,
 				Size:  tr.Size,
@@ -243,14 +253,15 @@ func createStatusFromStats(ctx context.Context, startTime time.Time, logMessages
 			if tr.Error != nil {
 				fi.Status = "failed"
 				fi.Error = tr.Error.Error()
-			} else if tr.Checked {
-				fi.Status = "checked"
-				fi.Progress = 100
 			} else {
 				fi.Status = "completed"
 				fi.Progress = 100
 			}
-			transfers = append(transfers, fi)
+			var elapsed time.Duration
+			if !tr.StartedAt.IsZero() && !tr.CompletedAt.IsZero() {
+				elapsed = tr.CompletedAt.Sub(tr.StartedAt)
+			}
+			tracker.observeFinished(fi, elapsed)
 		}
 
 		syncStatus.Transfers = transfers
@@ -292,33 +303,23 @@ func createStatusFromStats(ctx context.Context, startTime time.Time, logMessages
 		syncStatus.Status = "running"
 	}
 
-	// Derive error/check counts from the actual transfer list so displayed
-	// counts match the items shown (rclone's raw counters can diverge due to
-	// retries, transient errors, and internal list caps).
-	if len(syncStatus.Transfers) > 0 {
-		var errCount int
-		var checkCount int64
-		for _, ft := range syncStatus.Transfers {
-			switch ft.Status {
-			case "failed":
-				errCount++
-			case "checked", "checking":
-				checkCount++
-			}
-		}
-		syncStatus.Errors = errCount
-		syncStatus.Checks = checkCount
+	// Count failed files rather than rclone's raw error counter, so the count
+	// matches the failed transitions reported (the raw counter also includes
+	// retried attempts and transient errors)
+	if tracker.seen() {
+		syncStatus.Errors = tracker.failed
 	}
 
-	// Attach accumulated log messages (capped to prevent unbounded growth)
-	if len(logMessages) > maxLogMessagesPerStatus {
-		logMessages = logMessages[len(logMessages)-maxLogMessagesPerStatus:]
+	// Attach accumulated log messages (capped to prevent unbounded growth;
+	// the task's log stream carries the full set)
+	if max := int(maxLogMessagesPerStatus.Load()); len(logMessages) > max {
+		logMessages = logMessages[len(logMessages)-max:]
 	}
 	if len(logMessages) > 0 {
 		syncStatus.LogMessages = logMessages
 	}
 
-	return syncStatus, currentlyChecking
+	return syncStatus
 }
 
 // startProgress starts capturing rclone logs and producing structured SyncStatusDTO
@@ -334,10 +335,16 @@ func startProgress(ctx context.Context, outStatus chan *dto.SyncStatusDTO) func(
 	var logMu sync.Mutex
 	var logAccum []string
 
+	// Full log stream for the task, if the caller opened one
+	stream := logStreamFor(ctx)
+
 	appendLog := func(msg string) {
 		logMu.Lock()
 		logAccum = append(logAccum, msg)
 		logMu.Unlock()
+		if stream != nil {
+			stream.publish(msg)
+		}
 	}
 
 	drainLogs := func() []string {
@@ -361,6 +368,13 @@ func startProgress(ctx context.Context, outStatus chan *dto.SyncStatusDTO) func(
 		}
 	}
 
+	// Per-file transitions go to the task's transfer handler, if any
+	var onTransfer func(TransferTransition)
+	if group, ok := accounting.StatsGroupFromContext(ctx); ok {
+		onTransfer = transferHandlerFor(group)
+	}
+	tracker := newTransferTracker(onTransfer)
+
 	stopCh := make(chan struct{})
 	oldSyncPrint := operations.SyncPrintf
 
@@ -399,48 +413,12 @@ func startProgress(ctx context.Context, outStatus chan *dto.SyncStatusDTO) func(
 		ticker := time.NewTicker(defaultProgressInterval)
 		defer ticker.Stop()
 
-		// Accumulate completed checks by tracking the checking set across ticks.
-		// Files that leave remoteStats["checking"] between ticks are "done checking".
-		const maxCompletedChecks = 100
-		prevChecking := make(map[string]struct{})
-		var completedChecks []dto.FileTransferInfo
-
 		for {
 			select {
 			case <-ticker.C:
 				if !isClosed.Load() {
 					msgs := drainLogs()
-					status, curChecking := createStatusFromStats(ctx, startTime, msgs)
-
-					// Detect files that left the checking set → completed check
-					for name := range prevChecking {
-						if _, ok := curChecking[name]; !ok {
-							completedChecks = append(completedChecks, dto.FileTransferInfo{
-								Name:     name,
-								Status:   "checked",
-								Progress: 100,
-							})
-						}
-					}
-					prevChecking = curChecking
-
-					// Bound the accumulator
-					if len(completedChecks) > maxCompletedChecks {
-						completedChecks = completedChecks[len(completedChecks)-maxCompletedChecks:]
-					}
-
-					// Inject accumulated completed checks (skip duplicates already in list)
-					existingNames := make(map[string]struct{}, len(status.Transfers))
-					for _, ft := range status.Transfers {
-						existingNames[ft.Name] = struct{}{}
-					}
-					for _, cc := range completedChecks {
-						if _, exists := existingNames[cc.Name]; !exists {
-							status.Transfers = append(status.Transfers, cc)
-						}
-					}
-
-					safeSend(status)
+					safeSend(createStatusFromStats(ctx, startTime, msgs, tracker))
 				}
 			case <-stopCh:
 				return
@@ -459,15 +437,15 @@ func startProgress(ctx context.Context, outStatus chan *dto.SyncStatusDTO) func(
 		close(stopCh)
 		// 4. Wait for goroutine to finish
 		wg.Wait()
-		// 5. Emit one final DTO with any remaining accumulated messages
-		remaining := drainLogs()
-		if len(remaining) > 0 {
-			finalStatus, _ := createStatusFromStats(ctx, startTime, remaining)
-			// Direct send (non-blocking) — channel might be full
-			select {
-			case outStatus <- finalStatus:
-			default:
-			}
+		// 5. Emit one final DTO with any remaining accumulated messages; this
+		// also reports transitions for transfers that finished after the last tick
+		finalStatus := createStatusFromStats(ctx, startTime, drainLogs(), tracker)
+		finalStatus.Report = tracker.buildReport(TransferReportSize)
+		// The final DTO carries the run report, so give the consumer a moment
+		// to make room rather than dropping it outright
+		select {
+		case outStatus <- finalStatus:
+		case <-time.After(finalStatusTimeout):
 		}
 		// NOTE: Do NOT close outStatus here — the caller is responsible
 	}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RetryBudget caps the retries of all the syncs that share it, such as the
+// cards of a board run, so that many flaky syncs fail fast together instead
+// of each retrying on its own. The budget is checked before each retry; a
+// zero limit doesn't cap.
+type RetryBudget struct {
+	MaxRetries  int           // retries of all syncs together
+	MaxDuration time.Duration // time spent in retries of all syncs together, waits included
+
+	mu        sync.Mutex
+	retries   int
+	spent     time.Duration
+	exhausted bool
+}
+
+type retryBudgetKey struct{}
+
+// WithRetryBudget returns a context whose syncs draw their retries from budget
+func WithRetryBudget(ctx context.Context, budget *RetryBudget) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, budget)
+}
+
+// RetryBudgetFromContext returns the budget set by WithRetryBudget, or nil
+func RetryBudgetFromContext(ctx context.Context) *RetryBudget {
+	budget, _ := ctx.Value(retryBudgetKey{}).(*RetryBudget)
+	return budget
+}
+
+// Take reports whether a retry may run, counting it if so. Once a retry
+// is refused, the budget stays exhausted. A nil budget allows every retry.
+func (b *RetryBudget) Take() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.exhausted || (b.MaxRetries > 0 && b.retries >= b.MaxRetries) || (b.MaxDuration > 0 && b.spent >= b.MaxDuration) {
+		b.exhausted = true
+		return false
+	}
+	b.retries++
+	return true
+}
+
+// Spend adds time spent retrying
+func (b *RetryBudget) Spend(d time.Duration) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.spent += d
+}
+
+// Usage returns the retries and time used so far, and whether a retry has
+// been refused
+func (b *RetryBudget) Usage() (retries int, spent time.Duration, exhausted bool) {
+	if b == nil {
+		return 0, 0, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.retries, b.spent, b.exhausted
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rclone/rclone/fs"
+	"github.com/rclone/rclone/fs/accounting"
+	"github.com/rclone/rclone/fs/fserrors"
+)
+
+func TestRetryBudget(t *testing.T) {
+	budget := &RetryBudget{MaxRetries: 2}
+	if !budget.Take() || !budget.Take() {
+		t.Fatal("expected two retries to be allowed")
+	}
+	if budget.Take() {
+		t.Fatal("expected the third retry to be refused")
+	}
+	if retries, _, exhausted := budget.Usage(); retries != 2 || !exhausted {
+		t.Errorf("Usage() = %d, %v", retries, exhausted)
+	}
+
+	timed := &RetryBudget{MaxDuration: time.Minute}
+	timed.Spend(2 * time.Minute)
+	if timed.Take() {
+		t.Error("expected a spent time budget to refuse retries")
+	}
+
+	var none *RetryBudget
+	if !none.Take() {
+		t.Error("a nil budget should allow retries")
+	}
+}
+
+func TestRunRcloneWithRetryAndStats_Budget(t *testing.T) {
+	accounting.Start(context.Background()) // counts the errors syncs return, as at app startup
+	budget := &RetryBudget{MaxRetries: 3}
+	run := func(group string) int {
+		ctx, ci := fs.AddConfig(context.Background())
+		ci.Retries = 3
+		ctx = accounting.WithStatsGroup(WithRetryBudget(ctx, budget), group)
+		tries := 0
+		_ = RunRcloneWithRetryAndStats(ctx, true, false, nil, func() error {
+			tries++
+			return fserrors.RetryError(errors.New("flaky"))
+		})
+		return tries
+	}
+
+	// The first sync uses 2 of the 3 shared retries, the second the last one
+	if tries := run("budget-1"); tries != 3 {
+		t.Errorf("first sync ran %d times, want 3", tries)
+	}
+	if tries := run("budget-2"); tries != 2 {
+		t.Errorf("second sync ran %d times, want 2", tries)
+	}
+	if tries := run("budget-3"); tries != 1 {
+		t.Errorf("third sync ran %d times, want 1", tries)
+	}
+}
@@ -0,0 +1,236 @@
+package utils
+
+import (
+	"desktop/backend/dto"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TransferReportSize is how many files each list of a run's transfer report holds
+const TransferReportSize = 10
+
+// LargeFileSize is the size from which a file's progress is reported per
+// chunk rather than per whole percent
+const LargeFileSize = 1 << 30
+
+// largeFileProgressChunk is how many bytes of a large file are transferred
+// between two progress transitions
+const largeFileProgressChunk = 32 << 20
+
+// FileStallTimeout is how long a transfer may go without moving any bytes
+// before it is reported as stalled
+const FileStallTimeout = 60 * time.Second
+
+// TransferPhase is a per-file transfer lifecycle transition
+type TransferPhase string
+
+const (
+	TransferStarted    TransferPhase = "started"
+	TransferProgressed TransferPhase = "progressed"
+	TransferStalled    TransferPhase = "stalled"
+	TransferCompleted  TransferPhase = "completed"
+	TransferFailed     TransferPhase = "failed"
+)
+
+// TransferTransition is reported once each time a file's transfer changes
+// phase, and on progress only when its whole-percent progress changes (or,
+// for large files, each time another chunk is transferred). A stalled file
+// reports progress again once its bytes move.
+type TransferTransition struct {
+	Phase    TransferPhase
+	Transfer dto.FileTransferInfo
+}
+
+var (
+	transferHandlers   = make(map[string]func(TransferTransition)) // stats group -> handler
+	transferHandlersMu sync.Mutex
+)
+
+// WatchTransfers registers a handler for the per-file transfer transitions
+// of a task. The handler is called from the progress goroutine, so it should
+// not block for long.
+func WatchTransfers(taskId int, handler func(TransferTransition)) {
+	transferHandlersMu.Lock()
+	defer transferHandlersMu.Unlock()
+	transferHandlers[TaskStatsGroup(taskId)] = handler
+}
+
+// UnwatchTransfers removes a task's transfer handler
+func UnwatchTransfers(taskId int) {
+	transferHandlersMu.Lock()
+	defer transferHandlersMu.Unlock()
+	delete(transferHandlers, TaskStatsGroup(taskId))
+}
+
+func transferHandlerFor(group string) func(TransferTransition) {
+	transferHandlersMu.Lock()
+	defer transferHandlersMu.Unlock()
+	return transferHandlers[group]
+}
+
+// transferTracker diffs successive stats snapshots into per-file transitions.
+// It is only used from one goroutine at a time.
+type transferTracker struct {
+	active   map[string]*activeTransfer      // transferring file -> what was last seen of it
+	finished map[string]bool                 // finished file -> whether it failed
+	files    map[string]*dto.FileReportEntry // per-file attempts and last attempt, for the run report
+	failed   int                             // files whose latest attempt failed
+	emit     func(TransferTransition)
+	now      func() time.Time
+}
+
+// activeTransfer is what the tracker last saw of an in-progress transfer
+type activeTransfer struct {
+	pct       int       // last reported percentage
+	bytes     int64     // last reported bytes
+	lastBytes int64     // bytes in the latest snapshot
+	lastMoved time.Time // when its bytes last moved
+	stalled   bool
+}
+
+func newTransferTracker(emit func(TransferTransition)) *transferTracker {
+	return &transferTracker{
+		active:   make(map[string]*activeTransfer),
+		finished: make(map[string]bool),
+		files:    make(map[string]*dto.FileReportEntry),
+		emit:     emit,
+		now:      time.Now,
+	}
+}
+
+// notify reports a transition and counts attempts for the run report
+func (t *transferTracker) notify(phase TransferPhase, fi dto.FileTransferInfo) {
+	if phase == TransferStarted {
+		entry, ok := t.files[fi.Name]
+		if !ok {
+			entry = &dto.FileReportEntry{Name: fi.Name}
+			t.files[fi.Name] = entry
+		}
+		entry.Attempts++
+	}
+	if t.emit != nil {
+		t.emit(TransferTransition{Phase: phase, Transfer: fi})
+	}
+}
+
+// observeActive records an in-progress transfer and returns it marked as
+// stalled if its bytes haven't moved for FileStallTimeout
+func (t *transferTracker) observeActive(fi dto.FileTransferInfo) dto.FileTransferInfo {
+	now := t.now()
+	pct := int(fi.Progress)
+	at, ok := t.active[fi.Name]
+	if !ok {
+		// New file, or a retry of a finished one
+		if failed, done := t.finished[fi.Name]; done {
+			if failed {
+				t.failed--
+			}
+			delete(t.finished, fi.Name)
+		}
+		t.active[fi.Name] = &activeTransfer{pct: pct, bytes: fi.Bytes, lastBytes: fi.Bytes, lastMoved: now}
+		t.notify(TransferStarted, fi)
+		return fi
+	}
+
+	if fi.Bytes != at.lastBytes {
+		at.lastBytes = fi.Bytes
+		at.lastMoved = now
+		if at.stalled {
+			at.stalled = false
+			at.pct, at.bytes = pct, fi.Bytes
+			t.notify(TransferProgressed, fi)
+			return fi
+		}
+	} else if idle := now.Sub(at.lastMoved); idle >= FileStallTimeout {
+		fi.Stalled = true
+		fi.StalledMs = idle.Milliseconds()
+		if !at.stalled {
+			at.stalled = true
+			t.notify(TransferStalled, fi)
+		}
+		return fi
+	}
+
+	chunked := fi.Size >= LargeFileSize && fi.Bytes-at.bytes >= largeFileProgressChunk
+	if pct != at.pct || chunked {
+		at.pct, at.bytes = pct, fi.Bytes
+		t.notify(TransferProgressed, fi)
+	}
+	return fi
+}
+
+// observeFinished records a completed or failed transfer and how long it took.
+// Files that finished between two snapshots get their started transition first.
+func (t *transferTracker) observeFinished(fi dto.FileTransferInfo, elapsed time.Duration) {
+	failed := fi.Status == "failed"
+	prev, done := t.finished[fi.Name]
+	if done && prev == failed {
+		return
+	}
+	if _, ok := t.active[fi.Name]; !ok && !done {
+		t.notify(TransferStarted, fi)
+	}
+	delete(t.active, fi.Name)
+
+	if done && prev {
+		t.failed--
+	}
+	t.finished[fi.Name] = failed
+	entry := t.files[fi.Name]
+	entry.Size = fi.Size
+	entry.DurationMs = elapsed.Milliseconds()
+	entry.Speed = 0
+	if elapsed > 0 {
+		entry.Speed = float64(fi.Bytes) / elapsed.Seconds()
+	}
+	entry.Failed = failed
+	if failed {
+		t.failed++
+		t.notify(TransferFailed, fi)
+	} else {
+		t.notify(TransferCompleted, fi)
+	}
+}
+
+// seen reports whether any transfer has finished
+func (t *transferTracker) seen() bool {
+	return len(t.finished) > 0
+}
+
+// buildReport returns the top-n slowest, largest and most-retried finished
+// files, or nil if no transfer finished
+func (t *transferTracker) buildReport(n int) *dto.TransferReport {
+	var entries []dto.FileReportEntry
+	for name := range t.finished {
+		entries = append(entries, *t.files[name])
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	// Stable sorts below keep ties in name order
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+	top := func(less func(a, b dto.FileReportEntry) bool, keep func(dto.FileReportEntry) bool) []dto.FileReportEntry {
+		sorted := make([]dto.FileReportEntry, 0, len(entries))
+		for _, e := range entries {
+			if keep(e) {
+				sorted = append(sorted, e)
+			}
+		}
+		sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+		if len(sorted) > n {
+			sorted = sorted[:n]
+		}
+		return sorted
+	}
+
+	return &dto.TransferReport{
+		Slowest: top(func(a, b dto.FileReportEntry) bool { return a.DurationMs > b.DurationMs },
+			func(e dto.FileReportEntry) bool { return !e.Failed }),
+		Largest: top(func(a, b dto.FileReportEntry) bool { return a.Size > b.Size },
+			func(e dto.FileReportEntry) bool { return e.Size > 0 }),
+		MostRetried: top(func(a, b dto.FileReportEntry) bool { return a.Attempts > b.Attempts },
+			func(e dto.FileReportEntry) bool { return e.Attempts > 1 }),
+	}
+}
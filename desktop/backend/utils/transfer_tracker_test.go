@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"desktop/backend/dto"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTransferTrackerTransitions(t *testing.T) {
+	var got []string
+	tracker := newTransferTracker(func(tr TransferTransition) {
+		got = append(got, tr.Transfer.Name+":"+string(tr.Phase))
+	})
+
+	// a.txt progresses over several snapshots; repeated snapshots are quiet
+	tracker.observeActive(dto.FileTransferInfo{Name: "a.txt", Progress: 10})
+	tracker.observeActive(dto.FileTransferInfo{Name: "a.txt", Progress: 10})
+	tracker.observeActive(dto.FileTransferInfo{Name: "a.txt", Progress: 55})
+	tracker.observeFinished(dto.FileTransferInfo{Name: "a.txt", Status: "completed"}, time.Second)
+	tracker.observeFinished(dto.FileTransferInfo{Name: "a.txt", Status: "completed"}, time.Second)
+
+	// b.txt finished between snapshots, failed, then a retry succeeded
+	tracker.observeFinished(dto.FileTransferInfo{Name: "b.txt", Status: "failed"}, time.Second)
+	if tracker.failed != 1 {
+		t.Fatalf("expected 1 failed file, got %d", tracker.failed)
+	}
+	tracker.observeActive(dto.FileTransferInfo{Name: "b.txt", Progress: 0})
+	tracker.observeFinished(dto.FileTransferInfo{Name: "b.txt", Status: "completed"}, time.Second)
+
+	want := []string{
+		"a.txt:started", "a.txt:progressed", "a.txt:completed",
+		"b.txt:started", "b.txt:failed",
+		"b.txt:started", "b.txt:completed",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("transitions = %v, want %v", got, want)
+	}
+	if tracker.failed != 0 {
+		t.Errorf("expected no failed files after retry, got %d", tracker.failed)
+	}
+}
+
+func TestTransferTrackerReport(t *testing.T) {
+	tracker := newTransferTracker(nil)
+	if tracker.buildReport(2) != nil {
+		t.Fatal("expected no report before any transfer finished")
+	}
+
+	tracker.observeFinished(dto.FileTransferInfo{Name: "small", Size: 10, Bytes: 10, Status: "completed"}, time.Second)
+	tracker.observeFinished(dto.FileTransferInfo{Name: "big", Size: 1000, Bytes: 1000, Status: "completed"}, 2*time.Second)
+	tracker.observeFinished(dto.FileTransferInfo{Name: "flaky", Size: 100, Status: "failed"}, 5*time.Second)
+	tracker.observeActive(dto.FileTransferInfo{Name: "flaky"})
+	tracker.observeFinished(dto.FileTransferInfo{Name: "flaky", Size: 100, Bytes: 100, Status: "completed"}, 4*time.Second)
+	tracker.observeActive(dto.FileTransferInfo{Name: "pending"})
+
+	report := tracker.buildReport(2)
+	names := func(entries []dto.FileReportEntry) []string {
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.Name)
+		}
+		return out
+	}
+	if got := names(report.Slowest); !reflect.DeepEqual(got, []string{"flaky", "big"}) {
+		t.Errorf("slowest = %v", got)
+	}
+	if got := names(report.Largest); !reflect.DeepEqual(got, []string{"big", "flaky"}) {
+		t.Errorf("largest = %v", got)
+	}
+	if got := names(report.MostRetried); !reflect.DeepEqual(got, []string{"flaky"}) {
+		t.Errorf("most retried = %v", got)
+	}
+	if e := report.Slowest[0]; e.Attempts != 2 || e.DurationMs != 4000 || e.Speed != 25 {
+		t.Errorf("unexpected entry for flaky: %+v", e)
+	}
+}
+
+func TestTransferTrackerLargeFileAndStalls(t *testing.T) {
+	var got []string
+	tracker := newTransferTracker(func(tr TransferTransition) {
+		got = append(got, string(tr.Phase))
+	})
+	now := time.Now()
+	tracker.now = func() time.Time { return now }
+
+	// A 10 GiB file reports each chunk, not only each whole percent
+	size := int64(10 << 30)
+	file := func(bytes int64) dto.FileTransferInfo {
+		return dto.FileTransferInfo{Name: "big.iso", Size: size, Bytes: bytes, Progress: float64(bytes) / float64(size) * 100}
+	}
+	tracker.observeActive(file(0))
+	tracker.observeActive(file(largeFileProgressChunk / 2))
+	tracker.observeActive(file(largeFileProgressChunk))
+
+	// No bytes move for a while: it is reported stalled once
+	now = now.Add(FileStallTimeout)
+	if fi := tracker.observeActive(file(largeFileProgressChunk)); !fi.Stalled || fi.StalledMs != FileStallTimeout.Milliseconds() {
+		t.Errorf("expected the transfer to be stalled, got %+v", fi)
+	}
+	now = now.Add(time.Second)
+	tracker.observeActive(file(largeFileProgressChunk))
+
+	// Bytes move again
+	now = now.Add(time.Second)
+	if fi := tracker.observeActive(file(largeFileProgressChunk + 1)); fi.Stalled {
+		t.Error("expected the transfer to have resumed")
+	}
+
+	want := []string{"started", "progressed", "stalled", "progressed"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("transitions = %v, want %v", got, want)
+	}
+}
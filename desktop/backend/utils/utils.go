@@ -117,20 +117,16 @@ func RemoveTabMapping(pid int) {
 
 // logError logs the error to a file on the desktop, including stack trace and error line number
 func LogError(inErr error) {
-	if wd, err := os.Getwd(); err == nil {
-		if f, fileErr := os.OpenFile(filepath.Join(wd, "desktop.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); fileErr == nil {
-			defer func() {
-				if err := f.Close(); err != nil {
-					log.Printf("Warning: failed to close log file: %v", err)
-				}
-			}()
-			logger := log.New(f, "", log.LstdFlags)
-			logger.Printf("Error: %v\nStack Trace:\n%s", inErr, debug.Stack())
-		} else {
-			log.Printf("Failed to open log file: %v", fileErr)
-		}
+	if f, fileErr := os.OpenFile(LogFilePath("desktop.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644); fileErr == nil {
+		defer func() {
+			if err := f.Close(); err != nil {
+				log.Printf("Warning: failed to close log file: %v", err)
+			}
+		}()
+		logger := log.New(f, "", log.LstdFlags)
+		logger.Printf("Error: %v\nStack Trace:\n%s", inErr, debug.Stack())
 	} else {
-		log.Printf("Failed to get working directory: %v", err)
+		log.Printf("Failed to open log file: %v", fileErr)
 	}
 }
 
@@ -186,16 +182,11 @@ func ExpandHomePath(path string) (string, error) {
 	return path, nil
 }
 
-// MigrateConfigFiles migrates config files from old relative paths to new home directory paths
+// MigrateConfigFiles migrates config files from old relative paths to the config directory
 func MigrateConfigFiles() error {
-	homeDir, err := GetUserHomeDir()
-	if err != nil {
-		return err
-	}
-
 	// Define old and new paths
 	oldConfigDir := ".config"
-	newConfigDir := filepath.Join(homeDir, ".config", "gn-drive")
+	newConfigDir := ConfigDir()
 
 	// Create new config directory if it doesn't exist
 	if err := os.MkdirAll(newConfigDir, 0755); err != nil {
@@ -0,0 +1,182 @@
+package validation
+
+import (
+	"desktop/backend/models"
+	"fmt"
+	"strings"
+)
+
+// significantFilterSimilarity is the filter similarity above which two
+// profiles covering the same tree are considered to do the same work
+const significantFilterSimilarity = 0.5
+
+// DetectProfileOverlaps compares every pair of profiles and reports those that
+// transfer the same files (redundant work) or that can delete each other's
+// files (conflicting deletion rules)
+func DetectProfileOverlaps(profiles []models.Profile) []models.ProfileOverlap {
+	overlaps := []models.ProfileOverlap{}
+	for i := 0; i < len(profiles); i++ {
+		for j := i + 1; j < len(profiles); j++ {
+			if o, ok := compareProfiles(profiles[i], profiles[j]); ok {
+				overlaps = append(overlaps, o)
+			}
+		}
+	}
+	return overlaps
+}
+
+// ValidateMerge checks that the profiles can be folded into keep: they must
+// sync the same source to the same destination with the same pattern syntax
+func ValidateMerge(keep models.Profile, others []models.Profile) error {
+	if len(others) == 0 {
+		return &ValidationError{Field: "profiles", Message: "select at least one profile to merge"}
+	}
+	for _, o := range others {
+		if o.Name == keep.Name {
+			return &ValidationError{Field: "profiles", Message: fmt.Sprintf("cannot merge '%s' into itself", o.Name)}
+		}
+		if !samePath(o.From, keep.From) || !samePath(o.To, keep.To) {
+			return &ValidationError{Field: "profiles", Message: fmt.Sprintf("'%s' syncs different paths than '%s'", o.Name, keep.Name)}
+		}
+		if o.UseRegex != keep.UseRegex {
+			return &ValidationError{Field: "use_regex", Message: fmt.Sprintf("'%s' and '%s' use different pattern syntax", o.Name, keep.Name)}
+		}
+	}
+	return nil
+}
+
+// compareProfiles classifies how two profiles overlap, if they do
+func compareProfiles(a, b models.Profile) (models.ProfileOverlap, bool) {
+	o := models.ProfileOverlap{
+		Profiles:         []string{a.Name, b.Name},
+		FilterSimilarity: filterSimilarity(a, b),
+		Warnings:         []string{},
+	}
+	filtersOverlap := !disjointIncludes(a, b)
+
+	switch {
+	case samePath(a.From, b.From) && samePath(a.To, b.To):
+		o.Kind = models.OverlapDuplicate
+		o.Mergeable = true
+		if filtersOverlap {
+			o.Warnings = append(o.Warnings, fmt.Sprintf("'%s' and '%s' sync the same paths; running both repeats the same transfers", a.Name, b.Name))
+		}
+	case nestedPair(a, b) || nestedPair(b, a):
+		o.Kind = models.OverlapNested
+		if filtersOverlap && o.FilterSimilarity >= significantFilterSimilarity {
+			outer, inner := a, b
+			if nestedPair(b, a) {
+				outer, inner = b, a
+			}
+			o.Warnings = append(o.Warnings, fmt.Sprintf("'%s' already covers the paths of '%s'", outer.Name, inner.Name))
+		}
+	case samePath(a.From, b.To) && samePath(a.To, b.From):
+		o.Kind = models.OverlapReversed
+		o.Warnings = append(o.Warnings, fmt.Sprintf("'%s' and '%s' sync in opposite directions between the same paths; deletions on either side propagate back (consider one bisync profile)", a.Name, b.Name))
+	case pathsOverlap(a.To, b.To):
+		o.Kind = models.OverlapSharedDestination
+		if filtersOverlap {
+			o.Warnings = append(o.Warnings, fmt.Sprintf("'%s' and '%s' sync different sources into %s; each sync deletes files the other one copied", a.Name, b.Name, a.To))
+		}
+	default:
+		return o, false
+	}
+
+	// Deleting excluded files only conflicts when the destinations overlap
+	if o.Kind != models.OverlapReversed {
+		for _, p := range []models.Profile{a, b} {
+			if p.DeleteExcluded && o.FilterSimilarity < 1 {
+				o.Warnings = append(o.Warnings, fmt.Sprintf("'%s' deletes files it excludes from its destination, including files the other profile syncs", p.Name))
+			}
+		}
+	}
+
+	if len(o.Warnings) == 0 && !o.Mergeable {
+		return o, false
+	}
+	return o, true
+}
+
+// nestedPair reports whether inner's source and destination both lie within outer's
+func nestedPair(outer, inner models.Profile) bool {
+	return containsPath(outer.From, inner.From) && containsPath(outer.To, inner.To) &&
+		!(samePath(outer.From, inner.From) && samePath(outer.To, inner.To))
+}
+
+// filterSimilarity is the Jaccard similarity of the two profiles' include and
+// exclude rules; two profiles without rules are identical (1)
+func filterSimilarity(a, b models.Profile) float64 {
+	setA, setB := filterRuleSet(a), filterRuleSet(b)
+	if len(setA) == 0 && len(setB) == 0 {
+		return 1
+	}
+	shared := 0
+	for r := range setA {
+		if setB[r] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(setA)+len(setB)-shared)
+}
+
+// filterRuleSet returns a profile's filter rules as a set of "+ rule" / "- rule"
+func filterRuleSet(p models.Profile) map[string]bool {
+	set := make(map[string]bool)
+	for _, r := range p.IncludedPaths {
+		set["+ "+r] = true
+	}
+	for _, r := range p.ExcludedPaths {
+		set["- "+r] = true
+	}
+	return set
+}
+
+// disjointIncludes reports whether both profiles only include explicit
+// patterns and share none of them, so they transfer different files
+func disjointIncludes(a, b models.Profile) bool {
+	if len(a.IncludedPaths) == 0 || len(b.IncludedPaths) == 0 {
+		return false
+	}
+	for _, x := range a.IncludedPaths {
+		for _, y := range b.IncludedPaths {
+			if x == y || strings.HasPrefix(x, strings.TrimSuffix(y, "**")) || strings.HasPrefix(y, strings.TrimSuffix(x, "**")) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// splitEndpoint splits an rclone path into the remote (with colon, empty for
+// local paths) and the path without surrounding slashes
+func splitEndpoint(p string) (string, string) {
+	if strings.HasPrefix(p, "/") || (len(p) >= 2 && p[1] == ':' && len(p) > 2 && (p[2] == '\\' || p[2] == '/')) {
+		return "", strings.Trim(strings.ReplaceAll(p, "\\", "/"), "/")
+	}
+	if idx := strings.Index(p, ":"); idx != -1 {
+		return p[:idx+1], strings.Trim(p[idx+1:], "/")
+	}
+	return "", strings.Trim(p, "/")
+}
+
+// samePath reports whether two rclone paths name the same location
+func samePath(a, b string) bool {
+	remoteA, dirA := splitEndpoint(a)
+	remoteB, dirB := splitEndpoint(b)
+	return remoteA == remoteB && dirA == dirB
+}
+
+// containsPath reports whether child is parent or lies below it
+func containsPath(parent, child string) bool {
+	remoteP, dirP := splitEndpoint(parent)
+	remoteC, dirC := splitEndpoint(child)
+	if remoteP != remoteC {
+		return false
+	}
+	return dirP == "" || dirC == dirP || strings.HasPrefix(dirC, dirP+"/")
+}
+
+// pathsOverlap reports whether one path contains the other
+func pathsOverlap(a, b string) bool {
+	return containsPath(a, b) || containsPath(b, a)
+}
@@ -0,0 +1,105 @@
+package validation
+
+import (
+	"desktop/backend/models"
+	"testing"
+)
+
+func findOverlap(overlaps []models.ProfileOverlap, a, b string) *models.ProfileOverlap {
+	for i, o := range overlaps {
+		if (o.Profiles[0] == a && o.Profiles[1] == b) || (o.Profiles[0] == b && o.Profiles[1] == a) {
+			return &overlaps[i]
+		}
+	}
+	return nil
+}
+
+func TestDetectProfileOverlaps(t *testing.T) {
+	profiles := []models.Profile{
+		{Name: "docs", From: "/home/user/docs", To: "gdrive:backup/docs"},
+		{Name: "docs-copy", From: "/home/user/docs/", To: "gdrive:/backup/docs"},
+		{Name: "reports", From: "/home/user/docs/reports", To: "gdrive:backup/docs/reports"},
+		{Name: "photos", From: "/home/user/photos", To: "gdrive:backup/docs"},
+		{Name: "restore", From: "gdrive:backup/docs", To: "/home/user/docs"},
+		{Name: "music", From: "/home/user/music", To: "s3:music"},
+		{Name: "music-flac", From: "/home/user/music", To: "s3:music/flac", IncludedPaths: []string{"*.flac"}},
+	}
+
+	overlaps := DetectProfileOverlaps(profiles)
+
+	tests := []struct {
+		a, b      string
+		kind      string
+		mergeable bool
+	}{
+		{"docs", "docs-copy", models.OverlapDuplicate, true},
+		{"docs", "reports", models.OverlapNested, false},
+		{"docs", "photos", models.OverlapSharedDestination, false},
+		{"docs", "restore", models.OverlapReversed, false},
+	}
+	for _, tt := range tests {
+		o := findOverlap(overlaps, tt.a, tt.b)
+		if o == nil {
+			t.Errorf("expected %s/%s to overlap", tt.a, tt.b)
+			continue
+		}
+		if o.Kind != tt.kind || o.Mergeable != tt.mergeable || len(o.Warnings) == 0 {
+			t.Errorf("%s/%s: got %+v, want kind %s mergeable %v with warnings", tt.a, tt.b, o, tt.kind, tt.mergeable)
+		}
+	}
+
+	// Different destinations and sources don't overlap
+	if o := findOverlap(overlaps, "music", "docs"); o != nil {
+		t.Errorf("unexpected overlap: %+v", o)
+	}
+	// A nested profile with unrelated filters isn't redundant
+	if o := findOverlap(overlaps, "music", "music-flac"); o != nil {
+		t.Errorf("expected filtered nested profile not to be reported, got %+v", o)
+	}
+}
+
+func TestDetectProfileOverlaps_DeleteExcluded(t *testing.T) {
+	profiles := []models.Profile{
+		{Name: "a", From: "/data", To: "s3:data", ExcludedPaths: []string{"*.tmp"}, DeleteExcluded: true},
+		{Name: "b", From: "/data", To: "s3:data"},
+	}
+	o := findOverlap(DetectProfileOverlaps(profiles), "a", "b")
+	if o == nil || len(o.Warnings) != 2 {
+		t.Fatalf("expected a duplicate with a delete-excluded warning, got %+v", o)
+	}
+}
+
+func TestDisjointIncludes(t *testing.T) {
+	a := models.Profile{IncludedPaths: []string{"/photos/**"}}
+	b := models.Profile{IncludedPaths: []string{"/videos/**"}}
+	c := models.Profile{IncludedPaths: []string{"/photos/2024/**"}}
+	if !disjointIncludes(a, b) {
+		t.Error("expected photos and videos to be disjoint")
+	}
+	if disjointIncludes(a, c) {
+		t.Error("expected photos and photos/2024 to overlap")
+	}
+	if disjointIncludes(a, models.Profile{}) {
+		t.Error("expected a profile without includes to overlap everything")
+	}
+}
+
+func TestValidateMerge(t *testing.T) {
+	keep := models.Profile{Name: "docs", From: "/home/user/docs", To: "gdrive:backup"}
+
+	if err := ValidateMerge(keep, []models.Profile{{Name: "copy", From: "/home/user/docs/", To: "gdrive:/backup"}}); err != nil {
+		t.Errorf("expected same-path profiles to merge, got %v", err)
+	}
+	if err := ValidateMerge(keep, nil); err == nil {
+		t.Error("expected an error without profiles to merge")
+	}
+	if err := ValidateMerge(keep, []models.Profile{keep}); err == nil {
+		t.Error("expected an error merging a profile into itself")
+	}
+	if err := ValidateMerge(keep, []models.Profile{{Name: "other", From: "/home/user/docs", To: "gdrive:elsewhere"}}); err == nil {
+		t.Error("expected an error for a different destination")
+	}
+	if err := ValidateMerge(keep, []models.Profile{{Name: "regex", From: "/home/user/docs", To: "gdrive:backup", UseRegex: true}}); err == nil {
+		t.Error("expected an error for a different pattern syntax")
+	}
+}
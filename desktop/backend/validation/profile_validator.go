@@ -3,6 +3,7 @@ package validation
 import (
 	"desktop/backend/models"
 	"fmt"
+	"net"
 	"regexp"
 	"strings"
 	"time"
@@ -22,6 +23,15 @@ var remoteNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
 // sizeSuffixPattern matches rclone size suffix format: number followed by optional unit
 var sizeSuffixPattern = regexp.MustCompile(`^(?i)(\d+(\.\d+)?)\s*([KMGTPE](i?B)?|B)?$|^(?i)off$`)
 
+// storageClassPattern matches storage class names e.g. "STANDARD_IA", "GLACIER_IR"
+var storageClassPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// pathVariableNamePattern matches path variable names e.g. "PHOTOS_DIR"
+var pathVariableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// headerNamePattern matches HTTP header names
+var headerNamePattern = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
 // validConflictResolutions lists allowed bisync conflict resolution strategies
 var validConflictResolutions = map[string]bool{
 	"":        true, // empty = default (newer)
@@ -34,6 +44,21 @@ var validConflictResolutions = map[string]bool{
 	"path2":   true,
 }
 
+// validNotifyModes lists allowed per-profile notification overrides
+var validNotifyModes = map[string]bool{
+	"":         true, // empty = use global setting
+	"off":      true,
+	"failures": true,
+	"all":      true,
+}
+
+// validResumeModes lists what may happen at startup to an interrupted run
+var validResumeModes = map[string]bool{
+	models.ResumeInterruptedOffer:       true,
+	models.ResumeInterruptedRerun:       true,
+	models.ResumeInterruptedRetryFailed: true,
+}
+
 // ValidationError represents a validation error with field context
 type ValidationError struct {
 	Field   string
@@ -78,6 +103,12 @@ func (v *ProfileValidator) ValidateProfile(profile models.Profile) error {
 	if err := v.ValidateConflictResolution(profile.ConflictResolution); err != nil {
 		return err
 	}
+	if err := v.ValidateNotifyMode(profile.NotifyMode); err != nil {
+		return err
+	}
+	if err := v.ValidateResumeInterrupted(profile.ResumeInterrupted); err != nil {
+		return err
+	}
 	if err := v.ValidateMaxDelete(profile.MaxDelete); err != nil {
 		return err
 	}
@@ -96,6 +127,42 @@ func (v *ProfileValidator) ValidateProfile(profile models.Profile) error {
 	if err := v.ValidateRetries(profile.LowLevelRetries, "low_level_retries"); err != nil {
 		return err
 	}
+	if err := ValidateBindAddress(profile.BindAddress, profile.IPFamily); err != nil {
+		return err
+	}
+	if err := v.ValidateFanOut(profile); err != nil {
+		return err
+	}
+	if err := v.ValidateObjectOptions(profile); err != nil {
+		return err
+	}
+	if err := v.ValidateFailover(profile); err != nil {
+		return err
+	}
+	if err := v.ValidateSessions(profile); err != nil {
+		return err
+	}
+	if err := v.ValidateTransferOrder(profile); err != nil {
+		return err
+	}
+	if err := v.ValidateFreshnessTarget(profile.FreshnessTarget); err != nil {
+		return err
+	}
+	if err := v.ValidateLockedFiles(profile); err != nil {
+		return err
+	}
+	if err := v.ValidateCompression(profile); err != nil {
+		return err
+	}
+	if err := v.ValidateConflictNaming(profile); err != nil {
+		return err
+	}
+	if err := v.ValidateDeferDeletes(profile); err != nil {
+		return err
+	}
+	if err := v.ValidateDetectConflicts(profile); err != nil {
+		return err
+	}
 	if profile.UseRegex {
 		if err := v.ValidateRegexPatterns(profile.IncludedPaths, "included_paths"); err != nil {
 			return err
@@ -108,6 +175,224 @@ func (v *ProfileValidator) ValidateProfile(profile models.Profile) error {
 	return nil
 }
 
+// ValidateLockedFiles validates what a profile's runs do with source files
+// another application holds open
+func (v *ProfileValidator) ValidateLockedFiles(profile models.Profile) error {
+	switch profile.LockedFiles {
+	case "":
+		return nil
+	case models.LockedFilesRetry, models.LockedFilesSnapshot:
+	default:
+		return &ValidationError{Field: "locked_files", Message: "must be one of: retry, snapshot"}
+	}
+	if len(profile.FanOutTo) > 0 {
+		return &ValidationError{Field: "locked_files", Message: "cannot be combined with fan_out_to"}
+	}
+	return nil
+}
+
+// ValidateDeferDeletes validates for how many runs a profile keeps the files
+// deleted from its source before purging them from the destination
+func (v *ProfileValidator) ValidateDeferDeletes(profile models.Profile) error {
+	if profile.DeferDeletes == 0 {
+		return nil
+	}
+	if profile.DeferDeletes < 0 || profile.DeferDeletes > 1000 {
+		return &ValidationError{Field: "defer_deletes", Message: "must be between 0 and 1000"}
+	}
+	if len(profile.FanOutTo) > 0 {
+		return &ValidationError{Field: "defer_deletes", Message: "cannot be combined with fan_out_to"}
+	}
+	if profile.DeleteExcluded {
+		return &ValidationError{Field: "defer_deletes", Message: "cannot be combined with delete_excluded"}
+	}
+	return nil
+}
+
+// ValidateDetectConflicts validates that a profile's pushes and pulls can
+// leave out files changed on both sides
+func (v *ProfileValidator) ValidateDetectConflicts(profile models.Profile) error {
+	if !profile.DetectConflicts {
+		return nil
+	}
+	if len(profile.FanOutTo) > 0 {
+		return &ValidationError{Field: "detect_conflicts", Message: "cannot be combined with fan_out_to"}
+	}
+	// Files left out would be deleted from the destination
+	if profile.DeleteExcluded {
+		return &ValidationError{Field: "detect_conflicts", Message: "cannot be combined with delete_excluded"}
+	}
+	if profile.CompressDest {
+		return &ValidationError{Field: "detect_conflicts", Message: "cannot be combined with compress_dest"}
+	}
+	return nil
+}
+
+// ValidateCompression validates a profile's compression wrapping
+func (v *ProfileValidator) ValidateCompression(profile models.Profile) error {
+	switch profile.CompressMode {
+	case "", models.CompressGzip, models.CompressZstd:
+	default:
+		return &ValidationError{Field: "compress_mode", Message: "must be one of: gzip, zstd"}
+	}
+	if !profile.CompressDest {
+		if profile.CompressMode != "" {
+			return &ValidationError{Field: "compress_mode", Message: "requires compress_dest"}
+		}
+		return nil
+	}
+	if len(profile.FanOutTo) > 0 {
+		return &ValidationError{Field: "compress_dest", Message: "cannot be combined with fan_out_to"}
+	}
+	return nil
+}
+
+// ValidateConflictNaming validates how a profile names the conflict copies
+// its bisyncs keep
+func (v *ProfileValidator) ValidateConflictNaming(profile models.Profile) error {
+	naming := profile.ConflictNaming
+	if naming == nil {
+		return nil
+	}
+	switch profile.ConflictLoser {
+	case "num", "pathname":
+	default:
+		return &ValidationError{Field: "conflict_naming", Message: "requires conflict_loser num or pathname, which keep conflict copies"}
+	}
+	if naming.Folder == "" {
+		return nil
+	}
+	folder := strings.Trim(naming.Folder, "/")
+	if folder == "" || strings.HasPrefix(naming.Folder, "/") || strings.ContainsAny(folder, ":\\") {
+		return &ValidationError{Field: "conflict_naming", Message: "folder must be a path relative to the profile's paths"}
+	}
+	for _, part := range strings.Split(folder, "/") {
+		if part == "" || part == "." || part == ".." {
+			return &ValidationError{Field: "conflict_naming", Message: "folder must not contain empty, . or .. parts"}
+		}
+	}
+	return nil
+}
+
+// ValidateFanOut validates the extra destinations of a fan-out profile
+func (v *ProfileValidator) ValidateFanOut(profile models.Profile) error {
+	if profile.FanOutMode != models.FanOutSequential && profile.FanOutMode != models.FanOutParallel {
+		return &ValidationError{Field: "fan_out_mode", Message: fmt.Sprintf("invalid mode %q (must be empty or parallel)", profile.FanOutMode)}
+	}
+	if len(profile.FanOutTo) == 0 {
+		return nil
+	}
+	if profile.EncryptDest {
+		return &ValidationError{Field: "fan_out_to", Message: "cannot be combined with destination encryption"}
+	}
+	seen := map[string]bool{profile.From: true, profile.To: true}
+	for i, dest := range profile.FanOutTo {
+		field := fmt.Sprintf("fan_out_to[%d]", i)
+		if err := v.ValidateRclonePath(dest, field); err != nil {
+			return err
+		}
+		if dest == profile.From {
+			return &ValidationError{Field: field, Message: "cannot be the source path"}
+		}
+		if seen[dest] {
+			return &ValidationError{Field: field, Message: "duplicate destination"}
+		}
+		seen[dest] = true
+	}
+	return nil
+}
+
+// ValidateFailover validates the failover destination of a profile
+func (v *ProfileValidator) ValidateFailover(profile models.Profile) error {
+	if profile.FailoverTo == "" {
+		return nil
+	}
+	if err := v.ValidateRclonePath(profile.FailoverTo, "failover_to"); err != nil {
+		return err
+	}
+	if profile.FailoverTo == profile.To || profile.FailoverTo == profile.From {
+		return &ValidationError{Field: "failover_to", Message: "must differ from the source and destination paths"}
+	}
+	if len(profile.FanOutTo) > 0 {
+		return &ValidationError{Field: "failover_to", Message: "cannot be combined with fan-out destinations"}
+	}
+	return nil
+}
+
+// ValidateSessions validates the per-session transfer cap and order of a profile
+func (v *ProfileValidator) ValidateSessions(profile models.Profile) error {
+	switch profile.SessionOrder {
+	case "", models.SessionOrderSmallest, models.SessionOrderNewest:
+	default:
+		return &ValidationError{Field: "session_order", Message: "must be one of: smallest, newest"}
+	}
+	if profile.SessionTransfer == "" {
+		return nil
+	}
+	if err := v.ValidateSizeSuffix(profile.SessionTransfer, "session_transfer"); err != nil {
+		return err
+	}
+	if len(profile.FanOutTo) > 0 {
+		return &ValidationError{Field: "session_transfer", Message: "cannot be combined with fan-out destinations"}
+	}
+	return nil
+}
+
+// ValidateTransferOrder validates which files a profile's runs start first
+func (v *ProfileValidator) ValidateTransferOrder(profile models.Profile) error {
+	switch profile.TransferOrder {
+	case "":
+		return nil
+	case models.TransferOrderSmallest, models.TransferOrderLargest:
+	default:
+		return &ValidationError{Field: "transfer_order", Message: "must be one of: smallest, largest"}
+	}
+	if profile.SessionTransfer != "" {
+		return &ValidationError{Field: "transfer_order", Message: "cannot be combined with sessions, which transfer in session_order"}
+	}
+	if profile.OrderBy != "" {
+		return &ValidationError{Field: "transfer_order", Message: "cannot be combined with order_by"}
+	}
+	return nil
+}
+
+// ValidateFreshnessTarget validates how often a profile must complete a run
+func (v *ProfileValidator) ValidateFreshnessTarget(value string) error {
+	if value == "" {
+		return nil
+	}
+	if err := v.ValidateDuration(value, "freshness_target"); err != nil {
+		return err
+	}
+	if d, _ := time.ParseDuration(value); d < time.Minute {
+		return &ValidationError{Field: "freshness_target", Message: "must be at least 1m"}
+	}
+	return nil
+}
+
+// ValidateObjectOptions validates the storage class, upload headers and
+// server-side encryption of the objects a profile writes
+func (v *ProfileValidator) ValidateObjectOptions(profile models.Profile) error {
+	if profile.StorageClass != "" && !storageClassPattern.MatchString(profile.StorageClass) {
+		return &ValidationError{Field: "storage_class", Message: fmt.Sprintf("invalid storage class %q", profile.StorageClass)}
+	}
+	for i, h := range profile.UploadHeaders {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok || !headerNamePattern.MatchString(strings.TrimSpace(name)) || strings.ContainsAny(value, "\r\n") {
+			return &ValidationError{Field: fmt.Sprintf("upload_headers[%d]", i), Message: "must be a header like \"Cache-Control: max-age=86400\""}
+		}
+	}
+	switch profile.ServerSideEncryption {
+	case "", "AES256", "aws:kms":
+	default:
+		return &ValidationError{Field: "server_side_encryption", Message: "must be one of: AES256, aws:kms"}
+	}
+	if profile.SSEKMSKeyId != "" && profile.ServerSideEncryption != "aws:kms" {
+		return &ValidationError{Field: "sse_kms_key_id", Message: "requires server_side_encryption aws:kms"}
+	}
+	return nil
+}
+
 // ValidateName validates profile name
 func (v *ProfileValidator) ValidateName(name string) error {
 	if name == "" {
@@ -134,6 +419,24 @@ func (v *ProfileValidator) ValidateRclonePath(path string, fieldName string) err
 		return &ValidationError{Field: fieldName, Message: "path traversal not allowed"}
 	}
 
+	// Path variable, resolved to a local path on each machine at run time
+	if strings.HasPrefix(path, "${") {
+		name, rest, ok := SplitPathVariable(path)
+		if !ok {
+			return &ValidationError{Field: fieldName, Message: "path variable is missing its closing brace"}
+		}
+		if err := ValidatePathVariableName(name); err != nil {
+			return &ValidationError{Field: fieldName, Message: err.Error()}
+		}
+		if rest != "" && rest[0] != '/' && rest[0] != '\\' {
+			return &ValidationError{Field: fieldName, Message: "a path variable must be followed by a path separator"}
+		}
+		if strings.Contains(rest, "\x00") {
+			return &ValidationError{Field: fieldName, Message: "contains invalid characters"}
+		}
+		return nil
+	}
+
 	// Local path (starts with / on Unix or drive letter on Windows)
 	if strings.HasPrefix(path, "/") || (len(path) >= 2 && path[1] == ':') {
 		return v.validateLocalPath(path, fieldName)
@@ -143,6 +446,28 @@ func (v *ProfileValidator) ValidateRclonePath(path string, fieldName string) err
 	return v.validateRemotePath(path, fieldName)
 }
 
+// SplitPathVariable splits a path that starts with a path variable, e.g.
+// "${PHOTOS_DIR}/2024", into the variable's name and the rest of the path.
+// ok is false for other paths.
+func SplitPathVariable(path string) (name, rest string, ok bool) {
+	if !strings.HasPrefix(path, "${") {
+		return "", "", false
+	}
+	end := strings.IndexByte(path, '}')
+	if end < 0 {
+		return "", "", false
+	}
+	return path[2:end], path[end+1:], true
+}
+
+// ValidatePathVariableName validates the name of a path variable
+func ValidatePathVariableName(name string) error {
+	if !pathVariableNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid path variable name '%s' (letters, digits and underscores, not starting with a digit)", name)
+	}
+	return nil
+}
+
 // validateLocalPath validates a local filesystem path
 func (v *ProfileValidator) validateLocalPath(path string, fieldName string) error {
 	// Basic validation - path shouldn't be empty after prefix
@@ -168,6 +493,16 @@ func (v *ProfileValidator) validateRemotePath(path string, fieldName string) err
 	remoteName := parts[0]
 	remotePath := parts[1]
 
+	// Connection string options, e.g. "gdrive,root_folder_id=abc:" for a Drive computer
+	if name, options, ok := strings.Cut(remoteName, ","); ok {
+		remoteName = name
+		for _, option := range strings.Split(options, ",") {
+			if key, _, ok := strings.Cut(option, "="); !ok || !remoteNamePattern.MatchString(key) {
+				return &ValidationError{Field: fieldName, Message: fmt.Sprintf("invalid remote option '%s' (expected key=value)", option)}
+			}
+		}
+	}
+
 	// Validate remote name
 	if remoteName == "" {
 		return &ValidationError{Field: fieldName, Message: "remote name cannot be empty"}
@@ -245,6 +580,30 @@ func ValidateRemoteName(name string) error {
 	return nil
 }
 
+// ValidateBindAddress validates a bind address (IP or interface name) and IP
+// family. Interface names are not resolved, since a VPN interface may only
+// exist while connected.
+func ValidateBindAddress(address, family string) error {
+	switch family {
+	case models.IPFamilyAny, models.IPFamilyIPv4, models.IPFamilyIPv6:
+	default:
+		return &ValidationError{Field: "ip_family", Message: "must be ipv4 or ipv6"}
+	}
+	if address == "" {
+		return nil
+	}
+	if ip := net.ParseIP(address); ip != nil {
+		if (family == models.IPFamilyIPv4 && ip.To4() == nil) || (family == models.IPFamilyIPv6 && ip.To4() != nil) {
+			return &ValidationError{Field: "bind_address", Message: fmt.Sprintf("%s is not an %s address", address, family)}
+		}
+		return nil
+	}
+	if strings.ContainsAny(address, " /\\:") {
+		return &ValidationError{Field: "bind_address", Message: "must be an IP address or interface name"}
+	}
+	return nil
+}
+
 // ValidateSizeSuffix validates an rclone size suffix string (e.g. "100K", "10M", "1G", "off")
 func (v *ProfileValidator) ValidateSizeSuffix(value string, fieldName string) error {
 	if value == "" {
@@ -284,6 +643,28 @@ func (v *ProfileValidator) ValidateConflictResolution(value string) error {
 	return nil
 }
 
+// ValidateNotifyMode validates a per-profile notification override
+func (v *ProfileValidator) ValidateNotifyMode(value string) error {
+	if !validNotifyModes[value] {
+		return &ValidationError{
+			Field:   "notify_mode",
+			Message: "must be one of: off, failures, all",
+		}
+	}
+	return nil
+}
+
+// ValidateResumeInterrupted validates what happens to a run interrupted by the app exiting
+func (v *ProfileValidator) ValidateResumeInterrupted(value string) error {
+	if !validResumeModes[value] {
+		return &ValidationError{
+			Field:   "resume_interrupted",
+			Message: "must be one of: rerun, retry_failed (or empty to offer it)",
+		}
+	}
+	return nil
+}
+
 // ValidateMaxDelete validates the max delete limit
 func (v *ProfileValidator) ValidateMaxDelete(value *int) error {
 	if value == nil {
@@ -86,6 +86,34 @@ func TestValidateRclonePath_RemotePath(t *testing.T) {
 		{":path", true},           // Empty remote name
 		{"invalid@name:path", true}, // Invalid characters in remote name
 		{"name", true},             // No colon separator
+		{"gdrive,root_folder_id=0AbC_d-1:", false},     // Drive computer root
+		{"gdrive,root_folder_id:folder", true},          // Option without value
+		{"bad@name,root_folder_id=x:folder", true},      // Invalid remote name before the options
+	}
+
+	for _, tt := range tests {
+		err := v.ValidateRclonePath(tt.path, "test")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateRclonePath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateRclonePath_PathVariable(t *testing.T) {
+	v := NewProfileValidator()
+
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{"${PHOTOS_DIR}", false},
+		{"${PHOTOS_DIR}/2024", false},
+		{`${PHOTOS_DIR}\2024`, false},
+		{"${PHOTOS_DIR", true},       // Unclosed
+		{"${1PHOTOS}/2024", true},    // Name starts with a digit
+		{"${PHOTOS-DIR}/2024", true}, // Invalid characters in name
+		{"${PHOTOS_DIR}2024", true},  // No separator after the variable
+		{"${PHOTOS_DIR}/../x", true}, // Path traversal
 	}
 
 	for _, tt := range tests {
@@ -243,6 +271,322 @@ func TestValidateConflictResolution(t *testing.T) {
 	}
 }
 
+func TestValidateNotifyMode(t *testing.T) {
+	v := NewProfileValidator()
+
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"", false}, // Empty = global setting
+		{"off", false},
+		{"failures", false},
+		{"all", false},
+		{"loud", true},
+	}
+
+	for _, tt := range tests {
+		err := v.ValidateNotifyMode(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateNotifyMode(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateResumeInterrupted(t *testing.T) {
+	v := NewProfileValidator()
+
+	for _, value := range []string{"", "rerun", "retry_failed"} {
+		if err := v.ValidateResumeInterrupted(value); err != nil {
+			t.Errorf("ValidateResumeInterrupted(%q) = %v", value, err)
+		}
+	}
+	if err := v.ValidateResumeInterrupted("resume"); err == nil {
+		t.Error("expected an error for an unknown mode")
+	}
+}
+
+func TestValidateFanOut(t *testing.T) {
+	v := NewProfileValidator()
+
+	base := models.Profile{Name: "fan", From: "/home/user/docs", To: "gdrive:backup"}
+
+	tests := []struct {
+		name    string
+		mutate  func(p *models.Profile)
+		wantErr bool
+	}{
+		{"no fan-out", func(p *models.Profile) {}, false},
+		{"sequential", func(p *models.Profile) { p.FanOutTo = []string{"s3:backup", "/mnt/usb/backup"} }, false},
+		{"parallel", func(p *models.Profile) { p.FanOutTo = []string{"s3:backup"}; p.FanOutMode = "parallel" }, false},
+		{"unknown mode", func(p *models.Profile) { p.FanOutMode = "burst" }, true},
+		{"invalid path", func(p *models.Profile) { p.FanOutTo = []string{"bad remote:x"} }, true},
+		{"same as source", func(p *models.Profile) { p.FanOutTo = []string{"/home/user/docs"} }, true},
+		{"duplicate of to", func(p *models.Profile) { p.FanOutTo = []string{"gdrive:backup"} }, true},
+		{"duplicate entry", func(p *models.Profile) { p.FanOutTo = []string{"s3:backup", "s3:backup"} }, true},
+		{"with encryption", func(p *models.Profile) { p.FanOutTo = []string{"s3:backup"}; p.EncryptDest = true }, true},
+	}
+
+	for _, tt := range tests {
+		p := base
+		tt.mutate(&p)
+		err := v.ValidateFanOut(p)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ValidateFanOut() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateFailover(t *testing.T) {
+	v := NewProfileValidator()
+
+	tests := []struct {
+		name    string
+		mutate  func(p *models.Profile)
+		wantErr bool
+	}{
+		{"no failover", func(p *models.Profile) {}, false},
+		{"cloud fallback", func(p *models.Profile) { p.FailoverTo = "s3:backup" }, false},
+		{"invalid path", func(p *models.Profile) { p.FailoverTo = "bad remote:x" }, true},
+		{"same as destination", func(p *models.Profile) { p.FailoverTo = "nas:backup" }, true},
+		{"same as source", func(p *models.Profile) { p.FailoverTo = "/home/user/docs" }, true},
+		{"with fan-out", func(p *models.Profile) { p.FailoverTo = "s3:backup"; p.FanOutTo = []string{"gdrive:backup"} }, true},
+	}
+
+	for _, tt := range tests {
+		p := models.Profile{Name: "nas", From: "/home/user/docs", To: "nas:backup"}
+		tt.mutate(&p)
+		err := v.ValidateFailover(p)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ValidateFailover() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateSessions(t *testing.T) {
+	v := NewProfileValidator()
+
+	tests := []struct {
+		name    string
+		mutate  func(p *models.Profile)
+		wantErr bool
+	}{
+		{"no sessions", func(p *models.Profile) {}, false},
+		{"capped sessions", func(p *models.Profile) { p.SessionTransfer = "2G"; p.SessionOrder = "newest" }, false},
+		{"invalid cap", func(p *models.Profile) { p.SessionTransfer = "lots" }, true},
+		{"invalid order", func(p *models.Profile) { p.SessionTransfer = "2G"; p.SessionOrder = "largest" }, true},
+		{"with fan-out", func(p *models.Profile) { p.SessionTransfer = "2G"; p.FanOutTo = []string{"gdrive:backup"} }, true},
+	}
+
+	for _, tt := range tests {
+		p := models.Profile{Name: "nas", From: "/home/user/docs", To: "nas:backup"}
+		tt.mutate(&p)
+		err := v.ValidateSessions(p)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ValidateSessions() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateTransferOrder(t *testing.T) {
+	v := NewProfileValidator()
+
+	tests := []struct {
+		name    string
+		mutate  func(p *models.Profile)
+		wantErr bool
+	}{
+		{"no order", func(p *models.Profile) {}, false},
+		{"smallest first", func(p *models.Profile) { p.TransferOrder = "smallest" }, false},
+		{"largest first", func(p *models.Profile) { p.TransferOrder = "largest" }, false},
+		{"invalid order", func(p *models.Profile) { p.TransferOrder = "newest" }, true},
+		{"with sessions", func(p *models.Profile) { p.TransferOrder = "largest"; p.SessionTransfer = "2G" }, true},
+		{"with order_by", func(p *models.Profile) { p.TransferOrder = "largest"; p.OrderBy = "name" }, true},
+	}
+
+	for _, tt := range tests {
+		p := models.Profile{Name: "nas", From: "/home/user/docs", To: "nas:backup"}
+		tt.mutate(&p)
+		err := v.ValidateTransferOrder(p)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ValidateTransferOrder() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateLockedFiles(t *testing.T) {
+	v := NewProfileValidator()
+
+	tests := []struct {
+		name    string
+		mutate  func(p *models.Profile)
+		wantErr bool
+	}{
+		{"report only", func(p *models.Profile) {}, false},
+		{"retry", func(p *models.Profile) { p.LockedFiles = "retry" }, false},
+		{"snapshot", func(p *models.Profile) { p.LockedFiles = "snapshot" }, false},
+		{"invalid handling", func(p *models.Profile) { p.LockedFiles = "skip" }, true},
+		{"with fan-out", func(p *models.Profile) { p.LockedFiles = "retry"; p.FanOutTo = []string{"b2:backup"} }, true},
+	}
+
+	for _, tt := range tests {
+		p := models.Profile{Name: "nas", From: "/home/user/docs", To: "nas:backup"}
+		tt.mutate(&p)
+		err := v.ValidateLockedFiles(p)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ValidateLockedFiles() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateDeferDeletes(t *testing.T) {
+	v := NewProfileValidator()
+
+	tests := []struct {
+		name    string
+		mutate  func(p *models.Profile)
+		wantErr bool
+	}{
+		{"delete at once", func(p *models.Profile) {}, false},
+		{"three runs", func(p *models.Profile) { p.DeferDeletes = 3 }, false},
+		{"negative", func(p *models.Profile) { p.DeferDeletes = -1 }, true},
+		{"too many", func(p *models.Profile) { p.DeferDeletes = 1001 }, true},
+		{"with fan-out", func(p *models.Profile) { p.DeferDeletes = 3; p.FanOutTo = []string{"b2:backup"} }, true},
+		{"with delete excluded", func(p *models.Profile) { p.DeferDeletes = 3; p.DeleteExcluded = true }, true},
+	}
+
+	for _, tt := range tests {
+		p := models.Profile{Name: "nas", From: "/home/user/docs", To: "nas:backup"}
+		tt.mutate(&p)
+		err := v.ValidateDeferDeletes(p)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ValidateDeferDeletes() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateDetectConflicts(t *testing.T) {
+	v := NewProfileValidator()
+
+	tests := []struct {
+		name    string
+		mutate  func(p *models.Profile)
+		wantErr bool
+	}{
+		{"off", func(p *models.Profile) { p.DeleteExcluded = true }, false},
+		{"on", func(p *models.Profile) { p.DetectConflicts = true }, false},
+		{"with fan-out", func(p *models.Profile) { p.DetectConflicts = true; p.FanOutTo = []string{"b2:backup"} }, true},
+		{"with delete excluded", func(p *models.Profile) { p.DetectConflicts = true; p.DeleteExcluded = true }, true},
+		{"with compression", func(p *models.Profile) { p.DetectConflicts = true; p.CompressDest = true }, true},
+	}
+
+	for _, tt := range tests {
+		p := models.Profile{Name: "nas", From: "/home/user/docs", To: "nas:backup"}
+		tt.mutate(&p)
+		err := v.ValidateDetectConflicts(p)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ValidateDetectConflicts() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateCompression(t *testing.T) {
+	v := NewProfileValidator()
+
+	tests := []struct {
+		name    string
+		mutate  func(p *models.Profile)
+		wantErr bool
+	}{
+		{"no compression", func(p *models.Profile) {}, false},
+		{"default mode", func(p *models.Profile) { p.CompressDest = true }, false},
+		{"zstd", func(p *models.Profile) { p.CompressDest = true; p.CompressMode = "zstd" }, false},
+		{"invalid mode", func(p *models.Profile) { p.CompressDest = true; p.CompressMode = "brotli" }, true},
+		{"mode without compression", func(p *models.Profile) { p.CompressMode = "gzip" }, true},
+		{"with fan-out", func(p *models.Profile) { p.CompressDest = true; p.FanOutTo = []string{"b2:backup"} }, true},
+	}
+
+	for _, tt := range tests {
+		p := models.Profile{Name: "nas", From: "/home/user/docs", To: "nas:backup"}
+		tt.mutate(&p)
+		err := v.ValidateCompression(p)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ValidateCompression() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateConflictNaming(t *testing.T) {
+	v := NewProfileValidator()
+
+	tests := []struct {
+		name    string
+		loser   string
+		naming  *models.ConflictNaming
+		wantErr bool
+	}{
+		{"no naming", "", nil, false},
+		{"host and time", "num", &models.ConflictNaming{Hostname: true, Timestamp: true}, false},
+		{"folder", "pathname", &models.ConflictNaming{Folder: "_conflicts"}, false},
+		{"nested folder", "num", &models.ConflictNaming{Folder: "sync/_conflicts/"}, false},
+		{"losers deleted", "", &models.ConflictNaming{Hostname: true}, true},
+		{"absolute folder", "num", &models.ConflictNaming{Folder: "/tmp/conflicts"}, true},
+		{"folder outside", "num", &models.ConflictNaming{Folder: "../conflicts"}, true},
+		{"remote folder", "num", &models.ConflictNaming{Folder: "gdrive:conflicts"}, true},
+	}
+
+	for _, tt := range tests {
+		p := models.Profile{Name: "docs", From: "/home/user/docs", To: "gdrive:docs", ConflictLoser: tt.loser, ConflictNaming: tt.naming}
+		err := v.ValidateConflictNaming(p)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ValidateConflictNaming() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateFreshnessTarget(t *testing.T) {
+	v := NewProfileValidator()
+
+	for value, wantErr := range map[string]bool{"": false, "24h": false, "90m": false, "30s": true, "1d": true, "-1h": true} {
+		if err := v.ValidateFreshnessTarget(value); (err != nil) != wantErr {
+			t.Errorf("ValidateFreshnessTarget(%q) error = %v, wantErr %v", value, err, wantErr)
+		}
+	}
+}
+
+func TestValidateObjectOptions(t *testing.T) {
+	v := NewProfileValidator()
+
+	tests := []struct {
+		name    string
+		mutate  func(p *models.Profile)
+		wantErr bool
+	}{
+		{"none", func(p *models.Profile) {}, false},
+		{"archival", func(p *models.Profile) {
+			p.StorageClass = "GLACIER_IR"
+			p.UploadHeaders = []string{"Cache-Control: max-age=86400", "Content-Language: en"}
+			p.ServerSideEncryption = "aws:kms"
+			p.SSEKMSKeyId = "arn:aws:kms:us-east-1:123456789012:key/abc"
+		}, false},
+		{"bad storage class", func(p *models.Profile) { p.StorageClass = "COLD STORAGE" }, true},
+		{"header without value separator", func(p *models.Profile) { p.UploadHeaders = []string{"Cache-Control"} }, true},
+		{"header with bad name", func(p *models.Profile) { p.UploadHeaders = []string{"Cache Control: no-cache"} }, true},
+		{"header with newline", func(p *models.Profile) { p.UploadHeaders = []string{"X-A: b\r\nX-B: c"} }, true},
+		{"unknown encryption", func(p *models.Profile) { p.ServerSideEncryption = "rot13" }, true},
+		{"kms key without kms", func(p *models.Profile) { p.ServerSideEncryption = "AES256"; p.SSEKMSKeyId = "key" }, true},
+	}
+
+	for _, tt := range tests {
+		p := models.Profile{Name: "archive", From: "/data", To: "s3:archive"}
+		tt.mutate(&p)
+		err := v.ValidateObjectOptions(p)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ValidateObjectOptions() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
 func TestValidateMaxDelete(t *testing.T) {
 	v := NewProfileValidator()
 
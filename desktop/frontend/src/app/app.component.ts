@@ -47,6 +47,7 @@ export class AppComponent implements OnInit, OnDestroy {
   showAboutDialog = false;
   isLocked = true;
   isLoading = true;
+  recoveryKeyStep = false;
 
   ngOnInit() {
     if (this.isInitialized) {
@@ -66,6 +67,13 @@ export class AppComponent implements OnInit, OnDestroy {
       })
     );
 
+    this.subscriptions.add(
+      this.authService.recoveryKeyStep$.subscribe(step => {
+        this.recoveryKeyStep = step;
+        this.cdr.markForCheck();
+      })
+    );
+
     this.subscriptions.add(
       this.authService.loading$.subscribe(loading => {
         this.isLoading = loading;
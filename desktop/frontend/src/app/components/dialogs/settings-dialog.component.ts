@@ -56,17 +56,18 @@ interface ImportPreview {
 }
 import {
   GetSettings,
-  SetEnabled,
   SetMinimizeToTray,
   SetMinimizeToTrayOnStartup,
+  SetNotificationsEnabled,
   SetStartAtLogin,
-} from '../../../../wailsjs/desktop/backend/services/notificationservice';
+} from '../../../../wailsjs/desktop/backend/services/settingsservice';
 import { AuthService } from '../../services/auth.service';
 import { NeoButtonComponent } from '../neo/neo-button.component';
 import { NeoCardComponent } from '../neo/neo-card.component';
 import { NeoDialogComponent } from '../neo/neo-dialog.component';
 import { NeoInputComponent } from '../neo/neo-input.component';
 import { NeoToggleComponent } from '../neo/neo-toggle.component';
+import { RecoveryKeyPanelComponent } from '../recovery-key/recovery-key-panel.component';
 
 @Component({
   selector: 'app-settings-dialog',
@@ -80,6 +81,7 @@ import { NeoToggleComponent } from '../neo/neo-toggle.component';
     NeoCardComponent,
     NeoInputComponent,
     NeoToggleComponent,
+    RecoveryKeyPanelComponent,
   ],
   changeDetection: ChangeDetectionStrategy.OnPush,
   template: `
@@ -109,6 +111,9 @@ import { NeoToggleComponent } from '../neo/neo-toggle.component';
                   <neo-button variant="secondary" size="sm" (onClick)="showChangePasswordDialog = true">
                     Change
                   </neo-button>
+                  <neo-button variant="secondary" size="sm" (onClick)="showNewRecoveryKeyDialog = true">
+                    Recovery Key
+                  </neo-button>
                   <neo-button variant="danger" size="sm" (onClick)="showRemovePasswordDialog = true">
                     Remove
                   </neo-button>
@@ -330,6 +335,53 @@ import { NeoToggleComponent } from '../neo/neo-toggle.component';
       </form>
     </neo-dialog>
 
+    <!-- New Recovery Key Sub-Dialog -->
+    <neo-dialog
+      [(visible)]="showNewRecoveryKeyDialog"
+      title="New Recovery Key"
+      maxWidth="400px"
+    >
+      <form (ngSubmit)="doNewRecoveryKey()" class="space-y-4">
+        @if (securityError) {
+          <div class="p-3 bg-sys-accent-danger/20 border-2 border-sys-border text-sm">
+            {{ securityError }}
+          </div>
+        }
+        <p class="text-sm text-sys-fg-muted">
+          Create a new recovery key, e.g. if yours was lost. Your previous recovery key stops working.
+          Enter your current password to confirm.
+        </p>
+        <neo-input
+          label="Current Password"
+          type="password"
+          placeholder="Enter current password"
+          [(ngModel)]="currentPassword"
+          name="currentPassword"
+        ></neo-input>
+        <div class="flex justify-end gap-2 pt-2">
+          <neo-button variant="secondary" (onClick)="closeSecurityDialogs()">Cancel</neo-button>
+          <neo-button type="submit" [loading]="isSecurityLoading" [disabled]="!currentPassword">
+            Create Recovery Key
+          </neo-button>
+        </div>
+      </form>
+    </neo-dialog>
+
+    <!-- Recovery Key Sub-Dialog: shown once after a recovery key is created -->
+    <neo-dialog
+      [visible]="!!recoveryKey"
+      title="Save Your Recovery Key"
+      maxWidth="400px"
+      [showClose]="false"
+      [closeOnEscape]="false"
+      [closeOnBackdrop]="false"
+    >
+      <app-recovery-key-panel
+        [recoveryKey]="recoveryKey"
+        (confirmed)="recoveryKey = ''"
+      ></app-recovery-key-panel>
+    </neo-dialog>
+
     <!-- Export Sub-Dialog -->
     <neo-dialog
       [(visible)]="showExportDialog"
@@ -540,6 +592,8 @@ export class SettingsDialogComponent implements OnInit {
   showSetPasswordDialog = false;
   showChangePasswordDialog = false;
   showRemovePasswordDialog = false;
+  showNewRecoveryKeyDialog = false;
+  recoveryKey = '';
   isSecurityLoading = false;
   securityError = '';
   currentPassword = '';
@@ -605,6 +659,7 @@ export class SettingsDialogComponent implements OnInit {
     this.showSetPasswordDialog = false;
     this.showChangePasswordDialog = false;
     this.showRemovePasswordDialog = false;
+    this.showNewRecoveryKeyDialog = false;
     this.resetSecurityFields();
     this.cdr.markForCheck();
   }
@@ -638,7 +693,7 @@ export class SettingsDialogComponent implements OnInit {
     this.cdr.markForCheck();
 
     try {
-      await this.authService.setupPassword(this.newPassword);
+      const recoveryKey = await this.authService.setupPassword(this.newPassword);
       this.authEnabled = true;
       this.messageService.add({
         severity: 'success',
@@ -646,6 +701,24 @@ export class SettingsDialogComponent implements OnInit {
         detail: 'Your data is now encrypted',
       });
       this.closeSecurityDialogs();
+      this.recoveryKey = recoveryKey;
+    } catch (err) {
+      this.securityError = this.extractErrorMessage(err);
+    } finally {
+      this.isSecurityLoading = false;
+      this.cdr.markForCheck();
+    }
+  }
+
+  async doNewRecoveryKey(): Promise<void> {
+    this.securityError = '';
+    this.isSecurityLoading = true;
+    this.cdr.markForCheck();
+
+    try {
+      const recoveryKey = await this.authService.regenerateRecoveryKey(this.currentPassword);
+      this.closeSecurityDialogs();
+      this.recoveryKey = recoveryKey;
     } catch (err) {
       this.securityError = this.extractErrorMessage(err);
     } finally {
@@ -739,7 +812,7 @@ export class SettingsDialogComponent implements OnInit {
 
   async saveNotificationSetting(): Promise<void> {
     try {
-      await SetEnabled(this.notificationsEnabled);
+      await SetNotificationsEnabled(this.notificationsEnabled);
     } catch (err) {
       console.error('Failed to save setting:', err);
     }
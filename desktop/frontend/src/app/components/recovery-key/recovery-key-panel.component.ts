@@ -0,0 +1,70 @@
+import {
+  ChangeDetectionStrategy,
+  ChangeDetectorRef,
+  Component,
+  EventEmitter,
+  Input,
+  Output,
+  inject,
+} from '@angular/core';
+import { CommonModule } from '@angular/common';
+import { FormsModule } from '@angular/forms';
+import { NeoButtonComponent } from '../neo/neo-button.component';
+import { NeoToggleComponent } from '../neo/neo-toggle.component';
+
+/**
+ * Shows a one-time recovery key and waits for the user to confirm they saved it.
+ * The key is only returned by the backend when it is created, so it can't be shown again.
+ */
+@Component({
+  selector: 'app-recovery-key-panel',
+  standalone: true,
+  imports: [CommonModule, FormsModule, NeoButtonComponent, NeoToggleComponent],
+  changeDetection: ChangeDetectionStrategy.OnPush,
+  template: `
+    <div class="space-y-4">
+      <p class="text-sm text-sys-fg">
+        Save this recovery key somewhere safe, away from this computer. It unlocks GN Drive if you forget
+        your password, and it won't be shown again.
+      </p>
+
+      <div class="p-3 bg-sys-bg-secondary border-2 border-sys-border font-mono text-sm break-all select-all">
+        {{ recoveryKey }}
+      </div>
+
+      <neo-button variant="secondary" size="sm" (onClick)="copy()">
+        <i class="pi mr-1" [class.pi-copy]="!copied" [class.pi-check]="copied"></i>
+        {{ copied ? 'Copied' : 'Copy' }}
+      </neo-button>
+
+      <neo-toggle
+        label="I have saved my recovery key"
+        [(ngModel)]="saved"
+        name="recoveryKeySaved"
+      ></neo-toggle>
+
+      <neo-button [fullWidth]="true" [disabled]="!saved" (onClick)="confirmed.emit()">
+        Continue
+      </neo-button>
+    </div>
+  `,
+})
+export class RecoveryKeyPanelComponent {
+  @Input({ required: true }) recoveryKey = '';
+  @Output() confirmed = new EventEmitter<void>();
+
+  private readonly cdr = inject(ChangeDetectorRef);
+
+  saved = false;
+  copied = false;
+
+  async copy(): Promise<void> {
+    try {
+      await navigator.clipboard.writeText(this.recoveryKey);
+      this.copied = true;
+    } catch {
+      console.error('Failed to copy recovery key to clipboard');
+    }
+    this.cdr.markForCheck();
+  }
+}
@@ -11,12 +11,13 @@ import { CommonModule } from '@angular/common';
 import { FormsModule } from '@angular/forms';
 import { NeoButtonComponent } from '../neo/neo-button.component';
 import { NeoInputComponent } from '../neo/neo-input.component';
+import { RecoveryKeyPanelComponent } from '../recovery-key/recovery-key-panel.component';
 import { AuthService, type LockoutStatus } from '../../services/auth.service';
 
 @Component({
   selector: 'app-unlock-screen',
   standalone: true,
-  imports: [CommonModule, FormsModule, NeoButtonComponent, NeoInputComponent],
+  imports: [CommonModule, FormsModule, NeoButtonComponent, NeoInputComponent, RecoveryKeyPanelComponent],
   changeDetection: ChangeDetectionStrategy.OnPush,
   template: `
     <div class="flex items-center justify-center h-screen bg-sys-bg-secondary" style="--wails-draggable: drag">
@@ -25,7 +26,11 @@ import { AuthService, type LockoutStatus } from '../../services/auth.service';
         <div class="text-center mb-6">
           <h1 class="text-2xl font-bold text-sys-fg mb-1">GN Drive</h1>
           <p class="text-sm text-sys-fg-muted">
-            @if (isSetupMode) {
+            @if (recoveryKey) {
+              Your recovery key
+            } @else if (offerRecoveryKey) {
+              Set up password recovery
+            } @else if (isSetupMode) {
               Set a master password to protect your data
             } @else {
               Enter your password to unlock
@@ -48,8 +53,27 @@ import { AuthService, type LockoutStatus } from '../../services/auth.service';
           </div>
         }
 
-        <!-- Unlock Form -->
-        @if (!isSetupMode) {
+        <!-- Recovery Key: shown once after it is created -->
+        @if (recoveryKey) {
+          <app-recovery-key-panel
+            [recoveryKey]="recoveryKey"
+            (confirmed)="onRecoveryKeySaved()"
+          ></app-recovery-key-panel>
+        } @else if (offerRecoveryKey) {
+          <!-- Password set up before recovery keys existed -->
+          <div class="space-y-4">
+            <p class="text-sm text-sys-fg">
+              Your password has no recovery key yet. Without one, a forgotten password means your encrypted
+              data can't be unlocked.
+            </p>
+            <neo-button [fullWidth]="true" [loading]="isLoading" (onClick)="onCreateRecoveryKey()">
+              Create Recovery Key
+            </neo-button>
+            <neo-button variant="ghost" [fullWidth]="true" [disabled]="isLoading" (onClick)="onSkipRecoveryKey()">
+              Not now
+            </neo-button>
+          </div>
+        } @else if (!isSetupMode) {
           <form (ngSubmit)="onUnlock()" class="space-y-4">
             <neo-input
               label="Password"
@@ -78,7 +102,7 @@ import { AuthService, type LockoutStatus } from '../../services/auth.service';
         }
 
         <!-- Setup Form -->
-        @if (isSetupMode) {
+        @if (isSetupMode && !recoveryKey) {
           <form (ngSubmit)="onSetup()" class="space-y-4">
             <neo-input
               label="Password"
@@ -137,6 +161,11 @@ export class UnlockScreenComponent implements OnInit {
   confirmError = '';
   isLoading = false;
   lockoutStatus: LockoutStatus | null = null;
+  recoveryKey = '';
+  offerRecoveryKey = false;
+
+  // Kept after unlocking only to create a recovery key when there is none
+  private unlockedPassword = '';
 
   private lockoutTimer: ReturnType<typeof setInterval> | null = null;
 
@@ -158,13 +187,22 @@ export class UnlockScreenComponent implements OnInit {
     this.errorMessage = '';
     this.cdr.markForCheck();
 
+    // Stay up after unlocking, in case there is a recovery key to set up
+    this.authService.recoveryKeyStep$.next(true);
     try {
       await this.authService.unlock(this.password);
-      this.password = '';
-      this.unlocked.emit();
+      if (await this.authService.hasRecoveryKey()) {
+        this.password = '';
+        this.finish();
+      } else {
+        this.unlockedPassword = this.password;
+        this.password = '';
+        this.offerRecoveryKey = true;
+      }
     } catch (err) {
       this.errorMessage = this.extractErrorMessage(err);
       this.password = '';
+      this.authService.recoveryKeyStep$.next(false);
       await this.refreshLockoutStatus();
     } finally {
       this.isLoading = false;
@@ -194,11 +232,31 @@ export class UnlockScreenComponent implements OnInit {
     this.isLoading = true;
     this.cdr.markForCheck();
 
+    // Stay up after unlocking until the user confirms they saved the recovery key
+    this.authService.recoveryKeyStep$.next(true);
     try {
-      await this.authService.setupPassword(this.password);
+      this.recoveryKey = await this.authService.setupPassword(this.password);
       this.password = '';
       this.confirmPassword = '';
-      this.unlocked.emit();
+    } catch (err) {
+      this.errorMessage = this.extractErrorMessage(err);
+      this.authService.recoveryKeyStep$.next(false);
+    } finally {
+      this.isLoading = false;
+      this.cdr.markForCheck();
+    }
+  }
+
+  async onCreateRecoveryKey(): Promise<void> {
+    if (this.isLoading) return;
+    this.isLoading = true;
+    this.errorMessage = '';
+    this.cdr.markForCheck();
+
+    try {
+      this.recoveryKey = await this.authService.regenerateRecoveryKey(this.unlockedPassword);
+      this.unlockedPassword = '';
+      this.offerRecoveryKey = false;
     } catch (err) {
       this.errorMessage = this.extractErrorMessage(err);
     } finally {
@@ -207,6 +265,22 @@ export class UnlockScreenComponent implements OnInit {
     }
   }
 
+  onSkipRecoveryKey(): void {
+    this.unlockedPassword = '';
+    this.offerRecoveryKey = false;
+    this.finish();
+  }
+
+  onRecoveryKeySaved(): void {
+    this.recoveryKey = '';
+    this.finish();
+  }
+
+  private finish(): void {
+    this.authService.recoveryKeyStep$.next(false);
+    this.unlocked.emit();
+  }
+
   onSkipSetup(): void {
     this.skipped.emit();
   }
@@ -8,7 +8,8 @@ export type RemoteType =
     | "onedrive"
     | "yandex"
     | "gphotos"
-    | "iclouddrive";
+    | "iclouddrive"
+    | "simulated";
 
 export interface RemoteFormData {
     name: string;
@@ -40,6 +41,7 @@ export const REMOTE_TYPE_OPTIONS: RemoteTypeOption[] = [
     { value: "yandex", label: "Yandex Disk", icon: "yandex" },
     { value: "gphotos", label: "Google Photos", icon: "googlephotos" },
     { value: "iclouddrive", label: "iCloud Drive", icon: "icloud" },
+    { value: "simulated", label: "Simulated (demo)", icon: "cloud" },
 ];
 
 // Type guards
@@ -51,6 +53,7 @@ export function isValidRemoteType(type: string): type is RemoteType {
         "yandex",
         "gphotos",
         "iclouddrive",
+        "simulated",
     ].includes(type);
 }
 
@@ -10,6 +10,8 @@ import {
   ChangePassword,
   RemovePassword,
   GetLockoutStatus,
+  HasRecoveryKey,
+  RegenerateRecoveryKey,
 } from '../../../wailsjs/desktop/backend/services/authservice';
 
 export interface LockoutStatus {
@@ -24,6 +26,8 @@ export class AuthService {
   readonly isLocked$ = new BehaviorSubject<boolean>(true);
   readonly authEnabled$ = new BehaviorSubject<boolean>(false);
   readonly loading$ = new BehaviorSubject<boolean>(true);
+  /** The unlock screen stays up after unlocking while it shows a recovery key step */
+  readonly recoveryKeyStep$ = new BehaviorSubject<boolean>(false);
 
   private eventCleanups: (() => void)[] = [];
 
@@ -70,11 +74,22 @@ export class AuthService {
     this.authEnabled$.next(true);
   }
 
-  async setupPassword(password: string): Promise<void> {
-    await SetupPassword(password);
+  /** Sets up the password and returns the one-time recovery key, which must be shown to the user */
+  async setupPassword(password: string): Promise<string> {
+    const recoveryKey = await SetupPassword(password);
     // Update state directly after successful setup (don't rely on events)
     this.isLocked$.next(false);
     this.authEnabled$.next(true);
+    return recoveryKey;
+  }
+
+  async hasRecoveryKey(): Promise<boolean> {
+    return await HasRecoveryKey();
+  }
+
+  /** Replaces the recovery key and returns the new one, which must be shown to the user */
+  async regenerateRecoveryKey(password: string): Promise<string> {
+    return await RegenerateRecoveryKey(password);
   }
 
   async lock(): Promise<void> {
@@ -31,10 +31,10 @@ import type {
 } from "../../../wailsjs/desktop/backend/services/models";
 import {
     GetSettings,
-    SetEnabled,
     SetMinimizeToTray,
+    SetNotificationsEnabled,
     SetStartAtLogin,
-} from "../../../wailsjs/desktop/backend/services/notificationservice";
+} from "../../../wailsjs/desktop/backend/services/settingsservice";
 
 @Component({
     selector: "app-settings",
@@ -592,7 +592,7 @@ export class SettingsComponent implements OnInit {
 
     async saveNotificationSetting() {
         try {
-            await SetEnabled(this.notificationsEnabled);
+            await SetNotificationsEnabled(this.notificationsEnabled);
         } catch (e) {
             console.error("Failed to save notification setting:", e);
         }
@@ -7,7 +7,7 @@ import (
 	"embed"
 	"log"
 	"os"
-	"path/filepath"
+	"strings"
 
 	"github.com/wailsapp/wails/v3/pkg/application"
 	"github.com/wailsapp/wails/v3/pkg/events"
@@ -26,6 +26,10 @@ var assets embed.FS
 var appIcon []byte
 
 func main() {
+	// Resolve the config directory first: services and log files use it
+	configDir := utils.SetConfigDir(flagValue(utils.ConfigDirFlag))
+	log.Printf("[main] Config directory: %s", configDir)
+
 	// Create service instances
 	appService := be.NewApp()
 	appService.SetVersionInfo(Version, Commit)
@@ -39,38 +43,72 @@ func main() {
 	historyService := services.NewHistoryService(nil)
 	schedulerService := services.NewSchedulerService(nil)
 	notificationService := services.NewNotificationService(nil)
+	settingsService := services.NewSettingsService(nil)
 	cryptService := services.NewCryptService(nil)
 	boardService := services.NewBoardService(nil)
 	exportService := services.NewExportService(nil)
 	importService := services.NewImportService(nil)
 	flowService := services.NewFlowService(nil)
+	integrationService := services.NewIntegrationService(nil)
+	secretService := services.NewSecretService(nil)
+	trashService := services.NewTrashService(nil)
+	mountService := services.NewMountService(nil)
+	lifecycleService := services.NewLifecycleService(nil)
+	shutdownService := services.NewShutdownService(nil)
+	debugService := services.NewDebugService(nil)
 	trayService := services.NewTrayService(appIcon)
 
+	boundServices := []application.Service{
+		application.NewService(appService),
+		application.NewService(logService),
+		application.NewService(authService),
+		application.NewService(syncService),
+		application.NewService(configService),
+		application.NewService(remoteService),
+		application.NewService(tabService),
+		application.NewService(operationService),
+		application.NewService(historyService),
+		application.NewService(schedulerService),
+		application.NewService(notificationService),
+		application.NewService(settingsService),
+		application.NewService(cryptService),
+		application.NewService(boardService),
+		application.NewService(exportService),
+		application.NewService(importService),
+		application.NewService(flowService),
+		application.NewService(integrationService),
+		application.NewService(secretService),
+		application.NewService(trashService),
+		application.NewService(mountService),
+		application.NewService(lifecycleService),
+		application.NewService(debugService),
+		// Registered last so it shuts down first and stops the others in a safe order
+		application.NewService(shutdownService),
+	}
+	// Measure the frontend's calls to them for the debug panel
+	debugService.TrackServices(boundServices)
+
 	// Create application with all services registered
 	app := application.New(application.Options{
 		Name:        "gn-drive",
 		Description: "A desktop application for rclone file synchronization",
 		Assets: application.AssetOptions{
-			Handler: application.AssetFileServerFS(assets),
+			Handler:    application.AssetFileServerFS(assets),
+			Middleware: services.ServiceMetricsMiddleware(debugService),
 		},
-		Services: []application.Service{
-			application.NewService(appService),
-			application.NewService(logService),
-			application.NewService(authService),
-			application.NewService(syncService),
-			application.NewService(configService),
-			application.NewService(remoteService),
-			application.NewService(tabService),
-			application.NewService(operationService),
-			application.NewService(historyService),
-			application.NewService(schedulerService),
-			application.NewService(notificationService),
-			application.NewService(cryptService),
-			application.NewService(boardService),
-			application.NewService(exportService),
-			application.NewService(importService),
-			application.NewService(flowService),
+		// A second launch hands its arguments to this instance and exits,
+		// instead of opening the same database and rclone.conf again
+		SingleInstance: &application.SingleInstanceOptions{
+			UniqueID: utils.InstanceID(),
+			OnSecondInstanceLaunch: func(data application.SecondInstanceData) {
+				var args []string
+				if len(data.Args) > 1 {
+					args = data.Args[1:]
+				}
+				integrationService.HandleSecondInstance(args, data.WorkingDir)
+			},
 		},
+		Services: boundServices,
 	})
 
 	// Store the application reference in all services for events
@@ -85,15 +123,26 @@ func main() {
 	historyService.SetApp(app)
 	schedulerService.SetApp(app)
 	notificationService.SetApp(app)
+	settingsService.SetApp(app)
 	cryptService.SetApp(app)
 	boardService.SetApp(app)
 	exportService.SetApp(app)
 	importService.SetApp(app)
 	flowService.SetApp(app)
+	integrationService.SetApp(app)
+	secretService.SetApp(app)
+	trashService.SetApp(app)
+	mountService.SetApp(app)
+	lifecycleService.SetApp(app)
+	shutdownService.SetApp(app)
+	debugService.SetApp(app)
 
 	// Wire AuthService dependencies
 	authService.SetAppService(appService)
-	authService.SetNotificationService(notificationService)
+	authService.SetSettingsService(settingsService)
+	authService.SetSchedulerService(schedulerService)
+	authService.SetSyncService(syncService)
+	authService.SetMountService(mountService)
 
 	// Load env config and wire to SyncService
 	envConfig := utils.LoadEnvConfigFromEnvStr(be.GetEmbeddedEnvConfigStr())
@@ -106,20 +155,55 @@ func main() {
 
 	// Wire up service dependencies
 	schedulerService.SetSyncService(syncService)
+	schedulerService.SetOperationService(operationService)
+	schedulerService.SetConfigService(configService)
+	schedulerService.SetNotificationService(notificationService)
 	boardService.SetSyncService(syncService)
+	flowService.SetSyncService(syncService)
 	boardService.SetNotificationService(notificationService)
+	exportService.SetHistoryService(historyService)
+	importService.SetHistoryService(historyService)
+	importService.SetConfigService(configService)
+	importService.SetSchedulerService(schedulerService)
+	historyService.SetSyncService(syncService)
+	exportService.SetSchedulerService(schedulerService)
+	exportService.SetConfigService(configService)
 	syncService.SetLogService(logService)
+	syncService.SetHistoryService(historyService)
 	syncService.SetNotificationService(notificationService)
+	syncService.SetSettingsService(settingsService)
+	settingsService.SetSyncService(syncService)
+	schedulerService.SetSettingsService(settingsService)
+	notificationService.SetSettingsService(settingsService)
+	notificationService.SetSyncService(syncService)
+	notificationService.SetSchedulerService(schedulerService)
+	integrationService.SetConfigService(configService)
+	trashService.SetConfigService(configService)
+	trashService.SetSchedulerService(schedulerService)
+	integrationService.SetSyncService(syncService)
+	lifecycleService.SetOperationService(operationService)
+	operationService.SetSyncService(syncService)
+	syncService.SetOperationService(operationService)
+	configService.SetSchedulerService(schedulerService)
+	configService.SetHistoryService(historyService)
+	shutdownService.SetSchedulerService(schedulerService)
+	shutdownService.SetSyncService(syncService)
+	shutdownService.SetAuthService(authService)
+	shutdownService.SetSettingsService(settingsService)
+	shutdownService.SetMountService(mountService)
 
 	// Set singleton instances for cross-service access
 	services.SetBoardServiceInstance(boardService)
 	services.SetFlowServiceInstance(flowService)
+	services.SetLifecycleServiceInstance(lifecycleService)
 	services.SetTrayServiceInstance(trayService)
 
 	// Wire up tray service dependencies
 	trayService.SetApp(app)
 	trayService.SetBoardService(boardService)
 	trayService.SetFlowService(flowService)
+	trayService.SetAuthService(authService)
+	trayService.SetSettingsService(settingsService)
 
 	// Compute shared config once to avoid duplicate file I/O across services
 	homeDir, err := os.UserHomeDir()
@@ -127,7 +211,6 @@ func main() {
 		log.Printf("Warning: Could not get user home directory: %v", err)
 		homeDir = "."
 	}
-	configDir := filepath.Join(homeDir, ".config", "gn-drive")
 	wd, _ := os.Getwd()
 	services.SetSharedConfig(&services.SharedConfig{
 		HomeDir:    homeDir,
@@ -143,51 +226,105 @@ func main() {
 	// Read pre-unlock settings from auth.json for tray/startup behavior
 	preSettings := authService.GetPreUnlockSettings()
 
-	// Create the main window
-	window := app.Window.NewWithOptions(application.WebviewWindowOptions{
-		Title:  "gn-drive",
-		Width:  800,
-		Height: 800,
-	})
+	// Tray-only mode: start with just the tray icon and create the main
+	// window the first time the tray asks for it. Starting locked and hidden
+	// implies it: the unlock prompt appears when the tray opens the window.
+	trayOnly := hasFlag("--tray-only") || preSettings.TrayOnly || preSettings.StartLockedHidden
 
-	// Set window reference on shared EventBus for window-specific events
-	services.SetSharedEventBusWindow(window)
-
-	// Set EventBus on LogService after window is ready
-	logService.SetEventBus(services.GetSharedEventBus())
-
-	// Set the window URL to load the frontend
-	window.SetURL("/")
-
-	// Handle window close - minimize to tray or quit based on setting
-	window.RegisterHook(events.Common.WindowClosing, func(event *application.WindowEvent) {
-		if notificationService.IsMinimizeToTray(nil) {
-			// Cancel the close event and hide window instead
-			event.Cancel()
-			window.Hide()
-			be.HideFromDock()
-		} else {
-			// Quit the entire application (including backend)
-			app.Quit()
-		}
-	})
+	// createWindow creates the main window and wires it to the event bus
+	createWindow := func() application.Window {
+		window := app.Window.NewWithOptions(application.WebviewWindowOptions{
+			Title:  "gn-drive",
+			Width:  800,
+			Height: 800,
+		})
+
+		// Set window reference on shared EventBus for window-specific events
+		services.SetSharedEventBusWindow(window)
+
+		// Set EventBus on LogService after window is ready
+		logService.SetEventBus(services.GetSharedEventBus())
+
+		// Set the window URL to load the frontend
+		window.SetURL("/")
+
+		// Handle window close - minimize to tray or quit based on setting
+		window.RegisterHook(events.Common.WindowClosing, func(event *application.WindowEvent) {
+			if trayOnly || settingsService.IsMinimizeToTray(nil) {
+				// Cancel the close event and hide window instead
+				event.Cancel()
+				window.Hide()
+				be.HideFromDock()
+			} else {
+				// Quit the entire application (including backend)
+				app.Quit()
+			}
+		})
+		return window
+	}
+
+	var window application.Window
+	if !trayOnly {
+		window = createWindow()
+	}
 
 	// Initialize system tray
 	trayService.SetWindow(window)
+	trayService.SetWindowFactory(createWindow)
 	trayService.SetOnShowCallback(be.ShowInDock)
 	if err := trayService.Initialize(); err != nil {
 		log.Printf("Warning: Failed to initialize system tray: %v", err)
 	}
 
-	// Minimize to tray on startup if setting is enabled (from auth.json or defaults)
-	if preSettings.MinimizeToTrayOnStartup {
+	if trayOnly {
+		log.Println("[main] Tray-only mode: main window will be created on demand")
+		be.HideFromDock()
+	} else if preSettings.MinimizeToTrayOnStartup {
+		// Minimize to tray on startup if setting is enabled (from auth.json or defaults)
 		window.Hide()
 		be.HideFromDock()
 	}
 
+	// Handle "Sync this folder" requests from the OS shell and boards or flows
+	// to run: arguments passed at launch (or by a second launch, see
+	// SingleInstance above), and gn-drive:// URLs opened while running
+	app.Event.OnApplicationEvent(events.Common.ApplicationStarted, func(event *application.ApplicationEvent) {
+		integrationService.HandleArgs(os.Args[1:])
+	})
+	app.Event.OnApplicationEvent(events.Common.ApplicationLaunchedWithUrl, func(event *application.ApplicationEvent) {
+		if err := integrationService.HandleURL(event.Context().URL()); err != nil {
+			log.Printf("Warning: Failed to handle URL: %v", err)
+		}
+	})
+
 	// Run the application
 	err = app.Run()
 	if err != nil {
 		log.Fatal("Error:", err.Error())
 	}
 }
+
+// flagValue returns the value of a command-line flag passed as "name value"
+// or "name=value", or "" if it wasn't passed
+func flagValue(name string) string {
+	args := os.Args[1:]
+	for i, arg := range args {
+		if arg == name && i+1 < len(args) {
+			return args[i+1]
+		}
+		if value, ok := strings.CutPrefix(arg, name+"="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// hasFlag reports whether a boolean command-line flag was passed
+func hasFlag(name string) bool {
+	for _, arg := range os.Args[1:] {
+		if arg == name {
+			return true
+		}
+	}
+	return false
+}